@@ -8,17 +8,22 @@
 // - 查询可执行任务列表
 // - 启动、暂停与停止并发任务
 // - 查询正在执行的任务
-// - 身份验证与授权（待实现）
+// - 身份验证与授权（见 middleware.go）
 // - API 文档生成（待实现）
 //
 // 技术实现细节：
-// 1. 使用 net/http 包实现 HTTP API 接口。
-// 2. 提供 SubmitTask 方法，接收任务参数并将任务提交到协程池。
+// 1. 使用 net/http 包实现 HTTP API 接口，APIServer 持有真实的协程池与结果收集器实例。
+// 2. 提供 SubmitTask 方法，按任务名称将已注册的任务提交到协程池，支持幂等键去重重复提交。
 // 3. 提供 SetMaxConcurrency 和 SetRateLimit 方法，允许用户设置参数。
 // 4. 提供 GetTaskStatus 方法，查询特定任务的执行状态。
 // 5. 提供 GetAvailableTasks 方法，返回当前可执行的任务列表。
 // 6. 提供 StartPool、PausePool 和 StopPool 方法，控制协程池的生命周期。
 // 7. 提供 GetRunningTasks 方法，返回当前正在执行的任务列表。
+// 7.1 提供 GetStats 方法，返回协程池计数与结果收集器的实时统计。
+// 7.2 提供 StartVUs 方法，按协调者下发的统一开始时间运行虚拟用户（见 distributed.go）。
+// 7.3 提供 LiveStream 方法，通过 Server-Sent Events 推送实时统计和重要日志（见 live.go）。
+// 7.4 提供内置 Web 看板，展示实时进度和历史运行的报告（见 dashboard.go）。
+// 7.5 提供 GetTrendScenarios 和 GetTrend 方法，查询历史运行的性能趋势和回归检测结果（见 trend.go）。
 // 8. 实现身份验证和授权机制，确保 API 的安全性。
 // 9. 生成详细的 API 文档，提供使用示例和接口说明。
 //
@@ -46,54 +51,217 @@
 
 package api
 
-// TODO: 实现任务提交和管理的 API
-
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"sync"
+	"time"
 
-	"OpenStress/pool" // 假设这是协程池的实现
-	// "OpenStress/pool/error" // 引入错误处理模块
+	"github.com/potatoImp/OpenStress/auth"
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
 )
 
-var (
+// APIServer 控制 API 服务，封装协程池与结果收集器
+type APIServer struct {
+	pool      pool.TaskPool
+	collector result.ResultCollector
+	config    *config.Config
+	mux       *http.ServeMux
+	server    *http.Server
+	listener  net.Listener
+	logger    *pool.StressLogger
+
+	mu             sync.Mutex
+	authenticator  Authenticator
 	maxConcurrency int
 	rateLimit      int
-	taskPool       *pool.Pool // 假设这是协程池的实例
-	mu             sync.Mutex
-)
+	vuRuns         map[*pool.VURun]string // 正在运行的 VU 及其任务名
+	reportDir      string                 // 看板扫描的报告根目录，为空时使用结果收集器的目录
+	resultDir      string                 // 看板扫描的 JTL 结果目录，为空时使用结果收集器的目录
+
+	closing     context.Context    // Shutdown 时取消，结束实时推送等长连接
+	stopStreams context.CancelFunc // 取消 closing
+}
+
+// IdempotencyKeyHeader 携带幂等键的请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
 
 // TaskRequest 表示提交任务的请求结构
 type TaskRequest struct {
 	TaskName string                 `json:"task_name"`
 	Params   map[string]interface{} `json:"params"`
 	TaskID   string                 `json:"task_id"` // 新增字段
+	Priority int                    `json:"priority"`
+
+	// IdempotencyKey 幂等键，也可以通过 Idempotency-Key 请求头传入；
+	// 相同的键在任务等待或执行期间重复提交时返回已有任务
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// NewAPIServer 创建 API 服务并注册路由，collector 可以为 nil。
+// 协程池和结果收集器通过接口传入，通常为 *pool.Pool 和 *result.Collector，单元测试中可以替换为 mock
+func NewAPIServer(taskPool pool.TaskPool, collector result.ResultCollector, cfg *config.Config) *APIServer {
+	if cfg == nil {
+		cfg = config.NewConfig()
+	}
+	// 值为 nil 的 *result.Collector 转换为接口后不等于 nil，统一为 nil 接口
+	if c, ok := collector.(*result.Collector); ok && c == nil {
+		collector = nil
+	}
+	logger, _ := pool.GetModuleLogger("api")
+	s := &APIServer{
+		pool:      taskPool,
+		collector: collector,
+		config:    cfg,
+		mux:       http.NewServeMux(),
+		logger:    logger,
+	}
+	s.closing, s.stopStreams = context.WithCancel(context.Background())
+	s.registerRoutes()
+	return s
+}
+
+// registerRoutes 注册所有路由，每个路由都经过认证中间件并声明所需权限
+func (s *APIServer) registerRoutes() {
+	s.mux.HandleFunc("POST /api/tasks/submit", s.requirePermission(auth.PermissionSubmit, s.SubmitTask))
+	s.mux.HandleFunc("GET /api/tasks/status", s.requirePermission(auth.PermissionMonitor, s.GetTaskStatus))
+	s.mux.HandleFunc("GET /api/tasks/available", s.requirePermission(auth.PermissionMonitor, s.GetAvailableTasks))
+	s.mux.HandleFunc("GET /api/tasks/running", s.requirePermission(auth.PermissionMonitor, s.GetRunningTasks))
+	s.mux.HandleFunc("POST /api/pool/start", s.requirePermission(auth.PermissionManage, s.StartPool))
+	s.mux.HandleFunc("POST /api/pool/pause", s.requirePermission(auth.PermissionManage, s.PausePool))
+	s.mux.HandleFunc("POST /api/pool/resume", s.requirePermission(auth.PermissionManage, s.ResumePool))
+	s.mux.HandleFunc("POST /api/pool/stop", s.requirePermission(auth.PermissionManage, s.StopPool))
+	s.mux.HandleFunc("POST /api/pool/max-concurrency", s.requirePermission(auth.PermissionManage, s.SetMaxConcurrency))
+	s.mux.HandleFunc("POST /api/pool/rate-limit", s.requirePermission(auth.PermissionManage, s.SetRateLimit))
+	s.mux.HandleFunc("GET /api/stats", s.requirePermission(auth.PermissionMonitor, s.GetStats))
+	s.mux.HandleFunc("POST /api/vus/start", s.requirePermission(auth.PermissionSubmit, s.StartVUs))
+	s.mux.HandleFunc("POST /api/vus/stop", s.requirePermission(auth.PermissionManage, s.StopVUs))
+	s.mux.HandleFunc("GET /api/live", s.requirePermission(auth.PermissionMonitor, s.LiveStream))
+	s.mux.HandleFunc("GET /api/runs", s.requirePermission(auth.PermissionMonitor, s.GetRuns))
+	s.mux.HandleFunc("GET /api/trends", s.requirePermission(auth.PermissionMonitor, s.GetTrendScenarios))
+	s.mux.HandleFunc("GET /api/trends/{scenario}", s.requirePermission(auth.PermissionMonitor, s.GetTrend))
+	s.mux.HandleFunc("GET "+reportsPath, s.withAPIKeyCookie(s.requirePermission(auth.PermissionMonitor, s.ServeReports)))
+	s.mux.HandleFunc("GET "+resultsPath, s.withAPIKeyCookie(s.requirePermission(auth.PermissionMonitor, s.ServeResults)))
+	// 看板页面不包含数据，不需要认证
+	s.mux.HandleFunc("GET /dashboard/", s.Dashboard)
+	s.mux.Handle("GET /{$}", http.RedirectHandler("/dashboard/", http.StatusFound))
+}
+
+// log 通过 StressLogger 记录日志，日志记录器未初始化时忽略
+func (s *APIServer) log(level, message string) {
+	if s.logger != nil {
+		s.logger.LogDepth(1, level, message)
+	}
+}
+
+// Handler 返回路由处理器，便于测试或挂载到其他服务
+func (s *APIServer) Handler() http.Handler {
+	return s.mux
+}
+
+// Start 开始监听，config.EnableAPIServer 为 false 时直接返回
+// 端口监听失败会同步返回错误，请求处理在后台 goroutine 中进行
+func (s *APIServer) Start() error {
+	if !s.config.EnableAPIServer {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.APIAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", s.config.APIAddr, err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.server = &http.Server{Handler: s.mux, ReadHeaderTimeout: 10 * time.Second}
+	server := s.server
+	s.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.log("ERROR", fmt.Sprintf("API server stopped: %v", err))
+		}
+	}()
+	return nil
+}
+
+// Addr 返回实际监听地址，未启动时返回空字符串
+func (s *APIServer) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Shutdown 优雅关闭 API 服务，不会关闭协程池；实时推送连接立即结束
+func (s *APIServer) Shutdown(ctx context.Context) error {
+	s.stopStreams()
+	s.mu.Lock()
+	server := s.server
+	s.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
 // SubmitTask 提交任务到协程池
-func SubmitTask(w http.ResponseWriter, r *http.Request) {
+func (s *APIServer) SubmitTask(w http.ResponseWriter, r *http.Request) {
 	var req TaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	if req.TaskName == "" {
+		errorResponse(w, http.StatusBadRequest, "task_name is required")
+		return
+	}
+	if req.TaskID == "" {
+		req.TaskID = fmt.Sprintf("%s-%d", req.TaskName, time.Now().UnixNano())
+	}
 
-	// 定义任务的优先级和重试次数
-	priority := 1 // 示例优先级
-	retries := 3  // 示例重试次数
+	if !s.isAvailable(req.TaskName) {
+		errorResponse(w, http.StatusNotFound, "Unknown task: "+req.TaskName)
+		return
+	}
+
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get(IdempotencyKeyHeader)
+	}
 
 	// 提交任务到协程池
-	taskPool.Submit(func() {
-		// 这里可以执行具体的任务逻辑
-	}, priority, retries, req.TaskID)
+	info, deduplicated, err := s.pool.SubmitByName(req.TaskName, req.Priority, req.TaskID, req.IdempotencyKey, 0)
+	if err != nil {
+		errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if deduplicated {
+		jsonResponse(w, http.StatusOK, map[string]string{"status": "task already submitted", "task_id": info.ID, "task_status": info.Status})
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, map[string]string{"status": "task submitted", "task_id": info.ID})
+}
 
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]string{"status": "task submitted"})
+// isAvailable 判断任务是否已注册
+func (s *APIServer) isAvailable(name string) bool {
+	for _, task := range s.pool.GetAvailableTasks() {
+		if task == name {
+			return true
+		}
+	}
+	return false
 }
 
 // SetMaxConcurrency 设置最大并发数
-func SetMaxConcurrency(w http.ResponseWriter, r *http.Request) {
+func (s *APIServer) SetMaxConcurrency(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		MaxConcurrency int `json:"max_concurrency"`
 	}
@@ -101,84 +269,133 @@ func SetMaxConcurrency(w http.ResponseWriter, r *http.Request) {
 		errorResponse(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	if req.MaxConcurrency <= 0 {
+		errorResponse(w, http.StatusBadRequest, "max_concurrency must be positive")
+		return
+	}
 
-	mu.Lock()
-	maxConcurrency = req.MaxConcurrency
-	mu.Unlock()
+	s.mu.Lock()
+	s.maxConcurrency = req.MaxConcurrency
+	s.mu.Unlock()
+	s.pool.AdjustWorkers(req.MaxConcurrency)
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "max concurrency set"})
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "max concurrency set"})
 }
 
-// SetRateLimit 设置限流控制
-func SetRateLimit(w http.ResponseWriter, r *http.Request) {
+// SetRateLimit 设置限流控制（每秒任务数），指定 task_type 时只限制该类任务，rate_limit 为 0 表示取消限流
+func (s *APIServer) SetRateLimit(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		RateLimit int `json:"rate_limit"`
+		RateLimit int    `json:"rate_limit"`
+		Burst     int    `json:"burst"`
+		TaskType  string `json:"task_type"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	if req.RateLimit < 0 {
+		errorResponse(w, http.StatusBadRequest, "rate_limit cannot be negative")
+		return
+	}
 
-	mu.Lock()
-	rateLimit = req.RateLimit
-	mu.Unlock()
+	response := map[string]string{"status": "rate limit set"}
+	if req.TaskType != "" {
+		s.pool.SetTaskRateLimit(req.TaskType, float64(req.RateLimit), req.Burst)
+	} else {
+		s.mu.Lock()
+		s.rateLimit = req.RateLimit
+		s.mu.Unlock()
+		if warning := s.pool.SetRateLimit(float64(req.RateLimit), req.Burst); warning != "" {
+			response["warning"] = warning
+		}
+	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "rate limit set"})
+	jsonResponse(w, http.StatusOK, response)
 }
 
 // GetTaskStatus 查询任务执行状态
-func GetTaskStatus(w http.ResponseWriter, r *http.Request) {
+func (s *APIServer) GetTaskStatus(w http.ResponseWriter, r *http.Request) {
 	taskID := r.URL.Query().Get("task_id")
-	status, err := taskPool.GetTaskStatus(taskID)
+	status, err := s.pool.GetTaskStatus(taskID)
 	if err != nil {
 		errorResponse(w, http.StatusNotFound, "Task not found")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(status)
+	jsonResponse(w, http.StatusOK, status)
 }
 
 // GetAvailableTasks 查询可执行任务列表
-func GetAvailableTasks(w http.ResponseWriter, r *http.Request) {
-	tasks := taskPool.GetAvailableTasks()
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(tasks)
+func (s *APIServer) GetAvailableTasks(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, s.pool.GetAvailableTasks())
 }
 
-// StartPool 启动协程池
-func StartPool(w http.ResponseWriter, r *http.Request) {
-	taskPool.Start()
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "task pool started"})
+// StartPool 启动协程池：协程池创建后即开始调度任务，这里用于恢复被暂停的调度
+func (s *APIServer) StartPool(w http.ResponseWriter, r *http.Request) {
+	s.pool.Start()
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "task pool started"})
 }
 
 // PausePool 暂停协程池
-func PausePool(w http.ResponseWriter, r *http.Request) {
-	taskPool.Pause()
+func (s *APIServer) PausePool(w http.ResponseWriter, r *http.Request) {
+	s.pool.Pause()
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "task pool paused"})
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "task pool paused"})
+// ResumePool 恢复协程池
+func (s *APIServer) ResumePool(w http.ResponseWriter, r *http.Request) {
+	s.pool.Resume()
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "task pool resumed"})
 }
 
 // StopPool 停止协程池
-func StopPool(w http.ResponseWriter, r *http.Request) {
-	taskPool.Stop()
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "task pool stopped"})
+func (s *APIServer) StopPool(w http.ResponseWriter, r *http.Request) {
+	s.pool.Shutdown()
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "task pool stopped"})
 }
 
 // GetRunningTasks 查询正在执行的任务
-func GetRunningTasks(w http.ResponseWriter, r *http.Request) {
-	tasks := taskPool.GetRunningTasks()
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(tasks)
+func (s *APIServer) GetRunningTasks(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, s.pool.GetRunningTasks())
+}
+
+// GetStats 查询协程池计数与结果统计，尚无结果时 results 字段为空
+func (s *APIServer) GetStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resp := map[string]interface{}{
+		"pool":            s.pool.Stats(),
+		"max_concurrency": s.maxConcurrency,
+		"rate_limit":      s.rateLimit,
+	}
+	s.mu.Unlock()
+
+	if s.collector != nil {
+		if results := s.collector.Results(); len(results) > 0 {
+			stats, err := s.collector.CurrentStats(results)
+			if err != nil {
+				errorResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			data, err := result.ExportStatsMap(stats, result.ExportFormatJSON)
+			if err != nil {
+				errorResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			resp["results"] = json.RawMessage(data)
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, resp)
+}
+
+// jsonResponse 统一的 JSON 响应格式
+func jsonResponse(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
 }
 
 // errorResponse 统一的错误响应格式
 func errorResponse(w http.ResponseWriter, code int, message string) {
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	jsonResponse(w, code, map[string]string{"error": message})
 }
@@ -0,0 +1,150 @@
+// dashboard.go
+// 内置看板模块
+// 本文件负责 API 服务内置的 Web 看板：展示当前运行的实时进度、列出历史运行的 HTML 报告和 JTL 结果文件，
+// 并直接提供报告和结果文件的访问，OpenStress 以服务方式运行时无需额外部署前端。
+//
+// 技术实现细节：
+// 1. 看板页面（dashboard/index.html）通过 go:embed 编译进二进制，GET /dashboard/ 返回页面，GET / 重定向到看板。
+//    页面本身不包含数据，不需要认证；页面中的请求携带用户输入的 API Key。
+// 2. 实时进度使用 GET /api/live 的事件流（见 live.go），历史运行通过 GET /api/runs 获取。
+// 3. GET /api/runs 扫描结果收集器的报告根目录和 JTL 文件所在目录（可以通过 SetHistoryDirs 指定），
+//    见 result.ListReports 和 result.ListResultFiles。
+// 4. GET /reports/ 和 GET /results/ 提供两个目录中的文件，报告中的图表页面通过相对路径加载，
+//    无法携带请求头，因此这两个路由也接受 API Key Cookie（见 middleware.go）；以 . 开头的文件（锁文件）不提供。
+
+package api
+
+import (
+	"embed"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// dashboardAssets 内嵌的看板页面
+//
+//go:embed dashboard
+var dashboardAssets embed.FS
+
+// 报告和结果文件的访问路径
+const (
+	reportsPath = "/reports/"
+	resultsPath = "/results/"
+)
+
+// RunReport 历史运行的 HTML 报告
+type RunReport struct {
+	result.ReportRun
+	URL string `json:"url"` // HTML 报告的访问地址
+}
+
+// RunResult 历史运行的 JTL 结果文件
+type RunResult struct {
+	result.ResultFile
+	URLs []string `json:"urls"` // 各分段的下载地址
+}
+
+// RunsResponse GET /api/runs 的响应
+type RunsResponse struct {
+	Reports []RunReport `json:"reports"`
+	Results []RunResult `json:"results"`
+}
+
+// SetHistoryDirs 设置看板扫描的报告根目录和 JTL 结果目录，为空时使用结果收集器的目录
+func (s *APIServer) SetHistoryDirs(reportDir, resultDir string) {
+	s.mu.Lock()
+	s.reportDir = reportDir
+	s.resultDir = resultDir
+	s.mu.Unlock()
+}
+
+// historyDirs 返回看板扫描的报告根目录和 JTL 结果目录，未设置且没有结果收集器时为空
+func (s *APIServer) historyDirs() (string, string) {
+	s.mu.Lock()
+	reportDir, resultDir := s.reportDir, s.resultDir
+	s.mu.Unlock()
+	if s.collector != nil {
+		if reportDir == "" {
+			reportDir = s.collector.ReportDir()
+		}
+		if resultDir == "" {
+			resultDir = filepath.Dir(s.collector.JTLFilePath())
+		}
+	}
+	return reportDir, resultDir
+}
+
+// Dashboard 返回内置看板页面
+func (s *APIServer) Dashboard(w http.ResponseWriter, r *http.Request) {
+	page, err := dashboardAssets.ReadFile("dashboard/index.html")
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Dashboard is not available")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(page)
+}
+
+// GetRuns 列出历史运行的 HTML 报告和 JTL 结果文件，按时间倒序
+func (s *APIServer) GetRuns(w http.ResponseWriter, r *http.Request) {
+	reportDir, resultDir := s.historyDirs()
+	response := RunsResponse{Reports: []RunReport{}, Results: []RunResult{}}
+
+	if reportDir != "" {
+		reports, err := result.ListReports(reportDir)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, report := range reports {
+			response.Reports = append(response.Reports, RunReport{ReportRun: report, URL: reportsPath + path.Join(report.Dir, report.Report())})
+		}
+	}
+	if resultDir != "" {
+		files, err := result.ListResultFiles(resultDir)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, file := range files {
+			run := RunResult{ResultFile: file, URLs: []string{}}
+			for _, segment := range file.Segments {
+				run.URLs = append(run.URLs, resultsPath+segment)
+			}
+			response.Results = append(response.Results, run)
+		}
+	}
+	jsonResponse(w, http.StatusOK, response)
+}
+
+// ServeReports 提供报告根目录中的文件
+func (s *APIServer) ServeReports(w http.ResponseWriter, r *http.Request) {
+	reportDir, _ := s.historyDirs()
+	s.serveHistoryFile(w, r, reportsPath, reportDir)
+}
+
+// ServeResults 提供 JTL 结果目录中的文件
+func (s *APIServer) ServeResults(w http.ResponseWriter, r *http.Request) {
+	_, resultDir := s.historyDirs()
+	s.serveHistoryFile(w, r, resultsPath, resultDir)
+}
+
+// serveHistoryFile 提供 dir 中的文件，不列出目录，不提供以 . 开头的文件
+func (s *APIServer) serveHistoryFile(w http.ResponseWriter, r *http.Request, prefix, dir string) {
+	name := strings.TrimPrefix(r.URL.Path, prefix)
+	if dir == "" || name == "" || strings.HasSuffix(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	http.StripPrefix(strings.TrimSuffix(prefix, "/"), http.FileServer(http.Dir(dir))).ServeHTTP(w, r)
+}
@@ -0,0 +1,320 @@
+// distributed.go
+// 分布式启动协调模块
+// 本文件负责分布式压测时多个 worker 的同步启动：协调者计算统一的测量开始时间并下发给各个 worker，
+// 各 worker 的 VU 在同一时刻结束预热、开始测量，跨 worker 按秒聚合时第一个桶就是准确的。
+//
+// 技术实现细节：
+// 1. worker 端提供 POST /api/vus/start 接口，按任务名以虚拟用户方式运行已注册任务，
+//    请求中的 start_at 作为统一的测量开始时间，同时设置结果收集器的测量开始时间。
+// 2. 协调者（StartCoordinator）通过 pool.SyncStartTime 计算 start_at，并发下发给所有 worker。
+// 3. start_at 之前预留 Lead 时长用于指令下发；worker 收到时如果预热开始时间已过则拒绝启动，
+//    协调者汇总所有失败的 worker 返回错误。
+// 4. 除测量时长外，请求还可以指定总迭代次数、每个 VU 的迭代次数和最大错误数，先满足者生效；
+//    POST /api/vus/stop 停止正在运行的 VU（协调者通过 Stop 下发给所有 worker），
+//    结束原因写入结果收集器，出现在报告中。
+// 5. 请求中设置 mix（任务和权重）时按流量配比运行多个任务（pool.StartMixVUs），
+//    VU 结束后实际达到的配比写入日志和结果收集器（SetTrafficMix）。
+// 6. 请求中设置 arrival_rate 时按到达率（开放模型，pool.StartRegisteredArrivals）运行，
+//    因 VU 不足丢弃的到达数在结束时写入日志。
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// VUStartRequest 启动虚拟用户的请求
+type VUStartRequest struct {
+	TaskName        string    `json:"task_name"`
+	VUs             int       `json:"vus"`
+	DurationMs      int64     `json:"duration_ms"` // 为 0 时只按迭代次数停止
+	WarmUpMs        int64     `json:"warm_up_ms"`
+	StartAt         time.Time `json:"start_at"`          // 统一的测量开始时间，零值表示 worker 本地计算
+	Iterations      int       `json:"iterations"`        // 总迭代次数上限，分布式模式下为每个 worker 的上限
+	IterationsPerVU int       `json:"iterations_per_vu"` // 每个 VU 的迭代次数上限
+	MaxErrors       int       `json:"max_errors"`        // 最大错误数
+
+	AbortErrorRate           float64 `json:"abort_error_rate"`           // 滑动窗口内的错误率上限（百分比），超出时提前中止
+	AbortWindowMs            int64   `json:"abort_window_ms"`            // 错误率的滑动窗口，0 表示默认 10 秒
+	AbortMinIterations       int     `json:"abort_min_iterations"`       // 窗口内至少完成的迭代数，0 表示默认 20
+	AbortConsecutiveFailures int     `json:"abort_consecutive_failures"` // 连续失败次数上限，超出时提前中止
+
+	// 流量配比，设置时每次迭代按权重选择一个任务执行，TaskName 只作为这次运行的名称（默认 mix），用于停止
+	Mix []pool.MixEntry `json:"mix,omitempty"`
+
+	// 到达率（开放模型），大于 0 时按每秒到达数调度迭代，VUs 为同时执行的迭代上限，不能与 mix 同时使用
+	ArrivalRate         float64 `json:"arrival_rate,omitempty"`
+	ArrivalDistribution string  `json:"arrival_distribution,omitempty"` // constant（默认）或 poisson
+}
+
+// VUStopRequest 停止虚拟用户的请求
+type VUStopRequest struct {
+	TaskName string `json:"task_name"` // 为空时停止所有正在运行的 VU
+}
+
+// VUStartResponse 启动虚拟用户的响应
+type VUStartResponse struct {
+	Status  string    `json:"status"`
+	StartAt time.Time `json:"start_at"`
+}
+
+// StartVUs 以虚拟用户方式运行已注册的任务，立即返回，VU 在后台运行到测量阶段结束
+func (s *APIServer) StartVUs(w http.ResponseWriter, r *http.Request) {
+	var req VUStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.ArrivalRate > 0 && len(req.Mix) > 0 {
+		errorResponse(w, http.StatusBadRequest, "mix is not supported with an arrival rate")
+		return
+	}
+	var mix *pool.TrafficMix
+	if len(req.Mix) > 0 {
+		var err error
+		if mix, err = pool.NewTrafficMix(req.Mix); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, task := range mix.Tasks() {
+			if !s.isAvailable(task) {
+				errorResponse(w, http.StatusNotFound, "Unknown task: "+task)
+				return
+			}
+		}
+		if req.TaskName == "" {
+			req.TaskName = "mix"
+		}
+	} else if !s.isAvailable(req.TaskName) {
+		errorResponse(w, http.StatusNotFound, "Unknown task: "+req.TaskName)
+		return
+	}
+
+	cfg := pool.VUConfig{
+		VUs:             req.VUs,
+		Duration:        time.Duration(req.DurationMs) * time.Millisecond,
+		WarmUp:          time.Duration(req.WarmUpMs) * time.Millisecond,
+		StartAt:         req.StartAt,
+		Iterations:      req.Iterations,
+		IterationsPerVU: req.IterationsPerVU,
+		MaxErrors:       req.MaxErrors,
+		Abort: pool.AbortPolicy{
+			ErrorRate:           req.AbortErrorRate,
+			Window:              time.Duration(req.AbortWindowMs) * time.Millisecond,
+			MinIterations:       req.AbortMinIterations,
+			ConsecutiveFailures: req.AbortConsecutiveFailures,
+		},
+	}
+	var run *pool.VURun
+	var err error
+	switch {
+	case req.ArrivalRate > 0:
+		run, err = s.pool.StartRegisteredArrivals(req.TaskName, pool.ArrivalConfig{
+			VUConfig:     cfg,
+			Rate:         req.ArrivalRate,
+			Distribution: pool.ArrivalDistribution(req.ArrivalDistribution),
+		})
+	case mix != nil:
+		run, err = s.pool.StartMixVUs(mix, cfg)
+	default:
+		run, err = s.pool.StartRegisteredVUs(req.TaskName, cfg)
+	}
+	if err != nil {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+	if s.collector != nil && !req.StartAt.IsZero() {
+		s.collector.SetMeasurementStart(req.StartAt)
+	}
+	s.mu.Lock()
+	if s.vuRuns == nil {
+		s.vuRuns = make(map[*pool.VURun]string)
+	}
+	s.vuRuns[run] = req.TaskName
+	s.mu.Unlock()
+	go func() {
+		measureStart := run.Wait()
+		s.mu.Lock()
+		delete(s.vuRuns, run)
+		s.mu.Unlock()
+		if s.collector != nil {
+			if abort, ok := run.Abort(); ok {
+				s.collector.SetAbort(string(abort.Reason), abort.Detail, abort.Time)
+			} else {
+				s.collector.SetStopReason(string(run.StopReason()))
+			}
+			if mix != nil {
+				s.collector.SetTrafficMix(trafficShares(mix))
+			}
+		}
+		if dropped := run.Dropped(); dropped > 0 {
+			s.log("WARN", fmt.Sprintf("Arrival-rate run of %s dropped %d arrivals because all %d vus were busy", req.TaskName, dropped, req.VUs))
+		}
+		if mix != nil {
+			for _, share := range mix.Shares() {
+				s.log("INFO", fmt.Sprintf("Traffic mix of %s: %s %d iterations, %.2f%% (target %.2f%%)", req.TaskName, share.Task, share.Iterations, share.Actual, share.Target))
+			}
+		}
+		s.log("INFO", fmt.Sprintf("VU run of %s finished (%s), measurement started at %s", req.TaskName, run.StopReason(), measureStart.Format(time.RFC3339)))
+	}()
+
+	s.log("INFO", fmt.Sprintf("Started %d vus of %s, start at %s", req.VUs, req.TaskName, req.StartAt.Format(time.RFC3339)))
+	jsonResponse(w, http.StatusAccepted, VUStartResponse{Status: "vus started", StartAt: req.StartAt})
+}
+
+// trafficShares 把流量配比的实际占比转换为报告使用的格式
+func trafficShares(mix *pool.TrafficMix) []result.TrafficShare {
+	var shares []result.TrafficShare
+	for _, share := range mix.Shares() {
+		shares = append(shares, result.TrafficShare{
+			Task:       share.Task,
+			Weight:     share.Weight,
+			Target:     share.Target,
+			Iterations: share.Iterations,
+			Actual:     share.Actual,
+		})
+	}
+	return shares
+}
+
+// StopVUs 停止正在运行的 VU，VU 在当前迭代结束后退出，结束原因记为 external
+func (s *APIServer) StopVUs(w http.ResponseWriter, r *http.Request) {
+	var req VUStopRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	stopped := 0
+	s.mu.Lock()
+	for run, name := range s.vuRuns {
+		if req.TaskName == "" || req.TaskName == name {
+			run.Stop()
+			stopped++
+		}
+	}
+	s.mu.Unlock()
+	if stopped == 0 {
+		errorResponse(w, http.StatusNotFound, "No running vus")
+		return
+	}
+
+	s.log("INFO", fmt.Sprintf("Stopping %d vu runs", stopped))
+	jsonResponse(w, http.StatusOK, map[string]interface{}{"status": "vus stopping", "runs": stopped})
+}
+
+// StartCoordinator 分布式启动协调者，向所有 worker 下发统一的测量开始时间
+type StartCoordinator struct {
+	Workers []string      // worker 的 API 地址，例如 "http://10.0.0.2:8080"
+	APIKey  string        // 访问 worker API 使用的 API Key，为空时不携带
+	Lead    time.Duration // 留给 worker 接收指令并就绪的时间，默认 2s
+	Client  *http.Client  // 为空时使用 http.DefaultClient
+}
+
+// defaultStartLead 默认的下发提前量
+const defaultStartLead = 2 * time.Second
+
+// Start 计算统一的测量开始时间并下发给所有 worker，返回该时间
+// 任一 worker 启动失败时返回汇总的错误，已成功启动的 worker 仍按该时间运行
+func (c *StartCoordinator) Start(ctx context.Context, req VUStartRequest) (time.Time, error) {
+	if len(c.Workers) == 0 {
+		return time.Time{}, fmt.Errorf("no workers to start")
+	}
+	lead := c.Lead
+	if lead <= 0 {
+		lead = defaultStartLead
+	}
+	req.StartAt = pool.SyncStartTime(time.Now(), lead, time.Duration(req.WarmUpMs)*time.Millisecond)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to encode start request: %v", err)
+	}
+
+	errs := make([]error, len(c.Workers))
+	var wg sync.WaitGroup
+	for i, worker := range c.Workers {
+		wg.Add(1)
+		go func(i int, worker string) {
+			defer wg.Done()
+			if err := c.startWorker(ctx, worker, body); err != nil {
+				errs[i] = fmt.Errorf("worker %s: %v", worker, err)
+			}
+		}(i, worker)
+	}
+	wg.Wait()
+	return req.StartAt, errors.Join(errs...)
+}
+
+// Stop 通知所有 worker 停止正在运行的 VU，taskName 为空时停止所有任务
+// 没有正在运行 VU 的 worker 不算失败
+func (c *StartCoordinator) Stop(ctx context.Context, taskName string) error {
+	body, err := json.Marshal(VUStopRequest{TaskName: taskName})
+	if err != nil {
+		return fmt.Errorf("failed to encode stop request: %v", err)
+	}
+
+	errs := make([]error, len(c.Workers))
+	var wg sync.WaitGroup
+	for i, worker := range c.Workers {
+		wg.Add(1)
+		go func(i int, worker string) {
+			defer wg.Done()
+			err := c.post(ctx, worker, "/api/vus/stop", body, http.StatusOK, http.StatusNotFound)
+			if err != nil {
+				errs[i] = fmt.Errorf("worker %s: %v", worker, err)
+			}
+		}(i, worker)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// startWorker 向单个 worker 发送启动请求
+func (c *StartCoordinator) startWorker(ctx context.Context, worker string, body []byte) error {
+	return c.post(ctx, worker, "/api/vus/start", body, http.StatusAccepted)
+}
+
+// post 向 worker 发送请求，响应状态码不在 expected 中时返回错误
+func (c *StartCoordinator) post(ctx context.Context, worker, path string, body []byte, expected ...int) error {
+	url := strings.TrimSuffix(worker, "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set(APIKeyHeader, c.APIKey)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	var failure map[string]string
+	json.NewDecoder(resp.Body).Decode(&failure)
+	return fmt.Errorf("status %d: %s", resp.StatusCode, failure["error"])
+}
@@ -0,0 +1,138 @@
+// live.go
+// 实时进度推送模块
+// 本文件负责 GET /api/live 接口：通过 Server-Sent Events 向浏览器持续推送压测的实时统计和重要日志，
+// 压测过程中无需轮询即可观察 TPS、错误和响应时间的变化。
+//
+// 技术实现细节：
+// 1. 每隔 interval_ms（默认 1 秒，最小 100 毫秒）推送一条 stats 事件：协程池计数、结果计数器，
+//    以及上次推送之后完成的每一秒的请求汇总（见 result.Collector.LiveSeconds），连接建立时立即推送一次。
+// 2. 通过 StressLogger.Subscribe 订阅不低于 log_level（默认 WARN）的日志，每条日志推送一条 log 事件；
+//    浏览器处理不及时时丢弃日志，不影响压测本身。
+// 3. 浏览器的 EventSource 无法设置请求头，认证时 API Key 也可以通过 api_key 查询参数传入（见 middleware.go）。
+// 4. 客户端断开或 API 服务关闭（Shutdown）时结束推送，不会阻塞服务关闭。
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// 实时推送的默认值
+const (
+	defaultLiveInterval  = time.Second
+	minLiveInterval      = 100 * time.Millisecond
+	defaultLiveLogLevel  = "WARN"
+	liveLogBufferEntries = 256
+)
+
+// LiveStats 实时推送的 stats 事件
+type LiveStats struct {
+	Time    time.Time                 `json:"time"`
+	Pool    pool.PoolStats            `json:"pool"`
+	Results *result.CollectorCounters `json:"results,omitempty"` // 未配置结果收集器时为空
+	Seconds []result.LiveSecond       `json:"seconds"`           // 上次推送之后完成的各秒，当前秒完成后才推送
+}
+
+// LiveStream 通过 Server-Sent Events 推送实时统计（stats 事件）和重要日志（log 事件）
+func (s *APIServer) LiveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	interval := defaultLiveInterval
+	if v := r.URL.Query().Get("interval_ms"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || time.Duration(ms)*time.Millisecond < minLiveInterval {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("interval_ms must be an integer of at least %d", minLiveInterval.Milliseconds()))
+			return
+		}
+		interval = time.Duration(ms) * time.Millisecond
+	}
+	level := r.URL.Query().Get("log_level")
+	if level == "" {
+		level = defaultLiveLogLevel
+	}
+	var logs <-chan pool.LogEvent
+	if s.logger != nil {
+		ch, unsubscribe, err := s.logger.Subscribe(level, liveLogBufferEntries)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer unsubscribe()
+		logs = ch
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	s.log("INFO", fmt.Sprintf("Live stream opened by %s", r.RemoteAddr))
+	defer s.log("INFO", fmt.Sprintf("Live stream closed by %s", r.RemoteAddr))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := time.Now().Truncate(time.Second)
+	send := func(event string, v interface{}) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			s.log("ERROR", fmt.Sprintf("failed to encode live %s event: %v", event, err))
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if !send("stats", s.liveStats(&last)) {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.closing.Done():
+			return
+		case <-ticker.C:
+			if !send("stats", s.liveStats(&last)) {
+				return
+			}
+		case event, ok := <-logs:
+			if !ok {
+				logs = nil // 日志记录器已关闭，只推送统计
+				continue
+			}
+			if !send("log", event) {
+				return
+			}
+		}
+	}
+}
+
+// liveStats 生成 stats 事件，包含 *last 之后完成的各秒，并把 *last 推进到当前秒
+func (s *APIServer) liveStats(last *time.Time) LiveStats {
+	now := time.Now()
+	current := now.Truncate(time.Second)
+	stats := LiveStats{Time: now, Pool: s.pool.Stats(), Seconds: []result.LiveSecond{}}
+	if s.collector != nil {
+		counters := s.collector.Counters()
+		stats.Results = &counters
+		if current.After(*last) {
+			stats.Seconds = s.collector.LiveSeconds(*last, current)
+		}
+	}
+	*last = current
+	return stats
+}
@@ -0,0 +1,108 @@
+// middleware.go
+// API 中间件模块
+// 本文件负责 API 的身份验证与授权：从请求头读取 API Key，
+// 通过 auth.AuthManager 校验身份，并按路由检查 submit / manage / monitor 权限。
+//
+// 响应约定：
+// - 缺少或无效的 API Key 返回 401
+// - 身份有效但缺少路由所需权限返回 403
+// - 未配置认证器时不做校验（便于本地调试）
+// - 浏览器的 EventSource 无法设置请求头，Accept 为 text/event-stream 的请求可以通过 api_key 查询参数传入 API Key
+// - 报告中的图表页面通过相对路径加载，报告和结果文件的路由也接受看板写入的 API Key Cookie
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/potatoImp/OpenStress/auth"
+)
+
+// APIKeyHeader 携带 API Key 的请求头
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyQueryParam 事件流请求（EventSource）携带 API Key 的查询参数
+const APIKeyQueryParam = "api_key"
+
+// APIKeyCookie 看板写入的携带 API Key 的 Cookie，只用于报告和结果文件的路由
+const APIKeyCookie = "openstress_api_key"
+
+// Authenticator 身份验证与授权接口，*auth.AuthManager 实现了该接口
+type Authenticator interface {
+	ValidateAPIKey(apiKey string) (*auth.UserAuth, error)
+	HasPermission(user *auth.UserAuth, perm auth.Permission) bool
+}
+
+// SetAuthenticator 设置认证器，传入 nil 表示关闭认证
+func (s *APIServer) SetAuthenticator(authenticator Authenticator) {
+	s.mu.Lock()
+	s.authenticator = authenticator
+	s.mu.Unlock()
+}
+
+// requirePermission 认证中间件，校验 API Key 并检查路由所需权限
+func (s *APIServer) requirePermission(perm auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		authenticator := s.authenticator
+		s.mu.Unlock()
+
+		if authenticator == nil {
+			next(w, r)
+			return
+		}
+
+		route := r.Method + " " + r.URL.Path
+		apiKey := r.Header.Get(APIKeyHeader)
+		if apiKey == "" && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			apiKey = r.URL.Query().Get(APIKeyQueryParam)
+		}
+		if apiKey == "" {
+			s.log("WARN", fmt.Sprintf("Auth denied for %s from %s: missing API key", route, r.RemoteAddr))
+			errorResponse(w, http.StatusUnauthorized, "Missing API key")
+			return
+		}
+
+		user, err := authenticator.ValidateAPIKey(apiKey)
+		if err != nil || user == nil {
+			s.log("WARN", fmt.Sprintf("Auth denied for %s from %s: invalid API key %s", route, r.RemoteAddr, maskAPIKey(apiKey)))
+			errorResponse(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+
+		if !authenticator.HasPermission(user, perm) {
+			s.log("WARN", fmt.Sprintf("Auth denied for %s: user %s lacks %s permission", route, user.Username, perm))
+			errorResponse(w, http.StatusForbidden, fmt.Sprintf("Permission %s required", perm))
+			return
+		}
+
+		s.log("INFO", fmt.Sprintf("Auth granted for %s: user %s with %s permission", route, user.Username, perm))
+		next(w, r)
+	}
+}
+
+// maskAPIKey 日志中只保留 API Key 的前 4 位
+func maskAPIKey(apiKey string) string {
+	if len(apiKey) <= 4 {
+		return "****"
+	}
+	return apiKey[:4] + "****"
+}
+
+// withAPIKeyCookie 请求头中没有 API Key 时使用 APIKeyCookie 中的 API Key，需要放在 requirePermission 之前
+func (s *APIServer) withAPIKeyCookie(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(APIKeyHeader) == "" {
+			// 看板写入 Cookie 时对 API Key 做了 URL 编码
+			if cookie, err := r.Cookie(APIKeyCookie); err == nil && cookie.Value != "" {
+				if apiKey, err := url.QueryUnescape(cookie.Value); err == nil {
+					r.Header.Set(APIKeyHeader, apiKey)
+				}
+			}
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,99 @@
+// trend.go
+// 性能趋势查询模块
+// 本文件负责查询历史运行的性能趋势：列出有记录的场景，返回某个场景各次运行的关键指标，
+// 以及最近一次运行与滚动基线的对比（是否发生回归），便于在 CI 之外持续观察性能变化。
+//
+// 技术实现细节：
+// 1. 趋势数据由结果收集器的趋势库（result.TrendStore，见 result/trend.go）记录，收集器未配置时
+//    使用配置文件 report.history 创建只读的趋势库；两者都未配置时返回 404。
+// 2. GET /api/trends 列出场景，最近运行的场景在前；GET /api/trends/{scenario} 返回该场景按时间正序的运行记录，
+//    limit 参数限制返回最近的运行数，但回归对比始终基于全部记录计算，与运行结束时的判定一致。
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// TrendScenariosResponse GET /api/trends 的响应
+type TrendScenariosResponse struct {
+	Scenarios []result.TrendScenario `json:"scenarios"`
+}
+
+// TrendResponse GET /api/trends/{scenario} 的响应
+type TrendResponse struct {
+	Scenario     string              `json:"scenario"`
+	Runs         []result.TrendRun   `json:"runs"`          // 按时间正序
+	BaselineRuns int                 `json:"baseline_runs"` // 最近一次运行对比的基线包含的运行数
+	Comparison   []result.TrendDelta `json:"comparison"`    // 最近一次运行与基线的对比，只有一次运行时为空
+}
+
+// trendStore 返回查询使用的趋势库，未配置时返回 nil
+func (s *APIServer) trendStore() *result.TrendStore {
+	if s.collector != nil {
+		if store := s.collector.TrendStore(); store != nil {
+			return store
+		}
+	}
+	return result.NewTrendStore(s.config.Report.History)
+}
+
+// GetTrendScenarios 列出有趋势记录的场景
+func (s *APIServer) GetTrendScenarios(w http.ResponseWriter, r *http.Request) {
+	store := s.trendStore()
+	if store == nil {
+		errorResponse(w, http.StatusNotFound, "Trend history is not configured")
+		return
+	}
+	scenarios, err := store.Scenarios()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, TrendScenariosResponse{Scenarios: scenarios})
+}
+
+// GetTrend 返回一个场景的历史运行指标和最近一次运行的回归对比
+func (s *APIServer) GetTrend(w http.ResponseWriter, r *http.Request) {
+	store := s.trendStore()
+	if store == nil {
+		errorResponse(w, http.StatusNotFound, "Trend history is not configured")
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	scenario := r.PathValue("scenario")
+	runs, err := store.Runs(scenario, 0)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(runs) == 0 {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("No trend history for scenario %s", scenario))
+		return
+	}
+
+	response := TrendResponse{Scenario: scenario, Comparison: []result.TrendDelta{}}
+	history, latest := runs[:len(runs)-1], runs[len(runs)-1]
+	if comparison := store.Compare(history, latest); comparison != nil {
+		response.Comparison = comparison
+		response.BaselineRuns = min(len(history), store.Baseline())
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	response.Runs = runs
+	jsonResponse(w, http.StatusOK, response)
+}
@@ -2,7 +2,6 @@
 package auth
 
 import (
-	"OpenStress/pool"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -13,7 +12,8 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"gopkg.in/yaml.v2"
-	// "github.com/your-repo/OpenStress/pool"  // 导入 pool 包
+
+	"github.com/potatoImp/OpenStress/pool"
 )
 
 // 认证模块
@@ -40,9 +40,10 @@ const (
 // UserAuth 用户认证信息
 type UserAuth struct {
 	Username    string       `yaml:"username" json:"username"`
-	Password    string       `yaml:"password" json:"-"`              // 配置文件中的密码
+	Password    string       `yaml:"password,omitempty" json:"-"`    // 配置文件中的密码
 	APIKey      string       `yaml:"api_key" json:"api_key"`         // API密钥
 	Permissions []Permission `yaml:"permissions" json:"permissions"` // 权限列表
+	Version     int64        `yaml:"version" json:"version"`         // 版本号，用于乐观并发控制
 }
 
 // AuthConfig 认证配置
@@ -77,7 +78,9 @@ type cacheItem struct {
 // AuthManager 认证管理器
 type AuthManager struct {
 	mu              sync.RWMutex
+	adminMu         sync.Mutex // 串行化用户管理操作
 	config          *AuthConfig
+	configPath      string
 	mode            AuthMode
 	redisClient     *redis.Client
 	redisOpts       *redis.Options
@@ -179,7 +182,15 @@ func (am *AuthManager) loadConfig(configPath string) error {
 		return fmt.Errorf("failed to parse config file: %v", err)
 	}
 
+	// 未设置版本号的用户视为初始版本
+	for i := range config.Users {
+		if config.Users[i].Version == 0 {
+			config.Users[i].Version = 1
+		}
+	}
+
 	am.config = config
+	am.configPath = configPath
 	return nil
 }
 
@@ -0,0 +1,482 @@
+// users.go
+// 用户与权限管理
+// 本文件负责在运行时创建、更新、删除用户以及轮换 API 密钥。
+//
+// 持久化方式：
+// - ModeLocal：写回 YAML 配置文件（先写临时文件再重命名，避免写到一半损坏）。
+//   写回时在原文件的节点树上修改 users 列表，保留注释、未变化的用户、未知字段（如 password）和文件权限
+// - ModeRedis：写入 Redis 的 user:<用户名> 与 apikey:<密钥> 键，使用 WATCH 事务
+//
+// 并发控制：每个用户带有 Version，修改时必须携带期望版本号，不一致返回 ErrVersionConflict。
+// 所有操作都会以 AUDIT 前缀记录到认证日志中。
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUserExists 用户已存在
+	ErrUserExists = errors.New("user already exists")
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("user not found")
+	// ErrVersionConflict 版本冲突，用户已被其他操作修改
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// GetUser 获取用户信息
+func (am *AuthManager) GetUser(username string) (*UserAuth, error) {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	if i := findUser(am.config.Users, username); i >= 0 {
+		user := am.config.Users[i]
+		return &user, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+// ListUsers 返回所有用户
+func (am *AuthManager) ListUsers() []UserAuth {
+	return am.snapshotUsers()
+}
+
+// CreateUser 创建用户，未指定 API 密钥时自动生成
+func (am *AuthManager) CreateUser(actor string, user UserAuth) (*UserAuth, error) {
+	am.adminMu.Lock()
+	defer am.adminMu.Unlock()
+
+	created, err := am.createUser(user)
+	am.audit(actor, "create_user", user.Username, err)
+	return created, err
+}
+
+func (am *AuthManager) createUser(user UserAuth) (*UserAuth, error) {
+	if user.Username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+	if err := validatePermissions(user.Permissions); err != nil {
+		return nil, err
+	}
+
+	users := am.snapshotUsers()
+	if findUser(users, user.Username) >= 0 {
+		return nil, ErrUserExists
+	}
+	if user.APIKey == "" {
+		key, err := generateAPIKey()
+		if err != nil {
+			return nil, err
+		}
+		user.APIKey = key
+	} else if findAPIKey(users, user.APIKey) >= 0 {
+		return nil, fmt.Errorf("api key already in use")
+	}
+	user.Version = 1
+
+	users = append(users, user)
+	if err := am.commitUsers(users, user.Username, &user, 0, nil); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserPermissions 更新用户权限，expectedVersion 必须与当前版本一致
+func (am *AuthManager) UpdateUserPermissions(actor, username string, permissions []Permission, expectedVersion int64) (*UserAuth, error) {
+	am.adminMu.Lock()
+	defer am.adminMu.Unlock()
+
+	updated, err := am.updateUser(username, expectedVersion, func(user *UserAuth) error {
+		if err := validatePermissions(permissions); err != nil {
+			return err
+		}
+		user.Permissions = append([]Permission(nil), permissions...)
+		return nil
+	})
+	am.audit(actor, fmt.Sprintf("update_permissions %v", permissions), username, err)
+	return updated, err
+}
+
+// RotateAPIKey 为用户生成新的 API 密钥，旧密钥立即失效
+func (am *AuthManager) RotateAPIKey(actor, username string, expectedVersion int64) (*UserAuth, error) {
+	am.adminMu.Lock()
+	defer am.adminMu.Unlock()
+
+	rotated, err := am.rotateAPIKey(username, expectedVersion)
+	am.audit(actor, "rotate_api_key", username, err)
+	return rotated, err
+}
+
+func (am *AuthManager) rotateAPIKey(username string, expectedVersion int64) (*UserAuth, error) {
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	return am.updateUser(username, expectedVersion, func(user *UserAuth) error {
+		user.APIKey = key
+		return nil
+	})
+}
+
+// updateUser 按期望版本修改用户并持久化，旧的 API 密钥缓存会被清除
+func (am *AuthManager) updateUser(username string, expectedVersion int64, mutate func(user *UserAuth) error) (*UserAuth, error) {
+	users := am.snapshotUsers()
+	i := findUser(users, username)
+	if i < 0 {
+		return nil, ErrUserNotFound
+	}
+	if users[i].Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	oldKey := users[i].APIKey
+	user := users[i]
+	if err := mutate(&user); err != nil {
+		return nil, err
+	}
+	user.Version++
+	users[i] = user
+
+	if err := am.commitUsers(users, username, &user, expectedVersion, []string{oldKey}); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser 删除用户，expectedVersion 必须与当前版本一致
+func (am *AuthManager) DeleteUser(actor, username string, expectedVersion int64) error {
+	am.adminMu.Lock()
+	defer am.adminMu.Unlock()
+
+	err := am.deleteUser(username, expectedVersion)
+	am.audit(actor, "delete_user", username, err)
+	return err
+}
+
+func (am *AuthManager) deleteUser(username string, expectedVersion int64) error {
+	users := am.snapshotUsers()
+	i := findUser(users, username)
+	if i < 0 {
+		return ErrUserNotFound
+	}
+	if users[i].Version != expectedVersion {
+		return ErrVersionConflict
+	}
+
+	oldKey := users[i].APIKey
+	users = append(users[:i], users[i+1:]...)
+	return am.commitUsers(users, username, nil, expectedVersion, []string{oldKey})
+}
+
+// commitUsers 持久化用户变更并更新内存配置
+// changed 为 nil 表示删除用户；expectedVersion 为 0 表示新建用户
+func (am *AuthManager) commitUsers(users []UserAuth, username string, changed *UserAuth, expectedVersion int64, staleKeys []string) error {
+	am.mu.RLock()
+	mode := am.mode
+	client := am.redisClient
+	am.mu.RUnlock()
+
+	var err error
+	if mode == ModeRedis && client != nil {
+		err = am.persistRedis(client, username, changed, expectedVersion, staleKeys)
+	} else {
+		err = am.persistYAML(users)
+	}
+	if err != nil {
+		return err
+	}
+
+	am.mu.Lock()
+	am.config.Users = users
+	am.mu.Unlock()
+
+	// 清除旧密钥的本地缓存，保证权限变更与密钥轮换立即生效
+	for _, key := range staleKeys {
+		am.localCache.cache.Delete(key)
+	}
+	return nil
+}
+
+// persistYAML 将用户列表写回配置文件
+func (am *AuthManager) persistYAML(users []UserAuth) error {
+	info, err := os.Stat(am.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat config file: %v", err)
+	}
+	original, err := os.ReadFile(am.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(original, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if err := setUsersNode(&doc, users); err != nil {
+		return fmt.Errorf("failed to encode auth config: %v", err)
+	}
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode auth config: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode auth config: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(am.configPath), ".auth-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temp config file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp config file: %v", err)
+	}
+	// CreateTemp 创建的文件权限为 0600，沿用原文件的权限
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set config file mode: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temp config file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), am.configPath); err != nil {
+		return fmt.Errorf("failed to replace config file: %v", err)
+	}
+	return nil
+}
+
+// setUsersNode 按 users 更新文档中的 users 列表：未变化的用户节点原样保留，
+// 变化的用户只改写有变化的字段，删除的用户被移除，新用户追加在末尾
+func setUsersNode(doc *yamlv3.Node, users []UserAuth) error {
+	if doc.Kind == 0 {
+		doc.Kind = yamlv3.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		return fmt.Errorf("auth config root is not a mapping")
+	}
+
+	list := mappingValue(root, "users")
+	if list == nil || list.Kind != yamlv3.SequenceNode {
+		list = &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		setMappingValue(root, "users", list)
+	}
+
+	type storedUser struct {
+		node *yamlv3.Node
+		user UserAuth
+	}
+	existing := make(map[string]storedUser, len(list.Content))
+	for _, node := range list.Content {
+		var stored UserAuth
+		if err := node.Decode(&stored); err == nil {
+			// 与 loadConfig 一致，未设置版本号的用户视为初始版本
+			if stored.Version == 0 {
+				stored.Version = 1
+			}
+			existing[stored.Username] = storedUser{node: node, user: stored}
+		}
+	}
+
+	content := make([]*yamlv3.Node, 0, len(users))
+	for _, user := range users {
+		stored, ok := existing[user.Username]
+		if ok && reflect.DeepEqual(stored.user, user) {
+			content = append(content, stored.node)
+			continue
+		}
+		node := stored.node
+		if !ok {
+			node = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		}
+		if err := updateUserNode(node, user); err != nil {
+			return err
+		}
+		content = append(content, node)
+	}
+	list.Content = content
+	return nil
+}
+
+// updateUserNode 将用户字段写入映射节点，值未变化的字段保持原样
+func updateUserNode(node *yamlv3.Node, user UserAuth) error {
+	fields := []struct {
+		key   string
+		value interface{}
+		skip  bool
+	}{
+		{"username", user.Username, false},
+		{"password", user.Password, user.Password == ""}, // 内存中没有密码时保留文件中的原值
+		{"api_key", user.APIKey, false},
+		{"permissions", user.Permissions, false},
+		{"version", user.Version, false},
+	}
+	for _, field := range fields {
+		if field.skip {
+			continue
+		}
+		if current := mappingValue(node, field.key); current != nil {
+			decoded := reflect.New(reflect.TypeOf(field.value))
+			if err := current.Decode(decoded.Interface()); err == nil && reflect.DeepEqual(decoded.Elem().Interface(), field.value) {
+				continue
+			}
+		}
+		var value yamlv3.Node
+		if err := value.Encode(field.value); err != nil {
+			return err
+		}
+		setMappingValue(node, field.key, &value)
+	}
+	return nil
+}
+
+// mappingValue 返回映射节点中 key 对应的值节点，不存在返回 nil
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue 设置映射节点中 key 对应的值节点，保留原值节点上的行尾注释
+func setMappingValue(node *yamlv3.Node, key string, value *yamlv3.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value.LineComment = node.Content[i+1].LineComment
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// persistRedis 使用 WATCH 事务将用户写入 Redis，Redis 中的版本与期望版本不一致时返回 ErrVersionConflict
+func (am *AuthManager) persistRedis(client *redis.Client, username string, changed *UserAuth, expectedVersion int64, staleKeys []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	userKey := fmt.Sprintf("user:%s", username)
+	err := client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, userKey).Bytes()
+		switch {
+		case err == redis.Nil:
+		case err != nil:
+			return err
+		case expectedVersion == 0:
+			return ErrUserExists
+		default:
+			var stored UserAuth
+			if err := json.Unmarshal(data, &stored); err != nil {
+				return fmt.Errorf("failed to decode stored user %s: %v", username, err)
+			}
+			if stored.Version != expectedVersion {
+				return ErrVersionConflict
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range staleKeys {
+				pipe.Del(ctx, fmt.Sprintf("apikey:%s", key))
+			}
+			if changed == nil {
+				pipe.Del(ctx, userKey)
+				return nil
+			}
+			userData, err := json.Marshal(changed)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, userKey, userData, 0)
+			pipe.Set(ctx, fmt.Sprintf("apikey:%s", changed.APIKey), userData, 0)
+			return nil
+		})
+		return err
+	}, userKey)
+
+	if err == redis.TxFailedErr {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// audit 记录审计日志
+func (am *AuthManager) audit(actor, action, username string, err error) {
+	if err != nil {
+		am.logger.Log("WARN", fmt.Sprintf("AUDIT actor=%s action=%s user=%s result=failed: %v", actor, action, username, err))
+		return
+	}
+	am.logger.Log("INFO", fmt.Sprintf("AUDIT actor=%s action=%s user=%s result=ok", actor, action, username))
+}
+
+// snapshotUsers 复制当前用户列表
+func (am *AuthManager) snapshotUsers() []UserAuth {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	users := make([]UserAuth, len(am.config.Users))
+	copy(users, am.config.Users)
+	return users
+}
+
+// findUser 按用户名查找，不存在返回 -1
+func findUser(users []UserAuth, username string) int {
+	for i, user := range users {
+		if user.Username == username {
+			return i
+		}
+	}
+	return -1
+}
+
+// findAPIKey 按 API 密钥查找，不存在返回 -1
+func findAPIKey(users []UserAuth, apiKey string) int {
+	for i, user := range users {
+		if user.APIKey == apiKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// validatePermissions 检查权限是否合法
+func validatePermissions(permissions []Permission) error {
+	for _, p := range permissions {
+		switch p {
+		case PermissionSubmit, PermissionManage, PermissionMonitor:
+		default:
+			return fmt.Errorf("unknown permission: %s", p)
+		}
+	}
+	return nil
+}
+
+// generateAPIKey 生成随机 API 密钥
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %v", err)
+	}
+	return "osk_" + hex.EncodeToString(buf), nil
+}
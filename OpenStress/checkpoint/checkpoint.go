@@ -0,0 +1,180 @@
+// checkpoint.go
+// 检查点模块
+// 本文件负责定期把收集器计数器和任务池进度保存到磁盘，进程中途崩溃后，
+// 可以从最近一次检查点继续执行场景，并把结果合并写入同一个 JTL 文件。
+//
+// 技术实现细节：
+// 1. 检查点为 JSON 文件，先写入同目录下的临时文件再重命名，崩溃时不会留下写了一半的检查点。
+// 2. 任务池进度记录已结束任务的ID（pool.Progress），续跑时重新提交场景，已结束的任务会被任务池跳过。
+// 3. 场景结果在任务内同步写入 JTL，任务记录为已结束时其结果已经落盘；
+//    检查点之后才结束的任务会重新执行，因此续跑的结果可能比单次运行略多，但不会丢失。
+// 4. 场景正常结束并生成报告后应调用 Remove 删除检查点，避免下次续跑已经完成的场景。
+
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// DefaultInterval 默认的检查点保存间隔
+const DefaultInterval = 10 * time.Second
+
+// Checkpoint 一次运行的检查点
+type Checkpoint struct {
+	TaskID        string                   `json:"task_id"`        // 任务ID
+	JTLFilePath   string                   `json:"jtl_file_path"`  // 结果写入的 JTL 文件，续跑时继续追加
+	SavedAt       time.Time                `json:"saved_at"`       // 保存时间
+	Counters      result.CollectorCounters `json:"counters"`       // 收集器计数器
+	Pool          pool.PoolStats           `json:"pool"`           // 任务池计数器
+	FinishedTasks []string                 `json:"finished_tasks"` // 已结束任务的ID
+}
+
+// Save 原子地把检查点写入 path
+func Save(path string, cp *Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint file: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync checkpoint file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint file: %v", err)
+	}
+	return nil
+}
+
+// Load 读取 path 中的检查点
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file: %v", err)
+	}
+	if cp.JTLFilePath == "" {
+		return nil, fmt.Errorf("checkpoint %s has no JTL file path", path)
+	}
+	return &cp, nil
+}
+
+// Remove 删除检查点文件，文件不存在时不报错
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint file: %v", err)
+	}
+	return nil
+}
+
+// Writer 定期保存检查点
+type Writer struct {
+	path      string
+	taskID    string
+	collector *result.Collector
+	pool      *pool.Pool
+	progress  *pool.Progress
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// Start 开启任务池的进度记录，并每隔 interval 把检查点保存到 path。
+// interval 不大于 0 时使用 DefaultInterval；progress 为续跑时恢复的进度，新运行传入 nil
+func Start(path, taskID string, interval time.Duration, collector *result.Collector, p *pool.Pool, progress *pool.Progress) *Writer {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if progress == nil {
+		progress = pool.NewProgress(nil)
+	}
+	p.SetProgress(progress)
+
+	w := &Writer{
+		path:      path,
+		taskID:    taskID,
+		collector: collector,
+		pool:      p,
+		progress:  progress,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go w.run(interval)
+	return w
+}
+
+// run 定期保存检查点，直到 Stop 被调用
+func (w *Writer) run(interval time.Duration) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			// 保存失败不影响压测，记录日志后在下一次保存时重试
+			if err := w.Save(); err != nil {
+				if logger, logErr := pool.GetModuleLogger("Checkpoint"); logErr == nil {
+					logger.Log("ERROR", err.Error())
+				}
+			}
+		}
+	}
+}
+
+// Save 立即保存一次检查点
+func (w *Writer) Save() error {
+	return Save(w.path, w.snapshot())
+}
+
+// snapshot 生成当前的检查点。先记录任务进度再读取计数器（读取前等待此前提交的结果写入），
+// 已结束任务的结果一定已经计入计数器
+func (w *Writer) snapshot() *Checkpoint {
+	finished := w.progress.TaskIDs()
+	return &Checkpoint{
+		TaskID:        w.taskID,
+		JTLFilePath:   w.collector.JTLFilePath(),
+		SavedAt:       time.Now(),
+		Counters:      w.collector.Counters(),
+		Pool:          w.pool.Stats(),
+		FinishedTasks: finished,
+	}
+}
+
+// Stop 停止定期保存并保存最后一次检查点。多次调用是安全的
+func (w *Writer) Stop() error {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		w.stopErr = w.Save()
+	})
+	return w.stopErr
+}
@@ -21,6 +21,7 @@
 // 2. 多源加载功能：支持从多种来源加载配置，例如环境变量、命令行参数和默认值。
 //    - 实现一个优先级机制，允许用户自定义配置来源的优先级。
 //    - 提供方法来解析和合并来自不同来源的配置。
+//    - 统一配置文件 openstress.yaml 与环境变量覆盖见 file.go，配置来源的优先级与生效配置的输出见 sources.go。
 //    - 在加载配置时，确保所有来源的配置都经过验证，并记录加载过程中的任何错误。
 // 3. 配置验证机制：在加载和更新配置时，确保配置的有效性。
 //    - 实现配置验证逻辑，检查配置项是否符合预期。
@@ -28,6 +29,7 @@
 // 4. 动态配置更新：允许在运行时修改配置，并立即生效。
 //    - 实现配置更新逻辑，确保配置更新后能够立即生效。
 //    - 提供通知机制，告知系统其他部分配置已被更新。
+//    - 运行时配置（RuntimeConfig）的热加载和变更通知见 watcher.go。
 // 5. 日志记录功能：记录配置加载和更新的操作。
 //    - 实现日志记录逻辑，记录配置加载和更新的详细信息。
 // 6. 全局配置：实现一个全局配置用于控制服务启动时是否启动与api相关的接口监听功能。
@@ -36,20 +38,237 @@
 
 package config
 
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
 // Config 结构体用于存储全局配置
 // 该结构体包含控制服务启动时的配置选项
 // - EnableAPIServer: 控制是否启动 API 接口监听功能
+// - APIAddr: API 接口监听地址
+// - AuthConfigPath: API 认证配置文件路径，为空时不启用认证
+// - TaskHTTPConfigPath: 任务 HTTP 客户端配置文件路径（请求签名等），为空时使用默认客户端
+// - TestPlanPaths: HTTP 测试计划文件（例如由 HAR 导入），API 服务启动时注册为同名任务
+// - Thresholds: 压测结束后判定的阈值规则，未通过时进程以非零退出码结束
+// - TracingEndpoint: OTLP/HTTP 链路追踪接收地址，为空时不启用链路追踪
+// - TracingSampleRatio: 链路追踪采样比例
+// - NotificationConfigPath: 压测完成通知（Webhook/Slack/钉钉）配置文件路径，为空时不发送通知
+// - CheckpointPath / Resume: 检查点文件路径，以及是否从检查点继续中断的压测
+// - Log: 日志输出配置（控制台/文件、编码格式、各输出的最低级别）
+// - Seed: 全局随机种子，用于复现随机思考时间、数据打乱和负载生成
+// - RuntimeConfigPath: 运行时配置文件路径，文件变化时热加载日志级别、并发数和限流（见 watcher.go）
+// - Pool / Collector / Report: 协程池、结果收集和 HTML 报告配置
+// - AuthRedis: 认证使用的 Redis 连接，Addr 为空时只使用本地认证配置文件
+// - Publish: 报告发布配置，报告生成后上传到 S3 / MinIO，通知中使用上传后的链接（见 publish 包）
+// - RedactPatterns: 在默认敏感词之外追加的敏感字段名，这些字段的值不会写入日志、结果文件和报告（见 redact 包）
+// 以上配置均可以通过统一配置文件 openstress.yaml 和环境变量设置（见 file.go）
+// 配置优先级从低到高为：默认值 < 配置文件 < 环境变量（OPENSTRESS_*）< 命令行参数，每项的来源见 DumpEffectiveConfig
 // - OtherConfig: 其他相关配置
 
 type Config struct {
-	EnableAPIServer bool // 是否启用 API 接口监听功能
+	EnableAPIServer bool   // 是否启用 API 接口监听功能
+	APIAddr         string // API 接口监听地址
+	AuthConfigPath  string // API 认证配置文件路径，为空时不启用认证
+
+	TaskHTTPConfigPath string   // 任务 HTTP 客户端配置文件路径（YAML，可配置请求签名），为空时使用默认客户端
+	TestPlanPaths      []string // HTTP 测试计划文件（YAML，见 tasks.TestPlan），API 服务启动时注册为以计划名称命名的任务
+
+	Thresholds []string // 阈值规则（如 "p95 < 800ms"），未通过时进程以 result.ExitCodeThresholdsFailed 退出
+
+	TracingEndpoint    string  // OTLP/HTTP 链路追踪接收地址（如 "http://localhost:4318"），为空时不启用
+	TracingSampleRatio float64 // 链路追踪采样比例（0~1），小于等于 0 时全部采样
+
+	NotificationConfigPath string // 压测完成通知配置文件路径（YAML），为空时不发送通知
+
+	CheckpointPath string // 检查点文件路径，压测过程中定期保存计数器和任务进度，为空时不保存
+	Resume         bool   // 是否从检查点继续中断的压测，结果合并写入同一个 JTL 文件
+
+	Seed int64 // 全局随机种子，0 表示每次运行随机生成；实际使用的种子写入报告，可用于复现
+
+	RuntimeConfigPath string // 运行时配置文件路径（YAML），文件变化时热加载，为空时不监听
+
+	Pool      PoolConfig      // 协程池配置
+	Collector CollectorConfig // 结果收集配置
+	Report    ReportConfig    // HTML 报告配置
+	AuthRedis RedisConfig     // 认证使用的 Redis 连接
+
+	Publish PublishConfig // 报告发布配置，未配置时不上传报告
+
+	RedactPatterns []string // 追加的敏感字段名（不区分大小写的子串），例如 "x-session"
+
+	Log LogConfig // 日志输出配置
 	// 其他配置项...
+
+	configFile string                  // 加载的统一配置文件路径
+	sources    map[string]ConfigSource // 配置项的来源，见 sources.go
+}
+
+// LogConfig 日志输出配置，文件和控制台两个输出各自有编码格式和最低级别
+type LogConfig struct {
+	FileEnabled bool   // 是否写入日志文件
+	FileEncoder string // 文件编码格式："json" 或 "console"
+	FileLevel   string // 文件输出的最低级别：DEBUG、INFO、WARN、ERROR
+
+	ConsoleEnabled bool   // 是否输出到控制台（标准输出），适合交互式运行
+	ConsoleEncoder string // 控制台编码格式："console"（可读格式）或 "json"
+	ConsoleLevel   string // 控制台输出的最低级别
+	ConsoleColor   bool   // 控制台可读格式下是否用颜色区分级别
+
+	RemoteConfigPath string // 远程日志输出（Loki/Kafka）配置文件路径（YAML），为空时不启用
+}
+
+// RemoteLogConfig 远程日志输出配置，分布式部署的压测节点把日志集中发送到 Loki 或 Kafka
+type RemoteLogConfig struct {
+	Loki  *LokiConfig  `yaml:"loki"`  // Loki 输出，为空时不启用
+	Kafka *KafkaConfig `yaml:"kafka"` // Kafka 输出，为空时不启用
+
+	Level         string        `yaml:"level"`          // 远程输出的最低级别，默认 INFO
+	BufferSize    int           `yaml:"buffer_size"`    // 本地缓冲的最大日志条数，超过后丢弃最早的日志，默认 10000
+	BatchSize     int           `yaml:"batch_size"`     // 每次发送的最大日志条数，默认 100
+	FlushInterval time.Duration `yaml:"flush_interval"` // 发送间隔，默认 1s
+	MaxRetries    int           `yaml:"max_retries"`    // 发送失败时的重试次数，默认 3，小于 0 表示不重试
+	RetryBackoff  time.Duration `yaml:"retry_backoff"`  // 首次重试等待时间，之后每次翻倍，默认 200ms
+}
+
+// LokiConfig Loki 推送配置
+type LokiConfig struct {
+	URL      string            `yaml:"url"`       // Loki 地址，例如 "http://loki:3100"
+	TenantID string            `yaml:"tenant_id"` // 多租户时的 X-Scope-OrgID
+	Labels   map[string]string `yaml:"labels"`    // 附加的流标签，默认包含 job=openstress 和 host=主机名
+}
+
+// KafkaConfig Kafka 生产者配置
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"` // Broker 地址列表
+	Topic   string   `yaml:"topic"`   // 日志写入的主题
+}
+
+// LoadRemoteLogConfig 从 YAML 文件加载远程日志输出配置
+func LoadRemoteLogConfig(path string) (RemoteLogConfig, error) {
+	var cfg RemoteLogConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read remote log config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse remote log config: %v", err)
+	}
+	if cfg.Loki != nil && cfg.Loki.URL == "" {
+		return cfg, fmt.Errorf("loki url is required")
+	}
+	if cfg.Kafka != nil && (len(cfg.Kafka.Brokers) == 0 || cfg.Kafka.Topic == "") {
+		return cfg, fmt.Errorf("kafka brokers and topic are required")
+	}
+	return cfg, nil
+}
+
+// NotificationConfig 压测完成通知配置，压测结束或阈值未通过时向 Webhook 发送摘要
+type NotificationConfig struct {
+	Webhooks []WebhookConfig `yaml:"webhooks"` // 通知目标
+
+	ReportBaseURL string        `yaml:"report_base_url"` // 报告根目录对外发布的地址，为空时通知中给出本地报告路径
+	Timeout       time.Duration `yaml:"timeout"`         // 单次请求超时，默认 10s
+	MaxRetries    int           `yaml:"max_retries"`     // 发送失败时的重试次数，默认 3，小于 0 表示不重试
+	RetryBackoff  time.Duration `yaml:"retry_backoff"`   // 首次重试等待时间，之后每次翻倍，默认 500ms
+}
+
+// WebhookConfig 一个通知目标
+type WebhookConfig struct {
+	Name     string            `yaml:"name"`     // 名称，用于日志和错误信息
+	URL      string            `yaml:"url"`      // Webhook 地址
+	Format   string            `yaml:"format"`   // 消息格式："json"（默认）、"slack" 或 "dingtalk"
+	Events   []string          `yaml:"events"`   // 触发事件："completed"、"threshold_failed"，为空时两者都发送
+	Template string            `yaml:"template"` // 消息文本模板（text/template），为空时使用默认模板
+	Secret   string            `yaml:"secret"`   // 钉钉机器人加签密钥，为空时不加签
+	Headers  map[string]string `yaml:"headers"`  // 附加的请求头
+}
+
+// LoadNotificationConfig 从 YAML 文件加载压测完成通知配置
+func LoadNotificationConfig(path string) (NotificationConfig, error) {
+	var cfg NotificationConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read notification config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse notification config: %v", err)
+	}
+	for i, webhook := range cfg.Webhooks {
+		if webhook.URL == "" {
+			return cfg, fmt.Errorf("webhook %d (%s): url is required", i, webhook.Name)
+		}
+	}
+	return cfg, nil
+}
+
+// ElasticsearchConfig Elasticsearch 结果索引配置，每条结果作为一个文档批量写入，便于压测结束后在 Kibana 中按任意维度分析
+type ElasticsearchConfig struct {
+	URL      string            `yaml:"url"`      // Elasticsearch 地址，例如 http://localhost:9200，为空时不启用
+	Index    string            `yaml:"index"`    // 索引名，默认 openstress-results
+	Username string            `yaml:"username"` // Basic 认证用户名
+	Password string            `yaml:"password"` // Basic 认证密码
+	APIKey   string            `yaml:"api_key"`  // API Key（Base64 编码的 id:api_key），设置后不使用 Basic 认证
+	Labels   map[string]string `yaml:"labels"`   // 附加到每个文档的标签，例如 env、build
+
+	BufferSize    int           `yaml:"buffer_size"`    // 本地缓冲的最大文档数，超过后丢弃最早的文档，默认 100000
+	BatchSize     int           `yaml:"batch_size"`     // 每次批量写入的最大文档数，默认 500
+	FlushInterval time.Duration `yaml:"flush_interval"` // 写入间隔，默认 1s
+	MaxRetries    int           `yaml:"max_retries"`    // 写入失败时的重试次数，默认 3，小于 0 表示不重试
+	RetryBackoff  time.Duration `yaml:"retry_backoff"`  // 首次重试等待时间，之后每次翻倍，默认 200ms
+}
+
+// PublishConfig 报告发布配置，报告生成后上传到对象存储，通知中使用上传后的链接
+type PublishConfig struct {
+	S3 S3Config `yaml:"s3"` // S3 兼容对象存储，Bucket 为空时不上传
+}
+
+// S3Config S3 兼容对象存储（AWS S3、MinIO 等）的上传配置
+type S3Config struct {
+	Endpoint        string        `yaml:"endpoint"`          // 服务地址，例如 http://minio:9000，为空时按 Region 使用 AWS S3 的地址
+	Region          string        `yaml:"region"`            // 区域，默认 us-east-1
+	Bucket          string        `yaml:"bucket"`            // 存储桶
+	Prefix          string        `yaml:"prefix"`            // 对象键前缀，例如 openstress/reports
+	AccessKeyID     string        `yaml:"access_key_id"`     // 访问密钥，建议通过环境变量 OPENSTRESS_PUBLISH_S3_ACCESS_KEY_ID 设置
+	SecretAccessKey string        `yaml:"secret_access_key"` // 私有访问密钥，建议通过环境变量 OPENSTRESS_PUBLISH_S3_SECRET_ACCESS_KEY 设置
+	PathStyle       bool          `yaml:"path_style"`        // 使用路径形式的地址（endpoint/bucket/key），MinIO 通常需要开启
+	PublicURL       string        `yaml:"public_url"`        // 对外访问的根地址（例如 CDN），报告链接为 public_url/<对象键>
+	PresignExpiry   time.Duration `yaml:"presign_expiry"`    // 未配置 public_url 时生成预签名链接的有效期，0 表示使用对象地址，最长 7 天
+	Timeout         time.Duration `yaml:"timeout"`           // 单个文件的上传超时，默认 30s
+}
+
+// DefaultLogConfig 返回默认日志配置：JSON 格式写文件，不输出到控制台
+func DefaultLogConfig() LogConfig {
+	return LogConfig{
+		FileEnabled:    true,
+		FileEncoder:    "json",
+		FileLevel:      "INFO",
+		ConsoleEnabled: false,
+		ConsoleEncoder: "console",
+		ConsoleLevel:   "INFO",
+		ConsoleColor:   true,
+	}
 }
 
 // NewConfig 创建一个新的配置实例
 func NewConfig() *Config {
 	return &Config{
-		EnableAPIServer: true, // 默认启用 API 接口监听功能
+		EnableAPIServer: false,   // 默认不启用 API 接口监听功能，通过命令行参数 -api 启用
+		APIAddr:         ":8080", // 默认监听 8080 端口
+		AuthConfigPath:  "config/auth.yaml",
+		CheckpointPath:  "path/to/jtl/testTask.checkpoint.json",
+		Log:             DefaultLogConfig(),
+		Pool:            PoolConfig{Workers: 10},
+		Collector: CollectorConfig{
+			JTLFilePath:   "./results/api.jtl",
+			TaskID:        "api",
+			OutputFormat:  "jtl",
+			BatchSize:     10,
+			NumGoroutines: 2,
+		},
 	}
 }
 
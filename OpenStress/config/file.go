@@ -0,0 +1,387 @@
+// file.go
+// 统一配置文件模块
+// 本文件负责统一配置文件 openstress.yaml 的结构定义和加载：协程池（pool）、结果收集（collector）、
+// 报告（report）、API 服务（api）、认证（auth）、链路追踪（tracing）、报告发布（publish）和敏感信息隐藏（redact）的配置集中在一个文件中，
+// 加载后写入 Config，由 main 分发给各个模块。
+//
+// 技术实现细节：
+// 1. 配置的优先级从低到高为：NewConfig 的默认值、配置文件、环境变量、命令行参数（由 main 处理，见 SourcePrecedence）。
+//    配置文件中未出现的配置项保持默认值，因此文件只需要写出需要修改的部分；每个配置项的实际来源见 sources.go。
+// 2. 环境变量按 OPENSTRESS_<段>_<配置项> 命名（均为大写），例如 OPENSTRESS_POOL_WORKERS、
+//    OPENSTRESS_AUTH_REDIS_PASSWORD，适合在容器中注入密码等敏感配置；列表类型以逗号分隔，映射类型为逗号分隔的 k=v，
+//    时间使用 Go 时长格式（如 5s）。
+// 3. 配置文件中的未知配置项视为错误（通常是拼写错误），加载完成后统一校验（Config.Validate）。
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultConfigPath 默认的统一配置文件路径，文件存在时自动加载
+const DefaultConfigPath = "openstress.yaml"
+
+// EnvPrefix 覆盖配置文件的环境变量前缀
+const EnvPrefix = "OPENSTRESS_"
+
+// PoolConfig 协程池配置
+type PoolConfig struct {
+	Workers               int           `yaml:"workers"`                 // 最大并发数
+	RateLimit             float64       `yaml:"rate_limit"`              // 全局限流（每秒任务数），0 表示不限流
+	RateBurst             int           `yaml:"rate_burst"`              // 全局限流的突发容量
+	PriorityAging         time.Duration `yaml:"priority_aging"`          // 排队任务提升一级优先级的等待时间，0 表示使用协程池默认值
+	FinishedTaskRetention int           `yaml:"finished_task_retention"` // 保留可查询状态的已完成任务数，0 表示使用协程池默认值
+}
+
+// CollectorConfig 结果收集配置，对应 result.CollectorConfig 中与输出文件相关的部分
+type CollectorConfig struct {
+	JTLFilePath       string        `yaml:"jtl_file_path"`       // JTL 文件路径
+	TaskID            string        `yaml:"task_id"`             // 任务ID，用于生成唯一的文件名
+	OutputFormat      string        `yaml:"output_format"`       // 输出格式
+	BatchSize         int           `yaml:"batch_size"`          // 每次批量写入的记录数
+	NumGoroutines     int           `yaml:"num_goroutines"`      // 处理结果的协程数
+	JTLCompress       bool          `yaml:"jtl_compress"`        // 以 gzip 压缩写入 JTL 文件
+	JTLRotateSize     int64         `yaml:"jtl_rotate_size"`     // 单个 JTL 分段的最大字节数，0 表示不按大小轮转
+	JTLRotateInterval time.Duration `yaml:"jtl_rotate_interval"` // 单个 JTL 分段的最长写入时间，0 表示不按时间轮转
+	FailureBodyBytes  int           `yaml:"failure_body_bytes"`  // 失败请求保存的响应体最大字节数，0 表示不保存
+
+	// 成功判定策略，见 result.SuccessPolicy
+	SuccessStatusCodes []string          `yaml:"success_status_codes"` // 判定为成功的状态码（如 2xx、404），为空时 2xx 和 3xx 为成功
+	SuccessEndpoints   map[string]string `yaml:"success_endpoints"`    // URL 子串 -> 该接口的成功状态码，多个状态码以空格或 | 分隔
+	RequireAssertions  bool              `yaml:"require_assertions"`   // 任一内容断言失败时判定为失败
+
+	// 内存中结果的保留策略，见 result.ParseResultRetention
+	ResultRetention      string `yaml:"result_retention"`       // all（默认）、last、aggregates 或 disk
+	ResultRetentionLimit int    `yaml:"result_retention_limit"` // last 策略保留的最近结果数
+
+	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch"` // 结果写入 Elasticsearch，URL 为空时不启用
+
+	Scrub ScrubConfig `yaml:"scrub"` // 结果脱敏，针对含真实数据的环境
+}
+
+// ScrubConfig 结果脱敏配置，结果在写入 JTL 文件、Elasticsearch 和报告之前处理（见 result/scrub.go）。
+// 与 redact 包按字段名隐藏密钥不同，脱敏针对的是 URL 和消息中可能包含的用户数据
+type ScrubConfig struct {
+	StripQuery      bool     `yaml:"strip_query"`      // 去掉 URL 的查询参数和片段
+	MaskIdentifiers bool     `yaml:"mask_identifiers"` // 把 URL 和错误信息中的用户标识（邮箱、UUID、URL 路径中的数字 ID）替换为占位符
+	DropMessages    bool     `yaml:"drop_messages"`    // 不保存响应消息和失败响应体
+	Identifiers     []string `yaml:"identifiers"`      // 额外的用户标识（正则表达式），启用 mask_identifiers 时替换为 {id}
+}
+
+// ReportConfig HTML 报告配置
+type ReportConfig struct {
+	Dir                  string        `yaml:"dir"`                   // 报告根目录，为空时使用结果收集器的默认目录
+	Language             string        `yaml:"language"`              // 报告语言（zh-CN 或 en-US）
+	SelfContained        bool          `yaml:"self_contained"`        // 生成单个自包含的 HTML 文件
+	NamePattern          string        `yaml:"name_pattern"`          // 报告目录和文件的命名规则，支持 {name}、{time}、{task}
+	Template             string        `yaml:"template"`              // 自定义报告模板文件
+	IntermediateInterval time.Duration `yaml:"intermediate_interval"` // 阶段报告生成间隔，0 表示不生成
+	JUnitPath            string        `yaml:"junit_path"`            // 阈值判定结果的 JUnit XML 文件，供 CI 展示，为空时不生成
+
+	History HistoryConfig `yaml:"history"` // 历史运行趋势与回归检测，Dir 为空时不启用
+}
+
+// HistoryConfig 历史运行趋势配置：每次运行的关键指标按场景记录到 Dir 中，
+// 与最近若干次运行的平均值（滚动基线）比较，偏差超过容忍度时判定为回归（见 result/trend.go）
+type HistoryConfig struct {
+	Dir                string  `yaml:"dir"`                  // 趋势数据目录，每个场景一个文件，为空时不记录
+	Baseline           int     `yaml:"baseline"`             // 滚动基线包含的最近运行数，0 表示使用默认值 5
+	Tolerance          float64 `yaml:"tolerance"`            // 响应时间和 TPS 相对基线的最大偏差（百分比），0 表示使用默认值 10
+	ErrorRateTolerance float64 `yaml:"error_rate_tolerance"` // 错误率相对基线的最大上升（百分点），0 表示使用默认值 1
+}
+
+// RedisConfig 认证使用的 Redis 连接配置，Addr 为空时只使用本地认证配置文件
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// FileConfig 统一配置文件 openstress.yaml 的结构（见 openstress.example.yaml）
+type FileConfig struct {
+	Pool      poolSection      `yaml:"pool"`
+	Collector collectorSection `yaml:"collector"`
+	Report    reportSection    `yaml:"report"`
+	API       apiSection       `yaml:"api"`
+	Auth      authSection      `yaml:"auth"`
+	Tracing   tracingSection   `yaml:"tracing"`
+	Publish   PublishConfig    `yaml:"publish"`
+	Redact    redactSection    `yaml:"redact"`
+}
+
+// poolSection 配置文件的 pool 段
+type poolSection struct {
+	PoolConfig    `yaml:",inline"`
+	RuntimeConfig string `yaml:"runtime_config"` // 热加载的运行时配置文件，见 Config.RuntimeConfigPath
+}
+
+// collectorSection 配置文件的 collector 段
+type collectorSection struct {
+	CollectorConfig `yaml:",inline"`
+	CheckpointPath  string `yaml:"checkpoint_path"` // 检查点文件，见 Config.CheckpointPath
+}
+
+// reportSection 配置文件的 report 段
+type reportSection struct {
+	ReportConfig       `yaml:",inline"`
+	Thresholds         []string `yaml:"thresholds"`          // 阈值规则，见 Config.Thresholds
+	NotificationConfig string   `yaml:"notification_config"` // 压测完成通知配置文件，见 Config.NotificationConfigPath
+}
+
+// apiSection 配置文件的 api 段
+type apiSection struct {
+	Enabled        bool     `yaml:"enabled"`
+	Addr           string   `yaml:"addr"`
+	TaskHTTPConfig string   `yaml:"task_http_config"` // 任务 HTTP 客户端配置文件，见 Config.TaskHTTPConfigPath
+	TestPlans      []string `yaml:"test_plans"`       // HTTP 测试计划文件，见 Config.TestPlanPaths
+}
+
+// authSection 配置文件的 auth 段
+type authSection struct {
+	ConfigPath string      `yaml:"config_path"` // 认证配置文件（用户和 API Key），为空时不启用认证
+	Redis      RedisConfig `yaml:"redis"`
+}
+
+// tracingSection 配置文件的 tracing 段
+type tracingSection struct {
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// redactSection 配置文件的 redact 段
+type redactSection struct {
+	Patterns []string `yaml:"patterns"` // 追加的敏感字段名，见 Config.RedactPatterns
+}
+
+// fileConfigFrom 以 cfg 的当前值构建配置文件结构，未出现在文件中的配置项保持这些值
+func fileConfigFrom(cfg *Config) FileConfig {
+	return FileConfig{
+		Pool:      poolSection{PoolConfig: cfg.Pool, RuntimeConfig: cfg.RuntimeConfigPath},
+		Collector: collectorSection{CollectorConfig: cfg.Collector, CheckpointPath: cfg.CheckpointPath},
+		Report:    reportSection{ReportConfig: cfg.Report, Thresholds: cfg.Thresholds, NotificationConfig: cfg.NotificationConfigPath},
+		API:       apiSection{Enabled: cfg.EnableAPIServer, Addr: cfg.APIAddr, TaskHTTPConfig: cfg.TaskHTTPConfigPath, TestPlans: cfg.TestPlanPaths},
+		Auth:      authSection{ConfigPath: cfg.AuthConfigPath, Redis: cfg.AuthRedis},
+		Tracing:   tracingSection{Endpoint: cfg.TracingEndpoint, SampleRatio: cfg.TracingSampleRatio},
+		Publish:   cfg.Publish,
+		Redact:    redactSection{Patterns: cfg.RedactPatterns},
+	}
+}
+
+// applyTo 将配置文件结构写回 cfg
+func (f FileConfig) applyTo(cfg *Config) {
+	cfg.Pool, cfg.RuntimeConfigPath = f.Pool.PoolConfig, f.Pool.RuntimeConfig
+	cfg.Collector, cfg.CheckpointPath = f.Collector.CollectorConfig, f.Collector.CheckpointPath
+	cfg.Report, cfg.Thresholds, cfg.NotificationConfigPath = f.Report.ReportConfig, f.Report.Thresholds, f.Report.NotificationConfig
+	cfg.EnableAPIServer, cfg.APIAddr, cfg.TaskHTTPConfigPath = f.API.Enabled, f.API.Addr, f.API.TaskHTTPConfig
+	cfg.TestPlanPaths = f.API.TestPlans
+	cfg.AuthConfigPath, cfg.AuthRedis = f.Auth.ConfigPath, f.Auth.Redis
+	cfg.TracingEndpoint, cfg.TracingSampleRatio = f.Tracing.Endpoint, f.Tracing.SampleRatio
+	cfg.Publish = f.Publish
+	cfg.RedactPatterns = f.Redact.Patterns
+}
+
+// LoadConfig 在默认配置的基础上加载统一配置文件并应用环境变量覆盖，path 为空时只应用环境变量。
+// 每个配置项的来源记录在返回的 Config 中（见 Config.Source 和 DumpEffectiveConfig）
+func LoadConfig(path string) (*Config, error) {
+	cfg := NewConfig()
+	cfg.sources = make(map[string]ConfigSource)
+	file := fileConfigFrom(cfg)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+		var node interface{}
+		if err := yaml.Unmarshal(data, &node); err == nil {
+			markFileKeys(node, "", cfg.sources)
+		}
+		cfg.configFile = path
+	}
+	if err := applyEnvOverrides(&file, cfg.sources); err != nil {
+		return nil, err
+	}
+	file.applyTo(cfg)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate 检查配置的有效性，报告语言、阈值规则等由对应模块在创建时校验
+func (c *Config) Validate() error {
+	if c.Pool.Workers <= 0 {
+		return fmt.Errorf("pool.workers must be positive: %d", c.Pool.Workers)
+	}
+	if c.Pool.RateLimit < 0 || c.Pool.RateBurst < 0 {
+		return fmt.Errorf("pool.rate_limit and pool.rate_burst must not be negative")
+	}
+	if c.Pool.FinishedTaskRetention < 0 {
+		return fmt.Errorf("pool.finished_task_retention must not be negative: %d", c.Pool.FinishedTaskRetention)
+	}
+	if c.Collector.JTLFilePath == "" {
+		return fmt.Errorf("collector.jtl_file_path is required")
+	}
+	if c.Collector.BatchSize < 0 || c.Collector.NumGoroutines < 0 || c.Collector.JTLRotateSize < 0 || c.Collector.FailureBodyBytes < 0 || c.Collector.ResultRetentionLimit < 0 {
+		return fmt.Errorf("collector sizes must not be negative")
+	}
+	if es := c.Collector.Elasticsearch; es.URL != "" && (es.BufferSize < 0 || es.BatchSize < 0) {
+		return fmt.Errorf("collector.elasticsearch buffer_size and batch_size must not be negative")
+	}
+	if h := c.Report.History; h.Baseline < 0 || h.Tolerance < 0 || h.ErrorRateTolerance < 0 {
+		return fmt.Errorf("report.history baseline and tolerances must not be negative")
+	}
+	if c.EnableAPIServer && c.APIAddr == "" {
+		return fmt.Errorf("api.addr is required when the API server is enabled")
+	}
+	if c.AuthRedis.DB < 0 {
+		return fmt.Errorf("auth.redis.db must not be negative: %d", c.AuthRedis.DB)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1: %g", c.TracingSampleRatio)
+	}
+	if s3 := c.Publish.S3; s3.Bucket != "" {
+		if (s3.AccessKeyID == "") != (s3.SecretAccessKey == "") {
+			return fmt.Errorf("publish.s3.access_key_id and publish.s3.secret_access_key must be set together")
+		}
+		if s3.PresignExpiry < 0 || s3.PresignExpiry > 7*24*time.Hour {
+			return fmt.Errorf("publish.s3.presign_expiry must be between 0 and 7 days: %v", s3.PresignExpiry)
+		}
+	}
+	return nil
+}
+
+// walkFileConfig 按 yaml 标签递归遍历配置文件结构中的配置项，key 为以点分隔的路径（如 pool.workers），
+// inline 的结构体与外层使用同一路径
+func walkFileConfig(v reflect.Value, prefix string, fn func(key string, value reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		key := prefix
+		if tag[0] != "" {
+			if key != "" {
+				key += "."
+			}
+			key += tag[0]
+		}
+		value := v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := walkFileConfig(value, key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envName 返回配置项对应的环境变量名，例如 pool.workers 对应 OPENSTRESS_POOL_WORKERS
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnvOverrides 用环境变量覆盖配置文件结构中的配置项，并记录配置项的来源
+func applyEnvOverrides(file *FileConfig, sources map[string]ConfigSource) error {
+	return walkFileConfig(reflect.ValueOf(file).Elem(), "", func(key string, value reflect.Value) error {
+		name := envName(key)
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := setFromEnv(value, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+		sources[key] = SourceEnv
+		return nil
+	})
+}
+
+// markFileKeys 记录配置文件中出现的配置项
+func markFileKeys(node interface{}, prefix string, sources map[string]ConfigSource) {
+	section, ok := node.(map[interface{}]interface{})
+	if !ok {
+		if prefix != "" {
+			sources[prefix] = SourceFile
+		}
+		return
+	}
+	for name, child := range section {
+		key := fmt.Sprint(name)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		markFileKeys(child, key, sources)
+	}
+}
+
+// setFromEnv 将环境变量的字符串值转换为配置项的类型
+func setFromEnv(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && v.Type().Elem().Kind() == reflect.String:
+		items := reflect.MakeMap(v.Type())
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", item)
+			}
+			items.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), reflect.ValueOf(strings.TrimSpace(value)))
+		}
+		v.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
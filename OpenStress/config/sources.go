@@ -0,0 +1,91 @@
+// sources.go
+// 配置来源模块
+// 本文件负责记录每个配置项的来源（默认值、配置文件、环境变量或命令行参数），
+// 并输出最终生效的配置（DumpEffectiveConfig），用于排查"这个值是从哪里来的"一类问题。
+//
+// 技术实现细节：
+// 1. 多个来源设置同一配置项时，优先级高的来源生效，顺序见 SourcePrecedence；来源按配置项路径（如 pool.workers）记录。
+// 2. 配置文件和环境变量的来源由 LoadConfig 记录，命令行参数由 main 在解析后调用 Config.MarkSource 记录。
+// 3. 输出时隐藏敏感配置项（名称按 redact.IsSensitive 判断，例如密码）的值，避免密钥出现在日志中。
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/potatoImp/OpenStress/redact"
+)
+
+// ConfigSource 配置项的来源
+type ConfigSource string
+
+const (
+	SourceDefault ConfigSource = "default" // NewConfig 的默认值
+	SourceFile    ConfigSource = "file"    // 统一配置文件
+	SourceEnv     ConfigSource = "env"     // OPENSTRESS_* 环境变量
+	SourceFlag    ConfigSource = "flag"    // 命令行参数
+)
+
+// SourcePrecedence 配置来源的优先级，从低到高，后面的来源覆盖前面的来源
+var SourcePrecedence = []ConfigSource{SourceDefault, SourceFile, SourceEnv, SourceFlag}
+
+// 只能通过命令行参数设置、不在配置文件中的配置项
+const (
+	KeyResume = "resume"
+	KeySeed   = "seed"
+)
+
+// MarkSource 记录配置项的来源，key 为以点分隔的配置项路径，例如 api.addr
+func (c *Config) MarkSource(key string, source ConfigSource) {
+	if c.sources == nil {
+		c.sources = make(map[string]ConfigSource)
+	}
+	c.sources[key] = source
+}
+
+// Source 返回配置项的来源，未记录时为默认值
+func (c *Config) Source(key string) ConfigSource {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// DumpEffectiveConfig 返回最终生效的配置，每行一个配置项并注明来源，敏感配置项的值被隐藏
+func DumpEffectiveConfig(cfg *Config) string {
+	var b strings.Builder
+	precedence := make([]string, len(SourcePrecedence))
+	for i, source := range SourcePrecedence {
+		precedence[i] = string(source)
+	}
+	fmt.Fprintf(&b, "# effective config (precedence: %s)\n", strings.Join(precedence, " < "))
+	if cfg.configFile != "" {
+		fmt.Fprintf(&b, "# config file: %s\n", cfg.configFile)
+	}
+
+	file := fileConfigFrom(cfg)
+	walkFileConfig(reflect.ValueOf(&file).Elem(), "", func(key string, value reflect.Value) error {
+		writeEffectiveValue(&b, cfg, key, value.Interface())
+		return nil
+	})
+	writeEffectiveValue(&b, cfg, KeyResume, cfg.Resume)
+	writeEffectiveValue(&b, cfg, KeySeed, cfg.Seed)
+	return b.String()
+}
+
+// writeEffectiveValue 输出一个配置项及其来源，环境变量来源同时给出变量名
+func writeEffectiveValue(b *strings.Builder, cfg *Config, key string, value interface{}) {
+	if s, ok := value.(string); ok {
+		if s != "" && redact.IsSensitive(key) {
+			s = redact.Mask
+		}
+		value = fmt.Sprintf("%q", s)
+	}
+	source := string(cfg.Source(key))
+	if source == string(SourceEnv) {
+		source += " " + envName(key)
+	}
+	fmt.Fprintf(b, "%s: %v  # %s\n", key, value, source)
+}
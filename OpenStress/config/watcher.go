@@ -0,0 +1,338 @@
+// watcher.go
+// 配置热加载模块
+// 本文件负责运行时配置（RuntimeConfig）的加载、校验和热加载：监听配置文件的变化，
+// 校验通过后生效并通知订阅者，由订阅者（例如协程池，见 pool.ApplyConfigChanges）调整日志级别、并发数和限流。
+//
+// 技术实现细节：
+// 1. 使用 fsnotify 监听配置文件所在的目录而不是文件本身，编辑器先写临时文件再重命名的保存方式同样能被感知；
+//    短时间内的多次写入合并为一次加载（ConfigWatcher.Debounce，默认 DefaultReloadDebounce）。
+// 2. 加载或校验失败时保留之前的配置，并向订阅者发送带 Err 的事件，运行中的压测不受错误配置影响。
+// 3. 每次成功加载配置版本号加 1，事件中包含新旧配置的差异（ConfigChange），配置没有变化时不发送事件。
+// 4. 订阅通过带缓冲的通道实现，通知不阻塞加载：订阅者的缓冲区已满时丢弃该事件，并在 ConfigEvent.Dropped 中累计丢弃数。
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultReloadDebounce 配置文件变化后等待合并后续写入的时间
+const DefaultReloadDebounce = 100 * time.Millisecond
+
+// 配置项名称，见 ConfigChange.Field
+const (
+	FieldLogLevel      = "log_level"
+	FieldWorkers       = "workers"
+	FieldRateLimit     = "rate_limit"
+	FieldTaskRateLimit = "task_rate_limits"
+)
+
+// RuntimeConfig 运行时可以动态调整的配置，保存在 YAML 文件中（见 runtime.example.yaml）
+type RuntimeConfig struct {
+	LogLevel       string                     `yaml:"log_level"`        // 日志级别：DEBUG、INFO、WARN、ERROR，为空时不调整
+	Workers        int                        `yaml:"workers"`          // 协程池最大并发数，0 表示不调整
+	RateLimit      RateLimitConfig            `yaml:"rate_limit"`       // 全局限流
+	TaskRateLimits map[string]RateLimitConfig `yaml:"task_rate_limits"` // 按任务类型限流，键为任务类型
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Rate  float64 `yaml:"rate"`  // 每秒任务数，0 表示不限流
+	Burst int     `yaml:"burst"` // 突发容量
+}
+
+// ConfigChange 一个配置项的变化，配置项被删除时 New 为零值
+type ConfigChange struct {
+	Field string      // 配置项名称：FieldLogLevel、FieldWorkers、FieldRateLimit 或 FieldTaskRateLimit
+	Task  string      // FieldTaskRateLimit 对应的任务类型
+	Old   interface{} // 之前的值
+	New   interface{} // 新的值
+}
+
+// String 返回配置变化的可读描述，用于日志
+func (c ConfigChange) String() string {
+	field := c.Field
+	if c.Task != "" {
+		field += "." + c.Task
+	}
+	return fmt.Sprintf("%s: %v -> %v", field, c.Old, c.New)
+}
+
+// ConfigEvent 配置变化通知
+type ConfigEvent struct {
+	Time    time.Time
+	Version int            // 生效配置的版本号，每次成功加载加 1
+	Config  RuntimeConfig  // 生效的配置，加载失败时为之前的配置
+	Changes []ConfigChange // 与之前配置的差异
+	Err     error          // 加载或校验失败的原因，此时配置未变化
+	Dropped int            // 上一次通知之后因缓冲区已满丢弃的事件数
+}
+
+// LoadRuntimeConfig 从 YAML 文件加载运行时配置并校验
+func LoadRuntimeConfig(path string) (RuntimeConfig, error) {
+	var cfg RuntimeConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read runtime config: %v", err)
+	}
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse runtime config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Validate 检查运行时配置的有效性
+func (c RuntimeConfig) Validate() error {
+	switch strings.ToUpper(c.LogLevel) {
+	case "", "DEBUG", "INFO", "WARN", "WARNING", "ERROR":
+	default:
+		return fmt.Errorf("invalid log level: %s", c.LogLevel)
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative: %d", c.Workers)
+	}
+	if err := c.RateLimit.validate(); err != nil {
+		return fmt.Errorf("rate_limit: %v", err)
+	}
+	for task, limit := range c.TaskRateLimits {
+		if task == "" {
+			return fmt.Errorf("task_rate_limits: task type is required")
+		}
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("task_rate_limits.%s: %v", task, err)
+		}
+	}
+	return nil
+}
+
+// validate 检查限流配置的有效性
+func (r RateLimitConfig) validate() error {
+	if r.Rate < 0 || r.Burst < 0 {
+		return fmt.Errorf("rate and burst must not be negative")
+	}
+	return nil
+}
+
+// DiffRuntimeConfig 比较两份运行时配置，按配置项顺序返回差异，按任务类型限流按任务类型排序
+func DiffRuntimeConfig(old, updated RuntimeConfig) []ConfigChange {
+	var changes []ConfigChange
+	if !strings.EqualFold(old.LogLevel, updated.LogLevel) {
+		changes = append(changes, ConfigChange{Field: FieldLogLevel, Old: old.LogLevel, New: updated.LogLevel})
+	}
+	if old.Workers != updated.Workers {
+		changes = append(changes, ConfigChange{Field: FieldWorkers, Old: old.Workers, New: updated.Workers})
+	}
+	if old.RateLimit != updated.RateLimit {
+		changes = append(changes, ConfigChange{Field: FieldRateLimit, Old: old.RateLimit, New: updated.RateLimit})
+	}
+
+	tasks := make([]string, 0, len(old.TaskRateLimits)+len(updated.TaskRateLimits))
+	for task := range old.TaskRateLimits {
+		tasks = append(tasks, task)
+	}
+	for task := range updated.TaskRateLimits {
+		if _, ok := old.TaskRateLimits[task]; !ok {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Strings(tasks)
+	for _, task := range tasks {
+		oldLimit, newLimit := old.TaskRateLimits[task], updated.TaskRateLimits[task]
+		if oldLimit != newLimit {
+			changes = append(changes, ConfigChange{Field: FieldTaskRateLimit, Task: task, Old: oldLimit, New: newLimit})
+		}
+	}
+	return changes
+}
+
+// configSubscriber 一个配置变化的订阅者
+type configSubscriber struct {
+	ch      chan ConfigEvent
+	dropped int
+}
+
+// ConfigWatcher 监听运行时配置文件，变化时重新加载并通知订阅者
+type ConfigWatcher struct {
+	Debounce time.Duration // 合并连续写入的等待时间，需在 Start 之前设置
+
+	path    string
+	watcher *fsnotify.Watcher
+
+	mu          sync.Mutex
+	current     RuntimeConfig
+	version     int
+	subscribers map[*configSubscriber]struct{}
+	closed      bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewConfigWatcher 加载运行时配置文件并创建监听器，初始配置的版本号为 1；调用 Start 后开始监听变化
+func NewConfigWatcher(path string) (*ConfigWatcher, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve runtime config path: %v", err)
+	}
+	cfg, err := LoadRuntimeConfig(absPath)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigWatcher{
+		Debounce:    DefaultReloadDebounce,
+		path:        absPath,
+		current:     cfg,
+		version:     1,
+		subscribers: make(map[*configSubscriber]struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Start 开始监听配置文件所在的目录
+func (w *ConfigWatcher) Start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %v", err)
+	}
+	if err := watcher.Add(filepath.Dir(w.path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch runtime config: %v", err)
+	}
+	w.watcher = watcher
+	w.wg.Add(1)
+	go w.run()
+	return nil
+}
+
+// Current 返回当前生效的配置及其版本号
+func (w *ConfigWatcher) Current() (RuntimeConfig, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current, w.version
+}
+
+// Subscribe 订阅配置变化，buffer 为缓冲的事件数。返回的函数取消订阅并关闭通道；监听器关闭时通道同样会被关闭
+func (w *ConfigWatcher) Subscribe(buffer int) (<-chan ConfigEvent, func()) {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	sub := &configSubscriber{ch: make(chan ConfigEvent, buffer)}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	w.subscribers[sub] = struct{}{}
+
+	unsubscribe := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.subscribers[sub]; ok {
+			delete(w.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, unsubscribe
+}
+
+// Reload 立即重新加载配置文件，配置变化或加载失败时通知订阅者，返回本次加载的结果
+func (w *ConfigWatcher) Reload() ConfigEvent {
+	cfg, err := LoadRuntimeConfig(w.path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	event := ConfigEvent{Time: time.Now(), Version: w.version, Config: w.current, Err: err}
+	if err == nil {
+		event.Changes = DiffRuntimeConfig(w.current, cfg)
+		if len(event.Changes) == 0 {
+			return event
+		}
+		w.version++
+		w.current = cfg
+		event.Version, event.Config = w.version, cfg
+	}
+	w.publish(event)
+	return event
+}
+
+// Close 停止监听并关闭所有订阅者的通道
+func (w *ConfigWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	for sub := range w.subscribers {
+		close(sub.ch)
+	}
+	w.subscribers = nil
+	w.mu.Unlock()
+
+	close(w.done)
+	var err error
+	if w.watcher != nil {
+		err = w.watcher.Close()
+	}
+	w.wg.Wait()
+	return err
+}
+
+// run 处理文件系统事件，配置文件变化后等待 Debounce 再重新加载
+func (w *ConfigWatcher) run() {
+	defer w.wg.Done()
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			timer.Reset(w.Debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.mu.Lock()
+			w.publish(ConfigEvent{Time: time.Now(), Version: w.version, Config: w.current, Err: fmt.Errorf("config watcher error: %v", err)})
+			w.mu.Unlock()
+		case <-timer.C:
+			w.Reload()
+		}
+	}
+}
+
+// publish 把事件发送给订阅者，调用方持有 w.mu
+func (w *ConfigWatcher) publish(event ConfigEvent) {
+	for sub := range w.subscribers {
+		event.Dropped = sub.dropped
+		select {
+		case sub.ch <- event:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
@@ -1,28 +1,92 @@
 package main
 
 import (
-	"OpenStress/pool"
-	// "time"
-
-	"OpenStress/tests"
+	"context"
+	"flag"
 	"fmt"
-	// "OpenStress/result"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/potatoImp/OpenStress/api"
+	"github.com/potatoImp/OpenStress/auth"
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/notify"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/publish"
+	"github.com/potatoImp/OpenStress/random"
+	"github.com/potatoImp/OpenStress/redact"
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/selftest"
+	"github.com/potatoImp/OpenStress/tasks"
+	"github.com/potatoImp/OpenStress/tests"
+	"github.com/potatoImp/OpenStress/tracing"
 )
 
 var logger *pool.StressLogger
 
+// dumpConfig 为 true 时输出最终生效的配置及其来源后退出
+var dumpConfig bool
+
+// flagConfigKeys 命令行参数对应的配置项路径，用于记录配置来源
+var flagConfigKeys = map[string]string{
+	"api":            "api.enabled",
+	"addr":           "api.addr",
+	"notify":         "report.notification_config",
+	"checkpoint":     "collector.checkpoint_path",
+	"resume":         config.KeyResume,
+	"seed":           config.KeySeed,
+	"runtime-config": "pool.runtime_config",
+	"junit":          "report.junit_path",
+}
+
 func main() {
+	// 子命令：openstress selftest、openstress har-import、openstress jmx-import、openstress openapi-import
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(runSelfTest(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "har-import" {
+		os.Exit(runHARImport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "jmx-import" {
+		os.Exit(runJMXImport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "openapi-import" {
+		os.Exit(runOpenAPIImport(os.Args[2:]))
+	}
+	// 阈值未通过时以非零退出码结束，供 CI 门禁使用
+	os.Exit(run())
+}
 
+// run 初始化日志与链路追踪后运行压测或 API 服务，返回进程退出码
+// 独立为函数是为了让 defer 的清理逻辑在 os.Exit 之前执行
+func run() int {
 	// 初始化日志记录器
 	logDir := "./logs/"
 	logFile := "app.log"
-	var err error
-	logger, err = pool.InitializeLogger(logDir, logFile, "MainModule")
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	// 在初始化日志之前设置敏感词，所有日志都经过隐藏处理
+	redact.SetPatterns(append(append([]string(nil), redact.DefaultPatterns...), cfg.RedactPatterns...))
+	if dumpConfig {
+		fmt.Print(config.DumpEffectiveConfig(cfg))
+		return 0
+	}
+	logger, err = pool.InitializeLoggerWithConfig(logDir, logFile, "MainModule", cfg.Log)
 	if err != nil {
 		fmt.Printf("Error initializing logger: %v\n", err)
-		return
+		return 1
 	}
 	defer logger.Close() // 确保在程序结束时关闭日志记录器
+	logger.Log("DEBUG", "Effective config:\n"+config.DumpEffectiveConfig(cfg))
+	random.SetSeed(cfg.Seed)
+	logger.Log("INFO", fmt.Sprintf("Random seed: %d", random.Seed()))
 	// // 创建一个新的任务池
 	// taskPool := pool.NewPool(5) // 假设最大工作线程数为 5
 	// defer taskPool.Shutdown()   // 确保在退出时优雅地关闭任务池
@@ -74,9 +138,65 @@ func main() {
 	// // 模拟错误处理并记录日志
 	// handleError(err)
 
+	// 启用 API 时以服务方式运行，否则执行 pool 模块测试方法
+	if cfg.TracingEndpoint != "" {
+		shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+			Endpoint:    cfg.TracingEndpoint,
+			SampleRatio: cfg.TracingSampleRatio,
+		})
+		if err != nil {
+			logger.Log("ERROR", "Failed to initialize tracing: "+err.Error())
+		} else {
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				shutdownTracing(ctx)
+			}()
+		}
+	}
+	// SIGINT/SIGTERM 时先停止负载，再写出已收集的结果和部分报告
+	shutdown := pool.NewShutdownManager()
+	shutdown.Watch()
+	defer shutdown.Stop()
+
+	if cfg.EnableAPIServer {
+		runAPIServer(cfg, shutdown)
+		return 0
+	}
+
+	// 压测完成通知
+	var notifier *notify.Notifier
+	if cfg.NotificationConfigPath != "" {
+		notifyCfg, err := config.LoadNotificationConfig(cfg.NotificationConfigPath)
+		if err == nil {
+			notifier, err = notify.NewNotifier(notifyCfg)
+		}
+		if err != nil {
+			logger.Log("ERROR", "Failed to load notification config: "+err.Error())
+			return 1
+		}
+	}
+
+	// 报告发布
+	publisher, err := publish.New(cfg.Publish)
+	if err != nil {
+		logger.Log("ERROR", "Failed to create report publisher: "+err.Error())
+		return 1
+	}
+
 	// pool 模块测试方法
 	// tests.TestTask_AD()
-	tests.TestTaskPool1()
+	return tests.TestTaskPool1(tests.TaskPoolOptions{
+		Thresholds:     cfg.Thresholds,
+		Notifier:       notifier,
+		Publisher:      publisher,
+		Elasticsearch:  cfg.Collector.Elasticsearch,
+		History:        cfg.Report.History,
+		JUnitPath:      cfg.Report.JUnitPath,
+		CheckpointPath: cfg.CheckpointPath,
+		Resume:         cfg.Resume,
+		Shutdown:       shutdown,
+	})
 
 	// // result 模块测试方法
 	// collectorConfig := result.CollectorConfig{
@@ -126,7 +246,419 @@ func main() {
 	// })
 
 	// collector.Close()
+}
+
+// registerFlags 注册命令行参数，参数值写入 cfg
+func registerFlags(fs *flag.FlagSet, cfg *config.Config, configPath *string) {
+	fs.StringVar(configPath, "config", "", "unified config file (YAML), "+config.DefaultConfigPath+" is used when it exists")
+	fs.BoolVar(&dumpConfig, "dump-config", false, "print the effective config and where each value comes from, then exit")
+	fs.BoolVar(&cfg.EnableAPIServer, "api", cfg.EnableAPIServer, "run as an API server instead of the built-in test scenario")
+	fs.StringVar(&cfg.APIAddr, "addr", cfg.APIAddr, "API server listen address")
+	fs.StringVar(&cfg.NotificationConfigPath, "notify", cfg.NotificationConfigPath, "notification config file (YAML) for test completion webhooks")
+	fs.StringVar(&cfg.CheckpointPath, "checkpoint", cfg.CheckpointPath, "checkpoint file for resuming an interrupted test run, empty to disable checkpoints")
+	fs.BoolVar(&cfg.Resume, "resume", cfg.Resume, "resume the built-in test scenario from the last checkpoint, appending to the same JTL file")
+	fs.Int64Var(&cfg.Seed, "seed", cfg.Seed, "global random seed for reproducible think times and generated data, 0 for a random seed")
+	fs.StringVar(&cfg.Report.JUnitPath, "junit", cfg.Report.JUnitPath, "write threshold results as JUnit XML to this file for CI test reports")
+	fs.StringVar(&cfg.RuntimeConfigPath, "runtime-config", cfg.RuntimeConfigPath, "runtime config file (YAML) watched in API server mode for log level, worker count and rate limit changes")
+}
+
+// loadConfig 依次应用默认值、统一配置文件、环境变量和命令行参数，命令行中显式设置的参数优先
+func loadConfig(args []string) (*config.Config, error) {
+	var configPath string
+	registerFlags(flag.CommandLine, config.NewConfig(), &configPath)
+	flag.Parse()
+
+	// 未指定配置文件时使用当前目录下的 openstress.yaml（如果存在）
+	if configPath == "" {
+		if _, err := os.Stat(config.DefaultConfigPath); err == nil {
+			configPath = config.DefaultConfigPath
+		}
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
 
+	// 再次解析命令行，只有显式设置的参数会覆盖配置文件和环境变量
+	overrides := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	registerFlags(overrides, cfg, &configPath)
+	if err := overrides.Parse(args); err != nil {
+		return nil, err
+	}
+	overrides.Visit(func(f *flag.Flag) {
+		if key, ok := flagConfigKeys[f.Name]; ok {
+			cfg.MarkSource(key, config.SourceFlag)
+		}
+	})
+	return cfg, cfg.Validate()
+}
+
+// runSelfTest 测量本机作为压测机的最大请求速率，并保存容量估计
+func runSelfTest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	duration := fs.Duration("duration", 5*time.Second, "self-benchmark duration")
+	concurrency := fs.Int("concurrency", 0, "concurrent clients (default: 8 x CPUs)")
+	rate := fs.Float64("rate", 0, "planned request rate to check against the capacity estimate")
+	out := fs.String("out", selftest.DefaultResultPath, "where to save the result")
+	fs.Parse(args)
+
+	result, err := selftest.Run(selftest.Options{Duration: *duration, Concurrency: *concurrency})
+	if err != nil {
+		fmt.Printf("Self-benchmark failed: %v\n", err)
+		return 1
+	}
+	fmt.Println(result)
+
+	if err := result.Save(*out); err != nil {
+		fmt.Printf("Failed to save result: %v\n", err)
+	}
+	if *rate > 0 {
+		if warning, ok := result.CheckPlan(*rate); !ok {
+			fmt.Println("WARNING: " + warning)
+			return 2
+		}
+		fmt.Printf("Planned rate %.0f req/s is within capacity\n", *rate)
+	}
+	return 0
+}
+
+// runHARImport 将浏览器导出的 HAR 文件转换为 HTTP 测试计划
+func runHARImport(args []string) int {
+	fs := flag.NewFlagSet("har-import", flag.ExitOnError)
+	out := fs.String("out", "", "where to save the test plan (YAML), default: <har name>.plan.yaml")
+	name := fs.String("name", "", "test plan name, default: the HAR file name")
+	hosts := fs.String("hosts", "", "comma-separated hosts to import, empty for all hosts")
+	includeStatic := fs.Bool("include-static", false, "also import images, stylesheets, scripts, fonts and media")
+	keepCookies := fs.Bool("keep-cookies", false, "keep recorded Cookie headers (they are bound to the recorded session)")
+	maxThinkTime := fs.Duration("max-think-time", tasks.DefaultHARMaxThinkTime, "upper bound for think times derived from the recording, negative to drop think times")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: openstress har-import [flags] <file.har>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	opts := tasks.HAROptions{Name: *name, IncludeStatic: *includeStatic, KeepCookies: *keepCookies, MaxThinkTime: *maxThinkTime}
+	for _, host := range strings.Split(*hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			opts.Hosts = append(opts.Hosts, host)
+		}
+	}
+	harPath := fs.Arg(0)
+	plan, err := tasks.ImportHARFile(harPath, opts)
+	if err != nil {
+		fmt.Printf("HAR import failed: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(harPath, filepath.Ext(harPath)) + ".plan.yaml"
+	}
+	if err := plan.Save(*out); err != nil {
+		fmt.Printf("HAR import failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d requests into test plan %q: %s\n", len(plan.Requests), plan.Name, *out)
+	return 0
+}
+
+// runJMXImport 将 JMeter 脚本转换为 HTTP 测试计划，每个线程组保存为一个计划文件
+func runJMXImport(args []string) int {
+	fs := flag.NewFlagSet("jmx-import", flag.ExitOnError)
+	outDir := fs.String("out-dir", "", "directory to save the test plans (YAML), default: the directory of the JMX file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: openstress jmx-import [flags] <file.jmx>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	jmxPath := fs.Arg(0)
+	plans, warnings, err := tasks.ImportJMXFile(jmxPath)
+	for _, warning := range warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	if err != nil {
+		fmt.Printf("JMX import failed: %v\n", err)
+		return 1
+	}
+	if *outDir == "" {
+		*outDir = filepath.Dir(jmxPath)
+	}
+	base := strings.TrimSuffix(filepath.Base(jmxPath), filepath.Ext(jmxPath))
+	for i, plan := range plans {
+		// 只有一个线程组时与 har-import 一致，否则按序号区分
+		name := base + ".plan.yaml"
+		if len(plans) > 1 {
+			name = fmt.Sprintf("%s-%d.plan.yaml", base, i+1)
+		}
+		out := filepath.Join(*outDir, name)
+		if err := plan.Save(out); err != nil {
+			fmt.Printf("JMX import failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Imported %d requests into test plan %q: %s\n", len(plan.Requests), plan.Name, out)
+	}
+	return 0
+}
+
+// runOpenAPIImport 根据 OpenAPI 文档生成 HTTP 测试计划的骨架
+func runOpenAPIImport(args []string) int {
+	fs := flag.NewFlagSet("openapi-import", flag.ExitOnError)
+	out := fs.String("out", "", "where to save the test plan (YAML), default: <document name>.plan.yaml")
+	name := fs.String("name", "", "test plan name, default: the document title")
+	baseURL := fs.String("base-url", "", "server URL, default: the first server in the document")
+	tags := fs.String("tags", "", "comma-separated tags to import, empty for all operations")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: openstress openapi-import [flags] <openapi.yaml|openapi.json>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	opts := tasks.OpenAPIOptions{Name: *name, BaseURL: *baseURL}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	specPath := fs.Arg(0)
+	plan, warnings, err := tasks.ImportOpenAPIFile(specPath, opts)
+	for _, warning := range warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	if err != nil {
+		fmt.Printf("OpenAPI import failed: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(specPath, filepath.Ext(specPath)) + ".plan.yaml"
+	}
+	if err := plan.Save(*out); err != nil {
+		fmt.Printf("OpenAPI import failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Generated %d requests in test plan %q: %s (adjust the weights to match your traffic)\n", len(plan.Requests), plan.Name, *out)
+	return 0
+}
+
+// runAPIServer 创建协程池与结果收集器并启动 API 服务，收到退出信号后优雅关闭
+func runAPIServer(cfg *config.Config, shutdown *pool.ShutdownManager) {
+	taskPool := pool.NewPool(cfg.Pool.Workers)
+	if taskPool == nil {
+		return
+	}
+	defer taskPool.Shutdown()
+	if cfg.Pool.FinishedTaskRetention > 0 {
+		taskPool.SetFinishedTaskRetention(cfg.Pool.FinishedTaskRetention)
+	}
+	if cfg.Pool.PriorityAging > 0 {
+		taskPool.SetPriorityAging(cfg.Pool.PriorityAging)
+	}
+	// 自测结果只在启动时读取一次，用于设置限流时的容量提示
+	if benchmark, err := selftest.Load(selftest.DefaultResultPath); err == nil {
+		taskPool.SetGeneratorCapacity(benchmark.Capacity)
+	}
+	if cfg.Pool.RateLimit > 0 {
+		taskPool.SetRateLimit(cfg.Pool.RateLimit, cfg.Pool.RateBurst)
+	}
+	// 按场景配置任务使用的 HTTP 客户端（例如请求签名、带宽模拟），模拟的带宽写入运行元数据
+	var clientConfig tasks.HTTPClientConfig
+	if cfg.TaskHTTPConfigPath != "" {
+		var err error
+		if clientConfig, err = tasks.LoadHTTPClientConfig(cfg.TaskHTTPConfigPath); err != nil {
+			logger.Log("ERROR", "Failed to load task HTTP client config: "+err.Error())
+			return
+		}
+	}
+	collector, err := result.NewCollector(result.CollectorConfig{
+		BatchSize:                  cfg.Collector.BatchSize,
+		OutputFormat:               cfg.Collector.OutputFormat,
+		JTLFilePath:                cfg.Collector.JTLFilePath,
+		Logger:                     logger,
+		NumGoroutines:              cfg.Collector.NumGoroutines,
+		TaskID:                     cfg.Collector.TaskID,
+		Thresholds:                 cfg.Thresholds,
+		JTLCompress:                cfg.Collector.JTLCompress,
+		JTLRotateSize:              cfg.Collector.JTLRotateSize,
+		JTLRotateInterval:          cfg.Collector.JTLRotateInterval,
+		FailureBodyBytes:           cfg.Collector.FailureBodyBytes,
+		Elasticsearch:              cfg.Collector.Elasticsearch,
+		SuccessPolicy:              successPolicy(cfg.Collector),
+		ResultRetention:            cfg.Collector.ResultRetention,
+		ResultRetentionLimit:       cfg.Collector.ResultRetentionLimit,
+		Scrub:                      cfg.Collector.Scrub,
+		History:                    cfg.Report.History,
+		ReportDir:                  cfg.Report.Dir,
+		Language:                   cfg.Report.Language,
+		SelfContainedReport:        cfg.Report.SelfContained,
+		ReportNamePattern:          cfg.Report.NamePattern,
+		ReportTemplate:             cfg.Report.Template,
+		IntermediateReportInterval: cfg.Report.IntermediateInterval,
+		Bandwidth: result.SimulatedBandwidth{
+			UploadBytesPerSecond:   clientConfig.Transport.Bandwidth.UploadBytesPerSecond,
+			DownloadBytesPerSecond: clientConfig.Transport.Bandwidth.DownloadBytesPerSecond,
+		},
+	})
+	if err != nil {
+		logger.Log("ERROR", "Failed to create collector: "+err.Error())
+		return
+	}
+	defer collector.Close()
+
+	var client *http.Client
+	if cfg.TaskHTTPConfigPath != "" {
+		if clientConfig.Breaker != nil {
+			// 熔断状态变化写入日志和报告的熔断状态图
+			clientConfig.Breaker.OnStateChange = collector.RecordBreakerTransition
+		}
+		if client, err = tasks.NewHTTPClient(clientConfig); err != nil {
+			logger.Log("ERROR", "Failed to create task HTTP client: "+err.Error())
+			return
+		}
+	}
+	pool.RegisterTasksWithClient(taskPool, client)
+
+	// HTTP 测试计划注册为以计划名称命名的任务，可以通过 API 提交或以虚拟用户方式运行
+	for _, path := range cfg.TestPlanPaths {
+		plan, err := tasks.LoadTestPlan(path)
+		if err == nil {
+			var planTask *tasks.PlanTask
+			if planTask, err = tasks.NewPlanTask(plan); err == nil {
+				planTask.Collector, planTask.Client = collector, client
+				if plan.Proxy != "" {
+					// 计划单独指定了代理时使用独立的客户端，连接不与其他任务共用
+					planConfig := clientConfig
+					planConfig.Transport.Proxy = plan.Proxy
+					planConfig.Transport.ProxyUsername, planConfig.Transport.ProxyPassword = "", ""
+					planTask.Client, err = tasks.NewHTTPClient(planConfig)
+				}
+			}
+			if err == nil {
+				pool.RegisterTestPlan(taskPool, plan.Name, planTask)
+			}
+		}
+		if err != nil {
+			logger.Log("ERROR", fmt.Sprintf("Failed to load test plan %s: %v", path, err))
+			return
+		}
+	}
+
+	// 每秒采集压测机自身的资源使用情况、活跃 VU 数和队列深度，写入报告的资源使用图和负载图
+	monitor := pool.NewMonitor(logger, time.Second, pool.ResourceThresholds{
+		MaxCPUUsage:    90,
+		MaxMemoryUsage: 4 << 30,
+		MaxGoroutines:  100000,
+	})
+	monitor.AddMetricsSink(func(metrics pool.SystemMetrics) {
+		collector.RecordResourceSample(result.ResourceSample{
+			Timestamp:   metrics.Timestamp,
+			CPUUsage:    metrics.CPUUsage,
+			MemoryUsage: metrics.MemoryUsage,
+			Goroutines:  metrics.Goroutines,
+		})
+		collector.RecordLoadSample(result.LoadSample{
+			Timestamp:  metrics.Timestamp,
+			ActiveVUs:  taskPool.ActiveVUs(),
+			QueueDepth: taskPool.QueueDepth(),
+		})
+	})
+	// 压测机存活堆持续上涨时记录警告，并在资源使用图上标注疑似内存泄漏的区间
+	monitor.AddLeakSink(func(leak pool.LeakSuspicion) {
+		collector.RecordMemoryLeak(result.MemoryLeak{Start: leak.Start, End: leak.End, GrowthPerMinute: leak.GrowthPerMinute})
+	})
+	monitor.Start()
+	defer monitor.Stop()
+	taskPool.SetMonitor(monitor)
+
+	if cfg.RuntimeConfigPath != "" {
+		watcher, err := watchRuntimeConfig(cfg.RuntimeConfigPath, taskPool)
+		if err != nil {
+			logger.Log("ERROR", "Failed to watch runtime config: "+err.Error())
+			return
+		}
+		defer watcher.Close()
+	}
+
+	server := api.NewAPIServer(taskPool, collector, cfg)
+	if cfg.AuthConfigPath != "" {
+		var redisOpts *redis.Options
+		if cfg.AuthRedis.Addr != "" {
+			redisOpts = &redis.Options{Addr: cfg.AuthRedis.Addr, Password: cfg.AuthRedis.Password, DB: cfg.AuthRedis.DB}
+		}
+		authManager, err := auth.NewAuthManager(cfg.AuthConfigPath, redisOpts)
+		if err != nil {
+			logger.Log("ERROR", "Failed to create auth manager: "+err.Error())
+			return
+		}
+		defer authManager.Close()
+		server.SetAuthenticator(authManager)
+	}
+	if err := server.Start(); err != nil {
+		logger.Log("ERROR", "Failed to start API server: "+err.Error())
+		return
+	}
+	logger.Log("INFO", "API server listening on "+server.Addr()+", dashboard served at /dashboard/")
+
+	<-shutdown.Interrupted()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Log("ERROR", "Failed to shutdown API server: "+err.Error())
+	}
+}
+
+// watchRuntimeConfig 应用运行时配置文件中的初始配置，并在文件变化时热加载到协程池
+func watchRuntimeConfig(path string, taskPool *pool.Pool) (*config.ConfigWatcher, error) {
+	watcher, err := config.NewConfigWatcher(path)
+	if err != nil {
+		return nil, err
+	}
+	current, _ := watcher.Current()
+	if err := taskPool.ApplyConfigChanges(config.DiffRuntimeConfig(config.RuntimeConfig{}, current)); err != nil {
+		return nil, err
+	}
+	events, _ := watcher.Subscribe(16)
+	if err := watcher.Start(); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go func() {
+		for event := range events {
+			if event.Err != nil {
+				logger.Log("ERROR", fmt.Sprintf("Runtime config not reloaded, keeping version %d: %v", event.Version, event.Err))
+				continue
+			}
+			if err := taskPool.ApplyConfigChanges(event.Changes); err != nil {
+				logger.Log("ERROR", fmt.Sprintf("Failed to apply runtime config version %d: %v", event.Version, err))
+				continue
+			}
+			logger.Log("INFO", fmt.Sprintf("Runtime config version %d applied (%d changes)", event.Version, len(event.Changes)))
+		}
+	}()
+	logger.Log("INFO", "Watching runtime config "+path)
+	return watcher, nil
+}
+
+// successPolicy 将配置文件中的成功判定配置转换为结果收集器的策略，接口的多个状态码以空格或 | 分隔
+func successPolicy(cfg config.CollectorConfig) result.SuccessPolicy {
+	policy := result.SuccessPolicy{StatusCodes: cfg.SuccessStatusCodes, RequireAssertions: cfg.RequireAssertions}
+	if len(cfg.SuccessEndpoints) > 0 {
+		policy.Endpoints = make(map[string][]string, len(cfg.SuccessEndpoints))
+		for pattern, codes := range cfg.SuccessEndpoints {
+			policy.Endpoints[pattern] = strings.FieldsFunc(codes, func(r rune) bool { return r == ' ' || r == '|' })
+		}
+	}
+	return policy
 }
 
 // handleError 处理错误并记录日志
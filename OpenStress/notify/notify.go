@@ -0,0 +1,313 @@
+// notify.go
+// 压测完成通知模块
+// 本文件负责在压测结束或阈值未通过时，把测试摘要（TPS、P95、错误率、报告链接）发送到配置的 Webhook，
+// 支持通用 JSON、Slack 和钉钉机器人三种消息格式。
+//
+// 技术实现细节：
+// 1. 摘要由 SummaryFromStats 从 result 的统计结果中提取，消息文本使用 text/template 渲染，
+//    每个 Webhook 可以配置自己的模板，未配置时使用对应格式的默认模板。
+// 2. 每个 Webhook 可以按事件过滤：completed 每次压测结束都会发送，threshold_failed 只在阈值未通过时发送。
+// 3. 发送失败（网络错误、5xx、429）时按指数退避重试，其他 4xx 直接返回错误；一个 Webhook 失败不影响其他 Webhook。
+// 4. 钉钉机器人配置了加签密钥时，按钉钉要求在 URL 上附加 timestamp 和 sign 参数，并检查响应中的 errcode。
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// 通知事件
+const (
+	EventCompleted       = "completed"        // 压测结束
+	EventThresholdFailed = "threshold_failed" // 阈值未通过
+)
+
+// 消息格式
+const (
+	FormatJSON     = "json"
+	FormatSlack    = "slack"
+	FormatDingTalk = "dingtalk"
+)
+
+// defaultTemplates 各消息格式的默认模板
+var defaultTemplates = map[string]string{
+	FormatJSON: `{{.Title}}: {{if .ThresholdsPassed}}passed{{else}}thresholds failed{{end}}
+TPS {{printf "%.2f" .TPS}}, p95 {{.P95}}, error rate {{printf "%.2f" .ErrorRate}}%, {{.TotalRequests}} requests
+{{range .FailedThresholds}}failed: {{.}}
+{{end}}{{if .ReportURL}}report: {{.ReportURL}}{{end}}`,
+	FormatSlack: `{{if .ThresholdsPassed}}:white_check_mark:{{else}}:x:{{end}} *{{.Title}}* {{if .ThresholdsPassed}}passed{{else}}thresholds failed{{end}}
+• TPS: {{printf "%.2f" .TPS}}
+• p95: {{.P95}}
+• Error rate: {{printf "%.2f" .ErrorRate}}% of {{.TotalRequests}} requests
+{{range .FailedThresholds}}• Failed: ` + "`{{.}}`" + `
+{{end}}{{if .ReportURL}}<{{.ReportURL}}|View report>{{end}}`,
+	FormatDingTalk: `### {{.Title}} {{if .ThresholdsPassed}}通过{{else}}阈值未通过{{end}}
+
+- TPS：{{printf "%.2f" .TPS}}
+- P95：{{.P95}}
+- 错误率：{{printf "%.2f" .ErrorRate}}%（共 {{.TotalRequests}} 个请求）
+{{range .FailedThresholds}}- 未通过：{{.}}
+{{end}}{{if .ReportURL}}
+[查看报告]({{.ReportURL}}){{end}}`,
+}
+
+// Summary 压测结果摘要，也是消息模板的数据
+type Summary struct {
+	Event            string        `json:"event"`
+	Title            string        `json:"title"`
+	TotalRequests    int           `json:"total_requests"`
+	TPS              float64       `json:"tps"`
+	P95              time.Duration `json:"-"`
+	ErrorRate        float64       `json:"error_rate"` // 百分比
+	ThresholdsPassed bool          `json:"thresholds_passed"`
+	FailedThresholds []string      `json:"failed_thresholds,omitempty"`
+	ReportURL        string        `json:"report_url,omitempty"`
+	StartTime        time.Time     `json:"start_time"`
+	EndTime          time.Time     `json:"end_time"`
+}
+
+// jsonSummary JSON 格式的消息体：摘要字段加上渲染后的文本
+type jsonSummary struct {
+	Summary
+	P95Ms float64 `json:"p95_ms"`
+	Text  string  `json:"text"`
+}
+
+// webhook 已解析模板的通知目标
+type webhook struct {
+	cfg      config.WebhookConfig
+	template *template.Template
+}
+
+// Notifier 压测完成通知发送器
+type Notifier struct {
+	cfg      config.NotificationConfig
+	webhooks []webhook
+	client   *http.Client
+}
+
+// NewNotifier 校验配置并解析消息模板
+func NewNotifier(cfg config.NotificationConfig) (*Notifier, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+
+	n := &Notifier{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+	for i, wc := range cfg.Webhooks {
+		if wc.Name == "" {
+			wc.Name = fmt.Sprintf("webhook-%d", i)
+		}
+		if wc.Format == "" {
+			wc.Format = FormatJSON
+		}
+		text, ok := defaultTemplates[wc.Format]
+		if !ok {
+			return nil, fmt.Errorf("webhook %s: unsupported format %q", wc.Name, wc.Format)
+		}
+		if wc.URL == "" {
+			return nil, fmt.Errorf("webhook %s: url is required", wc.Name)
+		}
+		for _, event := range wc.Events {
+			if event != EventCompleted && event != EventThresholdFailed {
+				return nil, fmt.Errorf("webhook %s: unsupported event %q", wc.Name, event)
+			}
+		}
+		if wc.Template != "" {
+			text = wc.Template
+		}
+		tmpl, err := template.New(wc.Name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: invalid template: %v", wc.Name, err)
+		}
+		n.webhooks = append(n.webhooks, webhook{cfg: wc, template: tmpl})
+	}
+	return n, nil
+}
+
+// SummaryFromStats 从 result.Collector.GeneratePerformanceStats 的统计结果中提取摘要
+// 需要先调用 EvaluateThresholds 才能得到阈值判定结果，没有阈值时视为通过
+func SummaryFromStats(stats map[string]interface{}, title, reportURL string) Summary {
+	s := Summary{Event: EventCompleted, Title: title, ReportURL: reportURL, ThresholdsPassed: true}
+	s.TotalRequests, _ = stats["TotalRequests"].(int)
+	s.TPS, _ = stats["TPS"].(float64)
+	s.P95, _ = stats["P95ResponseTime"].(time.Duration)
+	if failures, ok := stats["FailureCount"].(int); ok && s.TotalRequests > 0 {
+		s.ErrorRate = float64(failures) / float64(s.TotalRequests) * 100
+	}
+	if start, ok := stats["AvgTpsStartTime"].(int64); ok {
+		s.StartTime = time.Unix(start, 0)
+	}
+	if end, ok := stats["AvgTpsEndTime"].(int64); ok {
+		s.EndTime = time.Unix(end, 0)
+	}
+	if passed, ok := stats["ThresholdsPassed"].(bool); ok && !passed {
+		s.ThresholdsPassed = false
+		s.Event = EventThresholdFailed
+	}
+	if results, ok := stats["ThresholdResults"].([]result.ThresholdResult); ok {
+		for _, r := range results {
+			if !r.Passed {
+				s.FailedThresholds = append(s.FailedThresholds, r.Expression)
+			}
+		}
+	}
+	return s
+}
+
+// ReportLink 返回通知中使用的报告链接：配置了 report_base_url 时为
+// base/<报告目录名>/<报告文件名>，否则为本地报告路径
+func (n *Notifier) ReportLink(reportPath string) string {
+	if n.cfg.ReportBaseURL == "" || reportPath == "" {
+		return reportPath
+	}
+	return strings.TrimRight(n.cfg.ReportBaseURL, "/") + "/" +
+		url.PathEscape(filepath.Base(filepath.Dir(reportPath))) + "/" + url.PathEscape(filepath.Base(reportPath))
+}
+
+// Notify 向所有订阅了该事件的 Webhook 发送摘要，返回各 Webhook 的发送错误
+func (n *Notifier) Notify(ctx context.Context, s Summary) error {
+	var errs []error
+	for _, w := range n.webhooks {
+		if !w.wants(s) {
+			continue
+		}
+		if err := n.send(ctx, w, s); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %v", w.cfg.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// wants 判断 Webhook 是否订阅了本次摘要对应的事件
+func (w webhook) wants(s Summary) bool {
+	if len(w.cfg.Events) == 0 {
+		return true
+	}
+	for _, event := range w.cfg.Events {
+		if event == EventCompleted || (event == EventThresholdFailed && !s.ThresholdsPassed) {
+			return true
+		}
+	}
+	return false
+}
+
+// payload 按消息格式生成请求体
+func (w webhook) payload(s Summary) ([]byte, error) {
+	var text bytes.Buffer
+	if err := w.template.Execute(&text, s); err != nil {
+		return nil, fmt.Errorf("failed to render template: %v", err)
+	}
+	switch w.cfg.Format {
+	case FormatSlack:
+		return json.Marshal(map[string]string{"text": text.String()})
+	case FormatDingTalk:
+		return json.Marshal(map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": s.Title, "text": text.String()},
+		})
+	default:
+		return json.Marshal(jsonSummary{Summary: s, P95Ms: float64(s.P95) / float64(time.Millisecond), Text: text.String()})
+	}
+}
+
+// send 发送一条消息，失败时按指数退避重试
+func (n *Notifier) send(ctx context.Context, w webhook, s Summary) error {
+	body, err := w.payload(s)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := n.post(ctx, w, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= n.cfg.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post 发送一次请求，返回错误是否值得重试
+func (n *Notifier) post(ctx context.Context, w webhook, body []byte) (bool, error) {
+	target := w.cfg.URL
+	if w.cfg.Format == FormatDingTalk && w.cfg.Secret != "" {
+		target = dingTalkSignedURL(target, w.cfg.Secret, time.Now())
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to send notification: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// 钉钉接口出错时仍返回 200，错误码在响应体中
+	if w.cfg.Format == FormatDingTalk {
+		var reply struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if json.Unmarshal(respBody, &reply) == nil && reply.ErrCode != 0 {
+			return false, fmt.Errorf("dingtalk error %d: %s", reply.ErrCode, reply.ErrMsg)
+		}
+	}
+	return false, nil
+}
+
+// dingTalkSignedURL 按钉钉机器人加签规则附加 timestamp 和 sign 参数
+func dingTalkSignedURL(rawURL, secret string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+}
@@ -0,0 +1,134 @@
+// abort.go
+// 错误预算模块
+// 本文件负责 VU 执行的错误预算：被测服务明显不可用时提前中止测试，避免长时间无意义地施压。
+//
+// 技术实现细节：
+// 1. 两种中止条件，先满足者生效：滑动窗口内出错迭代的比例超过 ErrorRate，或连续 ConsecutiveFailures 次迭代出错。
+// 2. 滑动窗口按秒分桶，窗口内迭代数少于 MinIterations 时不判断错误率，避免刚开始的个别错误触发中止。
+// 3. 连续失败按所有 VU 完成迭代的先后顺序计数，任一迭代成功即清零。
+// 4. 中止时记录原因、说明和时间（VURun.Abort），由调用方通过 Collector.SetAbort 写入报告。
+
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 错误预算的默认值
+const (
+	defaultAbortWindow        = 10 * time.Second
+	defaultAbortMinIterations = 20
+)
+
+// AbortPolicy 错误预算，超出时提前中止所有 VU，零值表示不启用
+type AbortPolicy struct {
+	ErrorRate           float64       // 滑动窗口内出错迭代的比例上限（百分比），0 表示不按错误率中止
+	Window              time.Duration // 错误率的滑动窗口，默认 10 秒
+	MinIterations       int           // 窗口内至少完成的迭代数，少于该值时不判断错误率，默认 20
+	ConsecutiveFailures int           // 连续出错的迭代数上限，0 表示不按连续失败中止
+}
+
+// enabled 是否启用了任一中止条件
+func (a AbortPolicy) enabled() bool {
+	return a.ErrorRate > 0 || a.ConsecutiveFailures > 0
+}
+
+// validate 检查错误预算配置
+func (a AbortPolicy) validate() error {
+	if a.ErrorRate < 0 || a.ErrorRate > 100 {
+		return fmt.Errorf("abort error rate must be between 0 and 100")
+	}
+	if a.Window < 0 || a.MinIterations < 0 || a.ConsecutiveFailures < 0 {
+		return fmt.Errorf("abort window, min iterations and consecutive failures must not be negative")
+	}
+	return nil
+}
+
+// AbortInfo 提前中止的原因和时间
+type AbortInfo struct {
+	Reason StopReason // StopErrorRate 或 StopConsecutiveFailures
+	Detail string     // 触发中止时的统计，例如错误率和窗口
+	Time   time.Time  // 中止时间
+}
+
+// windowBucket 一秒内完成的迭代数和出错的迭代数
+type windowBucket struct {
+	sec    int64
+	total  int
+	failed int
+}
+
+// errorBudget 按错误预算判断是否需要中止，并发安全
+type errorBudget struct {
+	policy AbortPolicy
+
+	mu          sync.Mutex
+	buckets     []windowBucket // 环形缓冲，下标为秒数对窗口秒数取模
+	consecutive int
+}
+
+// newErrorBudget 创建错误预算，policy 未启用任何条件时返回 nil
+func newErrorBudget(policy AbortPolicy) *errorBudget {
+	if !policy.enabled() {
+		return nil
+	}
+	if policy.Window <= 0 {
+		policy.Window = defaultAbortWindow
+	}
+	if policy.MinIterations <= 0 {
+		policy.MinIterations = defaultAbortMinIterations
+	}
+	seconds := int((policy.Window + time.Second - 1) / time.Second)
+	return &errorBudget{policy: policy, buckets: make([]windowBucket, seconds)}
+}
+
+// record 记录一次完成的迭代，超出错误预算时返回中止信息
+func (b *errorBudget) record(failed bool, now time.Time) (AbortInfo, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sec := now.Unix()
+	bucket := &b.buckets[sec%int64(len(b.buckets))]
+	if bucket.sec != sec {
+		*bucket = windowBucket{sec: sec}
+	}
+	bucket.total++
+	if failed {
+		bucket.failed++
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+
+	if limit := b.policy.ConsecutiveFailures; limit > 0 && b.consecutive >= limit {
+		return AbortInfo{
+			Reason: StopConsecutiveFailures,
+			Detail: fmt.Sprintf("%d consecutive failed iterations", b.consecutive),
+			Time:   now,
+		}, true
+	}
+	if b.policy.ErrorRate <= 0 {
+		return AbortInfo{}, false
+	}
+
+	var total, failures int
+	for _, w := range b.buckets {
+		if w.sec > sec-int64(len(b.buckets)) {
+			total += w.total
+			failures += w.failed
+		}
+	}
+	if total < b.policy.MinIterations {
+		return AbortInfo{}, false
+	}
+	if rate := float64(failures) / float64(total) * 100; rate > b.policy.ErrorRate {
+		return AbortInfo{
+			Reason: StopErrorRate,
+			Detail: fmt.Sprintf("error rate %.2f%% (%d/%d iterations) over the last %v exceeded %.2f%%", rate, failures, total, b.policy.Window, b.policy.ErrorRate),
+			Time:   now,
+		}, true
+	}
+	return AbortInfo{}, false
+}
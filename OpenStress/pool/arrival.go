@@ -0,0 +1,179 @@
+// arrival.go
+// 到达率（开放模型）执行模块
+// 本文件负责按固定的到达率调度迭代：迭代按计划的时间到达，不受响应时间影响，
+// 被测服务变慢时施加的负载保持不变，适合测量固定负载下的真实延迟。
+// 与之相对，VU 模式（vu.go）是封闭模型，每个 VU 完成上一次迭代后才开始下一次，服务变慢时到达率随之下降。
+//
+// 技术实现细节：
+// 1. 预分配 VUs 个 VU 执行迭代（同时执行的迭代上限），worker 预留、启动屏障、预热、钩子和停止条件与 VU 模式相同，
+//    IterationsPerVU 不适用。
+// 2. 调度协程在屏障放行后（预热开始时）按到达间隔生成计划到达时间：constant 为固定间隔 1/Rate，
+//    poisson 为均值 1/Rate 的指数分布间隔（泊松到达），随机数由全局随机种子派生，相同种子下可复现。
+// 3. 到达时没有空闲 VU 的迭代直接丢弃并计数（VURun.Dropped），不排队、不补发，避免积压后突发；
+//    丢弃数不为 0 说明 VU 数不足以承载该到达率，测得的延迟偏乐观，应增加 VUs 后重新测试。
+// 4. 调度协程落后于计划时间时（例如 GC 停顿）立即补发落后的到达，每次迭代的计划时间通过 VUContext.ScheduledAt
+//    提供，任务可以从计划时间开始计算延迟，避免协调遗漏（coordinated omission）。
+// 5. 协程池暂停期间不生成到达，恢复后从恢复时刻重新开始计划，暂停期间的到达不计入丢弃数。
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/potatoImp/OpenStress/random"
+)
+
+// ArrivalDistribution 到达间隔的分布
+type ArrivalDistribution string
+
+const (
+	ArrivalConstant ArrivalDistribution = "constant" // 固定间隔
+	ArrivalPoisson  ArrivalDistribution = "poisson"  // 泊松到达，间隔服从指数分布
+)
+
+// ArrivalConfig 到达率执行配置
+type ArrivalConfig struct {
+	VUConfig                         // VUs 为预分配的 VU 数，即同时执行的迭代上限；IterationsPerVU 不适用
+	Rate         float64             // 每秒到达的迭代数
+	Distribution ArrivalDistribution // 到达间隔分布，为空时使用 constant
+}
+
+// validate 检查到达率执行配置
+func (cfg ArrivalConfig) validate() error {
+	if cfg.Rate <= 0 {
+		return fmt.Errorf("arrival rate must be positive")
+	}
+	switch cfg.Distribution {
+	case "", ArrivalConstant, ArrivalPoisson:
+	default:
+		return fmt.Errorf("unknown arrival distribution %q", cfg.Distribution)
+	}
+	if cfg.IterationsPerVU != 0 {
+		return fmt.Errorf("iterations per vu is not supported with an arrival rate")
+	}
+	return cfg.VUConfig.validate()
+}
+
+// interval 返回到下一次到达的间隔
+func (cfg ArrivalConfig) interval(r *rand.Rand) time.Duration {
+	if cfg.Distribution == ArrivalPoisson {
+		return time.Duration(r.ExpFloat64() / cfg.Rate * float64(time.Second))
+	}
+	return time.Duration(float64(time.Second) / cfg.Rate)
+}
+
+// distribution 返回实际使用的到达间隔分布
+func (cfg ArrivalConfig) distribution() ArrivalDistribution {
+	if cfg.Distribution == "" {
+		return ArrivalConstant
+	}
+	return cfg.Distribution
+}
+
+// RunArrivals 按到达率运行 fn，阻塞直到测量阶段结束，返回测量开始时间
+func (p *Pool) RunArrivals(cfg ArrivalConfig, fn func(vu *VUContext)) (time.Time, error) {
+	run, err := p.StartArrivals(cfg, fn)
+	if err != nil {
+		return time.Time{}, err
+	}
+	measureStart := run.Wait()
+	stressLogger.Log("INFO", fmt.Sprintf("Arrival-rate run finished (%s), %d iterations, %d dropped, measurement started at %s",
+		run.StopReason(), run.Iterations(), run.Dropped(), measureStart.Format(time.RFC3339)))
+	return measureStart, nil
+}
+
+// StartArrivals 启动到达率执行后立即返回，通过 VURun.Wait 等待执行结束，VURun.Dropped 返回丢弃的到达数
+func (p *Pool) StartArrivals(cfg ArrivalConfig, fn func(vu *VUContext)) (*VURun, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	arrivals := make(chan time.Time)
+	dispatch := func(ctx context.Context, run *VURun) { p.dispatchArrivals(ctx, run, cfg, arrivals) }
+	loop := func(run *VURun, vu *VUContext, end time.Time) { p.runArrivals(run, vu, arrivals, fn) }
+	stressLogger.Log("INFO", fmt.Sprintf("Scheduling %.2f arrivals/s (%s) on up to %d vus", cfg.Rate, cfg.distribution(), cfg.VUs))
+	return p.startVUs(cfg.VUConfig, dispatch, loop)
+}
+
+// StartRegisteredArrivals 按到达率运行已注册的任务，任务函数收到的 threadID 为 VU 编号，
+// 使用 RegisterVUHooks 设置的钩子
+func (p *Pool) StartRegisteredArrivals(name string, cfg ArrivalConfig) (*VURun, error) {
+	value, ok := p.registry.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("task %s is not registered", name)
+	}
+	fn := value.(func(threadID int32))
+	if hooks, ok := p.vuHooks.Load(name); ok {
+		cfg.Hooks = hooks.(VUHooks)
+	}
+	return p.StartArrivals(cfg, func(vu *VUContext) { fn(int32(vu.VUID)) })
+}
+
+// dispatchArrivals 按计划时间把到达交给空闲的 VU，没有空闲 VU 时丢弃，结束时关闭 arrivals
+func (p *Pool) dispatchArrivals(ctx context.Context, run *VURun, cfg ArrivalConfig, arrivals chan<- time.Time) {
+	defer close(arrivals)
+	measureStart, err := run.barrier.Wait(ctx)
+	if err != nil {
+		return
+	}
+	var end time.Time
+	if cfg.Duration > 0 {
+		end = measureStart.Add(cfg.Duration)
+	}
+	r := random.New("arrivals")
+	next := time.Now()
+	for {
+		switch {
+		case run.stopped.Load():
+			return
+		case atomic.LoadInt32(&p.shutdownFlag) == 1:
+			run.stop(StopShutdown)
+			return
+		case atomic.LoadInt32(&p.isPaused) == 1:
+			time.Sleep(100 * time.Millisecond)
+			next = time.Now()
+			continue
+		}
+		wake := next
+		if !end.IsZero() && !next.Before(end) {
+			wake = end
+		}
+		if delay := time.Until(wake); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		if !end.IsZero() && !next.Before(end) {
+			return
+		}
+		select {
+		case arrivals <- next:
+		default:
+			run.dropped.Add(1)
+		}
+		next = next.Add(cfg.interval(r))
+	}
+}
+
+// runArrivals VU 等待到达并执行迭代，到达结束（arrivals 关闭）或满足停止条件时返回
+func (p *Pool) runArrivals(run *VURun, vu *VUContext, arrivals <-chan time.Time, fn func(vu *VUContext)) {
+	p.activeVUs.Add(1)
+	defer p.activeVUs.Add(-1)
+	for scheduled := range arrivals {
+		if run.stopped.Load() {
+			return
+		}
+		vu.ScheduledAt = scheduled
+		if !p.iterate(run, vu, fn) {
+			return
+		}
+	}
+	run.finish(StopDuration)
+}
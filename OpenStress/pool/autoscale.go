@@ -0,0 +1,180 @@
+// autoscale.go
+// 自动扩缩容模块
+// 本文件负责根据任务队列深度和 Monitor 上报的 CPU 使用率自动调整 worker 数量。
+//
+// 防抖（迟滞）策略：
+// 1. 扩容与缩容使用不同的触发条件：队列积压超过阈值才扩容，队列为空且空闲比例足够高才缩容，
+//    两者之间存在一段"不动作"的区间。
+// 2. 条件需连续满足 StableIntervals 次采样才会动作。
+// 3. 两次调整之间至少间隔 Cooldown。
+// 4. CPU 使用率超过 MaxCPUUsage 时不再扩容，并逐步缩容以释放压力。
+
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AutoScaleConfig 自动扩缩容配置
+type AutoScaleConfig struct {
+	MinWorkers         int           // 最少 worker 数
+	MaxWorkers         int           // 最多 worker 数
+	Interval           time.Duration // 采样间隔
+	ScaleUpQueueDepth  int           // 队列深度超过该值时扩容
+	ScaleDownIdleRatio float64       // 队列为空且空闲 worker 比例不低于该值时缩容（0~1）
+	MaxCPUUsage        float64       // CPU 使用率上限（百分比），0 表示不考虑 CPU
+	Step               int           // 每次调整的 worker 数
+	StableIntervals    int           // 条件需连续满足的采样次数
+	Cooldown           time.Duration // 两次调整之间的最小间隔
+}
+
+// ScaleSample 一次采样
+type ScaleSample struct {
+	QueueDepth int     // 等待 worker 的任务数
+	Running    int     // 正在执行的 worker 数
+	Workers    int     // 当前 worker 数
+	CPUUsage   float64 // CPU 使用率（百分比）
+}
+
+// AutoScaler 自动扩缩容器
+type AutoScaler struct {
+	pool    *Pool
+	monitor *Monitor
+	config  AutoScaleConfig
+
+	upStreak   int
+	downStreak int
+	lastChange time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// StartAutoScaler 启动自动扩缩容，monitor 为 nil 时只根据队列深度调整
+func (p *Pool) StartAutoScaler(config AutoScaleConfig, monitor *Monitor) (*AutoScaler, error) {
+	a, err := p.NewAutoScaler(config, monitor)
+	if err != nil {
+		return nil, err
+	}
+	a.wg.Add(1)
+	go a.run()
+
+	stressLogger.Log("INFO", fmt.Sprintf("Auto scaler started with workers in [%d, %d]", a.config.MinWorkers, a.config.MaxWorkers))
+	return a, nil
+}
+
+// NewAutoScaler 校验配置并创建自动扩缩容器，不启动采样；通常使用 StartAutoScaler
+func (p *Pool) NewAutoScaler(config AutoScaleConfig, monitor *Monitor) (*AutoScaler, error) {
+	if config.MinWorkers <= 0 || config.MaxWorkers < config.MinWorkers {
+		return nil, fmt.Errorf("invalid worker range [%d, %d]", config.MinWorkers, config.MaxWorkers)
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.Step <= 0 {
+		config.Step = 1
+	}
+	if config.StableIntervals <= 0 {
+		config.StableIntervals = 3
+	}
+	if config.ScaleDownIdleRatio <= 0 {
+		config.ScaleDownIdleRatio = 0.5
+	}
+
+	return &AutoScaler{
+		pool:     p,
+		monitor:  monitor,
+		config:   config,
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// Stop 停止自动扩缩容，可重复调用
+func (a *AutoScaler) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stopChan)
+		a.wg.Wait()
+		stressLogger.Log("INFO", "Auto scaler stopped")
+	})
+}
+
+// run 定期采样并调整
+func (a *AutoScaler) run() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopChan:
+			return
+		case now := <-ticker.C:
+			sample := a.sample()
+			if target := a.Decide(sample, now); target != sample.Workers {
+				stressLogger.Log("INFO", fmt.Sprintf("Auto scaling workers %d -> %d (queue: %d, running: %d, cpu: %.1f%%)",
+					sample.Workers, target, sample.QueueDepth, sample.Running, sample.CPUUsage))
+				a.pool.AdjustWorkers(target)
+			}
+		}
+	}
+}
+
+// sample 采集当前状态
+func (a *AutoScaler) sample() ScaleSample {
+	stats := a.pool.Stats()
+	s := ScaleSample{
+		QueueDepth: a.pool.QueueDepth(),
+		Running:    stats.RunningWorkers,
+		Workers:    stats.MaxWorkers,
+	}
+	if a.monitor != nil {
+		if metrics, ok := a.monitor.GetLatestMetrics(); ok {
+			s.CPUUsage = metrics.CPUUsage
+		}
+	}
+	return s
+}
+
+// Decide 根据采样结果返回目标 worker 数，不调整时返回当前值；连续满足次数和冷却时间记录在 AutoScaler 中
+func (a *AutoScaler) Decide(s ScaleSample, now time.Time) int {
+	cfg := a.config
+	cpuHot := cfg.MaxCPUUsage > 0 && s.CPUUsage >= cfg.MaxCPUUsage
+
+	wantUp := !cpuHot && s.QueueDepth > cfg.ScaleUpQueueDepth && s.Workers < cfg.MaxWorkers
+	idleRatio := 0.0
+	if s.Workers > 0 {
+		idleRatio = float64(s.Workers-s.Running) / float64(s.Workers)
+	}
+	wantDown := s.Workers > cfg.MinWorkers && (cpuHot || (s.QueueDepth == 0 && idleRatio >= cfg.ScaleDownIdleRatio))
+
+	switch {
+	case wantUp:
+		a.upStreak++
+		a.downStreak = 0
+	case wantDown:
+		a.downStreak++
+		a.upStreak = 0
+	default:
+		a.upStreak, a.downStreak = 0, 0
+		return s.Workers
+	}
+
+	if !a.lastChange.IsZero() && now.Sub(a.lastChange) < cfg.Cooldown {
+		return s.Workers
+	}
+
+	target := s.Workers
+	if a.upStreak >= cfg.StableIntervals {
+		target = min(s.Workers+cfg.Step, cfg.MaxWorkers)
+	} else if a.downStreak >= cfg.StableIntervals {
+		target = max(s.Workers-cfg.Step, cfg.MinWorkers)
+	}
+	if target != s.Workers {
+		a.lastChange = now
+		a.upStreak, a.downStreak = 0, 0
+	}
+	return target
+}
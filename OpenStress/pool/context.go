@@ -0,0 +1,124 @@
+// context.go
+// 任务上下文模块
+// 本文件负责定义随任务传递的 TaskContext，携带追踪ID、任务ID、虚拟用户编号和迭代次数，
+// 用于把同一次请求在日志、结果（ResultData.TraceID 等字段）和报告明细之间关联起来。
+//
+// 技术实现细节：
+// 1. 协程池任务在提交时生成追踪ID；VU 模式下每次迭代生成新的追踪ID。
+// 2. TaskContext.Log 在日志内容前加上 [trace=... task=... vu=... iter=...] 前缀。
+// 3. 可通过 WithTaskContext/TaskContextFrom 放入 context.Context，传递给下游的 HTTP 客户端等组件；
+//    VU 模式下同时放入 VU 编号（tasks.WithVU），HTTP 传输层绑定多个源 IP 时按 VU 选择源地址。
+// 4. 启用链路追踪时，任务（或迭代）的 span 使用同一个追踪ID，TaskContext.Context() 携带该 span。
+// 5. 协程池在执行前写入限流等待时间；每次执行（包括重试）使用 TaskContext 的独立副本并写入已重试次数，
+//    超时后仍在后台运行的执行不会看到后续重试的修改。结果通过 ResultData.ApplyExecution 记录，用于"排除重试/限流等待"的响应时间统计口径。
+
+package pool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/potatoImp/OpenStress/tasks"
+	"github.com/potatoImp/OpenStress/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TaskContext 任务上下文
+type TaskContext struct {
+	TraceID   string // 追踪ID，每个任务（VU 模式下每次迭代）唯一
+	TaskID    string // 协程池任务ID，VU 模式下为空
+	VUID      int    // 虚拟用户编号，非 VU 模式为 -1
+	Iteration int    // 迭代次数，从 0 开始
+
+	RetryCount   int           // 本次执行之前已重试的次数，0 表示首次执行
+	ThrottleWait time.Duration // 本次执行开始前的客户端限流等待时间
+
+	ctx context.Context // 执行期间的 context，携带当前 span
+}
+
+// traceFallback 随机数不可用时生成追踪ID的计数器
+var traceFallback uint64
+
+// NewTraceID 生成 16 字节的十六进制追踪ID
+func NewTraceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%016x%016x", time.Now().UnixNano(), atomic.AddUint64(&traceFallback, 1))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// newTaskContext 为协程池任务创建上下文
+func newTaskContext(taskID string) *TaskContext {
+	return &TaskContext{TraceID: NewTraceID(), TaskID: taskID, VUID: -1}
+}
+
+// LogPrefix 返回用于日志关联的前缀
+func (tc *TaskContext) LogPrefix() string {
+	var b strings.Builder
+	b.WriteString("[trace=" + tc.TraceID)
+	if tc.TaskID != "" {
+		b.WriteString(" task=" + tc.TaskID)
+	}
+	if tc.VUID >= 0 {
+		fmt.Fprintf(&b, " vu=%d iter=%d", tc.VUID, tc.Iteration)
+	}
+	b.WriteString("] ")
+	return b.String()
+}
+
+// Log 记录带追踪信息的日志
+func (tc *TaskContext) Log(level, message string) {
+	stressLogger.LogDepth(1, level, tc.LogPrefix()+message)
+}
+
+// ResultTrace 返回写入结果的追踪信息，实现 result.ExecutionContext
+func (tc *TaskContext) ResultTrace() (traceID string, vuID, iteration int) {
+	return tc.TraceID, tc.VUID, tc.Iteration
+}
+
+// ResultRetry 返回写入结果的重试次数和限流等待时间，实现 result.ExecutionContext
+func (tc *TaskContext) ResultRetry() (retryCount int, throttleWait time.Duration) {
+	return tc.RetryCount, tc.ThrottleWait
+}
+
+// Context 返回执行期间的 context.Context：携带当前任务（或迭代）的 span 和 TaskContext 本身，
+// 传给 tracing.NewClient 创建的 HTTP 客户端时，请求 span 会挂在任务 span 之下
+func (tc *TaskContext) Context() context.Context {
+	if tc.ctx != nil {
+		return tc.ctx
+	}
+	return WithTaskContext(context.Background(), tc)
+}
+
+// startSpan 为本次执行创建 span，追踪ID与 TraceID 一致
+func (tc *TaskContext) startSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	ctx := tracing.WithTraceID(WithTaskContext(context.Background(), tc), tc.TraceID)
+	ctx, span := tracing.StartSpan(ctx, name, attrs...)
+	tc.ctx = ctx
+	return span
+}
+
+// taskContextKey context.Context 中保存 TaskContext 的键
+type taskContextKey struct{}
+
+// WithTaskContext 将 TaskContext 放入 context.Context
+func WithTaskContext(ctx context.Context, tc *TaskContext) context.Context {
+	if tc.VUID >= 0 {
+		ctx = tasks.WithVU(ctx, tc.VUID)
+	}
+	return context.WithValue(ctx, taskContextKey{}, tc)
+}
+
+// TaskContextFrom 从 context.Context 中取出 TaskContext
+func TaskContextFrom(ctx context.Context) (*TaskContext, bool) {
+	tc, ok := ctx.Value(taskContextKey{}).(*TaskContext)
+	return tc, ok
+}
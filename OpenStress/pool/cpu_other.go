@@ -0,0 +1,10 @@
+//go:build !unix
+
+package pool
+
+import "time"
+
+// processCPUTime 当前平台不支持读取进程 CPU 时间，CPU 使用率恒为 0
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package pool
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPUTime 返回当前进程累计占用的 CPU 时间（用户态 + 内核态）
+func processCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
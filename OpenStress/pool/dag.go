@@ -0,0 +1,359 @@
+// dag.go
+// 任务依赖调度模块
+// 本文件负责按 TaskDetail.Dependencies 描述的有向无环图（DAG）调度任务。
+//
+// 调度规则：
+// 1. 提交时收集所有任务及其（传递的）依赖，检测循环依赖，存在循环时整组拒绝提交并指出环路。
+// 2. 只有所有依赖都成功完成的任务才会提交到协程池，支持扇入（多个依赖）和扇出（多个后继）。
+// 3. 任务失败、超时或无法提交时，其所有后继任务被标记为已取消，不再执行。
+// 4. 通过 DAGRun.Status 查询每个节点的状态，通过 DAGRun.Wait 等待整组任务结束。
+// 5. 任务 panic 时按失败处理并取消后继；协程池关闭时尚未开始执行的节点被标记为已取消，Wait 不会一直阻塞。
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DAGNodeStatus 依赖图中一个节点的状态
+type DAGNodeStatus struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"`
+	Dependencies []string  `json:"dependencies"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// dagNode 依赖图节点
+type dagNode struct {
+	detail      *TaskDetail
+	dependents  []*dagNode
+	pendingDeps int // 尚未成功完成的依赖数
+	status      TaskStatus
+	startTime   time.Time
+	endTime     time.Time
+	err         error
+}
+
+// DAGRun 一次依赖图调度
+type DAGRun struct {
+	name  string
+	pool  *Pool
+	mu    sync.Mutex
+	nodes map[string]*dagNode
+	order []string // 拓扑顺序，用于状态展示
+	left  int      // 尚未结束的节点数
+	done  chan struct{}
+}
+
+// SubmitDAG 按依赖关系提交一组任务，tasks 中任务的依赖即使未列出也会一并调度。
+// name 用作协程池任务ID的前缀（name/任务ID）
+func (p *Pool) SubmitDAG(name string, tasks []*TaskDetail) (*DAGRun, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("task logger not initialized")
+	}
+	run := &DAGRun{
+		name:  name,
+		pool:  p,
+		nodes: make(map[string]*dagNode),
+		done:  make(chan struct{}),
+	}
+
+	// 收集所有节点（包括传递依赖）
+	var collect func(t *TaskDetail) error
+	collect = func(t *TaskDetail) error {
+		if existing, ok := run.nodes[t.ID]; ok {
+			if existing.detail != t {
+				return fmt.Errorf("duplicate task ID %s in DAG %s", t.ID, name)
+			}
+			return nil
+		}
+		if t.Retry != nil {
+			if err := t.Retry.validate(); err != nil {
+				return fmt.Errorf("invalid retry policy for task %s in DAG %s: %v", t.ID, name, err)
+			}
+		}
+		run.nodes[t.ID] = &dagNode{detail: t, status: TaskPending}
+		for _, dep := range t.Dependencies {
+			if err := collect(dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, t := range tasks {
+		if err := collect(t); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := run.topoSort()
+	if err != nil {
+		return nil, err
+	}
+	run.order = order
+	run.left = len(order)
+
+	for _, id := range order {
+		node := run.nodes[id]
+		node.pendingDeps = len(node.detail.Dependencies)
+		for _, dep := range node.detail.Dependencies {
+			depNode := run.nodes[dep.ID]
+			depNode.dependents = append(depNode.dependents, node)
+		}
+	}
+
+	stressLogger.Log("INFO", fmt.Sprintf("Submitting DAG %s with %d tasks", name, len(order)))
+	if run.left == 0 {
+		close(run.done)
+		return run, nil
+	}
+
+	var roots []*dagNode
+	for _, id := range order {
+		if node := run.nodes[id]; node.pendingDeps == 0 {
+			roots = append(roots, node)
+		}
+	}
+	for _, node := range roots {
+		run.schedule(node)
+	}
+	go run.abortOnShutdown()
+	return run, nil
+}
+
+// abortOnShutdown 协程池关闭时取消所有尚未开始执行的节点，正在执行的节点结束后照常记录结果
+func (r *DAGRun) abortOnShutdown() {
+	select {
+	case <-r.done:
+		return
+	case <-r.pool.closing.Done():
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range r.order {
+		r.cancelNode(r.nodes[id], fmt.Errorf("pool shut down before task %s started", id))
+	}
+}
+
+// cancelNode 将尚未开始执行的节点标记为已取消，调用方需持有锁
+func (r *DAGRun) cancelNode(node *dagNode, err error) {
+	if node.status != TaskPending {
+		return
+	}
+	node.status = TaskCancelled
+	node.endTime = time.Now()
+	node.err = err
+	r.left--
+	if r.left == 0 {
+		close(r.done)
+	}
+}
+
+// topoSort 返回节点的拓扑顺序，存在循环依赖时返回包含环路的错误
+func (r *DAGRun) topoSort() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(r.nodes))
+	var order, path []string
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			// 从环路起点截取路径
+			for i, p := range path {
+				if p == id {
+					cycle := append(append([]string{}, path[i:]...), id)
+					return fmt.Errorf("cycle detected in DAG %s: %s", r.name, strings.Join(cycle, " -> "))
+				}
+			}
+			return fmt.Errorf("cycle detected in DAG %s at task %s", r.name, id)
+		}
+		state[id] = visiting
+		path = append(path, id)
+		for _, dep := range r.nodes[id].detail.Dependencies {
+			if err := visit(dep.ID); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+		order = append(order, id)
+		return nil
+	}
+
+	// 按ID排序，保证状态展示顺序稳定
+	ids := make([]string, 0, len(r.nodes))
+	for id := range r.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// schedule 将依赖已满足的节点提交到协程池
+func (r *DAGRun) schedule(node *dagNode) {
+	taskID := fmt.Sprintf("%s/%s", r.name, node.detail.ID)
+	node.detail.onRetry = func(attempt int32) { r.pool.recordRetry(taskID, int(attempt)) }
+	_, deduplicated, err := r.pool.submit(func(int32, *TaskContext) error {
+		r.mu.Lock()
+		if node.status != TaskPending {
+			// 协程池关闭时已被取消
+			r.mu.Unlock()
+			return nil
+		}
+		node.status = TaskRunning
+		node.startTime = time.Now()
+		r.mu.Unlock()
+
+		var err error
+		defer func() {
+			if rec := recover(); rec != nil {
+				r.finish(node, fmt.Errorf("task %s panicked: %v", node.detail.ID, rec))
+				// 继续向上抛出，由协程池记录为失败
+				panic(rec)
+			}
+			r.finish(node, err)
+		}()
+		// 返回错误，由协程池记录为失败
+		err = node.detail.Start()
+		return err
+	}, "", int(node.detail.Priority), taskID, "", node.detail.Timeout, RetryPolicy{})
+	if err == nil && deduplicated {
+		err = fmt.Errorf("%w: %s", ErrDuplicateTask, taskID)
+	}
+	if err != nil && atomic.LoadInt32(&r.pool.shutdownFlag) == 1 {
+		// 协程池正在关闭，节点未能开始执行，按取消处理
+		r.mu.Lock()
+		r.cancelNode(node, fmt.Errorf("pool shut down before task %s started", node.detail.ID))
+		r.mu.Unlock()
+		return
+	}
+	if err != nil {
+		r.finish(node, err)
+	}
+}
+
+// finish 记录节点结果，成功时调度依赖已满足的后继，失败时取消所有后继
+func (r *DAGRun) finish(node *dagNode, err error) {
+	var ready []*dagNode
+
+	r.mu.Lock()
+	if node.status != TaskPending && node.status != TaskRunning {
+		// 节点已被取消（协程池关闭），不再重复计数
+		r.mu.Unlock()
+		return
+	}
+	node.endTime = time.Now()
+	if err != nil {
+		node.status = TaskFailed
+		if TaskStatus(atomic.LoadInt32((*int32)(&node.detail.Status))) == TaskTimeout {
+			node.status = TaskTimeout
+		}
+		node.err = err
+		stressLogger.Log("ERROR", fmt.Sprintf("DAG %s: task %s failed: %v", r.name, node.detail.ID, err))
+		r.cancelDependents(node)
+	} else {
+		node.status = TaskCompleted
+		for _, next := range node.dependents {
+			next.pendingDeps--
+			if next.pendingDeps == 0 && next.status == TaskPending {
+				ready = append(ready, next)
+			}
+		}
+	}
+	r.left--
+	if r.left == 0 {
+		close(r.done)
+	}
+	r.mu.Unlock()
+
+	for _, next := range ready {
+		r.schedule(next)
+	}
+}
+
+// cancelDependents 递归取消节点的所有后继，调用方需持有锁
+func (r *DAGRun) cancelDependents(node *dagNode) {
+	for _, next := range node.dependents {
+		if next.status != TaskPending {
+			continue
+		}
+		next.status = TaskCancelled
+		next.endTime = time.Now()
+		next.err = fmt.Errorf("dependency %s did not complete", node.detail.ID)
+		r.left--
+		r.cancelDependents(next)
+	}
+}
+
+// Status 返回所有节点的状态，按拓扑顺序排列
+func (r *DAGRun) Status() []DAGNodeStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]DAGNodeStatus, 0, len(r.order))
+	for _, id := range r.order {
+		node := r.nodes[id]
+		deps := make([]string, 0, len(node.detail.Dependencies))
+		for _, dep := range node.detail.Dependencies {
+			deps = append(deps, dep.ID)
+		}
+		status := DAGNodeStatus{
+			ID:           id,
+			Status:       node.status.String(),
+			Dependencies: deps,
+			StartTime:    node.startTime,
+			EndTime:      node.endTime,
+		}
+		if node.err != nil {
+			status.Error = node.err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Done 返回整组任务结束时关闭的通道
+func (r *DAGRun) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait 等待整组任务结束，有节点未成功完成时返回汇总错误
+func (r *DAGRun) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var failed []string
+	for _, status := range r.Status() {
+		if status.Status != TaskCompleted.String() {
+			failed = append(failed, fmt.Sprintf("%s (%s)", status.ID, status.Status))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("DAG %s finished with %d unsuccessful tasks: %s", r.name, len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// idempotency.go
+// 任务去重模块
+// 本文件负责提交时的幂等处理：同一个任务ID或幂等键在任务等待或执行期间重复提交，
+// 不会创建新任务，而是返回已存在的任务，便于 API 调用方在超时后安全重试。
+//
+// 技术实现细节：
+// 1. 去重表以任务ID和幂等键为键、以当前占用该键的任务为值，使用 LoadOrStore/CompareAndSwap 保证并发提交时只有一个成功。
+// 2. 任务结束（完成、失败或被取消）后释放其占用的键，之后同一个键可以再次提交。
+
+package pool
+
+import "sync/atomic"
+
+// dedupKeys 返回任务占用的去重键：任务ID总是参与去重，幂等键不为空时也参与去重
+func dedupKeys(taskID, idempotencyKey string) []string {
+	var keys []string
+	if taskID != "" {
+		keys = append(keys, "id:"+taskID)
+	}
+	if idempotencyKey != "" {
+		keys = append(keys, "key:"+idempotencyKey)
+	}
+	return keys
+}
+
+// active 判断任务是否仍在等待或执行
+func (t *Task) active() bool {
+	status := TaskStatus(atomic.LoadInt32(&t.status))
+	return status == TaskPending || status == TaskRunning
+}
+
+// claimDedupKeys 为任务占用全部去重键，任一键已被活跃任务占用时回滚并返回该任务和 false
+func (p *Pool) claimDedupKeys(task *Task) (*Task, bool) {
+	for i, key := range task.dedupKeys {
+		if existing, ok := p.claimDedupKey(key, task); !ok {
+			for _, claimed := range task.dedupKeys[:i] {
+				p.dedup.CompareAndDelete(claimed, task)
+			}
+			return existing, false
+		}
+	}
+	return nil, true
+}
+
+// claimDedupKey 为任务占用一个去重键，键已被活跃任务占用时返回该任务和 false
+func (p *Pool) claimDedupKey(key string, task *Task) (*Task, bool) {
+	for {
+		value, loaded := p.dedup.LoadOrStore(key, task)
+		if !loaded {
+			return nil, true
+		}
+		existing := value.(*Task)
+		if existing.active() {
+			return existing, false
+		}
+		// 旧任务已结束但尚未释放键，替换为新任务
+		if p.dedup.CompareAndSwap(key, existing, task) {
+			return nil, true
+		}
+	}
+}
+
+// releaseDedupKeys 释放任务占用的去重键
+func (p *Pool) releaseDedupKeys(task *Task) {
+	for _, key := range task.dedupKeys {
+		p.dedup.CompareAndDelete(key, task)
+	}
+}
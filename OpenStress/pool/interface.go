@@ -0,0 +1,51 @@
+// interface.go
+// 协程池接口模块
+// 本文件负责定义协程池的接口 TaskPool，由 *Pool 实现。
+// 依赖协程池的模块（例如 API 服务）通过接口使用协程池，嵌入 OpenStress 的使用者可以在单元测试中替换为 mock，
+// 也可以提供自己的实现。
+//
+// 技术实现细节：
+// 1. 接口只包含任务提交、查询、控制和调整配置的方法，构造、日志和监控等初始化相关的方法仍在 *Pool 上。
+// 2. 可以使用 mockgen 生成 gomock 实现（见下方 go:generate），生成的文件不提交到仓库。
+
+package pool
+
+import (
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+)
+
+//go:generate mockgen -destination=mock_pool.go -package=pool github.com/potatoImp/OpenStress/pool TaskPool
+
+// TaskPool 协程池接口
+type TaskPool interface {
+	// 生命周期控制
+	Start()
+	Pause()
+	Resume()
+	Shutdown()
+	Wait()
+
+	// 任务提交与查询
+	Submit(fn func(threadID int32), priority int, taskID string, timeout time.Duration) error
+	SubmitByName(name string, priority int, taskID, idempotencyKey string, timeout time.Duration) (TaskInfo, bool, error)
+	GetAvailableTasks() []string
+	GetRunningTasks() []TaskInfo
+	GetTaskStatus(taskID string) (*TaskInfo, error)
+	Stats() PoolStats
+
+	// 虚拟用户
+	StartRegisteredVUs(name string, cfg VUConfig) (*VURun, error)
+	StartMixVUs(mix *TrafficMix, cfg VUConfig) (*VURun, error)
+	StartRegisteredArrivals(name string, cfg ArrivalConfig) (*VURun, error)
+
+	// 并发数与限流
+	AdjustWorkers(newWorkerCount int)
+	SetRateLimit(rate float64, burst int) string
+	SetTaskRateLimit(taskType string, rate float64, burst int)
+	ApplyConfigChanges(changes []config.ConfigChange) error
+}
+
+// 编译期检查 *Pool 实现了 TaskPool
+var _ TaskPool = (*Pool)(nil)
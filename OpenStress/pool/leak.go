@@ -0,0 +1,200 @@
+// leak.go
+// 压测机内存泄漏检测模块
+// 本文件负责跟踪压测机（负载生成端）存活堆大小的趋势，持续上涨时判定为疑似内存泄漏，
+// 避免长时间压测中压测机自身内存耗尽、GC 变频繁而误判为被测服务变慢。
+//
+// 技术实现细节：
+// 1. 使用上一次 GC 后存活的堆大小（runtime/metrics 的 /gc/heap/live:bytes），不受 GC 前后的锯齿影响；
+//    运行时不支持该指标时退化为 MemStats.HeapAlloc。
+// 2. 对最近 Window 个采样做最小二乘线性回归，增长速度（斜率）不低于 MinGrowthPerMinute 且拟合优度 R²
+//    不低于 MinR2（持续上涨而不是偶尔的跳变）时判定为疑似泄漏；采样数不足 Window 时不判断，避开启动阶段的正常增长。
+// 3. 从不泄漏变为泄漏时记录一次 WARNING，之后持续泄漏期间只延长本次疑似区间，不重复告警；
+//    每次更新通过 Monitor.AddLeakSink 通知订阅者，例如在报告的资源使用图上标注疑似泄漏的时间段。
+
+package pool
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// 内存泄漏检测的默认参数
+const (
+	defaultLeakWindow             = 120
+	defaultLeakMinGrowthPerMinute = 1 << 20
+	defaultLeakMinR2              = 0.8
+)
+
+// heapLiveMetric 上一次 GC 后存活堆大小的运行时指标名
+const heapLiveMetric = "/gc/heap/live:bytes"
+
+// LeakDetection 压测机内存泄漏检测配置，零值使用默认参数
+type LeakDetection struct {
+	Disabled           bool    // 关闭检测
+	Window             int     // 参与线性回归的采样数，默认 120（1 秒采样间隔下为 2 分钟）
+	MinGrowthPerMinute uint64  // 判定为泄漏的最小增长速度（字节/分钟），默认 1MB
+	MinR2              float64 // 判定为持续增长的最小拟合优度，默认 0.8
+}
+
+// withDefaults 返回填充默认值后的配置
+func (c LeakDetection) withDefaults() LeakDetection {
+	if c.Window <= 0 {
+		c.Window = defaultLeakWindow
+	}
+	if c.Window < 3 {
+		c.Window = 3
+	}
+	if c.MinGrowthPerMinute == 0 {
+		c.MinGrowthPerMinute = defaultLeakMinGrowthPerMinute
+	}
+	if c.MinR2 <= 0 {
+		c.MinR2 = defaultLeakMinR2
+	}
+	return c
+}
+
+// LeakSuspicion 一段疑似内存泄漏的时间区间
+type LeakSuspicion struct {
+	Start           time.Time // 检测窗口中第一个采样的时间，即开始上涨的时间
+	End             time.Time // 最近一次仍判定为泄漏的采样时间
+	GrowthPerMinute float64   // 最近一次回归得到的增长速度（字节/分钟）
+	R2              float64   // 最近一次回归的拟合优度
+	HeapLive        uint64    // 最近一次采样的存活堆大小（字节）
+}
+
+// heapPoint 一次存活堆大小采样
+type heapPoint struct {
+	at    time.Time
+	bytes uint64
+}
+
+// heapTrend 存活堆大小的趋势，由采集 goroutine 更新，查询时加锁
+type heapTrend struct {
+	cfg     LeakDetection
+	samples []heapPoint
+
+	mu         sync.Mutex
+	suspicions []LeakSuspicion
+	suspecting bool // 最后一个疑似区间是否仍在持续
+}
+
+// newHeapTrend 创建趋势跟踪，关闭检测时返回 nil
+func newHeapTrend(cfg LeakDetection) *heapTrend {
+	if cfg.Disabled {
+		return nil
+	}
+	return &heapTrend{cfg: cfg.withDefaults()}
+}
+
+// add 记录一次采样，判定为泄漏时返回当前的疑似区间，started 表示本次采样开始了新的疑似区间
+func (h *heapTrend) add(at time.Time, bytes uint64) (suspicion LeakSuspicion, leaking, started bool) {
+	h.samples = append(h.samples, heapPoint{at: at, bytes: bytes})
+	if len(h.samples) > h.cfg.Window {
+		h.samples = h.samples[len(h.samples)-h.cfg.Window:]
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) < h.cfg.Window {
+		return LeakSuspicion{}, false, false
+	}
+	slope, r2 := linearTrend(h.samples)
+	growth := slope * 60
+	if growth < float64(h.cfg.MinGrowthPerMinute) || r2 < h.cfg.MinR2 {
+		h.suspecting = false
+		return LeakSuspicion{}, false, false
+	}
+
+	if !h.suspecting {
+		h.suspicions = append(h.suspicions, LeakSuspicion{Start: h.samples[0].at})
+		h.suspecting = true
+		started = true
+	}
+	current := &h.suspicions[len(h.suspicions)-1]
+	current.End = at
+	current.GrowthPerMinute = growth
+	current.R2 = r2
+	current.HeapLive = bytes
+	return *current, true, started
+}
+
+// result 返回所有疑似泄漏区间的副本
+func (h *heapTrend) result() []LeakSuspicion {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]LeakSuspicion(nil), h.suspicions...)
+}
+
+// linearTrend 对采样做最小二乘线性回归，返回斜率（字节/秒）和拟合优度 R²，数据没有变化时 R² 为 0
+func linearTrend(points []heapPoint) (slope, r2 float64) {
+	n := float64(len(points))
+	var sumX, sumY float64
+	for _, p := range points {
+		sumX += p.at.Sub(points[0].at).Seconds()
+		sumY += float64(p.bytes)
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for _, p := range points {
+		dx := p.at.Sub(points[0].at).Seconds() - meanX
+		dy := float64(p.bytes) - meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, 0
+	}
+	slope = sxy / sxx
+	r2 = sxy * sxy / (sxx * syy)
+	return slope, r2
+}
+
+// heapLive 返回上一次 GC 后存活的堆大小，运行时不支持时使用 HeapAlloc
+func heapLive(memStats *runtime.MemStats) uint64 {
+	sample := []metrics.Sample{{Name: heapLiveMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		return sample[0].Value.Uint64()
+	}
+	return memStats.HeapAlloc
+}
+
+// checkLeak 把采样计入存活堆趋势，开始疑似泄漏时记录警告，并通知订阅者
+func (m *Monitor) checkLeak(sample SystemMetrics) {
+	if m.heapTrend == nil {
+		return
+	}
+	suspicion, leaking, started := m.heapTrend.add(sample.Timestamp, sample.HeapLive)
+	if !leaking {
+		return
+	}
+	if started {
+		m.logger.Log("WARNING", fmt.Sprintf("Probable memory leak in the load generator: live heap growing %.2f MB/min since %s (R²=%.2f, now %.2f MB)",
+			suspicion.GrowthPerMinute/(1024*1024), suspicion.Start.Format("15:04:05"), suspicion.R2, float64(suspicion.HeapLive)/(1024*1024)))
+	}
+	m.sinksMu.RLock()
+	for _, sink := range m.leakSinks {
+		sink(suspicion)
+	}
+	m.sinksMu.RUnlock()
+}
+
+// AddLeakSink 订阅疑似内存泄漏，持续泄漏期间每次采样后以更新后的区间（Start 不变）在采集 goroutine 中同步调用 sink
+func (m *Monitor) AddLeakSink(sink func(LeakSuspicion)) {
+	m.sinksMu.Lock()
+	defer m.sinksMu.Unlock()
+	m.leakSinks = append(m.leakSinks, sink)
+}
+
+// LeakSuspicions 返回运行以来所有疑似内存泄漏的区间，未启用检测时返回 nil
+func (m *Monitor) LeakSuspicions() []LeakSuspicion {
+	if m.heapTrend == nil {
+		return nil
+	}
+	return m.heapTrend.result()
+}
@@ -4,30 +4,50 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/redact"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// StressLogger 表示一个日志记录器
+// StressLogger 表示一个日志记录器。
+// 每个模块使用自己的 StressLogger（记录中的 module 字段为模块名），同一进程内的所有模块共享同一个输出（logSink）
 type StressLogger struct {
+	sink   *logSink
+	module string
+}
+
+// logSink 所有模块日志记录器共享的异步输出
+type logSink struct {
 	logger       *zap.Logger
 	logChan      chan *LogEntry
 	wg           sync.WaitGroup
-	module       string
-	file         *lumberjack.Logger
+	file         *lumberjack.Logger // nil when file output is disabled
 	closed       bool
-	mu           sync.Mutex // Protects the closed flag and channels
+	mu           sync.Mutex // Protects the closed flag, channels and currentLevel
 	currentLevel zapcore.Level
+	levels       []zap.AtomicLevel           // Per-output level thresholds
+	modules      sync.Map                    // module name -> *StressLogger
+	subscribers  map[*logSubscriber]struct{} // Receivers of live log events, protected by mu
+
+	coreMu   sync.RWMutex    // Protects logger and remotes, which change when remote outputs are added or removed
+	baseCore zapcore.Core    // Local outputs (file, console)
+	remotes  []*remoteWriter // Remote outputs (Loki, Kafka)
 }
 
 // LogEntry 表示一条日志记录
 type LogEntry struct {
 	level   string
+	module  string
 	message string
+	time    time.Time           // 调用 Log 的时间
+	caller  zapcore.EntryCaller // 调用 Log 的位置，在调用时获取（写入在后台协程中进行）
 }
 
 // Declare a global variable to hold the logger instance
@@ -36,7 +56,8 @@ var globalLogger *StressLogger
 // DefaultLogLevel 默认日志级别，初始化为 INFO
 var DefaultLogLevel zapcore.Level = zap.InfoLevel
 
-// This function is now only responsible for starting the logger if not already started
+// GetLogger 返回第一次调用 InitializeLogger 时创建的日志记录器（保持原有行为），
+// 新代码应使用 GetModuleLogger 获取带模块名的日志记录器
 func GetLogger() (*StressLogger, error) {
 	if globalLogger == nil {
 		return nil, fmt.Errorf("logger not initialized")
@@ -44,80 +65,253 @@ func GetLogger() (*StressLogger, error) {
 	return globalLogger, nil
 }
 
+// GetModuleLogger 返回指定模块的日志记录器，与其他模块共享输出
+func GetModuleLogger(module string) (*StressLogger, error) {
+	if globalLogger == nil {
+		return nil, fmt.Errorf("logger not initialized")
+	}
+	return globalLogger.Named(module), nil
+}
+
 var once sync.Once
 
-// InitializeLogger 创建并初始化日志记录器
+// InitializeLogger 创建并初始化日志记录器，返回 moduleName 模块的日志记录器，使用默认日志配置（JSON 格式写文件）。
+// 输出只在第一次调用时创建（logDir/logFile 以第一次调用为准），之后的调用返回共享该输出的模块日志记录器
 func InitializeLogger(logDir, logFile, moduleName string) (*StressLogger, error) {
+	return InitializeLoggerWithConfig(logDir, logFile, moduleName, config.DefaultLogConfig())
+}
+
+// InitializeLoggerWithConfig 按日志配置创建输出（文件、控制台，各自的编码格式和最低级别），
+// 其余行为与 InitializeLogger 相同
+func InitializeLoggerWithConfig(logDir, logFile, moduleName string, logConfig config.LogConfig) (*StressLogger, error) {
 	var err error
 	once.Do(func() {
 		if globalLogger != nil {
 			return
 		}
 
-		// Ensure the log directory exists
-		if err = os.MkdirAll(logDir, os.ModePerm); err != nil {
+		var sink *logSink
+		if sink, err = newLogSink(logDir, logFile, logConfig); err != nil {
 			return
 		}
 
-		fileWriter := &lumberjack.Logger{
+		// Start the logger's asynchronous processing
+		sink.start()
+
+		globalLogger = sink.named(moduleName)
+		stressLogger = sink.named("pool")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if globalLogger == nil {
+		return nil, fmt.Errorf("logger not initialized")
+	}
+	return globalLogger.Named(moduleName), nil
+}
+
+// newLogSink 按日志配置创建共享输出，每个输出使用独立的编码器和级别
+func newLogSink(logDir, logFile string, logConfig config.LogConfig) (*logSink, error) {
+	sink := &logSink{
+		logChan: make(chan *LogEntry, 10000),
+		closed:  false,
+	}
+
+	var cores []zapcore.Core
+	if logConfig.FileEnabled {
+		// Ensure the log directory exists
+		if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+		sink.file = &lumberjack.Logger{
 			Filename:   logDir + logFile,
 			MaxSize:    10,
 			MaxBackups: 3,
 			MaxAge:     28,
 			Compress:   true,
 		}
+		core, err := sink.newCore(logConfig.FileEncoder, false, logConfig.FileLevel, zapcore.AddSync(sink.file))
+		if err != nil {
+			return nil, fmt.Errorf("invalid file log config: %v", err)
+		}
+		cores = append(cores, core)
+	}
+	if logConfig.ConsoleEnabled {
+		core, err := sink.newCore(logConfig.ConsoleEncoder, logConfig.ConsoleColor, logConfig.ConsoleLevel, zapcore.Lock(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("invalid console log config: %v", err)
+		}
+		cores = append(cores, core)
+	}
+	if len(cores) == 0 {
+		return nil, fmt.Errorf("no log output enabled")
+	}
+
+	sink.baseCore = zapcore.NewTee(cores...)
+	sink.logger = zap.New(sink.baseCore)
+	sink.updateLevel()
+
+	// 远程输出（Loki/Kafka）
+	if logConfig.RemoteConfigPath != "" {
+		remoteConfig, err := config.LoadRemoteLogConfig(logConfig.RemoteConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := sink.addRemotes(remoteConfig); err != nil {
+			return nil, fmt.Errorf("invalid remote log config: %v", err)
+		}
+	}
+	return sink, nil
+}
 
-		encoderConfig := zap.NewProductionEncoderConfig()
-		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
-		encoderConfig.EncodeCaller = zapcore.FullCallerEncoder
-		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-
-		core := zapcore.NewCore(
-			zapcore.NewJSONEncoder(encoderConfig),
-			// Write only to file
-			zapcore.AddSync(fileWriter),
-			DefaultLogLevel, // Use the global default level
-		)
-
-		logger := zap.New(core)
-
-		stressLogger = &StressLogger{
-			logger:       logger,
-			logChan:      make(chan *LogEntry, 10000),
-			module:       moduleName,
-			file:         fileWriter,
-			closed:       false,
-			currentLevel: DefaultLogLevel,
+// newCore 创建一个输出，encoding 为 "json" 或 "console"，level 为空时使用 DefaultLogLevel
+func (s *logSink) newCore(encoding string, color bool, level string, writer zapcore.WriteSyncer) (zapcore.Core, error) {
+	zapLevel := DefaultLogLevel
+	if level != "" {
+		var err error
+		if zapLevel, err = parseLogLevel(level); err != nil {
+			return nil, err
 		}
+	}
+	atomicLevel := zap.NewAtomicLevelAt(zapLevel)
 
-		// Start the logger's asynchronous processing
-		stressLogger.start()
+	encoder, err := newEncoder(encoding, color)
+	if err != nil {
+		return nil, err
+	}
 
-		globalLogger = stressLogger
-	})
-	return globalLogger, err
+	s.levels = append(s.levels, atomicLevel)
+	return zapcore.NewCore(encoder, writer, atomicLevel), nil
+}
+
+// newEncoder 创建日志编码器，"console" 为可读格式，color 为 true 时用颜色区分级别
+func newEncoder(encoding string, color bool) (zapcore.Encoder, error) {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
+	encoderConfig.EncodeCaller = zapcore.FullCallerEncoder
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+
+	switch encoding {
+	case "", "json":
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	case "console":
+		if color {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	default:
+		return nil, fmt.Errorf("unsupported log encoder: %s", encoding)
+	}
+}
+
+// updateLevel 将入队过滤级别设为各输出（包括远程输出）中最低的级别，低于所有输出级别的日志不再进入队列，
+// 调用方需持有 s.mu
+func (s *logSink) updateLevel() {
+	levels := append([]zap.AtomicLevel(nil), s.levels...)
+	s.coreMu.RLock()
+	for _, remote := range s.remotes {
+		levels = append(levels, remote.level)
+	}
+	s.coreMu.RUnlock()
+
+	s.currentLevel = zapcore.InvalidLevel
+	for _, level := range levels {
+		if s.currentLevel == zapcore.InvalidLevel || level.Level() < s.currentLevel {
+			s.currentLevel = level.Level()
+		}
+	}
+}
+
+// rebuildLogger 由本地输出和远程输出重新组合 zap.Logger，调用方需持有 s.coreMu
+func (s *logSink) rebuildLogger() {
+	cores := []zapcore.Core{s.baseCore}
+	for _, remote := range s.remotes {
+		cores = append(cores, remote.core)
+	}
+	s.logger = zap.New(zapcore.NewTee(cores...))
+}
+
+// parseLogLevel 将日志级别名称转换为 zap 级别
+func parseLogLevel(level string) (zapcore.Level, error) {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return zap.DebugLevel, nil
+	case "INFO":
+		return zap.InfoLevel, nil
+	case "WARN", "WARNING":
+		return zap.WarnLevel, nil
+	case "ERROR":
+		return zap.ErrorLevel, nil
+	default:
+		return zap.InfoLevel, fmt.Errorf("invalid log level: %s", level)
+	}
+}
+
+// Named 返回指定模块的日志记录器，与当前日志记录器共享输出
+func (l *StressLogger) Named(module string) *StressLogger {
+	if module == l.module {
+		return l
+	}
+	return l.sink.named(module)
+}
+
+// FilePath 返回日志文件路径，未启用文件输出时返回空字符串
+func (l *StressLogger) FilePath() string {
+	if l.sink.file == nil {
+		return ""
+	}
+	return l.sink.file.Filename
+}
+
+// Module 返回日志记录器的模块名
+func (l *StressLogger) Module() string {
+	return l.module
+}
+
+// named 返回（必要时创建）指定模块的日志记录器
+func (s *logSink) named(module string) *StressLogger {
+	if existing, ok := s.modules.Load(module); ok {
+		return existing.(*StressLogger)
+	}
+	actual, _ := s.modules.LoadOrStore(module, &StressLogger{sink: s, module: module})
+	return actual.(*StressLogger)
 }
 
 // Log records a log entry
 func (l *StressLogger) Log(level string, message string) {
-	// Create a log entry
+	l.log(1, level, message)
+}
+
+// LogDepth 与 Log 相同，skip 为额外跳过的调用层数，用于日志封装函数把调用位置记为封装函数的调用方
+func (l *StressLogger) LogDepth(skip int, level string, message string) {
+	l.log(skip+1, level, message)
+}
+
+// log 记录日志，skip 为从 log 的调用方开始跳过的调用层数
+func (l *StressLogger) log(skip int, level string, message string) {
+	// Create a log entry, the caller must be captured here rather than in the background goroutine
 	logMessage := &LogEntry{
 		level:   level,
-		message: message,
+		module:  l.module,
+		message: redact.String(message), // Secrets never reach any output, including live subscribers
+		time:    time.Now(),
+		caller:  zapcore.NewEntryCaller(runtime.Caller(skip + 1)),
 	}
 
+	s := l.sink
 	// Locking here to make sure the channel is not closed while logging
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if l.closed {
+	if s.closed {
 		return // If the logger is closed, do not log
 	}
 
 	// Only log the message if its level is >= current log level
-	if levelPriority(level) >= levelPriority(l.currentLevel.String()) {
+	if levelPriority(level) >= levelPriority(strings.ToUpper(s.currentLevel.String())) {
 		// Push the log message into the channel for asynchronous processing
-		l.logChan <- logMessage
+		s.logChan <- logMessage
+		s.publish(logMessage)
 	}
 }
 
@@ -128,7 +322,7 @@ func levelPriority(level string) int {
 		return 1
 	case "INFO":
 		return 2
-	case "WARN":
+	case "WARN", "WARNING":
 		return 3
 	case "ERROR":
 		return 4
@@ -138,123 +332,127 @@ func levelPriority(level string) int {
 }
 
 // start begins the process of handling log messages asynchronously
-func (l *StressLogger) start() {
-	l.wg.Add(1)
+func (s *logSink) start() {
+	s.wg.Add(1)
 	go func() {
-		defer l.wg.Done()
+		defer s.wg.Done()
 
 		// Batch logs asynchronously
 		var logs []LogEntry
 
-		for logMsg := range l.logChan {
+		for logMsg := range s.logChan {
 			logs = append(logs, *logMsg)
 
 			// Process logs when there are 10 or more
 			if len(logs) >= 10 {
-				l.flushLogs(logs)
+				s.flushLogs(logs)
 				logs = nil
 			}
 		}
 
 		// Process any remaining logs
 		if len(logs) > 0 {
-			l.flushLogs(logs)
+			s.flushLogs(logs)
 		}
 	}()
 }
 
-// flushLogs writes a batch of logs to the storage
-func (l *StressLogger) flushLogs(logs []LogEntry) {
+// flushLogs writes a batch of logs to the storage.
+// zap's own caller annotation would point at this goroutine, so the entry time and caller
+// captured in Log are set on the entry directly.
+func (s *logSink) flushLogs(logs []LogEntry) {
 	for _, logMsg := range logs {
-		// Get stack trace information
-		_, file, line, ok := runtime.Caller(2) // Get the stack trace of the log function call
-		if !ok {
-			file = "unknown"
-			line = 0
-		}
-
-		// Get the current timestamp
-		currentTime := time.Now().Format("2006-01-02 15:04:05.000")
 		logEntry := map[string]interface{}{
-			"timestamp": currentTime,
+			"timestamp": logMsg.time.Format("2006-01-02 15:04:05.000"),
 			"level":     logMsg.level,
-			"module":    l.module,
+			"module":    logMsg.module,
 			"message":   logMsg.message,
 		}
 
 		// Record logs based on their level
+		var zapLevel zapcore.Level
 		switch logMsg.level {
 		case "INFO":
-			l.logger.Info(logMsg.message, zap.Any("details", logEntry))
-		case "ERROR", "DEBUG":
+			zapLevel = zap.InfoLevel
+		case "WARN", "WARNING":
+			zapLevel = zap.WarnLevel
+		case "ERROR":
+			zapLevel = zap.ErrorLevel
+		default:
+			zapLevel = zap.DebugLevel
+		}
+		if zapLevel == zap.ErrorLevel || zapLevel == zap.DebugLevel {
+			file, line := "unknown", 0
+			if logMsg.caller.Defined {
+				file, line = logMsg.caller.File, logMsg.caller.Line
+			}
 			logEntry["file"] = file
 			logEntry["line"] = line
-			l.logger.Error(logMsg.message, zap.Any("details", logEntry))
-		default:
-			l.logger.Debug(logMsg.message, zap.Any("details", logEntry))
 		}
+
+		// 持有读锁直到写完，移除远程输出时不会丢失正在写入的日志
+		s.coreMu.RLock()
+		if checked := s.logger.Check(zapLevel, logMsg.message); checked != nil {
+			checked.Time = logMsg.time
+			checked.Caller = logMsg.caller
+			checked.Write(zap.Any("details", logEntry))
+		}
+		s.coreMu.RUnlock()
 	}
 }
 
-// Close stops the logger and ensures all logs are written
+// Close stops the logger and ensures all logs are written.
+// All module loggers share one output, so closing any of them closes it for every module.
 func (l *StressLogger) Close() {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	s := l.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if l.closed {
+	if s.closed {
 		return // If the logger is already closed, return
 	}
 
 	// Close the log channel
-	l.closed = true
-	close(l.logChan) // Close the log channel
-	l.wg.Wait()      // Wait for all logs to be processed
-	if l.file != nil {
-		l.file.Close()
+	s.closed = true
+	s.closeSubscribers()
+	close(s.logChan) // Close the log channel
+	s.wg.Wait()      // Wait for all logs to be processed
+	if s.file != nil {
+		s.file.Close()
+	}
+
+	// Send what is left in the remote outputs' buffers
+	s.coreMu.Lock()
+	remotes := s.remotes
+	s.remotes = nil
+	s.rebuildLogger()
+	s.coreMu.Unlock()
+	for _, remote := range remotes {
+		if err := remote.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close remote log sink: %v\n", err)
+		}
 	}
 }
 
-// SetLogLevel 动态设置日志级别
+// SetLogLevel 动态设置日志级别，同时作用于所有输出
 func SetLogLevel(level string) error {
-	var zapLevel zapcore.Level
-	switch level {
-	case "DEBUG":
-		zapLevel = zap.DebugLevel
-	case "INFO":
-		zapLevel = zap.InfoLevel
-	case "WARN":
-		zapLevel = zap.WarnLevel
-	case "ERROR":
-		zapLevel = zap.ErrorLevel
-	default:
-		return fmt.Errorf("invalid log level: %s", level)
+	zapLevel, err := parseLogLevel(level)
+	if err != nil {
+		return err
 	}
 
-	// Update the global logger level
+	// Update the global default level
 	DefaultLogLevel = zapLevel
-
-	// Update the logger core
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
-	encoderConfig.EncodeCaller = zapcore.FullCallerEncoder
-	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-
-	fileWriter := &lumberjack.Logger{
-		Filename:   "logs/app.log",
-		MaxSize:    10,
-		MaxBackups: 3,
-		MaxAge:     28,
-		Compress:   true,
+	if globalLogger == nil {
+		return nil
 	}
 
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(fileWriter),
-		DefaultLogLevel, // Use the updated global level
-	)
-
-	// Recreate the logger with the new level
-	globalLogger.logger = zap.New(core)
-
+	s := globalLogger.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, atomicLevel := range s.levels {
+		atomicLevel.SetLevel(zapLevel)
+	}
+	s.updateLevel()
 	return nil
 }
@@ -0,0 +1,92 @@
+// logstream.go
+// 日志订阅模块
+// 本文件负责把日志实时转发给订阅者，例如 API 服务的实时进度推送把 WARN / ERROR 日志推送到浏览器。
+//
+// 技术实现细节：
+// 1. 订阅挂在所有模块共享的输出（logSink）上，任一模块的日志都会转发。
+// 2. 在 Log 调用时转发（而不是在后台批量写入时），订阅者能及时收到；只转发达到输出级别且不低于订阅级别的日志。
+// 3. 转发不阻塞记录日志的协程：订阅者的缓冲区已满时丢弃该条日志，并在 LogEvent.Dropped 中累计丢弃数。
+
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LogEvent 转发给订阅者的一条日志
+type LogEvent struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Module  string    `json:"module"`
+	Message string    `json:"message"`
+	Dropped int       `json:"dropped,omitempty"` // 上一条转发之后因缓冲区已满丢弃的日志数
+}
+
+// logSubscriber 一个日志订阅者
+type logSubscriber struct {
+	minLevel int
+	ch       chan LogEvent
+	dropped  int
+}
+
+// Subscribe 订阅级别不低于 minLevel（DEBUG、INFO、WARN、ERROR）的日志，buffer 为缓冲的日志条数。
+// 返回的函数取消订阅并关闭通道；日志记录器关闭时通道同样会被关闭
+func (l *StressLogger) Subscribe(minLevel string, buffer int) (<-chan LogEvent, func(), error) {
+	level := levelPriority(strings.ToUpper(minLevel))
+	if level == 0 {
+		return nil, nil, fmt.Errorf("unknown log level %q", minLevel)
+	}
+	if buffer <= 0 {
+		buffer = 1
+	}
+	sub := &logSubscriber{minLevel: level, ch: make(chan LogEvent, buffer)}
+
+	s := l.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(sub.ch)
+		return sub.ch, func() {}, nil
+	}
+	if s.subscribers == nil {
+		s.subscribers = make(map[*logSubscriber]struct{})
+	}
+	s.subscribers[sub] = struct{}{}
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[sub]; ok {
+			delete(s.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, unsubscribe, nil
+}
+
+// publish 把日志转发给订阅者，调用方持有 s.mu
+func (s *logSink) publish(entry *LogEntry) {
+	level := levelPriority(entry.level)
+	for sub := range s.subscribers {
+		if level < sub.minLevel {
+			continue
+		}
+		event := LogEvent{Time: entry.time, Level: entry.level, Module: entry.module, Message: entry.message, Dropped: sub.dropped}
+		select {
+		case sub.ch <- event:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// closeSubscribers 日志记录器关闭时关闭所有订阅者的通道，调用方持有 s.mu
+func (s *logSink) closeSubscribers() {
+	for sub := range s.subscribers {
+		close(sub.ch)
+	}
+	s.subscribers = nil
+}
@@ -0,0 +1,131 @@
+// mix.go
+// 流量配比模块
+// 本文件负责按权重混合多个已注册任务：每个 VU 每次迭代按权重随机选择一个任务执行，
+// 例如 70% 浏览、20% 搜索、10% 下单，模拟真实用户的业务分布，结束后给出实际达到的配比。
+//
+// 技术实现细节：
+// 1. 权重为正整数，目标占比为 权重 / 权重之和；选择时在 [0, 权重之和) 内取随机数，按累计权重二分查找。
+// 2. 随机数来自 VU 独占的 VUContext.Rand，相同的全局随机种子下每个 VU 选择的任务序列可以复现。
+// 3. 每个任务的迭代次数用原子计数累计（包括预热阶段，与 VURun 的总迭代次数口径一致），
+//    TrafficMix.Shares 返回目标占比和实际占比，由调用方通过 Collector.SetTrafficMix 写入报告。
+// 4. 混合运行使用 VUConfig.Hooks，各任务通过 RegisterVUHooks 设置的钩子不生效。
+
+package pool
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+)
+
+// MixEntry 流量配比中的一个任务及其权重
+type MixEntry struct {
+	Task   string `json:"task"`   // 已注册的任务名
+	Weight int    `json:"weight"` // 权重，必须为正数
+}
+
+// MixShare 一个任务的目标占比和实际占比
+type MixShare struct {
+	Task       string
+	Weight     int
+	Target     float64 // 目标占比（百分比）
+	Iterations int64   // 实际执行的迭代次数
+	Actual     float64 // 实际占比（百分比），没有迭代时为 0
+}
+
+// TrafficMix 按权重选择任务的流量配比，可以被多个 VU 并发使用
+type TrafficMix struct {
+	entries    []MixEntry
+	cumulative []int // 累计权重，用于二分查找
+	total      int   // 权重之和
+	counts     []atomic.Int64
+}
+
+// NewTrafficMix 创建流量配比，任务名为空、重复或权重不为正数时返回错误
+func NewTrafficMix(entries []MixEntry) (*TrafficMix, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("traffic mix must contain at least one task")
+	}
+	m := &TrafficMix{
+		entries:    append([]MixEntry(nil), entries...),
+		cumulative: make([]int, len(entries)),
+		counts:     make([]atomic.Int64, len(entries)),
+	}
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		if entry.Task == "" {
+			return nil, fmt.Errorf("traffic mix entry %d has no task name", i)
+		}
+		if seen[entry.Task] {
+			return nil, fmt.Errorf("task %s appears more than once in the traffic mix", entry.Task)
+		}
+		seen[entry.Task] = true
+		if entry.Weight <= 0 {
+			return nil, fmt.Errorf("weight of task %s must be positive, got %d", entry.Task, entry.Weight)
+		}
+		m.total += entry.Weight
+		m.cumulative[i] = m.total
+	}
+	return m, nil
+}
+
+// Tasks 返回配比中的任务名，顺序与创建时一致
+func (m *TrafficMix) Tasks() []string {
+	tasks := make([]string, len(m.entries))
+	for i, entry := range m.entries {
+		tasks[i] = entry.Task
+	}
+	return tasks
+}
+
+// pick 按权重随机选择一个任务，返回其下标并计入该任务的迭代次数
+func (m *TrafficMix) pick(r *rand.Rand) int {
+	n := r.Intn(m.total)
+	i := sort.SearchInts(m.cumulative, n+1)
+	m.counts[i].Add(1)
+	return i
+}
+
+// Pick 按权重随机选择一个任务并计入其迭代次数，用于自行调度任务的调用方
+func (m *TrafficMix) Pick(r *rand.Rand) string {
+	return m.entries[m.pick(r)].Task
+}
+
+// Shares 返回各任务的目标占比和截至目前的实际占比，顺序与创建时一致
+func (m *TrafficMix) Shares() []MixShare {
+	var total int64
+	shares := make([]MixShare, len(m.entries))
+	for i, entry := range m.entries {
+		shares[i] = MixShare{
+			Task:       entry.Task,
+			Weight:     entry.Weight,
+			Target:     float64(entry.Weight) * 100 / float64(m.total),
+			Iterations: m.counts[i].Load(),
+		}
+		total += shares[i].Iterations
+	}
+	if total > 0 {
+		for i := range shares {
+			shares[i].Actual = float64(shares[i].Iterations) * 100 / float64(total)
+		}
+	}
+	return shares
+}
+
+// StartMixVUs 以虚拟用户方式按流量配比运行多个已注册的任务，每次迭代按权重选择一个任务，
+// 任务函数收到的 threadID 为 VU 编号。所有任务必须已注册，钩子使用 cfg.Hooks
+func (p *Pool) StartMixVUs(mix *TrafficMix, cfg VUConfig) (*VURun, error) {
+	if mix == nil {
+		return nil, fmt.Errorf("traffic mix is nil")
+	}
+	fns := make([]func(threadID int32), len(mix.entries))
+	for i, entry := range mix.entries {
+		value, ok := p.registry.Load(entry.Task)
+		if !ok {
+			return nil, fmt.Errorf("task %s is not registered", entry.Task)
+		}
+		fns[i] = value.(func(threadID int32))
+	}
+	return p.StartVUs(cfg, func(vu *VUContext) { fns[mix.pick(vu.Rand)](int32(vu.VUID)) })
+}
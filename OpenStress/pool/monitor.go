@@ -28,7 +28,7 @@
 //
 // 4. 异常监控日志
 //    - 记录异常的系统行为
-//    - 记录资源泄漏警告
+//    - 记录资源泄漏警告（存活堆持续上涨时判定为疑似内存泄漏，见 leak.go）
 //    - 记录系统瓶颈
 //    - 使用 WARNING 或 ERROR 级别记录异常情况
 //
@@ -50,6 +50,7 @@ package pool
 import (
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -59,14 +60,20 @@ type ResourceThresholds struct {
 	MaxCPUUsage    float64 // CPU 使用率阈值
 	MaxMemoryUsage uint64  // 内存使用阈值（字节）
 	MaxGoroutines  int     // goroutine 数量阈值
+
+	// 内存泄漏检测（见 leak.go），零值使用默认参数
+	Leak LeakDetection
 }
 
 // TaskStats 任务统计信息
 type TaskStats struct {
-	TotalTasks     int64         // 总任务数
+	TotalTasks     int64         // 总任务数（已提交）
+	RunningTasks   int64         // 正在执行的任务数
 	CompletedTasks int64         // 完成的任务数
-	FailedTasks    int64         // 失败的任务数
-	AverageTime    time.Duration // 平均执行时间
+	FailedTasks    int64         // 失败的任务数（包括超时）
+	CancelledTasks int64         // 取消的任务数
+	Retries        int64         // 重试次数
+	AverageTime    time.Duration // 平均执行时间（仅统计完成的任务）
 }
 
 // statsData 内部使用的带锁的任务统计信息
@@ -81,26 +88,48 @@ type SystemMetrics struct {
 	MemoryUsage uint64  // 内存使用量
 	Goroutines  int     // goroutine 数量
 	Timestamp   time.Time
+
+	// 上一次 GC 后存活的堆大小（字节），不受 GC 锯齿影响，用于内存泄漏检测
+	HeapLive uint64
 }
 
-// TaskStatusUpdate 任务状态更新信息
+// TaskStatusUpdate 任务状态更新信息。
+// OldStatus 与 NewStatus 都为 TaskPending 表示任务提交；Retry 为 true 表示一次重试
 type TaskStatusUpdate struct {
 	TaskID        string
 	OldStatus     TaskStatus
 	NewStatus     TaskStatus
 	ExecutionTime time.Duration
+	Retry         bool
+	Attempt       int  // 第几次重试，仅 Retry 为 true 时有效
+	Submitted     bool // 任务提交事件，此时没有之前的状态，OldStatus 无意义
 }
 
 // Monitor 监控器结构体
 type Monitor struct {
-	logger          *StressLogger
-	taskStats       *statsData
-	thresholds      ResourceThresholds
-	metricsChan     chan SystemMetrics
+	logger           *StressLogger
+	taskStats        *statsData
+	thresholds       ResourceThresholds
+	metricsChan      chan SystemMetrics
 	statusUpdateChan chan TaskStatusUpdate
-	stopChan        chan struct{}
-	interval        time.Duration
-	wg              sync.WaitGroup
+	stopChan         chan struct{}
+	interval         time.Duration
+	wg               sync.WaitGroup
+
+	// 最新一次采样结果，以及计算 CPU 使用率所需的上一次采样
+	latestMu      sync.RWMutex
+	latest        SystemMetrics
+	hasLatest     bool
+	lastCPUTime   time.Duration
+	lastCPUSample time.Time
+
+	// 每次采样后回调的订阅者，例如把资源使用情况写入结果收集器
+	sinksMu sync.RWMutex
+	sinks   []func(SystemMetrics)
+
+	// 存活堆大小的趋势和疑似内存泄漏的订阅者（见 leak.go），leakSinks 由 sinksMu 保护
+	heapTrend *heapTrend
+	leakSinks []func(LeakSuspicion)
 }
 
 // NewMonitor 创建新的监控器实例
@@ -110,11 +139,12 @@ func NewMonitor(logger *StressLogger, interval time.Duration, thresholds Resourc
 		taskStats: &statsData{
 			stats: TaskStats{},
 		},
-		thresholds:      thresholds,
-		metricsChan:     make(chan SystemMetrics, 100),
+		thresholds:       thresholds,
+		metricsChan:      make(chan SystemMetrics, 100),
 		statusUpdateChan: make(chan TaskStatusUpdate, 1000),
-		stopChan:        make(chan struct{}),
-		interval:        interval,
+		stopChan:         make(chan struct{}),
+		interval:         interval,
+		heapTrend:        newHeapTrend(thresholds.Leak),
 	}
 }
 
@@ -147,13 +177,37 @@ func (m *Monitor) RecordTaskStatus(taskID string, oldStatus, newStatus TaskStatu
 		ExecutionTime: executionTime,
 	}
 
-	// 异步发送状态更新
+	m.sendUpdate(update)
+}
+
+// RecordTaskSubmitted 记录任务提交（异步），提交是任务的第一个事件，没有之前的状态
+func (m *Monitor) RecordTaskSubmitted(taskID string) {
+	m.sendUpdate(TaskStatusUpdate{
+		TaskID:    taskID,
+		NewStatus: TaskPending,
+		Submitted: true,
+	})
+}
+
+// RecordTaskRetry 记录任务重试（异步），attempt 为第几次重试
+func (m *Monitor) RecordTaskRetry(taskID string, attempt int) {
+	m.sendUpdate(TaskStatusUpdate{
+		TaskID:    taskID,
+		OldStatus: TaskFailed,
+		NewStatus: TaskRunning,
+		Retry:     true,
+		Attempt:   attempt,
+	})
+}
+
+// sendUpdate 异步发送状态更新
+func (m *Monitor) sendUpdate(update TaskStatusUpdate) {
 	select {
 	case m.statusUpdateChan <- update:
 		// 成功发送到通道
 	default:
 		// 通道已满，记录警告
-		m.logger.Log("WARNING", fmt.Sprintf("Status update channel full, dropping update for task %s", taskID))
+		m.logger.Log("WARNING", fmt.Sprintf("Status update channel full, dropping update for task %s", update.TaskID))
 	}
 }
 
@@ -175,17 +229,37 @@ func (m *Monitor) updateTaskStats(update TaskStatusUpdate) {
 	m.taskStats.mu.Lock()
 	defer m.taskStats.mu.Unlock()
 
+	stats := &m.taskStats.stats
+	if update.Retry {
+		stats.Retries++
+		m.logger.Log("WARNING", fmt.Sprintf("Task %s retrying (attempt %d)", update.TaskID, update.Attempt))
+		return
+	}
+	if update.Submitted {
+		stats.TotalTasks++
+		return
+	}
+
+	// 离开执行状态时减少执行中的任务数
+	if update.OldStatus == TaskRunning && update.NewStatus != TaskRunning && stats.RunningTasks > 0 {
+		stats.RunningTasks--
+	}
+
 	// 更新统计信息
 	switch update.NewStatus {
+	case TaskRunning:
+		stats.RunningTasks++
 	case TaskCompleted:
-		m.taskStats.stats.CompletedTasks++
-		m.taskStats.stats.AverageTime = (m.taskStats.stats.AverageTime*time.Duration(m.taskStats.stats.CompletedTasks-1) + update.ExecutionTime) / time.Duration(m.taskStats.stats.CompletedTasks)
+		stats.CompletedTasks++
+		stats.AverageTime = (stats.AverageTime*time.Duration(stats.CompletedTasks-1) + update.ExecutionTime) / time.Duration(stats.CompletedTasks)
 		m.logger.Log("INFO", fmt.Sprintf("Task %s completed in %v", update.TaskID, update.ExecutionTime))
-	case TaskFailed:
-		m.taskStats.stats.FailedTasks++
-		m.logger.Log("ERROR", fmt.Sprintf("Task %s failed after %v", update.TaskID, update.ExecutionTime))
+	case TaskFailed, TaskTimeout:
+		stats.FailedTasks++
+		m.logger.Log("ERROR", fmt.Sprintf("Task %s %s after %v", update.TaskID, strings.ToLower(update.NewStatus.String()), update.ExecutionTime))
+	case TaskCancelled:
+		stats.CancelledTasks++
+		m.logger.Log("INFO", fmt.Sprintf("Task %s cancelled", update.TaskID))
 	}
-	m.taskStats.stats.TotalTasks++
 }
 
 // collectMetrics 收集系统指标
@@ -200,12 +274,34 @@ func (m *Monitor) collectMetrics() {
 			return
 		case <-ticker.C:
 			metrics := m.getSystemMetrics()
-			m.metricsChan <- metrics
+			m.latestMu.Lock()
+			m.latest = metrics
+			m.hasLatest = true
+			m.latestMu.Unlock()
+
+			// 通道已满时丢弃，避免无人消费时阻塞采集
+			select {
+			case m.metricsChan <- metrics:
+			default:
+			}
+			m.sinksMu.RLock()
+			for _, sink := range m.sinks {
+				sink(metrics)
+			}
+			m.sinksMu.RUnlock()
 			m.checkThresholds(metrics)
+			m.checkLeak(metrics)
 		}
 	}
 }
 
+// AddMetricsSink 订阅系统指标采样，每次采样后在采集 goroutine 中同步调用 sink，sink 不应阻塞
+func (m *Monitor) AddMetricsSink(sink func(SystemMetrics)) {
+	m.sinksMu.Lock()
+	defer m.sinksMu.Unlock()
+	m.sinks = append(m.sinks, sink)
+}
+
 // getSystemMetrics 获取系统指标
 func (m *Monitor) getSystemMetrics() SystemMetrics {
 	var memStats runtime.MemStats
@@ -215,14 +311,33 @@ func (m *Monitor) getSystemMetrics() SystemMetrics {
 		MemoryUsage: memStats.Alloc,
 		Goroutines:  runtime.NumGoroutine(),
 		Timestamp:   time.Now(),
-		// Note: CPU 使用率的计算需要更复杂的实现
-		// 这里简化处理
-		CPUUsage: 0.0,
+		CPUUsage:    m.cpuUsage(),
+		HeapLive:    heapLive(&memStats),
 	}
 
 	return metrics
 }
 
+// cpuUsage 计算两次采样之间进程的 CPU 使用率（占全部 CPU 核心的百分比），首次采样返回 0
+func (m *Monitor) cpuUsage() float64 {
+	cpuTime, ok := processCPUTime()
+	if !ok {
+		return 0
+	}
+	now := time.Now()
+
+	var usage float64
+	if !m.lastCPUSample.IsZero() {
+		wall := now.Sub(m.lastCPUSample)
+		if wall > 0 {
+			usage = float64(cpuTime-m.lastCPUTime) / (float64(wall) * float64(runtime.NumCPU())) * 100
+		}
+	}
+	m.lastCPUTime = cpuTime
+	m.lastCPUSample = now
+	return usage
+}
+
 // checkThresholds 检查系统指标是否超过阈值
 func (m *Monitor) checkThresholds(metrics SystemMetrics) {
 	if metrics.CPUUsage > m.thresholds.MaxCPUUsage {
@@ -258,20 +373,27 @@ func (m *Monitor) generateReport() {
 	stats := m.taskStats.stats
 	m.taskStats.mu.RUnlock()
 
+	// 成功率按已结束（完成或失败）的任务计算，不包括仍在排队或执行的任务
 	successRate := float64(0)
-	if stats.TotalTasks > 0 {
-		successRate = float64(stats.CompletedTasks) / float64(stats.TotalTasks) * 100
+	if finished := stats.CompletedTasks + stats.FailedTasks; finished > 0 {
+		successRate = float64(stats.CompletedTasks) / float64(finished) * 100
 	}
 
 	report := fmt.Sprintf(`System Status Report:
 	Total Tasks: %d
+	Running Tasks: %d
 	Completed Tasks: %d
 	Failed Tasks: %d
+	Cancelled Tasks: %d
+	Retries: %d
 	Success Rate: %.2f%%
 	Average Execution Time: %v`,
 		stats.TotalTasks,
+		stats.RunningTasks,
 		stats.CompletedTasks,
 		stats.FailedTasks,
+		stats.CancelledTasks,
+		stats.Retries,
 		successRate,
 		stats.AverageTime)
 
@@ -286,12 +408,9 @@ func (m *Monitor) GetTaskStats() TaskStats {
 	return m.taskStats.stats
 }
 
-// GetLatestMetrics 获取最新的系统指标
+// GetLatestMetrics 获取最新的系统指标，尚未采样时返回 false
 func (m *Monitor) GetLatestMetrics() (SystemMetrics, bool) {
-	select {
-	case metrics := <-m.metricsChan:
-		return metrics, true
-	default:
-		return SystemMetrics{}, false
-	}
+	m.latestMu.RLock()
+	defer m.latestMu.RUnlock()
+	return m.latest, m.hasLatest
 }
@@ -1,11 +1,20 @@
 package pool
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/panjf2000/ants/v2"
+	"github.com/potatoImp/OpenStress/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // 引入日志模块
@@ -13,12 +22,46 @@ var stressLogger *StressLogger
 
 // Task represents a task with priority and retry settings.
 type Task struct {
-	ID         string
-	fn         func() // Task execution function (no error returned)
-	priority   int
-	retries    int
-	maxRetries int           // Maximum retry attempts
-	timeout    time.Duration // Task execution timeout
+	ID        string
+	fn        func(tc *TaskContext) error // Task execution function, receives the attempt's context; a returned error marks the attempt as failed
+	priority  int
+	retry     RetryPolicy   // Retry policy for failed attempts, the zero value means no retry
+	timeout   time.Duration // Execution timeout of each attempt
+	taskType  string        // Task type used for per-type rate limiting (registered task name)
+	status    int32         // TaskStatus, accessed atomically
+	startTime time.Time
+	endTime   time.Time
+	mu        sync.RWMutex  // Protects startTime and endTime
+	seq       uint64        // Submission order, used for FIFO ordering within a priority
+	enqueued  time.Duration // Time the task entered the queue, relative to the queue epoch
+	rank      float64       // Queue ordering key: priority adjusted for aging
+	dedupKeys []string      // Task ID and idempotency keys held while the task is pending or running
+	trace     *TaskContext  // Trace ID and task ID passed to the task function and stamped into logs
+}
+
+// TaskInfo is a read-only snapshot of a task, safe to expose through the API.
+type TaskInfo struct {
+	ID        string    `json:"id"`
+	Priority  int       `json:"priority"`
+	Status    string    `json:"status"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	TraceID   string    `json:"trace_id"`
+}
+
+// PoolStats is a snapshot of the pool counters.
+type PoolStats struct {
+	MaxWorkers     int   `json:"max_workers"`
+	RunningWorkers int   `json:"running_workers"`
+	Submitted      int64 `json:"submitted"`
+	Completed      int64 `json:"completed"`
+	Failed         int64 `json:"failed"`
+	TimedOut       int64 `json:"timed_out"`
+	Retries        int64 `json:"retries"`
+	Paused         bool  `json:"paused"`
+	Shutdown       bool  `json:"shutdown"`
+
+	RateLimit RateLimiterStats `json:"rate_limit"`
 }
 
 // Pool represents a goroutine pool with dynamic concurrency and priority scheduling.
@@ -29,8 +72,44 @@ type Pool struct {
 	isPaused        int32      // 0 means running, 1 means paused
 	shutdownFlag    int32      // 0 means not shutdown, 1 means shutdown
 	threadIDCounter int32      // Atomic counter for assigning ThreadID
+
+	activeVUs atomic.Int32 // Virtual users currently running iterations
+
+	tasks     sync.Map // taskID -> *Task, pending and running tasks plus the most recently finished ones
+	registry  sync.Map // task name -> func(threadID int32), tasks that can be submitted by name
+	vuHooks   sync.Map // task name -> VUHooks, lifecycle hooks used when a registered task runs as VUs
+	dedup     sync.Map // dedup key -> *Task, the active task holding a task ID or idempotency key
+	submitted int64    // Number of submitted tasks
+	completed int64    // Number of completed tasks
+	failed    int64    // Number of failed (panicked) tasks
+	timedOut  int64    // Number of tasks that exceeded their timeout
+	retried   int64    // Number of retried attempts
+
+	limiter  *RateLimiter  // Global and per-task-type rate limiter, enforced before execution
+	capacity atomic.Uint64 // Estimated generator capacity in req/s as float64 bits, 0 when unknown
+
+	queue        *taskQueue         // Tasks waiting for a worker, ordered by priority
+	slotMu       sync.Mutex         // Protects inFlight and unfinished
+	slotCond     *sync.Cond         // Signalled when a worker slot is released
+	inFlight     int                // Number of queued tasks handed to ants and not yet finished
+	unfinished   int                // Number of submitted tasks that have not reached a final status
+	idleCond     *sync.Cond         // Broadcast when unfinished drops to zero
+	dispatchDone chan struct{}      // Closed when the dispatcher exits
+	closing      context.Context    // Done once Shutdown starts
+	stopDispatch context.CancelFunc // Cancels closing: aborts rate limit waits in the dispatcher and pending DAG nodes
+
+	monitor  atomic.Pointer[Monitor]  // Receives task status changes, nil when not monitored
+	progress atomic.Pointer[Progress] // Records finished task IDs for resuming a run, nil when not tracked
+
+	finishedMu   sync.Mutex // Protects finished and retention
+	finished     []*Task    // Finished tasks still visible through GetTaskStatus, oldest first
+	retention    int        // Maximum number of finished tasks kept in tasks
+	shutdownOnce sync.Once  // Makes Shutdown idempotent
 }
 
+// DefaultFinishedTaskRetention is the number of finished tasks whose status stays queryable.
+const DefaultFinishedTaskRetention = 10000
+
 // NewPool creates a new Pool with the specified maximum number of workers.
 func NewPool(maxWorkers int) *Pool {
 	stressLogger.Log("INFO", fmt.Sprintf("Creating a new pool with %d workers", maxWorkers))
@@ -47,135 +126,673 @@ func NewPool(maxWorkers int) *Pool {
 		maxWorkers:      int32(maxWorkers),
 		taskPool:        taskPool,
 		threadIDCounter: 0,
+		limiter:         NewRateLimiter(),
+		queue:           newTaskQueue(),
+		dispatchDone:    make(chan struct{}),
+		retention:       DefaultFinishedTaskRetention,
 	}
+	pool.slotCond = sync.NewCond(&pool.slotMu)
+	pool.idleCond = sync.NewCond(&pool.slotMu)
+	pool.closing, pool.stopDispatch = context.WithCancel(context.Background())
+	go pool.dispatch()
 
 	stressLogger.Log("INFO", "Pool created successfully")
 	return pool
 }
 
-// Start initializes the worker goroutines.
+// Start resumes dispatching if the pool is paused.
+// Tasks are dispatched as soon as they are submitted, so calling Start is only needed after Pause.
 func (p *Pool) Start() {
-	stressLogger.Log("INFO", fmt.Sprintf("Starting %d worker goroutines...", p.maxWorkers))
+	p.Resume()
+}
+
+// ErrDuplicateTask is returned when a task ID is submitted while a task with the same ID is still pending or running.
+var ErrDuplicateTask = errors.New("task is already pending or running")
 
-	// Initialize workers
-	for i := 0; i < int(p.maxWorkers); i++ {
-		go p.worker()
+// ErrTaskTimeout is the error of an attempt that exceeded the task timeout.
+var ErrTaskTimeout = errors.New("task timed out")
+
+// ErrTaskFinished is returned when a task ID recorded as finished in the pool's Progress is submitted again,
+// typically when a resumed run resubmits its scenario.
+var ErrTaskFinished = errors.New("task already finished in a previous run")
+
+// Submit adds a new task to the pool.
+// Submitting a task ID that is still pending or running fails with ErrDuplicateTask;
+// use SubmitWithKey to get the existing task instead.
+// With progress tracking enabled (SetProgress), a task ID that already finished fails with ErrTaskFinished.
+func (p *Pool) Submit(fn func(threadID int32), priority int, taskID string, timeout time.Duration) error {
+	return p.submitUnique(withThreadID(fn), priority, taskID, timeout, RetryPolicy{})
+}
+
+// SubmitWithContext adds a new task whose function receives its TaskContext,
+// so the trace ID can be stamped into results and log entries.
+// Like Submit, a duplicate task ID fails with ErrDuplicateTask.
+func (p *Pool) SubmitWithContext(fn func(tc *TaskContext), priority int, taskID string, timeout time.Duration) error {
+	return p.submitUnique(func(_ int32, tc *TaskContext) error { fn(tc); return nil }, priority, taskID, timeout, RetryPolicy{})
+}
+
+// SubmitWithRetry adds a new task that reports failure by returning an error and is retried according to policy.
+// The timeout applies to each attempt; tc.RetryCount holds the number of retries before the current attempt,
+// so results recorded with ResultData.ApplyExecution carry it into the report.
+// A task whose last attempt fails is counted as failed, or as timed out when that attempt exceeded the timeout.
+func (p *Pool) SubmitWithRetry(fn func(tc *TaskContext) error, policy RetryPolicy, priority int, taskID string, timeout time.Duration) error {
+	if err := policy.validate(); err != nil {
+		return fmt.Errorf("invalid retry policy for task %s: %v", taskID, err)
 	}
+	return p.submitUnique(func(_ int32, tc *TaskContext) error { return fn(tc) }, priority, taskID, timeout, policy)
+}
 
-	stressLogger.Log("INFO", fmt.Sprintf("%d worker goroutines started", p.maxWorkers))
+// submitUnique submits a task and reports a deduplicated submission as ErrDuplicateTask.
+func (p *Pool) submitUnique(fn func(threadID int32, tc *TaskContext) error, priority int, taskID string, timeout time.Duration, retry RetryPolicy) error {
+	_, deduplicated, err := p.submit(fn, "", priority, taskID, "", timeout, retry)
+	if err == nil && deduplicated {
+		err = fmt.Errorf("%w: %s", ErrDuplicateTask, taskID)
+	}
+	return err
+}
+
+// withThreadID adapts a thread-ID task function to the internal task signature.
+func withThreadID(fn func(threadID int32)) func(threadID int32, tc *TaskContext) error {
+	return func(threadID int32, _ *TaskContext) error { fn(threadID); return nil }
 }
 
-// worker listens for tasks and executes them.
-func (p *Pool) worker() {
+// SubmitWithKey adds a new task to the pool with an idempotency key.
+// If a task holding the same task ID or key is still pending or running, no new task is created:
+// the existing task is returned and deduplicated is true.
+func (p *Pool) SubmitWithKey(fn func(threadID int32), priority int, taskID, idempotencyKey string, timeout time.Duration) (TaskInfo, bool, error) {
+	task, deduplicated, err := p.submit(withThreadID(fn), "", priority, taskID, idempotencyKey, timeout, RetryPolicy{})
+	if err != nil {
+		return TaskInfo{}, false, err
+	}
+	return task.info(), deduplicated, nil
+}
+
+// submit adds a new task of the given type to the pool and returns it.
+// When an active task already holds the same dedup key, that task is returned instead.
+func (p *Pool) submit(fn func(threadID int32, tc *TaskContext) error, taskType string, priority int, taskID, idempotencyKey string, timeout time.Duration, retry RetryPolicy) (*Task, bool, error) {
+	stressLogger.Log("INFO", fmt.Sprintf("Submitting task %s with priority %d", taskID, priority))
+
+	// Get a unique ThreadID for the current task, limiting it to maxWorkers
+	threadID := atomic.AddInt32(&p.threadIDCounter, 1) % atomic.LoadInt32(&p.maxWorkers)
+
+	trace := newTaskContext(taskID)
+	task := &Task{
+		ID:        taskID,
+		fn:        func(tc *TaskContext) error { return fn(threadID, tc) }, // Pass the threadID and the attempt's context to the task function
+		priority:  priority,
+		retry:     retry,
+		timeout:   timeout,
+		taskType:  taskType,
+		status:    int32(TaskPending),
+		dedupKeys: dedupKeys(taskID, idempotencyKey),
+		trace:     trace,
+	}
+	if atomic.LoadInt32(&p.shutdownFlag) == 1 {
+		return nil, false, fmt.Errorf("failed to submit task %s: pool is shut down", taskID)
+	}
+	if progress := p.progress.Load(); progress != nil && progress.Finished(taskID) {
+		return nil, false, fmt.Errorf("%w: %s", ErrTaskFinished, taskID)
+	}
+
+	// 幂等处理：相同的键仍在等待或执行时直接返回已有任务
+	if existing, ok := p.claimDedupKeys(task); !ok {
+		stressLogger.Log("INFO", fmt.Sprintf("Task %s deduplicated, task %s is already %s",
+			taskID, existing.ID, TaskStatus(atomic.LoadInt32(&existing.status))))
+		return existing, true, nil
+	}
+
+	// 先登记再入队：调度协程取出任务后任务状态即可被查询到
+	p.tasks.Store(taskID, task)
+	p.addUnfinished(1)
+	// 入队后由调度协程按优先级取出执行，提交本身不会阻塞
+	if err := p.queue.Push(task); err != nil {
+		p.tasks.CompareAndDelete(taskID, task)
+		p.releaseDedupKeys(task)
+		p.addUnfinished(-1)
+		stressLogger.Log("ERROR", fmt.Sprintf("Failed to submit task %s: %v", taskID, err))
+		return nil, false, fmt.Errorf("failed to submit task %s: %v", taskID, err)
+	}
+	atomic.AddInt64(&p.submitted, 1)
+	p.recordSubmitted(task.ID)
+	stressLogger.Log("INFO", fmt.Sprintf("Task %s submitted successfully", taskID))
+	return task, false, nil
+}
+
+// dispatch takes tasks from the priority queue and hands them to ants.
+// A task is only taken once a worker slot is free, so waiting tasks stay in the
+// queue and keep their priority order instead of blocking inside ants.
+// The dispatcher waits for a queued task before taking a slot, so an idle pool
+// does not hold a slot that RunVUs could reserve.
+// Rate limiting happens after the task is taken and before its slot is: a throttled
+// task waits in the dispatcher instead of occupying a worker. Tasks are released in
+// priority order, so a task waiting for its type's tokens also holds back the tasks behind it.
+func (p *Pool) dispatch() {
+	defer close(p.dispatchDone)
 	for {
-		if atomic.LoadInt32(&p.shutdownFlag) == 1 {
-			stressLogger.Log("INFO", "Pool shutdown detected, worker exiting")
+		if !p.queue.WaitNonEmpty() {
+			return
+		}
+		if !p.waitSlot() {
+			return
+		}
+		task, ok := p.queue.Pop()
+		if !ok {
 			return
 		}
 
-		// Check for pause status
-		if atomic.LoadInt32(&p.isPaused) == 1 {
-			stressLogger.Log("DEBUG", "Pool is paused, worker waiting")
-			time.Sleep(100 * time.Millisecond)
-			continue
+		// 限流：先经过任务类型限流，再经过全局限流
+		waited, err := p.limiter.Wait(p.closing, task.taskType)
+		if err != nil {
+			p.cancelTask(task)
+			return
 		}
+		task.trace.ThrottleWait = waited
 
-		// Submit tasks to ants pool, worker executes tasks asynchronously
-		task := &Task{
-			ID: "Sample-Task",
-			fn: func() {
-				stressLogger.Log("INFO", "Executing task...")
-				// Simulate task processing
-				time.Sleep(1 * time.Second)
-				stressLogger.Log("INFO", "Task completed")
-			},
+		if !p.acquireSlot() {
+			p.cancelTask(task)
+			return
 		}
+		err = p.taskPool.Submit(func() {
+			defer p.releaseSlot()
+			p.runTask(task)
+		})
+		if err != nil {
+			p.releaseSlot()
+			atomic.StoreInt32(&task.status, int32(TaskFailed))
+			atomic.AddInt64(&p.failed, 1)
+			p.releaseDedupKeys(task)
+			p.recordStatus(task, TaskPending, TaskFailed)
+			p.retainFinished(task)
+			p.addUnfinished(-1)
+			stressLogger.Log("ERROR", fmt.Sprintf("Failed to dispatch task %s: %v", task.ID, err))
+		}
+	}
+}
 
-		// Submit the task to the pool with panic recovery
-		err := p.taskPool.Submit(func() {
-			// 自动使用 defer 和 recover 捕获 panic
-			defer func() {
-				if r := recover(); r != nil {
-					stressLogger.Log("ERROR", fmt.Sprintf("Task %s panicked: %v", task.ID, r))
-				}
-			}()
+// cancelTask marks a task that never started as cancelled.
+func (p *Pool) cancelTask(task *Task) {
+	atomic.StoreInt32(&task.status, int32(TaskCancelled))
+	p.releaseDedupKeys(task)
+	p.recordStatus(task, TaskPending, TaskCancelled)
+	p.retainFinished(task)
+	p.addUnfinished(-1)
+}
 
-			// 执行任务
-			task.fn()
-		})
+// waitSlot waits until the pool is not paused and fewer than maxWorkers queued tasks are in flight,
+// without taking the slot. It returns false once the pool is shut down.
+func (p *Pool) waitSlot() bool {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	return p.waitSlotLocked()
+}
+
+// acquireSlot waits like waitSlot and takes the slot. It returns false once the pool is shut down.
+func (p *Pool) acquireSlot() bool {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	if !p.waitSlotLocked() {
+		return false
+	}
+	p.inFlight++
+	return true
+}
+
+// waitSlotLocked waits for a free slot with slotMu held.
+func (p *Pool) waitSlotLocked() bool {
+	for (atomic.LoadInt32(&p.isPaused) == 1 || p.inFlight >= int(atomic.LoadInt32(&p.maxWorkers))) &&
+		atomic.LoadInt32(&p.shutdownFlag) == 0 {
+		p.slotCond.Wait()
+	}
+	return atomic.LoadInt32(&p.shutdownFlag) == 0
+}
+
+// releaseSlot frees a worker slot taken by acquireSlot.
+func (p *Pool) releaseSlot() {
+	p.slotMu.Lock()
+	p.inFlight--
+	p.slotMu.Unlock()
+	p.slotCond.Signal()
+}
+
+// addUnfinished adjusts the number of unfinished tasks and wakes Wait once it drops to zero.
+func (p *Pool) addUnfinished(delta int) {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	p.unfinished += delta
+	if p.unfinished == 0 {
+		p.idleCond.Broadcast()
+	}
+}
+
+// Wait blocks until every submitted task has completed, failed, timed out or been cancelled.
+// After Shutdown it returns once the tasks that were already running have finished.
+// Virtual user runs are not included; use VURun.Wait for those.
+func (p *Pool) Wait() {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	for p.unfinished > 0 {
+		p.idleCond.Wait()
+	}
+}
+
+// runTask executes a task and keeps its status up to date.
+func (p *Pool) runTask(task *Task) {
+	task.mu.Lock()
+	task.startTime = time.Now()
+	task.mu.Unlock()
+	atomic.StoreInt32(&task.status, int32(TaskRunning))
+	p.recordStatus(task, TaskPending, TaskRunning)
+
+	// 任务生命周期 span，追踪ID与 TaskContext.TraceID 一致
+	span := task.trace.startSpan("task "+task.ID,
+		attribute.String("task.id", task.ID),
+		attribute.String("task.type", task.taskType),
+		attribute.Int("task.priority", task.priority),
+	)
+
+	// 使用 defer 和 recover 捕获 panic 错误
+	var err error
+	defer func() {
+		task.mu.Lock()
+		task.endTime = time.Now()
+		task.mu.Unlock()
+		defer p.addUnfinished(-1)
+		defer p.retainFinished(task)
+		defer p.releaseDedupKeys(task)
+		defer span.End()
+
+		defer p.recordFinished(task)
+
+		if r := recover(); r != nil {
+			atomic.StoreInt32(&task.status, int32(TaskFailed))
+			atomic.AddInt64(&p.failed, 1)
+			p.recordStatus(task, TaskRunning, TaskFailed)
+			task.trace.Log("ERROR", fmt.Sprintf("Task %s panicked: %v", task.ID, r))
+			span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", r))
+			return
+		}
+		if errors.Is(err, ErrTaskTimeout) {
+			atomic.StoreInt32(&task.status, int32(TaskTimeout))
+			atomic.AddInt64(&p.timedOut, 1)
+			p.recordStatus(task, TaskRunning, TaskTimeout)
+			task.trace.Log("ERROR", fmt.Sprintf("Task %s timed out after %v", task.ID, task.timeout))
+			span.SetStatus(codes.Error, fmt.Sprintf("timeout after %v", task.timeout))
+			return
+		}
 		if err != nil {
-			stressLogger.Log("ERROR", fmt.Sprintf("Failed to submit task %s: %v", task.ID, err))
+			atomic.StoreInt32(&task.status, int32(TaskFailed))
+			atomic.AddInt64(&p.failed, 1)
+			p.recordStatus(task, TaskRunning, TaskFailed)
+			task.trace.Log("ERROR", fmt.Sprintf("Task %s failed: %v", task.ID, err))
+			span.SetStatus(codes.Error, err.Error())
+			return
 		}
+		atomic.StoreInt32(&task.status, int32(TaskCompleted))
+		atomic.AddInt64(&p.completed, 1)
+		p.recordStatus(task, TaskRunning, TaskCompleted)
+	}()
+
+	// 限流等待已在调度协程中完成，这里只记录等待时间
+	if waited := task.trace.ThrottleWait; waited > 0 {
+		task.trace.Log("DEBUG", fmt.Sprintf("Task %s throttled for %v", task.ID, waited))
+		span.AddEvent("throttled", trace.WithAttributes(attribute.Float64("throttle_ms", float64(waited.Microseconds())/1000)))
 	}
+
+	// 执行任务
+	err = p.executeWithRetry(task)
 }
 
-// Submit adds a new task to the pool.
-func (p *Pool) Submit(fn func(threadID int32), priority int, taskID string, timeout time.Duration) {
-	stressLogger.Log("INFO", fmt.Sprintf("Submitting task %s with priority %d", taskID, priority))
+// executeWithRetry runs the task and retries failed attempts according to its retry policy.
+// Each attempt gets its own copy of the task context, so a timed-out attempt still running in the background
+// never observes the next attempt's retry count or context.
+// Panics are not retried. Backoff waits end early when the pool shuts down, returning the last error.
+func (p *Pool) executeWithRetry(task *Task) error {
+	base := task.trace.Context()
+	for attempt := 1; ; attempt++ {
+		tc := *task.trace
+		tc.RetryCount = attempt - 1
+		err := p.execute(task, &tc, base)
+		if err == nil || !task.retry.shouldRetry(err, attempt) {
+			return err
+		}
 
-	// Get a unique ThreadID for the current task, limiting it to maxWorkers
-	threadID := atomic.AddInt32(&p.threadIDCounter, 1) % p.maxWorkers
+		delay := task.retry.delay(attempt)
+		task.trace.Log("WARNING", fmt.Sprintf("Task %s attempt %d/%d failed: %v, retrying in %v", task.ID, attempt, task.retry.MaxAttempts, err, delay))
+		atomic.AddInt64(&p.retried, 1)
+		p.recordRetry(task.ID, attempt)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-p.closing.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
 
-	task := &Task{
-		ID:         taskID,
-		fn:         func() { fn(threadID) }, // Pass the threadID to the task function
-		priority:   priority,
-		retries:    0, // Default retries
-		maxRetries: 1, // Maximum retries
-		timeout:    timeout,
-	}
-
-	// Submit task to ants pool with panic recovery
-	err := p.taskPool.Submit(func() {
-		// 使用 defer 和 recover 捕获 panic 错误
-		defer func() {
-			if r := recover(); r != nil {
-				stressLogger.Log("ERROR", fmt.Sprintf("Task %s panicked: %v", taskID, r))
-			}
-		}()
+// execute runs one attempt of the task function with tc as its context and base as the parent of tc.Context().
+// tc belongs to this attempt and is not modified once the function has started.
+// With a timeout set, TaskContext.Context() carries the deadline and an attempt exceeding it returns ErrTaskTimeout;
+// a function that ignores the deadline keeps running in the background, but its worker is released.
+// A panic in the task function is re-raised in the calling worker.
+func (p *Pool) execute(task *Task, tc *TaskContext, base context.Context) error {
+	tc.ctx = WithTaskContext(base, tc)
+	if task.timeout <= 0 {
+		return task.fn(tc)
+	}
 
-		// 执行任务
-		task.fn()
-	})
+	ctx, cancel := context.WithTimeout(tc.ctx, task.timeout)
+	defer cancel()
+	tc.ctx = ctx
+
+	type outcome struct {
+		err       error
+		recovered interface{}
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		var err error
+		defer func() { done <- outcome{err, recover()} }()
+		err = task.fn(tc)
+	}()
+	select {
+	case o := <-done:
+		if o.recovered != nil {
+			panic(o.recovered)
+		}
+		return o.err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %v", ErrTaskTimeout, task.timeout)
+	}
+}
+
+// retainFinished keeps a finished task queryable and evicts the oldest finished tasks beyond the retention limit.
+// A task ID resubmitted after eviction of its previous run is left untouched.
+func (p *Pool) retainFinished(task *Task) {
+	p.finishedMu.Lock()
+	defer p.finishedMu.Unlock()
+	p.finished = append(p.finished, task)
+	for len(p.finished) > p.retention {
+		oldest := p.finished[0]
+		p.finished[0] = nil
+		p.finished = p.finished[1:]
+		p.tasks.CompareAndDelete(oldest.ID, oldest)
+	}
+}
+
+// SetFinishedTaskRetention sets how many finished tasks stay queryable through GetTaskStatus.
+// Pending and running tasks are never evicted.
+func (p *Pool) SetFinishedTaskRetention(n int) {
+	if n < 0 {
+		n = 0
+	}
+	p.finishedMu.Lock()
+	p.retention = n
+	p.finishedMu.Unlock()
+}
+
+// info returns a snapshot of the task.
+func (t *Task) info() TaskInfo {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return TaskInfo{
+		ID:        t.ID,
+		Priority:  t.priority,
+		Status:    TaskStatus(atomic.LoadInt32(&t.status)).String(),
+		StartTime: t.startTime,
+		EndTime:   t.endTime,
+		TraceID:   t.trace.TraceID,
+	}
+}
+
+// RegisterTask registers a named task function so it can be submitted by name (e.g. through the API).
+func (p *Pool) RegisterTask(name string, fn func(threadID int32)) {
+	p.registry.Store(name, fn)
+	stressLogger.Log("INFO", fmt.Sprintf("Registered task %s", name))
+}
+
+// SubmitByName submits a previously registered task.
+// idempotencyKey is optional; see SubmitWithKey for the deduplication rules.
+func (p *Pool) SubmitByName(name string, priority int, taskID, idempotencyKey string, timeout time.Duration) (TaskInfo, bool, error) {
+	value, ok := p.registry.Load(name)
+	if !ok {
+		return TaskInfo{}, false, fmt.Errorf("task %s is not registered", name)
+	}
+	task, deduplicated, err := p.submit(withThreadID(value.(func(threadID int32))), name, priority, taskID, idempotencyKey, timeout, RetryPolicy{})
 	if err != nil {
-		stressLogger.Log("ERROR", fmt.Sprintf("Failed to submit task %s: %v", taskID, err))
+		return TaskInfo{}, false, err
+	}
+	return task.info(), deduplicated, nil
+}
+
+// GetAvailableTasks returns the names of all registered tasks, sorted by name.
+func (p *Pool) GetAvailableTasks() []string {
+	var names []string
+	p.registry.Range(func(key, _ interface{}) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+// GetRunningTasks returns snapshots of all tasks that are currently running.
+func (p *Pool) GetRunningTasks() []TaskInfo {
+	running := []TaskInfo{}
+	p.tasks.Range(func(_, value interface{}) bool {
+		task := value.(*Task)
+		if TaskStatus(atomic.LoadInt32(&task.status)) == TaskRunning {
+			running = append(running, task.info())
+		}
+		return true
+	})
+	sort.Slice(running, func(i, j int) bool { return running[i].StartTime.Before(running[j].StartTime) })
+	return running
+}
+
+// Stats returns a snapshot of the pool counters.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		MaxWorkers:     int(atomic.LoadInt32(&p.maxWorkers)),
+		RunningWorkers: p.taskPool.Running(),
+		Submitted:      atomic.LoadInt64(&p.submitted),
+		Completed:      atomic.LoadInt64(&p.completed),
+		Failed:         atomic.LoadInt64(&p.failed),
+		TimedOut:       atomic.LoadInt64(&p.timedOut),
+		Retries:        atomic.LoadInt64(&p.retried),
+		Paused:         atomic.LoadInt32(&p.isPaused) == 1,
+		Shutdown:       atomic.LoadInt32(&p.shutdownFlag) == 1,
+		RateLimit:      p.limiter.Stats(),
 	}
-	stressLogger.Log("INFO", fmt.Sprintf("Task %s submitted successfully", taskID))
 }
 
-// Shutdown gracefully stops the pool and waits for all tasks to complete.
+// ActiveVUs returns the number of virtual users that have passed the start barrier and are running iterations.
+func (p *Pool) ActiveVUs() int {
+	return int(p.activeVUs.Load())
+}
+
+// QueueDepth returns the number of submitted tasks waiting for a free worker.
+func (p *Pool) QueueDepth() int {
+	return p.queue.Len() + p.taskPool.Waiting()
+}
+
+// SetRateLimit sets the global rate limit in tasks per second; rate <= 0 disables it.
+// It returns a warning when the rate exceeds the generator capacity set by SetGeneratorCapacity.
+func (p *Pool) SetRateLimit(rate float64, burst int) string {
+	stressLogger.Log("INFO", fmt.Sprintf("Setting global rate limit to %.2f/s (burst %d)", rate, burst))
+	p.limiter.SetGlobalRate(rate, burst)
+
+	// 与自测得到的压测机容量对比，超过时给出警告
+	capacity := math.Float64frombits(p.capacity.Load())
+	if capacity <= 0 || rate <= capacity {
+		return ""
+	}
+	warning := fmt.Sprintf("global rate limit %.0f/s exceeds this generator's estimated capacity of %.0f req/s; results may be limited by the load generator rather than the system under test",
+		rate, capacity)
+	stressLogger.Log("WARN", warning)
+	return warning
+}
+
+// SetGeneratorCapacity sets the estimated request rate this machine can drive, usually taken from a saved selftest result.
+// Passing 0 disables the capacity warning of SetRateLimit.
+func (p *Pool) SetGeneratorCapacity(capacity float64) {
+	p.capacity.Store(math.Float64bits(capacity))
+}
+
+// SetTaskRateLimit sets the rate limit for one task type; rate <= 0 disables it.
+func (p *Pool) SetTaskRateLimit(taskType string, rate float64, burst int) {
+	stressLogger.Log("INFO", fmt.Sprintf("Setting rate limit of task %s to %.2f/s (burst %d)", taskType, rate, burst))
+	p.limiter.SetTaskRate(taskType, rate, burst)
+}
+
+// SetPriorityAging sets how long a queued task waits before it gains one priority level,
+// so that a steady stream of high-priority tasks cannot starve lower-priority ones.
+// Zero or a negative value disables aging and schedules by strict priority. The default is DefaultPriorityAging.
+func (p *Pool) SetPriorityAging(aging time.Duration) {
+	stressLogger.Log("INFO", fmt.Sprintf("Setting priority aging to %v", aging))
+	p.queue.SetAging(aging)
+}
+
+// SetMonitor publishes task status changes (submit, start, complete, fail, retry) to monitor.
+// Passing nil stops publishing.
+func (p *Pool) SetMonitor(monitor *Monitor) {
+	p.monitor.Store(monitor)
+}
+
+// recordStatus publishes a task status change to the monitor, if any.
+// The execution time is only reported once the task has left the running state.
+func (p *Pool) recordStatus(task *Task, oldStatus, newStatus TaskStatus) {
+	monitor := p.monitor.Load()
+	if monitor == nil {
+		return
+	}
+	var executionTime time.Duration
+	if oldStatus == TaskRunning {
+		task.mu.RLock()
+		executionTime = task.endTime.Sub(task.startTime)
+		task.mu.RUnlock()
+	}
+	monitor.RecordTaskStatus(task.ID, oldStatus, newStatus, executionTime)
+}
+
+// recordSubmitted publishes the submission of a task to the monitor, if any.
+func (p *Pool) recordSubmitted(taskID string) {
+	if monitor := p.monitor.Load(); monitor != nil {
+		monitor.RecordTaskSubmitted(taskID)
+	}
+}
+
+// recordRetry publishes a retry of the task to the monitor, if any.
+func (p *Pool) recordRetry(taskID string, attempt int) {
+	if monitor := p.monitor.Load(); monitor != nil {
+		monitor.RecordTaskRetry(taskID, attempt)
+	}
+}
+
+// Shutdown stops dispatching, cancels the tasks still waiting in the queue and releases the worker pool.
+// Tasks that are already running are not interrupted and are not waited for; call Wait afterwards to wait for them.
+// Submissions after Shutdown fail. Calling Shutdown more than once is safe.
 func (p *Pool) Shutdown() {
+	p.shutdownOnce.Do(p.shutdown)
+}
+
+// shutdown performs the shutdown once.
+func (p *Pool) shutdown() {
 	stressLogger.Log("INFO", "Shutting down the pool")
 	atomic.StoreInt32(&p.shutdownFlag, 1)
+
+	// 停止调度，尚未开始执行的任务标记为已取消
+	pending := p.queue.Close()
+	for _, task := range pending {
+		p.cancelTask(task)
+	}
+	p.stopDispatch()
+	p.slotMu.Lock()
+	p.slotCond.Broadcast()
+	p.slotMu.Unlock()
+	<-p.dispatchDone
+	if len(pending) > 0 {
+		stressLogger.Log("INFO", fmt.Sprintf("Cancelled %d queued tasks", len(pending)))
+	}
+
 	p.taskPool.Release()
 	stressLogger.Log("INFO", "Pool shutdown completed")
 }
 
-// Pause pauses the pool, preventing any new tasks from starting.
+// Pause pauses the pool: queued tasks are not dispatched until Resume. Running tasks are not affected.
 func (p *Pool) Pause() {
 	stressLogger.Log("INFO", "Pausing the pool")
+	p.slotMu.Lock()
 	atomic.StoreInt32(&p.isPaused, 1)
+	p.slotMu.Unlock()
 	stressLogger.Log("INFO", "Pool paused")
 }
 
-// Resume resumes the pool, allowing tasks to start again.
+// Resume resumes the pool, allowing queued tasks to be dispatched again.
 func (p *Pool) Resume() {
 	stressLogger.Log("INFO", "Resuming the pool")
+	p.slotMu.Lock()
 	atomic.StoreInt32(&p.isPaused, 0)
+	p.slotCond.Broadcast()
+	p.slotMu.Unlock()
 	stressLogger.Log("INFO", "Pool resumed")
 }
 
 // AdjustWorkers dynamically adjusts the number of worker goroutines.
 func (p *Pool) AdjustWorkers(newWorkerCount int) {
-	stressLogger.Log("INFO", fmt.Sprintf("Adjusting workers to %d", newWorkerCount))
-	// You can resize the pool using ants' dynamic worker adjustment features if needed
+	if newWorkerCount <= 0 {
+		stressLogger.Log("WARN", fmt.Sprintf("Ignoring invalid worker count %d", newWorkerCount))
+		return
+	}
+	stressLogger.Log("INFO", fmt.Sprintf("Adjusting workers from %d to %d", atomic.LoadInt32(&p.maxWorkers), newWorkerCount))
+	p.taskPool.Tune(newWorkerCount)
+	atomic.StoreInt32(&p.maxWorkers, int32(newWorkerCount))
+
+	// 扩容后唤醒调度协程
+	p.slotMu.Lock()
+	p.slotCond.Broadcast()
+	p.slotMu.Unlock()
 	stressLogger.Log("INFO", fmt.Sprintf("Worker count adjusted to %d", newWorkerCount))
 }
 
+// ApplyConfigChanges applies the changed settings of a reloaded runtime config (see config.ConfigWatcher):
+// log level, worker count, and global and per-task rate limits.
+// A removed log level or worker count keeps the current setting; a removed rate limit disables it.
+func (p *Pool) ApplyConfigChanges(changes []config.ConfigChange) error {
+	for _, change := range changes {
+		stressLogger.Log("INFO", "Applying config change "+change.String())
+		switch change.Field {
+		case config.FieldLogLevel:
+			if level, _ := change.New.(string); level != "" {
+				if err := SetLogLevel(level); err != nil {
+					return err
+				}
+			}
+		case config.FieldWorkers:
+			if workers, _ := change.New.(int); workers > 0 {
+				p.AdjustWorkers(workers)
+			}
+		case config.FieldRateLimit:
+			limit, _ := change.New.(config.RateLimitConfig)
+			p.SetRateLimit(limit.Rate, limit.Burst)
+		case config.FieldTaskRateLimit:
+			limit, _ := change.New.(config.RateLimitConfig)
+			p.SetTaskRateLimit(change.Task, limit.Rate, limit.Burst)
+		default:
+			return fmt.Errorf("unknown config field %s", change.Field)
+		}
+	}
+	return nil
+}
+
 // GetTaskStatus returns the status of a task by its ID.
-func (p *Pool) GetTaskStatus(taskID string) (*Task, error) {
+func (p *Pool) GetTaskStatus(taskID string) (*TaskInfo, error) {
 	stressLogger.Log("INFO", fmt.Sprintf("Fetching status for task %s", taskID))
 
-	// Return the status of the task
-	return nil, fmt.Errorf("task not found")
+	value, ok := p.tasks.Load(taskID)
+	if !ok {
+		return nil, fmt.Errorf("task not found")
+	}
+	info := value.(*Task).info()
+	return &info, nil
 }
@@ -0,0 +1,78 @@
+// progress.go
+// 任务进度模块
+// 本文件负责记录已结束任务的ID，供断点续跑使用：进程中途崩溃后，
+// 从检查点恢复进度，重新提交场景时跳过上次已经结束的任务。
+//
+// 技术实现细节：
+// 1. 任务执行完成或失败（panic）后记录其ID；被取消的任务从未执行，不记录，续跑时会重新执行。
+// 2. 进度记录是可选的，通过 SetProgress 开启，未开启时不保存任何任务ID，避免长时间运行的任务池内存持续增长。
+// 3. 开启后提交进度中已有的任务ID会返回 ErrTaskFinished，场景代码可以照常提交全部任务。
+
+package pool
+
+import (
+	"sort"
+	"sync"
+)
+
+// Progress 已结束任务的ID集合，并发安全
+type Progress struct {
+	mu       sync.RWMutex
+	finished map[string]struct{}
+}
+
+// NewProgress 创建任务进度，finished 为上次运行已经结束的任务ID（可为空）
+func NewProgress(finished []string) *Progress {
+	p := &Progress{finished: make(map[string]struct{}, len(finished))}
+	for _, id := range finished {
+		p.finished[id] = struct{}{}
+	}
+	return p
+}
+
+// Finished 判断任务是否已经结束
+func (p *Progress) Finished(taskID string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.finished[taskID]
+	return ok
+}
+
+// TaskIDs 返回已结束任务的ID，按字典序排列
+func (p *Progress) TaskIDs() []string {
+	p.mu.RLock()
+	ids := make([]string, 0, len(p.finished))
+	for id := range p.finished {
+		ids = append(ids, id)
+	}
+	p.mu.RUnlock()
+	sort.Strings(ids)
+	return ids
+}
+
+// Len 返回已结束任务的数量
+func (p *Progress) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.finished)
+}
+
+// markFinished 记录任务已经结束
+func (p *Progress) markFinished(taskID string) {
+	p.mu.Lock()
+	p.finished[taskID] = struct{}{}
+	p.mu.Unlock()
+}
+
+// SetProgress 开启任务进度记录：之后结束的任务都会记录到 progress 中，
+// 提交 progress 中已有的任务ID会返回 ErrTaskFinished。传入 nil 关闭记录
+func (p *Pool) SetProgress(progress *Progress) {
+	p.progress.Store(progress)
+}
+
+// recordFinished 将结束的任务记录到任务进度中（如果已开启）
+func (p *Pool) recordFinished(task *Task) {
+	if progress := p.progress.Load(); progress != nil && task.ID != "" {
+		progress.markFinished(task.ID)
+	}
+}
@@ -0,0 +1,148 @@
+// queue.go
+// 任务优先级队列
+// 本文件负责待执行任务的调度顺序：基于堆实现的并发优先级队列，插入与弹出均为 O(log n)。
+//
+// 调度规则：
+// 1. 优先级数值越大越先执行。
+// 2. 同一优先级内按提交顺序先进先出，保证同级任务的公平性。
+// 3. 队列为空时 Pop 阻塞等待，队列关闭后 Pop 返回 false。
+// 4. 调度协程先通过 WaitNonEmpty 等到有任务，再占用 worker，空闲时不会提前占住 worker。
+// 5. 优先级老化：任务每等待一个 aging 间隔，有效优先级提高 1，持续提交的高优先级任务不会让低优先级任务饿死。
+//    所有任务以相同速度老化，两个任务的先后只取决于"优先级 - 入队时间/aging"，与当前时间无关，
+//    因此排序键在入队时计算一次即可，堆结构保持有效。aging 为 0 时退化为严格优先级。
+
+package pool
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPriorityAging is the waiting time after which a queued task gains one priority level.
+const DefaultPriorityAging = time.Second
+
+// taskHeap 按优先级和提交顺序排序的任务堆
+type taskHeap []*Task
+
+func (h taskHeap) Len() int { return len(h) }
+
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank > h[j].rank
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h taskHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(*Task)) }
+
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = nil // 避免内存泄漏
+	*h = old[:n-1]
+	return task
+}
+
+// taskQueue 并发安全的任务优先级队列
+type taskQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  taskHeap
+	seq    uint64
+	closed bool
+	aging  time.Duration // 有效优先级提高 1 所需的等待时间，0 表示不老化
+	epoch  time.Time     // 入队时间的基准，避免排序键过大
+}
+
+// newTaskQueue 创建任务队列
+func newTaskQueue() *taskQueue {
+	q := &taskQueue{aging: DefaultPriorityAging, epoch: time.Now()}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// rank 计算任务的排序键：优先级减去入队时间折算的老化量
+func (q *taskQueue) rank(task *Task) float64 {
+	if q.aging <= 0 {
+		return float64(task.priority)
+	}
+	return float64(task.priority) - float64(task.enqueued)/float64(q.aging)
+}
+
+// SetAging 调整老化间隔并重新排序队列中的任务
+func (q *taskQueue) SetAging(aging time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.aging = aging
+	for _, task := range q.items {
+		task.rank = q.rank(task)
+	}
+	heap.Init(&q.items)
+}
+
+// Push 将任务加入队列
+func (q *taskQueue) Push(task *Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return fmt.Errorf("task queue is closed")
+	}
+	q.seq++
+	task.seq = q.seq
+	task.enqueued = time.Since(q.epoch)
+	task.rank = q.rank(task)
+	heap.Push(&q.items, task)
+	q.cond.Signal()
+	return nil
+}
+
+// Pop 取出优先级最高的任务，队列为空时阻塞，队列关闭后返回 false
+func (q *taskQueue) Pop() (*Task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+	return heap.Pop(&q.items).(*Task), true
+}
+
+// WaitNonEmpty 阻塞直到队列中有任务，队列关闭后返回 false
+func (q *taskQueue) WaitNonEmpty() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	return !q.closed
+}
+
+// Len 返回队列中等待的任务数
+func (q *taskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Close 关闭队列并唤醒所有等待者，返回未执行的任务
+func (q *taskQueue) Close() []*Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	pending := []*Task(q.items)
+	q.items = nil
+	q.cond.Broadcast()
+	return pending
+}
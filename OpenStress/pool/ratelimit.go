@@ -0,0 +1,217 @@
+// ratelimit.go
+// 限流模块
+// 本文件负责任务执行前的令牌桶限流，支持全局限流与按任务类型限流，并可在运行时调整速率。
+//
+// 技术实现细节：
+// 1. 令牌桶采用预约方式：取令牌时令牌数可以为负，调用方按欠下的令牌数计算需要等待的时间，
+//    多个调用方并发等待时按到达顺序依次放行，不会出现惊群。
+// 2. 先经过任务类型的令牌桶，再经过全局令牌桶。队列任务在调度协程中等待令牌，拿到令牌后才占用 worker，
+//    被限流的任务不会占住 worker；虚拟用户已预留 worker，在每次迭代前等待令牌。
+// 3. 记录被限流（需要等待）的次数和累计等待时间，供统计与 API 查询。
+
+package pool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TokenBucket 令牌桶
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒生成的令牌数
+	burst  float64 // 桶容量
+	tokens float64 // 当前令牌数，可以为负（表示已被预约）
+	last   time.Time
+}
+
+// NewTokenBucket 创建令牌桶，burst 小于等于 0 时使用 rate 作为容量（至少为 1）
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	b := &TokenBucket{last: time.Now()}
+	b.SetRate(rate, burst)
+	b.tokens = b.burst
+	return b
+}
+
+// SetRate 运行时调整速率与容量
+func (b *TokenBucket) SetRate(rate float64, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.rate = rate
+	b.burst = float64(burst)
+	if b.burst <= 0 {
+		b.burst = rate
+	}
+	if b.burst < 1 {
+		b.burst = 1
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// Rate 返回当前速率
+func (b *TokenBucket) Rate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+// refill 按流逝时间补充令牌，调用方需持有锁
+func (b *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// reserve 预约一个令牌，返回需要等待的时间
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还预约的令牌
+func (b *TokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+// Wait 取一个令牌，必要时阻塞等待，返回实际等待时间
+func (b *TokenBucket) Wait(ctx context.Context) (time.Duration, error) {
+	delay := b.reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		b.cancel()
+		return 0, ctx.Err()
+	}
+}
+
+// RateLimiter 全局 + 按任务类型的限流器
+type RateLimiter struct {
+	mu        sync.RWMutex
+	global    *TokenBucket
+	perTask   map[string]*TokenBucket
+	throttled int64 // 被限流的次数
+	totalWait int64 // 累计等待时间（纳秒）
+}
+
+// RateLimiterStats 限流统计
+type RateLimiterStats struct {
+	GlobalRate   float64            `json:"global_rate"`
+	TaskRates    map[string]float64 `json:"task_rates"`
+	Throttled    int64              `json:"throttled"`
+	ThrottleWait time.Duration      `json:"throttle_wait"`
+}
+
+// NewRateLimiter 创建限流器，默认不限流
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{perTask: make(map[string]*TokenBucket)}
+}
+
+// SetGlobalRate 设置全局速率（每秒任务数），rate 小于等于 0 表示取消全局限流
+func (r *RateLimiter) SetGlobalRate(rate float64, burst int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case rate <= 0:
+		r.global = nil
+	case r.global == nil:
+		r.global = NewTokenBucket(rate, burst)
+	default:
+		r.global.SetRate(rate, burst)
+	}
+}
+
+// SetTaskRate 设置某类任务的速率，rate 小于等于 0 表示取消该类任务的限流
+func (r *RateLimiter) SetTaskRate(taskType string, rate float64, burst int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, ok := r.perTask[taskType]
+	switch {
+	case rate <= 0:
+		delete(r.perTask, taskType)
+	case !ok:
+		r.perTask[taskType] = NewTokenBucket(rate, burst)
+	default:
+		bucket.SetRate(rate, burst)
+	}
+}
+
+// Wait 依次经过任务类型限流和全局限流，返回总等待时间
+func (r *RateLimiter) Wait(ctx context.Context, taskType string) (time.Duration, error) {
+	r.mu.RLock()
+	taskBucket := r.perTask[taskType]
+	global := r.global
+	r.mu.RUnlock()
+
+	var waited time.Duration
+	for _, bucket := range []*TokenBucket{taskBucket, global} {
+		if bucket == nil {
+			continue
+		}
+		wait, err := bucket.Wait(ctx)
+		waited += wait
+		if err != nil {
+			r.record(waited)
+			return waited, err
+		}
+	}
+	r.record(waited)
+	return waited, nil
+}
+
+// record 记录限流统计
+func (r *RateLimiter) record(waited time.Duration) {
+	if waited <= 0 {
+		return
+	}
+	atomic.AddInt64(&r.throttled, 1)
+	atomic.AddInt64(&r.totalWait, int64(waited))
+}
+
+// Stats 返回限流统计
+func (r *RateLimiter) Stats() RateLimiterStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := RateLimiterStats{
+		TaskRates:    make(map[string]float64, len(r.perTask)),
+		Throttled:    atomic.LoadInt64(&r.throttled),
+		ThrottleWait: time.Duration(atomic.LoadInt64(&r.totalWait)),
+	}
+	if r.global != nil {
+		stats.GlobalRate = r.global.Rate()
+	}
+	for taskType, bucket := range r.perTask {
+		stats.TaskRates[taskType] = bucket.Rate()
+	}
+	return stats
+}
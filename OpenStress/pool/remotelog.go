@@ -0,0 +1,423 @@
+// remotelog.go
+// 远程日志输出模块
+// 本文件负责把日志发送到 Loki 或 Kafka，分布式部署的多个压测节点可以集中查看日志。
+//
+// 技术实现细节：
+// 1. 远程输出作为 zap 的一个 core 挂在共享输出（logSink）上，编码为 JSON，有独立的最低级别。
+// 2. 日志先写入本地有界缓冲，后台协程按批量大小或发送间隔发送，不阻塞日志写入；缓冲满时丢弃最早的日志。
+// 3. 发送失败时按指数退避重试，仍失败则放回缓冲等待下一轮发送，关闭时尽量发送剩余日志。
+// 4. 配置通过 YAML 文件加载（config.LoadRemoteLogConfig），也可以在运行时通过 AddRemoteSinks 添加。
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// remoteLogRecord 缓冲中的一条日志
+type remoteLogRecord struct {
+	time time.Time
+	line []byte
+}
+
+// remoteLogSender 远程日志接收端
+type remoteLogSender interface {
+	name() string
+	send(ctx context.Context, records []remoteLogRecord) error
+	close() error
+}
+
+// remoteWriter 带本地缓冲和重试的远程日志输出，实现 zapcore.WriteSyncer
+type remoteWriter struct {
+	sender remoteLogSender
+	cfg    config.RemoteLogConfig
+	level  zap.AtomicLevel
+	core   zapcore.Core
+
+	mu      sync.Mutex
+	buffer  []remoteLogRecord
+	dropped int64 // 因缓冲已满或关闭时发送失败而丢弃的日志数
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// AddRemoteSinks 按远程日志配置添加 Loki/Kafka 输出（所有模块共享），
+// 返回用于发送剩余日志并移除这些输出的函数
+func (l *StressLogger) AddRemoteSinks(cfg config.RemoteLogConfig) (func() error, error) {
+	return l.sink.addRemotes(cfg)
+}
+
+// addRemotes 创建远程输出并挂到共享输出上
+func (s *logSink) addRemotes(cfg config.RemoteLogConfig) (func() error, error) {
+	cfg = withRemoteLogDefaults(cfg)
+	zapLevel, err := parseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var senders []remoteLogSender
+	if cfg.Loki != nil {
+		senders = append(senders, newLokiSender(*cfg.Loki))
+	}
+	if cfg.Kafka != nil {
+		senders = append(senders, newKafkaSender(*cfg.Kafka))
+	}
+	if len(senders) == 0 {
+		return nil, fmt.Errorf("no remote log sink configured")
+	}
+
+	writers := make([]*remoteWriter, 0, len(senders))
+	for _, sender := range senders {
+		writer := newRemoteWriter(sender, cfg, zapLevel)
+		encoder, err := newEncoder("json", false)
+		if err != nil {
+			return nil, err
+		}
+		writer.core = zapcore.NewCore(encoder, writer, writer.level)
+		go writer.run()
+		writers = append(writers, writer)
+	}
+
+	s.coreMu.Lock()
+	s.remotes = append(s.remotes, writers...)
+	s.rebuildLogger()
+	s.coreMu.Unlock()
+	s.mu.Lock()
+	s.updateLevel()
+	s.mu.Unlock()
+
+	var once sync.Once
+	var closeErr error
+	return func() error {
+		once.Do(func() { closeErr = s.removeRemotes(writers) })
+		return closeErr
+	}, nil
+}
+
+// removeRemotes 从共享输出上移除远程输出，并发送剩余日志
+func (s *logSink) removeRemotes(writers []*remoteWriter) error {
+	s.coreMu.Lock()
+	kept := s.remotes[:0]
+	for _, remote := range s.remotes {
+		removed := false
+		for _, writer := range writers {
+			if remote == writer {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, remote)
+		}
+	}
+	s.remotes = kept
+	s.rebuildLogger()
+	s.coreMu.Unlock()
+	s.mu.Lock()
+	s.updateLevel()
+	s.mu.Unlock()
+
+	var errs []string
+	for _, writer := range writers {
+		if err := writer.close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to close remote log sinks: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// withRemoteLogDefaults 填充远程日志配置的默认值
+func withRemoteLogDefaults(cfg config.RemoteLogConfig) config.RemoteLogConfig {
+	if cfg.Level == "" {
+		cfg.Level = "INFO"
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	return cfg
+}
+
+// newRemoteWriter 创建远程输出
+func newRemoteWriter(sender remoteLogSender, cfg config.RemoteLogConfig, level zapcore.Level) *remoteWriter {
+	return &remoteWriter{
+		sender: sender,
+		cfg:    cfg,
+		level:  zap.NewAtomicLevelAt(level),
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Write 将一条编码后的日志放入本地缓冲，缓冲满时丢弃最早的日志
+func (w *remoteWriter) Write(p []byte) (int, error) {
+	record := remoteLogRecord{time: time.Now(), line: bytes.TrimRight(append([]byte(nil), p...), "\n")}
+
+	w.mu.Lock()
+	if len(w.buffer) >= w.cfg.BufferSize {
+		w.buffer = w.buffer[1:]
+		atomic.AddInt64(&w.dropped, 1)
+	}
+	w.buffer = append(w.buffer, record)
+	full := len(w.buffer) >= w.cfg.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Sync 日志由后台协程发送，这里不做任何事
+func (w *remoteWriter) Sync() error {
+	return nil
+}
+
+// run 按发送间隔或缓冲达到批量大小时发送日志
+func (w *remoteWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			w.flush(true)
+			return
+		case <-ticker.C:
+			w.flush(false)
+		case <-w.notify:
+			w.flush(false)
+		}
+	}
+}
+
+// flush 分批发送缓冲中的日志；发送失败时放回缓冲等待下一轮，final 为 true 时直接丢弃
+func (w *remoteWriter) flush(final bool) {
+	for {
+		w.mu.Lock()
+		n := len(w.buffer)
+		if n > w.cfg.BatchSize {
+			n = w.cfg.BatchSize
+		}
+		batch := append([]remoteLogRecord(nil), w.buffer[:n]...)
+		w.buffer = w.buffer[n:]
+		w.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := w.sendWithRetry(batch); err != nil {
+			// 不能写回日志模块，避免失败时循环产生日志
+			fmt.Fprintf(os.Stderr, "remote log sink %s: %v\n", w.sender.name(), err)
+			if final {
+				w.mu.Lock()
+				atomic.AddInt64(&w.dropped, int64(len(w.buffer)+len(batch)))
+				w.buffer = nil
+				w.mu.Unlock()
+				return
+			}
+			w.requeue(batch)
+			return
+		}
+	}
+}
+
+// requeue 将发送失败的日志放回缓冲头部，超出缓冲大小的部分丢弃最早的日志
+func (w *remoteWriter) requeue(batch []remoteLogRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buffer := append(batch, w.buffer...)
+	if extra := len(buffer) - w.cfg.BufferSize; extra > 0 {
+		buffer = buffer[extra:]
+		atomic.AddInt64(&w.dropped, int64(extra))
+	}
+	w.buffer = buffer
+}
+
+// sendWithRetry 发送一批日志，失败时按指数退避重试
+func (w *remoteWriter) sendWithRetry(batch []remoteLogRecord) error {
+	backoff := w.cfg.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= w.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-w.stop:
+				// 关闭时不再等待退避，立即做最后一次尝试
+			}
+			backoff *= 2
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = w.sender.send(ctx, batch)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to send %d log entries after %d retries: %v", len(batch), w.cfg.MaxRetries, err)
+}
+
+// close 发送剩余日志并关闭接收端，有日志被丢弃时返回错误
+func (w *remoteWriter) close() error {
+	close(w.stop)
+	<-w.done
+	if err := w.sender.close(); err != nil {
+		return fmt.Errorf("%s: %v", w.sender.name(), err)
+	}
+	if dropped := atomic.LoadInt64(&w.dropped); dropped > 0 {
+		return fmt.Errorf("%s: dropped %d log entries", w.sender.name(), dropped)
+	}
+	return nil
+}
+
+// lokiSender 通过 Loki 推送接口（/loki/api/v1/push）发送日志
+type lokiSender struct {
+	url      string
+	tenantID string
+	labels   map[string]string
+	client   *http.Client
+}
+
+// newLokiSender 创建 Loki 接收端
+func newLokiSender(cfg config.LokiConfig) *lokiSender {
+	labels := map[string]string{"job": "openstress"}
+	if host, err := os.Hostname(); err == nil {
+		labels["host"] = host
+	}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	return &lokiSender{
+		url:      strings.TrimRight(cfg.URL, "/") + "/loki/api/v1/push",
+		tenantID: cfg.TenantID,
+		labels:   labels,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// name 返回接收端名称
+func (l *lokiSender) name() string {
+	return "loki"
+}
+
+// send 推送一批日志，所有日志属于同一个流
+func (l *lokiSender) send(ctx context.Context, records []remoteLogRecord) error {
+	values := make([][2]string, 0, len(records))
+	for _, record := range records {
+		values = append(values, [2]string{strconv.FormatInt(record.time.UnixNano(), 10), string(record.line)})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"streams": []map[string]interface{}{{"stream": l.labels, "values": values}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode loki push request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create loki push request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", l.tenantID)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("loki push failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("loki push failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// close Loki 接收端没有需要释放的资源
+func (l *lokiSender) close() error {
+	return nil
+}
+
+// kafkaSender 通过 Kafka 生产者发送日志，消息键为主机名，同一节点的日志保持顺序
+type kafkaSender struct {
+	writer *kafka.Writer
+	key    []byte
+}
+
+// newKafkaSender 创建 Kafka 接收端，重试由 remoteWriter 负责
+func newKafkaSender(cfg config.KafkaConfig) *kafkaSender {
+	host, _ := os.Hostname()
+	return &kafkaSender{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			MaxAttempts:  1,
+			BatchTimeout: 10 * time.Millisecond,
+			WriteTimeout: 10 * time.Second,
+		},
+		key: []byte(host),
+	}
+}
+
+// name 返回接收端名称
+func (k *kafkaSender) name() string {
+	return "kafka"
+}
+
+// send 写入一批日志消息
+func (k *kafkaSender) send(ctx context.Context, records []remoteLogRecord) error {
+	messages := make([]kafka.Message, 0, len(records))
+	for _, record := range records {
+		messages = append(messages, kafka.Message{Key: k.key, Value: record.line, Time: record.time})
+	}
+	if err := k.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("kafka write failed: %v", err)
+	}
+	return nil
+}
+
+// close 关闭 Kafka 生产者
+func (k *kafkaSender) close() error {
+	return k.writer.Close()
+}
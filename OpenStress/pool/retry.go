@@ -0,0 +1,151 @@
+// retry.go
+// 重试策略模块
+// 本文件负责任务失败后的重试策略：最大执行次数、退避方式（固定间隔或指数退避）、随机抖动，
+// 以及按错误或状态码决定是否重试。
+//
+// 技术实现细节：
+// 1. RetryPolicy 通过 Pool.SubmitWithRetry 或 TaskDetail.Retry 设置，零值表示不重试。
+// 2. 第 n 次重试前的等待时间：固定间隔为 Delay，指数退避为 Delay*2^(n-1)，超过 MaxDelay 时取 MaxDelay；
+//    设置 Jitter 时在 [d*(1-Jitter), d*(1+Jitter)] 内随机，避免大量任务同时重试。
+// 3. RetryOn 为 nil 时所有错误都重试；RetryOnStatus 和 RetryOnErrors 按状态码（StatusError）和错误类型判断。
+// 4. 抖动使用由全局随机种子派生的随机数生成器（random.New），设置相同的种子时等待时间可以复现。
+// 5. 每次执行前把已重试次数写入 TaskContext.RetryCount，任务写入结果时通过 ResultData.ApplyExecution 记录到 JTL。
+
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/random"
+)
+
+// DefaultRetryDelay 未设置 RetryPolicy.Delay 时首次重试前的等待时间
+const DefaultRetryDelay = time.Second
+
+// BackoffStrategy 重试的退避方式
+type BackoffStrategy string
+
+// 退避方式
+const (
+	BackoffExponential BackoffStrategy = "exponential" // 每次重试的等待时间翻倍（默认）
+	BackoffFixed       BackoffStrategy = "fixed"       // 每次重试等待相同的时间
+)
+
+// RetryPolicy 任务失败后的重试策略，零值表示不重试
+type RetryPolicy struct {
+	MaxAttempts int             // 最多执行次数（包括首次执行），小于等于 1 表示不重试
+	Backoff     BackoffStrategy // 退避方式，默认 BackoffExponential
+	Delay       time.Duration   // 首次重试前的等待时间，默认 DefaultRetryDelay
+	MaxDelay    time.Duration   // 等待时间上限，0 表示不限制
+	Jitter      float64         // 随机抖动比例（0~1），0 表示不抖动
+
+	// RetryOn 判断失败是否需要重试，为 nil 时所有错误都重试
+	RetryOn func(err error) bool
+}
+
+// validate 检查重试策略配置
+func (r RetryPolicy) validate() error {
+	if r.MaxAttempts < 0 || r.Delay < 0 || r.MaxDelay < 0 {
+		return fmt.Errorf("retry max attempts, delay and max delay must not be negative")
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be between 0 and 1")
+	}
+	switch r.Backoff {
+	case "", BackoffExponential, BackoffFixed:
+		return nil
+	default:
+		return fmt.Errorf("unknown retry backoff strategy %q", r.Backoff)
+	}
+}
+
+// shouldRetry 判断第 attempt 次执行失败后是否继续重试
+func (r RetryPolicy) shouldRetry(err error, attempt int) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	return r.RetryOn == nil || r.RetryOn(err)
+}
+
+// jitterRng 抖动使用的随机数生成器，由全局随机种子派生，首次使用时创建
+var (
+	jitterMu  sync.Mutex
+	jitterRng *rand.Rand
+)
+
+// delay 返回第 retry 次重试（从 1 开始）前的等待时间
+func (r RetryPolicy) delay(retry int) time.Duration {
+	d := r.Delay
+	if d <= 0 {
+		d = DefaultRetryDelay
+	}
+	if r.Backoff != BackoffFixed {
+		for i := 1; i < retry && (r.MaxDelay <= 0 || d < r.MaxDelay); i++ {
+			d *= 2
+		}
+	}
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	if r.Jitter > 0 {
+		jitterMu.Lock()
+		if jitterRng == nil {
+			jitterRng = random.New("retry-jitter")
+		}
+		f := jitterRng.Float64()
+		jitterMu.Unlock()
+		d = time.Duration(float64(d) * (1 + r.Jitter*(2*f-1)))
+	}
+	return d
+}
+
+// StatusError 带状态码的任务错误，任务返回该错误时可以用 RetryOnStatus 按状态码决定是否重试
+type StatusError struct {
+	Code int   // 状态码，例如 HTTP 状态码
+	Err  error // 原始错误，可以为 nil
+}
+
+// Error 实现 error 接口
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("status %d: %v", e.Code, e.Err)
+	}
+	return fmt.Sprintf("status %d", e.Code)
+}
+
+// Unwrap 返回原始错误
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// RetryOnStatus 返回按状态码判断的 RetryOn：错误链中的 StatusError 状态码属于 codes 时重试
+func RetryOnStatus(codes ...int) func(err error) bool {
+	return func(err error) bool {
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			return false
+		}
+		for _, code := range codes {
+			if statusErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryOnErrors 返回按错误判断的 RetryOn：错误链中包含 targets 之一（errors.Is）时重试
+func RetryOnErrors(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
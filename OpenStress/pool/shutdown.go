@@ -0,0 +1,164 @@
+// shutdown.go
+// 优雅停止模块
+// 本文件负责协调收到 SIGINT/SIGTERM 时各子系统的停止（ShutdownManager）：先停止产生负载，
+// 再由主流程写出已收集的结果、生成标记为"已中断"的部分报告并以 ExitCodeInterrupted 退出，而不是在 Ctrl-C 时丢失全部结果。
+//
+// 技术实现细节：
+// 1. 各子系统通过 Register 注册停止步骤（例如停止 VU、关闭协程池），收到第一个信号时按注册顺序依次执行，
+//    每个步骤最多等待 StepTimeout，超时或出错时记录日志并继续执行后续步骤。
+// 2. 停止步骤只负责停止产生负载；结果收集器的写出、报告生成和日志关闭由主流程在负载停止后完成，
+//    通过 Interrupted 判断是否被中断，通过 Collector.SetAbort 在报告中标记（结束原因为 StopInterrupted）。
+// 3. 停止过程中再次收到信号时立即以 ExitCodeInterrupted 退出，用于停止步骤卡住的情况。
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ExitCodeInterrupted 压测被 SIGINT/SIGTERM 中断时的进程退出码（与 shell 中 Ctrl-C 的 128+2 约定一致）
+const ExitCodeInterrupted = 130
+
+// DefaultShutdownStepTimeout 每个停止步骤默认的最长等待时间
+const DefaultShutdownStepTimeout = 30 * time.Second
+
+// shutdownStep 一个已注册的停止步骤
+type shutdownStep struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// ShutdownManager 收到中断信号时按顺序停止各子系统，并发安全
+type ShutdownManager struct {
+	StepTimeout time.Duration // 每个停止步骤的最长等待时间，默认 DefaultShutdownStepTimeout
+
+	mu          sync.Mutex
+	steps       []shutdownStep
+	signal      os.Signal
+	at          time.Time
+	interrupted chan struct{} // 收到第一个信号时关闭
+	stopped     chan struct{} // 全部停止步骤执行完后关闭
+	signals     chan os.Signal
+	exit        func(code int) // 再次收到信号时调用，默认 os.Exit
+}
+
+// NewShutdownManager 创建停止管理器，调用 Watch 后开始监听信号
+func NewShutdownManager() *ShutdownManager {
+	return &ShutdownManager{
+		StepTimeout: DefaultShutdownStepTimeout,
+		interrupted: make(chan struct{}),
+		stopped:     make(chan struct{}),
+		exit:        os.Exit,
+	}
+}
+
+// Register 注册一个停止步骤，收到中断信号时按注册顺序执行；已经中断后注册的步骤立即执行
+func (m *ShutdownManager) Register(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	if m.signal == nil {
+		m.steps = append(m.steps, shutdownStep{name: name, fn: fn})
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+	m.runStep(shutdownStep{name: name, fn: fn})
+}
+
+// Watch 开始监听 SIGINT 和 SIGTERM
+func (m *ShutdownManager) Watch() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.signals != nil {
+		return
+	}
+	m.signals = make(chan os.Signal, 2)
+	signal.Notify(m.signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range m.signals {
+			if !m.Trigger(sig) {
+				stressLogger.Log("ERROR", fmt.Sprintf("Received %v again during shutdown, exiting immediately", sig))
+				m.exit(ExitCodeInterrupted)
+			}
+		}
+	}()
+}
+
+// Stop 停止监听信号，不影响已经开始的停止过程
+func (m *ShutdownManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.signals != nil {
+		signal.Stop(m.signals)
+	}
+}
+
+// Trigger 以 sig 为原因开始停止（与收到信号相同），在后台按顺序执行停止步骤。
+// 第一次调用返回 true，已经在停止时返回 false
+func (m *ShutdownManager) Trigger(sig os.Signal) bool {
+	m.mu.Lock()
+	if m.signal != nil {
+		m.mu.Unlock()
+		return false
+	}
+	m.signal, m.at = sig, time.Now()
+	steps := m.steps
+	m.steps = nil
+	close(m.interrupted)
+	m.mu.Unlock()
+
+	stressLogger.Log("WARN", fmt.Sprintf("Received %v, stopping load and finalizing results (send again to exit immediately)", sig))
+	go func() {
+		defer close(m.stopped)
+		for _, step := range steps {
+			m.runStep(step)
+		}
+		stressLogger.Log("INFO", "All shutdown steps completed")
+	}()
+	return true
+}
+
+// runStep 执行一个停止步骤，超时或出错时记录日志
+func (m *ShutdownManager) runStep(step shutdownStep) {
+	timeout := m.StepTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownStepTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- step.fn(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			stressLogger.Log("ERROR", fmt.Sprintf("Shutdown step %s failed: %v", step.name, err))
+			return
+		}
+		stressLogger.Log("INFO", fmt.Sprintf("Shutdown step %s completed", step.name))
+	case <-ctx.Done():
+		stressLogger.Log("ERROR", fmt.Sprintf("Shutdown step %s did not finish within %v", step.name, timeout))
+	}
+}
+
+// Interrupted 返回收到中断信号时关闭的通道
+func (m *ShutdownManager) Interrupted() <-chan struct{} {
+	return m.interrupted
+}
+
+// Stopped 返回全部停止步骤执行完后关闭的通道
+func (m *ShutdownManager) Stopped() <-chan struct{} {
+	return m.stopped
+}
+
+// Interruption 返回中断信号和收到信号的时间，没有中断时返回 false
+func (m *ShutdownManager) Interruption() (os.Signal, time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.signal, m.at, m.signal != nil
+}
@@ -103,17 +103,20 @@
 package pool
 
 import (
-	"OpenStress/tasks"
+	"context"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync/atomic"
 	"time"
+
+	"github.com/potatoImp/OpenStress/tasks"
 )
 
 // TaskStatus 定义任务状态
@@ -156,12 +159,15 @@ type TaskDetail struct {
 	RetryCount   int32         // 当前重试次数，使用原子操作
 	MaxRetries   int32         // 最大重试次数
 	RetryDelay   time.Duration // 重试间隔
+	Retry        *RetryPolicy  // 重试策略，设置后代替 MaxRetries 和 RetryDelay
 	Timeout      time.Duration // 任务超时时间
 	Priority     int32         // 任务优先级
 	Dependencies []*TaskDetail // 依赖任务
 	StartTime    time.Time     // 任务开始时间
 	EndTime      time.Time     // 任务结束时间
 	Error        error         // 任务执行中的错误信息
+
+	onRetry func(attempt int32) // 重试时的回调，由协程池设置，用于上报监控
 }
 
 // Logger 用于记录日志
@@ -252,16 +258,30 @@ func (t *TaskDetail) executeWithTimeout() error {
 
 // retry 重试任务
 func (t *TaskDetail) retry() error {
-	if atomic.LoadInt32((*int32)(&t.RetryCount)) >= t.MaxRetries {
-		logger.Log("ERROR", fmt.Sprintf("Task %s exceeded maximum retry attempts (%d)", t.ID, t.MaxRetries))
+	maxRetries, delay := t.MaxRetries, t.RetryDelay
+	if t.Retry != nil {
+		maxRetries = int32(max(t.Retry.MaxAttempts-1, 0))
+		if t.Retry.RetryOn != nil && !t.Retry.RetryOn(t.Error) {
+			logger.Log("ERROR", fmt.Sprintf("Task %s failed with a non-retryable error: %v", t.ID, t.Error))
+			return t.Error
+		}
+	}
+	if atomic.LoadInt32((*int32)(&t.RetryCount)) >= maxRetries {
+		logger.Log("ERROR", fmt.Sprintf("Task %s exceeded maximum retry attempts (%d)", t.ID, maxRetries))
 		return fmt.Errorf("exceeded maximum retry attempts")
 	}
 
 	atomic.AddInt32((*int32)(&t.RetryCount), 1)
 	currentRetry := atomic.LoadInt32((*int32)(&t.RetryCount))
+	if t.Retry != nil {
+		delay = t.Retry.delay(int(currentRetry))
+	}
 
-	logger.Log("WARNING", fmt.Sprintf("Retrying task %s (attempt %d/%d)", t.ID, currentRetry, t.MaxRetries))
-	time.Sleep(t.RetryDelay)
+	logger.Log("WARNING", fmt.Sprintf("Retrying task %s (attempt %d/%d) in %v", t.ID, currentRetry, maxRetries, delay))
+	if t.onRetry != nil {
+		t.onRetry(currentRetry)
+	}
+	time.Sleep(delay)
 
 	return t.executeTask()
 }
@@ -328,6 +348,57 @@ func LoadTasks(pool *Pool) {
 	}
 }
 
+// RegisterTasks 将 tasks.Task 上所有以 "Task_" 开头的方法注册到任务池，供按名称提交（如 API 调用）
+func RegisterTasks(pool *Pool) {
+	RegisterTasksWithClient(pool, nil)
+}
+
+// RegisterTasksWithClient 与 RegisterTasks 相同，任务通过 tasks.Task.HTTPClient 使用 client 发送请求
+// （例如 tasks.NewHTTPClient 创建的签名客户端），client 为 nil 时使用 tasks.DefaultHTTPClient。
+// 所有任务共用同一个 client，连接在请求之间复用
+func RegisterTasksWithClient(pool *Pool, client *http.Client) {
+	taskType := reflect.TypeOf(&tasks.Task{})
+	for i := 0; i < taskType.NumMethod(); i++ {
+		method := taskType.Method(i)
+		// 仅注册无参数（除接收者外）且以 "Task_" 开头的方法
+		if method.Type.NumIn() != 1 || !strings.HasPrefix(method.Name, "Task_") {
+			continue
+		}
+		name := method.Name
+		fn := method.Func
+		pool.RegisterTask(name, func(threadID int32) {
+			fn.Call([]reflect.Value{reflect.ValueOf(&tasks.Task{ID: name, Client: client})})
+		})
+	}
+}
+
+// RegisterTestPlan 将 HTTP 测试计划（见 tasks.TestPlan）注册为名为 name 的任务，每次执行按顺序回放一遍计划。
+// planTask 的 Collector 和 Client 由调用方设置；以虚拟用户方式运行时 threadID 为 VU 编号
+func RegisterTestPlan(pool *Pool, name string, planTask *tasks.PlanTask) {
+	pool.RegisterTask(name, func(threadID int32) {
+		if err := planTask.Run(context.Background(), nil, nil, int(threadID)); err != nil {
+			stressLogger.Log("DEBUG", fmt.Sprintf("Test plan %s stopped: %v", name, err))
+		}
+	})
+}
+
+// TestPlanVUConfig 按测试计划建议的负载生成虚拟用户配置，计划没有设置负载时返回 1 个 VU 执行一次；
+// 协程池不支持逐步启动，RampUp 不会被使用
+func TestPlanVUConfig(plan *tasks.TestPlan) VUConfig {
+	if plan.Load == nil {
+		return VUConfig{VUs: 1, IterationsPerVU: 1}
+	}
+	cfg := VUConfig{VUs: plan.Load.VUs, Duration: plan.Load.Duration, IterationsPerVU: plan.Load.IterationsPerVU}
+	if cfg.VUs <= 0 {
+		cfg.VUs = 1
+	}
+	if cfg.Duration == 0 && cfg.IterationsPerVU == 0 {
+		// 没有停止条件时与 JMeter 的无限循环不同，只执行一次，避免意外的无限压测
+		cfg.IterationsPerVU = 1
+	}
+	return cfg
+}
+
 // LoadTasks2 自动加载任务到任务池
 func LoadTasks2(pool *Pool) {
 	fmt.Println("Loading tasks...11111111111111")
@@ -75,9 +75,16 @@ func (tm *TimeoutManager) ExecuteWithTimeout(task func()) error {
 
 // Retry 执行带有重试机制的任务
 func (tm *TimeoutManager) Retry(task func()) {
+	tm.RetryWithContext(newTaskContext(""), func(*TaskContext) { task() })
+}
+
+// RetryWithContext 执行带有重试机制的任务，每次执行前把已重试次数写入 tc.RetryCount，
+// 任务可通过 ResultData.ApplyExecution 在结果中记录重试次数
+func (tm *TimeoutManager) RetryWithContext(tc *TaskContext, task func(tc *TaskContext)) {
 	for i := 0; i < tm.RetryCount; i++ {
 		tm.logger.Log("INFO", fmt.Sprintf("Attempting task retry %d/%d...", i+1, tm.RetryCount)) // 记录日志，级别为 INFO
-		err := tm.ExecuteWithTimeout(task)
+		tc.RetryCount = i
+		err := tm.ExecuteWithTimeout(func() { task(tc) })
 		if err == nil {
 			return
 		}
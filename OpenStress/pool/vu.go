@@ -0,0 +1,579 @@
+// vu.go
+// 虚拟用户（VU）执行模块
+// 本文件负责以虚拟用户方式运行任务，并通过启动屏障保证所有 VU 在同一时刻开始测量。
+//
+// 执行流程：
+// 1. 所有 VU 在启动屏障处等待，直到全部就绪。
+// 2. 屏障放行后所有 VU 同时开始执行，前 WarmUp 时长为预热阶段，不计入测量。
+// 3. 测量开始时间对齐到整秒，保证按秒聚合时第一个桶就是完整的。
+// 4. 分布式模式下由协调者通过 SyncStartTime 计算统一的 StartAt 下发给各个 worker，
+//    各 worker 使用相同的测量开始时间，跨 worker 的按秒聚合从第一个桶开始就是准确的。
+//    下发通过 worker 的 POST /api/vus/start 接口完成（见 api/distributed.go 的 StartCoordinator）。
+// 5. VU 占用的 worker 在启动前通过 reserveSlots 一次性预留，与调度协程使用同一份计数。
+// 6. 生命周期钩子（VUHooks）：OnTestStart 在预留 worker 之前执行一次，返回的数据通过 VUContext.SetupData
+//    共享给所有 VU；OnVUStart 在 VU 到达启动屏障之前执行，不计入测量，失败的 VU 不执行迭代；
+//    OnVUStop 在 VU 最后一次迭代之后执行；OnTestEnd 在所有 VU 结束后执行一次。钩子 panic 按错误处理。
+// 7. 停止条件：测量时长、总迭代次数、每个 VU 的迭代次数、最大错误数和外部停止（VURun.Stop，
+//    API 的 POST /api/vus/stop），先满足者生效，结束原因通过 VURun.StopReason 获取并写入报告。
+//    总迭代次数和错误数包含预热阶段；迭代 panic 或调用 VUContext.Fail 记为一次错误。
+//    错误预算（VUConfig.Abort，见 abort.go）超出时提前中止，中止原因和时间通过 VURun.Abort 获取。
+// 8. 每个 VU 的 Rand 由全局随机种子（random 包）和 VU 编号派生，思考时间等随机行为在相同种子下可复现。
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/potatoImp/OpenStress/random"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// VUConfig 虚拟用户执行配置
+type VUConfig struct {
+	VUs      int           // 虚拟用户数
+	Duration time.Duration // 测量阶段时长，为 0 时只按迭代次数停止
+	WarmUp   time.Duration // 预热时长，预热期间的迭代不计入测量
+	StartAt  time.Time     // 统一的测量开始时间（分布式模式由协调者下发），零值表示本地计算
+	Hooks    VUHooks       // 生命周期钩子
+
+	Iterations      int // 所有 VU 合计的迭代次数上限，0 表示不限制
+	IterationsPerVU int // 每个 VU 的迭代次数上限，0 表示不限制
+	MaxErrors       int // 出错的迭代数达到该值时停止所有 VU，0 表示不限制
+
+	Abort AbortPolicy // 错误预算，滑动窗口错误率或连续失败超出时提前中止
+}
+
+// StopReason VU 执行结束的原因
+type StopReason string
+
+const (
+	StopDuration        StopReason = "duration"          // 测量时长已到
+	StopIterations      StopReason = "iterations"        // 达到总迭代次数
+	StopIterationsPerVU StopReason = "iterations_per_vu" // 每个 VU 都完成了各自的迭代次数
+	StopMaxErrors       StopReason = "max_errors"        // 达到最大错误数
+	StopExternal        StopReason = "external"          // 通过 VURun.Stop 或 API 停止
+	StopShutdown        StopReason = "shutdown"          // 协程池关闭
+	StopInterrupted     StopReason = "interrupted"       // 收到 SIGINT/SIGTERM（见 ShutdownManager）
+
+	StopErrorRate           StopReason = "error_rate"           // 滑动窗口内的错误率超出错误预算（提前中止）
+	StopConsecutiveFailures StopReason = "consecutive_failures" // 连续失败次数超出错误预算（提前中止）
+)
+
+// VUHooks 虚拟用户生命周期钩子，均为可选
+type VUHooks struct {
+	OnTestStart func() (interface{}, error) // 所有 VU 启动前执行一次，返回的数据保存在每个 VU 的 SetupData 中，返回错误时不启动 VU
+	OnVUStart   func(vu *VUContext) error   // 每个 VU 第一次迭代前执行，通常在 vu.State 中创建 VU 独占的客户端、令牌等资源
+	OnVUStop    func(vu *VUContext)         // 每个 VU 结束后执行，用于释放 OnVUStart 创建的资源（OnVUStart 失败时不执行）
+	OnTestEnd   func(setupData interface{}) // 所有 VU 结束后执行一次
+}
+
+// VUContext 虚拟用户上下文，每个 VU 一个实例
+// 内嵌的 TaskContext 中 VUID 为虚拟用户编号（从 0 开始），Iteration 为当前迭代次数，
+// TraceID 在每次迭代开始前重新生成，ThrottleWait 为本次迭代开始前的限流等待时间
+type VUContext struct {
+	TaskContext
+	MeasureStart time.Time   // 测量开始时间
+	SetupData    interface{} // OnTestStart 返回的数据，所有 VU 共享，只读
+	State        interface{} // VU 独占的数据，通常在 OnVUStart 中设置，迭代之间保留
+	Rand         *rand.Rand  // VU 独占的随机数生成器，由全局随机种子和 VU 编号派生
+	ScheduledAt  time.Time   // 到达率模式下本次迭代计划的到达时间（见 arrival.go），VU 模式下为零值
+
+	failed bool // 当前迭代是否出错
+}
+
+// ThinkTime 在 [min, max] 内随机暂停，模拟用户思考时间
+func (vu *VUContext) ThinkTime(min, max time.Duration) {
+	d := min
+	if max > min {
+		d += time.Duration(vu.Rand.Int63n(int64(max-min) + 1))
+	}
+	time.Sleep(d)
+}
+
+// Fail 将当前迭代记为出错，出错的迭代计入 VUConfig.MaxErrors 和错误预算
+func (vu *VUContext) Fail(err error) {
+	vu.failed = true
+	if err != nil {
+		vu.Log("DEBUG", fmt.Sprintf("VU %d iteration %d failed: %v", vu.VUID, vu.Iteration, err))
+	}
+}
+
+// Measuring 判断当前是否处于测量阶段（预热阶段返回 false）
+func (vu *VUContext) Measuring() bool {
+	return !time.Now().Before(vu.MeasureStart)
+}
+
+// StartBarrier 启动屏障，所有参与者到达后统一放行，并给出对齐到整秒的测量开始时间
+type StartBarrier struct {
+	mu           sync.Mutex
+	parties      int
+	arrived      int
+	warmUp       time.Duration
+	startAt      time.Time
+	measureStart time.Time
+	released     chan struct{}
+}
+
+// NewStartBarrier 创建启动屏障
+// startAt 非零时作为统一的测量开始时间，否则在最后一个参与者到达时按 warmUp 计算
+func NewStartBarrier(parties int, warmUp time.Duration, startAt time.Time) *StartBarrier {
+	return &StartBarrier{
+		parties:  parties,
+		warmUp:   warmUp,
+		startAt:  startAt,
+		released: make(chan struct{}),
+	}
+}
+
+// SyncStartTime 计算统一的测量开始时间：now + lead + warmUp，向上对齐到整秒
+// lead 为留给各 worker 接收指令并就绪的时间
+func SyncStartTime(now time.Time, lead, warmUp time.Duration) time.Time {
+	return alignToSecond(now.Add(lead + warmUp))
+}
+
+// alignToSecond 向上对齐到整秒
+func alignToSecond(t time.Time) time.Time {
+	truncated := t.Truncate(time.Second)
+	if truncated.Equal(t) {
+		return t
+	}
+	return truncated.Add(time.Second)
+}
+
+// Arrive 参与者到达屏障，最后一个参与者到达时计算测量开始时间并放行
+func (b *StartBarrier) Arrive() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.arrived++
+	if b.arrived != b.parties {
+		return
+	}
+	if b.startAt.IsZero() {
+		b.measureStart = alignToSecond(time.Now().Add(b.warmUp))
+	} else {
+		b.measureStart = b.startAt
+	}
+	close(b.released)
+}
+
+// MeasureStart 返回测量开始时间，屏障放行前返回零值
+func (b *StartBarrier) MeasureStart() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.measureStart
+}
+
+// Wait 等待屏障放行，返回测量开始时间
+// 使用统一 StartAt 时会等待到 StartAt - warmUp 再放行，保证各 worker 的预热时长一致
+func (b *StartBarrier) Wait(ctx context.Context) (time.Time, error) {
+	select {
+	case <-b.released:
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	}
+
+	measureStart := b.MeasureStart()
+	if delay := time.Until(measureStart.Add(-b.warmUp)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		}
+	}
+	return measureStart, nil
+}
+
+// VURun 一次已启动的虚拟用户执行
+type VURun struct {
+	barrier *StartBarrier
+	wg      sync.WaitGroup
+	ctx     context.Context // 运行期间的 context，Stop、Wait 返回或协程池关闭时取消
+	cancel  context.CancelFunc
+	done    chan struct{} // 所有 VU 结束且 OnTestEnd 执行完后关闭
+
+	cfg        VUConfig
+	iterations atomic.Int64 // 已开始的迭代数（包括预热阶段）
+	dropped    atomic.Int64 // 到达率模式下没有空闲 VU 而丢弃的到达数
+	errors     atomic.Int64 // 出错的迭代数
+	stopped    atomic.Bool  // 是否已触发停止所有 VU 的条件
+	budget     *errorBudget // 错误预算，未启用时为 nil
+
+	mu     sync.Mutex
+	reason StopReason
+	global bool       // reason 是否为停止所有 VU 的原因
+	abort  *AbortInfo // 超出错误预算时的中止信息
+}
+
+// Wait 阻塞直到所有 VU 结束（包括 OnTestEnd 执行完），返回测量开始时间
+func (r *VURun) Wait() time.Time {
+	<-r.done
+	r.cancel()
+	return r.barrier.MeasureStart()
+}
+
+// Stop 通知所有 VU 在当前迭代结束后停止，不等待 VU 结束
+func (r *VURun) Stop() {
+	r.stop(StopExternal)
+	r.cancel()
+}
+
+// StopReason 返回结束原因，VU 尚未结束时返回空字符串
+// 多个条件先后满足时以第一个停止所有 VU 的条件为准
+func (r *VURun) StopReason() StopReason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reason
+}
+
+// Abort 返回提前中止的原因和时间，没有因错误预算中止时返回 false
+func (r *VURun) Abort() (AbortInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.abort == nil {
+		return AbortInfo{}, false
+	}
+	return *r.abort, true
+}
+
+// Iterations 返回已开始的迭代数（包括预热阶段）
+func (r *VURun) Iterations() int64 {
+	return r.iterations.Load()
+}
+
+// Errors 返回出错的迭代数
+func (r *VURun) Errors() int64 {
+	return r.errors.Load()
+}
+
+// Dropped 返回到达率模式下因没有空闲 VU 而丢弃的到达数，VU 模式下始终为 0
+func (r *VURun) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// stop 触发停止所有 VU 的条件，只记录第一个原因
+func (r *VURun) stop(reason StopReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.global {
+		return
+	}
+	r.reason = reason
+	r.global = true
+	r.stopped.Store(true)
+}
+
+// finish 记录单个 VU 因自身条件（测量时长、每个 VU 的迭代次数）结束的原因，
+// 已触发停止所有 VU 的条件时保留原有原因
+func (r *VURun) finish(reason StopReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.global {
+		r.reason = reason
+	}
+}
+
+// claimIteration 占用一次迭代名额，达到总迭代次数时触发停止并返回 false
+func (r *VURun) claimIteration() bool {
+	n := r.iterations.Add(1)
+	if r.cfg.Iterations > 0 && n > int64(r.cfg.Iterations) {
+		r.iterations.Add(-1)
+		r.stop(StopIterations)
+		return false
+	}
+	return true
+}
+
+// addError 记录一次出错的迭代，达到最大错误数时触发停止
+func (r *VURun) addError() {
+	n := r.errors.Add(1)
+	if r.cfg.MaxErrors > 0 && n >= int64(r.cfg.MaxErrors) {
+		r.stop(StopMaxErrors)
+	}
+}
+
+// checkBudget 将一次完成的迭代计入错误预算，超出时触发中止
+func (r *VURun) checkBudget(failed bool) {
+	if r.budget == nil {
+		return
+	}
+	info, exceeded := r.budget.record(failed, time.Now())
+	if !exceeded {
+		return
+	}
+	r.mu.Lock()
+	if r.global {
+		r.mu.Unlock()
+		return
+	}
+	r.reason = info.Reason
+	r.global = true
+	r.abort = &info
+	r.stopped.Store(true)
+	r.mu.Unlock()
+	stressLogger.Log("ERROR", fmt.Sprintf("Aborting vus at %s: %s", info.Time.Format(time.RFC3339), info.Detail))
+}
+
+// RunVUs 以虚拟用户方式运行 fn，阻塞直到测量阶段结束，返回测量开始时间
+// 每个 VU 独占一个 worker，VU 数不能超过协程池当前的空闲 worker 数
+func (p *Pool) RunVUs(cfg VUConfig, fn func(vu *VUContext)) (time.Time, error) {
+	run, err := p.StartVUs(cfg, fn)
+	if err != nil {
+		return time.Time{}, err
+	}
+	measureStart := run.Wait()
+	stressLogger.Log("INFO", fmt.Sprintf("All vus finished (%s), measurement started at %s", run.StopReason(), measureStart.Format(time.RFC3339)))
+	return measureStart, nil
+}
+
+// StartVUs 启动虚拟用户后立即返回，通过 VURun.Wait 等待执行结束
+// VU 占用的 worker 在启动前一次性预留，与调度协程共用同一份计数，不会和排队任务争抢 worker
+func (p *Pool) StartVUs(cfg VUConfig, fn func(vu *VUContext)) (*VURun, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return p.startVUs(cfg, nil, func(run *VURun, vu *VUContext, end time.Time) { p.runVU(run, vu, end, fn) })
+}
+
+// validate 检查 VU 执行配置
+func (cfg VUConfig) validate() error {
+	if cfg.VUs <= 0 {
+		return fmt.Errorf("vus must be positive")
+	}
+	if cfg.Duration < 0 || cfg.Iterations < 0 || cfg.IterationsPerVU < 0 || cfg.MaxErrors < 0 {
+		return fmt.Errorf("duration, iterations and max errors must not be negative")
+	}
+	if cfg.Duration == 0 && cfg.Iterations == 0 && cfg.IterationsPerVU == 0 {
+		return fmt.Errorf("duration or iterations must be set")
+	}
+	if err := cfg.Abort.validate(); err != nil {
+		return err
+	}
+	if !cfg.StartAt.IsZero() && time.Now().After(cfg.StartAt.Add(-cfg.WarmUp)) {
+		return fmt.Errorf("start time %s has already passed", cfg.StartAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// startVUs 预留 worker 并启动 VU，每个 VU 通过屏障后执行 loop，直到 loop 返回；
+// dispatch 不为 nil 时在单独的协程中与 VU 一起运行（到达率模式的到达调度），随 VU 一起计入 Wait
+func (p *Pool) startVUs(cfg VUConfig, dispatch func(ctx context.Context, run *VURun), loop func(run *VURun, vu *VUContext, end time.Time)) (*VURun, error) {
+	hooks := cfg.Hooks
+	var setupData interface{}
+	if hooks.OnTestStart != nil {
+		err := callHook("OnTestStart", func() (err error) {
+			setupData, err = hooks.OnTestStart()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.reserveSlots(cfg.VUs); err != nil {
+		p.testEnd(hooks, setupData)
+		return nil, err
+	}
+
+	stressLogger.Log("INFO", fmt.Sprintf("Running %d vus for %v (iterations: %d, per vu: %d, max errors: %d) with %v warm-up",
+		cfg.VUs, cfg.Duration, cfg.Iterations, cfg.IterationsPerVU, cfg.MaxErrors, cfg.WarmUp))
+
+	ctx, cancel := context.WithCancel(p.closing)
+	run := &VURun{barrier: NewStartBarrier(cfg.VUs, cfg.WarmUp, cfg.StartAt), ctx: ctx, cancel: cancel, done: make(chan struct{}), cfg: cfg, budget: newErrorBudget(cfg.Abort)}
+	if dispatch != nil {
+		run.wg.Add(1)
+		go func() {
+			defer run.wg.Done()
+			dispatch(ctx, run)
+		}()
+	}
+	for i := 0; i < cfg.VUs; i++ {
+		vu := &VUContext{TaskContext: TaskContext{VUID: i}, SetupData: setupData, Rand: random.New(fmt.Sprintf("vu-%d", i))}
+		run.wg.Add(1)
+		err := p.taskPool.Submit(func() {
+			defer run.wg.Done()
+			defer p.releaseSlot()
+			// OnVUStart 在屏障之前执行，所有 VU 准备好资源后才同时开始
+			started := hooks.OnVUStart == nil || p.vuStart(hooks, vu) == nil
+			run.barrier.Arrive()
+			measureStart, err := run.barrier.Wait(ctx)
+			if err == nil && started {
+				vu.MeasureStart = measureStart
+				var end time.Time
+				if cfg.Duration > 0 {
+					end = measureStart.Add(cfg.Duration)
+				}
+				loop(run, vu, end)
+			}
+			if started && hooks.OnVUStop != nil {
+				if err := callHook("OnVUStop", func() error { hooks.OnVUStop(vu); return nil }); err != nil {
+					vu.Log("ERROR", fmt.Sprintf("VU %d: %v", vu.VUID, err))
+				}
+			}
+		})
+		if err != nil {
+			// 未启动的 VU 归还预留的 worker，已启动的 VU 在屏障处随 ctx 取消退出
+			run.wg.Done()
+			p.releaseSlots(cfg.VUs - i)
+			cancel()
+			run.wg.Wait()
+			p.testEnd(hooks, setupData)
+			return nil, fmt.Errorf("failed to start vu %d: %v", i, err)
+		}
+	}
+	go func() {
+		run.wg.Wait()
+		p.testEnd(hooks, setupData)
+		close(run.done)
+	}()
+	return run, nil
+}
+
+// vuStart 执行 OnVUStart，失败时记录日志，该 VU 不执行迭代
+func (p *Pool) vuStart(hooks VUHooks, vu *VUContext) error {
+	err := callHook("OnVUStart", func() error { return hooks.OnVUStart(vu) })
+	if err != nil {
+		vu.Log("ERROR", fmt.Sprintf("VU %d will not run: %v", vu.VUID, err))
+	}
+	return err
+}
+
+// testEnd 执行 OnTestEnd
+func (p *Pool) testEnd(hooks VUHooks, setupData interface{}) {
+	if hooks.OnTestEnd == nil {
+		return
+	}
+	if err := callHook("OnTestEnd", func() error { hooks.OnTestEnd(setupData); return nil }); err != nil {
+		stressLogger.Log("ERROR", err.Error())
+	}
+}
+
+// callHook 执行钩子，将 panic 转换为错误
+func callHook(name string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", name, r)
+		}
+	}()
+	if err := fn(); err != nil {
+		return fmt.Errorf("%s failed: %v", name, err)
+	}
+	return nil
+}
+
+// RegisterVUHooks 为已注册的任务设置生命周期钩子，StartRegisteredVUs（包括 API 启动的 VU）运行该任务时使用
+func (p *Pool) RegisterVUHooks(name string, hooks VUHooks) {
+	p.vuHooks.Store(name, hooks)
+}
+
+// StartRegisteredVUs 以虚拟用户方式运行已注册的任务，任务函数收到的 threadID 为 VU 编号，
+// 使用 RegisterVUHooks 设置的钩子
+func (p *Pool) StartRegisteredVUs(name string, cfg VUConfig) (*VURun, error) {
+	value, ok := p.registry.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("task %s is not registered", name)
+	}
+	fn := value.(func(threadID int32))
+	if hooks, ok := p.vuHooks.Load(name); ok {
+		cfg.Hooks = hooks.(VUHooks)
+	}
+	return p.StartVUs(cfg, func(vu *VUContext) { fn(int32(vu.VUID)) })
+}
+
+// reserveSlots 一次性预留 n 个 worker，空闲 worker 不足或协程池已关闭时返回错误
+func (p *Pool) reserveSlots(n int) error {
+	p.slotMu.Lock()
+	defer p.slotMu.Unlock()
+	if atomic.LoadInt32(&p.shutdownFlag) == 1 {
+		return fmt.Errorf("pool is shut down")
+	}
+	if free := int(atomic.LoadInt32(&p.maxWorkers)) - p.inFlight; n > free {
+		return fmt.Errorf("not enough free workers for %d vus (free: %d)", n, free)
+	}
+	p.inFlight += n
+	return nil
+}
+
+// releaseSlots 归还 reserveSlots 预留但未使用的 worker
+func (p *Pool) releaseSlots(n int) {
+	p.slotMu.Lock()
+	p.inFlight -= n
+	p.slotMu.Unlock()
+	p.slotCond.Broadcast()
+}
+
+// runVU 循环执行迭代直到满足停止条件或协程池关闭，暂停期间不执行迭代，end 为零值表示不限时长
+func (p *Pool) runVU(run *VURun, vu *VUContext, end time.Time, fn func(vu *VUContext)) {
+	p.activeVUs.Add(1)
+	defer p.activeVUs.Add(-1)
+	for {
+		switch {
+		case run.stopped.Load():
+			return
+		case atomic.LoadInt32(&p.shutdownFlag) == 1:
+			run.stop(StopShutdown)
+			return
+		case !end.IsZero() && !time.Now().Before(end):
+			run.finish(StopDuration)
+			return
+		case run.cfg.IterationsPerVU > 0 && vu.Iteration >= run.cfg.IterationsPerVU:
+			run.finish(StopIterationsPerVU)
+			return
+		}
+		if atomic.LoadInt32(&p.isPaused) == 1 {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		if !p.iterate(run, vu, fn) {
+			return
+		}
+	}
+}
+
+// iterate 占用迭代名额并执行一次迭代，达到总迭代次数或限流等待被中断（停止、协程池关闭）时返回 false
+func (p *Pool) iterate(run *VURun, vu *VUContext, fn func(vu *VUContext)) bool {
+	if !run.claimIteration() {
+		return false
+	}
+	waited, err := p.limiter.Wait(run.ctx, "")
+	if err != nil {
+		// 迭代没有执行，归还占用的名额
+		run.iterations.Add(-1)
+		if atomic.LoadInt32(&p.shutdownFlag) == 1 {
+			run.stop(StopShutdown)
+		}
+		return false
+	}
+	vu.ThrottleWait = waited
+	vu.TraceID = NewTraceID()
+	span := vu.startSpan("vu iteration",
+		attribute.Int("vu.id", vu.VUID),
+		attribute.Int("vu.iteration", vu.Iteration),
+	)
+	vu.failed = false
+	p.runIteration(vu, fn)
+	span.End()
+	if vu.failed {
+		run.addError()
+	}
+	run.checkBudget(vu.failed)
+	vu.Iteration++
+	return true
+}
+
+// runIteration 执行一次迭代，单次迭代 panic 不影响 VU 继续运行，记为出错的迭代
+func (p *Pool) runIteration(vu *VUContext, fn func(vu *VUContext)) {
+	defer func() {
+		if r := recover(); r != nil {
+			vu.failed = true
+			vu.Log("ERROR", fmt.Sprintf("VU %d iteration %d panicked: %v", vu.VUID, vu.Iteration, r))
+		}
+	}()
+	fn(vu)
+}
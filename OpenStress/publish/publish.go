@@ -0,0 +1,79 @@
+// publish.go
+// 报告发布模块
+// 本文件负责定义报告发布的扩展接口 ReportPublisher：报告生成后把报告目录（HTML 报告和 static 目录，
+// 或单个自包含 HTML 文件）上传到外部存储，返回可以分享的链接，压测完成通知中使用该链接。
+//
+// 技术实现细节：
+// 1. SaveReportToFile 为每份报告生成独立的目录，发布时上传报告文件所在目录中的全部文件，
+//    对象键为 <前缀>/<报告目录名>/<相对路径>，与 notify.ReportLink 的链接格式一致。
+// 2. 内置 S3 兼容对象存储的实现（S3Publisher，见 s3.go），其他存储实现 ReportPublisher 即可接入。
+
+package publish
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/potatoImp/OpenStress/config"
+)
+
+// ReportPublisher 报告发布接口
+type ReportPublisher interface {
+	// Publish 上传 reportPath 所在的报告目录，返回报告 HTML 文件的分享链接
+	Publish(ctx context.Context, reportPath string) (string, error)
+}
+
+// New 按配置创建报告发布器，未配置时返回 nil
+func New(cfg config.PublishConfig) (ReportPublisher, error) {
+	if cfg.S3.Bucket == "" {
+		return nil, nil
+	}
+	publisher, err := NewS3Publisher(cfg.S3)
+	if err != nil {
+		return nil, err
+	}
+	return publisher, nil
+}
+
+// bundleFile 报告目录中的一个文件
+type bundleFile struct {
+	path string // 本地路径
+	rel  string // 相对于报告目录父目录的路径，以 / 分隔，例如 nightly_2024-01-02_03-04-05/static/script.js
+}
+
+// reportBundle 列出报告目录中的全部文件，返回的第一个文件为报告 HTML 文件
+func reportBundle(reportPath string) ([]bundleFile, error) {
+	reportPath = filepath.Clean(reportPath)
+	dir := filepath.Dir(reportPath)
+	base := filepath.Dir(dir)
+	rel := func(path string) (string, error) {
+		r, err := filepath.Rel(base, path)
+		return filepath.ToSlash(r), err
+	}
+
+	reportRel, err := rel(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve report path: %v", err)
+	}
+	files := []bundleFile{{path: reportPath, rel: reportRel}}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path == reportPath {
+			return nil
+		}
+		r, err := rel(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, bundleFile{path: path, rel: r})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report files: %v", err)
+	}
+	return files, nil
+}
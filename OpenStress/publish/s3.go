@@ -0,0 +1,278 @@
+// s3.go
+// S3 报告发布模块
+// 本文件负责把报告上传到 S3 兼容的对象存储（AWS S3、MinIO 等），并生成报告的分享链接。
+//
+// 技术实现细节：
+// 1. 请求使用 AWS Signature Version 4 签名（只依赖标准库）：上传时签名请求头，载荷的 SHA-256 写入
+//    x-amz-content-sha256；分享链接可以是预签名的 GET 地址（签名在查询参数中，有效期最长 7 天）。
+// 2. 默认使用虚拟主机形式的地址（bucket.endpoint/key），MinIO 等不支持的服务开启 PathStyle 使用 endpoint/bucket/key。
+// 3. 分享链接的优先级：配置了 PublicURL（例如存储桶前的 CDN）时为 PublicURL/<对象键>；否则配置了 PresignExpiry
+//    时为预签名地址；否则为对象地址（需要存储桶允许公开读取）。
+// 4. 上传时按扩展名设置 Content-Type，浏览器打开分享链接时直接显示报告而不是下载。
+
+package publish
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3MaxPresign      = 7 * 24 * time.Hour
+)
+
+// S3Publisher 把报告上传到 S3 兼容对象存储的发布器
+type S3Publisher struct {
+	cfg      config.S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Publisher 校验配置并创建 S3 发布器
+func NewS3Publisher(cfg config.S3Config) (*S3Publisher, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.PresignExpiry < 0 || cfg.PresignExpiry > s3MaxPresign {
+		return nil, fmt.Errorf("s3 presign expiry must be between 0 and 7 days: %v", cfg.PresignExpiry)
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.Endpoint)
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &S3Publisher{cfg: cfg, endpoint: endpoint, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+// Publish 上传报告目录中的全部文件，返回报告 HTML 文件的分享链接
+func (p *S3Publisher) Publish(ctx context.Context, reportPath string) (string, error) {
+	files, err := reportBundle(reportPath)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err := p.upload(ctx, file); err != nil {
+			return "", err
+		}
+	}
+	return p.ShareURL(p.objectKey(files[0].rel), time.Now())
+}
+
+// ShareURL 返回对象的分享链接，规则见文件头说明
+func (p *S3Publisher) ShareURL(key string, now time.Time) (string, error) {
+	if p.cfg.PublicURL != "" {
+		return strings.TrimRight(p.cfg.PublicURL, "/") + "/" + s3EscapePath(key), nil
+	}
+	if p.cfg.PresignExpiry > 0 {
+		return p.PresignedURL(key, p.cfg.PresignExpiry, now)
+	}
+	return p.objectURL(key).String(), nil
+}
+
+// PresignedURL 生成对象的预签名 GET 地址，expiry 为有效期（最长 7 天）
+func (p *S3Publisher) PresignedURL(key string, expiry time.Duration, now time.Time) (string, error) {
+	if expiry <= 0 || expiry > s3MaxPresign {
+		return "", fmt.Errorf("s3 presign expiry must be between 1s and 7 days: %v", expiry)
+	}
+	target := p.objectURL(key)
+	now = now.UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", p.cfg.AccessKeyID+"/"+p.scope(now))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	headers := map[string]string{"host": target.Host}
+	signature := p.signature(http.MethodGet, target.EscapedPath(), query, headers, s3UnsignedPayload, now)
+	target.RawQuery = s3CanonicalQuery(query) + "&X-Amz-Signature=" + signature
+	return target.String(), nil
+}
+
+// objectKey 返回报告文件的对象键
+func (p *S3Publisher) objectKey(rel string) string {
+	if p.cfg.Prefix == "" {
+		return rel
+	}
+	return p.cfg.Prefix + "/" + rel
+}
+
+// objectURL 返回对象地址，虚拟主机形式或路径形式
+func (p *S3Publisher) objectURL(key string) *url.URL {
+	u := *p.endpoint
+	objectPath := "/" + key
+	if p.cfg.PathStyle {
+		objectPath = "/" + p.cfg.Bucket + objectPath
+	} else {
+		u.Host = p.cfg.Bucket + "." + u.Host
+	}
+	u.Path = path.Join(strings.TrimRight(u.Path, "/"), objectPath)
+	u.RawPath = s3EscapePath(u.Path)
+	return &u
+}
+
+// upload 上传一个文件，载荷的 SHA-256 参与签名
+func (p *S3Publisher) upload(ctx context.Context, file bundleFile) error {
+	body, err := os.ReadFile(file.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", file.path, err)
+	}
+	key := p.objectKey(file.rel)
+	target := p.objectURL(key)
+	contentType := mime.TypeByExtension(filepath.Ext(file.path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	now := time.Now().UTC()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create upload request: %v", err)
+	}
+	headers := map[string]string{
+		"content-type":         contentType,
+		"host":                 target.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format("20060102T150405Z"),
+	}
+	for name, value := range headers {
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	if p.cfg.AccessKeyID != "" {
+		signature := p.signature(http.MethodPut, target.EscapedPath(), url.Values{}, headers, payloadHash, now)
+		req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			s3Algorithm, p.cfg.AccessKeyID, p.scope(now), s3SignedHeaders(headers), signature))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %v", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to upload %s: unexpected status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// scope 签名的凭证范围：日期/区域/s3/aws4_request
+func (p *S3Publisher) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + p.cfg.Region + "/s3/aws4_request"
+}
+
+// signature 按 Signature Version 4 计算签名，headers 的键为小写的请求头名
+func (p *S3Publisher) signature(method, escapedPath string, query url.Values, headers map[string]string, payloadHash string, now time.Time) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		method,
+		escapedPath,
+		s3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		s3SignedHeaders(headers),
+		payloadHash,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, now.Format("20060102T150405Z"), p.scope(now), hex.EncodeToString(hash[:])}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+p.cfg.SecretAccessKey), now.Format("20060102"))
+	for _, part := range []string{p.cfg.Region, "s3", "aws4_request"} {
+		key = s3HMAC(key, part)
+	}
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+// s3HMAC 计算 HMAC-SHA256
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3SignedHeaders 参与签名的请求头名，按字母顺序以分号连接
+func s3SignedHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ";")
+}
+
+// s3CanonicalQuery 按参数名排序并按 RFC 3986 编码查询参数
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath 编码对象路径，保留路径分隔符
+func s3EscapePath(p string) string {
+	return s3Escape(p, false)
+}
+
+// s3Escape 按 Signature Version 4 的规则编码：只保留字母、数字和 -_.~，encodeSlash 为 false 时保留 /
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+// random.go
+// 随机数模块
+// 本文件负责全局随机种子的管理，使随机思考时间、数据打乱和负载生成在多次运行之间可以复现。
+//
+// 技术实现细节：
+// 1. 全局种子通过 SetSeed 设置（对应配置项 Seed 和命令行参数 -seed），未设置时在首次使用时随机生成，
+//    生成的种子同样写入报告，出现不稳定结果时可以用同一个种子重新运行。
+// 2. 各组件通过 New 按名称（例如 "vu-3"、"redis"）派生独立的随机数生成器，
+//    种子由全局种子和名称共同决定，与协程调度顺序无关，同一名称在每次运行中得到相同的随机序列。
+// 3. 派生的 *rand.Rand 不是并发安全的，多个协程共享时需要自行加锁。
+
+package random
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+)
+
+var (
+	mu     sync.Mutex
+	seed   int64
+	seeded bool
+)
+
+// SetSeed 设置全局随机种子，seed 为 0 时随机生成一个种子
+// 应在创建任务和启动 VU 之前调用，之后派生的随机数生成器才会使用该种子
+func SetSeed(s int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	if s == 0 {
+		s = generateSeed()
+	}
+	seed = s
+	seeded = true
+}
+
+// Seed 返回当前的全局随机种子，未设置时随机生成并固定下来
+func Seed() int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	if !seeded {
+		seed = generateSeed()
+		seeded = true
+	}
+	return seed
+}
+
+// New 按名称派生随机数生成器，相同的全局种子和名称得到相同的随机序列
+func New(name string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return rand.New(rand.NewSource(Seed() ^ int64(h.Sum64())))
+}
+
+// generateSeed 随机生成一个非零种子
+func generateSeed() int64 {
+	for {
+		var s int64
+		if err := binary.Read(crand.Reader, binary.LittleEndian, &s); err != nil {
+			return rand.Int63() + 1
+		}
+		if s != 0 {
+			return s
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// redact.go
+// 敏感信息隐藏模块
+// 本文件负责在写入日志、结果文件和报告之前隐藏 API Key、密码、令牌和 Authorization 请求头等敏感信息。
+//
+// 技术实现细节：
+// 1. 敏感字段通过名称识别：字段名（不区分大小写）包含任一敏感词即视为敏感字段，默认敏感词见 DefaultPatterns，
+//    可以通过 SetPatterns 替换（对应统一配置文件中的 redact.patterns，在默认敏感词之外追加）。
+// 2. String 在自由文本中查找 "字段名: 值"、"字段名=值" 形式的内容并把值替换为 Mask，覆盖请求头、URL 查询参数、
+//    JSON 和 %+v 格式化的结构体；值带引号时保留引号，Bearer、Basic 等认证方案名保留，便于排查认证方式是否正确；
+//    Cookie 请求头的值隐藏到行尾。
+// 3. 不包含 ':' 和 '=' 的文本直接返回，大量成功结果的 URL 不需要经过正则匹配。
+
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask 敏感值的替换文本
+const Mask = "***"
+
+// DefaultPatterns 默认的敏感词
+var DefaultPatterns = []string{"authorization", "cookie", "key", "token", "secret", "password", "passwd", "credential"}
+
+var (
+	mu       sync.RWMutex
+	patterns []string
+	valueRe  *regexp.Regexp
+)
+
+func init() {
+	SetPatterns(DefaultPatterns)
+}
+
+// SetPatterns 替换敏感词列表，空字符串被忽略
+func SetPatterns(words []string) {
+	var cleaned, quoted []string
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		cleaned = append(cleaned, word)
+		quoted = append(quoted, regexp.QuoteMeta(word))
+	}
+
+	var re *regexp.Regexp
+	if len(quoted) > 0 {
+		// 1: 字段名和分隔符 2: 双引号中的值 3: 单引号中的值 4: 认证方案名 5: 不带引号的值
+		re = regexp.MustCompile(`(?i)(["']?[\w.\-]*(?:` + strings.Join(quoted, "|") + `)[\w.\-]*["']?\s*[:=]\s*)` +
+			`(?:"([^"]*)"|'([^']*)'|((?:bearer|basic|digest|token|negotiate)\s+)?([^\s,;&"'}\])]+))`)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	patterns = cleaned
+	valueRe = re
+}
+
+// Patterns 返回当前的敏感词列表
+func Patterns() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string(nil), patterns...)
+}
+
+// IsSensitive 判断字段名是否包含敏感词
+func IsSensitive(name string) bool {
+	name = strings.ToLower(name)
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, word := range patterns {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 隐藏文本中敏感字段的值
+func String(s string) string {
+	if !strings.ContainsAny(s, ":=") {
+		return s
+	}
+	mu.RLock()
+	re := valueRe
+	mu.RUnlock()
+	if re == nil {
+		return s
+	}
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[0] < last {
+			continue
+		}
+		b.WriteString(s[last:m[3]])
+		switch {
+		case m[4] >= 0:
+			b.WriteString(`"` + Mask + `"`)
+		case m[6] >= 0:
+			b.WriteString("'" + Mask + "'")
+		default:
+			if m[8] >= 0 {
+				b.WriteString(s[m[8]:m[9]])
+			}
+			b.WriteString(Mask)
+		}
+		last = m[1]
+		// Cookie 请求头中的每个 Cookie 都可能是会话凭证，隐藏到行尾
+		if m[4] < 0 && m[6] < 0 && strings.Contains(strings.ToLower(s[m[2]:m[3]]), "cookie") {
+			if end := strings.IndexAny(s[last:], "\r\n"); end >= 0 {
+				last += end
+			} else {
+				last = len(s)
+			}
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,290 @@
+// aggregate.go
+// 结果聚合模块
+// 本文件负责一次遍历结果数据，同时计算总体指标和每秒的 TPS、平均响应时间、平均流量序列，
+// 结果数据量很大（千万级）时按分片并发聚合后合并。
+//
+// 技术实现细节：
+// 1. 结果按顺序切成连续的分片，分片数为 GOMAXPROCS 和 记录数/minShardSize 中较小者，记录较少时只用一个分片，
+//    不启动额外的协程。
+// 2. 每个分片独立累计总数、响应时间、流量和按秒分桶的计数与累加值，全部是可直接相加的量；
+//    合并时逐项相加，最大/最小值取极值，因此结果与单线程遍历完全一致。
+// 3. 平均值（每秒平均响应时间、平均流量）在合并之后统一计算，保持原有的计算口径：
+//    每秒请求数包含所有结果，平均值的分母只统计成功和失败结果。
+// 4. 首条记录的开始时间和末条记录的结束时间直接取切片两端，与分片无关。
+// 5. 每秒另外累计按毫秒取整的响应时间直方图（同样可以直接相加），用于计算每秒的 P95，
+//    与每秒请求数一起组成吞吐量-响应时间样本，用于寻找被测系统的拐点。
+
+package result
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// minShardSize 每个分片的最少记录数，记录较少时并发的开销大于收益
+const minShardSize = 10000
+
+// secondStats 一秒内的累计值
+type secondStats struct {
+	requests     int   // 请求总数（所有类型）
+	success      int   // 成功数
+	failure      int   // 失败数
+	responseTime int64 // 总响应时间（纳秒）
+	successTime  int64 // 成功请求的总响应时间（纳秒）
+	failureTime  int64 // 失败请求的总响应时间（纳秒）
+	sent         int64 // 发送字节数
+	received     int64 // 接收字节数
+	successSent  int64 // 成功请求的发送字节数
+
+	latencies latencyHistogram // 响应时间直方图（按毫秒取整）
+}
+
+// add 累加另一个时间桶
+func (s *secondStats) add(o *secondStats) {
+	s.requests += o.requests
+	s.success += o.success
+	s.failure += o.failure
+	s.responseTime += o.responseTime
+	s.successTime += o.successTime
+	s.failureTime += o.failureTime
+	s.sent += o.sent
+	s.received += o.received
+	s.successSent += o.successSent
+	if len(o.latencies) > 0 && s.latencies == nil {
+		s.latencies = latencyHistogram{}
+	}
+	for rt, n := range o.latencies {
+		s.latencies[rt] += n
+	}
+}
+
+// resultAggregate 一组结果的聚合值
+type resultAggregate struct {
+	totalRequests     int
+	successCount      int
+	failureCount      int // 所有非成功结果
+	timeoutCount      int // 超时结果，同时计入 failureCount
+	totalResponseTime time.Duration
+	maxResponseTime   time.Duration
+	minResponseTime   time.Duration
+	totalSentData     int64
+	totalReceivedData int64
+
+	startSec, endSec int64 // 最早和最晚的开始时间（秒）
+	seconds          map[int64]*secondStats
+}
+
+// newResultAggregate 创建空的聚合值
+func newResultAggregate() *resultAggregate {
+	return &resultAggregate{
+		minResponseTime: time.Hour * 24 * 365, // 初始为很大值
+		seconds:         make(map[int64]*secondStats),
+	}
+}
+
+// observe 累计一条结果
+func (a *resultAggregate) observe(result *ResultData) {
+	a.totalRequests++
+	if result.Type == Success {
+		a.successCount++
+	} else {
+		a.failureCount++
+	}
+	if result.Type == Timeout {
+		a.timeoutCount++
+	}
+	a.totalResponseTime += result.ResponseTime
+	if result.ResponseTime > a.maxResponseTime {
+		a.maxResponseTime = result.ResponseTime
+	}
+	if result.ResponseTime < a.minResponseTime {
+		a.minResponseTime = result.ResponseTime
+	}
+	a.totalSentData += result.DataSent
+	a.totalReceivedData += result.DataReceived
+
+	sec := result.StartTime.Unix()
+	if a.startSec == 0 || sec < a.startSec {
+		a.startSec = sec
+	}
+	if sec > a.endSec {
+		a.endSec = sec
+	}
+	s := a.seconds[sec]
+	if s == nil {
+		s = &secondStats{latencies: latencyHistogram{}}
+		a.seconds[sec] = s
+	}
+	s.requests++
+	s.latencies[result.ResponseTime.Round(time.Millisecond)]++
+	s.responseTime += int64(result.ResponseTime)
+	s.sent += result.DataSent
+	s.received += result.DataReceived
+	switch result.Type {
+	case Success:
+		s.success++
+		s.successTime += int64(result.ResponseTime)
+		s.successSent += result.DataSent
+	default:
+		s.failure++
+		s.failureTime += int64(result.ResponseTime)
+	}
+}
+
+// merge 合并另一个分片的聚合值
+func (a *resultAggregate) merge(o *resultAggregate) {
+	a.totalRequests += o.totalRequests
+	a.successCount += o.successCount
+	a.failureCount += o.failureCount
+	a.timeoutCount += o.timeoutCount
+	a.totalResponseTime += o.totalResponseTime
+	if o.maxResponseTime > a.maxResponseTime {
+		a.maxResponseTime = o.maxResponseTime
+	}
+	if o.minResponseTime < a.minResponseTime {
+		a.minResponseTime = o.minResponseTime
+	}
+	a.totalSentData += o.totalSentData
+	a.totalReceivedData += o.totalReceivedData
+
+	if o.totalRequests > 0 {
+		if a.startSec == 0 || o.startSec < a.startSec {
+			a.startSec = o.startSec
+		}
+		if o.endSec > a.endSec {
+			a.endSec = o.endSec
+		}
+	}
+	for sec, s := range o.seconds {
+		if existing := a.seconds[sec]; existing != nil {
+			existing.add(s)
+		} else {
+			a.seconds[sec] = s
+		}
+	}
+}
+
+// aggregateResults 一次遍历（分片并发）聚合所有结果
+func aggregateResults(results []ResultData) *resultAggregate {
+	shards := runtime.GOMAXPROCS(0)
+	if n := len(results) / minShardSize; n < shards {
+		shards = n
+	}
+	if shards <= 1 {
+		agg := newResultAggregate()
+		for i := range results {
+			agg.observe(&results[i])
+		}
+		return agg
+	}
+
+	parts := make([]*resultAggregate, shards)
+	size := (len(results) + shards - 1) / shards
+	var wg sync.WaitGroup
+	for i := range parts {
+		start, end := i*size, (i+1)*size
+		if end > len(results) {
+			end = len(results)
+		}
+		wg.Add(1)
+		go func(i int, shard []ResultData) {
+			defer wg.Done()
+			agg := newResultAggregate()
+			for j := range shard {
+				agg.observe(&shard[j])
+			}
+			parts[i] = agg
+		}(i, results[start:end])
+	}
+	wg.Wait()
+
+	agg := parts[0]
+	for _, part := range parts[1:] {
+		agg.merge(part)
+	}
+	return agg
+}
+
+// forEachSecond 按时间顺序遍历 startSec 到 endSec 的每一秒，没有结果的秒传入空值
+func (a *resultAggregate) forEachSecond(fn func(s *secondStats)) {
+	empty := &secondStats{}
+	for sec := a.startSec; sec <= a.endSec; sec++ {
+		if s := a.seconds[sec]; s != nil {
+			fn(s)
+		} else {
+			fn(empty)
+		}
+	}
+}
+
+// tpsSeries 返回每秒的请求数、成功数和失败数
+func (a *resultAggregate) tpsSeries() (tpsValues, successValues, failureValues []int) {
+	a.forEachSecond(func(s *secondStats) {
+		tpsValues = append(tpsValues, s.requests)
+		successValues = append(successValues, s.success)
+		failureValues = append(failureValues, s.failure)
+	})
+	return tpsValues, successValues, failureValues
+}
+
+// responseTimeSeries 返回每秒的平均响应时间、成功和失败请求的平均响应时间（微秒）
+func (a *resultAggregate) responseTimeSeries() (avgResponseTime, avgSuccessResponseTime, avgFailureResponseTime []float64) {
+	average := func(total int64, count int) float64 {
+		if count == 0 {
+			return 0
+		}
+		return float64(total) / float64(count) / 1000
+	}
+	a.forEachSecond(func(s *secondStats) {
+		avgResponseTime = append(avgResponseTime, average(s.responseTime, s.success+s.failure))
+		avgSuccessResponseTime = append(avgSuccessResponseTime, average(s.successTime, s.success))
+		avgFailureResponseTime = append(avgFailureResponseTime, average(s.failureTime, s.failure))
+	})
+	return avgResponseTime, avgSuccessResponseTime, avgFailureResponseTime
+}
+
+// trafficSeries 返回每秒的平均发送、接收流量和成功请求的平均发送流量（字节）
+func (a *resultAggregate) trafficSeries() (avgSent, avgReceived, avgSuccessSent []int) {
+	a.forEachSecond(func(s *secondStats) {
+		if count := int64(s.success + s.failure); count > 0 {
+			avgSent = append(avgSent, int(s.sent/count))
+			avgReceived = append(avgReceived, int(s.received/count))
+		} else {
+			avgSent = append(avgSent, 0)
+			avgReceived = append(avgReceived, 0)
+		}
+		if s.success > 0 {
+			avgSuccessSent = append(avgSuccessSent, int(s.successSent/int64(s.success)))
+		} else {
+			avgSuccessSent = append(avgSuccessSent, 0)
+		}
+	})
+	return avgSent, avgReceived, avgSuccessSent
+}
+
+// ThroughputSample 一秒内的吞吐量和响应时间，用于分析吞吐量与响应时间的关系
+type ThroughputSample struct {
+	Second          int64         // 秒级时间戳
+	TPS             int           // 该秒开始的请求数
+	AvgResponseTime time.Duration // 平均响应时间
+	P95ResponseTime time.Duration // P95 响应时间（按毫秒取整）
+}
+
+// throughputSamples 返回每个有请求的秒的吞吐量和响应时间，按时间排序
+func (a *resultAggregate) throughputSamples() []ThroughputSample {
+	var samples []ThroughputSample
+	for sec := a.startSec; sec <= a.endSec; sec++ {
+		s := a.seconds[sec]
+		if s == nil || s.requests == 0 {
+			continue
+		}
+		samples = append(samples, ThroughputSample{
+			Second:          sec,
+			TPS:             s.requests,
+			AvgResponseTime: time.Duration(s.responseTime / int64(s.requests)),
+			P95ResponseTime: s.latencies.percentiles()[percentileKey(95)],
+		})
+	}
+	return samples
+}
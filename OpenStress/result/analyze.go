@@ -0,0 +1,129 @@
+// analyze.go
+// 流式统计模块
+// 本文件负责直接从 JTL 文件流式计算统计结果，不构建完整的 []ResultData，
+// 结果文件远大于内存时（例如多日的稳定性测试）也能生成报告。
+//
+// 技术实现细节：
+// 1. 逐条读取所有分段（包括压缩、轮转的分段）的记录，解析后立即累计到各项统计的累计器中，然后丢弃。
+// 2. 各项统计与内存统计使用相同的累计逻辑：总数和每秒序列（resultAggregate）、内容断言、主要错误、
+//    失败请求明细（只保留最早的若干条）和阶段耗时；响应时间百分位使用按毫秒计数的直方图，与排序样本的结果一致。
+// 3. 事务样本和消息投递样本需要按名称或时间段计算百分位，仍保存在内存中；它们通常只占结果的一小部分。
+// 4. 最终由 assembleStats 组装，统计结果的结构与 GeneratePerformanceStats 完全相同，并同样保存为最近一次统计结果。
+
+package result
+
+import (
+	"fmt"
+	"time"
+)
+
+// statsAccumulator 逐条累计统计所需的中间数据
+type statsAccumulator struct {
+	measureStart   time.Time
+	count          int
+	first, last    time.Time // 第一条请求记录的开始时间、最后一条请求记录的结束时间
+	warmUpExcluded int
+	agg            *resultAggregate
+	latency        *latencyAccumulator
+	assertions     *assertionCounter
+	failed         failedSampleSet
+	errors         *errorCounter
+	phases         *phaseAccumulator
+	tls            *tlsCounter
+	transactions   []ResultData
+	deliveries     []ResultData
+}
+
+// newStatsAccumulator 按收集器的测量开始时间和响应时间统计口径创建累计器
+func (c *Collector) newStatsAccumulator() *statsAccumulator {
+	c.mu.RLock()
+	measureStart := c.measureStart
+	c.mu.RUnlock()
+	return &statsAccumulator{
+		measureStart: measureStart,
+		agg:          newResultAggregate(),
+		latency:      newLatencyAccumulator(c.latencyOptions),
+		assertions:   newAssertionCounter(),
+		errors:       newErrorCounter(),
+		phases:       newPhaseAccumulator(),
+		tls:          newTLSCounter(),
+	}
+}
+
+// add 累计一条结果：排除预热阶段的结果，事务样本和投递样本单独保存
+func (a *statsAccumulator) add(r *ResultData) {
+	switch {
+	case !a.measureStart.IsZero() && r.StartTime.Before(a.measureStart):
+		a.warmUpExcluded++
+	case r.Transaction != "":
+		a.transactions = append(a.transactions, *r)
+	case r.DataType == DeliveryDataType:
+		a.deliveries = append(a.deliveries, *r)
+	default:
+		if a.count == 0 {
+			a.first = r.StartTime
+		}
+		a.count++
+		a.last = r.EndTime
+		a.agg.observe(r)
+		a.latency.add(r)
+		a.assertions.add(r)
+		a.failed.add(r)
+		a.errors.add(r)
+		a.phases.add(r)
+		a.tls.add(r)
+	}
+}
+
+// input 返回组装统计结果所需的中间数据
+func (a *statsAccumulator) input() statsInput {
+	return statsInput{
+		agg:            a.agg,
+		firstTimestamp: a.first.UnixMilli(),
+		lastTimestamp:  a.last.UnixMilli(),
+		warmUpExcluded: a.warmUpExcluded,
+		latency:        a.latency.summary(),
+		assertions:     a.assertions.stats(),
+		failedSamples:  a.failed.result(),
+		topErrors:      a.errors.top(),
+		phases:         a.phases.breakdown(),
+		tls:            a.tls.result(),
+		transactions:   a.transactions,
+		deliveries:     a.deliveries,
+	}
+}
+
+// AnalyzeFile 从 JTL 文件流式计算统计结果并保存为最近一次统计结果，path 为空时等待此前提交的结果写入后使用收集器的 JTL 文件。
+// 读取过程中不保存全部结果，适用于远大于内存的结果文件；无法解析的记录跳过
+func (c *Collector) AnalyzeFile(path string) (map[string]interface{}, error) {
+	if path == "" {
+		c.Flush()
+		path = c.jtlFilePath
+	}
+	acc := c.newStatsAccumulator()
+	skipped := 0
+	err := streamJTLRecords(path, func(record []string) error {
+		result, err := parseJTLRecord(record)
+		if err != nil {
+			skipped++
+			return nil
+		}
+		acc.add(&result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if skipped > 0 {
+		c.logger.Log("WARN", fmt.Sprintf("skipped %d unparseable records in %s", skipped, path))
+	}
+	if acc.count == 0 {
+		return nil, fmt.Errorf("no results to analyze")
+	}
+
+	stats := c.assembleStats(acc.input())
+	c.mu.Lock()
+	c.lastStats = stats
+	c.mu.Unlock()
+	return stats, nil
+}
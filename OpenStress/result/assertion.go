@@ -0,0 +1,197 @@
+// assertion.go
+// 内容断言模块
+// 本文件负责响应内容断言的提取、记录与汇总，用于发现"状态码正常但内容异常"的静默功能退化。
+//
+// 使用方式：
+// 1. 任务中使用 ExtractJSONPath / AssertJSONNonEmpty 等方法检查响应体，
+//    并将结果写入 ResultData.Assertions（断言名 -> 是否通过）。
+// 2. 统计时按 URL + 断言名汇总通过率，写入 stats["ContentAssertionStats"]。
+// 3. 通过阈值规则对通过率进行判定，例如：
+//    "content_rate{url=/search,assertion=results_non_empty} >= 99%"
+//    url 为子串匹配，省略时匹配所有请求。
+
+package result
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentAssertionStat 按 URL 和断言名汇总的内容断言统计
+type ContentAssertionStat struct {
+	URL       string  // 请求URL
+	Assertion string  // 断言名
+	Passed    int     // 通过次数
+	Total     int     // 总次数
+	Rate      float64 // 通过率（百分比）
+}
+
+// ExtractJSONPath 按点分路径从 JSON 响应体中提取值，数组下标使用数字，例如 "data.items.0.id"
+// 路径为空时返回整个文档
+func ExtractJSONPath(body []byte, path string) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid json body: %v", err)
+	}
+	if path == "" {
+		return doc, nil
+	}
+
+	current := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("path %s: key %s not found", path, key)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("path %s: invalid index %s", path, key)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path %s: cannot descend into %s", path, key)
+		}
+	}
+	return current, nil
+}
+
+// AssertJSONNonEmpty 判断 JSON 路径上的值是否存在且非空（非空数组、对象、字符串，或任意非 null 标量）
+func AssertJSONNonEmpty(body []byte, path string) bool {
+	value, err := ExtractJSONPath(body, path)
+	if err != nil {
+		return false
+	}
+	switch v := value.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+// calculateContentAssertionStats 按 URL + 断言名汇总断言通过率，按 URL、断言名排序
+func calculateContentAssertionStats(results []ResultData) []ContentAssertionStat {
+	counter := newAssertionCounter()
+	for i := range results {
+		counter.add(&results[i])
+	}
+	return counter.stats()
+}
+
+// assertionKey 断言按 URL 和断言名区分
+type assertionKey struct{ url, assertion string }
+
+// assertionCounter 逐条累计内容断言的通过次数
+type assertionCounter struct {
+	counts map[assertionKey]*ContentAssertionStat
+}
+
+// newAssertionCounter 创建空的断言计数
+func newAssertionCounter() *assertionCounter {
+	return &assertionCounter{counts: make(map[assertionKey]*ContentAssertionStat)}
+}
+
+// add 累计一条结果的断言
+func (a *assertionCounter) add(result *ResultData) {
+	for name, passed := range result.Assertions {
+		k := assertionKey{result.URL, name}
+		stat, ok := a.counts[k]
+		if !ok {
+			stat = &ContentAssertionStat{URL: result.URL, Assertion: name}
+			a.counts[k] = stat
+		}
+		stat.Total++
+		if passed {
+			stat.Passed++
+		}
+	}
+}
+
+// stats 计算通过率，按 URL、断言名排序返回
+func (a *assertionCounter) stats() []ContentAssertionStat {
+	stats := make([]ContentAssertionStat, 0, len(a.counts))
+	for _, stat := range a.counts {
+		stat.Rate = float64(stat.Passed) / float64(stat.Total) * 100
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].URL != stats[j].URL {
+			return stats[i].URL < stats[j].URL
+		}
+		return stats[i].Assertion < stats[j].Assertion
+	})
+	return stats
+}
+
+// contentRate 计算 URL 包含 urlPattern 的请求中，断言 assertion 的整体通过率
+func contentRate(stats map[string]interface{}, urlPattern, assertion string) (float64, bool) {
+	assertionStats, _ := stats["ContentAssertionStats"].([]ContentAssertionStat)
+
+	var passed, total int
+	for _, stat := range assertionStats {
+		if stat.Assertion != assertion || !strings.Contains(stat.URL, urlPattern) {
+			continue
+		}
+		passed += stat.Passed
+		total += stat.Total
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(passed) / float64(total) * 100, true
+}
+
+// encodeAssertions 将断言结果编码为 JTL 字段，格式为 name=true;name=false（按名称排序）
+// 断言名按 URL 查询参数规则转义，名称中的 ; = , 等字符不会破坏字段格式
+func encodeAssertions(assertions map[string]bool) string {
+	if len(assertions) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(assertions))
+	for name := range assertions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, url.QueryEscape(name)+"="+strconv.FormatBool(assertions[name]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// decodeAssertions 解析 encodeAssertions 生成的 JTL 字段
+func decodeAssertions(field string) map[string]bool {
+	if field == "" {
+		return nil
+	}
+	assertions := make(map[string]bool)
+	for _, part := range strings.Split(field, ";") {
+		escaped, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		name, err := url.QueryUnescape(escaped)
+		if err != nil {
+			continue
+		}
+		if passed, err := strconv.ParseBool(value); err == nil {
+			assertions[name] = passed
+		}
+	}
+	return assertions
+}
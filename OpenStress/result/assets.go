@@ -0,0 +1,60 @@
+// assets.go
+// 报告静态资源模块
+// 本文件负责管理 HTML 报告依赖的前端资源（echarts 脚本等），资源通过 go:embed 编译进二进制，
+// 生成报告时写到报告的 static 目录中，报告在无法访问外网的环境中也能完整显示。
+//
+// 技术实现细节：
+// 1. assets 目录中的文件（echarts.min.js 为 Apache-2.0 许可的 echarts 4.1.0）全部嵌入 reportAssets。
+// 2. static 目录中的图表页面通过相对路径 assets/ 加载脚本，不再使用 go-echarts 默认的在线资源地址。
+// 3. 自包含报告直接内联 echartsJS，不需要写出资源文件。
+
+package result
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// reportAssets 内嵌的报告前端资源
+//
+//go:embed assets
+var reportAssets embed.FS
+
+// chartAssetsHost static 目录中图表页面加载脚本的相对路径
+const chartAssetsHost = "assets/"
+
+// echartsJS 内嵌的 echarts 脚本
+var echartsJS = mustReadAsset("assets/echarts.min.js")
+
+// mustReadAsset 读取内嵌资源，资源缺失属于构建错误
+func mustReadAsset(name string) string {
+	data, err := reportAssets.ReadFile(name)
+	if err != nil {
+		panic(fmt.Sprintf("missing embedded report asset %s: %v", name, err))
+	}
+	return string(data)
+}
+
+// writeReportAssets 将内嵌资源写到 staticDir/assets 目录
+func writeReportAssets(staticDir string) error {
+	return fs.WalkDir(reportAssets, "assets", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(staticDir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := reportAssets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return fmt.Errorf("failed to write report asset %s: %v", path, err)
+		}
+		return nil
+	})
+}
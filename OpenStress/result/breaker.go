@@ -0,0 +1,156 @@
+// breaker.go
+// 熔断状态记录模块
+// 本文件负责记录 HTTP 任务层熔断器（见 tasks.CircuitBreakerTransport）的状态变化，
+// 写入日志并在 HTML 报告中生成各接口的熔断状态图，便于对照 TPS 和错误率判断下游接口何时不可用。
+//
+// 技术实现细节：
+// 1. 熔断器通过 BreakerConfig.OnStateChange 把状态变化转交给 Collector.RecordBreakerTransition。
+// 2. 统计时将状态变化写入 stats["BreakerTransitions"]，没有状态变化时报告不展示熔断状态图。
+// 3. 状态图按秒展开（时间跨度较长时按 chartBucket 合并），每个时间段取该段内最严重的状态，
+//    短暂的断开也能在图上看到；Y 轴依次为闭合、半开、断开。
+
+package result
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// maxBreakerTransitions 最多保留的熔断状态变化数，超过后丢弃最早的记录
+const maxBreakerTransitions = 10000
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+// 熔断器状态
+const (
+	BreakerClosed   BreakerState = "closed"    // 闭合：请求正常发送
+	BreakerHalfOpen BreakerState = "half_open" // 半开：只放行少量探测请求
+	BreakerOpen     BreakerState = "open"      // 断开：请求直接失败，不发送到下游
+)
+
+// severity 状态的严重程度，用于状态图的 Y 轴和合并时间段
+func (s BreakerState) severity() int {
+	switch s {
+	case BreakerOpen:
+		return 2
+	case BreakerHalfOpen:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// BreakerTransition 一次熔断状态变化
+type BreakerTransition struct {
+	Time     time.Time
+	Endpoint string // 接口，例如 "https://api.example.com/orders"
+	From     BreakerState
+	To       BreakerState
+	Reason   string // 状态变化的原因，例如连续失败次数
+}
+
+// RecordBreakerTransition 记录一次熔断状态变化并写入日志，断开记为 WARN，其他变化记为 INFO
+func (c *Collector) RecordBreakerTransition(t BreakerTransition) {
+	level := "INFO"
+	if t.To == BreakerOpen {
+		level = "WARN"
+	}
+	c.logger.Log(level, fmt.Sprintf("circuit breaker for %s: %s -> %s (%s)", t.Endpoint, t.From, t.To, t.Reason))
+
+	c.breakerMu.Lock()
+	defer c.breakerMu.Unlock()
+	if len(c.breakerTransitions) >= maxBreakerTransitions {
+		c.breakerTransitions = c.breakerTransitions[1:]
+	}
+	c.breakerTransitions = append(c.breakerTransitions, t)
+}
+
+// BreakerTransitions 返回已记录熔断状态变化的副本
+func (c *Collector) BreakerTransitions() []BreakerTransition {
+	c.breakerMu.Lock()
+	defer c.breakerMu.Unlock()
+	return append([]BreakerTransition(nil), c.breakerTransitions...)
+}
+
+// GenerateBreakerChartAsync 生成熔断状态图 breaker_chart.html
+func GenerateBreakerChartAsync(transitions []BreakerTransition, dir string) (string, error) {
+	line, err := newBreakerChart(transitions, DefaultLanguage)
+	if err != nil {
+		return "", err
+	}
+	return writeChartHTML(line, filepath.Join(dir, "breaker_chart.html"))
+}
+
+// newBreakerChart 创建熔断状态图，每个接口一条阶梯线
+func newBreakerChart(transitions []BreakerTransition, lang Language) (*charts.Line, error) {
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("no circuit breaker transitions")
+	}
+	sorted := append([]BreakerTransition(nil), transitions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	first := sorted[0].Time.Truncate(time.Second)
+	last := sorted[len(sorted)-1].Time.Truncate(time.Second)
+	bucket := chartBucket(last.Sub(first))
+	points := int(last.Sub(first)/bucket) + 1
+
+	// 每个接口在各时间段内最严重的状态，没有状态变化的时间段沿用上一时间段结束时的状态
+	var endpoints []string
+	states := make(map[string][]int)
+	current := make(map[string]BreakerState)
+	next := 0
+	xAxis := make([]string, 0, points)
+	for i := 0; i < points; i++ {
+		start := first.Add(time.Duration(i) * bucket)
+		xAxis = append(xAxis, start.Format("15:04:05"))
+		worst := make(map[string]int, len(current))
+		for endpoint, state := range current {
+			worst[endpoint] = state.severity()
+		}
+		for ; next < len(sorted) && sorted[next].Time.Before(start.Add(bucket)); next++ {
+			t := sorted[next]
+			if _, ok := states[t.Endpoint]; !ok {
+				endpoints = append(endpoints, t.Endpoint)
+				states[t.Endpoint] = make([]int, i, points) // 第一次状态变化之前为闭合
+				worst[t.Endpoint] = t.From.severity()
+			}
+			current[t.Endpoint] = t.To
+			worst[t.Endpoint] = max(worst[t.Endpoint], t.To.severity())
+		}
+		for _, endpoint := range endpoints {
+			states[endpoint] = append(states[endpoint], worst[endpoint])
+		}
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("breaker_chart_title"),
+			Subtitle: lang.text("chart_duration", xAxis[0], xAxis[len(xAxis)-1]),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Trigger: "axis"}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Type: "category",
+			Data: []string{lang.text("breaker_closed"), lang.text("breaker_half_open"), lang.text("breaker_open")},
+		}),
+	)
+	line.SetXAxis(xAxis)
+	step := charts.WithLineChartOpts(opts.LineChart{Step: "end"})
+	for _, endpoint := range endpoints {
+		data := make([]opts.LineData, 0, points)
+		for _, severity := range states[endpoint] {
+			data = append(data, opts.LineData{Value: severity})
+		}
+		line.AddSeries(endpoint, data, step)
+	}
+	return line, nil
+}
@@ -0,0 +1,177 @@
+// chartImage.go
+// 静态图表图片生成模块
+// 本文件负责在 echarts HTML 图表之外，额外生成 PNG / SVG 格式的静态图表，
+// 便于嵌入 Wiki、PDF、邮件等无法执行 JavaScript 的场景。
+//
+// 技术实现细节：
+// 1. 使用 go-chart 在进程内渲染，不依赖无头浏览器。
+// 2. 直接使用每秒原始数据绘制时间序列，不做横坐标截取；只有一个点的序列画成覆盖该秒的水平线。
+// 3. 图表标题固定使用英文（与 en-US 报告的 echarts 图表一致），避免字体缺失中文字形。
+// 4. 每张图独立渲染，某张图没有数据或渲染失败时其余图照常生成，错误汇总返回。
+
+package result
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	chart "github.com/wcharczuk/go-chart/v2"
+)
+
+// 支持的静态图表格式
+const (
+	ChartImagePNG = "png"
+	ChartImageSVG = "svg"
+)
+
+// chartSeries 静态图表的一条数据序列
+type chartSeries struct {
+	Name   string
+	Values []int
+}
+
+// GenerateChartImages 根据统计结果生成 TPS、响应时间、网络流量三张静态图表
+// formats 为空时同时生成 PNG 和 SVG，返回生成的文件路径
+func GenerateChartImages(stats map[string]interface{}, dir string, formats ...string) ([]string, error) {
+	if len(formats) == 0 {
+		formats = []string{ChartImagePNG, ChartImageSVG}
+	}
+
+	tpsStart, _ := stats["AvgTpsStartTime"].(int64)
+	responseStart, _ := stats["AvgResponseStartTime"].(int64)
+	trafficStart, _ := stats["AvgTrafficStartTime"].(int64)
+
+	charts := []struct {
+		name   string
+		title  string
+		start  int64
+		series []chartSeries
+	}{
+		{
+			name:  "tps_chart",
+			title: "Transactions Per Second",
+			start: tpsStart,
+			series: []chartSeries{
+				{Name: "Total TPS", Values: intSliceStat(stats, "TPSValues")},
+				{Name: "Success TPS", Values: intSliceStat(stats, "SuccessValues")},
+				{Name: "Failure TPS", Values: intSliceStat(stats, "FailureValues")},
+			},
+		},
+		{
+			name:  "response_time_chart",
+			title: "Response Time Over Time(ms)",
+			start: responseStart,
+			series: []chartSeries{
+				{Name: "Average Response Time", Values: intSliceStat(stats, "AvgResponseTimeValues")},
+				{Name: "Average Success Response Time", Values: intSliceStat(stats, "AvgSuccessResponseTimeValues")},
+				{Name: "Average Failure Response Time", Values: intSliceStat(stats, "AvgFailureResponseTimeValues")},
+			},
+		},
+		{
+			name:  "flow_trend_chart",
+			title: "Flow Trend Over Time (byte)",
+			start: trafficStart,
+			series: []chartSeries{
+				{Name: "Sent Traffic", Values: intSliceStat(stats, "AvgSentTrafficValues")},
+				{Name: "Received Traffic", Values: intSliceStat(stats, "AvgReceivedTrafficValues")},
+			},
+		},
+	}
+
+	// 每张图独立渲染，某张图失败不影响其他图
+	var paths, failures []string
+	for _, c := range charts {
+		for _, format := range formats {
+			path := filepath.Join(dir, c.name+"."+format)
+			if err := renderLineChartImage(c.title, c.start, c.series, format, path); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+				continue
+			}
+			paths = append(paths, path)
+		}
+	}
+	if len(failures) > 0 {
+		return paths, fmt.Errorf("failed to render %d chart images: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return paths, nil
+}
+
+// intSliceStat 从统计结果中读取 []int 序列，不存在时返回 nil
+func intSliceStat(stats map[string]interface{}, key string) []int {
+	values, _ := stats[key].([]int)
+	return values
+}
+
+// renderLineChartImage 渲染折线图并保存为 PNG 或 SVG
+func renderLineChartImage(title string, startTime int64, series []chartSeries, format string, path string) error {
+	var provider chart.RendererProvider
+	switch format {
+	case ChartImagePNG:
+		provider = chart.PNG
+	case ChartImageSVG:
+		provider = chart.SVG
+	default:
+		return fmt.Errorf("unsupported chart image format: %s", format)
+	}
+
+	graph := chart.Chart{
+		Title:  title,
+		Width:  1200,
+		Height: 500,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 60, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat("15:04:05"),
+		},
+	}
+
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		if len(s.Values) == 0 {
+			continue
+		}
+		values := s.Values
+		if len(values) == 1 {
+			// 运行不足两秒时只有一个点，go-chart 至少需要两个点才能确定坐标范围，画成覆盖这一秒的水平线
+			values = []int{values[0], values[0]}
+		}
+		xValues := make([]time.Time, len(values))
+		yValues := make([]float64, len(values))
+		for i, v := range values {
+			xValues[i] = time.Unix(startTime+int64(i), 0)
+			yValues[i] = float64(v)
+			minY, maxY = math.Min(minY, yValues[i]), math.Max(maxY, yValues[i])
+		}
+		graph.Series = append(graph.Series, chart.TimeSeries{
+			Name:    s.Name,
+			XValues: xValues,
+			YValues: yValues,
+		})
+	}
+	if len(graph.Series) == 0 {
+		return fmt.Errorf("no data points to render %q", title)
+	}
+	if minY == maxY {
+		// 所有点数值相同时纵轴范围为零，go-chart 无法渲染，手动给出范围
+		graph.YAxis.Range = &chart.ContinuousRange{Min: math.Min(minY, 0), Max: maxY + 1}
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create image file: %v", err)
+	}
+	defer file.Close()
+
+	if err := graph.Render(provider, file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to render chart image: %v", err)
+	}
+	return nil
+}
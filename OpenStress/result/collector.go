@@ -2,12 +2,21 @@ package result
 
 import (
 	// "encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"html/template"
+	"math/rand"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/redact"
 )
 
 // ResultType 定义结果类型
@@ -18,42 +27,176 @@ const (
 	Success ResultType = iota
 	// Failure 失败结果
 	Failure
+	// Timeout 超时结果，按失败统计，另外单独计数
+	Timeout
 )
 
+// FailureType 返回出错请求的结果类型：超时错误（context.DeadlineExceeded 或网络超时）为 Timeout，其他错误为 Failure
+func FailureType(err error) ResultType {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Timeout
+	}
+	return Failure
+}
+
 // ResultData 测试结果数据结构
 type ResultData struct {
-	ID           string        // 唯一标识符
-	Type         ResultType    // 结果类型（成功/失败）
-	ResponseTime time.Duration // 响应时间
-	StartTime    time.Time     // 开始时间
-	EndTime      time.Time     // 结束时间
-	ErrorMessage string        // 错误信息（如果失败）
-	StatusCode   int           // HTTP状态码
-	ThreadID     int           // 线程ID
-	URL          string        // 请求URL
-	Method       string        // 请求方法
-	DataSent     int64         // 发送的数据大小
-	DataReceived int64         // 接收的数据大小
-	DataType     string        // 数据类型
-	ResponseMsg  string        // 响应信息
-	GrpThreads   int           // 线程组中的线程数
-	AllThreads   int           // 所有线程数
-	Connect      int64         // 连接花费时间
+	ID           string          // 唯一标识符（样本ID），为空时由收集器生成（见 sampleid.go）
+	Type         ResultType      // 结果类型（成功/失败/超时）
+	ResponseTime time.Duration   // 响应时间
+	StartTime    time.Time       // 开始时间
+	EndTime      time.Time       // 结束时间
+	ErrorMessage string          // 错误信息（如果失败）
+	StatusCode   int             // HTTP状态码
+	ThreadID     int             // 线程ID
+	URL          string          // 请求URL
+	Method       string          // 请求方法
+	DataSent     int64           // 发送的数据大小
+	DataReceived int64           // 接收的数据大小
+	DataType     string          // 数据类型
+	ResponseMsg  string          // 响应信息
+	GrpThreads   int             // 线程组中的线程数
+	AllThreads   int             // 所有线程数
+	Connect      int64           // 连接花费时间（毫秒，DNS + TCP + TLS）
+	RetryCount   int             // 重试次数（0 表示首次尝试即完成）
+	ThrottleWait time.Duration   // 客户端限流等待时间（已包含在响应时间内）
+	Assertions   map[string]bool // 内容断言结果（断言名 -> 是否通过）
+	TraceID      string          // 追踪ID（pool.TaskContext.TraceID），用于关联日志与报告明细
+	VUID         int             // 虚拟用户编号（TraceID 为空时无意义）
+	Iteration    int             // 迭代次数（TraceID 为空时无意义）
+	DNSLookup    time.Duration   // DNS 解析耗时（复用连接时为 0）
+	TCPConnect   time.Duration   // TCP 连接耗时（复用连接时为 0）
+	TLSHandshake time.Duration   // TLS 握手耗时（复用连接或非 HTTPS 时为 0）
+	TLSVersion   string          // TLS 版本，例如 TLS 1.3，非 HTTPS 请求为空
+	TLSCipher    string          // TLS 加密套件名称，非 HTTPS 请求为空
+	TTFB         time.Duration   // 首字节时间：从发送请求到收到响应首字节，包含建立连接，0 表示未采集
+	Transaction  string          // 事务名称，非空表示这是一条事务样本（见 Transaction）
+	Rows         int64           // 数据库查询返回或影响的行数
+	ResponseBody string          // 失败请求的响应体片段，按 CollectorConfig.FailureBodyBytes 截断和抽样，成功结果不保存
+	Label        string          // 样本标签，通过 Collector.WithLabel 创建的子收集器自动填写（见 label.go）
+}
+
+// ExecutionContext 任务执行上下文（由 pool.TaskContext 实现），提供需要写入结果的追踪信息、重试次数和限流等待时间
+type ExecutionContext interface {
+	ResultTrace() (traceID string, vuID, iteration int)
+	ResultRetry() (retryCount int, throttleWait time.Duration)
+}
+
+// ApplyExecution 将执行上下文写入结果：追踪ID、VU 编号、迭代次数、重试次数和限流等待时间。
+// 限流等待发生在任务开始之前，这里把它计入响应时间（开始时间相应提前），
+// 全量口径反映包含客户端排队的耗时，调整口径再将其扣除
+func (r *ResultData) ApplyExecution(ec ExecutionContext) {
+	r.TraceID, r.VUID, r.Iteration = ec.ResultTrace()
+	retryCount, throttleWait := ec.ResultRetry()
+	r.RetryCount = retryCount
+	if throttleWait > 0 {
+		r.ThrottleWait = throttleWait
+		r.ResponseTime += throttleWait
+		r.StartTime = r.StartTime.Add(-throttleWait)
+	}
 }
 
 // Collector 结果收集器结构体
 type Collector struct {
 	mu            sync.RWMutex
-	results       []ResultData
+	results       resultStore                   // 按保留策略保存在内存中的结果（见 retention.go）
+	counters      CollectorCounters             // 结果计数器，与 results 共用 mu
+	live          [liveWindowSeconds]liveBucket // 最近各秒的实时统计，与 results 共用 mu
 	batchSize     int
 	outputFormat  string
 	jtlFilePath   string
-	dataChan      chan ResultData
-	done          chan struct{}
+	dataChan      chan queuedResult
+	done          chan struct{} // 关闭后停止定时收集任务
+	workersDone   chan struct{} // 处理协程处理完 dataChan 中的全部数据后关闭
 	logger        Logger
 	numGoroutines int // 并发 goroutine 数量
 	// 新增配置项：数据收集间隔（秒）
 	collectInterval int
+	latencyOptions  LatencyOptions // 响应时间百分位统计口径
+	// 最近一次生成的统计结果，供 ExportStats 导出
+	lastStats  map[string]interface{}
+	thresholds []Threshold // SLA/阈值规则
+	taskID     string      // 任务ID
+
+	// 阶段报告相关
+	stopReports     chan struct{}
+	stopReportsOnce sync.Once
+	reportSeq       atomic.Int64
+	reportDir       string             // HTML 报告根目录
+	selfContained   bool               // 是否生成单文件自包含报告
+	reportPattern   string             // 报告目录和 HTML 文件的命名规则
+	reportTemplate  *template.Template // 自定义报告模板，为 nil 时使用默认模板
+	language        Language           // 报告语言
+	chartOptions    chartOptions       // 趋势图降采样参数
+	metadata        RunMetadata        // 运行元数据，创建收集器时采集
+
+	// 测量开始时间，之前的结果属于预热阶段，不计入统计
+	measureStart time.Time
+
+	// 压测结束原因（时长、迭代次数、错误数、外部停止等），为空时报告中不展示
+	stopReason string
+
+	// 提前中止（超出错误预算）的说明和时间，abortTime 为零值表示没有中止
+	abortDetail string
+	abortTime   time.Time
+
+	// 流量配比的目标占比和实际占比，为空时报告中不展示
+	trafficMix []TrafficShare
+
+	// JTL 压缩与轮转，为 nil 时每次追加写入 jtlFilePath
+	segments *jtlSegments
+
+	// 失败响应体采样
+	failureBodyBytes      int
+	failureBodySampleRate float64
+	failureBodiesPerError int
+	failureBodyCounts     map[errorKey]int
+	failureBodyRng        *rand.Rand
+
+	// 运行目录锁，防止同一任务的并发运行交错写入结果
+	runLock *fileLock
+
+	// 结果写入 Elasticsearch，为 nil 时不启用
+	elasticsearch *esIndexer
+
+	// 成功判定策略（状态码和内容断言）
+	successPolicy *successPolicy
+
+	// 结果脱敏（见 scrub.go），未启用时为 nil
+	scrubber *scrubber
+
+	// 历史运行趋势库（见 trend.go），未配置时为 nil
+	trends *TrendStore
+
+	// 样本ID 序号，为没有 ID 的结果生成唯一 ID（见 sampleid.go）
+	sampleSeq atomic.Int64
+
+	// 压测机资源采样和负载（活跃 VU、队列深度）采样
+	resourceMu      sync.Mutex
+	resourceSamples []ResourceSample
+	loadSamples     []LoadSample
+	memoryLeaks     []MemoryLeak
+
+	// HTTP 熔断器状态变化
+	breakerMu          sync.Mutex
+	breakerTransitions []BreakerTransition
+
+	// 任务代码上报的自定义指标
+	metricsMu sync.Mutex
+	metrics   map[string]*customMetric
+
+	// 按批次记录已提交但尚未处理完的结果数，Flush 开始新批次并等待之前的批次全部处理完
+	pendingMu   sync.Mutex
+	pendingCond *sync.Cond
+	pendingGen  uint64
+	pending     map[uint64]int
+
+	// 关闭相关：sendMu 保证关闭 dataChan 时没有正在进行的发送
+	sendMu    sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // CollectorConfig 收集器配置
@@ -65,13 +208,129 @@ type CollectorConfig struct {
 	NumGoroutines   int    // 并发 goroutine 数量
 	CollectInterval int    // 数据收集间隔（秒）
 	TaskID          string // 任务ID，用于生成唯一的文件名
+	// LatencyOptions 响应时间百分位的统计口径（是否排除重试样本和限流等待）
+	LatencyOptions LatencyOptions
+	// Thresholds 阈值规则，例如 "p95 < 800ms"、"error_rate < 1%"、"tps > 300"
+	Thresholds []string
+	// IntermediateReportInterval 阶段报告生成间隔，0 表示不生成（用于长时间稳定性测试）
+	IntermediateReportInterval time.Duration
+	// ReportDir HTML 报告根目录，为空时使用 DefaultReportDir
+	ReportDir string
+	// SelfContainedReport 生成单个自包含的 HTML 报告文件（内联样式、脚本和图表数据），便于作为附件发送
+	SelfContainedReport bool
+	// Language 报告语言（zh-CN 或 en-US），为空时使用 DefaultLanguage
+	Language string
+	// ResumeJTLFilePath 断点续跑时继续写入的 JTL 文件（通常来自检查点），设置后不再生成新的文件名，
+	// 计数器从该文件中已有的记录开始累计
+	ResumeJTLFilePath string
+	// FailureBodyBytes 失败请求保存的响应体最大字节数，0 表示不保存响应体
+	FailureBodyBytes int
+	// FailureBodySampleRate 保存响应体的失败请求比例（0~1），0 表示全部保存
+	FailureBodySampleRate float64
+	// FailureBodiesPerError 每种错误（状态码 + 错误信息）最多保存的响应体数量，默认 DefaultFailureBodiesPerError
+	FailureBodiesPerError int
+	// JTLCompress 以 gzip 压缩写入 JTL 文件（文件名追加 .gz），适合长时间稳定性测试
+	JTLCompress bool
+	// JTLRotateSize 单个 JTL 分段的最大字节数（压缩时为压缩后的大小），超过后写入下一个分段，0 表示不按大小轮转
+	JTLRotateSize int64
+	// JTLRotateInterval 单个 JTL 分段的最长写入时间，超过后写入下一个分段，0 表示不按时间轮转
+	JTLRotateInterval time.Duration
+	// ChartBuckets 趋势图的最大点数，测试时长超过该秒数时按桶聚合，默认 DefaultChartBuckets
+	ChartBuckets int
+	// ChartAggregation 趋势图桶内的聚合方式（max、avg、p95），默认 max 以保留短暂的毛刺
+	ChartAggregation string
+	// TestPlan 测试计划名称，写入运行元数据（见 metadata.go），为空时使用 TaskID
+	TestPlan string
+	// ReportNamePattern 报告目录和 HTML 文件的命名规则，支持 {name}、{time}、{task} 占位符，
+	// 为空时使用 DefaultReportNamePattern（见 template.go）
+	ReportNamePattern string
+	// ReportTemplate 自定义 HTML 报告模板文件（html/template 语法），为空时使用 DefaultReportTemplate
+	ReportTemplate string
+	// Elasticsearch 每条结果作为一个文档写入 Elasticsearch（见 elasticsearch.go），URL 为空时不启用
+	Elasticsearch config.ElasticsearchConfig
+	// SuccessPolicy CollectDataWithParams 判定请求成功的策略（见 success.go），零值表示 2xx 和 3xx 为成功
+	SuccessPolicy SuccessPolicy
+	// ResultRetention 内存中结果的保留策略（all、last、aggregates、disk，见 retention.go），为空时保留全部结果
+	ResultRetention string
+	// ResultRetentionLimit last 策略保留的最近结果数
+	ResultRetentionLimit int
+	// Bandwidth 任务 HTTP 客户端模拟的带宽，写入运行元数据；零值表示未模拟
+	Bandwidth SimulatedBandwidth
+	// Scrub 结果脱敏（去掉查询参数、替换用户标识、丢弃响应消息，见 scrub.go），零值表示不脱敏
+	Scrub config.ScrubConfig
+	// History 历史运行趋势与回归检测（见 trend.go），Dir 为空时不记录
+	History config.HistoryConfig
 }
 
+// DefaultReportDir 默认的 HTML 报告根目录
+const DefaultReportDir = "path/to/htmlReport"
+
 // NewCollector 创建新的结果收集器
 func NewCollector(config CollectorConfig) (*Collector, error) {
 	if config.BatchSize <= 0 {
 		config.BatchSize = 100 // 默认批量大小
 	}
+	if config.NumGoroutines <= 0 {
+		config.NumGoroutines = 1 // 至少一个处理协程，否则 CollectResult 提交的结果不会被保存
+	}
+	if config.ReportDir == "" {
+		config.ReportDir = DefaultReportDir
+	}
+
+	thresholds, err := ParseThresholds(config.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+	language, err := ParseLanguage(config.Language)
+	if err != nil {
+		return nil, err
+	}
+	chartAggregation, err := ParseChartAggregation(config.ChartAggregation)
+	if err != nil {
+		return nil, err
+	}
+	if config.ChartBuckets <= 0 {
+		config.ChartBuckets = DefaultChartBuckets
+	}
+	if config.ReportNamePattern == "" {
+		config.ReportNamePattern = DefaultReportNamePattern
+	}
+	if err := validateReportNamePattern(config.ReportNamePattern); err != nil {
+		return nil, err
+	}
+	var reportTemplate *template.Template
+	if config.ReportTemplate != "" {
+		if reportTemplate, err = LoadReportTemplate(config.ReportTemplate); err != nil {
+			return nil, err
+		}
+	}
+	successPolicy, err := parseSuccessPolicy(config.SuccessPolicy)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := ParseResultRetention(config.ResultRetention, config.ResultRetentionLimit)
+	if err != nil {
+		return nil, err
+	}
+	scrubber, err := newScrubber(config.Scrub)
+	if err != nil {
+		return nil, err
+	}
+	if config.FailureBodySampleRate < 0 || config.FailureBodySampleRate > 1 {
+		return nil, fmt.Errorf("failure body sample rate must be between 0 and 1")
+	}
+	if config.JTLRotateSize < 0 || config.JTLRotateInterval < 0 {
+		return nil, fmt.Errorf("JTL rotation size and interval must not be negative")
+	}
+	segmented := config.JTLCompress || config.JTLRotateSize > 0 || config.JTLRotateInterval > 0
+	if segmented && config.ResumeJTLFilePath != "" {
+		return nil, fmt.Errorf("resuming is not supported with compressed or rotated JTL files")
+	}
+
+	// 续跑时沿用上次的 JTL 文件
+	if config.ResumeJTLFilePath != "" {
+		config.JTLFilePath = config.ResumeJTLFilePath
+	}
 
 	// 确保JTL文件目录存在
 	dir := filepath.Dir(config.JTLFilePath)
@@ -80,19 +339,78 @@ func NewCollector(config CollectorConfig) (*Collector, error) {
 	}
 
 	// 使用 TaskID 生成唯一的 JTL 文件名
-	jtlFileName := fmt.Sprintf("test_result_%s_%s.jtl", config.TaskID, time.Now().Format("20060102150405"))
-	config.JTLFilePath = filepath.Join(dir, jtlFileName)
+	if config.ResumeJTLFilePath == "" {
+		jtlFileName := fmt.Sprintf("test_result_%s_%s.jtl", config.TaskID, time.Now().Format("20060102150405"))
+		config.JTLFilePath = filepath.Join(dir, jtlFileName)
+	}
+
+	// 锁定运行目录，同一任务同一时间只允许一个运行写入
+	runLock, err := acquireLock(filepath.Join(dir, fmt.Sprintf(".%s.lock", config.TaskID)), config.JTLFilePath, config.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var counters CollectorCounters
+	if config.ResumeJTLFilePath != "" {
+		if counters, err = prepareResumeJTL(config.JTLFilePath); err != nil {
+			runLock.Release()
+			return nil, err
+		}
+	}
 
 	c := &Collector{
-		results:         make([]ResultData, 0),
+		results:         resultStore{mode: retention, limit: config.ResultRetentionLimit},
+		counters:        counters,
 		batchSize:       config.BatchSize,
 		outputFormat:    config.OutputFormat,
 		jtlFilePath:     config.JTLFilePath,
-		dataChan:        make(chan ResultData, 1000),
+		dataChan:        make(chan queuedResult, 1000),
 		done:            make(chan struct{}),
+		workersDone:     make(chan struct{}),
 		logger:          config.Logger,
 		numGoroutines:   config.NumGoroutines,
 		collectInterval: config.CollectInterval,
+		latencyOptions:  config.LatencyOptions,
+		thresholds:      thresholds,
+		taskID:          config.TaskID,
+		stopReports:     make(chan struct{}),
+		reportDir:       config.ReportDir,
+		selfContained:   config.SelfContainedReport,
+		reportPattern:   config.ReportNamePattern,
+		reportTemplate:  reportTemplate,
+		language:        language,
+		chartOptions:    chartOptions{buckets: config.ChartBuckets, aggregation: chartAggregation},
+		runLock:         runLock,
+		successPolicy:   successPolicy,
+		scrubber:        scrubber,
+		trends:          NewTrendStore(config.History),
+	}
+	if config.TestPlan == "" {
+		config.TestPlan = config.TaskID
+	}
+	c.pendingCond = sync.NewCond(&c.pendingMu)
+	c.pending = make(map[uint64]int)
+	c.metadata = CaptureRunMetadata(config.TestPlan)
+	if config.Bandwidth != (SimulatedBandwidth{}) {
+		bandwidth := config.Bandwidth
+		c.metadata.Bandwidth = &bandwidth
+	}
+	c.sampleSeq.Store(counters.Written)
+	if config.Elasticsearch.URL != "" {
+		if c.elasticsearch, err = newESIndexer(config.Elasticsearch, config.TaskID, c.metadata, c.logger); err != nil {
+			runLock.Release()
+			return nil, err
+		}
+	}
+
+	c.initFailureBodyCapture(config)
+	if segmented {
+		c.segments = &jtlSegments{
+			base:     config.JTLFilePath,
+			compress: config.JTLCompress,
+			maxSize:  config.JTLRotateSize,
+			interval: config.JTLRotateInterval,
+		}
 	}
 
 	// 启动异步处理goroutine
@@ -102,37 +420,110 @@ func NewCollector(config CollectorConfig) (*Collector, error) {
 	if c.collectInterval > 0 {
 		ticker := time.NewTicker(time.Duration(c.collectInterval) * time.Second)
 		go func() {
-			for range ticker.C {
-				// 定期收集数据
-				c.CollectData()
+			defer ticker.Stop()
+			for {
+				select {
+				case <-c.done:
+					return
+				case <-ticker.C:
+					// 定期收集数据
+					c.CollectData()
+				}
 			}
 		}()
 	}
 
+	// 启动阶段报告定时任务
+	if config.IntermediateReportInterval > 0 {
+		c.startIntermediateReports(config.IntermediateReportInterval)
+	}
+
 	return c, nil
 }
 
 // InitializeCollector 初始化结果收集器，准备接收数据。
+// NewCollector 已经启动了处理协程，这里只记录日志，保留用于兼容
 func (c *Collector) InitializeCollector() {
-	c.dataChan = make(chan ResultData, c.batchSize)
-	c.done = make(chan struct{})
+	c.logger.Log("INFO", "Collector initialized and ready to receive data.")
+}
 
-	// 启动异步处理数据的 goroutines
-	go c.processData()
+// ErrCollectorClosed 收集器已关闭，提交的结果没有保存
+var ErrCollectorClosed = errors.New("collector is closed")
 
-	c.logger.Log("INFO", "Collector initialized and ready to receive data.")
+// queuedResult 数据通道中等待处理的结果，failure 表示按失败结果保存
+type queuedResult struct {
+	data    ResultData
+	failure bool
+	gen     uint64 // 提交时的批次，见 Flush
 }
 
-// CollectResult 收集测试结果
+// CollectResult 收集测试结果，按结果类型保存，通道已满或收集器关闭后提交的结果会被丢弃
 func (c *Collector) CollectResult(data ResultData) {
+	c.enqueue(queuedResult{data: data, failure: data.Type != Success}, false)
+}
+
+// enqueue 将结果放入数据通道，wait 为 true 时在通道已满时等待处理协程腾出空间，否则丢弃结果；
+// 收集器已关闭时丢弃结果并返回 ErrCollectorClosed
+func (c *Collector) enqueue(item queuedResult, wait bool) error {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
+	if c.closed {
+		c.logger.Log("ERROR", "collector is closed, result dropped")
+		return ErrCollectorClosed
+	}
+	c.pendingMu.Lock()
+	item.gen = c.pendingGen
+	c.pending[item.gen]++
+	c.pendingMu.Unlock()
+	if wait {
+		c.dataChan <- item
+		return nil
+	}
 	select {
-	case c.dataChan <- data:
+	case c.dataChan <- item:
 	default:
+		c.finishPending(item.gen)
 		c.logger.Log("ERROR", "data channel is full, result dropped")
 	}
+	return nil
+}
+
+// finishPending gen 批次的一个结果处理完毕
+func (c *Collector) finishPending(gen uint64) {
+	c.pendingMu.Lock()
+	c.pending[gen]--
+	if c.pending[gen] == 0 {
+		delete(c.pending, gen)
+		c.pendingCond.Broadcast()
+	}
+	c.pendingMu.Unlock()
+}
+
+// Flush 等待调用之前提交的结果全部写入结果集和输出文件，用于在读取 Results、计数器或统计之前确保结果完整。
+// 之后提交的结果属于新批次，不需要等待，持续压测时也不会一直阻塞
+func (c *Collector) Flush() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	target := c.pendingGen
+	c.pendingGen++
+	for {
+		waiting := false
+		for gen := range c.pending {
+			if gen <= target {
+				waiting = true
+				break
+			}
+		}
+		if !waiting {
+			return
+		}
+		c.pendingCond.Wait()
+	}
 }
 
-// CollectDataWithParams 定期收集数据
+// CollectDataWithParams 定期收集数据，结果类型按成功判定策略（CollectorConfig.SuccessPolicy）由状态码决定，
+// id 为空时由收集器生成唯一的样本ID
 func (c *Collector) CollectDataWithParams(id string, startTime time.Time, endTime time.Time, statusCode int, method string, url string, dataSent int64, dataReceived int64, threadID int, dataType string, responseMsg string, grpThreads int, allThreads int, connect int64) {
 	// 计算响应时间
 	responseTime := endTime.Sub(startTime)
@@ -156,13 +547,13 @@ func (c *Collector) CollectDataWithParams(id string, startTime time.Time, endTim
 		AllThreads:   allThreads,
 		Connect:      connect,
 	}
+	if err := c.successPolicy.evaluate(result); err != nil {
+		result.Type = Failure
+		result.ErrorMessage = err.Error()
+	}
 
 	// 将结果发送到数据通道
-	select {
-	case c.dataChan <- result:
-	default:
-		c.logger.Log("ERROR", "data channel is full, result dropped")
-	}
+	c.enqueue(queuedResult{data: result, failure: result.Type != Success}, false)
 }
 
 // CollectData 定期收集数据
@@ -188,24 +579,26 @@ func (c *Collector) CollectData() {
 	c.CollectDataWithParams(id, startTime, endTime, statusCode, method, url, dataSent, dataReceived, threadID, dataType, responseMsg, grpThreads, allThreads, connect)
 }
 
-// processData 负责异步处理收集到的测试结果数据。
+// processData 负责异步处理收集到的测试结果数据，dataChan 关闭且数据全部处理完后关闭 workersDone。
 func (c *Collector) processData() {
+	defer close(c.workersDone)
 	var wg sync.WaitGroup
 
 	for i := 0; i < c.numGoroutines; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for data := range c.dataChan {
-				if data.Type == Success {
-					if err := c.SaveSuccessResult(data); err != nil {
+			for item := range c.dataChan {
+				if !item.failure {
+					if err := c.saveSuccessResult(item.data); err != nil {
 						c.logger.Log("ERROR", fmt.Sprintf("failed to save success result: %v", err))
 					}
 				} else {
-					if err := c.SaveFailureResult(data); err != nil {
+					if err := c.saveFailureResult(item.data); err != nil {
 						c.logger.Log("ERROR", fmt.Sprintf("failed to save failure result: %v", err))
 					}
 				}
+				c.finishPending(item.gen)
 			}
 		}()
 	}
@@ -213,41 +606,121 @@ func (c *Collector) processData() {
 	wg.Wait()
 }
 
-// SaveSuccessResult 保存成功结果到结果集中，并写入JTL文件（如果配置了路径）。
+// SaveSuccessResult 提交成功结果，立即返回，由处理协程异步写入结果集和JTL文件（如果配置了路径）。
+// 通道已满时等待处理协程腾出空间而不丢弃结果；需要读取刚保存的结果时先调用 Flush，Close 时写出全部结果
 func (c *Collector) SaveSuccessResult(data ResultData) error {
+	return c.enqueue(queuedResult{data: data}, true)
+}
+
+// SaveFailureResult 提交失败结果，立即返回，由处理协程异步写入结果集和JTL文件（如果配置了路径），其余同 SaveSuccessResult
+func (c *Collector) SaveFailureResult(data ResultData) error {
+	return c.enqueue(queuedResult{data: data, failure: true}, true)
+}
+
+// saveSuccessResult 保存成功结果到结果集中，并写入JTL文件（如果配置了路径）。
+func (c *Collector) saveSuccessResult(data ResultData) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	data.ResponseBody = ""
+	c.assignSampleID(&data)
+	redactResult(&data)
+	c.scrubber.apply(&data)
 
 	// 计算 ResponseTime，直接使用 time.Duration 的 Sub 方法
 	data.ResponseTime = data.EndTime.Sub(data.StartTime)
 
-	c.results = append(c.results, data)
+	c.results.add(data)
+
+	if c.elasticsearch != nil {
+		c.elasticsearch.add(data)
+	}
 
 	if c.jtlFilePath != "" {
 		if err := c.writeToJTL([]ResultData{data}); err != nil {
+			c.countResult(data, false)
 			c.logger.Log("ERROR", fmt.Sprintf("failed to write success result to JTL file: %v", err))
 			return err
 		}
 	}
+	c.countResult(data, c.jtlFilePath != "")
 	return nil
 }
 
-// SaveFailureResult 保存失败结果到结果集中，并写入JTL文件（如果配置了路径）。
-func (c *Collector) SaveFailureResult(data ResultData) error {
+// saveFailureResult 保存失败结果到结果集中，并写入JTL文件（如果配置了路径）。
+func (c *Collector) saveFailureResult(data ResultData) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.assignSampleID(&data)
+	redactResult(&data) // 在截断响应体之前处理，截断不会留下半个敏感值
+	c.scrubber.apply(&data)
+	c.captureFailureBody(&data)
+
+	c.results.add(data)
 
-	c.results = append(c.results, data)
+	if c.elasticsearch != nil {
+		c.elasticsearch.add(data)
+	}
 
 	if c.jtlFilePath != "" {
 		if err := c.writeToJTL([]ResultData{data}); err != nil {
+			c.countResult(data, false)
 			c.logger.Log("ERROR", fmt.Sprintf("failed to write failure result to JTL file: %v", err))
 			return err
 		}
 	}
+	c.countResult(data, c.jtlFilePath != "")
 	return nil
 }
 
+// redactResult 隐藏结果中的敏感信息（URL 查询参数中的令牌、错误信息和响应体中的密码等），
+// 结果在写入 JTL 文件之前处理，之后生成的报告和导出的文件中同样不包含这些信息
+func redactResult(data *ResultData) {
+	data.URL = redact.String(data.URL)
+	data.ErrorMessage = redact.String(data.ErrorMessage)
+	data.ResponseMsg = redact.String(data.ResponseMsg)
+	data.ResponseBody = redact.String(data.ResponseBody)
+}
+
+// SetMeasurementStart 设置测量开始时间（通常为 pool.RunVUs 的启动屏障给出的时间），
+// 开始时间之前的结果视为预热数据，不计入统计
+func (c *Collector) SetMeasurementStart(t time.Time) {
+	c.mu.Lock()
+	c.measureStart = t
+	c.mu.Unlock()
+}
+
+// SetStopReason 设置压测结束原因（通常为 pool.VURun.StopReason），写入报告
+func (c *Collector) SetStopReason(reason string) {
+	c.mu.Lock()
+	c.stopReason = reason
+	c.mu.Unlock()
+}
+
+// SetAbort 记录压测因超出错误预算或收到中断信号提前中止（通常来自 pool.VURun.Abort 或 pool.ShutdownManager），报告中醒目展示中止原因和时间
+func (c *Collector) SetAbort(reason, detail string, at time.Time) {
+	c.mu.Lock()
+	c.stopReason = reason
+	c.abortDetail = detail
+	c.abortTime = at
+	c.mu.Unlock()
+}
+
+// Results 返回当前已收集结果的副本，先等待此前提交的结果处理完（见 Flush）。
+// 结果保留策略为 last 时只包含最近的结果，为 aggregates 时为空，为 disk 时从 JTL 文件读取
+func (c *Collector) Results() []ResultData {
+	if c.results.mode == RetainDisk {
+		results, err := c.LoadResultsFromFile()
+		if err != nil {
+			c.logger.Log("ERROR", fmt.Sprintf("failed to load results from JTL file: %v", err))
+		}
+		return results
+	}
+	c.Flush()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.results.snapshot()
+}
+
 // generateTextReport 生成文本格式的报告。
 func (c *Collector) generateTextReport(results []ResultData) error {
 	var report strings.Builder
@@ -305,38 +778,49 @@ type Logger interface {
 	Log(level string, message string)
 }
 
-// Close 关闭收集器
+// Close 关闭收集器：停止接收新结果，等待已提交的结果全部写入后释放运行目录锁。
+// 可以重复调用或并发调用，只有第一次调用执行关闭，之后的调用返回相同的结果
 func (c *Collector) Close() error {
-	close(c.done)
-	return nil
-}
-
-// CloseCollector 关闭结果收集器，释放相关资源。
-func (c *Collector) CloseCollector() error {
-	// 关闭数据通道
-	close(c.dataChan)
-
-	// 关闭完成信号通道
-	close(c.done)
-
-	// 等待所有 goroutine 完成
-	// 使用 WaitGroup 来等待所有 goroutine 完成
-	var wg sync.WaitGroup
+	c.closeOnce.Do(func() {
+		// 停止阶段报告定时任务
+		c.stopIntermediateReports()
+
+		// 等待正在进行的发送结束后关闭数据通道，之后的发送会被丢弃
+		c.sendMu.Lock()
+		c.closed = true
+		close(c.dataChan)
+		c.sendMu.Unlock()
+
+		// 等待处理协程写完通道中剩余的结果
+		<-c.workersDone
+
+		// 停止定时收集任务
+		close(c.done)
+
+		// 关闭当前 JTL 分段，gzip 分段写入结尾
+		if c.segments != nil {
+			c.mu.Lock()
+			if err := c.segments.close(); err != nil {
+				c.logger.Log("ERROR", err.Error())
+			}
+			c.mu.Unlock()
+		}
 
-	// 启动多个 goroutine 来处理数据
-	for i := 0; i < c.numGoroutines; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for range c.dataChan {
+		// 写入剩余的 Elasticsearch 文档
+		if c.elasticsearch != nil {
+			if err := c.elasticsearch.close(); err != nil {
+				c.logger.Log("ERROR", err.Error())
 			}
-		}()
-	}
+		}
 
-	// 等待所有 goroutine 完成
-	wg.Wait()
+		// 释放运行目录锁
+		c.closeErr = c.runLock.Release()
+		c.logger.Log("INFO", "Collector has been closed and resources released.")
+	})
+	return c.closeErr
+}
 
-	// 日志记录收集器关闭信息
-	c.logger.Log("INFO", "Collector has been closed and resources released.")
-	return nil
+// CloseCollector 关闭结果收集器，释放相关资源。与 Close 相同，保留用于兼容
+func (c *Collector) CloseCollector() error {
+	return c.Close()
 }
@@ -0,0 +1,154 @@
+// delivery.go
+// 消息投递延迟统计模块
+// 本文件负责消息类压测（例如 MQTT 发布/订阅）的端到端投递延迟：从发布者发送消息到订阅者收到消息的耗时。
+//
+// 技术实现细节：
+// 1. 订阅者每收到一条消息保存一条投递样本，JTL 中 dataType 列为 DeliveryDataType，
+//    开始时间为消息的发布时间，响应时间为投递延迟。
+// 2. 投递样本不是请求，统计时与请求样本分开：总体指标只统计请求，投递延迟单独汇总百分位和投递吞吐量，
+//    并按时间段生成平均/最大投递延迟趋势图，分段方式与阶段耗时图一致。
+// 3. 发布者和订阅者在同一台压测机上时两端使用同一个时钟，延迟不受时钟偏差影响。
+
+package result
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// DeliveryDataType 投递样本在 JTL dataType 列中的取值
+const DeliveryDataType = "delivery"
+
+// DeliveryStats 投递延迟汇总
+type DeliveryStats struct {
+	Messages   int
+	Throughput float64 // 每秒投递的消息数（按收到时间计算），保留两位小数
+	Avg        time.Duration
+	Min        time.Duration
+	Max        time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+}
+
+// DeliverySample 一个时间段内的投递延迟
+type DeliverySample struct {
+	Timestamp time.Time     // 时间段开始时间
+	Messages  int           // 时间段内投递的消息数
+	Avg       time.Duration // 平均投递延迟
+	Max       time.Duration // 最大投递延迟
+}
+
+// splitDeliveries 将结果分为其他样本和投递样本
+func splitDeliveries(results []ResultData) ([]ResultData, []ResultData) {
+	var deliveries []ResultData
+	rest := make([]ResultData, 0, len(results))
+	for _, r := range results {
+		if r.DataType == DeliveryDataType {
+			deliveries = append(deliveries, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	return rest, deliveries
+}
+
+// calculateDeliveryStats 汇总投递延迟并按发布时间分段，没有投递样本时返回 false
+func calculateDeliveryStats(deliveries []ResultData) (DeliveryStats, []DeliverySample, bool) {
+	if len(deliveries) == 0 {
+		return DeliveryStats{}, nil, false
+	}
+	sorted := make([]ResultData, len(deliveries))
+	copy(sorted, deliveries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartTime.Before(sorted[j].StartTime) })
+
+	stats := DeliveryStats{Messages: len(sorted), Min: sorted[0].ResponseTime}
+	latencies := make([]time.Duration, 0, len(sorted))
+	var total time.Duration
+	for _, r := range sorted {
+		total += r.ResponseTime
+		stats.Min = min(stats.Min, r.ResponseTime)
+		stats.Max = max(stats.Max, r.ResponseTime)
+		latencies = append(latencies, r.ResponseTime)
+	}
+	stats.Avg = total / time.Duration(stats.Messages)
+	stats.Throughput = deliveryThroughput(sorted)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.P50 = percentileOf(latencies, 50)
+	stats.P90 = percentileOf(latencies, 90)
+	stats.P95 = percentileOf(latencies, 95)
+	stats.P99 = percentileOf(latencies, 99)
+
+	first := sorted[0].StartTime.Truncate(time.Second)
+	bucket := chartBucket(sorted[len(sorted)-1].StartTime.Sub(first))
+	var samples []DeliverySample
+	var sums []time.Duration
+	for _, r := range sorted {
+		start := first.Add(r.StartTime.Sub(first) / bucket * bucket)
+		if len(samples) == 0 || !samples[len(samples)-1].Timestamp.Equal(start) {
+			samples = append(samples, DeliverySample{Timestamp: start})
+			sums = append(sums, 0)
+		}
+		s := &samples[len(samples)-1]
+		s.Messages++
+		s.Max = max(s.Max, r.ResponseTime)
+		sums[len(sums)-1] += r.ResponseTime
+	}
+	for i := range samples {
+		samples[i].Avg = sums[i] / time.Duration(samples[i].Messages)
+	}
+	return stats, samples, true
+}
+
+// deliveryThroughput 按第一条到最后一条消息的收到时间计算每秒投递的消息数，不足一秒按一秒计算
+func deliveryThroughput(deliveries []ResultData) float64 {
+	first, last := deliveries[0].EndTime, deliveries[0].EndTime
+	for _, r := range deliveries {
+		if r.EndTime.Before(first) {
+			first = r.EndTime
+		}
+		if r.EndTime.After(last) {
+			last = r.EndTime
+		}
+	}
+	elapsed := math.Max(last.Sub(first).Seconds(), 1)
+	return math.Round(float64(len(deliveries))/elapsed*100) / 100
+}
+
+// newDeliveryChart 创建投递延迟趋势图
+func newDeliveryChart(samples []DeliverySample, lang Language) (*charts.Line, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no delivery latency samples")
+	}
+
+	xAxis := make([]string, 0, len(samples))
+	avg := make([]opts.LineData, 0, len(samples))
+	peak := make([]opts.LineData, 0, len(samples))
+	for _, s := range samples {
+		xAxis = append(xAxis, s.Timestamp.Format("15:04:05"))
+		avg = append(avg, opts.LineData{Value: fmt.Sprintf("%.2f", float64(s.Avg)/float64(time.Millisecond))})
+		peak = append(peak, opts.LineData{Value: fmt.Sprintf("%.2f", float64(s.Max)/float64(time.Millisecond))})
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("delivery_chart_title"),
+			Subtitle: lang.text("chart_duration", samples[0].Timestamp.Format("15:04:05"), samples[len(samples)-1].Timestamp.Format("15:04:05")),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Trigger: "axis"}),
+	)
+	line.SetXAxis(xAxis)
+	line.AddSeries(lang.text("delivery_series_avg"), avg)
+	line.AddSeries(lang.text("delivery_series_max"), peak)
+	return line, nil
+}
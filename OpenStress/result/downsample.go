@@ -0,0 +1,126 @@
+// downsample.go
+// 时间序列降采样模块
+// 本文件负责将每秒一个点的时间序列聚合为固定数量的桶，用于长时间压测的趋势图，
+// 使 6 小时以上的测试图表仍能看出短暂的毛刺。
+//
+// 技术实现细节：
+// 1. 序列长度不超过桶数时每秒一个点原样展示；否则每个桶覆盖 ceil(长度/桶数) 秒，桶数默认 DefaultChartBuckets，
+//    通过 CollectorConfig.ChartBuckets 配置。
+// 2. 每个桶按聚合方式取值：max（默认，保留毛刺）、avg（平均值）、p95（桶内第 95 百分位，过滤单点抖动）。
+// 3. 横坐标为每个桶的起始时间；桶宽大于 1 秒时在图表副标题中注明桶宽和聚合方式。
+// 4. 聚合参数由 GeneratePerformanceStats 写入 stats["ChartBuckets"] 和 stats["ChartAggregation"]，
+//    直接调用 Generate*ChartAsync 时使用默认值。
+
+package result
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 图表桶内聚合方式
+const (
+	ChartAggregateMax = "max"
+	ChartAggregateAvg = "avg"
+	ChartAggregateP95 = "p95"
+)
+
+// DefaultChartBuckets 趋势图默认的最大点数
+const DefaultChartBuckets = 120
+
+// ParseChartAggregation 解析图表聚合方式，大小写不敏感；空字符串返回 ChartAggregateMax
+func ParseChartAggregation(s string) (string, error) {
+	switch agg := strings.ToLower(strings.TrimSpace(s)); agg {
+	case "":
+		return ChartAggregateMax, nil
+	case ChartAggregateMax, ChartAggregateAvg, ChartAggregateP95:
+		return agg, nil
+	}
+	return "", fmt.Errorf("unsupported chart aggregation %q (supported: %s, %s, %s)", s, ChartAggregateMax, ChartAggregateAvg, ChartAggregateP95)
+}
+
+// chartOptions 趋势图的降采样参数
+type chartOptions struct {
+	buckets     int
+	aggregation string
+}
+
+// defaultChartOptions 默认的降采样参数
+var defaultChartOptions = chartOptions{buckets: DefaultChartBuckets, aggregation: ChartAggregateMax}
+
+// reportChartOptions 返回统计结果中指定的降采样参数，未指定或无效时使用默认值
+func reportChartOptions(stats map[string]interface{}) chartOptions {
+	options := defaultChartOptions
+	if buckets, ok := stats["ChartBuckets"].(int); ok && buckets > 0 {
+		options.buckets = buckets
+	}
+	if name, ok := stats["ChartAggregation"].(string); ok {
+		if agg, err := ParseChartAggregation(name); err == nil {
+			options.aggregation = agg
+		}
+	}
+	return options
+}
+
+// bucketSeconds 返回长度为 n 的序列每个桶覆盖的秒数
+func (o chartOptions) bucketSeconds(n int) int {
+	if o.buckets <= 0 || n <= o.buckets {
+		return 1
+	}
+	return (n + o.buckets - 1) / o.buckets
+}
+
+// downsample 将从 startTime 开始每秒一个点的序列聚合为桶，返回桶起始时间（HH:MM:SS）和桶的聚合值；
+// 序列不超过桶数（包括只有一个点的短时间测试）时每秒一个点，空序列返回 ErrEmptySeries
+func (o chartOptions) downsample(startTime time.Time, values []int) ([]string, []int, error) {
+	if len(values) == 0 {
+		return nil, nil, ErrEmptySeries
+	}
+	size := o.bucketSeconds(len(values))
+	var xAxis []string
+	var yAxis []int
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		xAxis = append(xAxis, startTime.Add(time.Duration(start)*time.Second).Format("15:04:05"))
+		yAxis = append(yAxis, aggregateBucket(values[start:end], o.aggregation))
+	}
+	return xAxis, yAxis, nil
+}
+
+// subtitle 在图表副标题后注明桶宽和聚合方式，桶宽为 1 秒时原样返回
+func (o chartOptions) subtitle(subtitle string, n int, lang Language) string {
+	size := o.bucketSeconds(n)
+	if size == 1 {
+		return subtitle
+	}
+	return subtitle + lang.text("chart_bucket", size, lang.text("chart_aggregation_"+o.aggregation))
+}
+
+// aggregateBucket 按聚合方式计算一个桶的值
+func aggregateBucket(values []int, aggregation string) int {
+	switch aggregation {
+	case ChartAggregateAvg:
+		sum := 0
+		for _, v := range values {
+			sum += v
+		}
+		return int(math.Round(float64(sum) / float64(len(values))))
+	case ChartAggregateP95:
+		sorted := append([]int(nil), values...)
+		sort.Ints(sorted)
+		return sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+	}
+	max := values[0]
+	for _, v := range values[1:] {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
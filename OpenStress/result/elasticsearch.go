@@ -0,0 +1,383 @@
+// elasticsearch.go
+// Elasticsearch 结果索引模块
+// 本文件负责把每条结果（附带运行ID和标签）作为一个文档批量写入 Elasticsearch，
+// 压测结束后可以在 Kibana 中按任意维度（接口、状态码、标签、压测机等）分析响应时间和错误。
+//
+// 技术实现细节：
+// 1. 结果在写入 JTL 文件的同时放入本地有界缓冲（已隐藏敏感信息），后台协程按批量大小或写入间隔调用 _bulk 接口，
+//    不阻塞结果保存；缓冲满时丢弃最早的文档。
+// 2. 文档ID为 <运行ID>-<序号>，重试同一批文档时覆盖而不是重复写入。
+// 3. 请求失败或文档返回 429/5xx 时按指数退避重试，仍失败则放回缓冲等待下一轮；文档因其他原因被拒绝（例如映射冲突）时
+//    不重试，计入拒绝数。关闭收集器时尽量写入剩余文档，有文档被丢弃或拒绝时记录错误日志。
+
+package result
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+)
+
+// DefaultElasticsearchIndex 默认的结果索引名
+const DefaultElasticsearchIndex = "openstress-results"
+
+// esDocument 写入 Elasticsearch 的结果文档，时间字段为毫秒
+type esDocument struct {
+	Timestamp    time.Time         `json:"@timestamp"`
+	RunID        string            `json:"run_id"`
+	TestPlan     string            `json:"test_plan"`
+	Host         string            `json:"host"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	RequestID    string            `json:"request_id"`
+	Result       string            `json:"result"` // success、failure 或 timeout
+	Success      bool              `json:"success"`
+	ResponseTime float64           `json:"response_time_ms"`
+	StatusCode   int               `json:"status_code"`
+	Method       string            `json:"method,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	ThreadID     int               `json:"thread_id"`
+	BytesSent    int64             `json:"bytes_sent"`
+	BytesRecv    int64             `json:"bytes_received"`
+	Connect      int64             `json:"connect_ms"`
+	TTFB         float64           `json:"ttfb_ms,omitempty"`
+	RetryCount   int               `json:"retry_count"`
+	ThrottleWait float64           `json:"throttle_wait_ms,omitempty"`
+	Transaction  string            `json:"transaction,omitempty"`
+	Label        string            `json:"label,omitempty"`
+	TraceID      string            `json:"trace_id,omitempty"`
+	VUID         int               `json:"vu_id,omitempty"`
+	Iteration    int               `json:"iteration,omitempty"`
+	Assertions   map[string]bool   `json:"assertions,omitempty"`
+}
+
+// esEntry 缓冲中的一个文档
+type esEntry struct {
+	id  string
+	doc esDocument
+}
+
+// esIndexer 带本地缓冲和重试的 Elasticsearch 批量写入
+type esIndexer struct {
+	cfg      config.ElasticsearchConfig
+	bulkURL  string
+	client   *http.Client
+	logger   Logger
+	runID    string
+	testPlan string
+	host     string
+
+	mu       sync.Mutex
+	buffer   []esEntry
+	seq      int64
+	dropped  int64 // 因缓冲已满或关闭时写入失败而丢弃的文档数
+	rejected int64 // 被 Elasticsearch 拒绝的文档数
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// withElasticsearchDefaults 填充 Elasticsearch 配置的默认值
+func withElasticsearchDefaults(cfg config.ElasticsearchConfig) config.ElasticsearchConfig {
+	if cfg.Index == "" {
+		cfg.Index = DefaultElasticsearchIndex
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 100000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	return cfg
+}
+
+// newESIndexer 创建 Elasticsearch 批量写入并启动后台协程
+func newESIndexer(cfg config.ElasticsearchConfig, runID string, metadata RunMetadata, logger Logger) (*esIndexer, error) {
+	cfg = withElasticsearchDefaults(cfg)
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("invalid elasticsearch url: %s", cfg.URL)
+	}
+	if cfg.Index != strings.ToLower(cfg.Index) {
+		return nil, fmt.Errorf("elasticsearch index name must be lowercase: %s", cfg.Index)
+	}
+	ix := &esIndexer{
+		cfg:      cfg,
+		bulkURL:  strings.TrimRight(cfg.URL, "/") + "/_bulk",
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		runID:    runID,
+		testPlan: metadata.TestPlan,
+		host:     metadata.Hostname,
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go ix.run()
+	return ix, nil
+}
+
+// add 将一条结果转换为文档放入本地缓冲，缓冲满时丢弃最早的文档
+func (ix *esIndexer) add(data ResultData) {
+	doc := esDocument{
+		Timestamp:    data.StartTime,
+		RunID:        ix.runID,
+		TestPlan:     ix.testPlan,
+		Host:         ix.host,
+		Labels:       ix.cfg.Labels,
+		RequestID:    data.ID,
+		Result:       esResultName(data.Type),
+		Success:      data.Type == Success,
+		ResponseTime: durationMillis(data.ResponseTime),
+		StatusCode:   data.StatusCode,
+		Method:       data.Method,
+		URL:          data.URL,
+		ErrorMessage: data.ErrorMessage,
+		ThreadID:     data.ThreadID,
+		BytesSent:    data.DataSent,
+		BytesRecv:    data.DataReceived,
+		Connect:      data.Connect,
+		TTFB:         durationMillis(data.TTFB),
+		RetryCount:   data.RetryCount,
+		ThrottleWait: durationMillis(data.ThrottleWait),
+		Transaction:  data.Transaction,
+		Label:        data.Label,
+		TraceID:      data.TraceID,
+		VUID:         data.VUID,
+		Iteration:    data.Iteration,
+		Assertions:   data.Assertions,
+	}
+
+	ix.mu.Lock()
+	ix.seq++
+	if len(ix.buffer) >= ix.cfg.BufferSize {
+		ix.buffer = ix.buffer[1:]
+		ix.dropped++
+	}
+	ix.buffer = append(ix.buffer, esEntry{id: fmt.Sprintf("%s-%d", ix.runID, ix.seq), doc: doc})
+	full := len(ix.buffer) >= ix.cfg.BatchSize
+	ix.mu.Unlock()
+
+	if full {
+		select {
+		case ix.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// esResultName 返回结果类型在文档中的名称
+func esResultName(t ResultType) string {
+	switch t {
+	case Success:
+		return "success"
+	case Timeout:
+		return "timeout"
+	default:
+		return "failure"
+	}
+}
+
+// run 按写入间隔或缓冲达到批量大小时写入文档
+func (ix *esIndexer) run() {
+	defer close(ix.done)
+	ticker := time.NewTicker(ix.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ix.stop:
+			ix.flush(true)
+			return
+		case <-ticker.C:
+			ix.flush(false)
+		case <-ix.notify:
+			ix.flush(false)
+		}
+	}
+}
+
+// flush 分批写入缓冲中的文档；写入失败时放回缓冲等待下一轮，final 为 true 时直接丢弃
+func (ix *esIndexer) flush(final bool) {
+	for {
+		ix.mu.Lock()
+		n := len(ix.buffer)
+		if n > ix.cfg.BatchSize {
+			n = ix.cfg.BatchSize
+		}
+		batch := append([]esEntry(nil), ix.buffer[:n]...)
+		ix.buffer = ix.buffer[n:]
+		ix.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+
+		if pending, err := ix.sendWithRetry(batch); err != nil {
+			ix.logger.Log("ERROR", fmt.Sprintf("elasticsearch indexing: %v", err))
+			if final {
+				ix.mu.Lock()
+				ix.dropped += int64(len(ix.buffer) + len(pending))
+				ix.buffer = nil
+				ix.mu.Unlock()
+				return
+			}
+			ix.requeue(pending)
+			return
+		}
+	}
+}
+
+// requeue 将写入失败的文档放回缓冲头部，超出缓冲大小的部分丢弃最早的文档
+func (ix *esIndexer) requeue(batch []esEntry) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	buffer := append(batch, ix.buffer...)
+	if extra := len(buffer) - ix.cfg.BufferSize; extra > 0 {
+		buffer = buffer[extra:]
+		ix.dropped += int64(extra)
+	}
+	ix.buffer = buffer
+}
+
+// sendWithRetry 写入一批文档，失败时按指数退避重试（部分文档被限流时只重试这些文档），
+// 返回最终仍未写入的文档
+func (ix *esIndexer) sendWithRetry(batch []esEntry) ([]esEntry, error) {
+	backoff := ix.cfg.RetryBackoff
+	pending := batch
+	var err error
+	for attempt := 0; attempt <= ix.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ix.stop:
+				// 关闭时不再等待退避，立即做最后一次尝试
+			}
+			backoff *= 2
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		var retry []esEntry
+		retry, err = ix.send(ctx, pending)
+		cancel()
+		if err == nil {
+			if len(retry) == 0 {
+				return nil, nil
+			}
+			pending = retry
+			err = fmt.Errorf("%d documents were throttled", len(retry))
+		}
+	}
+	return pending, fmt.Errorf("failed to index %d documents after %d retries: %v", len(pending), ix.cfg.MaxRetries, err)
+}
+
+// esBulkResponse _bulk 接口的响应，只解析需要的字段
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// send 调用 _bulk 接口写入一批文档（NDJSON 格式，每个文档前一行为 index 操作），
+// 返回被限流（429）或服务端出错（5xx）需要重试的文档，其他原因被拒绝的文档不重试
+func (ix *esIndexer) send(ctx context.Context, batch []esEntry) ([]esEntry, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, entry := range batch {
+		action := map[string]map[string]string{"index": {"_index": ix.cfg.Index, "_id": entry.id}}
+		if err := encoder.Encode(action); err != nil {
+			return nil, fmt.Errorf("failed to encode bulk action: %v", err)
+		}
+		if err := encoder.Encode(entry.doc); err != nil {
+			return nil, fmt.Errorf("failed to encode document %s: %v", entry.id, err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ix.bulkURL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bulk request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if ix.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+ix.cfg.APIKey)
+	} else if ix.cfg.Username != "" {
+		req.SetBasicAuth(ix.cfg.Username, ix.cfg.Password)
+	}
+	resp, err := ix.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bulk request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("bulk request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var result esBulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk response: %v", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+
+	var retry []esEntry
+	var rejected int64
+	var firstErr string
+	for i, item := range result.Items {
+		for _, status := range item {
+			if status.Status < 300 || i >= len(batch) {
+				continue
+			}
+			if status.Status == http.StatusTooManyRequests || status.Status >= 500 {
+				retry = append(retry, batch[i])
+				continue
+			}
+			rejected++
+			if firstErr == "" {
+				firstErr = fmt.Sprintf("%s: %s", status.Error.Type, status.Error.Reason)
+			}
+		}
+	}
+	if rejected > 0 {
+		ix.mu.Lock()
+		ix.rejected += rejected
+		ix.mu.Unlock()
+		ix.logger.Log("ERROR", fmt.Sprintf("elasticsearch rejected %d documents: %s", rejected, firstErr))
+	}
+	return retry, nil
+}
+
+// close 写入剩余文档并停止后台协程，有文档被丢弃或拒绝时返回错误
+func (ix *esIndexer) close() error {
+	close(ix.stop)
+	<-ix.done
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.dropped > 0 || ix.rejected > 0 {
+		return fmt.Errorf("elasticsearch: dropped %d and rejected %d result documents", ix.dropped, ix.rejected)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// export.go
+// 统计结果导出模块
+// 本文件负责将最终统计结果导出为机器可读格式（JSON / CSV / OpenMetrics / JUnit XML），
+// 方便 CI 流水线直接消费，而不必解析 HTML 报告。
+//
+// 导出约定：
+// - 时间类型（time.Duration）在 JSON/CSV 中统一转换为毫秒，在 OpenMetrics 中转换为秒
+// - 每秒序列（[]int 等）仅在 JSON 中导出，CSV/OpenMetrics 只包含标量指标
+// - 嵌套的 map（如百分位）在 CSV 中展开为 key.subkey 形式，在 OpenMetrics 中作为 key 标签（标签值按规范转义）
+// - JUnit XML 只包含阈值判定结果（见 junit.go），需要先调用 EvaluateThresholds
+// - ExportStats 导出 GeneratePerformanceStats 保存的快照，实时查询使用 CurrentStats，不覆盖该快照
+
+package result
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 支持的导出格式
+const (
+	ExportFormatJSON        = "json"
+	ExportFormatCSV         = "csv"
+	ExportFormatOpenMetrics = "openmetrics"
+	ExportFormatJUnit       = "junit"
+)
+
+// ExportStats 将最近一次 GeneratePerformanceStats 的结果导出为指定格式。
+// 实时查询（CurrentStats）不会覆盖该快照
+func (c *Collector) ExportStats(format string) ([]byte, error) {
+	c.mu.RLock()
+	stats := c.lastStats
+	c.mu.RUnlock()
+
+	if stats == nil {
+		return nil, fmt.Errorf("no stats available, call GeneratePerformanceStats first")
+	}
+	return ExportStatsMap(stats, format)
+}
+
+// ExportStatsMap 将统计结果导出为指定格式
+func ExportStatsMap(stats map[string]interface{}, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case ExportFormatJSON:
+		return exportJSON(stats)
+	case ExportFormatCSV:
+		return exportCSV(stats)
+	case ExportFormatOpenMetrics:
+		return exportOpenMetrics(stats), nil
+	case ExportFormatJUnit:
+		return exportJUnit(stats)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// exportJSON 导出 JSON，时间类型转换为毫秒
+func exportJSON(stats map[string]interface{}) ([]byte, error) {
+	normalized := make(map[string]interface{}, len(stats))
+	for key, value := range stats {
+		normalized[key] = normalizeExportValue(value)
+	}
+	return json.MarshalIndent(normalized, "", "  ")
+}
+
+// normalizeExportValue 将 time.Duration 等类型转换为便于序列化的值
+func normalizeExportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Duration:
+		return durationMillis(v)
+	case map[string]time.Duration:
+		m := make(map[string]float64, len(v))
+		for k, d := range v {
+			m[k] = durationMillis(d)
+		}
+		return m
+	default:
+		return v
+	}
+}
+
+// durationMillis 将时间转换为毫秒（保留三位小数）
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// exportCSV 导出 key,value 两列的 CSV，仅包含标量指标
+func exportCSV(stats map[string]interface{}) ([]byte, error) {
+	rows := make(map[string]string)
+	for key, value := range stats {
+		switch v := value.(type) {
+		case map[string]time.Duration:
+			for sub, d := range v {
+				rows[key+"."+sub] = strconv.FormatFloat(durationMillis(d), 'f', -1, 64)
+			}
+		default:
+			if s, ok := scalarString(value); ok {
+				rows[key] = s
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"metric", "value"}); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %v", err)
+	}
+	for _, key := range keys {
+		if err := writer.Write([]string{key, rows[key]}); err != nil {
+			return nil, fmt.Errorf("failed to write csv record: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scalarString 将标量值格式化为字符串，非标量返回 false
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case time.Duration:
+		return strconv.FormatFloat(durationMillis(v), 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+// exportOpenMetrics 导出 OpenMetrics 文本格式，仅包含数值型指标
+func exportOpenMetrics(stats map[string]interface{}) []byte {
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, key := range keys {
+		name := "openstress_" + snakeCase(key)
+		switch v := stats[key].(type) {
+		case time.Duration:
+			name += "_seconds"
+			fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+			fmt.Fprintf(&buf, "%s %s\n", name, formatMetricFloat(v.Seconds()))
+		case map[string]time.Duration:
+			name += "_seconds"
+			fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+			subKeys := make([]string, 0, len(v))
+			for sub := range v {
+				subKeys = append(subKeys, sub)
+			}
+			sort.Strings(subKeys)
+			for _, sub := range subKeys {
+				fmt.Fprintf(&buf, "%s{key=\"%s\"} %s\n", name, escapeLabelValue(sub), formatMetricFloat(v[sub].Seconds()))
+			}
+		case int:
+			fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+			fmt.Fprintf(&buf, "%s %d\n", name, v)
+		case int64:
+			fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+			fmt.Fprintf(&buf, "%s %d\n", name, v)
+		case float64:
+			fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+			fmt.Fprintf(&buf, "%s %s\n", name, formatMetricFloat(v))
+		}
+	}
+	buf.WriteString("# EOF\n")
+	return buf.Bytes()
+}
+
+// labelValueEscaper 按 OpenMetrics 规范转义标签值中的反斜杠、双引号和换行
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue 转义标签值
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// formatMetricFloat 格式化浮点数
+func formatMetricFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// snakeCase 将 CamelCase 键名转换为 snake_case，例如 P95ResponseTime -> p95_response_time
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+// failurebody.go
+// 失败响应体采样模块
+// 本文件负责按配置保存失败请求的响应体片段，并在报告的错误分析中展示每种错误的代表性响应，便于定位根因。
+//
+// 技术实现细节：
+// 1. 任务在 ResultData.ResponseBody 中带上响应体（通常只读取前若干字节），收集器保存失败结果时按
+//    CollectorConfig.FailureBodyBytes 截断，按 FailureBodySampleRate 抽样，每种错误（状态码 + 错误信息）
+//    最多保存 FailureBodiesPerError 条，长时间压测中大量相同的失败不会让结果文件膨胀；成功结果不保存响应体。
+// 2. 响应体写入 JTL 的 failureBody 列（CSV 转义，不替换逗号），重新加载结果后仍可用于报告。
+// 3. collectTopErrors 为每种错误取第一条保存的响应体作为代表，HTML 报告和 Markdown 摘要的错误分析中展示。
+
+package result
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/potatoImp/OpenStress/random"
+)
+
+// DefaultFailureBodiesPerError 每种错误默认最多保存的响应体数量
+const DefaultFailureBodiesPerError = 5
+
+// captureFailureBody 按配置截断、抽样失败结果的响应体，未选中时清空；调用方需持有 c.mu
+func (c *Collector) captureFailureBody(data *ResultData) {
+	if data.ResponseBody == "" {
+		return
+	}
+	if c.failureBodyBytes <= 0 {
+		data.ResponseBody = ""
+		return
+	}
+	key := errorKey{data.StatusCode, data.ErrorMessage}
+	if c.failureBodyCounts[key] >= c.failureBodiesPerError ||
+		(c.failureBodySampleRate > 0 && c.failureBodyRng.Float64() >= c.failureBodySampleRate) {
+		data.ResponseBody = ""
+		return
+	}
+	c.failureBodyCounts[key]++
+	data.ResponseBody = truncateBody(data.ResponseBody, c.failureBodyBytes)
+}
+
+// truncateBody 截断到最多 n 个字节，去掉被截断的多字节字符，二进制内容中的无效字节替换为 U+FFFD
+func truncateBody(body string, n int) string {
+	if len(body) > n {
+		body = body[:n]
+		for i := 0; i < utf8.UTFMax-1 && len(body) > 0; i++ {
+			if r, size := utf8.DecodeLastRuneInString(body); r != utf8.RuneError || size != 1 {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+	}
+	return strings.ToValidUTF8(body, "\uFFFD")
+}
+
+// initFailureBodyCapture 按收集器配置初始化响应体采样状态
+func (c *Collector) initFailureBodyCapture(config CollectorConfig) {
+	c.failureBodyBytes = config.FailureBodyBytes
+	c.failureBodySampleRate = config.FailureBodySampleRate
+	c.failureBodiesPerError = config.FailureBodiesPerError
+	if c.failureBodiesPerError <= 0 {
+		c.failureBodiesPerError = DefaultFailureBodiesPerError
+	}
+	c.failureBodyCounts = make(map[errorKey]int)
+	c.failureBodyRng = random.New("failure-body")
+}
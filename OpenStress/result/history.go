@@ -0,0 +1,162 @@
+// history.go
+// 历史运行模块
+// 本文件负责扫描报告根目录和 JTL 结果目录，列出历史运行生成的 HTML 报告和结果文件，
+// 供 API 服务的内置看板（见 api/dashboard.go）展示和下载。
+//
+// 技术实现细节：
+// 1. 报告目录由 SaveReportToFile 生成，默认命名为 <名称>_<yyyy-MM-dd_HH-mm-ss>，从目录名解析报告名称和生成时间；
+//    使用自定义命名规则（ReportNamePattern）时以目录名为名称、目录的修改时间为生成时间。
+//    不包含 HTML 文件的目录和以 . 开头的文件（例如报告锁文件）不列出。
+// 2. 结果文件按 JTL 分段规则（见 rotation.go）合并：以第一个分段（原文件名，压缩时追加 .gz）为一次运行，
+//    列出所有分段及其总大小。
+// 3. 结果按时间倒序排列，最近的运行在前；目录不存在时返回空列表。
+
+package result
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportDirPattern 默认命名规则生成的报告目录名
+var reportDirPattern = regexp.MustCompile(`^(.+)_(\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2})$`)
+
+// jtlSegmentPattern 第二个及之后的 JTL 分段文件名（序号插在扩展名前）
+var jtlSegmentPattern = regexp.MustCompile(`\.\d+\.jtl(\.gz)?$`)
+
+// ReportRun 一次历史运行生成的 HTML 报告
+type ReportRun struct {
+	Name  string    `json:"name"`  // 报告名称
+	Time  time.Time `json:"time"`  // 生成时间
+	Dir   string    `json:"dir"`   // 报告目录名（相对报告根目录）
+	Files []string  `json:"files"` // 报告目录中的文件（不含 static 目录），HTML 报告在前
+	Size  int64     `json:"size"`  // 列出文件的总字节数
+}
+
+// ResultFile 一次历史运行写入的 JTL 结果文件
+type ResultFile struct {
+	Name     string    `json:"name"`     // 第一个分段的文件名
+	Time     time.Time `json:"time"`     // 最后一个分段的修改时间
+	Segments []string  `json:"segments"` // 按序号排列的所有分段文件名
+	Size     int64     `json:"size"`     // 所有分段的总字节数
+}
+
+// Report 返回报告的 HTML 文件名（与目录同名的 HTML 文件优先），没有 HTML 文件时返回空字符串
+func (r ReportRun) Report() string {
+	if len(r.Files) > 0 && strings.HasSuffix(r.Files[0], ".html") {
+		return r.Files[0]
+	}
+	return ""
+}
+
+// ListReports 列出报告根目录中包含 HTML 文件的报告目录，按生成时间倒序
+func ListReports(reportDir string) ([]ReportRun, error) {
+	entries, err := os.ReadDir(reportDir)
+	if os.IsNotExist(err) {
+		return []ReportRun{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report directory: %v", err)
+	}
+
+	reports := []ReportRun{}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		run := ReportRun{Name: entry.Name(), Dir: entry.Name(), Files: []string{}}
+		if info, err := entry.Info(); err == nil {
+			run.Time = info.ModTime()
+		}
+		if match := reportDirPattern.FindStringSubmatch(entry.Name()); match != nil {
+			if generated, err := time.ParseInLocation(reportTimeFormat, match[2], time.Local); err == nil {
+				run.Name, run.Time = match[1], generated
+			}
+		}
+		files, err := os.ReadDir(filepath.Join(reportDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read report %s: %v", entry.Name(), err)
+		}
+		for _, file := range files {
+			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+				continue
+			}
+			if info, err := file.Info(); err == nil {
+				run.Size += info.Size()
+			}
+			run.Files = append(run.Files, file.Name())
+		}
+		// 与目录同名的 HTML 文件是报告本身，排在最前
+		rank := func(file string) int {
+			switch {
+			case file == entry.Name()+".html":
+				return 0
+			case strings.HasSuffix(file, ".html"):
+				return 1
+			default:
+				return 2
+			}
+		}
+		sort.SliceStable(run.Files, func(i, j int) bool { return rank(run.Files[i]) < rank(run.Files[j]) })
+		if run.Report() == "" {
+			continue
+		}
+		reports = append(reports, run)
+	}
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].Time.After(reports[j].Time) })
+	return reports, nil
+}
+
+// ListResultFiles 列出结果目录中的 JTL 文件（分段合并为一次运行），按最后修改时间倒序
+func ListResultFiles(dir string) ([]ResultFile, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []ResultFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result directory: %v", err)
+	}
+
+	files := []ResultFile{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || jtlSegmentPattern.MatchString(name) {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".gz")
+		if !strings.HasSuffix(base, ".jtl") {
+			continue
+		}
+		// 同一个文件未压缩和压缩的第一个分段同时存在时只列出一次
+		if base != name {
+			if _, err := os.Stat(filepath.Join(dir, base)); err == nil {
+				continue
+			}
+		}
+		file := ResultFile{Name: name, Segments: []string{}}
+		for _, path := range listJTLSegments(filepath.Join(dir, base)) {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			file.Segments = append(file.Segments, filepath.Base(path))
+			file.Size += info.Size()
+			if info.ModTime().After(file.Time) {
+				file.Time = info.ModTime()
+			}
+		}
+		files = append(files, file)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Time.After(files[j].Time) })
+	return files, nil
+}
+
+// ReportDir 返回收集器的 HTML 报告根目录
+func (c *Collector) ReportDir() string {
+	return c.reportDir
+}
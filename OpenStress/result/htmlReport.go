@@ -2,12 +2,14 @@ package result
 
 import (
 	"fmt"
+	"html/template"
 	"strings"
-
 	"time"
+
+	"github.com/go-echarts/go-echarts/v2/render"
 )
 
-// GenerateSummaryReport 生成测试报告
+// GenerateSummaryReport 生成测试报告，使用 CollectorConfig.Language 指定的语言
 func (c *Collector) GenerateSummaryReport(results []ResultData) string {
 	var totalRequests, successCount, failureCount int
 	var totalResponseTime time.Duration
@@ -72,210 +74,57 @@ func (c *Collector) GenerateSummaryReport(results []ResultData) string {
 	totalReceivedDataStr := formatBytes(totalReceivedData)
 
 	// 生成报告
-	report := fmt.Sprintf("测试报告:\n\n")
-	report += fmt.Sprintf("总请求数: %d\n", totalRequests)
-	report += fmt.Sprintf("成功请求数: %d (%.3f%%)\n", successCount, successRate)
-	report += fmt.Sprintf("失败请求数: %d\n", failureCount)
-	report += fmt.Sprintf("平均响应时间: %s\n", avgResponseTime)
-	report += fmt.Sprintf("最大响应时间: %s\n", maxResponseTime)
-	report += fmt.Sprintf("最小响应时间: %s\n", minResponseTime)
-	report += fmt.Sprintf("总运行时间: %s\n", totalRunTime)
-	report += fmt.Sprintf("TPS: %.2f\n", tps)
-	report += fmt.Sprintf("每秒发送数据流量: %s\n", sentDataPerSecStr)
-	report += fmt.Sprintf("每秒接收数据流量: %s\n", receivedDataPerSecStr)
-	report += fmt.Sprintf("总发送数据量: %s\n", totalSentDataStr)
-	report += fmt.Sprintf("总接收数据量: %s\n", totalReceivedDataStr)
+	lang := c.language
+	report := lang.text("summary_title") + "\n\n"
+	report += fmt.Sprintf("%s: %d\n", lang.text("summary_total_requests"), totalRequests)
+	report += fmt.Sprintf("%s: %d (%.3f%%)\n", lang.text("summary_success_count"), successCount, successRate)
+	report += fmt.Sprintf("%s: %d\n", lang.text("summary_failure_count"), failureCount)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_avg_response_time"), avgResponseTime)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_max_response_time"), maxResponseTime)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_min_response_time"), minResponseTime)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_total_run_time"), totalRunTime)
+	report += fmt.Sprintf("%s: %.2f\n", lang.text("summary_tps"), tps)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_sent_data_per_sec"), sentDataPerSecStr)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_received_data_per_sec"), receivedDataPerSecStr)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_total_sent_data"), totalSentDataStr)
+	report += fmt.Sprintf("%s: %s\n", lang.text("summary_total_received_data"), totalReceivedDataStr)
 
 	// 返回报告
 	return report
 }
 
 // GenerateHTMLReport 生成性能测试报告的HTML
+// 报告引用 static 目录中的样式、脚本和图表页面，需要与 SaveReportToFile 生成的 static 目录一起使用
+// 报告语言由 stats["Language"] 指定，未指定时使用 DefaultLanguage
 func GenerateHTMLReport(stats map[string]interface{}, title ...string) string {
-	var builder strings.Builder
+	report, _ := generateHTMLReport(stats, title, nil, nil) // 默认模板不会执行失败
+	return report
+}
 
-	// 可选的参数，使用默认值
-	pageTitle := "性能测试报告"                             // 默认标题
-	logoPath := ""                                    // 默认无logo
-	analysisContent := generateDefaultAnalysis(stats) // 根据测试数据自动生成的默认分析内容
+// GenerateHTMLReportWithTemplate 使用自定义报告模板（见 LoadReportTemplate）生成报告 HTML，tmpl 为 nil 时使用默认模板
+func GenerateHTMLReportWithTemplate(stats map[string]interface{}, tmpl *template.Template, title ...string) (string, error) {
+	return generateHTMLReport(stats, title, nil, tmpl)
+}
 
-	// 如果传入了自定义的标题，则使用传入的标题
+// reportTitle 返回报告标题，未传入时使用对应语言的默认标题
+func reportTitle(title []string, lang Language) string {
 	if len(title) > 0 {
-		pageTitle = title[0]
-	}
-
-	// 参考标准列表
-	standards := []PerformanceStandard{
-		{Field: "AvgResponseTime", Max: MaxAvgResponseTime, Compare: func(value interface{}) float64 {
-			return value.(time.Duration).Seconds()
-		}},
-		{Field: "SuccessRate", Min: MinSuccessRate, Compare: func(value interface{}) float64 {
-			return value.(float64)
-		}},
-		{Field: "TPS", Min: MaxTPS, Compare: func(value interface{}) float64 {
-			return value.(float64)
-		}},
-		{Field: "AvgResponseTime", Max: MaxHighFreqResponseTime, Compare: func(value interface{}) float64 {
-			return value.(time.Duration).Seconds()
-		}},
+		return title[0]
 	}
+	return lang.text("default_title")
+}
 
-	// HTML基础结构
-	builder.WriteString("<!DOCTYPE html>")
-	builder.WriteString("<html lang='zh'>")
-	builder.WriteString("<head>")
-	builder.WriteString("<meta charset='UTF-8'>")
-	builder.WriteString("<meta name='viewport' content='width=device-width, initial-scale=1.0'>")
-	builder.WriteString("<title>" + pageTitle + "</title>")
-
-	// 如果传入了logo路径，则添加logo
-	if logoPath != "" {
-		builder.WriteString("<link rel='icon' href='" + logoPath + "'>") // 设置logo图标
+// generateHTMLReport 生成报告 HTML，inline 不为 nil 时生成自包含报告：
+// 样式、echarts 脚本和图表直接写入页面，inline 中没有的图表不展示；tmpl 为 nil 时使用默认报告模板
+func generateHTMLReport(stats map[string]interface{}, title []string, inline map[string]render.ChartSnippet, tmpl *template.Template) (string, error) {
+	if tmpl == nil {
+		tmpl = defaultReportTemplate
 	}
-
-	// 更新CSS和JS文件路径
-	builder.WriteString("<link rel='stylesheet' href='static/styles.css'>")
-	builder.WriteString("<style>")
-	builder.WriteString(".error {color: red; font-weight: bold;}")      // 错误字段样式
-	builder.WriteString(".warning {color: orange; font-weight: bold;}") // 警告字段样式
-	builder.WriteString(".chart {height: auto; min-height: 400px;}")    // 添加自动高度，最小高度 400px
-	builder.WriteString("</style>")
-	builder.WriteString("<script src='https://cdn.jsdelivr.net/npm/chart.js'></script>") // 引入Chart.js库
-	builder.WriteString("</head>")
-	builder.WriteString("<body>")
-	builder.WriteString("<div class='container'>")
-
-	// 标题部分
-	builder.WriteString("<header><h1>" + pageTitle + "</h1></header>")
-
-	// 测试概览部分
-	builder.WriteString("<section class='report-summary'>")
-	builder.WriteString("<h2>测试概览</h2>")
-	builder.WriteString("<table>")
-	builder.WriteString("<tr><th>开始时间</th><td>" + time.Unix(stats["AvgTpsStartTime"].(int64), 0).Format("2006-01-02 15:04:05") + "</td></tr>")
-	builder.WriteString("<tr><th>结束时间</th><td>" + time.Unix(stats["AvgTpsEndTime"].(int64), 0).Format("2006-01-02 15:04:05") + "</td></tr>")
-	builder.WriteString("</table>")
-	builder.WriteString("</section>")
-
-	// 测试统计数据部分
-	builder.WriteString("<section class='test-statistics'>")
-	builder.WriteString("<h2>测试统计数据</h2>")
-	builder.WriteString("<table>")
-
-	// 统计数据列表，包括 SuccessRate
-	keys := []string{"TotalRequests", "SuccessCount", "FailureCount", "SuccessRate", "AvgResponseTime", "MaxResponseTime", "MinResponseTime", "TotalRunTime", "TPS", "SentDataPerSec", "ReceivedDataPerSec", "TotalSentData", "TotalReceivedData"}
-
-	for _, key := range keys {
-		value := stats[key]
-		class := ""
-
-		// 针对每个字段比较参考标准
-		for _, standard := range standards {
-			if standard.Field == key {
-				compareValue := standard.Compare(value)
-				if standard.Min > 0 && compareValue < standard.Min {
-					class = "error"
-				} else if standard.Max > 0 && compareValue > standard.Max {
-					class = "warning"
-				}
-			}
-		}
-
-		// 对 AvgResponseTime, MaxResponseTime, MinResponseTime, TotalRunTime 字段特殊处理，转换为毫秒并保留两位小数
-		if key == "AvgResponseTime" || key == "MaxResponseTime" || key == "MinResponseTime" || key == "TotalRunTime" {
-			value = fmt.Sprintf("%.2f ms", float64(value.(time.Duration))/float64(time.Millisecond))
-		}
-
-		// 对 SuccessRate 特殊处理，添加 % 符号
-		if key == "SuccessRate" {
-			value = fmt.Sprintf("%.3f%%", value)
-		}
-
-		// 生成数据行
-		builder.WriteString("<tr>")
-		builder.WriteString("<th>" + key + "</th>")
-		if class != "" {
-			builder.WriteString("<td class='" + class + "'>" + fmt.Sprintf("%v", value) + "</td>")
-		} else {
-			builder.WriteString("<td>" + fmt.Sprintf("%v", value) + "</td>")
-		}
-		builder.WriteString("</tr>")
+	var page strings.Builder
+	if err := tmpl.Execute(&page, newReportViewModel(stats, title, inline)); err != nil {
+		return "", fmt.Errorf("failed to execute report template: %v", err)
 	}
-
-	builder.WriteString("</table>")
-	builder.WriteString("</section>")
-
-	// 统计图部分 - 使用 <img> 标签嵌入 SVG 图像
-	builder.WriteString("<section class='charts'>")
-	builder.WriteString("<h2>视图展示</h2>")
-
-	// 添加TPS趋势图部分
-	builder.WriteString("<div class='chart'><h3>TPS趋势图</h3>")
-	// 使用iframe标签来嵌入tps_chart.html，并应用优化后的样式
-	builder.WriteString("<iframe class='tps-chart' src='static/tps_chart.html' frameborder='0'></iframe>")
-	builder.WriteString("</div>")
-
-	// 添加response_time_chart趋势图部分
-	builder.WriteString("<div class='chart'><h3>请求响应时间趋势图</h3>")
-	// 使用iframe标签来嵌入response_time_chart.html，并应用优化后的样式
-	builder.WriteString("<iframe class='tps-chart' src='static/response_time_chart.html' frameborder='0'></iframe>")
-	builder.WriteString("</div>")
-
-	// 添加response_time_chart趋势图部分
-	builder.WriteString("<div class='chart'><h3>网络流量趋势图</h3>")
-	// 使用iframe标签来嵌入flow_trend_chart.html，并应用优化后的样式
-	builder.WriteString("<iframe class='tps-chart' src='static/flow_trend_chart.html' frameborder='0'></iframe>")
-	builder.WriteString("</div>")
-	builder.WriteString("</section>")
-
-	// 分析部分
-	builder.WriteString("<section class='analysis'>")
-	builder.WriteString("<h2>分析</h2>")
-	builder.WriteString("<p>" + analysisContent + "</p>")
-	builder.WriteString("</section>")
-
-	builder.WriteString("<section class='analysis'>")
-	builder.WriteString("<h2>参考标准</h2>")
-	builder.WriteString("<p>参考标准：高频接口平均响应时应小于 1 秒，普通接口平均响应时间应低于 2.5 秒，请求成功率应大于 99%。</p>")
-	builder.WriteString("</section>")
-
-	builder.WriteString("<section class='reference-standards'>")
-	builder.WriteString("<h3>参考概念</h3>")
-
-	// 增加概念的外观样式，使其不那么密集
-	builder.WriteString("<div class='concept-card'><p><strong>TPS (Transactions Per Second)</strong>：指每秒钟能够处理的事务数。事务通常指一个完整的请求-响应周期，TPS 越高，说明系统的处理能力越强。常用于衡量系统的吞吐量。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>QPS (Queries Per Second)</strong>：指每秒钟能够处理的查询数。QPS 更侧重于查询操作的性能，通常用于数据库或搜索引擎的性能测试。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>平均响应时间 (Average Response Time)</strong>：指系统处理一个请求所需的平均时间。通常以毫秒为单位，响应时间越低，说明系统的性能越好。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>最大响应时间 (Max Response Time)</strong>：指系统处理请求时所出现的最长响应时间，通常用于衡量系统在高负载下的稳定性。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>最小响应时间 (Min Response Time)</strong>：指系统处理请求时所出现的最短响应时间。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>上行流量 (Outbound Traffic)</strong>：指从系统发送到客户端或其他服务器的数据量。通常与客户端发送请求的数据量有关。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>下行流量 (Inbound Traffic)</strong>：指从客户端或其他服务器接收的数据量。通常与系统返回响应的数据量有关。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>请求成功率 (Success Rate)</strong>：指成功处理的请求占总请求数的比例，通常以百分比表示。成功率越高，说明系统的稳定性越好。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>吞吐量 (Throughput)</strong>：指系统单位时间内处理的请求或数据量。吞吐量高意味着系统的处理能力强。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>并发数 (Concurrency)</strong>：指系统同时处理的请求数。高并发场景下，系统需要处理大量的同时请求，测试并发数可以评估系统的承载能力。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>响应时间分布 (Response Time Distribution)</strong>：指系统处理请求时响应时间的分布情况，通常会显示请求的响应时间在一定范围内的比例，用于衡量系统的稳定性。</p></div>")
-
-	builder.WriteString("<div class='concept-card'><p><strong>稳定性 (Stability)</strong>：指系统在持续负载下的表现能力。稳定性测试通常用于验证系统是否能够在长时间高负载的情况下正常工作。</p></div>")
-
-	builder.WriteString("</section>")
-
-	// 结束HTML
-	builder.WriteString("</div>")                                   // container
-	builder.WriteString("<script src='static/script.js'></script>") // 引入新的 JavaScript 文件
-	builder.WriteString("</body></html>")
-
-	// 返回生成的HTML内容
-	return builder.String()
+	return page.String(), nil
 }
 
 // generateCSS 生成默认的CSS样式
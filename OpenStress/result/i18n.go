@@ -0,0 +1,534 @@
+// i18n.go
+// 报告多语言模块
+// 本文件负责 HTML 报告、文本摘要报告和 Markdown 摘要的多语言文本，目前提供简体中文（zh-CN）和英文（en-US）两套文本。
+//
+// 技术实现细节：
+// 1. 每种语言一套以消息键索引的文本，缺失的键回退到默认语言（zh-CN），仍缺失时返回键本身，便于发现遗漏。
+// 2. 报告语言通过 CollectorConfig.Language 配置，GeneratePerformanceStats 将其写入 stats["Language"]，
+//    直接调用 GenerateHTMLReport 时也可以自行设置 stats["Language"]。
+// 3. 文本中的参数使用 fmt 占位符，由调用方传入格式化后的数值。
+
+package result
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Language 报告语言
+type Language string
+
+// 支持的报告语言
+const (
+	LanguageZhCN Language = "zh-CN"
+	LanguageEnUS Language = "en-US"
+)
+
+// DefaultLanguage 默认的报告语言
+const DefaultLanguage = LanguageZhCN
+
+// ParseLanguage 解析报告语言，大小写不敏感，支持 "zh"、"en" 简写和下划线分隔；空字符串返回默认语言
+func ParseLanguage(s string) (Language, error) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", "-")) {
+	case "":
+		return DefaultLanguage, nil
+	case "zh", "zh-cn":
+		return LanguageZhCN, nil
+	case "en", "en-us":
+		return LanguageEnUS, nil
+	}
+	return "", fmt.Errorf("unsupported report language %q (supported: %s, %s)", s, LanguageZhCN, LanguageEnUS)
+}
+
+// reportLanguage 返回统计结果中指定的报告语言，未指定或无法识别时使用默认语言
+func reportLanguage(stats map[string]interface{}) Language {
+	var name string
+	switch v := stats["Language"].(type) {
+	case Language:
+		name = string(v)
+	case string:
+		name = v
+	}
+	lang, err := ParseLanguage(name)
+	if err != nil {
+		return DefaultLanguage
+	}
+	return lang
+}
+
+// text 返回消息键对应的文本，args 不为空时按 fmt 格式化
+func (l Language) text(key string, args ...interface{}) string {
+	msg, ok := messages[l][key]
+	if !ok {
+		if msg, ok = messages[DefaultLanguage][key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// concepts 返回报告“参考概念”部分的概念卡片
+func (l Language) concepts() []concept {
+	if cards, ok := conceptCards[l]; ok {
+		return cards
+	}
+	return conceptCards[DefaultLanguage]
+}
+
+// concept 概念卡片
+type concept struct {
+	Name        string
+	Description string
+}
+
+// messages 各语言的报告文本
+var messages = map[Language]map[string]string{
+	LanguageZhCN: {
+		"html_lang":     "zh",
+		"default_title": "性能测试报告",
+		"colon":         "：",
+
+		"overview":   "测试概览",
+		"start_time": "开始时间",
+		"end_time":   "结束时间",
+		"statistics": "测试统计数据",
+
+		"percentiles":      "响应时间百分位",
+		"percentiles_note": "默认统计口径：%v；重试样本数：%v（共重试 %v 次）；限流等待总时长：%v",
+		"percentile":       "百分位",
+		"all_samples":      "全部样本",
+		"adjusted_samples": "排除重试与限流等待",
+
+		"latency_view_all":              "全部样本",
+		"latency_view_exclude_retries":  "排除重试样本",
+		"latency_view_exclude_throttle": "扣除限流等待",
+		"latency_view_exclude_both":     "排除重试样本，扣除限流等待",
+
+		"thresholds":       "阈值判定",
+		"threshold_rule":   "规则",
+		"threshold_actual": "实际值",
+		"threshold_result": "结果",
+		"threshold_passed": "通过",
+		"threshold_failed": "未通过",
+
+		"assertions":                       "内容断言",
+		"assertion":                        "断言",
+		"assertion_passed":                 "通过/总数",
+		"assertion_rate":                   "通过率",
+		"failed_samples":                   "失败请求明细",
+		"failed_time":                      "时间",
+		"failed_status":                    "状态码",
+		"failed_error":                     "错误信息",
+		"failed_trace_id":                  "追踪ID",
+		"failed_vu_iteration":              "VU/迭代",
+		"failure_payloads":                 "失败响应示例",
+		"failure_payload":                  "响应体",
+		"transactions":                     "事务统计",
+		"transaction_name":                 "事务",
+		"delivery":                         "消息投递延迟",
+		"tls_connections":                  "TLS 连接",
+		"tls_version":                      "TLS 版本",
+		"tls_cipher":                       "加密套件",
+		"tls_handshakes":                   "握手次数",
+		"tls_avg_handshake":                "平均握手耗时",
+		"tls_max_handshake":                "最长握手耗时",
+		"traffic_mix":                      "流量配比",
+		"traffic_mix_task":                 "任务",
+		"traffic_mix_weight":               "权重",
+		"traffic_mix_target":               "目标占比",
+		"traffic_mix_iterations":           "迭代次数",
+		"traffic_mix_actual":               "实际占比",
+		"trend":                            "历史趋势对比",
+		"trend_baseline":                   "与最近 %d 次运行的平均值比较",
+		"trend_metric":                     "指标",
+		"trend_baseline_value":             "基线",
+		"trend_actual":                     "本次",
+		"trend_change":                     "变化",
+		"trend_regressed":                  "回归",
+		"trend_ok":                         "正常",
+		"md_trend_ok":                      "没有指标相对基线回归",
+		"md_trend_regressed":               "%d 项指标相对基线回归",
+		"delivery_messages":                "投递消息数",
+		"delivery_throughput":              "投递吞吐量 (msg/s)",
+		"custom_metrics":                   "自定义指标",
+		"custom_metric_name":               "指标",
+		"custom_metric_type":               "类型",
+		"custom_metric_value":              "值",
+		"custom_metric_rate":               "速率",
+		"custom_metric_min":                "最小值",
+		"custom_metric_avg":                "平均值",
+		"custom_metric_max":                "最大值",
+		"custom_metric_counter":            "计数器",
+		"custom_metric_gauge":              "仪表",
+		"custom_metric_trend":              "趋势",
+		"stop_reason":                      "结束原因",
+		"seed":                             "随机种子",
+		"test_plan":                        "测试计划",
+		"openstress_version":               "OpenStress 版本",
+		"git_commit":                       "Git 提交",
+		"hostname":                         "主机名",
+		"os_arch":                          "操作系统",
+		"go_version":                       "Go 版本",
+		"gomaxprocs":                       "GOMAXPROCS / CPU 核数",
+		"cli_args":                         "命令行参数",
+		"simulated_bandwidth":              "模拟带宽",
+		"bandwidth_value":                  "上行 %s，下行 %s",
+		"bandwidth_unlimited":              "不限速",
+		"stop_reason_duration":             "达到测试时长",
+		"stop_reason_iterations":           "达到总迭代次数",
+		"stop_reason_iterations_per_vu":    "每个 VU 完成迭代次数",
+		"stop_reason_max_errors":           "达到最大错误数",
+		"stop_reason_external":             "外部停止",
+		"stop_reason_shutdown":             "协程池关闭",
+		"stop_reason_interrupted":          "收到中断信号",
+		"stop_reason_error_rate":           "错误率超出错误预算",
+		"stop_reason_consecutive_failures": "连续失败超出错误预算",
+		"aborted":                          "测试已提前中止",
+		"abort_time":                       "中止时间",
+		"abort_detail":                     "中止说明",
+		"md_aborted":                       "⚠️ 测试已提前中止：%s（%s）",
+		"charts":                           "视图展示",
+		"analysis":                         "分析",
+		"standards":                        "参考标准",
+		"standards_description":            "参考标准：高频接口平均响应时应小于 1 秒，普通接口平均响应时间应低于 2.5 秒，请求成功率应大于 99%。",
+		"concepts":                         "参考概念",
+
+		"tps_chart":                    "TPS趋势图",
+		"tps_chart_title":              "每秒事务数 (TPS)",
+		"tps_series_total":             "总 TPS",
+		"tps_series_success":           "成功 TPS",
+		"tps_series_failure":           "失败 TPS",
+		"response_time_chart":          "请求响应时间趋势图",
+		"response_time_chart_title":    "响应时间趋势 (ms)",
+		"response_time_series_total":   "平均响应时间",
+		"response_time_series_success": "成功请求平均响应时间",
+		"response_time_series_failure": "失败请求平均响应时间",
+		"flow_trend_chart":             "网络流量趋势图",
+		"flow_trend_chart_title":       "网络流量趋势 (byte)",
+		"flow_trend_series_sent":       "发送流量",
+		"flow_trend_series_received":   "接收流量",
+		"chart_duration":               "测试时间：%s 至 %s",
+		"chart_bucket":                 "（每点为 %d 秒内的%s）",
+		"chart_aggregation_max":        "最大值",
+		"chart_aggregation_avg":        "平均值",
+		"chart_aggregation_p95":        "P95",
+		"tps_latency_chart":            "吞吐量与响应时间",
+		"tps_latency_chart_title":      "吞吐量-响应时间关系",
+		"tps_latency_chart_subtitle":   "横轴为每秒请求数，共 %d 秒；响应时间开始陡增处即系统拐点",
+		"tps_latency_series_avg":       "平均响应时间",
+		"tps_latency_series_p95":       "P95 响应时间",
+		"resource_chart":               "压测机资源使用",
+		"resource_chart_title":         "压测机资源使用",
+		"resource_chart_sampled":       "采样时间：%s 至 %s",
+		"resource_axis_cpu":            "CPU %",
+		"resource_axis_memory":         "MB / goroutine 数",
+		"resource_series_cpu":          "CPU 使用率 (%)",
+		"resource_series_memory":       "内存 (MB)",
+		"resource_series_goroutines":   "goroutine 数量",
+		"resource_memory_leak":         "疑似内存泄漏 (+%.2f MB/min)",
+		"load_chart":                   "虚拟用户与队列",
+		"load_chart_title":             "活跃虚拟用户与队列深度",
+		"load_series_vus":              "活跃虚拟用户",
+		"load_series_queue":            "队列深度",
+		"phase_chart":                  "请求阶段耗时",
+		"phase_chart_title":            "请求阶段耗时 (ms)",
+		"phase_series_dns":             "DNS 解析",
+		"phase_series_connect":         "TCP 连接",
+		"phase_series_tls":             "TLS 握手",
+		"phase_series_server":          "服务端处理（至首字节）",
+		"phase_series_download":        "响应下载",
+		"delivery_chart":               "消息投递延迟趋势图",
+		"delivery_chart_title":         "消息投递延迟 (ms)",
+		"delivery_series_avg":          "平均投递延迟",
+		"delivery_series_max":          "最大投递延迟",
+		"breaker_chart":                "熔断状态图",
+		"breaker_chart_title":          "接口熔断状态",
+		"breaker_closed":               "闭合",
+		"breaker_half_open":            "半开",
+		"breaker_open":                 "断开",
+		"custom_metrics_chart":         "自定义指标趋势图",
+		"custom_metrics_chart_title":   "自定义指标",
+		"custom_metric_rate_series":    "%s（每秒）",
+
+		"analysis_success_high":    "本次测试的请求成功率非常高，达到了 %s%%，表明系统能够高效处理请求。",
+		"analysis_success_good":    "本次测试的请求成功率达到了 %s%%，系统表现良好，但仍有一定的优化空间。",
+		"analysis_success_low":     "本次测试的请求成功率为 %s%%，说明系统可能存在一定的瓶颈或故障，需要进一步排查。",
+		"analysis_response_fast":   "系统的平均响应时间非常低，达到了 %s 毫秒，符合高频接口的性能标准。",
+		"analysis_response_normal": "系统的平均响应时间为 %s 毫秒，符合普通接口的性能标准。",
+		"analysis_response_slow":   "系统的平均响应时间为 %s 毫秒，可能会影响用户体验，需要进一步优化。",
+		"analysis_tps_high":        "TPS（事务每秒）达到了 %s，说明系统能够承载较高的负载。",
+		"analysis_tps_medium":      "TPS（事务每秒）为 %s，系统能够处理中等负载的请求。",
+		"analysis_tps_low":         "TPS（事务每秒）为 %s，系统在高负载下的表现较为平缓，可能会有性能瓶颈。",
+		"analysis_data_flow":       "每秒发送的数据流量为 %s，每秒接收的数据流量为 %s，系统的数据吞吐量良好。",
+
+		"summary_title":                 "测试报告:",
+		"summary_total_requests":        "总请求数",
+		"summary_success_count":         "成功请求数",
+		"summary_failure_count":         "失败请求数",
+		"summary_timeout_count":         "超时请求数",
+		"summary_retries":               "重试次数（重试样本数）",
+		"summary_avg_response_time":     "平均响应时间",
+		"summary_max_response_time":     "最大响应时间",
+		"summary_min_response_time":     "最小响应时间",
+		"summary_total_run_time":        "总运行时间",
+		"summary_tps":                   "TPS",
+		"summary_sent_data_per_sec":     "每秒发送数据流量",
+		"summary_received_data_per_sec": "每秒接收数据流量",
+		"summary_total_sent_data":       "总发送数据量",
+		"summary_total_received_data":   "总接收数据量",
+
+		"md_metric":            "指标",
+		"md_value":             "数值",
+		"md_success_rate":      "成功率",
+		"md_top_errors":        "主要错误",
+		"md_count":             "次数",
+		"md_thresholds_passed": "全部阈值通过",
+		"md_thresholds_failed": "%d/%d 条阈值未通过",
+	},
+	LanguageEnUS: {
+		"html_lang":     "en",
+		"default_title": "Performance Test Report",
+		"colon":         ": ",
+
+		"overview":   "Test Overview",
+		"start_time": "Start Time",
+		"end_time":   "End Time",
+		"statistics": "Test Statistics",
+
+		"percentiles":      "Response Time Percentiles",
+		"percentiles_note": "Default view: %v; retried samples: %v (%v retries in total); total throttle wait: %v",
+		"percentile":       "Percentile",
+		"all_samples":      "All Samples",
+		"adjusted_samples": "Excluding Retries and Throttle Wait",
+
+		"latency_view_all":              "All samples",
+		"latency_view_exclude_retries":  "Retried samples excluded",
+		"latency_view_exclude_throttle": "Throttle wait deducted",
+		"latency_view_exclude_both":     "Retried samples excluded, throttle wait deducted",
+
+		"thresholds":       "Thresholds",
+		"threshold_rule":   "Rule",
+		"threshold_actual": "Actual",
+		"threshold_result": "Result",
+		"threshold_passed": "Passed",
+		"threshold_failed": "Failed",
+
+		"assertions":                       "Content Assertions",
+		"assertion":                        "Assertion",
+		"assertion_passed":                 "Passed/Total",
+		"assertion_rate":                   "Pass Rate",
+		"failed_samples":                   "Failed Requests",
+		"failed_time":                      "Time",
+		"failed_status":                    "Status Code",
+		"failed_error":                     "Error",
+		"failed_trace_id":                  "Trace ID",
+		"failed_vu_iteration":              "VU/Iteration",
+		"failure_payloads":                 "Failure Response Samples",
+		"failure_payload":                  "Response Body",
+		"transactions":                     "Transactions",
+		"transaction_name":                 "Transaction",
+		"delivery":                         "Message Delivery Latency",
+		"tls_connections":                  "TLS Connections",
+		"tls_version":                      "TLS Version",
+		"tls_cipher":                       "Cipher Suite",
+		"tls_handshakes":                   "Handshakes",
+		"tls_avg_handshake":                "Avg Handshake",
+		"tls_max_handshake":                "Max Handshake",
+		"traffic_mix":                      "Traffic Mix",
+		"traffic_mix_task":                 "Task",
+		"traffic_mix_weight":               "Weight",
+		"traffic_mix_target":               "Target Share",
+		"traffic_mix_iterations":           "Iterations",
+		"traffic_mix_actual":               "Actual Share",
+		"trend":                            "Trend vs. Baseline",
+		"trend_baseline":                   "Compared with the average of the last %d runs",
+		"trend_metric":                     "Metric",
+		"trend_baseline_value":             "Baseline",
+		"trend_actual":                     "This Run",
+		"trend_change":                     "Change",
+		"trend_regressed":                  "Regressed",
+		"trend_ok":                         "OK",
+		"md_trend_ok":                      "No regression against the baseline",
+		"md_trend_regressed":               "%d metric(s) regressed against the baseline",
+		"delivery_messages":                "Delivered Messages",
+		"delivery_throughput":              "Delivery Throughput (msg/s)",
+		"custom_metrics":                   "Custom Metrics",
+		"custom_metric_name":               "Metric",
+		"custom_metric_type":               "Type",
+		"custom_metric_value":              "Value",
+		"custom_metric_rate":               "Rate",
+		"custom_metric_min":                "Min",
+		"custom_metric_avg":                "Avg",
+		"custom_metric_max":                "Max",
+		"custom_metric_counter":            "Counter",
+		"custom_metric_gauge":              "Gauge",
+		"custom_metric_trend":              "Trend",
+		"stop_reason":                      "Stop Reason",
+		"seed":                             "Random Seed",
+		"test_plan":                        "Test Plan",
+		"openstress_version":               "OpenStress Version",
+		"git_commit":                       "Git Commit",
+		"hostname":                         "Hostname",
+		"os_arch":                          "OS",
+		"go_version":                       "Go Version",
+		"gomaxprocs":                       "GOMAXPROCS / CPUs",
+		"cli_args":                         "Command-line Arguments",
+		"simulated_bandwidth":              "Simulated Bandwidth",
+		"bandwidth_value":                  "upload %s, download %s",
+		"bandwidth_unlimited":              "unlimited",
+		"stop_reason_duration":             "Duration reached",
+		"stop_reason_iterations":           "Total iterations reached",
+		"stop_reason_iterations_per_vu":    "Every VU finished its iterations",
+		"stop_reason_max_errors":           "Max errors reached",
+		"stop_reason_external":             "Stopped externally",
+		"stop_reason_shutdown":             "Pool shut down",
+		"stop_reason_interrupted":          "Interrupted by signal",
+		"stop_reason_error_rate":           "Error rate exceeded the error budget",
+		"stop_reason_consecutive_failures": "Consecutive failures exceeded the error budget",
+		"aborted":                          "Test Aborted Early",
+		"abort_time":                       "Abort Time",
+		"abort_detail":                     "Abort Details",
+		"md_aborted":                       "⚠️ Test aborted early: %s (%s)",
+		"charts":                           "Charts",
+		"analysis":                         "Analysis",
+		"standards":                        "Reference Standards",
+		"standards_description":            "Reference standards: high-frequency endpoints should average under 1 second, regular endpoints under 2.5 seconds, and the success rate should be above 99%.",
+		"concepts":                         "Concepts",
+
+		"tps_chart":                    "TPS Trend",
+		"tps_chart_title":              "Transactions Per Second",
+		"tps_series_total":             "Total TPS",
+		"tps_series_success":           "Success TPS",
+		"tps_series_failure":           "Failure TPS",
+		"response_time_chart":          "Response Time Trend",
+		"response_time_chart_title":    "Response Time Over Time(ms)",
+		"response_time_series_total":   "Average Response Time",
+		"response_time_series_success": "Average Success Response Time",
+		"response_time_series_failure": "Average Failure Response Time",
+		"flow_trend_chart":             "Network Traffic Trend",
+		"flow_trend_chart_title":       "Flow Trend Over Time (byte)",
+		"flow_trend_series_sent":       "Sent Traffic",
+		"flow_trend_series_received":   "Received Traffic",
+		"chart_duration":               "Test Duration: %s to %s",
+		"chart_bucket":                 " (each point is the %[2]s of %[1]d seconds)",
+		"chart_aggregation_max":        "max",
+		"chart_aggregation_avg":        "average",
+		"chart_aggregation_p95":        "p95",
+		"tps_latency_chart":            "Latency vs Throughput",
+		"tps_latency_chart_title":      "Latency vs Throughput",
+		"tps_latency_chart_subtitle":   "X axis: requests per second over %d seconds; the knee is where latency starts to climb",
+		"tps_latency_series_avg":       "Average Response Time",
+		"tps_latency_series_p95":       "P95 Response Time",
+		"resource_chart":               "Load Generator Resource Usage",
+		"resource_chart_title":         "Load Generator Resource Usage",
+		"resource_chart_sampled":       "Sampled: %s to %s",
+		"resource_axis_cpu":            "CPU %",
+		"resource_axis_memory":         "MB / goroutines",
+		"resource_series_cpu":          "CPU Usage (%)",
+		"resource_series_memory":       "Memory (MB)",
+		"resource_series_goroutines":   "Goroutines",
+		"resource_memory_leak":         "Probable memory leak (+%.2f MB/min)",
+		"load_chart":                   "VUs + Queue",
+		"load_chart_title":             "Active VUs and Queue Depth",
+		"load_series_vus":              "Active VUs",
+		"load_series_queue":            "Queue Depth",
+		"phase_chart":                  "Request Phase Breakdown",
+		"phase_chart_title":            "Request Phase Breakdown (ms)",
+		"phase_series_dns":             "DNS Lookup",
+		"phase_series_connect":         "TCP Connect",
+		"phase_series_tls":             "TLS Handshake",
+		"phase_series_server":          "Server Wait (to first byte)",
+		"phase_series_download":        "Content Download",
+		"delivery_chart":               "Message Delivery Latency",
+		"delivery_chart_title":         "Message Delivery Latency (ms)",
+		"delivery_series_avg":          "Average Delivery Latency",
+		"delivery_series_max":          "Max Delivery Latency",
+		"breaker_chart":                "Circuit Breaker State",
+		"breaker_chart_title":          "Circuit Breaker State by Endpoint",
+		"breaker_closed":               "Closed",
+		"breaker_half_open":            "Half-open",
+		"breaker_open":                 "Open",
+		"custom_metrics_chart":         "Custom Metrics",
+		"custom_metrics_chart_title":   "Custom Metrics",
+		"custom_metric_rate_series":    "%s (per second)",
+
+		"analysis_success_high":    "The success rate was very high at %s%%, showing the system handled requests efficiently.",
+		"analysis_success_good":    "The success rate reached %s%%; the system performed well but there is still room for improvement.",
+		"analysis_success_low":     "The success rate was %s%%, which suggests a bottleneck or fault that needs further investigation.",
+		"analysis_response_fast":   "The average response time was very low at %s ms, meeting the standard for high-frequency endpoints.",
+		"analysis_response_normal": "The average response time was %s ms, meeting the standard for regular endpoints.",
+		"analysis_response_slow":   "The average response time was %s ms, which may hurt user experience and needs further optimization.",
+		"analysis_tps_high":        "TPS (transactions per second) reached %s, so the system can sustain a high load.",
+		"analysis_tps_medium":      "TPS (transactions per second) was %s, so the system can handle a moderate load.",
+		"analysis_tps_low":         "TPS (transactions per second) was %s; throughput flattens under high load and there may be a bottleneck.",
+		"analysis_data_flow":       "The system sent %s and received %s per second; data throughput looks healthy.",
+
+		"summary_title":                 "Test Report:",
+		"summary_total_requests":        "Total Requests",
+		"summary_success_count":         "Successful Requests",
+		"summary_failure_count":         "Failed Requests",
+		"summary_timeout_count":         "Timed-out Requests",
+		"summary_retries":               "Retries (Retried Samples)",
+		"summary_avg_response_time":     "Average Response Time",
+		"summary_max_response_time":     "Max Response Time",
+		"summary_min_response_time":     "Min Response Time",
+		"summary_total_run_time":        "Total Run Time",
+		"summary_tps":                   "TPS",
+		"summary_sent_data_per_sec":     "Data Sent Per Second",
+		"summary_received_data_per_sec": "Data Received Per Second",
+		"summary_total_sent_data":       "Total Data Sent",
+		"summary_total_received_data":   "Total Data Received",
+
+		"md_metric":            "Metric",
+		"md_value":             "Value",
+		"md_success_rate":      "Success Rate",
+		"md_top_errors":        "Top Errors",
+		"md_count":             "Count",
+		"md_thresholds_passed": "All thresholds passed",
+		"md_thresholds_failed": "%d of %d thresholds failed",
+	},
+}
+
+// conceptCards 各语言的参考概念
+var conceptCards = map[Language][]concept{
+	LanguageZhCN: {
+		{"TPS (Transactions Per Second)", "指每秒钟能够处理的事务数。事务通常指一个完整的请求-响应周期，TPS 越高，说明系统的处理能力越强。常用于衡量系统的吞吐量。"},
+		{"QPS (Queries Per Second)", "指每秒钟能够处理的查询数。QPS 更侧重于查询操作的性能，通常用于数据库或搜索引擎的性能测试。"},
+		{"平均响应时间 (Average Response Time)", "指系统处理一个请求所需的平均时间。通常以毫秒为单位，响应时间越低，说明系统的性能越好。"},
+		{"最大响应时间 (Max Response Time)", "指系统处理请求时所出现的最长响应时间，通常用于衡量系统在高负载下的稳定性。"},
+		{"最小响应时间 (Min Response Time)", "指系统处理请求时所出现的最短响应时间。"},
+		{"上行流量 (Outbound Traffic)", "指从系统发送到客户端或其他服务器的数据量。通常与客户端发送请求的数据量有关。"},
+		{"下行流量 (Inbound Traffic)", "指从客户端或其他服务器接收的数据量。通常与系统返回响应的数据量有关。"},
+		{"请求成功率 (Success Rate)", "指成功处理的请求占总请求数的比例，通常以百分比表示。成功率越高，说明系统的稳定性越好。"},
+		{"吞吐量 (Throughput)", "指系统单位时间内处理的请求或数据量。吞吐量高意味着系统的处理能力强。"},
+		{"并发数 (Concurrency)", "指系统同时处理的请求数。高并发场景下，系统需要处理大量的同时请求，测试并发数可以评估系统的承载能力。"},
+		{"响应时间分布 (Response Time Distribution)", "指系统处理请求时响应时间的分布情况，通常会显示请求的响应时间在一定范围内的比例，用于衡量系统的稳定性。"},
+		{"稳定性 (Stability)", "指系统在持续负载下的表现能力。稳定性测试通常用于验证系统是否能够在长时间高负载的情况下正常工作。"},
+	},
+	LanguageEnUS: {
+		{"TPS (Transactions Per Second)", "The number of transactions processed per second. A transaction is usually one complete request-response cycle; a higher TPS means more processing capacity. Commonly used to measure throughput."},
+		{"QPS (Queries Per Second)", "The number of queries processed per second. QPS focuses on query performance and is typically used when testing databases or search engines."},
+		{"Average Response Time", "The average time the system takes to handle one request, usually in milliseconds. Lower is better."},
+		{"Max Response Time", "The longest response time observed, often used to judge stability under high load."},
+		{"Min Response Time", "The shortest response time observed."},
+		{"Outbound Traffic", "The amount of data sent from the system to clients or other servers, usually related to the size of the requests sent."},
+		{"Inbound Traffic", "The amount of data received from clients or other servers, usually related to the size of the responses returned."},
+		{"Success Rate", "The share of requests that succeeded, as a percentage. A higher success rate means a more stable system."},
+		{"Throughput", "The number of requests or amount of data processed per unit of time. Higher throughput means more processing capacity."},
+		{"Concurrency", "The number of requests handled at the same time. Testing concurrency shows how much simultaneous load the system can carry."},
+		{"Response Time Distribution", "How response times are spread, usually shown as the share of requests within each range; used to judge stability."},
+		{"Stability", "How the system behaves under sustained load. Stability tests check that the system keeps working under high load for a long time."},
+	},
+}
+
+// stopReasonText 返回结束原因的本地化描述，未知原因原样返回
+func stopReasonText(reason string, lang Language) string {
+	key := "stop_reason_" + reason
+	if text := lang.text(key); text != key {
+		return text
+	}
+	return reason
+}
@@ -1,112 +1,67 @@
 package result
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
-// adjustXAxisPoints 用于按平均间隔截取 20 个中间时间点，并根据这些时间点返回对应的 Y 轴数值
-// values 数组表示从 startTime 到 endTime 之间每秒的数据，按顺序对应
-func adjustXAxisPoints(startTime, endTime time.Time, values []int) ([]string, []int) {
-	// 如果传入的 values 数组为空，返回错误
-	if len(values) == 0 {
-		fmt.Println("Error: values array is empty")
-		return nil, nil
-	}
-
-	// 目标是从 startTime 到 endTime 之间均匀切割成 20 段，得到 20 个中间点和 21 个边界点
-	numSegments := 20
-
-	// 计算总时间间隔（秒）
-	timeInterval := endTime.Sub(startTime).Seconds()
-
-	// 计算每段的时间间隔
-	segmentTime := timeInterval / float64(numSegments)
+// ErrEmptySeries 图表的数据序列为空，例如测试还没有结果或统计结果中缺少该序列
+var ErrEmptySeries = errors.New("chart series is empty")
 
-	// 创建 xAxis 和 yAxis 数组
-	xAxis := make([]string, numSegments) // 存储 20 个中间点时间
-	yAxis := make([]int, numSegments+1)  // 存储 21 个边界点对应的值
-
-	// 均匀切割时间，获取边界时间点和中间时间点
-	for i := 0; i < numSegments; i++ {
-		// 计算每个段的边界时间点
-		startSegment := startTime.Add(time.Second * time.Duration(float64(i)*segmentTime))
-		endSegment := startTime.Add(time.Second * time.Duration(float64(i+1)*segmentTime))
-
-		// 将边界点对应的值存储到 yAxis 数组中
-		startSegmentIndex := int(startSegment.Sub(startTime).Seconds()) // 计算边界点在 values 数组中的索引
-		endSegmentIndex := int(endSegment.Sub(startTime).Seconds())     // 同上
-
-		// 边界时间点对应的值
-		yAxis[i] = values[startSegmentIndex]
-		if i == numSegments-1 {
-			yAxis[i+1] = values[endSegmentIndex] // 最后一个边界点
-		}
-
-		// 计算中间点时间，存储在 xAxis 中
-		middleTime := startSegment.Add(time.Second * time.Duration(segmentTime/2))
-		xAxis[i] = middleTime.Format("15:04:05") // 转换为 "HH:MM:SS" 格式
-	}
+// ChartError 图表构建失败的详细信息
+type ChartError struct {
+	Chart  string // 图表名，例如 tps_chart
+	Series string // 出错的数据序列
+	Err    error  // 失败原因
+}
 
-	// 最后一个边界点对应的值
-	yAxis[numSegments] = values[int(endTime.Sub(startTime).Seconds())]
+// Error 实现 error 接口
+func (e *ChartError) Error() string {
+	return fmt.Sprintf("failed to build %s: series %s: %v", e.Chart, e.Series, e.Err)
+}
 
-	// 返回 xAxis 和 yAxis
-	return xAxis, yAxis
+// Unwrap 返回失败原因，便于使用 errors.Is 判断
+func (e *ChartError) Unwrap() error {
+	return e.Err
 }
 
-func GenerateTpsChartAsync(tpsValues []int, successValues []int, failureValues []int, startTime int64, endTime int64, dir string) (string, error) {
+// newTpsChart 创建 TPS 趋势图
+func newTpsChart(tpsValues []int, successValues []int, failureValues []int, startTime int64, endTime int64, options chartOptions, lang Language) (*charts.Line, error) {
 	// 将 time.Unix 转换为 time.Time 类型
 	startTimeTime := time.Unix(startTime, 0)
 	endTimeTime := time.Unix(endTime, 0)
 
-	// 调整横坐标点数并获取调整后的数据
-	xAxis, tpsValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, tpsValues)
-	if xAxis == nil || len(tpsValuesAdjusted) == 0 {
-		fmt.Println("Error: Failed to adjust xAxis or tpsValues")
-		return "", fmt.Errorf("failed to adjust xAxis or tpsValues")
+	// 按桶聚合每秒的数据，长时间测试中保留短暂的毛刺
+	xAxis, tpsValuesAdjusted, err := options.downsample(startTimeTime, tpsValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "tps_chart", Series: "TPSValues", Err: err}
 	}
 
-	_, successValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, successValues)
-	if len(successValuesAdjusted) == 0 {
-		fmt.Println("Error: Failed to adjust successValues")
-		return "", fmt.Errorf("failed to adjust successValues")
+	_, successValuesAdjusted, err := options.downsample(startTimeTime, successValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "tps_chart", Series: "SuccessValues", Err: err}
 	}
 
-	_, failureValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, failureValues)
-	if len(failureValuesAdjusted) == 0 {
-		fmt.Println("Error: Failed to adjust failureValues")
-		return "", fmt.Errorf("failed to adjust failureValues")
+	_, failureValuesAdjusted, err := options.downsample(startTimeTime, failureValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "tps_chart", Series: "FailureValues", Err: err}
 	}
 
 	// 创建折线图对象
 	line := charts.NewLine()
-	if line == nil {
-		fmt.Println("Error: Failed to create line chart object")
-		return "", fmt.Errorf("failed to create line chart object")
-	}
-
-	// // 打印调整后的数据
-	// fmt.Println("Adjusted TPS Values:", tpsValuesAdjusted)
-	// fmt.Println("Adjusted Success Values:", successValuesAdjusted)
-	// fmt.Println("Adjusted Failure Values:", failureValuesAdjusted)
-
 	line.SetXAxis(xAxis)
-	// line.SetXAxis([]string{"14_21_36", "14_21_39", "14_21_43", "Thu", "Fri", "Sat", "Sun", "exoi", "8", "9"})
-	// 添加数据系列
-	line.AddSeries("Total TPS", generateLineData(tpsValuesAdjusted))
-	if err := checkError("Failed to add Total TPS series"); err != nil {
-		return "", err
-	}
 
-	// 取消注释以启用其他数据系列
-	line.AddSeries("Success TPS", generateLineData(successValuesAdjusted))
-	line.AddSeries("Failure TPS", generateLineData(failureValuesAdjusted))
+	// 添加数据系列
+	line.AddSeries(lang.text("tps_series_total"), generateLineData(tpsValuesAdjusted))
+	line.AddSeries(lang.text("tps_series_success"), generateLineData(successValuesAdjusted))
+	line.AddSeries(lang.text("tps_series_failure"), generateLineData(failureValuesAdjusted))
 
 	// 打印生成的数据
 	// fmt.Println("Y轴数据:", generateLineData(tpsValuesAdjusted))
@@ -118,77 +73,43 @@ func GenerateTpsChartAsync(tpsValues []int, successValues []int, failureValues [
 
 	// 设置全局选项
 	line.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title:    "Transactions Per Second",
-		Subtitle: fmt.Sprintf("Test Duration: %s to %s", startTimeTime.Format("15:04:05"), endTimeTime.Format("15:04:05")),
+		Title:    lang.text("tps_chart_title"),
+		Subtitle: options.subtitle(lang.text("chart_duration", startTimeTime.Format("15:04:05"), endTimeTime.Format("15:04:05")), len(tpsValues), lang),
 	}), charts.WithLegendOpts(opts.Legend{
 		Bottom: "bottom", // 设置图例的位置，可以是 "top"、"bottom"、"left"、"right"
 	}))
 
-	// 获取渲染的 HTML 内容（不需要通过 io.Writer）
-	htmlContent := line.RenderContent()
-	if htmlContent == nil {
-		fmt.Println("Error: Failed to render chart content")
-		return "", fmt.Errorf("failed to render chart content")
-	}
-
-	// 打印渲染后的 HTML 内容
-	// fmt.Println("Rendered HTML Content:")
-	// fmt.Println(string(htmlContent)) // 打印整个 HTML 内容
-
-	// 生成 HTML 文件路径
-	htmlFilePath := filepath.Join(dir, "/tps_chart.html")
-
-	// 创建文件并检查错误
-	htmlFile, err := os.Create(htmlFilePath)
-	if err != nil {
-		fmt.Printf("Error creating HTML file: %v\n", err)
-		return "", fmt.Errorf("failed to create HTML file: %v", err)
-	}
-	defer func() {
-		if cerr := htmlFile.Close(); cerr != nil {
-			fmt.Printf("Error closing HTML file: %v\n", cerr)
-		}
-	}()
-
-	// 将渲染的 HTML 内容写入文件
-	_, err = htmlFile.Write(htmlContent)
-	if err != nil {
-		fmt.Printf("Error writing HTML content to file: %v\n", err)
-		return "", fmt.Errorf("failed to write HTML content to file: %v", err)
-	}
-
-	// 返回 HTML 文件路径
-	return htmlFilePath, nil
+	return line, nil
 }
 
-// 辅助函数：用于检查错误并打印相应的错误信息
-func checkError(msg string) error {
-	if r := recover(); r != nil {
-		fmt.Printf("Error: %s: %v\n", msg, r)
-		return fmt.Errorf("%s: %v", msg, r)
+func GenerateTpsChartAsync(tpsValues []int, successValues []int, failureValues []int, startTime int64, endTime int64, dir string) (string, error) {
+	line, err := newTpsChart(tpsValues, successValues, failureValues, startTime, endTime, defaultChartOptions, DefaultLanguage)
+	if err != nil {
+		return "", err
 	}
-	return nil
+	return writeChartHTML(line, filepath.Join(dir, "tps_chart.html"))
 }
 
-func GenerateResponseTimeChartAsync(avgResponseTimeValues []int, avgSuccessResponseTimeValues []int, avgFailureResponseTimeValues []int, avgResponseStartTime int64, avgResponseEndTime int64, dir string) (string, error) {
+// newResponseTimeChart 创建请求响应时间趋势图
+func newResponseTimeChart(avgResponseTimeValues []int, avgSuccessResponseTimeValues []int, avgFailureResponseTimeValues []int, avgResponseStartTime int64, avgResponseEndTime int64, options chartOptions, lang Language) (*charts.Line, error) {
 	// 将 time.Unix 转换为 time.Time 类型
 	startTimeTime := time.Unix(avgResponseStartTime, 0)
 	endTimeTime := time.Unix(avgResponseEndTime, 0)
 
-	// 调整横坐标点数并获取调整后的数据
-	xAxis, avgResponseTimeValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, avgResponseTimeValues)
-	if len(avgResponseTimeValuesAdjusted) == 0 {
-		return "", fmt.Errorf("failed to adjust avgResponseTimeValues")
+	// 按桶聚合每秒的数据，长时间测试中保留短暂的毛刺
+	xAxis, avgResponseTimeValuesAdjusted, err := options.downsample(startTimeTime, avgResponseTimeValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "response_time_chart", Series: "AvgResponseTimeValues", Err: err}
 	}
 
-	_, avgSuccessResponseTimeValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, avgSuccessResponseTimeValues)
-	if len(avgSuccessResponseTimeValuesAdjusted) == 0 {
-		return "", fmt.Errorf("failed to adjust avgSuccessResponseTimeValues")
+	_, avgSuccessResponseTimeValuesAdjusted, err := options.downsample(startTimeTime, avgSuccessResponseTimeValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "response_time_chart", Series: "AvgSuccessResponseTimeValues", Err: err}
 	}
 
-	_, avgFailureResponseTimeValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, avgFailureResponseTimeValues)
-	if len(avgFailureResponseTimeValuesAdjusted) == 0 {
-		return "", fmt.Errorf("failed to adjust avgFailureResponseTimeValues")
+	_, avgFailureResponseTimeValuesAdjusted, err := options.downsample(startTimeTime, avgFailureResponseTimeValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "response_time_chart", Series: "AvgFailureResponseTimeValues", Err: err}
 	}
 
 	// 创建折线图对象
@@ -211,68 +132,43 @@ func GenerateResponseTimeChartAsync(avgResponseTimeValues []int, avgSuccessRespo
 	// fmt.Println("Y轴数据长度:", len(generateLineData(avgFailureResponseTimeValuesAdjusted)))
 
 	// 添加数据系列
-	line.AddSeries("Average Response Time", generateLineData(avgResponseTimeValuesAdjusted))
-	line.AddSeries("Average Success Response Time", generateLineData(avgSuccessResponseTimeValuesAdjusted))
-	line.AddSeries("Average Failure Response Time", generateLineData(avgFailureResponseTimeValuesAdjusted))
+	line.AddSeries(lang.text("response_time_series_total"), generateLineData(avgResponseTimeValuesAdjusted))
+	line.AddSeries(lang.text("response_time_series_success"), generateLineData(avgSuccessResponseTimeValuesAdjusted))
+	line.AddSeries(lang.text("response_time_series_failure"), generateLineData(avgFailureResponseTimeValuesAdjusted))
 
 	// 设置全局选项
 	line.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title:    "Response Time Over Time(ms)",
-		Subtitle: fmt.Sprintf("Test Duration: %s to %s", startTimeTime.Format("15:04:05"), endTimeTime.Format("15:04:05")),
+		Title:    lang.text("response_time_chart_title"),
+		Subtitle: options.subtitle(lang.text("chart_duration", startTimeTime.Format("15:04:05"), endTimeTime.Format("15:04:05")), len(avgResponseTimeValues), lang),
 	}), charts.WithLegendOpts(opts.Legend{
 		Bottom: "bottom", // 设置图例的位置，可以是 "top"、"bottom"、"left"、"right"
 	}))
 
-	// 获取渲染的 HTML 内容（不需要通过 io.Writer）
-	htmlContent := line.RenderContent()
-	if htmlContent == nil {
-		fmt.Println("Error: Failed to render chart content")
-		return "", fmt.Errorf("failed to render chart content")
-	}
-
-	// 打印渲染后的 HTML 内容
-	// fmt.Println("Rendered HTML Content:")
-	// fmt.Println(string(htmlContent)) // 打印整个 HTML 内容
-
-	// 生成 HTML 文件路径
-	htmlFilePath := filepath.Join(dir, "response_time_chart.html")
-	// fmt.Println("HTML 文件路径:", htmlFilePath)
-
-	// 创建文件并检查错误
-	htmlFile, err := os.Create(htmlFilePath)
-	if err != nil {
-		fmt.Printf("Error creating HTML file: %v\n", err)
-		return "", fmt.Errorf("failed to create HTML file: %v", err)
-	}
-	defer func() {
-		if cerr := htmlFile.Close(); cerr != nil {
-			fmt.Printf("Error closing HTML file: %v\n", cerr)
-		}
-	}()
+	return line, nil
+}
 
-	// 将渲染的 HTML 内容写入文件
-	_, err = htmlFile.Write(htmlContent)
+func GenerateResponseTimeChartAsync(avgResponseTimeValues []int, avgSuccessResponseTimeValues []int, avgFailureResponseTimeValues []int, avgResponseStartTime int64, avgResponseEndTime int64, dir string) (string, error) {
+	line, err := newResponseTimeChart(avgResponseTimeValues, avgSuccessResponseTimeValues, avgFailureResponseTimeValues, avgResponseStartTime, avgResponseEndTime, defaultChartOptions, DefaultLanguage)
 	if err != nil {
-		fmt.Printf("Error writing HTML content to file: %v\n", err)
-		return "", fmt.Errorf("failed to write HTML content to file: %v", err)
+		return "", err
 	}
-
-	// 返回 HTML 文件路径
-	return htmlFilePath, nil
+	return writeChartHTML(line, filepath.Join(dir, "response_time_chart.html"))
 }
 
-func GenerateFlowTrendChartAsync(avgSentTrafficValues []int, avgReceivedTrafficValues []int, avgTrafficStartTime int64, avgTrafficEndTime int64, dir string) (string, error) {
+// newFlowTrendChart 创建网络流量趋势图
+func newFlowTrendChart(avgSentTrafficValues []int, avgReceivedTrafficValues []int, avgTrafficStartTime int64, avgTrafficEndTime int64, options chartOptions, lang Language) (*charts.Line, error) {
 	// 将 time.Unix 转换为 time.Time 类型
 	startTimeTime := time.Unix(avgTrafficStartTime, 0)
 	endTimeTime := time.Unix(avgTrafficEndTime, 0)
 
-	// 调整横坐标点数
-	_, avgSentTrafficValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, avgSentTrafficValues)
-	xAxis, avgReceivedTrafficValuesAdjusted := adjustXAxisPoints(startTimeTime, endTimeTime, avgReceivedTrafficValues)
-
-	// 检查数据是否为空
-	if len(avgSentTrafficValuesAdjusted) == 0 || len(avgReceivedTrafficValuesAdjusted) == 0 {
-		return "", fmt.Errorf("failed to adjust traffic values")
+	// 按桶聚合每秒的数据
+	_, avgSentTrafficValuesAdjusted, err := options.downsample(startTimeTime, avgSentTrafficValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "flow_trend_chart", Series: "AvgSentTrafficValues", Err: err}
+	}
+	xAxis, avgReceivedTrafficValuesAdjusted, err := options.downsample(startTimeTime, avgReceivedTrafficValues)
+	if err != nil {
+		return nil, &ChartError{Chart: "flow_trend_chart", Series: "AvgReceivedTrafficValues", Err: err}
 	}
 
 	// 创建折线图对象
@@ -284,8 +180,8 @@ func GenerateFlowTrendChartAsync(avgSentTrafficValues []int, avgReceivedTrafficV
 	// fmt.Println("X轴数据长度:", len(xAxis))
 
 	// 添加数据系列
-	line.AddSeries("Sent Traffic", generateLineData(avgSentTrafficValuesAdjusted))
-	line.AddSeries("Received Traffic", generateLineData(avgReceivedTrafficValuesAdjusted))
+	line.AddSeries(lang.text("flow_trend_series_sent"), generateLineData(avgSentTrafficValuesAdjusted))
+	line.AddSeries(lang.text("flow_trend_series_received"), generateLineData(avgReceivedTrafficValuesAdjusted))
 
 	// fmt.Println("Y轴数据:", generateLineData(avgSentTrafficValuesAdjusted))
 	// fmt.Println("Y轴数据长度:", len(generateLineData(avgSentTrafficValuesAdjusted)))
@@ -296,45 +192,197 @@ func GenerateFlowTrendChartAsync(avgSentTrafficValues []int, avgReceivedTrafficV
 	// 设置全局选项
 	line.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Flow Trend Over Time (byte)",
-			Subtitle: fmt.Sprintf("Test Duration: %s to %s", startTimeTime.Format("15:04:05"), endTimeTime.Format("15:04:05")),
+			Title:    lang.text("flow_trend_chart_title"),
+			Subtitle: options.subtitle(lang.text("chart_duration", startTimeTime.Format("15:04:05"), endTimeTime.Format("15:04:05")), len(avgReceivedTrafficValues), lang),
 		}),
 		charts.WithLegendOpts(opts.Legend{
 			Bottom: "bottom", // 设置图例位置
 		}),
 	)
 
-	// 获取渲染的 HTML 内容
-	htmlContent := line.RenderContent()
-	if htmlContent == nil {
-		return "", fmt.Errorf("failed to render chart content")
+	return line, nil
+}
+
+func GenerateFlowTrendChartAsync(avgSentTrafficValues []int, avgReceivedTrafficValues []int, avgTrafficStartTime int64, avgTrafficEndTime int64, dir string) (string, error) {
+	line, err := newFlowTrendChart(avgSentTrafficValues, avgReceivedTrafficValues, avgTrafficStartTime, avgTrafficEndTime, defaultChartOptions, DefaultLanguage)
+	if err != nil {
+		return "", err
 	}
+	return writeChartHTML(line, filepath.Join(dir, "flow_trend_chart.html"))
+}
 
-	// 打印渲染后的 HTML 内容
-	// fmt.Println("Rendered HTML Content:")
-	// fmt.Println(string(htmlContent)) // 打印整个 HTML 内容
+// maxThroughputBuckets 吞吐量-响应时间图横轴的最大点数，TPS 取值较多时按区间合并
+const maxThroughputBuckets = 50
 
-	// 生成 HTML 文件路径
-	htmlFilePath := filepath.Join(dir, "flow_trend_chart.html")
-	// fmt.Println("HTML 文件路径:", htmlFilePath)
+// minThroughputLevels 至少有这么多种不同的 TPS 时才生成吞吐量-响应时间图，负载不变时图中只有一个点，没有分析价值
+const minThroughputLevels = 3
 
-	// 创建文件并检查错误
-	htmlFile, err := os.Create(htmlFilePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create HTML file: %v", err)
+// throughputLevels 返回样本中不同 TPS 的数量
+func throughputLevels(samples []ThroughputSample) int {
+	levels := make(map[int]bool)
+	for _, s := range samples {
+		levels[s.TPS] = true
+	}
+	return len(levels)
+}
+
+// newLatencyThroughputChart 创建吞吐量-响应时间关系图：横轴为每秒请求数（从低到高），纵轴为这些秒的平均和 P95 响应时间，
+// TPS 继续上升而响应时间开始陡增的位置即被测系统的拐点。
+// 同一 TPS 区间内平均响应时间按请求数加权，P95 取各秒 P95 的平均值
+func newLatencyThroughputChart(samples []ThroughputSample, lang Language) (*charts.Line, error) {
+	if len(samples) == 0 {
+		return nil, &ChartError{Chart: "tps_latency_chart", Series: "ThroughputSamples", Err: ErrEmptySeries}
+	}
+
+	minTPS, maxTPS := samples[0].TPS, samples[0].TPS
+	for _, s := range samples {
+		minTPS, maxTPS = min(minTPS, s.TPS), max(maxTPS, s.TPS)
+	}
+	width := 1
+	if levels := throughputLevels(samples); levels > maxThroughputBuckets {
+		width = (maxTPS - minTPS + maxThroughputBuckets) / maxThroughputBuckets
 	}
-	defer func() {
-		if cerr := htmlFile.Close(); cerr != nil {
-			fmt.Printf("Error closing HTML file: %v\n", cerr)
+
+	type bucket struct {
+		requests int
+		weighted float64 // 平均响应时间（毫秒）× 请求数
+		p95      float64 // 各秒 P95（毫秒）之和
+		seconds  int
+	}
+	buckets := make(map[int]*bucket)
+	for _, s := range samples {
+		index := (s.TPS - minTPS) / width
+		b := buckets[index]
+		if b == nil {
+			b = &bucket{}
+			buckets[index] = b
 		}
-	}()
+		b.requests += s.TPS
+		b.weighted += float64(s.AvgResponseTime) / float64(time.Millisecond) * float64(s.TPS)
+		b.p95 += float64(s.P95ResponseTime) / float64(time.Millisecond)
+		b.seconds++
+	}
+	indexes := make([]int, 0, len(buckets))
+	for index := range buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	xAxis := make([]string, 0, len(indexes))
+	avgData := make([]opts.LineData, 0, len(indexes))
+	p95Data := make([]opts.LineData, 0, len(indexes))
+	for _, index := range indexes {
+		b := buckets[index]
+		low := minTPS + index*width
+		if width == 1 {
+			xAxis = append(xAxis, fmt.Sprint(low))
+		} else {
+			xAxis = append(xAxis, fmt.Sprintf("%d-%d", low, low+width-1))
+		}
+		avgData = append(avgData, opts.LineData{Value: fmt.Sprintf("%.2f", b.weighted/float64(b.requests))})
+		p95Data = append(p95Data, opts.LineData{Value: fmt.Sprintf("%.2f", b.p95/float64(b.seconds))})
+	}
 
-	// 将渲染的 HTML 内容写入文件
-	_, err = htmlFile.Write(htmlContent)
-	if err != nil {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("tps_latency_chart_title"),
+			Subtitle: lang.text("tps_latency_chart_subtitle", len(samples)),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+		charts.WithXAxisOpts(opts.XAxis{Name: "TPS"}),
+		charts.WithYAxisOpts(opts.YAxis{Name: "ms"}),
+	)
+	line.SetXAxis(xAxis)
+	line.AddSeries(lang.text("tps_latency_series_avg"), avgData)
+	line.AddSeries(lang.text("tps_latency_series_p95"), p95Data)
+
+	return line, nil
+}
+
+// writeChartHTML 将图表渲染为独立的 HTML 页面并写入 path
+// 页面从同目录的 assets/ 加载 echarts 脚本，需要先调用 writeReportAssets 写出资源
+func writeChartHTML(line *charts.Line, path string) (string, error) {
+	line.AssetsHost = chartAssetsHost
+	htmlContent := line.RenderContent()
+	if htmlContent == nil {
+		return "", fmt.Errorf("failed to render chart content")
+	}
+	if err := os.WriteFile(path, htmlContent, 0644); err != nil {
 		return "", fmt.Errorf("failed to write HTML content to file: %v", err)
 	}
+	return path, nil
+}
+
+// buildReportCharts 根据统计结果构建报告中的各张图表，返回图表名到图表的映射
+// 图表文本使用 stats["Language"] 指定的语言，没有数据或构建失败的图表不包含在结果中，
+// 失败原因（*ChartError）合并后返回
+func buildReportCharts(stats map[string]interface{}) (map[string]*charts.Line, error) {
+	lang := reportLanguage(stats)
+	options := reportChartOptions(stats)
+	tpsStart, _ := stats["AvgTpsStartTime"].(int64)
+	tpsEnd, _ := stats["AvgTpsEndTime"].(int64)
+	responseStart, _ := stats["AvgResponseStartTime"].(int64)
+	responseEnd, _ := stats["AvgResponseEndTime"].(int64)
+	trafficStart, _ := stats["AvgTrafficStartTime"].(int64)
+	trafficEnd, _ := stats["AvgTrafficEndTime"].(int64)
+
+	builders := map[string]func() (*charts.Line, error){
+		"tps_chart": func() (*charts.Line, error) {
+			return newTpsChart(intSliceStat(stats, "TPSValues"), intSliceStat(stats, "SuccessValues"),
+				intSliceStat(stats, "FailureValues"), tpsStart, tpsEnd, options, lang)
+		},
+		"response_time_chart": func() (*charts.Line, error) {
+			return newResponseTimeChart(intSliceStat(stats, "AvgResponseTimeValues"), intSliceStat(stats, "AvgSuccessResponseTimeValues"),
+				intSliceStat(stats, "AvgFailureResponseTimeValues"), responseStart, responseEnd, options, lang)
+		},
+		"flow_trend_chart": func() (*charts.Line, error) {
+			return newFlowTrendChart(intSliceStat(stats, "AvgSentTrafficValues"), intSliceStat(stats, "AvgReceivedTrafficValues"),
+				trafficStart, trafficEnd, options, lang)
+		},
+	}
+	if samples, ok := stats["ResourceSamples"].([]ResourceSample); ok && len(samples) > 0 {
+		leaks, _ := stats["MemoryLeaks"].([]MemoryLeak)
+		builders["resource_chart"] = func() (*charts.Line, error) { return newResourceChart(samples, leaks, lang) }
+	}
+	if samples, ok := stats["LoadSamples"].([]LoadSample); ok && len(samples) > 0 {
+		builders["load_chart"] = func() (*charts.Line, error) { return newLoadChart(samples, lang) }
+	}
+	if samples, ok := stats["ThroughputSamples"].([]ThroughputSample); ok && throughputLevels(samples) >= minThroughputLevels {
+		builders["tps_latency_chart"] = func() (*charts.Line, error) { return newLatencyThroughputChart(samples, lang) }
+	}
+	if breakdown, ok := stats["PhaseBreakdown"].([]PhaseSample); ok && len(breakdown) > 0 {
+		builders["phase_chart"] = func() (*charts.Line, error) { return newPhaseChart(breakdown, lang) }
+	}
+	if samples, ok := stats["DeliveryLatency"].([]DeliverySample); ok && len(samples) > 0 {
+		builders["delivery_chart"] = func() (*charts.Line, error) { return newDeliveryChart(samples, lang) }
+	}
+
+	if transitions, ok := stats["BreakerTransitions"].([]BreakerTransition); ok && len(transitions) > 0 {
+		builders["breaker_chart"] = func() (*charts.Line, error) { return newBreakerChart(transitions, lang) }
+	}
+	if metrics, ok := stats["CustomMetrics"].([]CustomMetricStat); ok && len(metrics) > 0 {
+		samples, _ := stats["CustomMetricSeries"].([]CustomMetricSample)
+		builders["custom_metrics_chart"] = func() (*charts.Line, error) { return newCustomMetricsChart(metrics, samples, lang) }
+	}
 
-	// 返回生成的 HTML 文件路径
-	return htmlFilePath, nil
+	lines := make(map[string]*charts.Line, len(builders))
+	names := make([]string, 0, len(builders))
+	for name := range builders {
+		names = append(names, name)
+	}
+	sort.Strings(names) // 错误信息按图表名排序，便于阅读
+
+	var errs []error
+	for _, name := range names {
+		line, err := builders[name]()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lines[name] = line
+	}
+	return lines, errors.Join(errs...)
 }
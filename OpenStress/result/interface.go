@@ -0,0 +1,48 @@
+// interface.go
+// 结果收集器接口模块
+// 本文件负责定义结果收集器的接口 ResultCollector，由 *Collector 实现。
+// 依赖结果收集器的模块（例如 API 服务）通过接口使用收集器，嵌入 OpenStress 的使用者可以在单元测试中替换为 mock，
+// 也可以把结果转发到自己的存储。
+//
+// 技术实现细节：
+// 1. 接口包含结果的收集、保存、统计和报告生成，以及运行状态（测量开始时间、停止原因、提前中止）的设置；
+//    输出文件的轮转、检查点恢复等实现细节仍在 *Collector 上。
+// 2. 可以使用 mockgen 生成 gomock 实现（见下方 go:generate），生成的文件不提交到仓库。
+
+package result
+
+import "time"
+
+//go:generate mockgen -destination=mock_collector.go -package=result github.com/potatoImp/OpenStress/result ResultCollector
+
+// ResultCollector 结果收集器接口
+type ResultCollector interface {
+	// 收集与保存结果
+	CollectResult(data ResultData)
+	SaveSuccessResult(data ResultData) error
+	SaveFailureResult(data ResultData) error
+	Flush()
+	Results() []ResultData
+	Counters() CollectorCounters
+	LiveSeconds(from, to time.Time) []LiveSecond
+
+	// 统计与报告
+	LoadResultsFromFile() ([]ResultData, error)
+	CurrentStats(results []ResultData) (map[string]interface{}, error)
+	GeneratePerformanceStats(results []ResultData) (map[string]interface{}, error)
+	SaveReportToFile(stats map[string]interface{}, customName ...string) (string, error)
+	JTLFilePath() string
+	ReportDir() string
+	TrendStore() *TrendStore
+
+	// 运行状态
+	SetMeasurementStart(t time.Time)
+	SetStopReason(reason string)
+	SetAbort(reason, detail string, at time.Time)
+	SetTrafficMix(shares []TrafficShare)
+
+	Close() error
+}
+
+// 编译期检查 *Collector 实现了 ResultCollector
+var _ ResultCollector = (*Collector)(nil)
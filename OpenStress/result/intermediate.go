@@ -0,0 +1,87 @@
+// intermediate.go
+// 阶段报告模块
+// 本文件负责在长时间稳定性（Soak）测试中，按固定间隔为最近一个时间窗口生成阶段报告，
+// 报告包含该窗口的汇总数据和关键图表，便于在多日运行期间及时发现性能退化。
+
+package result
+
+import (
+	"fmt"
+	"time"
+)
+
+// startIntermediateReports 启动阶段报告定时任务
+func (c *Collector) startIntermediateReports(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		windowStart := time.Now()
+		for {
+			select {
+			case <-c.stopReports:
+				return
+			case windowEnd := <-ticker.C:
+				path, err := c.GenerateIntermediateReport(windowStart, windowEnd)
+				if err != nil {
+					c.logger.Log("WARN", fmt.Sprintf("Skipping intermediate report for window %s - %s: %v",
+						windowStart.Format("2006-01-02 15:04:05"), windowEnd.Format("2006-01-02 15:04:05"), err))
+				} else {
+					c.logger.Log("INFO", fmt.Sprintf("Intermediate report generated: %s", path))
+				}
+				windowStart = windowEnd
+			}
+		}
+	}()
+}
+
+// GenerateIntermediateReport 为 [windowStart, windowEnd) 时间窗口内的结果生成阶段报告，返回报告路径
+func (c *Collector) GenerateIntermediateReport(windowStart, windowEnd time.Time) (string, error) {
+	window := c.resultsInWindow(windowStart, windowEnd)
+	if len(window) == 0 {
+		return "", fmt.Errorf("no results in window")
+	}
+	seq := c.reportSeq.Add(1)
+
+	stats, err := c.computePerformanceStats(window)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("intermediate_report_%s_%03d", c.taskID, seq)
+	return c.SaveReportToFile(stats, name)
+}
+
+// resultsInWindow 复制 StartTime 落在 [windowStart, windowEnd) 内的结果
+// 只持有读锁，扫描期间处理协程写入结果会短暂等待，但统计计算和报告生成都在锁外进行。
+// 结果保留策略为 disk 时从 JTL 文件中过滤，为 aggregates 时没有可用的结果
+func (c *Collector) resultsInWindow(windowStart, windowEnd time.Time) []ResultData {
+	inWindow := func(result *ResultData) bool {
+		return !result.StartTime.Before(windowStart) && result.StartTime.Before(windowEnd)
+	}
+	var window []ResultData
+	if c.results.mode == RetainDisk {
+		for _, result := range c.Results() {
+			if inWindow(&result) {
+				window = append(window, result)
+			}
+		}
+		return window
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.results.each(func(result *ResultData) {
+		if inWindow(result) {
+			window = append(window, *result)
+		}
+	})
+	return window
+}
+
+// stopIntermediateReports 停止阶段报告定时任务，可重复调用
+func (c *Collector) stopIntermediateReports() {
+	c.stopReportsOnce.Do(func() {
+		close(c.stopReports)
+	})
+}
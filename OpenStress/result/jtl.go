@@ -32,6 +32,11 @@ type JTLRecord struct {
 	Latency      int64  // 延迟
 	IdleTime     int64  // 空闲时间
 	Connect      int64  // 连接时间
+	Retries      int    // 重试次数
+	ThrottleWait int64  // 限流等待时间（毫秒）
+	TraceID      string // 追踪ID
+	VUID         int    // 虚拟用户编号
+	Iteration    int    // 迭代次数
 }
 
 // 替换掉数据中的逗号
@@ -40,8 +45,53 @@ func sanitizeField(field string) string {
 	return strings.ReplaceAll(field, ",", "_")
 }
 
-// writeToJTL 将一批结果写入JTL文件
+// jtlHeaders JTL 文件的表头
+var jtlHeaders = []string{
+	"timeStamp",
+	"elapsed",
+	"label",
+	"responseCode",
+	"responseMessage",
+	"threadName",
+	"dataType",
+	"success",
+	"failureMessage",
+	"bytes",
+	"sentBytes",
+	"grpThreads",
+	"allThreads",
+	"URL",
+	"Latency",
+	"IdleTime",
+	"Connect",
+	"retries",
+	"throttleWait",
+	"assertions",
+	"traceId",
+	"vu",
+	"iteration",
+	"dnsLookup",
+	"tcpConnect",
+	"tlsHandshake",
+	"rows",
+	"failureBody",
+	"timedOut",
+	"sampleId",
+	"sampleLabel",
+	"tlsVersion",
+	"tlsCipher",
+}
+
+// writeToJTL 将一批结果写入JTL文件，配置了压缩或轮转时写入当前分段
 func (c *Collector) writeToJTL(batch []ResultData) error {
+	records := make([][]string, 0, len(batch))
+	for _, data := range batch {
+		records = append(records, jtlRecord(data))
+	}
+	if c.segments != nil {
+		return c.segments.write(records)
+	}
+
 	file, err := os.OpenFile(c.jtlFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to open JTL file: %v", err)
@@ -53,52 +103,13 @@ func (c *Collector) writeToJTL(batch []ResultData) error {
 
 	// 如果文件是新创建的，写入表头
 	if stat, _ := file.Stat(); stat.Size() == 0 {
-		headers := []string{
-			"timeStamp",
-			"elapsed",
-			"label",
-			"responseCode",
-			"responseMessage",
-			"threadName",
-			"dataType",
-			"success",
-			"failureMessage",
-			"bytes",
-			"sentBytes",
-			"grpThreads",
-			"allThreads",
-			"URL",
-			"Latency",
-			"IdleTime",
-			"Connect",
-		}
-		if err := writer.Write(headers); err != nil {
+		if err := writer.Write(jtlHeaders); err != nil {
 			return fmt.Errorf("failed to write headers: %v", err)
 		}
 	}
 
 	// 写入数据
-	for _, data := range batch {
-		record := []string{
-			sanitizeField(strconv.FormatInt(data.StartTime.UnixNano()/1e6, 10)),
-			sanitizeField(strconv.FormatInt(data.ResponseTime.Milliseconds(), 10)),
-			sanitizeField(data.Method),
-			sanitizeField(strconv.Itoa(data.StatusCode)),
-			"", // responseMessage 空
-			sanitizeField(fmt.Sprintf("Thread-%d", data.ThreadID)),
-			"", // dataType 空
-			sanitizeField(strconv.FormatBool(data.Type == Success)),
-			sanitizeField(data.ErrorMessage),
-			sanitizeField(strconv.FormatInt(data.DataReceived, 10)),
-			sanitizeField(strconv.FormatInt(data.DataSent, 10)),
-			"1", // grpThreads 固定值
-			"1", // allThreads 固定值
-			sanitizeField(data.URL),
-			"0", // Latency 固定值
-			"0", // IdleTime 固定值
-			"0", // Connect 固定值
-		}
-
+	for _, record := range records {
 		if err := writer.Write(record); err != nil {
 			return fmt.Errorf("failed to write record: %v", err)
 		}
@@ -107,6 +118,57 @@ func (c *Collector) writeToJTL(batch []ResultData) error {
 	return nil
 }
 
+// jtlRecord 将一条结果转换为 JTL 记录
+func jtlRecord(data ResultData) []string {
+	// 没有追踪ID的结果不记录 VU 编号和迭代次数
+	vu, iteration := "", ""
+	if data.TraceID != "" {
+		vu, iteration = strconv.Itoa(data.VUID), strconv.Itoa(data.Iteration)
+	}
+	// 事务样本的 label 为事务名，dataType 标记为事务；投递样本的 dataType 标记为投递
+	label, dataType := data.Method, ""
+	if data.Transaction != "" {
+		label, dataType = data.Transaction, TransactionDataType
+	} else if data.DataType == DeliveryDataType {
+		dataType = DeliveryDataType
+	}
+	return []string{
+		sanitizeField(strconv.FormatInt(data.StartTime.UnixNano()/1e6, 10)),
+		sanitizeField(strconv.FormatInt(data.ResponseTime.Milliseconds(), 10)),
+		sanitizeField(label),
+		sanitizeField(strconv.Itoa(data.StatusCode)),
+		"", // responseMessage 空
+		sanitizeField(fmt.Sprintf("Thread-%d", data.ThreadID)),
+		dataType,
+		sanitizeField(strconv.FormatBool(data.Type == Success)),
+		sanitizeField(data.ErrorMessage),
+		sanitizeField(strconv.FormatInt(data.DataReceived, 10)),
+		sanitizeField(strconv.FormatInt(data.DataSent, 10)),
+		"1", // grpThreads 固定值
+		"1", // allThreads 固定值
+		sanitizeField(data.URL),
+		strconv.FormatInt(data.TTFB.Milliseconds(), 10), // Latency 为首字节时间
+		"0", // IdleTime 固定值
+		strconv.FormatInt(data.Connect, 10),
+		strconv.Itoa(data.RetryCount),
+		strconv.FormatInt(data.ThrottleWait.Milliseconds(), 10),
+		sanitizeField(encodeAssertions(data.Assertions)),
+		sanitizeField(data.TraceID),
+		vu,
+		iteration,
+		strconv.FormatInt(data.DNSLookup.Milliseconds(), 10),
+		strconv.FormatInt(data.TCPConnect.Milliseconds(), 10),
+		strconv.FormatInt(data.TLSHandshake.Milliseconds(), 10),
+		strconv.FormatInt(data.Rows, 10),
+		data.ResponseBody, // 由 CSV 转义，保留原始逗号
+		strconv.FormatBool(data.Type == Timeout),
+		sanitizeField(data.ID),
+		sanitizeField(data.Label),
+		sanitizeField(data.TLSVersion),
+		sanitizeField(data.TLSCipher),
+	}
+}
+
 // generateJTLFileName 生成JTL文件名
 func generateJTLFileName() string {
 	return fmt.Sprintf("test_result_%s.jtl", time.Now().Format("20060102150405"))
@@ -0,0 +1,133 @@
+// junit.go
+// JUnit XML 导出模块
+// 本文件负责把阈值判定结果导出为 JUnit 风格的 XML，每条 SLA/阈值规则是一个测试用例，
+// Jenkins、GitLab 等 CI 可以把压测门禁和单元测试一起展示。
+//
+// 技术实现细节：
+// 1. 通过 ExportStatsMap(stats, ExportFormatJUnit) 导出，需要先调用 EvaluateThresholds 把判定结果写入 stats["ThresholdResults"]。
+// 2. 一次运行是一个 testsuite，名称为测试计划名称，耗时为压测总时长；未通过的规则输出 failure 元素，
+//    message 为实际值，正文为阈值表达式和实际值。
+// 3. 关键指标（请求数、成功率、TPS）和运行ID、随机种子作为 testsuite 的 property 输出，便于在 CI 中查看。
+
+package result
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// junitClassName 阈值测试用例的类名，CI 中按类名分组展示
+const junitClassName = "openstress.thresholds"
+
+// junitTestSuites JUnit XML 的根元素
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite 一次运行的阈值判定
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Hostname   string          `xml:"hostname,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+// junitProperty testsuite 的属性
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitTestCase 一条阈值规则
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure 未通过的阈值规则
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// exportJUnit 导出阈值判定结果的 JUnit XML
+func exportJUnit(stats map[string]interface{}) ([]byte, error) {
+	results, ok := stats["ThresholdResults"].([]ThresholdResult)
+	if !ok {
+		return nil, fmt.Errorf("no threshold results available, call EvaluateThresholds first")
+	}
+
+	metadata, _ := stats["RunMetadata"].(RunMetadata)
+	runTime, _ := stats["TotalRunTime"].(time.Duration)
+	suite := junitTestSuite{
+		Name:     metadata.TestPlan,
+		Tests:    len(results),
+		Time:     junitSeconds(runTime),
+		Hostname: metadata.Hostname,
+		Cases:    make([]junitTestCase, 0, len(results)),
+	}
+	if suite.Name == "" {
+		suite.Name = "OpenStress"
+	}
+	if !metadata.StartTime.IsZero() {
+		suite.Timestamp = metadata.StartTime.Format("2006-01-02T15:04:05")
+	}
+	for _, property := range []struct {
+		name string
+		key  string
+	}{
+		{"total_requests", "TotalRequests"},
+		{"success_rate", "SuccessRate"},
+		{"tps", "TPS"},
+		{"seed", "Seed"},
+	} {
+		if value, ok := stats[property.key]; ok {
+			suite.Properties = append(suite.Properties, junitProperty{Name: property.name, Value: fmt.Sprint(value)})
+		}
+	}
+
+	for _, r := range results {
+		testCase := junitTestCase{Name: r.Expression, ClassName: junitClassName, Time: junitSeconds(0)}
+		if !r.Passed {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: r.Message,
+				Type:    "ThresholdFailed",
+				Text:    fmt.Sprintf("threshold %q not met: %s", r.Expression, r.Message),
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{
+		Name:     "OpenStress",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JUnit XML: %v", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// junitSeconds 将时长格式化为 JUnit 使用的秒数（保留三位小数）
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,81 @@
+// label.go
+// 标签子收集器模块
+// 本文件负责按标签划分的子收集器（Collector.WithLabel），任务代码为每类请求创建一个子收集器，
+// 保存结果时自动写入标签和默认的请求方法、URL，不需要在每次保存时重复填写这些信息。
+//
+// 技术实现细节：
+// 1. LabeledCollector 嵌入父收集器，实现 ResultCollector 接口，可以直接替换父收集器传给任务；
+//    结果仍写入父收集器，统计、报告和输出文件与父收集器共用。
+// 2. 只填充结果中为空的字段，调用方显式设置的标签、方法和 URL 优先。
+// 3. 标签写入 ResultData.Label 和 JTL 的 sampleLabel 列，加载时恢复，并随 Elasticsearch 文档写入 label 字段。
+
+package result
+
+// LabeledCollector 按标签划分的子收集器，并发安全
+type LabeledCollector struct {
+	*Collector
+	label  string
+	method string
+	url    string
+}
+
+// 编译期检查 *LabeledCollector 实现了 ResultCollector
+var _ ResultCollector = (*LabeledCollector)(nil)
+
+// WithLabel 创建按标签划分的子收集器，通过它保存的结果自动带上标签
+func (c *Collector) WithLabel(label string) *LabeledCollector {
+	return &LabeledCollector{Collector: c, label: label}
+}
+
+// WithLabel 创建同一父收集器下的另一个标签的子收集器，沿用当前的默认方法和 URL
+func (l *LabeledCollector) WithLabel(label string) *LabeledCollector {
+	child := *l
+	child.label = label
+	return &child
+}
+
+// WithRequest 返回设置了默认请求方法和 URL 的子收集器副本
+func (l *LabeledCollector) WithRequest(method, url string) *LabeledCollector {
+	child := *l
+	child.method, child.url = method, url
+	return &child
+}
+
+// Label 返回子收集器的标签
+func (l *LabeledCollector) Label() string {
+	return l.label
+}
+
+// tag 为结果填充标签和默认的请求信息
+func (l *LabeledCollector) tag(data ResultData) ResultData {
+	if data.Label == "" {
+		data.Label = l.label
+	}
+	if data.Method == "" {
+		data.Method = l.method
+	}
+	if data.URL == "" {
+		data.URL = l.url
+	}
+	return data
+}
+
+// CollectResult 为结果打上标签后提交给父收集器
+func (l *LabeledCollector) CollectResult(data ResultData) {
+	l.Collector.CollectResult(l.tag(data))
+}
+
+// SaveSuccessResult 为结果打上标签后保存到父收集器
+func (l *LabeledCollector) SaveSuccessResult(data ResultData) error {
+	return l.Collector.SaveSuccessResult(l.tag(data))
+}
+
+// SaveFailureResult 为结果打上标签后保存到父收集器
+func (l *LabeledCollector) SaveFailureResult(data ResultData) error {
+	return l.Collector.SaveFailureResult(l.tag(data))
+}
+
+// EvaluateSuccess 按父收集器的成功判定策略判定打上标签（含默认 URL）后的结果
+func (l *LabeledCollector) EvaluateSuccess(data ResultData) error {
+	return l.Collector.EvaluateSuccess(l.tag(data))
+}
@@ -0,0 +1,82 @@
+// live.go
+// 实时统计模块
+// 本文件负责压测过程中按秒汇总最近完成的请求，供 API 服务的实时进度推送（GET /api/live）使用，
+// 不需要复制全部结果重新统计。
+//
+// 技术实现细节：
+// 1. 保存结果时按完成时间（EndTime）所在的秒累计请求数、失败数、超时数和响应时间，与计数器共用 c.mu。
+// 2. 只保留最近 liveWindowSeconds 秒，使用按秒数取模的环形缓冲，内存占用固定。
+// 3. LiveSeconds 返回时间段内每一秒的汇总，没有请求完成的秒也会返回（各项为 0），图表不会断开。
+
+package result
+
+import (
+	"time"
+)
+
+// liveWindowSeconds 实时统计保留的秒数
+const liveWindowSeconds = 120
+
+// liveBucket 一秒内完成的请求汇总
+type liveBucket struct {
+	sec          int64
+	requests     int
+	failures     int
+	timeouts     int
+	responseTime time.Duration
+	maxResponse  time.Duration
+}
+
+// LiveSecond 一秒内完成的请求汇总
+type LiveSecond struct {
+	Time          time.Time `json:"time"`
+	Requests      int       `json:"requests"` // 该秒完成的请求数，即该秒的 TPS
+	Failures      int       `json:"failures"` // 失败请求数（包括超时）
+	Timeouts      int       `json:"timeouts"`
+	AvgResponseMs float64   `json:"avg_response_ms"`
+	MaxResponseMs float64   `json:"max_response_ms"`
+}
+
+// recordLive 累计一条结果到实时统计，调用方持有 c.mu
+func (c *Collector) recordLive(data ResultData) {
+	sec := data.EndTime.Unix()
+	bucket := &c.live[sec%liveWindowSeconds]
+	if bucket.sec != sec {
+		*bucket = liveBucket{sec: sec}
+	}
+	bucket.requests++
+	if data.Type != Success {
+		bucket.failures++
+	}
+	if data.Type == Timeout {
+		bucket.timeouts++
+	}
+	bucket.responseTime += data.ResponseTime
+	bucket.maxResponse = max(bucket.maxResponse, data.ResponseTime)
+}
+
+// LiveSeconds 返回 [from, to) 内每一秒完成的请求汇总，只保留最近 liveWindowSeconds 秒，先等待此前提交的结果处理完（见 Flush）
+func (c *Collector) LiveSeconds(from, to time.Time) []LiveSecond {
+	c.Flush()
+	start, end := from.Unix(), to.Unix()
+	if to.After(time.Unix(end, 0)) {
+		end++ // to 不在整秒时包含其所在的秒
+	}
+	start = max(start, end-liveWindowSeconds)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	seconds := make([]LiveSecond, 0, max(end-start, 0))
+	for sec := start; sec < end; sec++ {
+		second := LiveSecond{Time: time.Unix(sec, 0)}
+		if bucket := c.live[sec%liveWindowSeconds]; bucket.sec == sec && bucket.requests > 0 {
+			second.Requests = bucket.requests
+			second.Failures = bucket.failures
+			second.Timeouts = bucket.timeouts
+			second.AvgResponseMs = float64(bucket.responseTime) / float64(bucket.requests) / float64(time.Millisecond)
+			second.MaxResponseMs = float64(bucket.maxResponse) / float64(time.Millisecond)
+		}
+		seconds = append(seconds, second)
+	}
+	return seconds
+}
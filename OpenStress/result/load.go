@@ -0,0 +1,76 @@
+// load.go
+// 负载采样模块
+// 本文件负责记录每秒的活跃虚拟用户数和协程池队列深度，并在 HTML 报告中生成 "VUs + 队列" 趋势图，
+// 与响应时间趋势对照，判断延迟尖刺是否出现在压测端饱和（队列积压、VU 数变化）的时刻。
+//
+// 技术实现细节：
+// 1. 采样与资源采样共用 pool.Monitor 的采集周期，由调用方通过 Pool.ActiveVUs 和 Pool.QueueDepth 读取后
+//    交给 Collector.RecordLoadSample；最多保留 maxResourceSamples 个采样，超过后丢弃最早的采样。
+// 2. 统计时将采样写入 stats["LoadSamples"]，没有采样时报告不展示该图。
+
+package result
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// LoadSample 一次负载采样
+type LoadSample struct {
+	Timestamp  time.Time // 采样时间
+	ActiveVUs  int       // 正在执行迭代的虚拟用户数
+	QueueDepth int       // 等待空闲 worker 的任务数
+}
+
+// RecordLoadSample 记录一次负载采样
+func (c *Collector) RecordLoadSample(sample LoadSample) {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	if len(c.loadSamples) >= maxResourceSamples {
+		c.loadSamples = c.loadSamples[1:]
+	}
+	c.loadSamples = append(c.loadSamples, sample)
+}
+
+// LoadSamples 返回已记录负载采样的副本
+func (c *Collector) LoadSamples() []LoadSample {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	return append([]LoadSample(nil), c.loadSamples...)
+}
+
+// newLoadChart 创建活跃 VU 与队列深度趋势图
+func newLoadChart(samples []LoadSample, lang Language) (*charts.Line, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no load samples")
+	}
+
+	xAxis := make([]string, 0, len(samples))
+	vuData := make([]opts.LineData, 0, len(samples))
+	queueData := make([]opts.LineData, 0, len(samples))
+	for _, sample := range samples {
+		xAxis = append(xAxis, sample.Timestamp.Format("15:04:05"))
+		vuData = append(vuData, opts.LineData{Value: sample.ActiveVUs})
+		queueData = append(queueData, opts.LineData{Value: sample.QueueDepth})
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("load_chart_title"),
+			Subtitle: lang.text("resource_chart_sampled", samples[0].Timestamp.Format("15:04:05"), samples[len(samples)-1].Timestamp.Format("15:04:05")),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+	)
+
+	line.SetXAxis(xAxis)
+	line.AddSeries(lang.text("load_series_vus"), vuData)
+	line.AddSeries(lang.text("load_series_queue"), queueData)
+
+	return line, nil
+}
@@ -0,0 +1,126 @@
+// lock.go
+// 结果目录锁模块
+// 本文件负责为运行目录和 JTL 输出创建锁文件，防止两个意外并发的运行交错写入同一份结果。
+//
+// 技术实现细节：
+// 1. 使用 O_CREATE|O_EXCL 原子创建锁文件，锁文件中记录持有者的 PID、主机名、任务ID和开始时间。
+// 2. 锁已存在时，如果持有进程在本机且已退出，视为残留锁并自动清理；否则返回错误并指出正在运行的任务。
+// 3. 正常结束时由 Release 删除锁文件。
+// 4. 锁文件放在多个运行共享的目录中才有意义：运行锁位于 JTL 所在目录（按任务ID区分），
+//    报告锁位于报告根目录（按报告名区分），而不是每次新建的时间戳目录中。
+
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockInfo 锁文件内容
+type lockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	TaskID    string    `json:"task_id"`
+	StartedAt time.Time `json:"started_at"`
+	Target    string    `json:"target"` // 被保护的输出路径
+}
+
+// fileLock 已持有的锁
+type fileLock struct {
+	path string
+}
+
+// ErrLocked 目标已被其他运行锁定
+var ErrLocked = errors.New("results are locked by another run")
+
+// acquireLock 创建锁文件，target 为被保护的输出路径，记录在锁文件中用于提示信息
+func acquireLock(lockPath, target, taskID string) (*fileLock, error) {
+	host, _ := os.Hostname()
+	info := lockInfo{
+		PID:       os.Getpid(),
+		Host:      host,
+		TaskID:    taskID,
+		StartedAt: time.Now(),
+		Target:    target,
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lock info: %v", err)
+	}
+
+	// 最多尝试两次：第二次用于清理残留锁之后重新获取
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, writeErr := file.Write(data)
+			closeErr := file.Close()
+			if writeErr != nil || closeErr != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to write lock file %s: %v", lockPath, errors.Join(writeErr, closeErr))
+			}
+			return &fileLock{path: lockPath}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file %s: %v", lockPath, err)
+		}
+
+		holder, readErr := readLockInfo(lockPath)
+		if readErr == nil && holder.stale(host) {
+			os.Remove(lockPath)
+			continue
+		}
+		return nil, lockedError(lockPath, holder, readErr)
+	}
+	return nil, fmt.Errorf("failed to acquire lock %s", lockPath)
+}
+
+// Release 释放锁，可重复调用
+func (l *fileLock) Release() error {
+	if l == nil || l.path == "" {
+		return nil
+	}
+	err := os.Remove(l.path)
+	l.path = ""
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %v", err)
+	}
+	return nil
+}
+
+// readLockInfo 读取锁文件内容
+func readLockInfo(lockPath string) (lockInfo, error) {
+	var info lockInfo
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+// stale 判断锁是否为残留锁：仅当持有进程在本机且已经退出时返回 true
+func (info lockInfo) stale(host string) bool {
+	if info.Host != host || info.PID <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(info.PID)
+	if err != nil {
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH)
+}
+
+// lockedError 生成指向现有运行的错误信息
+func lockedError(lockPath string, holder lockInfo, readErr error) error {
+	if readErr != nil {
+		return fmt.Errorf("%w: lock file %s is unreadable (%v); if no other run is active, remove it and retry",
+			ErrLocked, lockPath, readErr)
+	}
+	return fmt.Errorf("%w: task %s (pid %d on %s, started %s) is still writing %s; wait for that run to finish or use a different output path. If that run is no longer active, remove %s",
+		ErrLocked, holder.TaskID, holder.PID, holder.Host, holder.StartedAt.Format("2006-01-02 15:04:05"), holder.Target, lockPath)
+}
@@ -0,0 +1,297 @@
+// markdown.go
+// Markdown 摘要报告模块
+// 本文件负责生成紧凑的 Markdown 摘要（关键指标表、阈值判定、主要错误），
+// 便于 CI 直接发布为 GitHub/GitLab 合并请求评论。
+//
+// 技术实现细节：
+// 1. 只使用 GitHub 与 GitLab 都支持的 Markdown 语法（标题、表格、粗体），不依赖 HTML。
+// 2. 主要错误在统计时按“状态码 + 错误信息”汇总全部失败请求（stats["TopErrors"]），
+//    而不是取报告中的失败请求明细，避免只看到最早的一批失败。
+// 3. 单元格中的竖线和换行会被转义，错误信息过长时截断，避免破坏表格。
+// 4. 文本使用 stats["Language"] 指定的语言。
+// 5. 保存了失败响应体时，主要错误表格之后用代码块展示每种错误的代表性响应，过长时截断。
+
+package result
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTopErrors Markdown 摘要中最多展示的错误种类数
+const maxTopErrors = 5
+
+// maxMarkdownCellLength 单元格文本的最大长度（按字符计）
+const maxMarkdownCellLength = 120
+
+// maxMarkdownBodyLength 摘要中展示的失败响应体最大长度（字符）
+const maxMarkdownBodyLength = 500
+
+// ErrorSummary 按状态码和错误信息汇总的失败请求
+type ErrorSummary struct {
+	StatusCode int    // 状态码
+	Error      string // 错误信息
+	Count      int    // 出现次数
+	Sample     string // 代表性的响应体片段（见 CollectorConfig.FailureBodyBytes），未保存时为空
+}
+
+// collectTopErrors 按状态码和错误信息汇总失败请求，按出现次数从多到少返回前 maxTopErrors 种
+func collectTopErrors(results []ResultData) []ErrorSummary {
+	counter := newErrorCounter()
+	for i := range results {
+		counter.add(&results[i])
+	}
+	return counter.top()
+}
+
+// errorKey 错误按状态码和错误信息区分
+type errorKey struct {
+	statusCode int
+	message    string
+}
+
+// errorCounter 逐条累计每种错误的次数和第一条保存的响应体
+type errorCounter struct {
+	counts  map[errorKey]int
+	samples map[errorKey]string
+}
+
+// newErrorCounter 创建空的错误计数
+func newErrorCounter() *errorCounter {
+	return &errorCounter{counts: make(map[errorKey]int), samples: make(map[errorKey]string)}
+}
+
+// add 累计一条结果，非失败结果忽略
+func (e *errorCounter) add(r *ResultData) {
+	if r.Type == Success {
+		return
+	}
+	key := errorKey{r.StatusCode, r.ErrorMessage}
+	e.counts[key]++
+	if e.samples[key] == "" {
+		e.samples[key] = r.ResponseBody
+	}
+}
+
+// top 按出现次数从多到少返回前 maxTopErrors 种错误
+func (e *errorCounter) top() []ErrorSummary {
+	summaries := make([]ErrorSummary, 0, len(e.counts))
+	for key, count := range e.counts {
+		summaries = append(summaries, ErrorSummary{StatusCode: key.statusCode, Error: key.message, Count: count, Sample: e.samples[key]})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		if summaries[i].StatusCode != summaries[j].StatusCode {
+			return summaries[i].StatusCode < summaries[j].StatusCode
+		}
+		return summaries[i].Error < summaries[j].Error
+	})
+	if len(summaries) > maxTopErrors {
+		summaries = summaries[:maxTopErrors]
+	}
+	return summaries
+}
+
+// GenerateMarkdownReport 生成 Markdown 格式的测试摘要，适合作为合并请求评论发布
+func GenerateMarkdownReport(stats map[string]interface{}, title ...string) string {
+	lang := reportLanguage(stats)
+	var builder strings.Builder
+
+	builder.WriteString("## " + markdownCell(reportTitle(title, lang)) + "\n\n")
+	if aborted, _ := stats["Aborted"].(bool); aborted {
+		reason, _ := stats["StopReason"].(string)
+		abortTime, _ := stats["AbortTime"].(time.Time)
+		detail, _ := stats["AbortDetail"].(string)
+		builder.WriteString("> **" + lang.text("md_aborted", stopReasonText(reason, lang), abortTime.Format("2006-01-02 15:04:05")) + "** " + markdownCell(detail) + "\n\n")
+	}
+	if seed, ok := stats["Seed"].(int64); ok {
+		builder.WriteString(lang.text("seed") + ": `" + fmt.Sprintf("%d", seed) + "`\n\n")
+	}
+
+	// 关键指标
+	builder.WriteString("| " + lang.text("md_metric") + " | " + lang.text("md_value") + " |\n")
+	builder.WriteString("| --- | ---: |\n")
+	writeRow := func(label, value string) {
+		builder.WriteString("| " + label + " | " + value + " |\n")
+	}
+	totalRequests, _ := stats["TotalRequests"].(int)
+	failureCount, _ := stats["FailureCount"].(int)
+	successRate, _ := stats["SuccessRate"].(float64)
+	tps, _ := stats["TPS"].(float64)
+	writeRow(lang.text("summary_total_requests"), fmt.Sprintf("%d", totalRequests))
+	writeRow(lang.text("md_success_rate"), fmt.Sprintf("%.3f%%", successRate))
+	writeRow(lang.text("summary_failure_count"), fmt.Sprintf("%d", failureCount))
+	if timeoutCount, _ := stats["TimeoutCount"].(int); timeoutCount > 0 {
+		writeRow(lang.text("summary_timeout_count"), fmt.Sprintf("%d", timeoutCount))
+	}
+	if retries, _ := stats["TotalRetries"].(int); retries > 0 {
+		writeRow(lang.text("summary_retries"), fmt.Sprintf("%d (%v)", retries, stats["RetriedCount"]))
+	}
+	writeRow(lang.text("summary_tps"), fmt.Sprintf("%.2f", tps))
+	writeRow(lang.text("summary_avg_response_time"), markdownMillis(stats["AvgResponseTime"]))
+	for _, p := range percentileLevels {
+		writeRow(fmt.Sprintf("P%g", p), markdownMillis(stats[percentileKey(p)]))
+	}
+	writeRow(lang.text("summary_max_response_time"), markdownMillis(stats["MaxResponseTime"]))
+	if reason, ok := stats["StopReason"].(string); ok {
+		writeRow(lang.text("stop_reason"), markdownCell(stopReasonText(reason, lang)))
+	}
+
+	// 消息投递延迟
+	if delivery, ok := stats["DeliveryStats"].(DeliveryStats); ok {
+		writeRow(lang.text("delivery_messages"), fmt.Sprintf("%d", delivery.Messages))
+		writeRow(lang.text("delivery_throughput"), fmt.Sprintf("%.2f", delivery.Throughput))
+		writeRow(lang.text("delivery")+" P95", markdownMillis(delivery.P95))
+	}
+
+	// 事务统计
+	if transactionStats, ok := stats["TransactionStats"].([]TransactionStat); ok && len(transactionStats) > 0 {
+		builder.WriteString("\n### " + lang.text("transactions") + "\n\n")
+		builder.WriteString("| " + lang.text("transaction_name") + " | " + lang.text("md_count") + " | " + lang.text("md_success_rate") + " | TPS | " + lang.text("summary_avg_response_time") + " | P95 |\n")
+		builder.WriteString("| --- | ---: | ---: | ---: | ---: | ---: |\n")
+		for _, stat := range transactionStats {
+			builder.WriteString(fmt.Sprintf("| %s | %d | %.3f%% | %.2f | %s | %s |\n", markdownCell(stat.Name), stat.Count, stat.SuccessRate, stat.TPS,
+				markdownMillis(stat.AvgResponseTime), markdownMillis(stat.P95ResponseTime)))
+		}
+	}
+
+	// TLS 连接
+	if tlsStats, ok := stats["TLSStats"].([]TLSStat); ok && len(tlsStats) > 0 {
+		builder.WriteString("\n### " + lang.text("tls_connections") + "\n\n")
+		builder.WriteString("| " + lang.text("tls_version") + " | " + lang.text("tls_cipher") + " | " + lang.text("md_count") + " | " + lang.text("tls_handshakes") + " | " + lang.text("tls_avg_handshake") + " |\n")
+		builder.WriteString("| --- | --- | ---: | ---: | ---: |\n")
+		for _, stat := range tlsStats {
+			builder.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %s |\n", markdownCell(stat.Version), markdownCell(stat.Cipher), stat.Requests, stat.Handshakes, markdownMillis(stat.AvgHandshake)))
+		}
+	}
+
+	// 流量配比
+	if shares, ok := stats["TrafficMix"].([]TrafficShare); ok && len(shares) > 0 {
+		builder.WriteString("\n### " + lang.text("traffic_mix") + "\n\n")
+		builder.WriteString("| " + lang.text("traffic_mix_task") + " | " + lang.text("traffic_mix_weight") + " | " + lang.text("traffic_mix_target") + " | " + lang.text("traffic_mix_iterations") + " | " + lang.text("traffic_mix_actual") + " |\n")
+		builder.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+		for _, share := range shares {
+			builder.WriteString(fmt.Sprintf("| %s | %d | %.2f%% | %d | %.2f%% |\n", markdownCell(share.Task), share.Weight, share.Target, share.Iterations, share.Actual))
+		}
+	}
+
+	// 自定义指标
+	if metrics, ok := stats["CustomMetrics"].([]CustomMetricStat); ok && len(metrics) > 0 {
+		builder.WriteString("\n### " + lang.text("custom_metrics") + "\n\n")
+		builder.WriteString("| " + lang.text("custom_metric_name") + " | " + lang.text("custom_metric_type") + " | " + lang.text("md_count") + " | " + lang.text("custom_metric_value") + " | " + lang.text("custom_metric_min") + " | " + lang.text("custom_metric_max") + " | P95 |\n")
+		builder.WriteString("| --- | --- | ---: | ---: | ---: | ---: | ---: |\n")
+		for _, metric := range metrics {
+			p95 := "-"
+			if metric.Type == MetricTrend {
+				p95 = fmt.Sprintf("%.2f", metric.P95)
+			}
+			builder.WriteString(fmt.Sprintf("| %s | %s | %d | %.2f | %.2f | %.2f | %s |\n", markdownCell(metric.Name), lang.text("custom_metric_"+string(metric.Type)),
+				metric.Count, metric.Value, metric.Min, metric.Max, p95))
+		}
+	}
+
+	// 阈值判定
+	if thresholdResults, ok := stats["ThresholdResults"].([]ThresholdResult); ok && len(thresholdResults) > 0 {
+		failed := 0
+		for _, r := range thresholdResults {
+			if !r.Passed {
+				failed++
+			}
+		}
+		builder.WriteString("\n### " + lang.text("thresholds") + "\n\n")
+		if failed == 0 {
+			builder.WriteString("**" + lang.text("md_thresholds_passed") + "**\n\n")
+		} else {
+			builder.WriteString("**" + lang.text("md_thresholds_failed", failed, len(thresholdResults)) + "**\n\n")
+		}
+		builder.WriteString("| | " + lang.text("threshold_rule") + " | " + lang.text("threshold_actual") + " |\n")
+		builder.WriteString("| :---: | --- | --- |\n")
+		for _, r := range thresholdResults {
+			verdict := "✅"
+			if !r.Passed {
+				verdict = "❌"
+			}
+			builder.WriteString("| " + verdict + " | " + markdownCell(r.Expression) + " | " + markdownCell(r.Message) + " |\n")
+		}
+	}
+
+	// 与历史运行的滚动基线对比
+	if deltas, ok := stats["TrendComparison"].([]TrendDelta); ok && len(deltas) > 0 {
+		regressed := 0
+		for _, d := range deltas {
+			if d.Regressed {
+				regressed++
+			}
+		}
+		runs, _ := stats["TrendBaselineRuns"].(int)
+		builder.WriteString("\n### " + lang.text("trend") + "\n\n")
+		if regressed == 0 {
+			builder.WriteString("**" + lang.text("md_trend_ok") + "**")
+		} else {
+			builder.WriteString("**" + lang.text("md_trend_regressed", regressed) + "**")
+		}
+		builder.WriteString(" (" + lang.text("trend_baseline", runs) + ")\n\n")
+		builder.WriteString("| | " + lang.text("trend_metric") + " | " + lang.text("trend_baseline_value") + " | " + lang.text("trend_actual") + " | " + lang.text("trend_change") + " |\n")
+		builder.WriteString("| :---: | --- | ---: | ---: | ---: |\n")
+		for _, d := range deltas {
+			verdict := "✅"
+			if d.Regressed {
+				verdict = "❌"
+			}
+			builder.WriteString(fmt.Sprintf("| %s | %s | %.2f | %.2f | %s |\n", verdict, d.Metric, d.Baseline, d.Actual, d.ChangeText()))
+		}
+	}
+
+	// 主要错误
+	if topErrors, ok := stats["TopErrors"].([]ErrorSummary); ok && len(topErrors) > 0 {
+		builder.WriteString("\n### " + lang.text("md_top_errors") + "\n\n")
+		builder.WriteString("| " + lang.text("md_count") + " | " + lang.text("failed_status") + " | " + lang.text("failed_error") + " |\n")
+		builder.WriteString("| ---: | ---: | --- |\n")
+		for _, e := range topErrors {
+			builder.WriteString(fmt.Sprintf("| %d | %d | %s |\n", e.Count, e.StatusCode, markdownCell(e.Error)))
+		}
+		// 代表性的失败响应
+		for _, e := range topErrors {
+			if e.Sample == "" {
+				continue
+			}
+			body := e.Sample
+			if runes := []rune(body); len(runes) > maxMarkdownBodyLength {
+				body = string(runes[:maxMarkdownBodyLength]) + "…"
+			}
+			fence := "```"
+			for strings.Contains(body, fence) {
+				fence += "`"
+			}
+			builder.WriteString(fmt.Sprintf("\n**%s: %d %s**\n\n%s\n%s\n%s\n",
+				lang.text("failure_payload"), e.StatusCode, markdownCell(e.Error), fence, body, fence))
+		}
+	}
+
+	return builder.String()
+}
+
+// markdownMillis 将时长格式化为毫秒，非时长的值显示为 "-"
+func markdownMillis(value interface{}) string {
+	d, ok := value.(time.Duration)
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+}
+
+// markdownCell 转义表格单元格中的竖线和换行，并截断过长的文本
+func markdownCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxMarkdownCellLength {
+		s = string(runes[:maxMarkdownCellLength]) + "…"
+	}
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,188 @@
+// metadata.go
+// 运行元数据模块
+// 本文件负责在创建结果收集器时记录本次运行的元数据：测试计划名称、Git 提交、主机名、GOMAXPROCS、
+// 操作系统、OpenStress 版本和命令行参数，写入统计结果、HTML 报告概览和导出的 JSON，几个月后也能追溯结果的来源。
+//
+// 技术实现细节：
+// 1. 元数据在 NewCollector 时采集，StartTime 为收集器创建时间；测试计划名称来自 CollectorConfig.TestPlan，为空时使用 TaskID。
+// 2. OpenStress 版本取自二进制的构建信息（模块版本和构建时的 VCS 提交，有未提交的修改时追加 -dirty）。
+// 3. Git 提交为当前工作目录所在仓库的 HEAD（通常是测试计划所在的仓库），通过 git rev-parse 获取，
+//    没有安装 git 或不在仓库中时为空；进程内只获取一次，调度器重复创建收集器时不会重复执行。
+// 4. 命令行参数中名称包含 key、token、secret、password 等敏感词（见 redact 包，可配置）的参数值替换为 ***，避免凭据写入报告。
+// 5. 统计结果中以 stats["RunMetadata"]（RunMetadata）保存，JSON 导出时按字段标签序列化，CSV 和 OpenMetrics 不导出。
+// 6. 任务 HTTP 客户端模拟了客户端带宽（tasks.BandwidthConfig）时，通过 CollectorConfig.Bandwidth 记录到元数据中，
+//    报告中可以区分限速客户端和正常客户端的结果。
+
+package result
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/redact"
+)
+
+// gitCommitTimeout 获取 Git 提交的超时时间
+const gitCommitTimeout = 2 * time.Second
+
+// RunMetadata 一次运行的元数据
+type RunMetadata struct {
+	TestPlan   string    `json:"test_plan"`            // 测试计划名称
+	StartTime  time.Time `json:"start_time"`           // 收集器创建时间
+	Version    string    `json:"version"`              // OpenStress 版本
+	GitCommit  string    `json:"git_commit,omitempty"` // 工作目录所在 Git 仓库的 HEAD 提交
+	Hostname   string    `json:"hostname"`
+	OS         string    `json:"os"` // 操作系统和架构，例如 linux/amd64
+	GoVersion  string    `json:"go_version"`
+	GOMAXPROCS int       `json:"gomaxprocs"`
+	NumCPU     int       `json:"num_cpu"`
+	Args       []string  `json:"args"` // 命令行参数（不含程序名），敏感参数的值已隐藏
+
+	Bandwidth *SimulatedBandwidth `json:"bandwidth,omitempty"` // 模拟的客户端带宽，未模拟时为空
+}
+
+// SimulatedBandwidth 模拟的客户端带宽（每个连接），0 表示该方向不限速
+type SimulatedBandwidth struct {
+	UploadBytesPerSecond   int64 `json:"upload_bytes_per_second"`
+	DownloadBytesPerSecond int64 `json:"download_bytes_per_second"`
+}
+
+// 进程内不变的元数据只采集一次
+var (
+	processMetadataOnce sync.Once
+	processMetadata     RunMetadata
+)
+
+// CaptureRunMetadata 采集当前进程的运行元数据，testPlan 为测试计划名称
+func CaptureRunMetadata(testPlan string) RunMetadata {
+	processMetadataOnce.Do(func() {
+		hostname, _ := os.Hostname()
+		processMetadata = RunMetadata{
+			Version:   openStressVersion(),
+			GitCommit: workingDirCommit(),
+			Hostname:  hostname,
+			OS:        runtime.GOOS + "/" + runtime.GOARCH,
+			GoVersion: runtime.Version(),
+			NumCPU:    runtime.NumCPU(),
+		}
+	})
+	metadata := processMetadata
+	metadata.TestPlan = testPlan
+	metadata.StartTime = time.Now()
+	metadata.GOMAXPROCS = runtime.GOMAXPROCS(0)
+	metadata.Args = redactArgs(os.Args[1:])
+	return metadata
+}
+
+// redactArgs 复制命令行参数，隐藏敏感参数（-token=xxx 或 -token xxx，参数名按 redact.IsSensitive 判断）的值
+func redactArgs(args []string) []string {
+	redacted := make([]string, 0, len(args))
+	hideNext := false
+	for _, arg := range args {
+		if hideNext {
+			redacted = append(redacted, redact.Mask)
+			hideNext = false
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		sensitive := strings.HasPrefix(arg, "-") && redact.IsSensitive(name)
+		switch {
+		case sensitive && hasValue:
+			arg = arg[:strings.Index(arg, "=")+1] + redact.Mask
+		case sensitive:
+			hideNext = true
+		}
+		redacted = append(redacted, arg)
+	}
+	return redacted
+}
+
+// openStressVersion 返回构建信息中的模块版本和 VCS 提交，没有构建信息时返回 "unknown"
+func openStressVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	var revision string
+	var dirty bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			dirty = setting.Value == "true"
+		}
+	}
+	if revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if dirty {
+			revision += "-dirty"
+		}
+		version = strings.TrimSpace(version + " " + revision)
+	}
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
+
+// workingDirCommit 返回当前工作目录所在 Git 仓库的 HEAD 提交，获取失败时返回空字符串
+func workingDirCommit() string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommitTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// RunMetadata 返回收集器创建时采集的运行元数据
+func (c *Collector) RunMetadata() RunMetadata {
+	return c.metadata
+}
+
+// runMetadataRows 报告概览表格中的运行元数据行，值为空的项不展示
+func runMetadataRows(metadata RunMetadata, lang Language) []ReportRow {
+	var rows []ReportRow
+	for _, row := range []ReportRow{
+		{Label: lang.text("test_plan"), Value: metadata.TestPlan},
+		{Label: lang.text("openstress_version"), Value: metadata.Version},
+		{Label: lang.text("git_commit"), Value: metadata.GitCommit},
+		{Label: lang.text("hostname"), Value: metadata.Hostname},
+		{Label: lang.text("os_arch"), Value: metadata.OS},
+		{Label: lang.text("go_version"), Value: metadata.GoVersion},
+		{Label: lang.text("gomaxprocs"), Value: fmt.Sprintf("%d / %d", metadata.GOMAXPROCS, metadata.NumCPU)},
+		{Label: lang.text("cli_args"), Value: strings.Join(metadata.Args, " ")},
+		{Label: lang.text("simulated_bandwidth"), Value: bandwidthText(metadata.Bandwidth, lang)},
+	} {
+		if row.Value != "" {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// bandwidthText 模拟带宽的展示文本，未模拟时返回空字符串
+func bandwidthText(bandwidth *SimulatedBandwidth, lang Language) string {
+	if bandwidth == nil {
+		return ""
+	}
+	rate := func(bytesPerSecond int64) string {
+		if bytesPerSecond <= 0 {
+			return lang.text("bandwidth_unlimited")
+		}
+		return formatBytes(bytesPerSecond) + "/s"
+	}
+	return lang.text("bandwidth_value", rate(bandwidth.UploadBytesPerSecond), rate(bandwidth.DownloadBytesPerSecond))
+}
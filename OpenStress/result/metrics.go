@@ -0,0 +1,329 @@
+// metrics.go
+// 自定义指标模块
+// 本文件负责任务代码上报的自定义指标（类似 k6 的自定义指标），例如处理的条目数、缓存命中、队列长度等，
+// 收集器汇总后在报告中作为额外的表格行和趋势图展示。
+//
+// 技术实现细节：
+// 1. 支持三种指标：计数器（Counter，累加）、仪表（Gauge，记录最新值）、趋势（Trend，记录每个值并统计分布）。
+// 2. 任务代码通过 collector.Counter(name) 等方法获取指标句柄，句柄并发安全，可以在任务之间共享。
+// 3. 指标值按秒分桶保存，统计时排除预热阶段（测量开始时间之前）的分桶，趋势图按 chartBucket 合并分桶。
+// 4. 同名指标只能是一种类型，以不同类型再次获取时记录错误日志，返回的句柄不记录任何值。
+
+package result
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// MetricType 自定义指标类型
+type MetricType string
+
+// 支持的自定义指标类型
+const (
+	MetricCounter MetricType = "counter" // 计数器：累加上报的值
+	MetricGauge   MetricType = "gauge"   // 仪表：保留最新上报的值
+	MetricTrend   MetricType = "trend"   // 趋势：统计上报值的分布
+)
+
+// metricBucket 一秒内上报的指标值
+type metricBucket struct {
+	count    int
+	sum      float64
+	min, max float64
+	last     float64
+	values   []float64 // 仅趋势指标保存每个值，用于计算百分位
+}
+
+// customMetric 一个自定义指标的全部分桶
+type customMetric struct {
+	name    string
+	typ     MetricType
+	seconds map[int64]*metricBucket
+}
+
+// record 在 at 所在的秒记录一个值
+func (m *customMetric) record(value float64, at time.Time) {
+	sec := at.Unix()
+	b, ok := m.seconds[sec]
+	if !ok {
+		b = &metricBucket{min: value, max: value}
+		m.seconds[sec] = b
+	}
+	b.count++
+	b.sum += value
+	b.min = math.Min(b.min, value)
+	b.max = math.Max(b.max, value)
+	b.last = value
+	if m.typ == MetricTrend {
+		b.values = append(b.values, value)
+	}
+}
+
+// metricHandle 指标句柄的公共部分，metric 为 nil 时不记录任何值
+type metricHandle struct {
+	collector *Collector
+	metric    *customMetric
+}
+
+// record 记录一个值
+func (h metricHandle) record(value float64) {
+	if h.metric == nil {
+		return
+	}
+	h.collector.metricsMu.Lock()
+	h.metric.record(value, time.Now())
+	h.collector.metricsMu.Unlock()
+}
+
+// Counter 计数器指标句柄
+type Counter struct{ metricHandle }
+
+// Add 累加 value
+func (c *Counter) Add(value float64) { c.record(value) }
+
+// Inc 累加 1
+func (c *Counter) Inc() { c.record(1) }
+
+// Gauge 仪表指标句柄
+type Gauge struct{ metricHandle }
+
+// Set 设置当前值
+func (g *Gauge) Set(value float64) { g.record(value) }
+
+// Trend 趋势指标句柄
+type Trend struct{ metricHandle }
+
+// Add 记录一个值，例如一次业务处理的耗时或返回的条目数
+func (t *Trend) Add(value float64) { t.record(value) }
+
+// AddDuration 以毫秒记录一个时长
+func (t *Trend) AddDuration(d time.Duration) { t.record(float64(d) / float64(time.Millisecond)) }
+
+// Counter 返回名为 name 的计数器，不存在时创建
+func (c *Collector) Counter(name string) *Counter {
+	return &Counter{c.metricHandle(name, MetricCounter)}
+}
+
+// Gauge 返回名为 name 的仪表，不存在时创建
+func (c *Collector) Gauge(name string) *Gauge {
+	return &Gauge{c.metricHandle(name, MetricGauge)}
+}
+
+// Trend 返回名为 name 的趋势指标，不存在时创建
+func (c *Collector) Trend(name string) *Trend {
+	return &Trend{c.metricHandle(name, MetricTrend)}
+}
+
+// metricHandle 查找或创建指标，同名指标已经是其他类型时记录错误并返回不记录值的句柄
+func (c *Collector) metricHandle(name string, typ MetricType) metricHandle {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	if c.metrics == nil {
+		c.metrics = make(map[string]*customMetric)
+	}
+	m, ok := c.metrics[name]
+	if !ok {
+		m = &customMetric{name: name, typ: typ, seconds: make(map[int64]*metricBucket)}
+		c.metrics[name] = m
+	}
+	if m.typ != typ {
+		c.logger.Log("ERROR", fmt.Sprintf("custom metric %q is a %s, not a %s; values are dropped", name, m.typ, typ))
+		return metricHandle{collector: c}
+	}
+	return metricHandle{collector: c, metric: m}
+}
+
+// CustomMetricStat 一个自定义指标的汇总统计
+type CustomMetricStat struct {
+	Name  string
+	Type  MetricType
+	Count int     // 上报次数
+	Value float64 // 计数器为累计值，仪表为最新值，趋势为平均值
+	Rate  float64 // 计数器每秒的累计值（按压测总时长计算），其他类型为 0
+	Min   float64
+	Avg   float64
+	Max   float64
+	P90   float64 // 仅趋势指标
+	P95   float64 // 仅趋势指标
+}
+
+// CustomMetricSample 一个时间段内各自定义指标的值，键为指标名，时间段内没有上报的指标不包含在内。
+// 计数器为时间段内每秒的累计值，仪表为时间段内最后的值，趋势为时间段内的平均值
+type CustomMetricSample struct {
+	Timestamp time.Time
+	Values    map[string]float64
+}
+
+// customMetricStats 汇总测量开始时间之后的自定义指标，结果按名称排序；runTime 用于计算计数器的速率
+func (c *Collector) customMetricStats(measureStart time.Time, runTime time.Duration) ([]CustomMetricStat, []CustomMetricSample) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+
+	from := int64(math.MinInt64)
+	if !measureStart.IsZero() {
+		from = measureStart.Unix()
+	}
+	names := make([]string, 0, len(c.metrics))
+	first, last := int64(math.MaxInt64), int64(math.MinInt64)
+	for name, m := range c.metrics {
+		used := false
+		for sec := range m.seconds {
+			if sec < from {
+				continue
+			}
+			used = true
+			first, last = min(first, sec), max(last, sec)
+		}
+		if used {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	stats := make([]CustomMetricStat, 0, len(names))
+	for _, name := range names {
+		stats = append(stats, c.metrics[name].summarize(from, runTime))
+	}
+	return stats, c.metricSeries(names, first, last)
+}
+
+// summarize 汇总 from 秒及之后的分桶
+func (m *customMetric) summarize(from int64, runTime time.Duration) CustomMetricStat {
+	secs := make([]int64, 0, len(m.seconds))
+	for sec := range m.seconds {
+		if sec >= from {
+			secs = append(secs, sec)
+		}
+	}
+	sort.Slice(secs, func(i, j int) bool { return secs[i] < secs[j] })
+
+	stat := CustomMetricStat{Name: m.name, Type: m.typ, Min: math.Inf(1), Max: math.Inf(-1)}
+	var sum float64
+	var values []float64
+	for _, sec := range secs {
+		b := m.seconds[sec]
+		stat.Count += b.count
+		sum += b.sum
+		stat.Min = math.Min(stat.Min, b.min)
+		stat.Max = math.Max(stat.Max, b.max)
+		stat.Value = b.last
+		values = append(values, b.values...)
+	}
+	stat.Avg = sum / float64(stat.Count)
+	switch m.typ {
+	case MetricCounter:
+		stat.Value = sum
+		if runTime > 0 {
+			stat.Rate = sum / runTime.Seconds()
+		}
+	case MetricTrend:
+		stat.Value = stat.Avg
+		sort.Float64s(values)
+		stat.P90 = floatPercentile(values, 90)
+		stat.P95 = floatPercentile(values, 95)
+	}
+	return stat
+}
+
+// floatPercentile 在已排序的值中取第 p 百分位（最近秩法，与 percentileOf 相同）
+func floatPercentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// metricSeries 将 first 到 last 秒的分桶按 chartBucket 合并为趋势图数据
+func (c *Collector) metricSeries(names []string, first, last int64) []CustomMetricSample {
+	bucket := int64(chartBucket(time.Duration(last-first)*time.Second) / time.Second)
+	samples := make([]CustomMetricSample, 0, (last-first)/bucket+1)
+	for start := first; start <= last; start += bucket {
+		sample := CustomMetricSample{Timestamp: time.Unix(start, 0), Values: make(map[string]float64, len(names))}
+		for _, name := range names {
+			m := c.metrics[name]
+			var count int
+			var sum, lastValue float64
+			for sec := start; sec < start+bucket && sec <= last; sec++ {
+				if b, ok := m.seconds[sec]; ok {
+					count += b.count
+					sum += b.sum
+					lastValue = b.last
+				}
+			}
+			switch {
+			case m.typ == MetricCounter:
+				sample.Values[name] = sum / float64(bucket)
+			case count == 0:
+				// 仪表和趋势在没有上报的时间段不画点
+			case m.typ == MetricGauge:
+				sample.Values[name] = lastValue
+			default:
+				sample.Values[name] = sum / float64(count)
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// GenerateCustomMetricsChartAsync 生成自定义指标趋势图 custom_metrics_chart.html
+func GenerateCustomMetricsChartAsync(metrics []CustomMetricStat, samples []CustomMetricSample, dir string) (string, error) {
+	line, err := newCustomMetricsChart(metrics, samples, DefaultLanguage)
+	if err != nil {
+		return "", err
+	}
+	return writeChartHTML(line, filepath.Join(dir, "custom_metrics_chart.html"))
+}
+
+// newCustomMetricsChart 创建自定义指标趋势图，每个指标一条曲线，计数器显示为每秒的累计值
+func newCustomMetricsChart(metrics []CustomMetricStat, samples []CustomMetricSample, lang Language) (*charts.Line, error) {
+	if len(metrics) == 0 || len(samples) == 0 {
+		return nil, fmt.Errorf("no custom metric samples")
+	}
+
+	xAxis := make([]string, 0, len(samples))
+	for _, s := range samples {
+		xAxis = append(xAxis, s.Timestamp.Format("15:04:05"))
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("custom_metrics_chart_title"),
+			Subtitle: lang.text("chart_duration", samples[0].Timestamp.Format("15:04:05"), samples[len(samples)-1].Timestamp.Format("15:04:05")),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Trigger: "axis"}),
+	)
+	line.SetXAxis(xAxis)
+	for _, metric := range metrics {
+		data := make([]opts.LineData, 0, len(samples))
+		for _, s := range samples {
+			if value, ok := s.Values[metric.Name]; ok {
+				data = append(data, opts.LineData{Value: fmt.Sprintf("%.2f", value)})
+			} else {
+				data = append(data, opts.LineData{Value: "-"})
+			}
+		}
+		name := metric.Name
+		if metric.Type == MetricCounter {
+			name = lang.text("custom_metric_rate_series", metric.Name)
+		}
+		line.AddSeries(name, data)
+	}
+	return line, nil
+}
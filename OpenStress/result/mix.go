@@ -0,0 +1,26 @@
+// mix.go
+// 流量配比报告模块
+// 本文件负责在报告中展示流量配比的目标占比和实际占比（由 pool.TrafficMix 按权重选择任务），
+// 用于确认压测期间各业务的请求分布是否符合设计，例如目标 10% 的下单实际只跑到 3% 时，下单接口的结论需要谨慎看待。
+//
+// 技术实现细节：
+// 1. 配比由调用方在 VU 结束后通过 SetTrafficMix 写入（result 包不依赖 pool 包），没有设置时报告不展示该表。
+// 2. 实际占比按任务的迭代次数计算，一次迭代内的多个请求只算一次，与按请求数统计的接口占比不同。
+
+package result
+
+// TrafficShare 流量配比中一个任务的目标占比和实际占比
+type TrafficShare struct {
+	Task       string  // 任务名
+	Weight     int     // 权重
+	Target     float64 // 目标占比（百分比）
+	Iterations int64   // 实际执行的迭代次数
+	Actual     float64 // 实际占比（百分比）
+}
+
+// SetTrafficMix 记录流量配比的目标占比和实际占比（通常来自 pool.TrafficMix.Shares），写入报告
+func (c *Collector) SetTrafficMix(shares []TrafficShare) {
+	c.mu.Lock()
+	c.trafficMix = append([]TrafficShare(nil), shares...)
+	c.mu.Unlock()
+}
@@ -0,0 +1,232 @@
+// percentile.go
+// 响应时间百分位统计模块
+// 本文件负责计算响应时间百分位（P50/P90/P95/P99），并支持两种统计口径：
+// - 全量口径：所有样本原样参与统计
+// - 调整口径：排除重试样本、扣除客户端限流等待时间
+// 两种口径同时写入统计结果，默认口径由 LatencyOptions 决定，便于与组织的 SLO 定义对齐。
+
+package result
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LatencyOptions 响应时间统计口径配置
+type LatencyOptions struct {
+	ExcludeRetries      bool // 是否排除发生过重试的样本
+	ExcludeThrottleWait bool // 是否从响应时间中扣除客户端限流等待时间
+}
+
+// messageKey 返回统计口径在多语言文案中的键名
+func (o LatencyOptions) messageKey() string {
+	switch {
+	case o.ExcludeRetries && o.ExcludeThrottleWait:
+		return "latency_view_exclude_both"
+	case o.ExcludeRetries:
+		return "latency_view_exclude_retries"
+	case o.ExcludeThrottleWait:
+		return "latency_view_exclude_throttle"
+	default:
+		return "latency_view_all"
+	}
+}
+
+// Description 按报告语言返回统计口径的描述
+func (o LatencyOptions) Description(lang Language) string {
+	return lang.text(o.messageKey())
+}
+
+// percentileLevels 需要统计的百分位
+var percentileLevels = []float64{50, 90, 95, 99}
+
+// percentileKey 返回百分位在统计结果中的键名，例如 P95ResponseTime
+func percentileKey(p float64) string {
+	return fmt.Sprintf("P%gResponseTime", p)
+}
+
+// latencySamples 按统计口径提取响应时间样本
+func latencySamples(results []ResultData, opts LatencyOptions) []time.Duration {
+	samples := make([]time.Duration, 0, len(results))
+	for i := range results {
+		if rt, ok := opts.sample(&results[i]); ok {
+			samples = append(samples, rt)
+		}
+	}
+	return samples
+}
+
+// sample 按统计口径返回一条结果的响应时间样本，排除的样本返回 false
+func (o LatencyOptions) sample(result *ResultData) (time.Duration, bool) {
+	if o.ExcludeRetries && result.RetryCount > 0 {
+		return 0, false
+	}
+	rt := result.ResponseTime
+	if o.ExcludeThrottleWait && result.ThrottleWait > 0 {
+		rt -= result.ThrottleWait
+		if rt < 0 {
+			rt = 0
+		}
+	}
+	return rt, true
+}
+
+// calculatePercentiles 计算指定百分位（最近秩法），返回 键名 -> 响应时间
+func calculatePercentiles(samples []time.Duration) map[string]time.Duration {
+	values := make(map[string]time.Duration, len(percentileLevels))
+	if len(samples) == 0 {
+		for _, p := range percentileLevels {
+			values[percentileKey(p)] = 0
+		}
+		return values
+	}
+
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, p := range percentileLevels {
+		values[percentileKey(p)] = percentileOf(sorted, p)
+	}
+	return values
+}
+
+// percentileOf 在已排序的样本中取第 p 百分位
+func percentileOf(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// latencySummary 响应时间百分位及重试、限流等待汇总
+type latencySummary struct {
+	selected          map[string]time.Duration // 按 Collector 配置的口径
+	all               map[string]time.Duration // 全部样本
+	adjusted          map[string]time.Duration // 排除重试样本并扣除限流等待
+	retriedCount      int                      // 发生过重试的样本数
+	totalRetries      int                      // 所有样本的重试次数之和
+	totalThrottleWait time.Duration
+}
+
+// summarizeLatency 计算结果的响应时间百分位及重试、限流等待汇总
+func summarizeLatency(results []ResultData, opts LatencyOptions) latencySummary {
+	summary := latencySummary{
+		all:      calculatePercentiles(latencySamples(results, LatencyOptions{})),
+		adjusted: calculatePercentiles(latencySamples(results, LatencyOptions{ExcludeRetries: true, ExcludeThrottleWait: true})),
+		selected: calculatePercentiles(latencySamples(results, opts)),
+	}
+	for _, result := range results {
+		if result.RetryCount > 0 {
+			summary.retriedCount++
+			summary.totalRetries += result.RetryCount
+		}
+		summary.totalThrottleWait += result.ThrottleWait
+	}
+	return summary
+}
+
+// addLatencyPercentiles 将两种口径的百分位写入统计结果
+//   - P50ResponseTime 等键：按 Collector 配置的口径计算
+//   - LatencyPercentilesAll：全部样本
+//   - LatencyPercentilesAdjusted：排除重试样本并扣除限流等待
+func (c *Collector) addLatencyPercentiles(stats map[string]interface{}, summary latencySummary) {
+	for key, value := range summary.selected {
+		stats[key] = value
+	}
+	stats["LatencyView"] = c.latencyOptions.Description(c.language)
+	stats["LatencyPercentilesAll"] = summary.all
+	stats["LatencyPercentilesAdjusted"] = summary.adjusted
+	stats["RetriedCount"] = summary.retriedCount
+	stats["TotalRetries"] = summary.totalRetries
+	stats["TotalThrottleWait"] = summary.totalThrottleWait
+}
+
+// latencyHistogram 按响应时间计数的直方图，流式统计时代替样本切片；
+// 从 JTL 读取的响应时间精确到毫秒，不同取值的数量有限，百分位与排序样本的结果完全一致
+type latencyHistogram map[time.Duration]int
+
+// percentiles 计算指定百分位（最近秩法，与 calculatePercentiles 相同）
+func (h latencyHistogram) percentiles() map[string]time.Duration {
+	values := make(map[string]time.Duration, len(percentileLevels))
+	keys := make([]time.Duration, 0, len(h))
+	total := 0
+	for rt, n := range h {
+		keys = append(keys, rt)
+		total += n
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, p := range percentileLevels {
+		values[percentileKey(p)] = 0
+		if total == 0 {
+			continue
+		}
+		rank := int(p/100*float64(total)+0.5) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		if rank >= total {
+			rank = total - 1
+		}
+		seen := 0
+		for _, rt := range keys {
+			seen += h[rt]
+			if seen > rank {
+				values[percentileKey(p)] = rt
+				break
+			}
+		}
+	}
+	return values
+}
+
+// latencyAccumulator 逐条累计 latencySummary 所需的数据
+type latencyAccumulator struct {
+	opts                    LatencyOptions
+	all, adjusted, selected latencyHistogram
+	retriedCount            int
+	totalRetries            int
+	totalThrottleWait       time.Duration
+}
+
+// newLatencyAccumulator 创建按 opts 口径统计的累计器
+func newLatencyAccumulator(opts LatencyOptions) *latencyAccumulator {
+	return &latencyAccumulator{opts: opts, all: latencyHistogram{}, adjusted: latencyHistogram{}, selected: latencyHistogram{}}
+}
+
+// add 累计一条结果
+func (a *latencyAccumulator) add(result *ResultData) {
+	a.all[result.ResponseTime]++
+	if rt, ok := (LatencyOptions{ExcludeRetries: true, ExcludeThrottleWait: true}).sample(result); ok {
+		a.adjusted[rt]++
+	}
+	if rt, ok := a.opts.sample(result); ok {
+		a.selected[rt]++
+	}
+	if result.RetryCount > 0 {
+		a.retriedCount++
+		a.totalRetries += result.RetryCount
+	}
+	a.totalThrottleWait += result.ThrottleWait
+}
+
+// summary 返回累计结果
+func (a *latencyAccumulator) summary() latencySummary {
+	return latencySummary{
+		selected:          a.selected.percentiles(),
+		all:               a.all.percentiles(),
+		adjusted:          a.adjusted.percentiles(),
+		retriedCount:      a.retriedCount,
+		totalRetries:      a.totalRetries,
+		totalThrottleWait: a.totalThrottleWait,
+	}
+}
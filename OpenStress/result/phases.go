@@ -0,0 +1,198 @@
+// phases.go
+// 请求阶段耗时模块
+// 本文件负责记录 HTTP 请求各阶段的耗时（DNS 解析、TCP 连接、TLS 握手、首字节时间），
+// 并在报告中按时间段生成阶段耗时堆叠图，用于区分慢在建连、服务端处理还是响应下载。
+//
+// 技术实现细节：
+// 1. 阶段耗时由任务执行器（tasks.DoTimed）通过 httptrace 采集，ApplyPhases 写入结果，
+//    同时填充 JTL 的 Latency（首字节时间）和 Connect（DNS + TCP + TLS）列。
+// 2. 复用连接的请求没有 DNS、连接和握手阶段，对应耗时为 0。
+// 3. 没有首字节时间的结果（非 HTTP 任务或未采集阶段耗时）不计入阶段耗时图，全部没有时报告不展示该图。
+// 4. 阶段耗时按开始时间分段取平均，分段数不超过 maxPhasePoints，长时间测试时自动加大分段长度。
+// 5. 同时实现 TLSInfo 的阶段耗时（tasks.PhaseTimings）还会写入 TLS 版本和加密套件，按版本和套件的汇总见 tls.go。
+
+package result
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// maxPhasePoints 阶段耗时图的最大分段数
+const maxPhasePoints = 120
+
+// PhaseTimer 提供一次请求各阶段耗时（由 tasks.PhaseTimings 实现）
+type PhaseTimer interface {
+	ResultPhases() (dnsLookup, tcpConnect, tlsHandshake, ttfb time.Duration)
+}
+
+// TLSInfo 提供一次请求的 TLS 版本和加密套件（由 tasks.PhaseTimings 实现）
+type TLSInfo interface {
+	ResultTLS() (version, cipher string)
+}
+
+// ApplyPhases 将请求各阶段耗时写入结果，Connect 为建立连接的总耗时（毫秒）；
+// pt 同时实现 TLSInfo 时一并写入 TLS 版本和加密套件
+func (r *ResultData) ApplyPhases(pt PhaseTimer) {
+	r.DNSLookup, r.TCPConnect, r.TLSHandshake, r.TTFB = pt.ResultPhases()
+	r.Connect = (r.DNSLookup + r.TCPConnect + r.TLSHandshake).Milliseconds()
+	if info, ok := pt.(TLSInfo); ok {
+		r.TLSVersion, r.TLSCipher = info.ResultTLS()
+	}
+}
+
+// PhaseSample 一个时间段内各阶段的平均耗时
+type PhaseSample struct {
+	Timestamp    time.Time     // 时间段开始时间
+	Requests     int           // 时间段内有阶段耗时的请求数
+	DNSLookup    time.Duration // DNS 解析
+	TCPConnect   time.Duration // TCP 连接
+	TLSHandshake time.Duration // TLS 握手
+	ServerWait   time.Duration // 建立连接后到收到首字节
+	Download     time.Duration // 收到首字节后到读完响应
+}
+
+// phaseBreakdown 按开始时间分段计算各阶段的平均耗时，没有阶段耗时的结果不参与计算
+func phaseBreakdown(results []ResultData) []PhaseSample {
+	acc := newPhaseAccumulator()
+	for i := range results {
+		acc.add(&results[i])
+	}
+	return acc.breakdown()
+}
+
+// phaseAccumulator 按开始时间的秒逐条累计各阶段耗时，结束时再按分段长度合并，结果不依赖结果的顺序
+type phaseAccumulator struct {
+	seconds map[int64]*PhaseSample // 每秒各阶段耗时之和
+}
+
+// newPhaseAccumulator 创建空的阶段耗时累计
+func newPhaseAccumulator() *phaseAccumulator {
+	return &phaseAccumulator{seconds: make(map[int64]*PhaseSample)}
+}
+
+// add 累计一条结果，没有首字节时间的结果忽略
+func (a *phaseAccumulator) add(r *ResultData) {
+	if r.TTFB <= 0 {
+		return
+	}
+	sec := r.StartTime.Unix()
+	s := a.seconds[sec]
+	if s == nil {
+		s = &PhaseSample{}
+		a.seconds[sec] = s
+	}
+	connect := r.DNSLookup + r.TCPConnect + r.TLSHandshake
+	s.Requests++
+	s.DNSLookup += r.DNSLookup
+	s.TCPConnect += r.TCPConnect
+	s.TLSHandshake += r.TLSHandshake
+	s.ServerWait += max(r.TTFB-connect, 0)
+	s.Download += max(r.ResponseTime-r.ThrottleWait-r.TTFB, 0)
+}
+
+// breakdown 按分段长度合并每秒的耗时并取平均
+func (a *phaseAccumulator) breakdown() []PhaseSample {
+	if len(a.seconds) == 0 {
+		return nil
+	}
+	secs := make([]int64, 0, len(a.seconds))
+	for sec := range a.seconds {
+		secs = append(secs, sec)
+	}
+	sort.Slice(secs, func(i, j int) bool { return secs[i] < secs[j] })
+
+	first := secs[0]
+	bucket := int64(chartBucket(time.Duration(secs[len(secs)-1]-first)*time.Second) / time.Second)
+
+	var samples []PhaseSample
+	for _, sec := range secs {
+		start := time.Unix(first+(sec-first)/bucket*bucket, 0)
+		if len(samples) == 0 || !samples[len(samples)-1].Timestamp.Equal(start) {
+			samples = append(samples, PhaseSample{Timestamp: start})
+		}
+		s, total := &samples[len(samples)-1], a.seconds[sec]
+		s.Requests += total.Requests
+		s.DNSLookup += total.DNSLookup
+		s.TCPConnect += total.TCPConnect
+		s.TLSHandshake += total.TLSHandshake
+		s.ServerWait += total.ServerWait
+		s.Download += total.Download
+	}
+	for i := range samples {
+		n := time.Duration(samples[i].Requests)
+		samples[i].DNSLookup /= n
+		samples[i].TCPConnect /= n
+		samples[i].TLSHandshake /= n
+		samples[i].ServerWait /= n
+		samples[i].Download /= n
+	}
+	return samples
+}
+
+// chartBucket 返回趋势图的分段长度：默认 1 秒，时间跨度较长时加大分段，使分段数不超过 maxPhasePoints
+func chartBucket(span time.Duration) time.Duration {
+	if points := int(span/time.Second) + 1; points > maxPhasePoints {
+		return time.Duration((points+maxPhasePoints-1)/maxPhasePoints) * time.Second
+	}
+	return time.Second
+}
+
+// parseMillis 解析 JTL 中的毫秒数，无法解析时返回 0
+func parseMillis(s string) time.Duration {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// GeneratePhaseChartAsync 生成请求阶段耗时图 phase_chart.html
+func GeneratePhaseChartAsync(samples []PhaseSample, dir string) (string, error) {
+	line, err := newPhaseChart(samples, DefaultLanguage)
+	if err != nil {
+		return "", err
+	}
+	return writeChartHTML(line, filepath.Join(dir, "phase_chart.html"))
+}
+
+// newPhaseChart 创建请求阶段耗时堆叠图，各阶段叠加后为平均响应时间
+func newPhaseChart(samples []PhaseSample, lang Language) (*charts.Line, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no phase timings")
+	}
+
+	xAxis := make([]string, 0, len(samples))
+	series := make([][]opts.LineData, 5)
+	for _, s := range samples {
+		xAxis = append(xAxis, s.Timestamp.Format("15:04:05"))
+		for i, d := range []time.Duration{s.DNSLookup, s.TCPConnect, s.TLSHandshake, s.ServerWait, s.Download} {
+			series[i] = append(series[i], opts.LineData{Value: fmt.Sprintf("%.2f", float64(d)/float64(time.Millisecond))})
+		}
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("phase_chart_title"),
+			Subtitle: lang.text("chart_duration", samples[0].Timestamp.Format("15:04:05"), samples[len(samples)-1].Timestamp.Format("15:04:05")),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Trigger: "axis"}),
+	)
+	line.SetXAxis(xAxis)
+	stacked := charts.WithLineChartOpts(opts.LineChart{Stack: "phases"})
+	area := charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.4})
+	for i, key := range []string{"phase_series_dns", "phase_series_connect", "phase_series_tls", "phase_series_server", "phase_series_download"} {
+		line.AddSeries(lang.text(key), series[i], stacked, area)
+	}
+	return line, nil
+}
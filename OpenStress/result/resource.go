@@ -0,0 +1,145 @@
+// resource.go
+// 压测机资源使用模块
+// 本文件负责记录压测机（负载生成端）自身的资源使用情况，并在 HTML 报告中生成资源使用趋势图，
+// 用于判断瓶颈出现在被测服务还是压测机本身（例如 CPU 打满后 TPS 不再上升、响应时间上涨）。
+//
+// 技术实现细节：
+// 1. 资源采样由 pool.Monitor 定期产生，通过 Monitor.AddMetricsSink 转交给 Collector.RecordResourceSample。
+// 2. 统计时将采样写入 stats["ResourceSamples"]，没有采样时报告不展示资源使用图。
+// 3. 图表使用双 Y 轴：左轴为 CPU 使用率（%），右轴为内存（MB）和 goroutine 数量。
+// 4. pool.Monitor 判定的疑似内存泄漏（存活堆持续上涨）通过 Collector.RecordMemoryLeak 记录，
+//    写入 stats["MemoryLeaks"]，在资源使用图的内存曲线上标注为高亮区间。
+
+package result
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// maxResourceSamples 最多保留的资源采样数，超过后丢弃最早的采样
+const maxResourceSamples = 10000
+
+// ResourceSample 一次压测机资源采样
+type ResourceSample struct {
+	Timestamp   time.Time // 采样时间
+	CPUUsage    float64   // 进程 CPU 使用率（占全部核心的百分比）
+	MemoryUsage uint64    // 内存使用量（字节）
+	Goroutines  int       // goroutine 数量
+}
+
+// MemoryLeak 一段疑似内存泄漏的时间区间（来自 pool.Monitor 的存活堆趋势检测）
+type MemoryLeak struct {
+	Start           time.Time // 开始上涨的时间
+	End             time.Time // 最近一次仍判定为泄漏的时间
+	GrowthPerMinute float64   // 存活堆的增长速度（字节/分钟）
+}
+
+// RecordResourceSample 记录一次压测机资源采样
+func (c *Collector) RecordResourceSample(sample ResourceSample) {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	if len(c.resourceSamples) >= maxResourceSamples {
+		c.resourceSamples = c.resourceSamples[1:]
+	}
+	c.resourceSamples = append(c.resourceSamples, sample)
+}
+
+// RecordMemoryLeak 记录一段疑似内存泄漏，Start 与最后一段相同时视为同一段的更新，替换最后一段
+func (c *Collector) RecordMemoryLeak(leak MemoryLeak) {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	if n := len(c.memoryLeaks); n > 0 && c.memoryLeaks[n-1].Start.Equal(leak.Start) {
+		c.memoryLeaks[n-1] = leak
+		return
+	}
+	c.memoryLeaks = append(c.memoryLeaks, leak)
+}
+
+// MemoryLeaks 返回已记录的疑似内存泄漏区间的副本
+func (c *Collector) MemoryLeaks() []MemoryLeak {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	return append([]MemoryLeak(nil), c.memoryLeaks...)
+}
+
+// ResourceSamples 返回已记录资源采样的副本
+func (c *Collector) ResourceSamples() []ResourceSample {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	return append([]ResourceSample(nil), c.resourceSamples...)
+}
+
+// GenerateResourceChartAsync 生成压测机资源使用趋势图 resource_chart.html
+func GenerateResourceChartAsync(samples []ResourceSample, dir string) (string, error) {
+	line, err := newResourceChart(samples, nil, DefaultLanguage)
+	if err != nil {
+		return "", err
+	}
+	return writeChartHTML(line, filepath.Join(dir, "resource_chart.html"))
+}
+
+// newResourceChart 创建压测机资源使用趋势图，疑似内存泄漏的区间在内存曲线上高亮标注
+func newResourceChart(samples []ResourceSample, leaks []MemoryLeak, lang Language) (*charts.Line, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no resource samples")
+	}
+
+	xAxis := make([]string, 0, len(samples))
+	cpuData := make([]opts.LineData, 0, len(samples))
+	memoryData := make([]opts.LineData, 0, len(samples))
+	goroutineData := make([]opts.LineData, 0, len(samples))
+	for _, sample := range samples {
+		xAxis = append(xAxis, sample.Timestamp.Format("15:04:05"))
+		cpuData = append(cpuData, opts.LineData{Value: fmt.Sprintf("%.2f", sample.CPUUsage)})
+		memoryData = append(memoryData, opts.LineData{Value: fmt.Sprintf("%.2f", float64(sample.MemoryUsage)/(1024*1024))})
+		goroutineData = append(goroutineData, opts.LineData{Value: sample.Goroutines})
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    lang.text("resource_chart_title"),
+			Subtitle: lang.text("resource_chart_sampled", samples[0].Timestamp.Format("15:04:05"), samples[len(samples)-1].Timestamp.Format("15:04:05")),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Bottom: "bottom",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{Name: lang.text("resource_axis_cpu")}),
+	)
+	line.ExtendYAxis(opts.YAxis{Name: lang.text("resource_axis_memory"), Position: "right"})
+
+	line.SetXAxis(xAxis)
+	line.AddSeries(lang.text("resource_series_cpu"), cpuData)
+	line.AddSeries(lang.text("resource_series_memory"), memoryData, append(leakMarkAreas(samples, leaks, lang), charts.WithLineChartOpts(opts.LineChart{YAxisIndex: 1}))...)
+	line.AddSeries(lang.text("resource_series_goroutines"), goroutineData, charts.WithLineChartOpts(opts.LineChart{YAxisIndex: 1}))
+
+	return line, nil
+}
+
+// leakMarkAreas 把疑似内存泄漏区间转换为内存曲线上的标注区域，区间边界对齐到最近的采样时间
+func leakMarkAreas(samples []ResourceSample, leaks []MemoryLeak, lang Language) []charts.SeriesOpts {
+	var seriesOpts []charts.SeriesOpts
+	for _, leak := range leaks {
+		start := sort.Search(len(samples), func(i int) bool { return !samples[i].Timestamp.Before(leak.Start) })
+		end := sort.Search(len(samples), func(i int) bool { return samples[i].Timestamp.After(leak.End) }) - 1
+		if start >= len(samples) || end < start {
+			continue
+		}
+		seriesOpts = append(seriesOpts, charts.WithMarkAreaData([]opts.MarkAreaData{
+			{Name: lang.text("resource_memory_leak", leak.GrowthPerMinute/(1024*1024)), XAxis: samples[start].Timestamp.Format("15:04:05")},
+			{XAxis: samples[end].Timestamp.Format("15:04:05")},
+		}))
+	}
+	if len(seriesOpts) > 0 {
+		seriesOpts = append(seriesOpts, charts.WithMarkAreaStyleOpts(opts.MarkAreaStyle{
+			ItemStyle: &opts.ItemStyle{Color: "rgba(255, 99, 71, 0.2)"},
+		}))
+	}
+	return seriesOpts
+}
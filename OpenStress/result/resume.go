@@ -0,0 +1,100 @@
+// resume.go
+// 断点续跑模块
+// 本文件负责收集器计数器，以及从中断的运行继续写入同一个 JTL 文件。
+//
+// 技术实现细节：
+// 1. 计数器在保存结果时更新（与 results 共用 c.mu），供检查点定期保存。
+// 2. 续跑时不再生成新的 JTL 文件名，而是追加写入上次的文件，最终报告覆盖两次运行的全部结果。
+// 3. 进程崩溃可能留下写了一半的最后一行，续跑前截断到最后一个换行符，避免 CSV 解析失败。
+// 4. 续跑时的计数器以 JTL 文件中的记录为准重新统计，检查点之后、崩溃之前写入的结果也会计入。
+
+package result
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+// CollectorCounters 收集器计数器
+type CollectorCounters struct {
+	Written int64 `json:"written"` // 已写入 JTL 文件的结果数
+	Success int64 `json:"success"` // 成功结果数
+	Failure int64 `json:"failure"` // 失败结果数
+}
+
+// Counters 返回收集器计数器的快照，先等待此前提交的结果处理完（见 Flush）
+func (c *Collector) Counters() CollectorCounters {
+	c.Flush()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.counters
+}
+
+// JTLFilePath 返回收集器写入的 JTL 文件路径
+func (c *Collector) JTLFilePath() string {
+	return c.jtlFilePath
+}
+
+// countResult 在保存结果后更新计数器和实时统计，调用方需持有 c.mu
+func (c *Collector) countResult(data ResultData, written bool) {
+	if data.Type == Success {
+		c.counters.Success++
+	} else {
+		c.counters.Failure++
+	}
+	if written {
+		c.counters.Written++
+	}
+	c.recordLive(data)
+}
+
+// prepareResumeJTL 截断 JTL 文件末尾不完整的行，并根据文件中的记录重新统计计数器
+func prepareResumeJTL(path string) (CollectorCounters, error) {
+	var counters CollectorCounters
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// 上次运行还没有写入任何结果
+		return counters, nil
+	}
+	if err != nil {
+		return counters, fmt.Errorf("failed to read JTL file to resume: %v", err)
+	}
+	if n := bytes.LastIndexByte(data, '\n') + 1; n < len(data) {
+		if err := os.Truncate(path, int64(n)); err != nil {
+			return counters, fmt.Errorf("failed to truncate incomplete JTL record: %v", err)
+		}
+		data = data[:n]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	if _, err := reader.Read(); err != nil {
+		if err == io.EOF {
+			return counters, nil
+		}
+		return counters, fmt.Errorf("failed to read JTL header: %v", err)
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return counters, fmt.Errorf("failed to read JTL record: %v", err)
+		}
+		if len(record) < 8 {
+			continue
+		}
+		counters.Written++
+		if record[7] == "true" {
+			counters.Success++
+		} else {
+			counters.Failure++
+		}
+	}
+	return counters, nil
+}
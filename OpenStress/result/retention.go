@@ -0,0 +1,82 @@
+// retention.go
+// 结果保留策略模块
+// 本文件负责控制内存中保留多少条结果，避免持续数天的稳定性（Soak）测试中结果切片无限增长耗尽内存。
+//
+// 技术实现细节：
+// 1. all（默认）：保留全部结果，与之前的行为一致。
+// 2. last：环形缓冲，只保留最近 ResultRetentionLimit 条结果，Results 和阶段报告只包含这些结果。
+// 3. aggregates：内存中不保留结果，只保留计数器、实时统计等汇总数据，Results 返回空；
+//    完整统计通过 AnalyzeFile 从 JTL 文件流式计算。
+// 4. disk：内存中不保留结果，需要结果时从 JTL 文件读取（Results 等同于 LoadResultsFromFile，阶段报告按时间窗口过滤）。
+
+package result
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 结果保留策略
+const (
+	RetainAll        = "all"
+	RetainLast       = "last"
+	RetainAggregates = "aggregates"
+	RetainDisk       = "disk"
+)
+
+// ParseResultRetention 解析结果保留策略，大小写不敏感；空字符串返回 RetainAll。last 策略要求 limit 为正数
+func ParseResultRetention(s string, limit int) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(s)); mode {
+	case "":
+		return RetainAll, nil
+	case RetainLast:
+		if limit <= 0 {
+			return "", fmt.Errorf("result retention %q requires a positive limit", mode)
+		}
+		return mode, nil
+	case RetainAll, RetainAggregates, RetainDisk:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported result retention %q (supported: %s, %s, %s, %s)", s, RetainAll, RetainLast, RetainAggregates, RetainDisk)
+}
+
+// resultStore 按保留策略在内存中保存结果，由 Collector.mu 保护
+type resultStore struct {
+	mode  string
+	limit int          // last 策略的最大条数
+	items []ResultData // last 策略下为环形缓冲
+	next  int          // 环形缓冲中下一条结果的写入位置
+}
+
+// add 保存一条结果
+func (s *resultStore) add(data ResultData) {
+	switch s.mode {
+	case RetainAggregates, RetainDisk:
+		return
+	case RetainLast:
+		if len(s.items) < s.limit {
+			s.items = append(s.items, data)
+			return
+		}
+		s.items[s.next] = data
+		s.next = (s.next + 1) % s.limit
+		return
+	}
+	s.items = append(s.items, data)
+}
+
+// each 按保存顺序遍历内存中的结果
+func (s *resultStore) each(fn func(data *ResultData)) {
+	for i := range s.items {
+		fn(&s.items[(s.next+i)%len(s.items)])
+	}
+}
+
+// snapshot 按保存顺序复制内存中的结果
+func (s *resultStore) snapshot() []ResultData {
+	results := make([]ResultData, 0, len(s.items))
+	s.each(func(data *ResultData) {
+		results = append(results, *data)
+	})
+	return results
+}
@@ -0,0 +1,245 @@
+// rotation.go
+// JTL 压缩与轮转模块
+// 本文件负责长时间稳定性测试中 JTL 文件的 gzip 压缩写入和按大小/时间轮转，以及跨分段读取结果。
+//
+// 技术实现细节：
+// 1. 分段命名：第一个分段使用原文件名，之后的分段在扩展名前插入序号，压缩时追加 .gz，
+//    例如 test_result_x.jtl.gz、test_result_x.1.jtl.gz、test_result_x.2.jtl.gz。JTLFilePath 仍返回原文件名。
+// 2. 每个分段都有表头，可以单独用 JMeter 等工具打开；写入新一批结果前检查当前分段的大小（压缩时为压缩后的大小）
+//    和写入时长，超过 JTLRotateSize 或 JTLRotateInterval 时关闭当前分段并开始下一个分段。
+// 3. 当前分段保持打开，每批结果写入后刷新（gzip 写入同步块），运行中生成阶段报告时也能读到已写入的结果；
+//    读取未关闭的 gzip 分段时忽略缺少的结尾，只使用完整的行。
+// 4. LoadResultsFromFile 和 AnalyzeFile 按序号依次流式读取所有存在的分段（普通或 .gz），
+//    未配置压缩和轮转时只有原文件一个分段。
+
+package result
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jtlSegments JTL 分段写入器，调用方需持有 c.mu
+type jtlSegments struct {
+	base     string        // 原文件名
+	compress bool          // 是否 gzip 压缩
+	maxSize  int64         // 单个分段的最大字节数，0 表示不限制
+	interval time.Duration // 单个分段的最长写入时间，0 表示不限制
+
+	index  int
+	file   *os.File
+	gz     *gzip.Writer
+	csv    *csv.Writer
+	size   int64 // 当前分段写入磁盘的字节数
+	opened time.Time
+}
+
+// countingWriter 统计写入文件的字节数
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+// Write 实现 io.Writer
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	*w.n += int64(n)
+	return n, err
+}
+
+// jtlSegmentPath 返回第 index 个分段的文件名
+func jtlSegmentPath(base string, index int, compress bool) string {
+	path := base
+	if index > 0 {
+		ext := filepath.Ext(base)
+		path = strings.TrimSuffix(base, ext) + fmt.Sprintf(".%d", index) + ext
+	}
+	if compress {
+		path += ".gz"
+	}
+	return path
+}
+
+// write 写入一批记录，需要时先轮转到下一个分段
+func (s *jtlSegments) write(records [][]string) error {
+	if s.file != nil && ((s.maxSize > 0 && s.size >= s.maxSize) || (s.interval > 0 && time.Since(s.opened) >= s.interval)) {
+		if err := s.close(); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		if err := s.csv.Write(record); err != nil {
+			return fmt.Errorf("failed to write record: %v", err)
+		}
+	}
+	s.csv.Flush()
+	if err := s.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write record: %v", err)
+	}
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			return fmt.Errorf("failed to flush compressed JTL segment: %v", err)
+		}
+	}
+	return nil
+}
+
+// open 创建当前序号的分段并写入表头
+func (s *jtlSegments) open() error {
+	path := jtlSegmentPath(s.base, s.index, s.compress)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open JTL segment: %v", err)
+	}
+	s.file = file
+	s.size = 0
+	s.opened = time.Now()
+	var w io.Writer = countingWriter{w: file, n: &s.size}
+	if s.compress {
+		s.gz = gzip.NewWriter(w)
+		w = s.gz
+	}
+	s.csv = csv.NewWriter(w)
+	if err := s.csv.Write(jtlHeaders); err != nil {
+		return fmt.Errorf("failed to write headers: %v", err)
+	}
+	return nil
+}
+
+// close 关闭当前分段，gzip 分段写入结尾，之后的写入使用下一个序号，不会覆盖已关闭的分段
+func (s *jtlSegments) close() error {
+	if s.file == nil {
+		return nil
+	}
+	s.index++
+	var err error
+	if s.gz != nil {
+		err = s.gz.Close()
+		s.gz = nil
+	}
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file, s.csv = nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to close JTL segment: %v", err)
+	}
+	return nil
+}
+
+// listJTLSegments 按序号返回所有存在的分段（普通或 .gz）
+func listJTLSegments(base string) []string {
+	var paths []string
+	for i := 0; ; i++ {
+		path := jtlSegmentPath(base, i, false)
+		if _, err := os.Stat(path); err != nil {
+			path += ".gz"
+			if _, err := os.Stat(path); err != nil {
+				return paths
+			}
+		}
+		paths = append(paths, path)
+	}
+}
+
+// streamJTLRecords 按顺序逐条读取所有分段的记录（不含表头），不把整个文件读入内存；fn 返回错误时停止读取
+func streamJTLRecords(base string, fn func(record []string) error) error {
+	paths := listJTLSegments(base)
+	if len(paths) == 0 {
+		return fmt.Errorf("failed to open result file: %v", &os.PathError{Op: "open", Path: base, Err: os.ErrNotExist})
+	}
+	for _, path := range paths {
+		if err := streamJTLSegment(path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamJTLSegment 逐条读取一个分段的记录，未关闭的 gzip 分段只使用完整的行
+func streamJTLSegment(path string, fn func(record []string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open result file: %v", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	compressed := strings.HasSuffix(path, ".gz")
+	if compressed {
+		gz, err := gzip.NewReader(file)
+		if err == io.EOF {
+			return nil // 刚创建还没有写入内容的分段
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open compressed result file %s: %v", path, err)
+		}
+		reader = &completeLinesReader{r: gz}
+	}
+
+	csvReader := csv.NewReader(reader)
+	// 跳过文件的标题行
+	if _, err := csvReader.Read(); err != nil {
+		if err == io.EOF && compressed {
+			return nil
+		}
+		return fmt.Errorf("failed to read header: %v", err)
+	}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV records: %v", err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// completeLinesReader 读取未关闭的 gzip 分段：数据意外结束时丢弃最后一个换行符之后不完整的部分
+type completeLinesReader struct {
+	r       io.Reader
+	pending []byte // 已读取但尚未返回的数据
+	err     error  // 底层读取的结束原因
+}
+
+// Read 实现 io.Reader，只返回到最后一个换行符为止的数据，正常结束时返回剩余的全部数据
+func (c *completeLinesReader) Read(p []byte) (int, error) {
+	for {
+		end := bytes.LastIndexByte(c.pending, '\n') + 1
+		if c.err == io.EOF {
+			end = len(c.pending)
+		}
+		if end > 0 {
+			n := copy(p, c.pending[:end])
+			c.pending = c.pending[n:]
+			return n, nil
+		}
+		if c.err != nil {
+			if c.err == io.ErrUnexpectedEOF {
+				return 0, io.EOF
+			}
+			return 0, c.err
+		}
+		chunk := make([]byte, 32*1024)
+		n, err := c.r.Read(chunk)
+		c.pending = append(c.pending, chunk[:n]...)
+		c.err = err
+	}
+}
@@ -0,0 +1,26 @@
+// sampleid.go
+// 样本ID模块
+// 本文件负责为调用方没有指定 ID 的结果生成唯一的样本ID，便于在 JTL、Elasticsearch 和报告明细中定位单个请求。
+//
+// 技术实现细节：
+// 1. 样本ID 由运行ID（任务ID，与 Elasticsearch 文档的 run_id 相同）、VU 编号、迭代次数和收集器内递增的序号组成，
+//    例如 api-vu3-it12-1042；没有追踪信息的结果为 api-1042。序号使用原子计数器，并发保存结果时不会重复。
+// 2. 续跑时序号从 JTL 文件中已有的记录数开始，不与上次运行的样本重复。
+// 3. 样本ID 写入 JTL 的 sampleId 列，加载时恢复；旧版本文件没有该列。
+
+package result
+
+import "fmt"
+
+// assignSampleID 结果没有 ID 时生成唯一的样本ID
+func (c *Collector) assignSampleID(data *ResultData) {
+	if data.ID != "" {
+		return
+	}
+	seq := c.sampleSeq.Add(1)
+	if data.TraceID != "" {
+		data.ID = fmt.Sprintf("%s-vu%d-it%d-%d", c.taskID, data.VUID, data.Iteration, seq)
+		return
+	}
+	data.ID = fmt.Sprintf("%s-%d", c.taskID, seq)
+}
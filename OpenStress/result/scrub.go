@@ -0,0 +1,114 @@
+// scrub.go
+// 结果脱敏模块
+// 本文件负责对含真实数据的环境压测时，在结果写入 JTL 文件、Elasticsearch 和报告之前去掉可能包含用户数据的内容，
+// 满足合规要求：URL 查询参数、URL 和消息中的用户标识，以及响应消息和失败响应体。
+//
+// 技术实现细节：
+// 1. 配置见 config.ScrubConfig，各选项相互独立，全部关闭时不创建脱敏器，保存结果时没有额外开销。
+// 2. 在 redactResult（按字段名隐藏密钥）之后处理，之后的统计、报告、导出和按 URL 的汇总都只看到脱敏后的结果。
+// 3. 用户标识替换为占位符而不是删除：邮箱为 {email}，UUID 为 {uuid}，URL 路径中的纯数字段为 {id}，
+//    同一接口不同用户的请求脱敏后 URL 相同，按 URL 汇总的统计仍然有意义。
+//    错误信息中只替换邮箱、UUID 和自定义标识，不替换数字，避免状态码、端口等有用信息丢失。
+// 4. 丢弃响应消息时保留错误信息（同样经过标识替换），主要错误的汇总依赖错误信息。
+
+package result
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/potatoImp/OpenStress/config"
+)
+
+// 用户标识的识别规则
+var (
+	emailPattern     = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	uuidPattern      = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	numericIDPattern = regexp.MustCompile(`/[0-9]+(/|$)`)
+)
+
+// scrubber 结果脱敏器
+type scrubber struct {
+	stripQuery      bool
+	maskIdentifiers bool
+	dropMessages    bool
+	identifiers     []*regexp.Regexp // 自定义的用户标识
+}
+
+// newScrubber 根据配置创建脱敏器，没有启用任何选项时返回 nil
+func newScrubber(cfg config.ScrubConfig) (*scrubber, error) {
+	if !cfg.StripQuery && !cfg.MaskIdentifiers && !cfg.DropMessages {
+		return nil, nil
+	}
+	s := &scrubber{stripQuery: cfg.StripQuery, maskIdentifiers: cfg.MaskIdentifiers, dropMessages: cfg.DropMessages}
+	for _, pattern := range cfg.Identifiers {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrub identifier pattern %q: %v", pattern, err)
+		}
+		s.identifiers = append(s.identifiers, re)
+	}
+	return s, nil
+}
+
+// apply 对一条结果脱敏，s 为 nil 时不做处理
+func (s *scrubber) apply(data *ResultData) {
+	if s == nil {
+		return
+	}
+	if s.stripQuery {
+		if i := strings.IndexAny(data.URL, "?#"); i >= 0 {
+			data.URL = data.URL[:i]
+		}
+	}
+	if s.dropMessages {
+		data.ResponseMsg = ""
+		data.ResponseBody = ""
+	}
+	if s.maskIdentifiers {
+		data.URL = s.maskURL(data.URL)
+		data.ErrorMessage = s.maskText(data.ErrorMessage)
+		data.ResponseMsg = s.maskText(data.ResponseMsg)
+		data.ResponseBody = s.maskText(data.ResponseBody)
+	}
+}
+
+// maskText 替换文本中的邮箱、UUID 和自定义标识
+func (s *scrubber) maskText(text string) string {
+	if text == "" {
+		return text
+	}
+	text = emailPattern.ReplaceAllString(text, "{email}")
+	text = uuidPattern.ReplaceAllString(text, "{uuid}")
+	for _, re := range s.identifiers {
+		text = re.ReplaceAllString(text, "{id}")
+	}
+	return text
+}
+
+// maskURL 替换 URL 中的用户标识，路径中的纯数字段替换为 {id}，主机名和端口不受影响
+func (s *scrubber) maskURL(url string) string {
+	url = s.maskText(url)
+	prefix, path := "", url
+	if i := strings.Index(url, "://"); i >= 0 {
+		if j := strings.IndexByte(url[i+3:], '/'); j >= 0 {
+			prefix, path = url[:i+3+j], url[i+3+j:]
+		} else {
+			return url
+		}
+	}
+	query := ""
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, query = path[:i], path[i:]
+	}
+	// 相邻的数字段共用分隔符，替换一次后可能留下下一段，重复替换直到没有变化
+	for {
+		masked := numericIDPattern.ReplaceAllString(path, "/{id}$1")
+		if masked == path {
+			break
+		}
+		path = masked
+	}
+	return prefix + path + query
+}
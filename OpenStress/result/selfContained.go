@@ -0,0 +1,31 @@
+// selfContained.go
+// 自包含报告模块
+// 本文件负责生成单文件 HTML 报告：样式、echarts 脚本和图表数据全部内联到页面中，
+// 报告可以直接作为邮件附件或上传到工单系统，离线打开也能正常显示图表。
+//
+// 技术实现细节：
+// 1. echarts 脚本使用 assets.go 中内嵌的 echartsJS，不依赖 CDN。
+// 2. 图表与 static 目录中的图表页面同样由 buildReportCharts 构建，通过 RenderSnippet 取得图表元素和初始化脚本嵌入报告。
+// 3. 某张图没有数据或构建失败时只跳过该图，其余内容照常生成。
+
+package result
+
+import (
+	"github.com/go-echarts/go-echarts/v2/render"
+)
+
+// GenerateSelfContainedHTMLReport 生成单文件的性能测试报告HTML，不引用任何外部文件
+func GenerateSelfContainedHTMLReport(stats map[string]interface{}, title ...string) string {
+	report, _ := generateHTMLReport(stats, title, reportChartSnippets(stats), nil) // 默认模板不会执行失败
+	return report
+}
+
+// reportChartSnippets 构建报告中的各张图表，返回图表名到图表片段的映射
+func reportChartSnippets(stats map[string]interface{}) map[string]render.ChartSnippet {
+	lines, _ := buildReportCharts(stats) // 构建失败的图表不展示
+	snippets := make(map[string]render.ChartSnippet, len(lines))
+	for name, line := range lines {
+		snippets[name] = line.RenderSnippet()
+	}
+	return snippets
+}
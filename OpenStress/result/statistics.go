@@ -1,169 +1,222 @@
 package result
 
 import (
-	"encoding/csv"
 	"fmt"
 	"math"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/potatoImp/OpenStress/random"
 )
 
-// LoadResultsFromFile 从本地文件异步加载结果数据
+// LoadResultsFromFile 从本地文件加载结果数据，无法解析的记录跳过。先等待此前提交的结果写入文件（见 Flush）
 func (c *Collector) LoadResultsFromFile() ([]ResultData, error) {
-	// 打开结果文件
+	c.Flush()
 	fmt.Println("Loading results from file:", c.jtlFilePath)
-	file, err := os.Open(c.jtlFilePath)
+	var results []ResultData
+	line := 0
+	// 逐条读取所有分段（配置了压缩或轮转时可能有多个 .gz 分段）的记录，不含表头
+	err := streamJTLRecords(c.jtlFilePath, func(record []string) error {
+		line++
+		result, err := parseJTLRecord(record)
+		if err != nil {
+			fmt.Printf("Skipping record at line %d: %v\n", line, err)
+			return nil
+		}
+		results = append(results, result)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open result file: %v", err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// parseJTLRecord 解析一条 JTL 记录，兼容缺少新增列的旧版本文件
+func parseJTLRecord(record []string) (ResultData, error) {
+	// 确保记录有足够的字段
+	if len(record) < 17 {
+		return ResultData{}, fmt.Errorf("incomplete record: %+v", record)
 	}
-	defer file.Close()
 
-	// 读取 CSV 文件
-	reader := csv.NewReader(file)
-	// 跳过文件的标题行
-	if _, err := reader.Read(); err != nil {
-		return nil, fmt.Errorf("failed to read header: %v", err)
+	// 解析每个字段
+	id := record[0]
+	var resultType ResultType
+	if record[7] == "true" {
+		resultType = Success
+	} else if record[7] == "false" {
+		resultType = Failure
+	}
+
+	// 响应时间
+	responseTime, err := time.ParseDuration(record[1] + "ms") // 假设是毫秒单位
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse response time: %v", err)
 	}
 
-	// 读取所有行
-	records, err := reader.ReadAll()
+	// 状态码
+	statusCode, err := strconv.Atoi(record[3])
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV records: %v", err)
-	}
-
-	// 使用 channel 与协程并发分析数据
-	dataChannel := make(chan ResultData, len(records)) // 使用缓冲channel来减少阻塞
-
-	// 启动协程进行数据处理
-	go func() {
-		defer close(dataChannel) // 结束后关闭 channel
-		for i, record := range records {
-			// 确保记录有足够的字段
-			if len(record) < 17 {
-				fmt.Printf("Skipping incomplete record at line %d: %+v\n", i+1, record)
-				continue // 忽略不完整的记录
-			}
-
-			// 解析每个字段
-			id := record[0]
-			var resultType ResultType
-			if record[7] == "true" {
-				resultType = Success
-			} else if record[7] == "false" {
-				resultType = Failure
-			}
-
-			// 响应时间
-			responseTime, err := time.ParseDuration(record[1] + "ms") // 假设是毫秒单位
-			if err != nil {
-				fmt.Printf("failed to parse response time at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// 状态码
-			statusCode, err := strconv.Atoi(record[3])
-			if err != nil {
-				fmt.Printf("failed to parse status code at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// 时间戳转换为开始时间
-			timeStamp, err := strconv.ParseInt(record[0], 10, 64)
-			if err != nil {
-				fmt.Printf("failed to parse timestamp at line %d: %v\n", i+1, err)
-				continue
-			}
-			startTime := time.Unix(0, timeStamp*int64(time.Millisecond))
-
-			// 线程ID
-			threadID, err := strconv.Atoi(record[9])
-			if err != nil {
-				fmt.Printf("failed to parse thread ID at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// URL
-			url := record[13]
-
-			// 请求方法
-			method := record[2] // 假设是 GET/POST 等方法
-
-			// 发送和接收的数据大小
-			dataSent, err := strconv.ParseInt(record[10], 10, 64)
-			if err != nil {
-				fmt.Printf("failed to parse data sent at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			dataReceived, err := strconv.ParseInt(record[11], 10, 64)
-			if err != nil {
-				fmt.Printf("failed to parse data received at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// 数据类型
-			dataType := record[6]
-
-			// 响应信息
-			responseMsg := record[5]
-
-			// 线程组中的线程数
-			grpThreads, err := strconv.Atoi(record[12])
-			if err != nil {
-				fmt.Printf("failed to parse group threads at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// 所有线程数
-			allThreads, err := strconv.Atoi(record[14])
-			if err != nil {
-				fmt.Printf("failed to parse all threads at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// 连接花费时间
-			connect, err := strconv.ParseInt(record[15], 10, 64)
-			if err != nil {
-				fmt.Printf("failed to parse connect time at line %d: %v\n", i+1, err)
-				continue
-			}
-
-			// 生成 ResultData
-			result := ResultData{
-				ID:           id,
-				Type:         resultType,
-				ResponseTime: responseTime,
-				StartTime:    startTime,
-				EndTime:      startTime.Add(responseTime), // 假设结束时间等于开始时间加上响应时间
-				StatusCode:   statusCode,
-				ThreadID:     threadID,
-				URL:          url,
-				Method:       method,
-				DataSent:     dataSent,
-				DataReceived: dataReceived,
-				DataType:     dataType,
-				ResponseMsg:  responseMsg,
-				GrpThreads:   grpThreads,
-				AllThreads:   allThreads,
-				Connect:      connect,
-			}
-
-			// 将解析的结果传递给主协程进行处理
-			dataChannel <- result
+		return ResultData{}, fmt.Errorf("failed to parse status code: %v", err)
+	}
+
+	// 时间戳转换为开始时间
+	timeStamp, err := strconv.ParseInt(record[0], 10, 64)
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse timestamp: %v", err)
+	}
+	startTime := time.Unix(0, timeStamp*int64(time.Millisecond))
+
+	// 线程ID
+	threadID, err := strconv.Atoi(record[9])
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse thread ID: %v", err)
+	}
+
+	// URL
+	url := record[13]
+
+	// 请求方法
+	method := record[2] // 假设是 GET/POST 等方法
+
+	// 发送和接收的数据大小
+	dataSent, err := strconv.ParseInt(record[10], 10, 64)
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse data sent: %v", err)
+	}
+
+	dataReceived, err := strconv.ParseInt(record[11], 10, 64)
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse data received: %v", err)
+	}
+
+	// 数据类型
+	dataType := record[6]
+
+	// 响应信息
+	responseMsg := record[5]
+
+	// 线程组中的线程数
+	grpThreads, err := strconv.Atoi(record[11])
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse group threads: %v", err)
+	}
+
+	// 所有线程数
+	allThreads, err := strconv.Atoi(record[12])
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse all threads: %v", err)
+	}
+
+	// 连接花费时间
+	connect, err := strconv.ParseInt(record[16], 10, 64)
+	if err != nil {
+		return ResultData{}, fmt.Errorf("failed to parse connect time: %v", err)
+	}
+
+	// 重试次数和限流等待时间（旧版本文件没有这两列）
+	var retryCount int
+	var throttleWait time.Duration
+	if len(record) >= 19 {
+		retryCount, _ = strconv.Atoi(record[17])
+		if waitMs, err := strconv.ParseInt(record[18], 10, 64); err == nil {
+			throttleWait = time.Duration(waitMs) * time.Millisecond
 		}
-	}()
+	}
 
-	var results []ResultData
-	// 等待所有数据解析完成
-	for result := range dataChannel {
-		results = append(results, result)
+	// 内容断言结果（旧版本文件没有该列）
+	var assertions map[string]bool
+	if len(record) >= 20 {
+		assertions = decodeAssertions(record[19])
 	}
 
-	return results, nil
+	// 追踪ID、VU 编号和迭代次数（旧版本文件没有这三列）
+	var traceID string
+	var vuID, iteration int
+	if len(record) >= 23 {
+		traceID = record[20]
+		vuID, _ = strconv.Atoi(record[21])
+		iteration, _ = strconv.Atoi(record[22])
+	}
+
+	// 首字节时间（Latency 列）和各阶段耗时（旧版本文件没有阶段耗时列）
+	var dnsLookup, tcpConnect, tlsHandshake time.Duration
+	latency, _ := strconv.ParseInt(record[14], 10, 64)
+	if len(record) >= 26 {
+		dnsLookup = parseMillis(record[23])
+		tcpConnect = parseMillis(record[24])
+		tlsHandshake = parseMillis(record[25])
+	}
+	var rows int64
+	if len(record) >= 27 {
+		rows, _ = strconv.ParseInt(record[26], 10, 64)
+	}
+	var responseBody string
+	if len(record) >= 28 {
+		responseBody = record[27]
+	}
+	// 超时结果（旧版本文件没有该列，超时记为普通失败）
+	if len(record) >= 29 && record[28] == "true" {
+		resultType = Timeout
+	}
+	// 样本ID（旧版本文件没有该列，沿用时间戳列）
+	if len(record) >= 30 {
+		id = record[29]
+	}
+	var label string
+	if len(record) >= 31 {
+		label = record[30]
+	}
+	var tlsVersion, tlsCipher string
+	if len(record) >= 33 {
+		tlsVersion, tlsCipher = record[31], record[32]
+	}
+
+	// 事务样本（dataType 列为 TransactionDataType，label 列为事务名）
+	var transaction string
+	if dataType == TransactionDataType {
+		transaction, method = method, ""
+	}
+
+	return ResultData{
+		ID:           id,
+		Type:         resultType,
+		ResponseTime: responseTime,
+		StartTime:    startTime,
+		EndTime:      startTime.Add(responseTime), // 假设结束时间等于开始时间加上响应时间
+		StatusCode:   statusCode,
+		ErrorMessage: record[8], // failureMessage 列，错误分析按状态码和错误信息汇总
+		ThreadID:     threadID,
+		URL:          url,
+		Method:       method,
+		DataSent:     dataSent,
+		DataReceived: dataReceived,
+		DataType:     dataType,
+		ResponseMsg:  responseMsg,
+		GrpThreads:   grpThreads,
+		AllThreads:   allThreads,
+		Connect:      connect,
+		RetryCount:   retryCount,
+		ThrottleWait: throttleWait,
+		Assertions:   assertions,
+		TraceID:      traceID,
+		VUID:         vuID,
+		Iteration:    iteration,
+		DNSLookup:    dnsLookup,
+		TCPConnect:   tcpConnect,
+		TLSHandshake: tlsHandshake,
+		TLSVersion:   tlsVersion,
+		TLSCipher:    tlsCipher,
+		TTFB:         time.Duration(latency) * time.Millisecond,
+		Transaction:  transaction,
+		Rows:         rows,
+		ResponseBody: responseBody,
+		Label:        label,
+	}, nil
 }
 
 // formatBytes 将字节数转换为适当的单位
@@ -181,45 +234,97 @@ func formatBytes(bytes int64) string {
 	}
 }
 
+// GeneratePerformanceStats 生成性能统计数据，并保存为最近一次统计结果
 func (c *Collector) GeneratePerformanceStats(results []ResultData) (map[string]interface{}, error) {
-	var totalRequests, successCount, failureCount int
-	var totalResponseTime time.Duration
-	var maxResponseTime, minResponseTime time.Duration = 0, time.Hour * 24 * 365 // 初始为很大值
-	var totalSentData, totalReceivedData int64
+	stats, err := c.computePerformanceStats(results)
+	if err != nil {
+		return nil, err
+	}
 
-	var firstTimestamp int64 = results[0].StartTime.UnixMilli() // 第一条记录的时间戳
-	var lastTimestamp int64                                     // 最后一条记录的时间戳
+	// 保存统计结果，供 ExportStats 导出
+	c.mu.Lock()
+	c.lastStats = stats
+	c.mu.Unlock()
 
-	// 统计各项数据
-	for _, result := range results {
-		totalRequests++
-		if result.Type == Success {
-			successCount++
-		} else {
-			failureCount++
-		}
+	return stats, nil
+}
 
-		// 累加响应时间
-		totalResponseTime += result.ResponseTime
+// CurrentStats 计算统计数据但不保存为最近一次统计结果，用于实时查询（例如 API 轮询），
+// 不影响 ExportStats 导出的快照
+func (c *Collector) CurrentStats(results []ResultData) (map[string]interface{}, error) {
+	return c.computePerformanceStats(results)
+}
 
-		// 最大响应时间
-		if result.ResponseTime > maxResponseTime {
-			maxResponseTime = result.ResponseTime
-		}
+// excludeWarmUp 过滤掉测量开始时间之前的结果，返回剩余结果和被排除的数量
+func (c *Collector) excludeWarmUp(results []ResultData) ([]ResultData, int) {
+	c.mu.RLock()
+	measureStart := c.measureStart
+	c.mu.RUnlock()
 
-		// 最小响应时间
-		if result.ResponseTime < minResponseTime {
-			minResponseTime = result.ResponseTime
+	if measureStart.IsZero() {
+		return results, 0
+	}
+	measured := make([]ResultData, 0, len(results))
+	for _, result := range results {
+		if !result.StartTime.Before(measureStart) {
+			measured = append(measured, result)
 		}
+	}
+	return measured, len(results) - len(measured)
+}
 
-		// 累加发送和接收的数据
-		totalSentData += result.DataSent
-		totalReceivedData += result.DataReceived
-
-		// 更新最后一个时间戳
-		lastTimestamp = result.EndTime.UnixMilli()
+// computePerformanceStats 计算性能统计数据
+func (c *Collector) computePerformanceStats(results []ResultData) (map[string]interface{}, error) {
+	// 排除预热阶段的结果
+	results, warmUpExcluded := c.excludeWarmUp(results)
+	// 事务样本和消息投递样本单独汇总，总体指标只统计请求
+	results, transactions := splitTransactions(results)
+	results, deliveries := splitDeliveries(results)
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results to analyze")
 	}
 
+	return c.assembleStats(statsInput{
+		agg:            aggregateResults(results), // 一次遍历（结果很多时分片并发）计算总体指标和每秒序列
+		firstTimestamp: results[0].StartTime.UnixMilli(),
+		lastTimestamp:  results[len(results)-1].EndTime.UnixMilli(),
+		warmUpExcluded: warmUpExcluded,
+		latency:        summarizeLatency(results, c.latencyOptions),
+		assertions:     calculateContentAssertionStats(results),
+		failedSamples:  collectFailedSamples(results),
+		topErrors:      collectTopErrors(results),
+		phases:         phaseBreakdown(results),
+		tls:            calculateTLSStats(results),
+		transactions:   transactions,
+		deliveries:     deliveries,
+	}), nil
+}
+
+// statsInput 组装统计结果所需的中间数据，内存中的结果（computePerformanceStats）和
+// 流式读取的 JTL 文件（AnalyzeFile）分别计算后由 assembleStats 组装成相同结构的统计结果
+type statsInput struct {
+	agg            *resultAggregate
+	firstTimestamp int64 // 第一条请求记录的开始时间（毫秒）
+	lastTimestamp  int64 // 最后一条请求记录的结束时间（毫秒）
+	warmUpExcluded int
+	latency        latencySummary
+	assertions     []ContentAssertionStat
+	failedSamples  []FailedSample
+	topErrors      []ErrorSummary
+	phases         []PhaseSample
+	tls            []TLSStat
+	transactions   []ResultData // 事务样本
+	deliveries     []ResultData // 消息投递样本
+}
+
+// assembleStats 根据中间数据生成统计结果
+func (c *Collector) assembleStats(in statsInput) map[string]interface{} {
+	agg := in.agg
+	totalRequests, successCount, failureCount := agg.totalRequests, agg.successCount, agg.failureCount
+	totalResponseTime, maxResponseTime, minResponseTime := agg.totalResponseTime, agg.maxResponseTime, agg.minResponseTime
+	totalSentData, totalReceivedData := agg.totalSentData, agg.totalReceivedData
+	firstTimestamp, lastTimestamp := in.firstTimestamp, in.lastTimestamp
+
 	// 计算成功率，保留三位小数
 	successRate := (float64(successCount) / float64(totalRequests)) * 100
 	successRate = math.Round(successRate*1000) / 1000 // 四舍五入到小数点后三位
@@ -227,10 +332,11 @@ func (c *Collector) GeneratePerformanceStats(results []ResultData) (map[string]i
 	// 计算平均响应时间
 	avgResponseTime := totalResponseTime / time.Duration(totalRequests)
 
-	// 使用 CalculateTPS 计算每秒的 TPS 数据
-	tpsValues, successValues, failureValues, tpsStartTime, tpsEndTime := c.CalculateTPS(results)
+	// 每秒的 TPS 数据
+	tpsValues, successValues, failureValues := agg.tpsSeries()
+	tpsStartTime, tpsEndTime := agg.startSec, agg.endSec
 
-	// 计算每秒事务数（TPS），基于 CalculateTPS 的结果
+	// 计算每秒事务数（TPS）
 	var tps float64
 	totalRunTime := time.Duration(lastTimestamp-firstTimestamp) * time.Millisecond
 	if totalRunTime.Seconds() > 0 {
@@ -252,7 +358,8 @@ func (c *Collector) GeneratePerformanceStats(results []ResultData) (map[string]i
 	totalReceivedDataStr := formatBytes(totalReceivedData)
 
 	// 计算平均响应时间（每秒）
-	avgResponseTimeValues, avgSuccessResponseTimeValues, avgFailureResponseTimeValues, avgResponseStartTime, avgResponseEndTime := c.CalculateAvgResponseTime(results)
+	avgResponseTimeValues, avgSuccessResponseTimeValues, avgFailureResponseTimeValues := agg.responseTimeSeries()
+	avgResponseStartTime, avgResponseEndTime := agg.startSec, agg.endSec
 
 	// 将响应时间数组转换为整数数组
 	avgResponseTimeValuesInt := convertToIntArray(avgResponseTimeValues)
@@ -260,13 +367,15 @@ func (c *Collector) GeneratePerformanceStats(results []ResultData) (map[string]i
 	avgFailureResponseTimeValuesInt := convertToIntArray(avgFailureResponseTimeValues)
 
 	// 计算平均流量（每秒）
-	avgSentTrafficValues, avgReceivedTrafficValues, avgSuccessSentTrafficValues, avgTrafficStartTime, avgTrafficEndTime := c.CalculateAvgTraffic(results)
+	avgSentTrafficValues, avgReceivedTrafficValues, avgSuccessSentTrafficValues := agg.trafficSeries()
+	avgTrafficStartTime, avgTrafficEndTime := agg.startSec, agg.endSec
 
 	// 返回所有统计数据
 	stats := map[string]interface{}{
 		"TotalRequests":      totalRequests,
 		"SuccessCount":       successCount,
 		"FailureCount":       failureCount,
+		"TimeoutCount":       agg.timeoutCount,
 		"SuccessRate":        successRate, // 保留三位小数的 float64
 		"AvgResponseTime":    avgResponseTime,
 		"MaxResponseTime":    maxResponseTime,
@@ -294,215 +403,131 @@ func (c *Collector) GeneratePerformanceStats(results []ResultData) (map[string]i
 		"AvgSuccessSentTrafficValues": avgSuccessSentTrafficValues,
 		"AvgTrafficStartTime":         avgTrafficStartTime,
 		"AvgTrafficEndTime":           avgTrafficEndTime,
+		// 每秒的吞吐量与平均、P95 响应时间，用于吞吐量-响应时间关系图
+		"ThroughputSamples": agg.throughputSamples(),
 	}
 
-	return stats, nil
-}
+	// 响应时间百分位（包含全量口径与排除重试/限流口径）
+	c.addLatencyPercentiles(stats, in.latency)
 
-// convertToIntArray 将浮动的时间值数组转换为整数数组
-func convertToIntArray(floatArray []float64) []int {
-	var intArray []int
-	for _, value := range floatArray {
-		intArray = append(intArray, int(value/1000))
-	}
-	fmt.Println(intArray)
-	return intArray
-}
+	stats["WarmUpExcluded"] = in.warmUpExcluded
 
-func (c *Collector) CalculateTPS(results []ResultData) ([]int, []int, []int, int64, int64) {
-	// 按秒聚合数据
-	tpsData := make(map[int64]int)     // 每秒的请求总数
-	successData := make(map[int64]int) // 每秒的成功请求数
-	failureData := make(map[int64]int) // 每秒的失败请求数
+	// 报告语言
+	stats["Language"] = c.language
 
-	var startTime, endTime int64
+	// 趋势图降采样参数
+	stats["ChartBuckets"] = c.chartOptions.buckets
+	stats["ChartAggregation"] = c.chartOptions.aggregation
 
-	for _, result := range results {
-		// 计算时间戳（按秒计算）
-		sec := result.StartTime.Unix()
+	// 随机种子，用相同的种子重新运行可以复现随机行为
+	stats["Seed"] = random.Seed()
 
-		if startTime == 0 || sec < startTime {
-			startTime = sec
-		}
-		if sec > endTime {
-			endTime = sec
-		}
+	// 运行元数据（测试计划、版本、主机和命令行参数等），便于追溯结果的来源
+	stats["RunMetadata"] = c.metadata
 
-		// 聚合 TPS
-		tpsData[sec]++
-		if result.Type == Success {
-			successData[sec]++
-		} else if result.Type == Failure {
-			failureData[sec]++
-		}
+	// 结束原因
+	c.mu.RLock()
+	if c.stopReason != "" {
+		stats["StopReason"] = c.stopReason
 	}
-
-	// 生成横坐标数据（秒）
-	var xAxis []int64
-	for sec := startTime; sec <= endTime; sec++ {
-		xAxis = append(xAxis, sec)
+	if !c.abortTime.IsZero() {
+		stats["Aborted"] = true
+		stats["AbortTime"] = c.abortTime
+		stats["AbortDetail"] = c.abortDetail
 	}
-
-	// 汇总每秒的请求数量、成功请求数量、失败请求数量
-	var tpsValues []int
-	var successValues []int
-	var failureValues []int
-
-	for _, sec := range xAxis {
-		tpsValues = append(tpsValues, tpsData[sec])
-		successValues = append(successValues, successData[sec])
-		failureValues = append(failureValues, failureData[sec])
+	if len(c.trafficMix) > 0 {
+		stats["TrafficMix"] = c.trafficMix
 	}
+	c.mu.RUnlock()
 
-	return tpsValues, successValues, failureValues, startTime, endTime
-}
+	// 内容断言通过率（按 URL + 断言名汇总）
+	stats["ContentAssertionStats"] = in.assertions
 
-func (c *Collector) CalculateAvgResponseTime(results []ResultData) ([]float64, []float64, []float64, int64, int64) {
-	// 按秒聚合数据
-	totalResponseTime := make(map[int64]int64)   // 每秒的总响应时间（单位：毫秒）
-	successResponseTime := make(map[int64]int64) // 每秒的成功请求的响应时间（单位：毫秒）
-	failureResponseTime := make(map[int64]int64) // 每秒的失败请求的响应时间（单位：毫秒）
-	successCount := make(map[int64]int)          // 每秒成功请求的数量
-	failureCount := make(map[int64]int)          // 每秒失败请求的数量
+	// 失败请求明细（带追踪ID，便于在日志中定位）
+	stats["FailedSamples"] = in.failedSamples
 
-	var startTime, endTime int64
+	// 按状态码和错误信息汇总的主要错误（用于 Markdown 摘要）
+	stats["TopErrors"] = in.topErrors
 
-	for _, result := range results {
-		// 计算时间戳（按秒计算）
-		sec := result.StartTime.Unix()
-
-		if startTime == 0 || sec < startTime {
-			startTime = sec
-		}
-		if sec > endTime {
-			endTime = sec
-		}
-
-		// 聚合响应时间（直接以毫秒为单位进行加总）
-		totalResponseTime[sec] += int64(result.ResponseTime)
-
-		if result.Type == Success {
-			successResponseTime[sec] += int64(result.ResponseTime)
-			successCount[sec]++
-		} else if result.Type == Failure {
-			failureResponseTime[sec] += int64(result.ResponseTime)
-			failureCount[sec]++
-		}
+	// 事务统计（按事务名汇总）
+	if len(in.transactions) > 0 {
+		stats["TransactionStats"] = calculateTransactionStats(in.transactions)
+	}
 
+	// 消息投递延迟（发布/订阅类任务）
+	if deliveryStats, samples, ok := calculateDeliveryStats(in.deliveries); ok {
+		stats["DeliveryStats"] = deliveryStats
+		stats["DeliveryLatency"] = samples
 	}
 
-	// 生成横坐标数据（秒）
-	var xAxis []int64
-	for sec := startTime; sec <= endTime; sec++ {
-		xAxis = append(xAxis, sec)
+	// 请求阶段耗时（DNS、连接、TLS、首字节、下载）
+	if len(in.phases) > 0 {
+		stats["PhaseBreakdown"] = in.phases
+	}
+	// TLS 版本、加密套件和握手耗时
+	if len(in.tls) > 0 {
+		stats["TLSStats"] = in.tls
 	}
 
-	// 汇总每秒的平均响应时间、成功请求的平均响应时间、失败请求的平均响应时间
-	var avgResponseTime []float64
-	var avgSuccessResponseTime []float64
-	var avgFailureResponseTime []float64
+	// 压测机资源使用采样
+	if samples := c.ResourceSamples(); len(samples) > 0 {
+		stats["ResourceSamples"] = samples
+	}
+	// 压测机疑似内存泄漏的区间，在资源使用图上标注
+	if leaks := c.MemoryLeaks(); len(leaks) > 0 {
+		stats["MemoryLeaks"] = leaks
+	}
 
-	for _, sec := range xAxis {
-		// 计算每秒的平均响应时间
-		if successCount[sec]+failureCount[sec] > 0 {
-			avgResponseTime = append(avgResponseTime, float64(totalResponseTime[sec])/float64(successCount[sec]+failureCount[sec])/1000)
-		} else {
-			avgResponseTime = append(avgResponseTime, 0)
-		}
+	// 活跃 VU 数和队列深度采样
+	if samples := c.LoadSamples(); len(samples) > 0 {
+		stats["LoadSamples"] = samples
+	}
 
-		// 计算每秒的成功请求的平均响应时间
-		if successCount[sec] > 0 {
-			avgSuccessResponseTime = append(avgSuccessResponseTime, float64(successResponseTime[sec])/float64(successCount[sec])/1000)
-		} else {
-			avgSuccessResponseTime = append(avgSuccessResponseTime, 0)
-		}
+	// HTTP 熔断器状态变化
+	if transitions := c.BreakerTransitions(); len(transitions) > 0 {
+		stats["BreakerTransitions"] = transitions
+	}
 
-		// 计算每秒的失败请求的平均响应时间
-		if failureCount[sec] > 0 {
-			avgFailureResponseTime = append(avgFailureResponseTime, float64(failureResponseTime[sec])/float64(failureCount[sec])/1000)
-		} else {
-			avgFailureResponseTime = append(avgFailureResponseTime, 0)
-		}
+	// 任务代码上报的自定义指标
+	c.mu.RLock()
+	measureStart := c.measureStart
+	c.mu.RUnlock()
+	if metrics, series := c.customMetricStats(measureStart, totalRunTime); len(metrics) > 0 {
+		stats["CustomMetrics"] = metrics
+		stats["CustomMetricSeries"] = series
 	}
 
-	return avgResponseTime, avgSuccessResponseTime, avgFailureResponseTime, startTime, endTime
+	return stats
 }
 
-func (c *Collector) CalculateAvgTraffic(results []ResultData) ([]int, []int, []int, int64, int64) {
-	// 按秒聚合数据
-	totalSent := make(map[int64]int64)       // 每秒的发送数据总量
-	totalReceived := make(map[int64]int64)   // 每秒的接收数据总量
-	successSent := make(map[int64]int64)     // 每秒成功请求的发送数据总量
-	successReceived := make(map[int64]int64) // 每秒成功请求的接收数据总量
-	failureSent := make(map[int64]int64)     // 每秒失败请求的发送数据总量
-	failureReceived := make(map[int64]int64) // 每秒失败请求的接收数据总量
-	successCount := make(map[int64]int)      // 每秒成功请求的数量
-	failureCount := make(map[int64]int)      // 每秒失败请求的数量
-
-	var startTime, endTime int64
-
-	// 遍历结果数据，计算每秒的聚合数据
-	for _, result := range results {
-		// 计算时间戳（按秒计算）
-		sec := result.StartTime.Unix()
-
-		// 更新最早和最晚的时间
-		if startTime == 0 || sec < startTime {
-			startTime = sec
-		}
-		if sec > endTime {
-			endTime = sec
-		}
-
-		// 聚合流量数据
-		totalSent[sec] += result.DataSent
-		totalReceived[sec] += result.DataReceived
-
-		if result.Type == Success {
-			successSent[sec] += result.DataSent
-			successReceived[sec] += result.DataReceived
-			successCount[sec]++
-		} else if result.Type == Failure {
-			failureSent[sec] += result.DataSent
-			failureReceived[sec] += result.DataReceived
-			failureCount[sec]++
-		}
-	}
-
-	// 生成横坐标数据（秒）
-	var xAxis []int64
-	for sec := startTime; sec <= endTime; sec++ {
-		xAxis = append(xAxis, sec)
+// convertToIntArray 将浮动的时间值数组转换为整数数组
+func convertToIntArray(floatArray []float64) []int {
+	var intArray []int
+	for _, value := range floatArray {
+		intArray = append(intArray, int(value/1000))
 	}
+	return intArray
+}
 
-	// 汇总每秒的平均流量、成功请求的平均发送流量、成功请求的平均接收流量
-	var avgSentTraffic []int
-	var avgReceivedTraffic []int
-	var avgSuccessSentTraffic []int
-	var avgSuccessReceivedTraffic []int
-
-	for _, sec := range xAxis {
-		// 计算每秒的平均发送流量
-		if successCount[sec]+failureCount[sec] > 0 {
-			avgSentTraffic = append(avgSentTraffic, int(totalSent[sec]/int64(successCount[sec]+failureCount[sec])))
-			avgReceivedTraffic = append(avgReceivedTraffic, int(totalReceived[sec]/int64(successCount[sec]+failureCount[sec])))
-		} else {
-			avgSentTraffic = append(avgSentTraffic, 0)
-			avgReceivedTraffic = append(avgReceivedTraffic, 0)
-		}
+// CalculateTPS 计算每秒的请求数、成功数和失败数，返回序列及起止时间（秒）
+func (c *Collector) CalculateTPS(results []ResultData) ([]int, []int, []int, int64, int64) {
+	agg := aggregateResults(results)
+	tpsValues, successValues, failureValues := agg.tpsSeries()
+	return tpsValues, successValues, failureValues, agg.startSec, agg.endSec
+}
 
-		// 计算每秒成功请求的平均发送流量
-		if successCount[sec] > 0 {
-			avgSuccessSentTraffic = append(avgSuccessSentTraffic, int(successSent[sec]/int64(successCount[sec])))
-			avgSuccessReceivedTraffic = append(avgSuccessReceivedTraffic, int(successReceived[sec]/int64(successCount[sec])))
-		} else {
-			avgSuccessSentTraffic = append(avgSuccessSentTraffic, 0)
-			avgSuccessReceivedTraffic = append(avgSuccessReceivedTraffic, 0)
-		}
-	}
+// CalculateAvgResponseTime 计算每秒的平均响应时间（微秒），返回总体、成功、失败三条序列及起止时间（秒）
+func (c *Collector) CalculateAvgResponseTime(results []ResultData) ([]float64, []float64, []float64, int64, int64) {
+	agg := aggregateResults(results)
+	avgResponseTime, avgSuccessResponseTime, avgFailureResponseTime := agg.responseTimeSeries()
+	return avgResponseTime, avgSuccessResponseTime, avgFailureResponseTime, agg.startSec, agg.endSec
+}
 
-	// 返回修正后的值
-	return avgSentTraffic, avgReceivedTraffic, avgSuccessSentTraffic, startTime, endTime
+// CalculateAvgTraffic 计算每秒的平均发送、接收流量和成功请求的平均发送流量（字节），返回序列及起止时间（秒）
+func (c *Collector) CalculateAvgTraffic(results []ResultData) ([]int, []int, []int, int64, int64) {
+	agg := aggregateResults(results)
+	avgSent, avgReceived, avgSuccessSent := agg.trafficSeries()
+	return avgSent, avgReceived, avgSuccessSent, agg.startSec, agg.endSec
 }
 
 func (c *Collector) GenerateChart(tpsValues, successValues, failureValues []int, startTime, endTime int64) {
@@ -569,56 +594,54 @@ type PerformanceStandard struct {
 }
 
 // generateDefaultAnalysis 根据传入的测试数据生成默认的分析内容
-// 通过测试数据来动态生成一段分析报告
-func generateDefaultAnalysis(stats map[string]interface{}) string {
-	// 获取测试数据
-	successRate := stats["SuccessRate"].(float64)
-	avgResponseTime := stats["AvgResponseTime"].(time.Duration) // 修改为 time.Duration 类型
-	tps := stats["TPS"].(float64)
-	sentDataPerSec := stats["SentDataPerSec"].(string)
-	receivedDataPerSec := stats["ReceivedDataPerSec"].(string)
-
-	// 将 time.Duration 转换为毫秒并格式化为两位小数
-	avgResponseTimeMillis := float64(avgResponseTime) / float64(time.Millisecond)
-	avgResponseTimeFormatted := fmt.Sprintf("%.2f", avgResponseTimeMillis) // 保留两位小数
+// 通过测试数据来动态生成一段分析报告，缺少对应指标的部分不生成
+func generateDefaultAnalysis(stats map[string]interface{}, lang Language) string {
+	var parts []string
 
 	// 根据成功率生成分析内容，精确到小数点后三位
-	var successAnalysis string
-	successRateFormatted := fmt.Sprintf("%.3f", successRate) // 格式化成功率为小数点后三位
-	if successRate >= 99 {
-		successAnalysis = "本次测试的请求成功率非常高，达到了 " + successRateFormatted + "%，表明系统能够高效处理请求。"
-	} else if successRate >= 90 {
-		successAnalysis = "本次测试的请求成功率达到了 " + successRateFormatted + "%，系统表现良好，但仍有一定的优化空间。"
-	} else {
-		successAnalysis = "本次测试的请求成功率为 " + successRateFormatted + "%，说明系统可能存在一定的瓶颈或故障，需要进一步排查。"
+	if successRate, ok := stats["SuccessRate"].(float64); ok {
+		successRateFormatted := fmt.Sprintf("%.3f", successRate) // 格式化成功率为小数点后三位
+		if successRate >= 99 {
+			parts = append(parts, lang.text("analysis_success_high", successRateFormatted))
+		} else if successRate >= 90 {
+			parts = append(parts, lang.text("analysis_success_good", successRateFormatted))
+		} else {
+			parts = append(parts, lang.text("analysis_success_low", successRateFormatted))
+		}
 	}
 
-	// 根据平均响应时间生成分析内容
-	var responseTimeAnalysis string
-	if avgResponseTimeMillis <= 1000 {
-		responseTimeAnalysis = "系统的平均响应时间非常低，达到了 " + avgResponseTimeFormatted + " 毫秒，符合高频接口的性能标准。"
-	} else if avgResponseTimeMillis <= 2000 {
-		responseTimeAnalysis = "系统的平均响应时间为 " + avgResponseTimeFormatted + " 毫秒，符合普通接口的性能标准。"
-	} else {
-		responseTimeAnalysis = "系统的平均响应时间为 " + avgResponseTimeFormatted + " 毫秒，可能会影响用户体验，需要进一步优化。"
+	// 根据平均响应时间生成分析内容，转换为毫秒并保留两位小数
+	if avgResponseTime, ok := stats["AvgResponseTime"].(time.Duration); ok {
+		avgResponseTimeMillis := float64(avgResponseTime) / float64(time.Millisecond)
+		avgResponseTimeFormatted := fmt.Sprintf("%.2f", avgResponseTimeMillis)
+		if avgResponseTimeMillis <= 1000 {
+			parts = append(parts, lang.text("analysis_response_fast", avgResponseTimeFormatted))
+		} else if avgResponseTimeMillis <= 2000 {
+			parts = append(parts, lang.text("analysis_response_normal", avgResponseTimeFormatted))
+		} else {
+			parts = append(parts, lang.text("analysis_response_slow", avgResponseTimeFormatted))
+		}
 	}
 
 	// 根据TPS生成分析内容，精确到小数点后二位
-	var tpsAnalysis string
-	tpsFormatted := fmt.Sprintf("%.2f", tps) // 格式化TPS为小数点后二位
-	if tps >= 5000 {
-		tpsAnalysis = fmt.Sprintf("TPS（事务每秒）达到了 %s，说明系统能够承载较高的负载。", tpsFormatted)
-	} else if tps >= 2000 {
-		tpsAnalysis = fmt.Sprintf("TPS（事务每秒）为 %s，系统能够处理中等负载的请求。", tpsFormatted)
-	} else {
-		tpsAnalysis = fmt.Sprintf("TPS（事务每秒）为 %s，系统在高负载下的表现较为平缓，可能会有性能瓶颈。", tpsFormatted)
+	if tps, ok := stats["TPS"].(float64); ok {
+		tpsFormatted := fmt.Sprintf("%.2f", tps)
+		if tps >= 5000 {
+			parts = append(parts, lang.text("analysis_tps_high", tpsFormatted))
+		} else if tps >= 2000 {
+			parts = append(parts, lang.text("analysis_tps_medium", tpsFormatted))
+		} else {
+			parts = append(parts, lang.text("analysis_tps_low", tpsFormatted))
+		}
 	}
 
 	// 根据数据流量生成分析内容
-	var dataFlowAnalysis string
-	dataFlowAnalysis = "每秒发送的数据流量为 " + sentDataPerSec + "，每秒接收的数据流量为 " + receivedDataPerSec + "，系统的数据吞吐量良好。"
+	sentDataPerSec, sentOK := stats["SentDataPerSec"].(string)
+	receivedDataPerSec, receivedOK := stats["ReceivedDataPerSec"].(string)
+	if sentOK && receivedOK {
+		parts = append(parts, lang.text("analysis_data_flow", sentDataPerSec, receivedDataPerSec))
+	}
 
 	// 组合分析内容
-	analysis := successAnalysis + " " + responseTimeAnalysis + " " + tpsAnalysis + " " + dataFlowAnalysis
-	return analysis
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,139 @@
+// success.go
+// 成功判定模块
+// 本文件负责根据状态码和内容断言判定一次请求是否成功（SuccessPolicy），CollectDataWithParams 构建 ResultData 时按收集器的策略
+// 设置结果类型，自行构建 ResultData 的任务也可以通过 Collector.EvaluateSuccess 使用同一策略。
+//
+// 技术实现细节：
+// 1. 状态码规则支持类别（如 "2xx"）和具体状态码（如 "404"），默认 2xx 和 3xx 为成功。
+// 2. Endpoints 按 URL 子串为接口单独指定成功状态码，多个子串匹配时使用最长的子串，未匹配的请求使用全局规则。
+// 3. RequireAssertions 开启时，任一内容断言（ResultData.Assertions）失败即判定为失败。
+
+package result
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultSuccessStatusCodes 默认的成功状态码：2xx 和 3xx
+var DefaultSuccessStatusCodes = []string{"2xx", "3xx"}
+
+// SuccessPolicy 请求成功判定策略
+type SuccessPolicy struct {
+	// StatusCodes 判定为成功的状态码，支持 "2xx" 这样的类别和具体状态码，为空时使用 DefaultSuccessStatusCodes
+	StatusCodes []string
+	// Endpoints 按 URL 子串指定接口的成功状态码（例如 "/health" -> ["200"]、"/items" -> ["2xx", "404"]），覆盖全局规则
+	Endpoints map[string][]string
+	// RequireAssertions 任一内容断言失败时判定为失败
+	RequireAssertions bool
+}
+
+// statusCodeRule 一条状态码规则，class 不为 0 时匹配整个类别（如 2 表示 2xx）
+type statusCodeRule struct {
+	class int
+	code  int
+}
+
+// endpointRule 按 URL 子串匹配的接口状态码规则
+type endpointRule struct {
+	pattern string
+	rules   []statusCodeRule
+}
+
+// successPolicy 解析后的成功判定策略
+type successPolicy struct {
+	rules             []statusCodeRule
+	endpoints         []endpointRule // 按子串长度从长到短排序
+	requireAssertions bool
+}
+
+// parseStatusCodes 解析状态码规则
+func parseStatusCodes(codes []string) ([]statusCodeRule, error) {
+	rules := make([]statusCodeRule, 0, len(codes))
+	for _, raw := range codes {
+		code := strings.ToLower(strings.TrimSpace(raw))
+		if len(code) == 3 && strings.HasSuffix(code, "xx") && code[0] >= '1' && code[0] <= '5' {
+			rules = append(rules, statusCodeRule{class: int(code[0] - '0')})
+			continue
+		}
+		n, err := strconv.Atoi(code)
+		if err != nil || n < 100 || n > 599 {
+			return nil, fmt.Errorf("invalid success status code %q", raw)
+		}
+		rules = append(rules, statusCodeRule{code: n})
+	}
+	return rules, nil
+}
+
+// parseSuccessPolicy 校验并解析成功判定策略
+func parseSuccessPolicy(p SuccessPolicy) (*successPolicy, error) {
+	codes := p.StatusCodes
+	if len(codes) == 0 {
+		codes = DefaultSuccessStatusCodes
+	}
+	rules, err := parseStatusCodes(codes)
+	if err != nil {
+		return nil, err
+	}
+	policy := &successPolicy{rules: rules, requireAssertions: p.RequireAssertions}
+	for pattern, codes := range p.Endpoints {
+		if pattern == "" || len(codes) == 0 {
+			return nil, fmt.Errorf("endpoint success rule needs a URL pattern and status codes: %q", pattern)
+		}
+		rules, err := parseStatusCodes(codes)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: %v", pattern, err)
+		}
+		policy.endpoints = append(policy.endpoints, endpointRule{pattern: pattern, rules: rules})
+	}
+	sort.Slice(policy.endpoints, func(i, j int) bool {
+		a, b := policy.endpoints[i].pattern, policy.endpoints[j].pattern
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return policy, nil
+}
+
+// evaluate 判定结果是否成功，失败时返回原因
+func (p *successPolicy) evaluate(data ResultData) error {
+	rules := p.rules
+	for _, endpoint := range p.endpoints {
+		if strings.Contains(data.URL, endpoint.pattern) {
+			rules = endpoint.rules
+			break
+		}
+	}
+	matched := false
+	for _, rule := range rules {
+		if (rule.class != 0 && data.StatusCode/100 == rule.class) || (rule.code != 0 && data.StatusCode == rule.code) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return fmt.Errorf("request failed with status %d", data.StatusCode)
+	}
+	if p.requireAssertions {
+		names := make([]string, 0, len(data.Assertions))
+		for name, passed := range data.Assertions {
+			if !passed {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return fmt.Errorf("assertion failed: %s", strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// EvaluateSuccess 按收集器的成功判定策略（CollectorConfig.SuccessPolicy）判定结果是否成功，失败时返回原因，
+// 供自行构建 ResultData 的任务在保存结果前使用
+func (c *Collector) EvaluateSuccess(data ResultData) error {
+	return c.successPolicy.evaluate(data)
+}
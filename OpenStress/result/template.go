@@ -0,0 +1,95 @@
+// template.go
+// 报告模板模块
+// 本文件负责 HTML 报告的页面模板和报告目录的命名规则，允许用户替换报告的页面结构和输出文件名。
+//
+// 技术实现细节：
+// 1. 报告页面通过 html/template 渲染，默认模板为内嵌的 templates/report.html（DefaultReportTemplate），
+//    数据为 ReportViewModel（见 viewmodel.go），用户数据按 html/template 的规则转义。
+// 2. 默认模板定义了 head（编码、标题、样式和脚本）、body（概览、统计表、图表和分析）、scripts（页面底部脚本）
+//    和完整页面 report 四个模板。
+// 3. 自定义模板通过 CollectorConfig.ReportTemplate 指定文件路径，在创建收集器时解析，语法错误在启动时报告；
+//    文件内容为完整页面，可以通过 {{template "head" .}}、{{template "body" .}} 复用内置部分并在前后追加内容
+//    （例如公司页眉），也可以通过 Stats 和 Metadata 完全自行排版。
+// 4. 模板中可以使用 ms（时间转换为毫秒）、percent（百分比）和 bytes（字节数转换为 KB/MB）函数。
+// 5. 报告目录和 HTML 文件名由 CollectorConfig.ReportNamePattern 决定，支持 {name}（报告名称）、
+//    {time}（生成时间 yyyy-MM-dd_HH-mm-ss）和 {task}（任务ID）占位符，默认 DefaultReportNamePattern。
+
+package result
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultReportTemplate 默认的报告模板，自定义模板可以以此为基础修改
+//
+//go:embed templates/report.html
+var DefaultReportTemplate string
+
+// DefaultReportNamePattern 默认的报告目录和 HTML 文件命名规则
+const DefaultReportNamePattern = "{name}_{time}"
+
+// reportTimeFormat 报告命名规则中 {time} 的格式
+const reportTimeFormat = "2006-01-02_15-04-05"
+
+// reportPageTemplate 默认模板中完整页面的模板名
+const reportPageTemplate = "report"
+
+// defaultReportTemplate 解析后的默认报告页面模板
+var defaultReportTemplate = template.Must(newReportTemplate("default").Parse(DefaultReportTemplate)).Lookup(reportPageTemplate)
+
+// newReportTemplate 创建注册了模板函数的报告模板
+func newReportTemplate(name string) *template.Template {
+	return template.New(name).Funcs(template.FuncMap{
+		"ms": func(d time.Duration) string {
+			return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+		},
+		"percent": func(v float64) string {
+			return fmt.Sprintf("%.3f%%", v)
+		},
+		"bytes": func(n int64) string {
+			return formatBytes(n)
+		},
+	})
+}
+
+// LoadReportTemplate 从文件加载自定义报告模板（html/template 语法，数据为 ReportViewModel），
+// 文件中可以引用默认模板定义的 head、body 和 scripts，也可以重新定义它们
+func LoadReportTemplate(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report template: %v", err)
+	}
+	tmpl, err := newReportTemplate(path).Parse(DefaultReportTemplate)
+	if err == nil {
+		tmpl, err = tmpl.Parse(string(content))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template %s: %v", path, err)
+	}
+	return tmpl, nil
+}
+
+// validateReportNamePattern 检查报告命名规则，规则中不能包含路径分隔符
+func validateReportNamePattern(pattern string) error {
+	if strings.ContainsAny(pattern, `/\`) {
+		return fmt.Errorf("report name pattern %q must not contain path separators", pattern)
+	}
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("report name pattern must not be empty")
+	}
+	return nil
+}
+
+// reportBaseName 按命名规则生成报告目录和 HTML 文件的名称（不含扩展名）
+func reportBaseName(pattern, name, taskID string, generated time.Time) string {
+	return strings.NewReplacer(
+		"{name}", name,
+		"{time}", generated.Format(reportTimeFormat),
+		"{task}", taskID,
+	).Replace(pattern)
+}
@@ -0,0 +1,250 @@
+// threshold.go
+// 阈值判定模块
+// 本文件负责 SLA/阈值规则的解析与判定，用于 CI 门禁。
+//
+// 规则格式：<指标> <运算符> <数值>[单位]，例如：
+//   - "p95 < 800ms"
+//   - "error_rate < 1%"
+//   - "tps > 300"
+//   - "content_rate{url=/search,assertion=results_non_empty} >= 99%"
+//
+// 支持的指标：p50 / p90 / p95 / p99 / avg / max / min（响应时间），
+// error_rate / success_rate（百分比），tps，total_requests，
+// content_rate（内容断言通过率，百分比，参数见 assertion.go）。
+// 响应时间类阈值统一换算为毫秒比较，支持 ms / s / us 单位，不带单位时按毫秒处理。
+
+package result
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExitCodeThresholdsFailed 阈值未通过时建议的进程退出码
+const ExitCodeThresholdsFailed = 99
+
+// Threshold 阈值规则
+type Threshold struct {
+	Expression string  // 原始表达式
+	Metric     string  // 指标名
+	Operator   string  // 运算符：< <= > >= ==
+	Value      float64 // 阈值（响应时间为毫秒，比率为百分比）
+	URLPattern string  // content_rate 的 URL 子串，空表示所有请求
+	Assertion  string  // content_rate 的断言名
+}
+
+// ThresholdResult 阈值判定结果
+type ThresholdResult struct {
+	Threshold
+	Actual  float64 // 实际值（单位同 Value）
+	Passed  bool    // 是否通过
+	Message string  // 判定说明
+}
+
+// contentRateMetric 内容断言通过率指标，需要参数，不在 thresholdMetrics 中
+const contentRateMetric = "content_rate"
+
+// thresholdMetrics 指标名到统计键及取值方式的映射
+var thresholdMetrics = map[string]func(stats map[string]interface{}) (float64, bool){
+	"p50":            durationStat("P50ResponseTime"),
+	"p90":            durationStat("P90ResponseTime"),
+	"p95":            durationStat("P95ResponseTime"),
+	"p99":            durationStat("P99ResponseTime"),
+	"avg":            durationStat("AvgResponseTime"),
+	"max":            durationStat("MaxResponseTime"),
+	"min":            durationStat("MinResponseTime"),
+	"tps":            floatStat("TPS"),
+	"success_rate":   floatStat("SuccessRate"),
+	"total_requests": floatStat("TotalRequests"),
+	"error_rate": func(stats map[string]interface{}) (float64, bool) {
+		total, ok1 := stats["TotalRequests"].(int)
+		failures, ok2 := stats["FailureCount"].(int)
+		if !ok1 || !ok2 || total == 0 {
+			return 0, ok1 && ok2
+		}
+		return float64(failures) / float64(total) * 100, true
+	},
+}
+
+// durationStat 读取 time.Duration 类型的统计项并转换为毫秒
+func durationStat(key string) func(stats map[string]interface{}) (float64, bool) {
+	return func(stats map[string]interface{}) (float64, bool) {
+		d, ok := stats[key].(time.Duration)
+		return float64(d) / float64(time.Millisecond), ok
+	}
+}
+
+// floatStat 读取数值类型的统计项
+func floatStat(key string) func(stats map[string]interface{}) (float64, bool) {
+	return func(stats map[string]interface{}) (float64, bool) {
+		switch v := stats[key].(type) {
+		case float64:
+			return v, true
+		case int:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		default:
+			return 0, false
+		}
+	}
+}
+
+// ParseThreshold 解析阈值表达式
+func ParseThreshold(expr string) (Threshold, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 3 {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: expected \"<metric> <op> <value>\"", expr)
+	}
+
+	metric, params, err := parseThresholdMetric(fields[0])
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: %v", expr, err)
+	}
+
+	op := fields[1]
+	switch op {
+	case "<", "<=", ">", ">=", "==":
+	default:
+		return Threshold{}, fmt.Errorf("invalid threshold %q: unknown operator %s", expr, op)
+	}
+
+	value, err := parseThresholdValue(fields[2])
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: %v", expr, err)
+	}
+
+	return Threshold{
+		Expression: expr,
+		Metric:     metric,
+		Operator:   op,
+		Value:      value,
+		URLPattern: params["url"],
+		Assertion:  params["assertion"],
+	}, nil
+}
+
+// parseThresholdMetric 解析指标名及其参数，例如 content_rate{url=/search,assertion=results_non_empty}
+func parseThresholdMetric(raw string) (string, map[string]string, error) {
+	name, rest, hasParams := strings.Cut(raw, "{")
+	metric := strings.ToLower(name)
+
+	if metric == contentRateMetric {
+		if !hasParams || !strings.HasSuffix(rest, "}") {
+			return "", nil, fmt.Errorf("content_rate requires {assertion=<name>}")
+		}
+		params := make(map[string]string)
+		for _, pair := range strings.Split(strings.TrimSuffix(rest, "}"), ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || (key != "url" && key != "assertion") {
+				return "", nil, fmt.Errorf("invalid content_rate parameter %q", pair)
+			}
+			params[key] = value
+		}
+		if params["assertion"] == "" {
+			return "", nil, fmt.Errorf("content_rate requires an assertion name")
+		}
+		return metric, params, nil
+	}
+
+	if hasParams {
+		return "", nil, fmt.Errorf("metric %s does not take parameters", name)
+	}
+	if _, ok := thresholdMetrics[metric]; !ok {
+		return "", nil, fmt.Errorf("unknown metric %s", name)
+	}
+	return metric, nil, nil
+}
+
+// parseThresholdValue 解析阈值数值，时间统一换算为毫秒，百分号去除
+func parseThresholdValue(raw string) (float64, error) {
+	switch {
+	case strings.HasSuffix(raw, "%"):
+		return strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	case strings.HasSuffix(raw, "ms"), strings.HasSuffix(raw, "us"), strings.HasSuffix(raw, "s"), strings.HasSuffix(raw, "m"):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return 0, err
+		}
+		return float64(d) / float64(time.Millisecond), nil
+	default:
+		return strconv.ParseFloat(raw, 64)
+	}
+}
+
+// ParseThresholds 批量解析阈值表达式
+func ParseThresholds(exprs []string) ([]Threshold, error) {
+	thresholds := make([]Threshold, 0, len(exprs))
+	for _, expr := range exprs {
+		t, err := ParseThreshold(expr)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, nil
+}
+
+// actual 从统计结果中读取规则对应的实际值
+func (t Threshold) actual(stats map[string]interface{}) (float64, bool) {
+	if t.Metric == contentRateMetric {
+		return contentRate(stats, t.URLPattern, t.Assertion)
+	}
+	return thresholdMetrics[t.Metric](stats)
+}
+
+// compare 按运算符比较
+func (t Threshold) compare(actual float64) bool {
+	switch t.Operator {
+	case "<":
+		return actual < t.Value
+	case "<=":
+		return actual <= t.Value
+	case ">":
+		return actual > t.Value
+	case ">=":
+		return actual >= t.Value
+	case "==":
+		return actual == t.Value
+	}
+	return false
+}
+
+// EvaluateThresholds 对统计结果执行阈值判定
+// 判定结果同时写入 stats["ThresholdResults"] 与 stats["ThresholdsPassed"]，供 HTML 报告展示
+func (c *Collector) EvaluateThresholds(stats map[string]interface{}) ([]ThresholdResult, bool) {
+	results := make([]ThresholdResult, 0, len(c.thresholds))
+	passed := true
+
+	for _, t := range c.thresholds {
+		actual, ok := t.actual(stats)
+		r := ThresholdResult{Threshold: t, Actual: actual}
+		if !ok {
+			r.Message = fmt.Sprintf("metric %s not available in stats", t.Metric)
+		} else {
+			r.Passed = t.compare(actual)
+			r.Message = fmt.Sprintf("%s = %.3f", t.Metric, actual)
+		}
+		if !r.Passed {
+			passed = false
+			c.logger.Log("WARN", fmt.Sprintf("Threshold failed: %s (%s)", t.Expression, r.Message))
+		}
+		results = append(results, r)
+	}
+
+	stats["ThresholdResults"] = results
+	stats["ThresholdsPassed"] = passed
+	return results, passed
+}
+
+// ThresholdExitCode 根据判定结果返回进程退出码，全部通过返回 0
+func ThresholdExitCode(results []ThresholdResult) int {
+	for _, r := range results {
+		if !r.Passed {
+			return ExitCodeThresholdsFailed
+		}
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+// tls.go
+// TLS 连接统计模块
+// 本文件负责按 TLS 版本和加密套件汇总 HTTPS 请求：请求数、新建握手次数和握手耗时，
+// 作为报告中连接阶段分析的补充，用于确认协商结果是否符合预期（例如仍有客户端协商到 TLS 1.2）以及握手开销。
+//
+// 技术实现细节：
+// 1. TLS 版本和加密套件由 tasks.DoTimed 采集（见 ResultData.ApplyPhases），写入 JTL 的 tlsVersion、tlsCipher 列。
+// 2. 复用连接的请求计入请求数，但没有握手，不参与握手耗时的平均值。
+// 3. 没有 TLS 信息的结果（非 HTTPS 请求或未采集阶段耗时）忽略，全部没有时报告不展示该表。
+
+package result
+
+import (
+	"sort"
+	"time"
+)
+
+// TLSStat 一种 TLS 版本和加密套件组合的汇总
+type TLSStat struct {
+	Version      string        // TLS 版本，例如 TLS 1.3
+	Cipher       string        // 加密套件名称
+	Requests     int           // 请求数（含复用连接的请求）
+	Handshakes   int           // 新建连接的握手次数
+	AvgHandshake time.Duration // 平均握手耗时
+	MaxHandshake time.Duration // 最长握手耗时
+}
+
+// tlsKey TLS 版本和加密套件组合
+type tlsKey struct {
+	version, cipher string
+}
+
+// tlsCounter 逐条累计 TLS 统计，结果不依赖结果的顺序
+type tlsCounter struct {
+	stats map[tlsKey]*TLSStat
+	total map[tlsKey]time.Duration // 握手耗时之和
+}
+
+// newTLSCounter 创建空的 TLS 统计
+func newTLSCounter() *tlsCounter {
+	return &tlsCounter{stats: make(map[tlsKey]*TLSStat), total: make(map[tlsKey]time.Duration)}
+}
+
+// add 累计一条结果，没有 TLS 信息的结果忽略
+func (c *tlsCounter) add(r *ResultData) {
+	if r.TLSVersion == "" {
+		return
+	}
+	key := tlsKey{r.TLSVersion, r.TLSCipher}
+	stat := c.stats[key]
+	if stat == nil {
+		stat = &TLSStat{Version: r.TLSVersion, Cipher: r.TLSCipher}
+		c.stats[key] = stat
+	}
+	stat.Requests++
+	if r.TLSHandshake > 0 {
+		stat.Handshakes++
+		c.total[key] += r.TLSHandshake
+		stat.MaxHandshake = max(stat.MaxHandshake, r.TLSHandshake)
+	}
+}
+
+// result 返回按请求数从多到少排序的汇总
+func (c *tlsCounter) result() []TLSStat {
+	stats := make([]TLSStat, 0, len(c.stats))
+	for key, stat := range c.stats {
+		if stat.Handshakes > 0 {
+			stat.AvgHandshake = c.total[key] / time.Duration(stat.Handshakes)
+		}
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Requests != stats[j].Requests {
+			return stats[i].Requests > stats[j].Requests
+		}
+		if stats[i].Version != stats[j].Version {
+			return stats[i].Version > stats[j].Version
+		}
+		return stats[i].Cipher < stats[j].Cipher
+	})
+	return stats
+}
+
+// calculateTLSStats 按 TLS 版本和加密套件汇总结果
+func calculateTLSStats(results []ResultData) []TLSStat {
+	counter := newTLSCounter()
+	for i := range results {
+		counter.add(&results[i])
+	}
+	return counter.result()
+}
@@ -0,0 +1,72 @@
+// trace.go
+// 失败请求明细模块
+// 本文件负责从结果中挑选失败请求，连同追踪ID、VU 编号和迭代次数写入 stats["FailedSamples"]，
+// 报告中可以按追踪ID在日志里找到同一个请求的完整记录。
+
+package result
+
+import (
+	"sort"
+	"time"
+)
+
+// maxFailedSamples 报告中最多展示的失败请求数
+const maxFailedSamples = 50
+
+// FailedSample 一条失败请求明细
+type FailedSample struct {
+	StartTime  time.Time // 请求开始时间
+	URL        string    // 请求URL
+	StatusCode int       // 状态码
+	Error      string    // 错误信息
+	TraceID    string    // 追踪ID，为空表示结果未携带追踪信息
+	VUID       int       // 虚拟用户编号
+	Iteration  int       // 迭代次数
+}
+
+// collectFailedSamples 按开始时间取最早的 maxFailedSamples 条失败请求
+func collectFailedSamples(results []ResultData) []FailedSample {
+	var set failedSampleSet
+	for i := range results {
+		set.add(&results[i])
+	}
+	return set.result()
+}
+
+// failedSampleSet 逐条收集失败请求，只保留开始时间最早的 maxFailedSamples 条
+type failedSampleSet struct {
+	samples []FailedSample
+}
+
+// add 收集一条结果，非失败结果忽略；积累到一定数量时丢弃较晚的失败请求，内存占用有上限
+func (s *failedSampleSet) add(r *ResultData) {
+	if r.Type == Success {
+		return
+	}
+	s.samples = append(s.samples, FailedSample{
+		StartTime:  r.StartTime,
+		URL:        r.URL,
+		StatusCode: r.StatusCode,
+		Error:      r.ErrorMessage,
+		TraceID:    r.TraceID,
+		VUID:       r.VUID,
+		Iteration:  r.Iteration,
+	})
+	if len(s.samples) >= 2*maxFailedSamples {
+		s.trim()
+	}
+}
+
+// trim 按开始时间稳定排序（同一时间保持收集顺序）并只保留最早的 maxFailedSamples 条
+func (s *failedSampleSet) trim() {
+	sort.SliceStable(s.samples, func(i, j int) bool { return s.samples[i].StartTime.Before(s.samples[j].StartTime) })
+	if len(s.samples) > maxFailedSamples {
+		s.samples = s.samples[:maxFailedSamples]
+	}
+}
+
+// result 返回收集到的失败请求
+func (s *failedSampleSet) result() []FailedSample {
+	s.trim()
+	return s.samples
+}
@@ -0,0 +1,232 @@
+// transaction.go
+// 事务统计模块
+// 本文件负责事务（Transaction）的计时与统计：事务把多个请求作为一个端到端的整体计时，
+// 类似 JMeter 的 Transaction Controller，例如"下单"事务包含创建订单、查询订单和支付三个请求。
+//
+// 技术实现细节：
+// 1. 事务内的每个请求照常作为普通样本保存，事务结束时额外保存一条事务样本，
+//    JTL 中 label 列为事务名、dataType 列为 TransactionDataType。
+// 2. 事务耗时为从开始到结束的墙钟时间，包含请求之间的思考时间和变量提取等客户端处理；
+//    任一请求失败或事务函数返回错误时事务失败，错误信息取第一个失败原因。
+// 3. 统计时事务样本与请求样本分开：总体指标只统计请求，事务按名称单独汇总 TPS 和响应时间。
+
+package result
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TransactionDataType 事务样本在 JTL dataType 列中的取值
+const TransactionDataType = "transaction"
+
+// Transaction 一次事务执行，并发安全
+type Transaction struct {
+	collector *Collector
+	name      string
+	start     time.Time
+
+	mu       sync.Mutex
+	first    *ResultData // 第一个请求样本，事务样本沿用其线程和追踪信息
+	samples  int
+	failures int
+	sent     int64
+	received int64
+	errorMsg string
+	status   int
+	ended    bool
+	sample   ResultData
+}
+
+// BeginTransaction 开始一次事务
+func (c *Collector) BeginTransaction(name string) *Transaction {
+	return &Transaction{collector: c, name: name, start: time.Now()}
+}
+
+// RunTransaction 执行 fn 并把其中记录的请求作为一次事务统计，fn 返回错误时事务失败，返回 fn 的错误
+func (c *Collector) RunTransaction(name string, fn func(tx *Transaction) error) error {
+	tx := c.BeginTransaction(name)
+	err := fn(tx)
+	if err != nil {
+		tx.Fail(err)
+	}
+	tx.End()
+	return err
+}
+
+// Name 返回事务名称
+func (t *Transaction) Name() string {
+	return t.name
+}
+
+// Add 保存事务中的一个请求样本，并计入事务
+func (t *Transaction) Add(data ResultData) error {
+	t.mu.Lock()
+	if !t.ended {
+		t.samples++
+		t.sent += data.DataSent
+		t.received += data.DataReceived
+		if t.first == nil {
+			first := data
+			t.first = &first
+		}
+		if data.Type != Success {
+			t.failures++
+			t.fail(data.StatusCode, data.ErrorMessage)
+		}
+	}
+	t.mu.Unlock()
+
+	if data.Type == Success {
+		return t.collector.SaveSuccessResult(data)
+	}
+	return t.collector.SaveFailureResult(data)
+}
+
+// Fail 将事务标记为失败，用于没有对应请求样本的失败（例如变量提取失败）
+func (t *Transaction) Fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.ended && err != nil {
+		t.fail(0, err.Error())
+	}
+}
+
+// fail 记录第一个失败原因，调用方需持有 t.mu
+func (t *Transaction) fail(statusCode int, message string) {
+	if t.errorMsg != "" {
+		return
+	}
+	if message == "" {
+		message = fmt.Sprintf("request failed with status %d", statusCode)
+	}
+	t.errorMsg = message
+	t.status = statusCode
+}
+
+// End 结束事务并保存事务样本，重复调用返回第一次保存的样本
+func (t *Transaction) End() ResultData {
+	t.mu.Lock()
+	if t.ended {
+		defer t.mu.Unlock()
+		return t.sample
+	}
+	t.ended = true
+	end := time.Now()
+	sample := ResultData{
+		ID:           t.name,
+		Type:         Success,
+		StartTime:    t.start,
+		EndTime:      end,
+		ResponseTime: end.Sub(t.start),
+		StatusCode:   t.status,
+		DataSent:     t.sent,
+		DataReceived: t.received,
+		DataType:     TransactionDataType,
+		ResponseMsg:  fmt.Sprintf("Number of samples in transaction : %d, number of failing samples : %d", t.samples, t.failures),
+		Transaction:  t.name,
+	}
+	if t.first != nil {
+		sample.ThreadID = t.first.ThreadID
+		sample.TraceID, sample.VUID, sample.Iteration = t.first.TraceID, t.first.VUID, t.first.Iteration
+		if sample.StatusCode == 0 {
+			sample.StatusCode = t.first.StatusCode
+		}
+	}
+	if t.errorMsg != "" {
+		sample.Type = Failure
+		sample.ErrorMessage = t.errorMsg
+	}
+	t.sample = sample
+	t.mu.Unlock()
+
+	if sample.Type == Success {
+		t.collector.SaveSuccessResult(sample)
+	} else {
+		t.collector.SaveFailureResult(sample)
+	}
+	return sample
+}
+
+// TransactionStat 一个事务的汇总统计
+type TransactionStat struct {
+	Name            string
+	Count           int
+	SuccessCount    int
+	FailureCount    int
+	SuccessRate     float64 // 百分比，保留三位小数
+	TPS             float64 // 每秒完成的事务数，保留两位小数
+	AvgResponseTime time.Duration
+	MinResponseTime time.Duration
+	MaxResponseTime time.Duration
+	P90ResponseTime time.Duration
+	P95ResponseTime time.Duration
+	P99ResponseTime time.Duration
+}
+
+// splitTransactions 将结果分为请求样本和事务样本
+func splitTransactions(results []ResultData) ([]ResultData, []ResultData) {
+	var transactions []ResultData
+	requests := make([]ResultData, 0, len(results))
+	for _, r := range results {
+		if r.Transaction != "" {
+			transactions = append(transactions, r)
+		} else {
+			requests = append(requests, r)
+		}
+	}
+	return requests, transactions
+}
+
+// calculateTransactionStats 按事务名汇总事务样本，结果按名称排序
+func calculateTransactionStats(transactions []ResultData) []TransactionStat {
+	groups := make(map[string][]ResultData)
+	for _, r := range transactions {
+		groups[r.Transaction] = append(groups[r.Transaction], r)
+	}
+
+	stats := make([]TransactionStat, 0, len(groups))
+	for name, samples := range groups {
+		stat := TransactionStat{Name: name, Count: len(samples), MinResponseTime: samples[0].ResponseTime}
+		durations := make([]time.Duration, 0, len(samples))
+		var total time.Duration
+		first, last := samples[0].StartTime, samples[0].EndTime
+		for _, r := range samples {
+			if r.Type == Success {
+				stat.SuccessCount++
+			} else {
+				stat.FailureCount++
+			}
+			total += r.ResponseTime
+			if r.ResponseTime < stat.MinResponseTime {
+				stat.MinResponseTime = r.ResponseTime
+			}
+			if r.ResponseTime > stat.MaxResponseTime {
+				stat.MaxResponseTime = r.ResponseTime
+			}
+			if r.StartTime.Before(first) {
+				first = r.StartTime
+			}
+			if r.EndTime.After(last) {
+				last = r.EndTime
+			}
+			durations = append(durations, r.ResponseTime)
+		}
+		stat.AvgResponseTime = total / time.Duration(stat.Count)
+		stat.SuccessRate = math.Round(float64(stat.SuccessCount)/float64(stat.Count)*100*1000) / 1000
+		if elapsed := last.Sub(first).Seconds(); elapsed > 0 {
+			stat.TPS = math.Round(float64(stat.Count)/elapsed*100) / 100
+		}
+
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		stat.P90ResponseTime = percentileOf(durations, 90)
+		stat.P95ResponseTime = percentileOf(durations, 95)
+		stat.P99ResponseTime = percentileOf(durations, 99)
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,340 @@
+// trend.go
+// 历史运行趋势模块
+// 本文件负责按场景记录每次运行的关键指标（响应时间、TPS、错误率），供 API 查询性能趋势，
+// 并把本次运行与最近若干次运行的滚动基线比较，偏差超过容忍度时判定为性能回归，写入报告和日志。
+//
+// 技术实现细节：
+// 1. 趋势数据保存在 config.HistoryConfig.Dir 中，每个场景一个 JSON Lines 文件（<场景名>.jsonl），每次运行追加一行，
+//    不依赖外部数据库；场景名中不能用于文件名的字符替换为 _，场景的原始名称保存在每条记录中。
+//    无法解析的行（例如进程崩溃时写了一半）跳过，不影响其他记录。
+// 2. 指标名与阈值规则一致（avg、p90、p95、p99、tps、error_rate、total_requests），取值方式复用 thresholdMetrics，
+//    响应时间为毫秒，错误率为百分比。
+// 3. 滚动基线为同一场景最近 Baseline 次运行的平均值；响应时间上升或 TPS 下降超过 Tolerance%，
+//    或错误率上升超过 ErrorRateTolerance 个百分点时判定为回归。错误率通常接近 0，相对偏差没有意义，因此按百分点比较；
+//    基线为 0 的响应时间和 TPS 不做比较。没有历史运行时只记录，不判定。
+// 4. 比较结果写入 stats["TrendComparison"] 和 stats["TrendBaselineRuns"]，报告中展示与基线的对比；
+//    记录中保存本次回归的指标，查询趋势时可以直接看出哪些运行发生了回归。
+
+package result
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/potatoImp/OpenStress/config"
+)
+
+// 回归检测的默认参数
+const (
+	DefaultTrendBaseline           = 5
+	DefaultTrendTolerance          = 10.0
+	DefaultTrendErrorRateTolerance = 1.0
+)
+
+// trendFileExt 趋势文件的扩展名
+const trendFileExt = ".jsonl"
+
+// trendMetrics 每次运行记录的指标，顺序即报告中的展示顺序
+var trendMetrics = []string{"avg", "p90", "p95", "p99", "tps", "error_rate", "total_requests"}
+
+// regressionDirections 参与回归检测的指标，值为 true 表示数值越低越差（如 TPS）
+var regressionDirections = map[string]bool{
+	"avg":        false,
+	"p90":        false,
+	"p95":        false,
+	"p99":        false,
+	"tps":        true,
+	"error_rate": false,
+}
+
+// TrendRun 一次运行的关键指标
+type TrendRun struct {
+	Scenario  string             `json:"scenario"`            // 场景名
+	Time      time.Time          `json:"time"`                // 记录时间
+	TaskID    string             `json:"task_id,omitempty"`   // 任务ID
+	Metrics   map[string]float64 `json:"metrics"`             // 指标名 -> 值（响应时间为毫秒，错误率为百分比）
+	Regressed []string           `json:"regressed,omitempty"` // 相对当时的滚动基线发生回归的指标
+}
+
+// TrendDelta 一个指标与滚动基线的对比
+type TrendDelta struct {
+	Metric    string  `json:"metric"`
+	Baseline  float64 `json:"baseline"`  // 基线（最近若干次运行的平均值）
+	Actual    float64 `json:"actual"`    // 本次运行的值
+	Change    float64 `json:"change"`    // 变化量：错误率为百分点，其余为相对基线的百分比，正数表示数值上升
+	Limit     float64 `json:"limit"`     // 允许的最大变差（百分比或百分点）
+	Regressed bool    `json:"regressed"` // 变差超过 Limit
+}
+
+// ChangeText 返回带单位的变化量，错误率为百分点（pp），其余为百分比
+func (d TrendDelta) ChangeText() string {
+	if d.Metric == "error_rate" {
+		return fmt.Sprintf("%+.2fpp", d.Change)
+	}
+	return fmt.Sprintf("%+.2f%%", d.Change)
+}
+
+// TrendScenario 一个场景的趋势概况
+type TrendScenario struct {
+	Scenario  string    `json:"scenario"`
+	Runs      int       `json:"runs"`      // 记录的运行次数
+	LastRun   time.Time `json:"last_run"`  // 最近一次运行的时间
+	Regressed []string  `json:"regressed"` // 最近一次运行发生回归的指标
+}
+
+// TrendStore 按场景保存历史运行指标的本地趋势库，可以被多个协程并发使用
+type TrendStore struct {
+	mu                 sync.Mutex
+	dir                string
+	baseline           int
+	tolerance          float64
+	errorRateTolerance float64
+}
+
+// NewTrendStore 根据配置创建趋势库，Dir 为空时返回 nil（不记录趋势）
+func NewTrendStore(cfg config.HistoryConfig) *TrendStore {
+	if cfg.Dir == "" {
+		return nil
+	}
+	s := &TrendStore{
+		dir:                cfg.Dir,
+		baseline:           cfg.Baseline,
+		tolerance:          cfg.Tolerance,
+		errorRateTolerance: cfg.ErrorRateTolerance,
+	}
+	if s.baseline <= 0 {
+		s.baseline = DefaultTrendBaseline
+	}
+	if s.tolerance <= 0 {
+		s.tolerance = DefaultTrendTolerance
+	}
+	if s.errorRateTolerance <= 0 {
+		s.errorRateTolerance = DefaultTrendErrorRateTolerance
+	}
+	return s
+}
+
+// Baseline 返回滚动基线包含的运行数
+func (s *TrendStore) Baseline() int {
+	return s.baseline
+}
+
+// trendFileName 返回场景的趋势文件名，不能用于文件名的字符替换为 _
+func trendFileName(scenario string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, scenario)
+	return strings.TrimLeft(name, ".") + trendFileExt
+}
+
+// Record 追加一次运行的记录
+func (s *TrendStore) Record(run TrendRun) error {
+	if run.Scenario == "" {
+		return fmt.Errorf("trend run has no scenario name")
+	}
+	line, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode trend run: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create trend directory: %v", err)
+	}
+	file, err := os.OpenFile(filepath.Join(s.dir, trendFileName(run.Scenario)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open trend file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write trend run: %v", err)
+	}
+	return nil
+}
+
+// Runs 返回场景最近 limit 次运行的记录（limit <= 0 时返回全部），按时间正序；场景没有记录时返回空列表
+func (s *TrendStore) Runs(scenario string, limit int) ([]TrendRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := readTrendFile(filepath.Join(s.dir, trendFileName(scenario)))
+	if err != nil {
+		return nil, err
+	}
+	// 不同的场景名替换字符后可能对应同一个文件，只返回该场景的记录
+	runs := all[:0]
+	for _, run := range all {
+		if run.Scenario == scenario {
+			runs = append(runs, run)
+		}
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	return runs, nil
+}
+
+// Scenarios 列出有记录的场景，最近运行的场景在前
+func (s *TrendStore) Scenarios() ([]TrendScenario, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []TrendScenario{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trend directory: %v", err)
+	}
+
+	scenarios := []TrendScenario{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), trendFileExt) {
+			continue
+		}
+		runs, err := readTrendFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) == 0 {
+			continue
+		}
+		last := runs[len(runs)-1]
+		regressed := last.Regressed
+		if regressed == nil {
+			regressed = []string{}
+		}
+		scenarios = append(scenarios, TrendScenario{Scenario: last.Scenario, Runs: len(runs), LastRun: last.Time, Regressed: regressed})
+	}
+	sort.SliceStable(scenarios, func(i, j int) bool { return scenarios[i].LastRun.After(scenarios[j].LastRun) })
+	return scenarios, nil
+}
+
+// readTrendFile 读取趋势文件中的全部记录，文件不存在时返回空列表，跳过无法解析的行
+func readTrendFile(path string) ([]TrendRun, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []TrendRun{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trend file: %v", err)
+	}
+	defer file.Close()
+
+	runs := []TrendRun{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var run TrendRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil || run.Scenario == "" {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trend file: %v", err)
+	}
+	return runs, nil
+}
+
+// Compare 将 latest 与 history（同一场景之前的运行）中最近 Baseline 次运行的平均值比较，
+// 返回各指标的对比，history 为空时返回 nil
+func (s *TrendStore) Compare(history []TrendRun, latest TrendRun) []TrendDelta {
+	if len(history) > s.baseline {
+		history = history[len(history)-s.baseline:]
+	}
+	if len(history) == 0 {
+		return nil
+	}
+
+	var deltas []TrendDelta
+	for _, metric := range trendMetrics {
+		lowerIsWorse, checked := regressionDirections[metric]
+		actual, ok := latest.Metrics[metric]
+		if !checked || !ok {
+			continue
+		}
+		var sum float64
+		var n int
+		for _, run := range history {
+			if value, ok := run.Metrics[metric]; ok {
+				sum += value
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		delta := TrendDelta{Metric: metric, Baseline: sum / float64(n), Actual: actual, Limit: s.tolerance}
+		if metric == "error_rate" {
+			delta.Change = actual - delta.Baseline
+			delta.Limit = s.errorRateTolerance
+		} else if delta.Baseline != 0 {
+			delta.Change = (actual - delta.Baseline) / delta.Baseline * 100
+		} else {
+			continue
+		}
+		worsening := delta.Change
+		if lowerIsWorse {
+			worsening = -worsening
+		}
+		delta.Regressed = worsening > delta.Limit
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
+
+// trendRunFromStats 从统计结果中提取一次运行的关键指标
+func trendRunFromStats(stats map[string]interface{}, scenario, taskID string) TrendRun {
+	run := TrendRun{Scenario: scenario, Time: time.Now(), TaskID: taskID, Metrics: make(map[string]float64, len(trendMetrics))}
+	for _, metric := range trendMetrics {
+		if value, ok := thresholdMetrics[metric](stats); ok {
+			run.Metrics[metric] = value
+		}
+	}
+	return run
+}
+
+// RecordTrend 把本次运行的关键指标记录到趋势库，并与同一场景的滚动基线比较，
+// 比较结果写入 stats["TrendComparison"] 和 stats["TrendBaselineRuns"]，发生回归的指标记录警告日志。
+// 未配置趋势库（CollectorConfig.History.Dir 为空）时不做处理，返回 nil
+func (c *Collector) RecordTrend(stats map[string]interface{}, scenario string) ([]TrendDelta, error) {
+	if c.trends == nil {
+		return nil, nil
+	}
+	history, err := c.trends.Runs(scenario, c.trends.Baseline())
+	if err != nil {
+		return nil, err
+	}
+	run := trendRunFromStats(stats, scenario, c.taskID)
+	deltas := c.trends.Compare(history, run)
+	for _, delta := range deltas {
+		if delta.Regressed {
+			run.Regressed = append(run.Regressed, delta.Metric)
+			c.logger.Log("WARN", fmt.Sprintf("Performance regression in %s: %s = %.3f, baseline %.3f over the last %d runs (%s, limit %.2f)",
+				scenario, delta.Metric, delta.Actual, delta.Baseline, len(history), delta.ChangeText(), delta.Limit))
+		}
+	}
+	if err := c.trends.Record(run); err != nil {
+		return nil, err
+	}
+
+	stats["TrendComparison"] = deltas
+	stats["TrendBaselineRuns"] = len(history)
+	return deltas, nil
+}
+
+// TrendStore 返回收集器的趋势库，未配置时返回 nil
+func (c *Collector) TrendStore() *TrendStore {
+	return c.trends
+}
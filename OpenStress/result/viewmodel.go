@@ -0,0 +1,314 @@
+// viewmodel.go
+// 报告视图模型模块
+// 本文件负责把统计结果（stats map）转换为 HTML 报告模板使用的强类型视图模型 ReportViewModel，
+// 模板（templates/report.html）只负责排版，不再直接对 map 做类型断言。
+//
+// 技术实现细节：
+// 1. 所有取值都使用带 ok 的类型断言，缺少的必填指标显示为 "-"，缺少的可选部分（百分位、事务、阈值等）不展示，
+//    手工构造或来自旧版本的统计结果也不会导致报告生成时 panic。
+// 2. 统计表的格式与之前保持一致：时间转换为毫秒并保留两位小数，成功率保留三位小数并追加 %，
+//    对照参考标准（PerformanceStandard）给出 error / warning 样式。
+// 3. 文本通过 ReportViewModel.T 按报告语言取得；用户数据（URL、错误信息、阈值表达式等）由 html/template 自动转义。
+// 4. 自包含报告的样式、echarts 脚本和图表片段以 template.CSS / template.JS / template.HTML 传入，原样写入页面。
+
+package result
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/render"
+)
+
+// ReportViewModel HTML 报告模板的数据
+type ReportViewModel struct {
+	Title         string
+	Language      string // 页面语言，例如 zh
+	SelfContained bool   // 是否为自包含报告
+	CSS           template.CSS
+	EchartsJS     template.JS
+
+	Abort       *ReportAbort
+	Overview    []ReportRow
+	Statistics  []ReportRow
+	Percentiles *ReportPercentiles
+
+	Transactions    []TransactionStat
+	CustomMetrics   []CustomMetricStat
+	Delivery        *DeliveryStats
+	TLS             []TLSStat
+	TrafficMix      []TrafficShare
+	Thresholds      []ThresholdResult
+	Trend           *ReportTrend
+	Assertions      []ContentAssertionStat
+	FailurePayloads []ErrorSummary
+	FailedSamples   []FailedSample
+	Charts          []ReportChart
+
+	Analysis string
+	Concepts []concept
+
+	// 原始统计结果和运行元数据，供自定义模板使用
+	Stats    map[string]interface{}
+	Metadata RunMetadata
+
+	lang Language
+}
+
+// ReportTrend 本次运行与历史运行滚动基线的对比（见 trend.go）
+type ReportTrend struct {
+	BaselineRuns int // 基线包含的运行数
+	Deltas       []TrendDelta
+}
+
+// ReportRow 报告表格中的一行
+type ReportRow struct {
+	Label string
+	Value string
+	Class string // 单元格样式：error、warning 或空
+}
+
+// ReportAbort 提前中止的说明
+type ReportAbort struct {
+	Reason string
+	Time   string
+	Detail string
+}
+
+// ReportPercentiles 响应时间百分位表
+type ReportPercentiles struct {
+	Note string
+	Rows []ReportPercentileRow
+}
+
+// ReportPercentileRow 一个百分位的全量口径与排除重试/限流口径
+type ReportPercentileRow struct {
+	Label    string
+	All      time.Duration
+	Adjusted time.Duration
+}
+
+// ReportChart 报告中的一张图表
+type ReportChart struct {
+	Heading string
+	Name    string        // 图表页面名，例如 tps_chart
+	Element template.HTML // 自包含报告中的图表元素
+	Script  template.HTML // 自包含报告中的图表初始化脚本
+}
+
+// T 返回报告语言下消息键对应的文本
+func (m *ReportViewModel) T(key string, args ...interface{}) string {
+	return m.lang.text(key, args...)
+}
+
+// statisticsKeys 统计表中依次展示的指标
+var statisticsKeys = []string{"TotalRequests", "SuccessCount", "FailureCount", "TimeoutCount", "SuccessRate", "AvgResponseTime", "MaxResponseTime", "MinResponseTime", "TotalRunTime", "TPS", "SentDataPerSec", "ReceivedDataPerSec", "TotalSentData", "TotalReceivedData"}
+
+// reportStandards 统计表对照的参考标准
+var reportStandards = []PerformanceStandard{
+	{Field: "AvgResponseTime", Max: MaxAvgResponseTime, Compare: durationSeconds},
+	{Field: "SuccessRate", Min: MinSuccessRate, Compare: floatValue},
+	{Field: "TPS", Min: MaxTPS, Compare: floatValue},
+	{Field: "AvgResponseTime", Max: MaxHighFreqResponseTime, Compare: durationSeconds},
+}
+
+// durationSeconds 参考标准取值：时间转换为秒
+func durationSeconds(value interface{}) float64 {
+	d, _ := value.(time.Duration)
+	return d.Seconds()
+}
+
+// floatValue 参考标准取值：浮点数
+func floatValue(value interface{}) float64 {
+	f, _ := value.(float64)
+	return f
+}
+
+// newReportViewModel 从统计结果构建报告视图模型，inline 不为 nil 时构建自包含报告
+func newReportViewModel(stats map[string]interface{}, title []string, inline map[string]render.ChartSnippet) *ReportViewModel {
+	lang := reportLanguage(stats)
+	m := &ReportViewModel{
+		Title:         reportTitle(title, lang),
+		Language:      lang.text("html_lang"),
+		SelfContained: inline != nil,
+		Analysis:      generateDefaultAnalysis(stats, lang),
+		Concepts:      lang.concepts(),
+		Stats:         stats,
+		lang:          lang,
+	}
+	if inline != nil {
+		m.CSS = template.CSS(generateCSS())
+		m.EchartsJS = template.JS(echartsJS)
+	}
+	if metadata, ok := stats["RunMetadata"].(RunMetadata); ok {
+		m.Metadata = metadata
+	}
+
+	// 提前中止提示
+	if aborted, _ := stats["Aborted"].(bool); aborted {
+		reason, _ := stats["StopReason"].(string)
+		detail, _ := stats["AbortDetail"].(string)
+		abort := &ReportAbort{Reason: stopReasonText(reason, lang), Time: "-", Detail: detail}
+		if at, ok := stats["AbortTime"].(time.Time); ok {
+			abort.Time = at.Format("2006-01-02 15:04:05")
+		}
+		m.Abort = abort
+	}
+
+	m.Overview = overviewRows(stats, lang)
+	m.Statistics = statisticsRows(stats, lang)
+	m.Percentiles = percentileTable(stats, lang)
+
+	m.Transactions, _ = stats["TransactionStats"].([]TransactionStat)
+	m.CustomMetrics, _ = stats["CustomMetrics"].([]CustomMetricStat)
+	if delivery, ok := stats["DeliveryStats"].(DeliveryStats); ok {
+		m.Delivery = &delivery
+	}
+	m.TLS, _ = stats["TLSStats"].([]TLSStat)
+	m.TrafficMix, _ = stats["TrafficMix"].([]TrafficShare)
+	m.Thresholds, _ = stats["ThresholdResults"].([]ThresholdResult)
+	if deltas, ok := stats["TrendComparison"].([]TrendDelta); ok && len(deltas) > 0 {
+		runs, _ := stats["TrendBaselineRuns"].(int)
+		m.Trend = &ReportTrend{BaselineRuns: runs, Deltas: deltas}
+	}
+	m.Assertions, _ = stats["ContentAssertionStats"].([]ContentAssertionStat)
+	if topErrors, ok := stats["TopErrors"].([]ErrorSummary); ok {
+		for _, e := range topErrors {
+			if e.Sample != "" {
+				m.FailurePayloads = append(m.FailurePayloads, e)
+			}
+		}
+	}
+	m.FailedSamples, _ = stats["FailedSamples"].([]FailedSample)
+	m.Charts = reportCharts(stats, lang, inline)
+	return m
+}
+
+// overviewRows 测试概览：开始和结束时间、随机种子和运行元数据
+func overviewRows(stats map[string]interface{}, lang Language) []ReportRow {
+	unixTime := func(key string) string {
+		if sec, ok := stats[key].(int64); ok {
+			return time.Unix(sec, 0).Format("2006-01-02 15:04:05")
+		}
+		return "-"
+	}
+	rows := []ReportRow{
+		{Label: lang.text("start_time"), Value: unixTime("AvgTpsStartTime")},
+		{Label: lang.text("end_time"), Value: unixTime("AvgTpsEndTime")},
+	}
+	if seed, ok := stats["Seed"].(int64); ok {
+		rows = append(rows, ReportRow{Label: lang.text("seed"), Value: fmt.Sprintf("%d", seed)})
+	}
+	if metadata, ok := stats["RunMetadata"].(RunMetadata); ok {
+		rows = append(rows, runMetadataRows(metadata, lang)...)
+	}
+	return rows
+}
+
+// statisticsRows 测试统计数据，缺少的指标显示为 "-"
+func statisticsRows(stats map[string]interface{}, lang Language) []ReportRow {
+	rows := make([]ReportRow, 0, len(statisticsKeys)+1)
+	for _, key := range statisticsKeys {
+		row := ReportRow{Label: key, Value: "-"}
+		value, ok := stats[key]
+		if !ok || value == nil {
+			rows = append(rows, row)
+			continue
+		}
+
+		// 对照参考标准
+		for _, standard := range reportStandards {
+			if standard.Field != key {
+				continue
+			}
+			compareValue := standard.Compare(value)
+			if standard.Min > 0 && compareValue < standard.Min {
+				row.Class = "error"
+			} else if standard.Max > 0 && compareValue > standard.Max {
+				row.Class = "warning"
+			}
+		}
+
+		switch v := value.(type) {
+		case time.Duration:
+			row.Value = fmt.Sprintf("%.2f ms", float64(v)/float64(time.Millisecond))
+		default:
+			if key == "SuccessRate" {
+				row.Value = fmt.Sprintf("%.3f%%", v)
+			} else {
+				row.Value = fmt.Sprintf("%v", v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if reason, ok := stats["StopReason"].(string); ok {
+		rows = append(rows, ReportRow{Label: "StopReason", Value: stopReasonText(reason, lang)})
+	}
+	return rows
+}
+
+// percentileTable 响应时间百分位表，没有百分位统计时返回 nil
+func percentileTable(stats map[string]interface{}, lang Language) *ReportPercentiles {
+	all, ok := stats["LatencyPercentilesAll"].(map[string]time.Duration)
+	if !ok {
+		return nil
+	}
+	adjusted, _ := stats["LatencyPercentilesAdjusted"].(map[string]time.Duration)
+	table := &ReportPercentiles{
+		Note: lang.text("percentiles_note", stats["LatencyView"], stats["RetriedCount"], stats["TotalRetries"], stats["TotalThrottleWait"]),
+	}
+	for _, p := range percentileLevels {
+		key := percentileKey(p)
+		table.Rows = append(table.Rows, ReportPercentileRow{Label: fmt.Sprintf("P%g", p), All: all[key], Adjusted: adjusted[key]})
+	}
+	return table
+}
+
+// reportCharts 报告中展示的图表，只有对应数据存在时才展示可选图表；自包含报告中构建失败的图表不展示
+func reportCharts(stats map[string]interface{}, lang Language, inline map[string]render.ChartSnippet) []ReportChart {
+	names := []string{"tps_chart", "response_time_chart", "flow_trend_chart"}
+	// 吞吐量-响应时间关系图，负载有变化（例如阶梯加压）时用于寻找拐点
+	if samples, ok := stats["ThroughputSamples"].([]ThroughputSample); ok && throughputLevels(samples) >= minThroughputLevels {
+		names = append(names, "tps_latency_chart")
+	}
+	// 请求阶段耗时图，只有采集了阶段耗时的 HTTP 请求才有数据
+	if breakdown, ok := stats["PhaseBreakdown"].([]PhaseSample); ok && len(breakdown) > 0 {
+		names = append(names, "phase_chart")
+	}
+	// 消息投递延迟趋势图，只有发布/订阅类任务才有数据
+	if samples, ok := stats["DeliveryLatency"].([]DeliverySample); ok && len(samples) > 0 {
+		names = append(names, "delivery_chart")
+	}
+	// 熔断状态图，只有配置了熔断器且状态发生过变化才有数据
+	if transitions, ok := stats["BreakerTransitions"].([]BreakerTransition); ok && len(transitions) > 0 {
+		names = append(names, "breaker_chart")
+	}
+	// 自定义指标趋势图，只有任务代码上报了自定义指标才有数据
+	if metrics, ok := stats["CustomMetrics"].([]CustomMetricStat); ok && len(metrics) > 0 {
+		names = append(names, "custom_metrics_chart")
+	}
+	// 压测机资源使用趋势图，CPU 接近打满时瓶颈可能在压测机而不是被测服务
+	if samples, ok := stats["ResourceSamples"].([]ResourceSample); ok && len(samples) > 0 {
+		names = append(names, "resource_chart")
+	}
+	// 活跃 VU 与队列深度图，与响应时间对照判断延迟上涨是否由压测端饱和引起
+	if samples, ok := stats["LoadSamples"].([]LoadSample); ok && len(samples) > 0 {
+		names = append(names, "load_chart")
+	}
+
+	charts := make([]ReportChart, 0, len(names))
+	for _, name := range names {
+		chart := ReportChart{Heading: lang.text(name), Name: name}
+		if inline != nil {
+			snippet, ok := inline[name]
+			if !ok {
+				continue
+			}
+			chart.Element = template.HTML(snippet.Element)
+			chart.Script = template.HTML(snippet.Script)
+		}
+		charts = append(charts, chart)
+	}
+	return charts
+}
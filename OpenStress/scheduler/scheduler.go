@@ -0,0 +1,310 @@
+// scheduler.go
+// 定时调度模块
+// 本文件负责在协程池之上提供定时执行能力：
+// - 延迟执行：在指定时间运行一次
+// - 周期执行：按 cron 表达式重复运行，例如每 5 分钟运行一次冒烟场景作为持续探测
+//
+// 技术实现细节：
+// 1. 调度协程只等待最近一次触发时间，到期后把任务提交到协程池执行，不占用 worker 等待。
+// 2. 每次运行生成独立的运行ID（任务名 + 触发时间），作为协程池的任务ID和结果收集器的 TaskID，
+//    因此每次运行都有独立的 JTL 文件和运行目录锁，结果互不混淆。
+// 3. 同一个任务的上一次运行尚未结束时跳过本次触发并记录警告，避免探测任务相互叠加。
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// JobFunc 定时任务函数。runID 为本次运行的任务ID；collector 为本次运行专属的结果收集器，
+// 未配置收集器时为 nil，函数返回后收集器会被关闭
+type JobFunc func(runID string, collector *result.Collector)
+
+// JobInfo 定时任务快照
+type JobInfo struct {
+	Name      string    `json:"name"`
+	Schedule  string    `json:"schedule"`
+	NextRun   time.Time `json:"next_run"` // 零值表示不会再触发
+	LastRun   time.Time `json:"last_run"`
+	LastRunID string    `json:"last_run_id"`
+	Runs      int       `json:"runs"`
+	Skipped   int       `json:"skipped"` // 因上一次运行未结束而跳过的次数
+}
+
+// job 定时任务
+type job struct {
+	name      string
+	spec      string
+	schedule  Schedule
+	priority  int
+	fn        JobFunc
+	next      time.Time
+	lastRun   time.Time
+	lastRunID string
+	runs      int
+	skipped   int
+}
+
+// Scheduler 定时调度器
+type Scheduler struct {
+	pool            *pool.Pool
+	collectorConfig *result.CollectorConfig // 每次运行的收集器配置模板，TaskID 会被替换为运行ID
+	logger          *pool.StressLogger
+
+	mu   sync.Mutex
+	jobs map[string]*job
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewScheduler 创建调度器，collectorConfig 为 nil 时不为每次运行创建结果收集器
+func NewScheduler(taskPool *pool.Pool, collectorConfig *result.CollectorConfig) *Scheduler {
+	logger, _ := pool.GetModuleLogger("scheduler")
+	return &Scheduler{
+		pool:            taskPool,
+		collectorConfig: collectorConfig,
+		logger:          logger,
+		jobs:            make(map[string]*job),
+		wake:            make(chan struct{}, 1),
+		stop:            make(chan struct{}),
+	}
+}
+
+// log 记录日志，日志模块未初始化时忽略
+func (s *Scheduler) log(level, message string) {
+	if s.logger != nil {
+		s.logger.LogDepth(1, level, message)
+	}
+}
+
+// ScheduleAt 在指定时间运行一次
+func (s *Scheduler) ScheduleAt(name string, at time.Time, priority int, fn JobFunc) error {
+	return s.add(name, "@at "+at.Format(time.RFC3339), onceSchedule{at: at}, priority, fn)
+}
+
+// ScheduleCron 按 cron 表达式周期运行
+func (s *Scheduler) ScheduleCron(name, expr string, priority int, fn JobFunc) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	return s.add(name, expr, schedule, priority, fn)
+}
+
+// add 添加定时任务并唤醒调度协程
+func (s *Scheduler) add(name, spec string, schedule Schedule, priority int, fn JobFunc) error {
+	if name == "" {
+		return fmt.Errorf("job name is required")
+	}
+	if fn == nil {
+		return fmt.Errorf("job %s has no function", name)
+	}
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("job %s (%s) will never run", name, spec)
+	}
+
+	s.mu.Lock()
+	if _, exists := s.jobs[name]; exists {
+		s.mu.Unlock()
+		return fmt.Errorf("job %s is already scheduled", name)
+	}
+	s.jobs[name] = &job{name: name, spec: spec, schedule: schedule, priority: priority, fn: fn, next: next}
+	s.mu.Unlock()
+
+	s.log("INFO", fmt.Sprintf("Scheduled job %s (%s), next run at %s", name, spec, next.Format("2006-01-02 15:04:05")))
+	s.notify()
+	return nil
+}
+
+// Remove 删除定时任务，不影响已经在运行的实例
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	delete(s.jobs, name)
+	s.mu.Unlock()
+
+	if ok {
+		s.log("INFO", fmt.Sprintf("Removed scheduled job %s", name))
+		s.notify()
+	}
+	return ok
+}
+
+// Jobs 返回所有定时任务的快照，按下一次运行时间排序
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		infos = append(infos, JobInfo{
+			Name:      j.name,
+			Schedule:  j.spec,
+			NextRun:   j.next,
+			LastRun:   j.lastRun,
+			LastRunID: j.lastRunID,
+			Runs:      j.runs,
+			Skipped:   j.skipped,
+		})
+	}
+	sort.Slice(infos, func(i, k int) bool {
+		if infos[i].NextRun.IsZero() != infos[k].NextRun.IsZero() {
+			return !infos[i].NextRun.IsZero()
+		}
+		return infos[i].NextRun.Before(infos[k].NextRun)
+	})
+	return infos
+}
+
+// Start 启动调度协程
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go s.run()
+	s.log("INFO", "Scheduler started")
+}
+
+// Stop 停止调度，可重复调用；已提交到协程池的运行不受影响
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+		s.log("INFO", "Scheduler stopped")
+	})
+}
+
+// notify 唤醒调度协程重新计算下一次触发时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 调度循环：等待最近一次触发时间，到期后提交任务
+func (s *Scheduler) run() {
+	defer s.wg.Done()
+	for {
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if next := s.nextRun(); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-s.stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-s.wake:
+		case now := <-fire:
+			s.runDue(now)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// nextRun 返回所有任务中最早的下一次触发时间
+func (s *Scheduler) nextRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, j := range s.jobs {
+		if !j.next.IsZero() && (next.IsZero() || j.next.Before(next)) {
+			next = j.next
+		}
+	}
+	return next
+}
+
+// dueRun 一次待提交的运行
+type dueRun struct {
+	job   *job
+	runID string
+}
+
+// runDue 提交所有已到期的任务，并计算它们的下一次触发时间
+func (s *Scheduler) runDue(now time.Time) {
+	var due []dueRun
+
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || j.next.After(now) {
+			continue
+		}
+		fireAt := j.next
+		j.next = j.schedule.Next(now)
+
+		if s.stillRunning(j.lastRunID) {
+			j.skipped++
+			s.log("WARN", fmt.Sprintf("Skipping run of job %s: previous run %s is still in progress", j.name, j.lastRunID))
+			continue
+		}
+		runID := fmt.Sprintf("%s-%s", j.name, fireAt.Format("20060102-150405"))
+		j.lastRun = now
+		j.lastRunID = runID
+		j.runs++
+		due = append(due, dueRun{job: j, runID: runID})
+	}
+	s.mu.Unlock()
+
+	for _, d := range due {
+		d := d
+		err := s.pool.Submit(func(int32) { s.execute(d.job, d.runID) }, d.job.priority, d.runID, 0)
+		if err != nil {
+			s.log("ERROR", fmt.Sprintf("Failed to submit run %s of job %s: %v", d.runID, d.job.name, err))
+		}
+	}
+}
+
+// stillRunning 判断上一次运行是否仍在等待或执行
+func (s *Scheduler) stillRunning(runID string) bool {
+	if runID == "" {
+		return false
+	}
+	info, err := s.pool.GetTaskStatus(runID)
+	if err != nil {
+		return false
+	}
+	return info.Status == pool.TaskPending.String() || info.Status == pool.TaskRunning.String()
+}
+
+// execute 为本次运行创建独立的结果收集器并执行任务函数
+func (s *Scheduler) execute(j *job, runID string) {
+	var collector *result.Collector
+	if s.collectorConfig != nil {
+		config := *s.collectorConfig
+		config.TaskID = runID
+		if config.TestPlan == "" {
+			config.TestPlan = j.name
+		}
+		c, err := result.NewCollector(config)
+		if err != nil {
+			s.log("ERROR", fmt.Sprintf("Run %s of job %s aborted: failed to create collector: %v", runID, j.name, err))
+			return
+		}
+		collector = c
+		defer func() {
+			if err := collector.Close(); err != nil {
+				s.log("ERROR", fmt.Sprintf("Failed to close collector of run %s: %v", runID, err))
+			}
+		}()
+	}
+
+	s.log("INFO", fmt.Sprintf("Running job %s as %s", j.name, runID))
+	j.fn(runID, collector)
+}
@@ -0,0 +1,194 @@
+// selftest.go
+// 压测机自测模块
+// 本文件负责测量本机作为压测机的最大请求速率：在进程内启动一个 echo 服务，
+// 使用多个并发客户端在固定时长内尽可能快地发送请求，得出本机可驱动的容量估计。
+//
+// 使用方式：
+//   openstress selftest [-duration 5s] [-concurrency N] [-rate 计划速率]
+//
+// 结果会保存到 DefaultResultPath，API 服务启动时读取一次，后续设置的目标速率超过容量估计时会给出警告。
+// 请求失败时客户端按指数退避后重试，避免在连接耗尽时空转占满 CPU。
+
+package selftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultResultPath 自测结果的默认保存路径
+const DefaultResultPath = ".openstress/selftest.json"
+
+// capacityFactor 容量估计的安全系数：压测机只驱动 echo 服务时的速率是上限，
+// 真实场景还要解析响应、记录结果，因此按 70% 估计
+const capacityFactor = 0.7
+
+// 请求失败后的退避时间范围
+const (
+	minBackoff = 10 * time.Millisecond
+	maxBackoff = 500 * time.Millisecond
+)
+
+// Options 自测参数
+type Options struct {
+	Duration    time.Duration // 测试时长
+	Concurrency int           // 并发客户端数，0 表示 CPU 核数的 8 倍
+	Target      string        // 请求的目标 URL，为空时使用进程内的 echo 服务
+}
+
+// Result 自测结果
+type Result struct {
+	CPUs        int           `json:"cpus"`
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration"`
+	Requests    int64         `json:"requests"`
+	Errors      int64         `json:"errors"`
+	RPS         float64       `json:"rps"`
+	P50         time.Duration `json:"p50"`
+	P99         time.Duration `json:"p99"`
+	Capacity    float64       `json:"capacity"` // 容量估计（每秒请求数）
+	MeasuredAt  time.Time     `json:"measured_at"`
+}
+
+// Run 执行自测
+func Run(opts Options) (*Result, error) {
+	if opts.Duration <= 0 {
+		opts.Duration = 5 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = runtime.NumCPU() * 8
+	}
+
+	target := opts.Target
+	if target == "" {
+		payload := []byte(`{"status":"ok"}`)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(payload)
+		}))
+		defer server.Close()
+		target = server.URL
+	}
+
+	transport := &http.Transport{
+		MaxIdleConns:        opts.Concurrency,
+		MaxIdleConnsPerHost: opts.Concurrency,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+	var requests, failures int64
+	latencies := make([][]time.Duration, opts.Concurrency)
+	deadline := time.Now().Add(opts.Duration)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			backoff := minBackoff
+			for time.Now().Before(deadline) {
+				begin := time.Now()
+				resp, err := client.Get(target)
+				if err != nil {
+					atomic.AddInt64(&failures, 1)
+					// 失败后退避，退避时间不超过剩余测试时长
+					time.Sleep(min(backoff, time.Until(deadline)))
+					backoff = min(backoff*2, maxBackoff)
+					continue
+				}
+				backoff = minBackoff
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				latencies[worker] = append(latencies[worker], time.Since(begin))
+				atomic.AddInt64(&requests, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if requests == 0 {
+		return nil, fmt.Errorf("selftest completed no requests (%d errors)", failures)
+	}
+
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	rps := float64(requests) / elapsed.Seconds()
+	return &Result{
+		CPUs:        runtime.NumCPU(),
+		Concurrency: opts.Concurrency,
+		Duration:    elapsed,
+		Requests:    requests,
+		Errors:      failures,
+		RPS:         rps,
+		P50:         all[len(all)*50/100],
+		P99:         all[len(all)*99/100],
+		Capacity:    rps * capacityFactor,
+		MeasuredAt:  time.Now(),
+	}, nil
+}
+
+// String 返回可读的自测报告
+func (r *Result) String() string {
+	return fmt.Sprintf(`Generator self-benchmark:
+	CPUs: %d
+	Concurrency: %d
+	Duration: %v
+	Requests: %d (errors: %d)
+	Max rate: %.0f req/s
+	Latency p50/p99: %v / %v
+	Capacity estimate: %.0f req/s`,
+		r.CPUs, r.Concurrency, r.Duration.Round(time.Millisecond), r.Requests, r.Errors,
+		r.RPS, r.P50, r.P99, r.Capacity)
+}
+
+// CheckPlan 检查计划速率是否超过容量估计，超过时返回警告信息
+func (r *Result) CheckPlan(plannedRPS float64) (string, bool) {
+	if plannedRPS <= r.Capacity {
+		return "", true
+	}
+	return fmt.Sprintf("planned rate %.0f req/s exceeds this generator's estimated capacity of %.0f req/s (selftest at %s); results may be limited by the load generator rather than the system under test",
+		plannedRPS, r.Capacity, r.MeasuredAt.Format("2006-01-02 15:04:05")), false
+}
+
+// Save 保存自测结果
+func (r *Result) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create selftest directory: %v", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode selftest result: %v", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Load 读取已保存的自测结果
+func Load(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse selftest result: %v", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,319 @@
+// authflow.go
+// 登录令牌模块
+// 本文件负责测试场景中的登录流程：执行一次登录请求，从响应中提取 Bearer 令牌，
+// 注入后续请求，并在令牌过期或服务端返回 401 时自动重新登录。
+//
+// 技术实现细节：
+// 1. AuthFlow 持有一个令牌，并发请求共用；令牌失效时只有一个请求重新登录，其他请求等待新令牌。
+// 2. 令牌通过 JSON 路径（兼容 "$." 前缀）或正则表达式（第一个捕获组）提取，
+//    响应中带有效期（例如 expires_in）时按有效期提前刷新，否则使用配置的 TTL，两者都没有时只在 401 时刷新。
+// 3. AuthTransport 包装 http.RoundTripper：请求前注入令牌，收到 401 后作废该令牌、重新登录并重试一次；
+//    请求体无法重放（没有 GetBody）时不重试，直接返回 401 响应。
+// 4. AuthSessions 按虚拟用户管理登录：每个 VU 各自登录（模拟不同用户），或所有 VU 共用一个令牌。
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// 登录令牌默认值
+const (
+	DefaultTokenHeader   = "Authorization"
+	DefaultTokenPrefix   = "Bearer "
+	DefaultRefreshBefore = 10 * time.Second // 令牌到期前提前刷新的时间
+)
+
+// maxLoginBody 读取登录响应体的最大字节数
+const maxLoginBody = 1 << 20
+
+// TokenExtractor 令牌提取规则，JSONPath 与 Regex 二选一
+type TokenExtractor struct {
+	JSONPath      string // 令牌所在的 JSON 路径，例如 "$.data.access_token"
+	Regex         string // 匹配令牌的正则表达式，取第一个捕获组
+	ExpiresInPath string // 可选：令牌有效期（秒）所在的 JSON 路径，例如 "$.expires_in"
+}
+
+// LoginConfig 登录流程配置
+type LoginConfig struct {
+	// NewRequest 创建登录请求，每次登录调用一次；vuID 为虚拟用户编号，共用令牌时为 -1
+	NewRequest    func(ctx context.Context, vuID int) (*http.Request, error)
+	Extract       TokenExtractor
+	Header        string        // 注入令牌的请求头，默认 DefaultTokenHeader
+	Prefix        string        // 令牌前缀，默认 DefaultTokenPrefix
+	TTL           time.Duration // 响应中没有有效期时使用的令牌有效期，0 表示只在 401 时刷新
+	RefreshBefore time.Duration // 到期前提前刷新的时间，默认 DefaultRefreshBefore
+	Client        *http.Client  // 发送登录请求的客户端，为 nil 时使用 DefaultHTTPClient
+}
+
+// AuthFlow 一个登录会话，持有当前令牌，并发安全
+type AuthFlow struct {
+	cfg   LoginConfig
+	vuID  int
+	regex *regexp.Regexp
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time // 零值表示不过期
+	logins    int
+}
+
+// NewAuthFlow 创建共用的登录会话
+func NewAuthFlow(cfg LoginConfig) (*AuthFlow, error) {
+	return newAuthFlow(cfg, -1)
+}
+
+// newAuthFlow 校验配置并创建登录会话
+func newAuthFlow(cfg LoginConfig, vuID int) (*AuthFlow, error) {
+	if cfg.NewRequest == nil {
+		return nil, fmt.Errorf("login request is required")
+	}
+	if (cfg.Extract.JSONPath == "") == (cfg.Extract.Regex == "") {
+		return nil, fmt.Errorf("exactly one of json path and regex is required to extract the token")
+	}
+	if cfg.Header == "" {
+		cfg.Header = DefaultTokenHeader
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultTokenPrefix
+	}
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = DefaultRefreshBefore
+	}
+	flow := &AuthFlow{cfg: cfg, vuID: vuID}
+	if cfg.Extract.Regex != "" {
+		regex, err := regexp.Compile(cfg.Extract.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token regex: %v", err)
+		}
+		if regex.NumSubexp() < 1 {
+			return nil, fmt.Errorf("token regex %q has no capture group", cfg.Extract.Regex)
+		}
+		flow.regex = regex
+	}
+	return flow, nil
+}
+
+// Token 返回当前令牌，没有令牌或令牌即将过期时先登录
+func (a *AuthFlow) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && (a.expiresAt.IsZero() || time.Now().Add(a.cfg.RefreshBefore).Before(a.expiresAt)) {
+		return a.token, nil
+	}
+	if err := a.login(ctx); err != nil {
+		return "", err
+	}
+	return a.token, nil
+}
+
+// Invalidate 作废令牌（例如服务端返回 401），下次 Token 时重新登录。
+// 只有 token 仍是当前令牌时才作废，并发收到 401 的请求不会导致重复登录
+func (a *AuthFlow) Invalidate(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == token {
+		a.token = ""
+	}
+}
+
+// Logins 返回已执行的登录次数
+func (a *AuthFlow) Logins() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.logins
+}
+
+// login 执行登录请求并提取令牌，调用方需持有 a.mu
+func (a *AuthFlow) login(ctx context.Context) error {
+	req, err := a.cfg.NewRequest(ctx, a.vuID)
+	if err != nil {
+		return fmt.Errorf("failed to create login request: %v", err)
+	}
+	client := a.cfg.Client
+	if client == nil {
+		client = DefaultHTTPClient()
+	}
+	a.logins++
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("login request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxLoginBody))
+	if err != nil {
+		return fmt.Errorf("failed to read login response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("login failed with status %d", resp.StatusCode)
+	}
+
+	token, err := a.extractToken(body)
+	if err != nil {
+		return err
+	}
+	a.token = token
+	a.expiresAt = time.Time{}
+	if ttl := a.tokenTTL(body); ttl > 0 {
+		a.expiresAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+// extractToken 按提取规则从登录响应体中提取令牌
+func (a *AuthFlow) extractToken(body []byte) (string, error) {
+	if a.regex != nil {
+		match := a.regex.FindSubmatch(body)
+		if match == nil || len(match[1]) == 0 {
+			return "", fmt.Errorf("token regex %q did not match the login response", a.cfg.Extract.Regex)
+		}
+		return string(match[1]), nil
+	}
+	value, err := result.ExtractJSONPath(body, trimJSONPath(a.cfg.Extract.JSONPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to extract token from login response: %v", err)
+	}
+	token, ok := value.(string)
+	if !ok || token == "" {
+		return "", fmt.Errorf("token at %s is not a non-empty string", a.cfg.Extract.JSONPath)
+	}
+	return token, nil
+}
+
+// tokenTTL 返回令牌有效期：优先使用响应中的有效期（秒），否则使用配置的 TTL
+func (a *AuthFlow) tokenTTL(body []byte) time.Duration {
+	if a.cfg.Extract.ExpiresInPath != "" {
+		if value, err := result.ExtractJSONPath(body, trimJSONPath(a.cfg.Extract.ExpiresInPath)); err == nil {
+			switch v := value.(type) {
+			case float64:
+				return time.Duration(v * float64(time.Second))
+			case string:
+				if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+					return time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+	return a.cfg.TTL
+}
+
+// trimJSONPath 去掉 JSONPath 风格的 "$." 或 "$" 前缀，转换为 result.ExtractJSONPath 使用的点分路径
+func trimJSONPath(path string) string {
+	path = strings.TrimPrefix(path, "$")
+	return strings.TrimPrefix(path, ".")
+}
+
+// Transport 返回注入令牌的 RoundTripper，base 为 nil 时使用 DefaultHTTPClient 的传输层
+func (a *AuthFlow) Transport(base http.RoundTripper) *AuthTransport {
+	if base == nil {
+		base = DefaultHTTPClient().Transport
+	}
+	return &AuthTransport{Base: base, Flow: a}
+}
+
+// Client 返回注入令牌的 HTTP 客户端，base 为 nil 时使用 DefaultHTTPClient 的配置
+func (a *AuthFlow) Client(base *http.Client) *http.Client {
+	if base == nil {
+		base = DefaultHTTPClient()
+	}
+	client := *base
+	client.Transport = a.Transport(base.Transport)
+	return &client
+}
+
+// AuthTransport 为每个请求注入登录令牌，收到 401 时重新登录并重试一次
+type AuthTransport struct {
+	Base http.RoundTripper
+	Flow *AuthFlow
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Flow.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.Base.RoundTrip(t.withToken(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// 请求体已被读取且无法重放时不重试
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	t.Flow.Invalidate(token)
+	newToken, err := t.Flow.Token(req.Context())
+	if err != nil {
+		// 重新登录失败时返回原始的 401 响应，由调用方按失败处理
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := t.withToken(req, newToken)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %v", err)
+		}
+	}
+	return t.Base.RoundTrip(retry)
+}
+
+// withToken 克隆请求并设置令牌请求头，RoundTrip 不应修改原始请求
+func (t *AuthTransport) withToken(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set(t.Flow.cfg.Header, t.Flow.cfg.Prefix+token)
+	return clone
+}
+
+// AuthSessions 按虚拟用户管理登录会话
+type AuthSessions struct {
+	cfg    LoginConfig
+	shared *AuthFlow // 共用令牌时的唯一会话，每个 VU 各自登录时为 nil
+
+	mu    sync.Mutex
+	perVU map[int]*AuthFlow
+}
+
+// NewAuthSessions 创建登录会话管理，perVU 为 true 时每个虚拟用户各自登录，否则所有虚拟用户共用一个令牌
+func NewAuthSessions(cfg LoginConfig, perVU bool) (*AuthSessions, error) {
+	// 先校验一次配置，避免每个 VU 第一次请求时才报错
+	flow, err := newAuthFlow(cfg, -1)
+	if err != nil {
+		return nil, err
+	}
+	sessions := &AuthSessions{cfg: cfg}
+	if perVU {
+		sessions.perVU = make(map[int]*AuthFlow)
+	} else {
+		sessions.shared = flow
+	}
+	return sessions, nil
+}
+
+// ForVU 返回虚拟用户使用的登录会话，每个 VU 第一次请求时登录
+func (s *AuthSessions) ForVU(vuID int) *AuthFlow {
+	if s.shared != nil {
+		return s.shared
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, ok := s.perVU[vuID]
+	if !ok {
+		// 配置已在 NewAuthSessions 中校验过
+		flow, _ = newAuthFlow(s.cfg, vuID)
+		s.perVU[vuID] = flow
+	}
+	return flow
+}
@@ -0,0 +1,134 @@
+// bandwidth.go
+// 带宽模拟模块
+// 本文件负责在 HTTP 连接上限制上行和下行的字节速率，模拟移动网络、弱网等低带宽客户端。
+//
+// 技术实现细节：
+// 1. 限速作用在每个 TCP 连接上（包装 Transport 的 DialContext），统计的是实际传输的字节，包括 TLS 握手和协议开销。
+// 2. HTTP/1.1 下一个连接同时只承载一个请求，每个 VU 的请求独占所在连接的带宽，相当于按 VU 限速；
+//    HTTP/2 下多个 VU 的请求会复用同一个连接、共享带宽，需要按 VU 模拟时应同时设置 disable_http2。
+// 3. 读写按约 50ms 的数据量分块，每块传输后按速率计算下一块的最早时间并等待，空闲时间不会积累成突发流量。
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// bandwidthChunkInterval 限速时每次读写的数据量对应的传输时间
+const bandwidthChunkInterval = 50 * time.Millisecond
+
+// BandwidthConfig 客户端带宽模拟配置，0 表示该方向不限速
+type BandwidthConfig struct {
+	UploadBytesPerSecond   int64 `yaml:"upload_bytes_per_second"`   // 上行（发送请求）每秒字节数
+	DownloadBytesPerSecond int64 `yaml:"download_bytes_per_second"` // 下行（接收响应）每秒字节数
+}
+
+// Enabled 是否配置了任一方向的限速
+func (c BandwidthConfig) Enabled() bool {
+	return c.UploadBytesPerSecond > 0 || c.DownloadBytesPerSecond > 0
+}
+
+// Validate 校验带宽配置
+func (c BandwidthConfig) Validate() error {
+	if c.UploadBytesPerSecond < 0 || c.DownloadBytesPerSecond < 0 {
+		return fmt.Errorf("bandwidth must not be negative (upload %d, download %d)", c.UploadBytesPerSecond, c.DownloadBytesPerSecond)
+	}
+	return nil
+}
+
+// byteLimiter 按字节限速，由一个连接的同一方向共用
+type byteLimiter struct {
+	rate  int64 // 每秒字节数
+	chunk int   // 每次读写的最大字节数
+
+	mu   sync.Mutex
+	next time.Time // 下一块数据最早可以完成传输的时间
+}
+
+// newByteLimiter 创建限速器，rate 小于等于 0 时返回 nil（不限速）
+func newByteLimiter(rate int64) *byteLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	chunk := int(rate * int64(bandwidthChunkInterval) / int64(time.Second))
+	if chunk < 1 {
+		chunk = 1
+	}
+	return &byteLimiter{rate: rate, chunk: chunk}
+}
+
+// wait 记录传输了 n 个字节，等待到这些字节按速率传输完成的时间
+func (l *byteLimiter) wait(n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	delay := l.next.Sub(now)
+	l.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// throttledConn 限制读写速率的连接
+type throttledConn struct {
+	net.Conn
+	read  *byteLimiter
+	write *byteLimiter
+}
+
+// Read 实现 net.Conn，每次最多读取一块数据并按下行速率等待
+func (c *throttledConn) Read(p []byte) (int, error) {
+	if c.read == nil {
+		return c.Conn.Read(p)
+	}
+	if len(p) > c.read.chunk {
+		p = p[:c.read.chunk]
+	}
+	n, err := c.Conn.Read(p)
+	c.read.wait(n)
+	return n, err
+}
+
+// Write 实现 net.Conn，按块写入并按上行速率等待
+func (c *throttledConn) Write(p []byte) (int, error) {
+	if c.write == nil {
+		return c.Conn.Write(p)
+	}
+	written := 0
+	for written < len(p) {
+		end := written + c.write.chunk
+		if end > len(p) {
+			end = len(p)
+		}
+		n, err := c.Conn.Write(p[written:end])
+		written += n
+		c.write.wait(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// throttleDialer 包装 DialContext，新建的每个连接按配置限速
+func throttleDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), cfg BandwidthConfig) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &throttledConn{
+			Conn:  conn,
+			read:  newByteLimiter(cfg.DownloadBytesPerSecond),
+			write: newByteLimiter(cfg.UploadBytesPerSecond),
+		}, nil
+	}
+}
@@ -0,0 +1,193 @@
+// breaker.go
+// 接口熔断模块
+// 本文件负责 HTTP 任务层按接口的熔断器：下游接口不可用时快速失败，
+// 避免所有 worker 都阻塞在超时的请求上，其他接口的压测也无法进行。
+//
+// 技术实现细节：
+// 1. CircuitBreakerTransport 包装 http.RoundTripper，按接口（scheme://host/path，不含查询参数）分别熔断。
+// 2. 闭合状态下连续失败达到 FailureThreshold 次后断开；断开期间请求直接返回 ErrCircuitOpen，不发送到下游。
+// 3. 断开 OpenTimeout 后进入半开状态，最多同时放行 HalfOpenProbes 个探测请求，
+//    探测全部成功后闭合，任一探测失败重新断开。
+// 4. 默认网络错误和 5xx 响应记为失败，可以通过 BreakerConfig.IsFailure 自定义。
+// 5. 状态变化通过 BreakerConfig.OnStateChange 通知，通常设置为 Collector.RecordBreakerTransition，
+//    写入日志并在报告中生成熔断状态图。
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// 熔断器默认值
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenTimeout      = 10 * time.Second
+	DefaultBreakerHalfOpenProbes   = 1
+)
+
+// ErrCircuitOpen 接口处于熔断状态，请求没有发送
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerConfig 熔断器配置，零值字段使用默认值
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // 连续失败多少次后断开，默认 DefaultBreakerFailureThreshold
+	OpenTimeout      time.Duration `yaml:"open_timeout"`      // 断开后多久进入半开状态，默认 DefaultBreakerOpenTimeout
+	HalfOpenProbes   int           `yaml:"half_open_probes"`  // 半开状态放行的探测请求数，全部成功后闭合，默认 DefaultBreakerHalfOpenProbes
+
+	// IsFailure 判断一次请求是否失败，为 nil 时网络错误和 5xx 响应记为失败
+	IsFailure func(resp *http.Response, err error) bool `yaml:"-"`
+	// OnStateChange 状态变化时调用（在熔断器的锁外），通常设置为 Collector.RecordBreakerTransition
+	OnStateChange func(result.BreakerTransition) `yaml:"-"`
+}
+
+// endpointBreaker 一个接口的熔断状态
+type endpointBreaker struct {
+	state     result.BreakerState
+	failures  int       // 闭合状态下的连续失败次数
+	openedAt  time.Time // 最近一次断开的时间
+	probes    int       // 半开状态已放行的探测请求数
+	successes int       // 半开状态成功的探测请求数
+}
+
+// CircuitBreakerTransport 按接口熔断的 RoundTripper，并发安全
+type CircuitBreakerTransport struct {
+	Base http.RoundTripper
+	cfg  BreakerConfig
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointBreaker
+}
+
+// NewCircuitBreakerTransport 创建按接口熔断的传输层，base 为 nil 时使用 DefaultHTTPClient 的传输层
+func NewCircuitBreakerTransport(base http.RoundTripper, cfg BreakerConfig) *CircuitBreakerTransport {
+	if base == nil {
+		base = DefaultHTTPClient().Transport
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultBreakerFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = DefaultBreakerHalfOpenProbes
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+	return &CircuitBreakerTransport{Base: base, cfg: cfg, endpoints: make(map[string]*endpointBreaker)}
+}
+
+// BreakerEndpoint 返回请求地址对应的熔断接口：scheme://host/path，不含查询参数
+func BreakerEndpoint(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// State 返回接口当前的熔断状态，没有请求过的接口为闭合
+func (t *CircuitBreakerTransport) State(endpoint string) result.BreakerState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b, ok := t.endpoints[endpoint]; ok {
+		return b.state
+	}
+	return result.BreakerClosed
+}
+
+// RoundTrip 实现 http.RoundTripper 接口，接口熔断时返回包装了 ErrCircuitOpen 的错误
+func (t *CircuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := BreakerEndpoint(req.URL)
+	if !t.allow(endpoint) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, endpoint)
+	}
+	resp, err := t.Base.RoundTrip(req)
+	t.report(endpoint, t.cfg.IsFailure(resp, err))
+	return resp, err
+}
+
+// allow 判断是否放行请求，断开超过 OpenTimeout 时进入半开状态
+func (t *CircuitBreakerTransport) allow(endpoint string) bool {
+	var transition *result.BreakerTransition
+	defer func() { t.notify(transition) }()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.endpoints[endpoint]
+	if !ok {
+		b = &endpointBreaker{state: result.BreakerClosed}
+		t.endpoints[endpoint] = b
+	}
+	if b.state == result.BreakerOpen {
+		if time.Since(b.openedAt) < t.cfg.OpenTimeout {
+			return false
+		}
+		b.probes, b.successes = 0, 0
+		transition = t.transition(endpoint, b, result.BreakerHalfOpen, fmt.Sprintf("open for %v", t.cfg.OpenTimeout))
+	}
+	if b.state == result.BreakerHalfOpen {
+		if b.probes >= t.cfg.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// report 记录一次已放行请求的结果
+func (t *CircuitBreakerTransport) report(endpoint string, failed bool) {
+	var transition *result.BreakerTransition
+	defer func() { t.notify(transition) }()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.endpoints[endpoint]
+	switch b.state {
+	case result.BreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= t.cfg.FailureThreshold {
+			b.openedAt = time.Now()
+			transition = t.transition(endpoint, b, result.BreakerOpen, fmt.Sprintf("%d consecutive failures", b.failures))
+		}
+	case result.BreakerHalfOpen:
+		if failed {
+			b.openedAt = time.Now()
+			transition = t.transition(endpoint, b, result.BreakerOpen, "probe request failed")
+			return
+		}
+		b.successes++
+		if b.successes >= t.cfg.HalfOpenProbes {
+			b.failures = 0
+			transition = t.transition(endpoint, b, result.BreakerClosed, fmt.Sprintf("%d probe requests succeeded", b.successes))
+		}
+	}
+	// 断开状态下完成的请求是断开之前放行的，不影响状态
+}
+
+// transition 切换状态并返回状态变化记录，调用方持有 t.mu
+func (t *CircuitBreakerTransport) transition(endpoint string, b *endpointBreaker, to result.BreakerState, reason string) *result.BreakerTransition {
+	from := b.state
+	b.state = to
+	return &result.BreakerTransition{Time: time.Now(), Endpoint: endpoint, From: from, To: to, Reason: reason}
+}
+
+// notify 在锁外通知状态变化
+func (t *CircuitBreakerTransport) notify(transition *result.BreakerTransition) {
+	if transition != nil && t.cfg.OnStateChange != nil {
+		t.cfg.OnStateChange(*transition)
+	}
+}
@@ -0,0 +1,253 @@
+// correlation.go
+// 请求关联模块
+// 本文件负责多步业务事务中请求之间的关联：从上一个响应中提取值（正则、JSON 路径、响应头）
+// 保存为场景变量，后续请求通过 ${name} 引用，例如先创建订单再用订单号查询和支付。
+//
+// 技术实现细节：
+// 1. Variables 保存一个虚拟用户的场景变量，并发安全；不同 VU 各自持有一份，互不干扰。
+// 2. 提取失败返回 ExtractionError，包含变量名、提取方式、表达式、失败原因和响应片段，
+//    多个提取规则失败时合并返回，便于一次看清所有问题；Optional 的规则失败时使用默认值。
+// 3. JSON 路径兼容 "$." 前缀，提取到的数字按原样格式化，对象和数组保存为 JSON 文本。
+// 4. 引用未定义的变量时 Expand 返回错误，而不是把 "${name}" 原样发送出去。
+
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// 提取方式
+const (
+	ExtractRegex    = "regex"    // 对响应体执行正则表达式
+	ExtractJSONPath = "jsonpath" // 按 JSON 路径取值
+	ExtractHeader   = "header"   // 读取响应头
+)
+
+// maxErrorSnippet 提取错误中包含的响应体片段最大长度
+const maxErrorSnippet = 200
+
+// Extractor 一条提取规则
+type Extractor struct {
+	Variable   string // 保存到的变量名
+	Type       string // 提取方式：ExtractRegex、ExtractJSONPath 或 ExtractHeader
+	Expression string // 正则表达式、JSON 路径或响应头名称
+	Group      int    // 正则取第几个捕获组，0 表示有捕获组时取第一个，否则取整个匹配
+	Optional   bool   // 提取失败时使用 Default，而不是返回错误
+	Default    string // Optional 时提取失败使用的默认值
+}
+
+// ExtractionError 提取失败的详细信息
+type ExtractionError struct {
+	Variable   string // 变量名
+	Type       string // 提取方式
+	Expression string // 表达式
+	StatusCode int    // 响应状态码
+	Reason     string // 失败原因
+	Snippet    string // 响应体片段
+}
+
+// Error 实现 error 接口
+func (e *ExtractionError) Error() string {
+	msg := fmt.Sprintf("extract %s (%s %s) from response with status %d: %s", e.Variable, e.Type, e.Expression, e.StatusCode, e.Reason)
+	if e.Snippet != "" {
+		msg += fmt.Sprintf("; response body: %q", e.Snippet)
+	}
+	return msg
+}
+
+// Variables 一个虚拟用户的场景变量，并发安全
+type Variables struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewVariables 创建场景变量，initial 为初始值（可为 nil）
+func NewVariables(initial map[string]string) *Variables {
+	v := &Variables{values: make(map[string]string, len(initial))}
+	for name, value := range initial {
+		v.values[name] = value
+	}
+	return v
+}
+
+// Set 设置变量
+func (v *Variables) Set(name, value string) {
+	v.mu.Lock()
+	v.values[name] = value
+	v.mu.Unlock()
+}
+
+// Get 获取变量
+func (v *Variables) Get(name string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	value, ok := v.values[name]
+	return value, ok
+}
+
+// snapshot 返回所有变量的副本
+func (v *Variables) snapshot() map[string]string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	values := make(map[string]string, len(v.values))
+	for name, value := range v.values {
+		values[name] = value
+	}
+	return values
+}
+
+// variableRef 变量引用 ${name}
+var variableRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// Expand 将 s 中的 ${name} 替换为变量值，引用了未定义的变量时返回错误
+func (v *Variables) Expand(s string) (string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	var missing []string
+	expanded := variableRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		value, ok := v.values[name]
+		if !ok {
+			missing = append(missing, name)
+			return ref
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variables: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// NewRequest 展开 URL、请求体和请求头中的变量后创建请求
+func (v *Variables) NewRequest(ctx context.Context, method, rawURL, body string, header map[string]string) (*http.Request, error) {
+	expandedURL, err := v.Expand(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("url: %v", err)
+	}
+	expandedBody, err := v.Expand(body)
+	if err != nil {
+		return nil, fmt.Errorf("body: %v", err)
+	}
+	var reader io.Reader
+	if expandedBody != "" {
+		reader = strings.NewReader(expandedBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, expandedURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range header {
+		expanded, err := v.Expand(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %v", name, err)
+		}
+		req.Header.Set(name, expanded)
+	}
+	return req, nil
+}
+
+// Extract 按提取规则从响应中提取值并保存为变量。body 为已读取的响应体（响应体只能读取一次）。
+// 全部规则都会执行，失败的规则合并为一个错误返回，每个错误都是 *ExtractionError
+func (v *Variables) Extract(resp *http.Response, body []byte, extractors ...Extractor) error {
+	var errs []error
+	for _, ex := range extractors {
+		value, reason := extractValue(resp, body, ex)
+		if reason == "" {
+			v.Set(ex.Variable, value)
+			continue
+		}
+		if ex.Optional {
+			v.Set(ex.Variable, ex.Default)
+			continue
+		}
+		extractErr := &ExtractionError{
+			Variable:   ex.Variable,
+			Type:       ex.Type,
+			Expression: ex.Expression,
+			Reason:     reason,
+		}
+		if resp != nil {
+			extractErr.StatusCode = resp.StatusCode
+		}
+		if ex.Type != ExtractHeader {
+			extractErr.Snippet = snippet(body)
+		}
+		errs = append(errs, extractErr)
+	}
+	return errors.Join(errs...)
+}
+
+// extractValue 执行一条提取规则，失败时返回原因
+func extractValue(resp *http.Response, body []byte, ex Extractor) (string, string) {
+	switch ex.Type {
+	case ExtractRegex:
+		regex, err := regexp.Compile(ex.Expression)
+		if err != nil {
+			return "", fmt.Sprintf("invalid regex: %v", err)
+		}
+		match := regex.FindSubmatch(body)
+		if match == nil {
+			return "", "no match"
+		}
+		group := ex.Group
+		if group == 0 && regex.NumSubexp() > 0 {
+			group = 1
+		}
+		if group >= len(match) {
+			return "", fmt.Sprintf("regex has no capture group %d", group)
+		}
+		return string(match[group]), ""
+	case ExtractJSONPath:
+		value, err := result.ExtractJSONPath(body, trimJSONPath(ex.Expression))
+		if err != nil {
+			return "", err.Error()
+		}
+		return formatJSONValue(value), ""
+	case ExtractHeader:
+		if resp == nil {
+			return "", "no response"
+		}
+		values := resp.Header.Values(ex.Expression)
+		if len(values) == 0 {
+			return "", "header not found"
+		}
+		return values[0], ""
+	default:
+		return "", fmt.Sprintf("unknown extractor type %q", ex.Type)
+	}
+}
+
+// formatJSONValue 将 JSON 值格式化为变量值：字符串原样保存，数字不使用科学计数法，对象和数组保存为 JSON 文本
+func formatJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// snippet 截取响应体开头用于错误信息
+func snippet(body []byte) string {
+	if len(body) > maxErrorSnippet {
+		return string(body[:maxErrorSnippet]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,202 @@
+// database.go
+// 数据库压测任务模块
+// 本文件负责直接压测后端数据库：通过 database/sql 使用共享连接池执行参数化 SQL，
+// 把查询耗时、返回（或影响）的行数和错误写入收集器。
+//
+// 技术实现细节：
+// 1. 本模块不引入具体驱动，使用方以空导入注册驱动，例如
+//    _ "github.com/go-sql-driver/mysql" 或 _ "github.com/lib/pq"，Driver 填写驱动注册的名称。
+// 2. 一个 DBTask 持有一个 *sql.DB，所有任务共用它的连接池，连接数上限等参数来自 DBConfig。
+// 3. SQL 使用驱动自己的占位符（MySQL 为 ?，Postgres 为 $1），参数中的字符串支持 ${name} 场景变量，
+//    SQL 文本本身不展开变量，避免拼接 SQL。
+// 4. 查询读取完所有行并计数，执行语句（Exec）记录影响的行数；结果的 URL 为 "驱动://查询名"，
+//    不包含 DSN，避免密码写入报告。
+
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// DefaultQueryTimeout 单条 SQL 的默认超时
+const DefaultQueryTimeout = 10 * time.Second
+
+// DBQuery 一条参数化 SQL
+type DBQuery struct {
+	Name string        `yaml:"name"` // 报告中的名称，为空时使用 SQL 文本
+	SQL  string        `yaml:"sql"`  // SQL 文本，使用驱动的占位符
+	Args []interface{} `yaml:"args"` // 参数，字符串参数支持 ${name} 场景变量
+	Exec bool          `yaml:"exec"` // true 时作为执行语句（INSERT/UPDATE/DELETE），记录影响的行数
+}
+
+// DBConfig 数据库压测配置
+type DBConfig struct {
+	Driver          string        `yaml:"driver"`             // 已注册的驱动名称，例如 mysql、postgres
+	DSN             string        `yaml:"dsn"`                // 数据源名称，格式由驱动决定
+	MaxOpenConns    int           `yaml:"max_open_conns"`     // 最大连接数，0 表示不限制
+	MaxIdleConns    int           `yaml:"max_idle_conns"`     // 最大空闲连接数，0 表示使用 database/sql 的默认值
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`  // 连接最长存活时间，0 表示不限制
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"` // 连接最长空闲时间，0 表示不限制
+	QueryTimeout    time.Duration `yaml:"query_timeout"`      // 单条 SQL 的超时，默认 DefaultQueryTimeout
+	Queries         []DBQuery     `yaml:"queries"`            // Run 依次执行的 SQL
+}
+
+// DBTask 数据库压测任务，并发安全，应在任务之间共用以共享连接池
+type DBTask struct {
+	Collector *result.Collector // 结果收集器，为 nil 时不记录结果
+	DB        *sql.DB           // 共享的连接池
+
+	cfg DBConfig
+}
+
+// NewDBTask 校验配置、打开连接池并创建数据库压测任务，不会立即建立连接（见 Ping）
+func NewDBTask(cfg DBConfig) (*DBTask, error) {
+	if cfg.Driver == "" {
+		return nil, fmt.Errorf("sql driver is required")
+	}
+	if !slices.Contains(sql.Drivers(), cfg.Driver) {
+		return nil, fmt.Errorf("sql driver %q is not registered, import it with a blank import (registered: %s)", cfg.Driver, strings.Join(sql.Drivers(), ", "))
+	}
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("dsn is required")
+	}
+	for i, q := range cfg.Queries {
+		if strings.TrimSpace(q.SQL) == "" {
+			return nil, fmt.Errorf("query %d has no sql", i)
+		}
+	}
+	if cfg.QueryTimeout <= 0 {
+		cfg.QueryTimeout = DefaultQueryTimeout
+	}
+
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return &DBTask{DB: db, cfg: cfg}, nil
+}
+
+// Ping 建立连接并检查数据库是否可用，建议在压测开始前调用
+func (d *DBTask) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.QueryTimeout)
+	defer cancel()
+	if err := d.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	return nil
+}
+
+// Run 依次执行配置的 SQL，遇到错误时停止；vars 为 nil 时参数中的变量引用视为未定义
+func (d *DBTask) Run(ctx context.Context, vars *Variables, threadID int) error {
+	for _, q := range d.cfg.Queries {
+		if _, err := d.Execute(ctx, q, vars, threadID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Execute 执行一条 SQL 并把结果写入收集器
+func (d *DBTask) Execute(ctx context.Context, q DBQuery, vars *Variables, threadID int) (result.ResultData, error) {
+	name := q.Name
+	if name == "" {
+		name = strings.Join(strings.Fields(q.SQL), " ")
+	}
+	data := result.ResultData{
+		ID:       name,
+		Method:   "QUERY",
+		URL:      d.cfg.Driver + "://" + name,
+		ThreadID: threadID,
+	}
+	if q.Exec {
+		data.Method = "EXEC"
+	}
+
+	args, err := expandArgs(q.Args, vars)
+	data.StartTime = time.Now()
+	if err == nil {
+		data.Rows, err = d.query(ctx, q, args)
+	}
+	data.EndTime = time.Now()
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.ResponseMsg = fmt.Sprintf("%d rows", data.Rows)
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		err = fmt.Errorf("%s: %v", name, err)
+		data.ErrorMessage = err.Error()
+	}
+	if d.Collector != nil {
+		if data.Type == result.Success {
+			d.Collector.SaveSuccessResult(data)
+		} else {
+			d.Collector.SaveFailureResult(data)
+		}
+	}
+	return data, err
+}
+
+// query 执行 SQL，返回读取或影响的行数
+func (d *DBTask) query(ctx context.Context, q DBQuery, args []interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.QueryTimeout)
+	defer cancel()
+	if q.Exec {
+		res, err := d.DB.ExecContext(ctx, q.SQL, args...)
+		if err != nil {
+			return 0, err
+		}
+		// 部分驱动不支持影响行数，此时记为 0
+		affected, _ := res.RowsAffected()
+		return affected, nil
+	}
+
+	rows, err := d.DB.QueryContext(ctx, q.SQL, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var count int64
+	for rows.Next() {
+		count++
+	}
+	return count, rows.Err()
+}
+
+// expandArgs 展开字符串参数中的场景变量
+func expandArgs(args []interface{}, vars *Variables) ([]interface{}, error) {
+	if vars == nil {
+		vars = NewVariables(nil)
+	}
+	expanded := make([]interface{}, len(args))
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			expanded[i] = arg
+			continue
+		}
+		value, err := vars.Expand(s)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d: %v", i, err)
+		}
+		expanded[i] = value
+	}
+	return expanded, nil
+}
+
+// Close 关闭连接池
+func (d *DBTask) Close() error {
+	return d.DB.Close()
+}
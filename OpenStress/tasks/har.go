@@ -0,0 +1,223 @@
+// har.go
+// HAR 导入模块
+// 本文件负责把浏览器导出的 HAR 文件（HTTP Archive 1.2）转换为 HTTP 测试计划（见 plan.go），
+// 录制一次业务操作后即可回放压测，再按需把固定值改为场景变量。
+//
+// 技术实现细节：
+// 1. 请求按开始时间排序；思考时间为上一个请求结束到本请求开始的间隔，并行请求的间隔为 0，
+//    超过 MaxThinkTime 的间隔按 MaxThinkTime 截断（录制时的长时间停顿通常不是用户思考）。
+// 2. 默认跳过静态资源（图片、样式、脚本、字体、媒体），按浏览器记录的资源类型或 URL 扩展名判断；
+//    可以只保留指定主机的请求，排除第三方统计等请求。
+// 3. 不回放由客户端自动生成的请求头（Host、Content-Length、Connection、Accept-Encoding、HTTP/2 伪头等）；
+//    Cookie 与录制时的会话绑定，默认不导入。
+// 4. 请求体优先使用 postData.text，只有表单参数时按 application/x-www-form-urlencoded 编码；
+//    录制内容中的 "{{" 会被转义，回放时按原文发送。
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultHARMaxThinkTime 导入 HAR 时思考时间的默认上限
+const DefaultHARMaxThinkTime = 10 * time.Second
+
+// harSkippedHeaders 导入时丢弃的请求头（小写），由客户端在发送时自动生成
+var harSkippedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"keep-alive":        true,
+	"accept-encoding":   true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"te":                true,
+}
+
+// harStaticTypes 浏览器记录的静态资源类型（Chrome 的 _resourceType）
+var harStaticTypes = map[string]bool{
+	"image": true, "stylesheet": true, "script": true, "font": true, "media": true,
+}
+
+// harStaticExtensions 静态资源的 URL 扩展名
+var harStaticExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true,
+	".css": true, ".js": true, ".map": true, ".woff": true, ".woff2": true, ".ttf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true,
+}
+
+// HAROptions HAR 导入选项
+type HAROptions struct {
+	Name          string        // 测试计划名称，为空时使用 HAR 文件名或 "har"
+	Hosts         []string      // 只导入这些主机的请求，为空时导入全部主机
+	IncludeStatic bool          // 是否导入静态资源请求
+	KeepCookies   bool          // 是否导入 Cookie 请求头
+	MaxThinkTime  time.Duration // 思考时间上限，0 使用 DefaultHARMaxThinkTime，小于 0 表示不导入思考时间
+}
+
+// harFile HAR 文件中用到的字段
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// harEntry 一个请求记录
+type harEntry struct {
+	StartedDateTime time.Time  `json:"startedDateTime"`
+	Time            float64    `json:"time"` // 请求总耗时（毫秒）
+	Request         harRequest `json:"request"`
+	ResourceType    string     `json:"_resourceType"`
+}
+
+// harRequest 请求内容
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	PostData *struct {
+		MimeType string         `json:"mimeType"`
+		Text     string         `json:"text"`
+		Params   []harNameValue `json:"params"`
+	} `json:"postData"`
+}
+
+// harNameValue 请求头或表单参数
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ImportHARFile 读取 HAR 文件并转换为测试计划
+func ImportHARFile(path string, opts HAROptions) (*TestPlan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HAR file: %v", err)
+	}
+	defer f.Close()
+	if opts.Name == "" {
+		opts.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	plan, err := ImportHAR(f, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan.Source = filepath.Base(path)
+	return plan, nil
+}
+
+// ImportHAR 将 HAR 内容转换为测试计划，过滤后没有请求时返回错误
+func ImportHAR(r io.Reader, opts HAROptions) (*TestPlan, error) {
+	var har harFile
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %v", err)
+	}
+	if opts.MaxThinkTime == 0 {
+		opts.MaxThinkTime = DefaultHARMaxThinkTime
+	}
+	if opts.Name == "" {
+		opts.Name = "har"
+	}
+
+	entries := make([]harEntry, 0, len(har.Log.Entries))
+	for _, entry := range har.Log.Entries {
+		if harIncluded(entry, opts) {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no requests to import from HAR file")
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	plan := &TestPlan{Name: opts.Name, Requests: make([]PlanRequest, 0, len(entries))}
+	var lastEnd time.Time
+	for i, entry := range entries {
+		request := PlanRequest{
+			Method:  strings.ToUpper(entry.Request.Method),
+			URL:     escapeTemplate(entry.Request.URL),
+			Headers: harHeaders(entry.Request.Headers, opts.KeepCookies),
+			Body:    escapeTemplate(harBody(entry.Request)),
+		}
+		if i > 0 && opts.MaxThinkTime > 0 {
+			if gap := entry.StartedDateTime.Sub(lastEnd); gap > 0 {
+				request.ThinkTime = min(gap, opts.MaxThinkTime).Round(time.Millisecond)
+			}
+		}
+		if end := entry.StartedDateTime.Add(time.Duration(entry.Time * float64(time.Millisecond))); end.After(lastEnd) {
+			lastEnd = end
+		}
+		plan.Requests = append(plan.Requests, request)
+	}
+	return plan, nil
+}
+
+// harIncluded 判断请求是否需要导入
+func harIncluded(entry harEntry, opts HAROptions) bool {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	if len(opts.Hosts) > 0 {
+		matched := false
+		for _, host := range opts.Hosts {
+			if strings.EqualFold(u.Hostname(), host) || strings.EqualFold(u.Host, host) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if !opts.IncludeStatic && (harStaticTypes[entry.ResourceType] || harStaticExtensions[strings.ToLower(path.Ext(u.Path))]) {
+		return false
+	}
+	return true
+}
+
+// harHeaders 转换请求头，丢弃客户端自动生成的请求头，同名请求头以逗号合并
+func harHeaders(headers []harNameValue, keepCookies bool) map[string]string {
+	result := make(map[string]string, len(headers))
+	for _, h := range headers {
+		name := strings.ToLower(h.Name)
+		if strings.HasPrefix(name, ":") || harSkippedHeaders[name] || (name == "cookie" && !keepCookies) {
+			continue
+		}
+		if existing, ok := result[h.Name]; ok {
+			result[h.Name] = existing + ", " + escapeTemplate(h.Value)
+			continue
+		}
+		result[h.Name] = escapeTemplate(h.Value)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// harBody 返回请求体，只有表单参数时按 URL 编码拼接
+func harBody(request harRequest) string {
+	if request.PostData == nil {
+		return ""
+	}
+	if request.PostData.Text != "" || len(request.PostData.Params) == 0 {
+		return request.PostData.Text
+	}
+	form := url.Values{}
+	for _, p := range request.PostData.Params {
+		form.Add(p.Name, p.Value)
+	}
+	return form.Encode()
+}
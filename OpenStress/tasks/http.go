@@ -1,11 +1,126 @@
 package tasks
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
 
 // Task 任务结构体
 type Task struct {
 	ID      string
-	Execute func() // 任务执行函数
+	Execute func()       // 任务执行函数
+	Client  *http.Client // 发送压测请求的 HTTP 客户端（按场景配置签名等），为空时使用 DefaultHTTPClient
+}
+
+// HTTPClient 返回任务发送请求使用的 HTTP 客户端
+func (t *Task) HTTPClient() *http.Client {
+	if t.Client == nil {
+		return DefaultHTTPClient()
+	}
+	return t.Client
+}
+
+// HTTPClientConfig 场景 HTTP 客户端配置
+type HTTPClientConfig struct {
+	Timeout         time.Duration `yaml:"timeout"`           // 请求超时时间，0 表示不超时
+	Signer          *SignerConfig `yaml:"signer"`            // 请求签名配置，为空时不签名
+	SecretsFile     string        `yaml:"secrets_file"`      // 签名凭证所在的 YAML 密钥文件，为空时从环境变量读取
+	SecretEnvPrefix string        `yaml:"secret_env_prefix"` // 从环境变量读取凭证时的前缀，默认 OPENSTRESS_SECRET_
+
+	Transport TransportConfig `yaml:"transport"` // 传输层配置（连接池、keep-alive、HTTP/2、TLS、代理）
+	Breaker   *BreakerConfig  `yaml:"breaker"`   // 按接口熔断的配置，为空时不熔断
+}
+
+// LoadHTTPClientConfig 从 YAML 文件加载场景 HTTP 客户端配置
+func LoadHTTPClientConfig(path string) (HTTPClientConfig, error) {
+	var cfg HTTPClientConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read http client config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse http client config: %v", err)
+	}
+	return cfg, nil
+}
+
+// NewHTTPClient 按配置创建 HTTP 客户端，配置了签名时每个请求在发送前自动签名，
+// 配置了熔断时接口熔断期间的请求直接失败（不签名、不发送）。
+// 客户端应在任务之间共用，连接和熔断状态才能在任务之间共享
+func NewHTTPClient(cfg HTTPClientConfig) (*http.Client, error) {
+	transport, err := NewTransport(cfg.Transport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http transport: %v", err)
+	}
+	client := &http.Client{Timeout: cfg.Timeout, Transport: transport}
+	if cfg.Signer != nil {
+		if client.Transport, err = newSigningRoundTripper(cfg, transport); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Breaker != nil {
+		client.Transport = NewCircuitBreakerTransport(client.Transport, *cfg.Breaker)
+	}
+	return client, nil
+}
+
+// newSigningRoundTripper 按配置读取签名凭证，返回在发送前签名的传输层
+func newSigningRoundTripper(cfg HTTPClientConfig, transport http.RoundTripper) (http.RoundTripper, error) {
+
+	var store SecretStore
+	if cfg.SecretsFile != "" {
+		fileStore, err := NewFileSecretStore(cfg.SecretsFile)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+	} else {
+		prefix := cfg.SecretEnvPrefix
+		if prefix == "" {
+			prefix = "OPENSTRESS_SECRET_"
+		}
+		store = &EnvSecretStore{Prefix: prefix}
+	}
+
+	signer, err := NewSignerFromConfig(*cfg.Signer, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request signer: %v", err)
+	}
+	return NewSigningTransport(transport, signer), nil
+}
+
+// MaxCapturedBody ReadBody 最多保留的响应体字节数，收集器再按 CollectorConfig.FailureBodyBytes 截断
+const MaxCapturedBody = 64 << 10
+
+// ReadBody 读取并关闭响应体，返回响应体的前 MaxCapturedBody 个字节和读取的总字节数，
+// 请求失败时可以把返回的内容写入 ResultData.ResponseBody
+func ReadBody(resp *http.Response) ([]byte, int64, error) {
+	defer resp.Body.Close()
+	w := &headWriter{limit: MaxCapturedBody}
+	n, err := io.Copy(w, resp.Body)
+	return w.buf, n, err
+}
+
+// headWriter 保留写入内容的前 limit 个字节，其余丢弃
+type headWriter struct {
+	buf   []byte
+	limit int
+}
+
+// Write 实现 io.Writer
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.buf = append(w.buf, p[:room]...)
+	}
+	return len(p), nil
 }
 
 // Task_HTTP 任务示例
@@ -0,0 +1,495 @@
+// jmx.go
+// JMeter 脚本导入模块
+// 本文件负责把 JMeter 的 .jmx 脚本转换为 HTTP 测试计划（见 plan.go），便于从 JMeter 迁移已有的压测脚本。
+// 只支持常用的子集：线程组、HTTP 请求、HTTP 请求默认值、HTTP 信息头管理器、CSV 数据文件设置、
+// 用户定义的变量和固定定时器；其他元件跳过并给出提示。
+//
+// 技术实现细节：
+// 1. .jmx 文件中每个元件后面紧跟一个 hashTree 保存它的子元件，按这一结构递归遍历；禁用的元件和监听器直接跳过。
+// 2. 每个线程组转换为一个测试计划：线程数、Ramp-Up、循环次数和调度器时长转换为建议负载（PlanLoad），
+//    ${__P(name,default)} 形式的属性引用使用默认值。
+// 3. 配置元件（默认值、信息头、定时器、CSV 数据文件）作用于同一层级及下层的请求，与 JMeter 的作用域一致；
+//    作用域内的多个固定定时器累加为请求的思考时间。逻辑控制器（事务控制器等）按顺序展开其中的请求。
+// 4. JMeter 的 ${name} 变量引用与场景变量语法一致，原样保留；${__Random(...)} 等函数不支持，导入时给出提示。
+//    请求参数按请求方法拼接到查询字符串或编码为表单请求体，编码时保留变量引用。
+
+package tasks
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jmxNode .jmx 文件中的一个 XML 元素
+type jmxNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []jmxNode  `xml:",any"`
+}
+
+// attr 返回属性值
+func (n *jmxNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// prop 返回名称为 name 的直接子属性（stringProp、boolProp、elementProp 等）
+func (n *jmxNode) prop(name string) *jmxNode {
+	for i := range n.Nodes {
+		if n.Nodes[i].attr("name") == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// str 返回属性的文本，不存在时返回空字符串
+func (n *jmxNode) str(name string) string {
+	if p := n.prop(name); p != nil {
+		return p.Text
+	}
+	return ""
+}
+
+// isTrue 判断布尔属性是否为 true
+func (n *jmxNode) isTrue(name string) bool {
+	return strings.TrimSpace(n.str(name)) == "true"
+}
+
+// collection 返回 elementProp 中 collectionProp 的元素，例如请求参数和信息头
+func (n *jmxNode) collection(name string) []jmxNode {
+	p := n.prop(name)
+	if p == nil {
+		return nil
+	}
+	if p.XMLName.Local == "elementProp" {
+		for i := range p.Nodes {
+			if p.Nodes[i].XMLName.Local == "collectionProp" {
+				return p.Nodes[i].Nodes
+			}
+		}
+		return nil
+	}
+	return p.Nodes
+}
+
+// enabled 判断元件是否启用
+func (n *jmxNode) enabled() bool {
+	return n.attr("enabled") != "false"
+}
+
+// title 返回元件在提示信息中的名称
+func (n *jmxNode) title() string {
+	return fmt.Sprintf("%q (%s)", n.attr("testname"), n.XMLName.Local)
+}
+
+// jmxScope 配置元件的作用域
+type jmxScope struct {
+	defaults  map[string]string // HTTP 请求默认值，键为属性名（HTTPSampler.domain 等）
+	headers   map[string]string // 信息头
+	thinkTime time.Duration     // 固定定时器的延迟之和
+}
+
+// jmxIgnored 不影响请求的元件（监听器等），导入时静默跳过
+var jmxIgnored = map[string]bool{
+	"ResultCollector": true, "BackendListener": true, "Summariser": true, "TestPlan": true,
+}
+
+// jmxControllers 按顺序展开子元件的逻辑控制器，其余控制器展开时给出提示
+var jmxControllers = map[string]bool{
+	"GenericController": true, "TransactionController": true,
+}
+
+// jmxProperty ${__P(name,default)} 或 ${__property(name,,default)} 形式的属性引用
+var jmxProperty = regexp.MustCompile(`^\$\{__(?:P|property)\(([^,)]*)((?:,[^,)]*)*)\)\}$`)
+
+// jmxImporter 一次导入的状态
+type jmxImporter struct {
+	plans    []*TestPlan
+	warnings []string
+	vars     map[string]string
+	data     []PlanDataSet // 线程组之外的 CSV 数据文件，所有线程组共用
+}
+
+// ImportJMXFile 读取 JMeter 脚本，每个线程组转换为一个测试计划，同时返回转换时跳过的内容
+func ImportJMXFile(path string) ([]*TestPlan, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open JMX file: %v", err)
+	}
+	defer f.Close()
+	plans, warnings, err := ImportJMX(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, plan := range plans {
+		plan.Source = filepath.Base(path)
+	}
+	return plans, warnings, nil
+}
+
+// ImportJMX 将 JMeter 脚本转换为测试计划，没有可导入的线程组时返回错误
+func ImportJMX(r io.Reader) ([]*TestPlan, []string, error) {
+	var root jmxNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JMX file: %v", err)
+	}
+	if root.XMLName.Local != "jmeterTestPlan" || len(root.Nodes) == 0 || root.Nodes[0].XMLName.Local != "hashTree" {
+		return nil, nil, fmt.Errorf("not a JMeter test plan")
+	}
+
+	im := &jmxImporter{vars: make(map[string]string)}
+	tree := root.Nodes[0].Nodes
+	for i := 0; i < len(tree); i++ {
+		if tree[i].XMLName.Local != "TestPlan" {
+			continue
+		}
+		for _, arg := range tree[i].collection("TestPlan.user_defined_variables") {
+			im.vars[arg.str("Argument.name")] = arg.str("Argument.value")
+		}
+		if children := jmxChildren(tree, i); children != nil {
+			im.walk(children, jmxScope{}, nil)
+		}
+	}
+	if len(im.plans) == 0 {
+		return nil, im.warnings, fmt.Errorf("no thread groups with HTTP requests to import")
+	}
+	for _, plan := range im.plans {
+		if len(im.vars) > 0 {
+			plan.Variables = im.vars
+		}
+		plan.Data = append(append([]PlanDataSet(nil), im.data...), plan.Data...)
+	}
+	return im.plans, im.warnings, nil
+}
+
+// jmxChildren 返回第 i 个元件的子元件（紧跟其后的 hashTree）
+func jmxChildren(tree []jmxNode, i int) []jmxNode {
+	if i+1 < len(tree) && tree[i+1].XMLName.Local == "hashTree" {
+		return tree[i+1].Nodes
+	}
+	return nil
+}
+
+// warn 记录转换时跳过的内容
+func (im *jmxImporter) warn(format string, args ...interface{}) {
+	im.warnings = append(im.warnings, fmt.Sprintf(format, args...))
+}
+
+// walk 处理同一层级的元件：先应用配置元件，再按顺序处理线程组、请求和控制器；plan 为 nil 表示线程组之外
+func (im *jmxImporter) walk(tree []jmxNode, parent jmxScope, plan *TestPlan) {
+	scope := im.configure(tree, parent, plan)
+	for i := 0; i < len(tree); i++ {
+		node := &tree[i]
+		name := node.XMLName.Local
+		if name == "hashTree" || !node.enabled() || jmxIgnored[name] || jmxConfigElement(name) {
+			continue
+		}
+		children := jmxChildren(tree, i)
+		switch {
+		case name == "ThreadGroup":
+			if plan != nil {
+				im.warn("skipped nested thread group %s", node.title())
+				continue
+			}
+			im.threadGroup(node, children, scope)
+		case name == "HTTPSamplerProxy":
+			if plan == nil {
+				im.warn("skipped HTTP request %s outside a thread group", node.title())
+				continue
+			}
+			plan.Requests = append(plan.Requests, im.sampler(node, im.configure(children, scope, plan)))
+			for j := range children {
+				child := &children[j]
+				if child.XMLName.Local != "hashTree" && child.enabled() && !jmxConfigElement(child.XMLName.Local) {
+					im.warn("skipped unsupported element %s of HTTP request %s", child.title(), node.title())
+				}
+			}
+		case plan != nil && strings.HasSuffix(name, "Controller"):
+			if !jmxControllers[name] {
+				im.warn("imported the requests of controller %s unconditionally", node.title())
+			}
+			im.walk(children, scope, plan)
+		default:
+			im.warn("skipped unsupported element %s", node.title())
+		}
+	}
+}
+
+// jmxConfigElement 判断是否为 configure 处理的配置元件
+func jmxConfigElement(name string) bool {
+	switch name {
+	case "ConfigTestElement", "HeaderManager", "ConstantTimer", "CSVDataSet", "Arguments":
+		return true
+	}
+	return false
+}
+
+// configure 应用同一层级的配置元件，返回子元件使用的作用域
+func (im *jmxImporter) configure(tree []jmxNode, parent jmxScope, plan *TestPlan) jmxScope {
+	scope := jmxScope{defaults: parent.defaults, headers: parent.headers, thinkTime: parent.thinkTime}
+	for i := range tree {
+		node := &tree[i]
+		if !node.enabled() {
+			continue
+		}
+		switch node.XMLName.Local {
+		case "ConfigTestElement":
+			defaults := make(map[string]string, len(scope.defaults)+len(node.Nodes))
+			for k, v := range scope.defaults {
+				defaults[k] = v
+			}
+			for _, p := range node.Nodes {
+				if name := p.attr("name"); strings.HasPrefix(name, "HTTPSampler.") && strings.TrimSpace(p.Text) != "" {
+					defaults[name] = p.Text
+				}
+			}
+			scope.defaults = defaults
+		case "HeaderManager":
+			headers := make(map[string]string, len(scope.headers))
+			for k, v := range scope.headers {
+				headers[k] = v
+			}
+			for _, h := range node.collection("HeaderManager.headers") {
+				if name := h.str("Header.name"); name != "" {
+					headers[name] = h.str("Header.value")
+				}
+			}
+			scope.headers = headers
+		case "ConstantTimer":
+			delay, err := jmxInt(node.str("ConstantTimer.delay"))
+			if err != nil || delay < 0 {
+				im.warn("skipped timer %s: invalid delay %q", node.title(), node.str("ConstantTimer.delay"))
+				continue
+			}
+			scope.thinkTime += time.Duration(delay) * time.Millisecond
+		case "CSVDataSet":
+			data := im.dataSet(node)
+			if plan == nil {
+				im.data = append(im.data, data)
+			} else {
+				plan.Data = append(plan.Data, data)
+			}
+		case "Arguments":
+			for _, arg := range node.collection("Arguments.arguments") {
+				im.vars[arg.str("Argument.name")] = arg.str("Argument.value")
+			}
+		}
+	}
+	return scope
+}
+
+// dataSet 转换 CSV 数据文件设置
+func (im *jmxImporter) dataSet(node *jmxNode) PlanDataSet {
+	data := PlanDataSet{
+		File:            strings.TrimSpace(node.str("filename")),
+		IgnoreFirstLine: node.isTrue("ignoreFirstLine"),
+		StopAtEOF:       node.prop("recycle") != nil && !node.isTrue("recycle"),
+	}
+	if delimiter := node.str("delimiter"); delimiter != "" && delimiter != "," {
+		data.Delimiter = delimiter
+	}
+	for _, name := range strings.Split(node.str("variableNames"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			data.Variables = append(data.Variables, name)
+		}
+	}
+	if mode := node.str("shareMode"); mode != "" && mode != "shareMode.all" {
+		im.warn("data set %s is shared by all virtual users (share mode %s is not supported)", node.title(), mode)
+	}
+	return data
+}
+
+// threadGroup 将线程组转换为测试计划
+func (im *jmxImporter) threadGroup(node *jmxNode, children []jmxNode, scope jmxScope) {
+	plan := &TestPlan{Name: node.attr("testname"), Load: &PlanLoad{VUs: 1}}
+	if plan.Name == "" {
+		plan.Name = fmt.Sprintf("thread-group-%d", len(im.plans)+1)
+	}
+	if vus, ok := im.groupInt(node, "ThreadGroup.num_threads"); ok && vus > 0 {
+		plan.Load.VUs = vus
+	}
+	if rampUp, ok := im.groupInt(node, "ThreadGroup.ramp_time"); ok {
+		plan.Load.RampUp = time.Duration(rampUp) * time.Second
+	}
+	if controller := node.prop("ThreadGroup.main_controller"); controller != nil && !controller.isTrue("LoopController.continue_forever") {
+		if loops, ok := im.groupInt(controller, "LoopController.loops"); ok && loops > 0 {
+			plan.Load.IterationsPerVU = loops
+		}
+	}
+	if node.isTrue("ThreadGroup.scheduler") {
+		if duration, ok := im.groupInt(node, "ThreadGroup.duration"); ok {
+			plan.Load.Duration = time.Duration(duration) * time.Second
+		}
+	}
+
+	im.walk(children, scope, plan)
+	if len(plan.Requests) == 0 {
+		im.warn("skipped thread group %s without HTTP requests", node.title())
+		return
+	}
+	im.plans = append(im.plans, plan)
+}
+
+// groupInt 读取线程组的整数属性，无法解析时给出提示
+func (im *jmxImporter) groupInt(node *jmxNode, name string) (int, bool) {
+	raw := node.str(name)
+	if strings.TrimSpace(raw) == "" {
+		return 0, false
+	}
+	value, err := jmxInt(raw)
+	if err != nil {
+		im.warn("ignored %s of %s: %v", name, node.title(), err)
+		return 0, false
+	}
+	return value, true
+}
+
+// jmxInt 解析整数，支持带默认值的属性引用
+func jmxInt(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if m := jmxProperty.FindStringSubmatch(raw); m != nil {
+		args := strings.Split(m[2], ",")
+		raw = strings.TrimSpace(args[len(args)-1])
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("not a number: %q", raw)
+	}
+	return value, nil
+}
+
+// sampler 将 HTTP 请求转换为计划中的请求
+func (im *jmxImporter) sampler(node *jmxNode, scope jmxScope) PlanRequest {
+	get := func(key string) string {
+		if value := strings.TrimSpace(node.str("HTTPSampler." + key)); value != "" {
+			return value
+		}
+		return strings.TrimSpace(scope.defaults["HTTPSampler."+key])
+	}
+	request := PlanRequest{
+		Name:      node.attr("testname"),
+		Method:    strings.ToUpper(get("method")),
+		ThinkTime: scope.thinkTime,
+	}
+	if request.Method == "" {
+		request.Method = http.MethodGet
+	}
+
+	rawURL := get("path")
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		protocol := get("protocol")
+		if protocol == "" {
+			protocol = "http"
+		}
+		host := get("domain")
+		if port := get("port"); port != "" && !(protocol == "http" && port == "80") && !(protocol == "https" && port == "443") {
+			host += ":" + port
+		}
+		if rawURL != "" && !strings.HasPrefix(rawURL, "/") {
+			rawURL = "/" + rawURL
+		}
+		rawURL = protocol + "://" + host + rawURL
+		if host == "" {
+			im.warn("HTTP request %s has no server name", node.title())
+		}
+	}
+
+	headers := make(map[string]string, len(scope.headers))
+	for name, value := range scope.headers {
+		headers[name] = escapeTemplate(value)
+	}
+	args := node.collection("HTTPsampler.Arguments")
+	if node.isTrue("HTTPSampler.postBodyRaw") {
+		var body strings.Builder
+		for _, arg := range args {
+			body.WriteString(arg.str("Argument.value"))
+		}
+		request.Body = body.String()
+	} else if len(args) > 0 {
+		params := make([]string, 0, len(args))
+		for _, arg := range args {
+			encode := arg.isTrue("HTTPArgument.always_encode")
+			params = append(params, jmxEncode(arg.str("Argument.name"), encode)+"="+jmxEncode(arg.str("Argument.value"), encode))
+		}
+		query := strings.Join(params, "&")
+		switch request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			request.Body = query
+			if !jmxHasHeader(headers, "Content-Type") {
+				headers["Content-Type"] = "application/x-www-form-urlencoded"
+			}
+		default:
+			if strings.Contains(rawURL, "?") {
+				rawURL += "&" + query
+			} else {
+				rawURL += "?" + query
+			}
+		}
+	}
+	if node.isTrue("HTTPSampler.DO_MULTIPART_POST") || len(node.collection("HTTPsampler.Files")) > 0 {
+		im.warn("HTTP request %s: multipart bodies and file uploads are not supported, parameters are sent as a form", node.title())
+	}
+
+	request.URL = escapeTemplate(rawURL)
+	request.Body = escapeTemplate(request.Body)
+	if len(headers) > 0 {
+		request.Headers = headers
+	}
+	for _, s := range append([]string{request.URL, request.Body}, jmxValues(headers)...) {
+		if strings.Contains(s, "${__") {
+			im.warn("HTTP request %s uses JMeter functions, which are sent as is", node.title())
+			break
+		}
+	}
+	return request
+}
+
+// jmxHasHeader 判断是否设置了请求头（不区分大小写）
+func jmxHasHeader(headers map[string]string, name string) bool {
+	for h := range headers {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// jmxValues 返回请求头的值
+func jmxValues(headers map[string]string) []string {
+	values := make([]string, 0, len(headers))
+	for _, v := range headers {
+		values = append(values, v)
+	}
+	return values
+}
+
+// jmxEncode URL 编码参数，保留其中的 ${name} 变量引用
+func jmxEncode(s string, encode bool) string {
+	if !encode {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range variableRef.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
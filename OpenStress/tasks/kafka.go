@@ -0,0 +1,393 @@
+// kafka.go
+// Kafka 压测任务模块
+// 本文件负责 Kafka 的生产/消费吞吐压测：生产者按目标速率发送消息，可选地从主题消费本次运行发送的消息，
+// 计算端到端延迟和消费滞后（lag），生产结果和投递延迟写入收集器，报告中展示生产和消费的数量与每秒吞吐量。
+//
+// 技术实现细节：
+// 1. 基于 go.mod 中已有的 kafka-go，所有生产者共用一个 kafka.Writer；BatchSize 默认为 1，
+//    每条消息单独发送，生产结果的响应时间即为发送到 Broker 确认的耗时。
+// 2. 消息头 openstress-run 为本次运行的随机标识，openstress-sent 为发送时间（UnixNano，大端序），
+//    消费者只统计带本次运行标识的消息，投递样本与 MQTT 任务相同（见 result.DeliveryDataType）。
+// 3. 开启消费时，运行开始前读取每个分区的最新偏移量，每个分区一个不加入消费组的 Reader 从该偏移量开始读取，
+//    避免消费组分配分区前发送的消息被跳过，也不会提交偏移量影响业务消费组。
+// 4. 滞后为已发送但尚未消费的本次运行消息数，Run 返回结束时的滞后和运行过程中的最大滞后；
+//    发送结束后最多等待 DrainTimeout 让消费者追上。
+
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka 任务默认值
+const (
+	DefaultKafkaMessageSize  = 256
+	DefaultKafkaTimeout      = 10 * time.Second
+	DefaultKafkaDrainTimeout = 5 * time.Second
+	kafkaRunHeader           = "openstress-run"
+	kafkaSentHeader          = "openstress-sent"
+)
+
+// KafkaConfig Kafka 生产/消费压测配置
+type KafkaConfig struct {
+	Brokers      []string      `yaml:"brokers"`       // Broker 地址列表
+	Topic        string        `yaml:"topic"`         // 主题，需预先创建
+	Producers    int           `yaml:"producers"`     // 并发生产者数，默认 1
+	Messages     int           `yaml:"messages"`      // 每个生产者发送的消息数，默认 1
+	Rate         float64       `yaml:"rate"`          // 每个生产者每秒发送的消息数，0 表示不限速
+	MessageSize  int           `yaml:"message_size"`  // 消息体字节数，默认 DefaultKafkaMessageSize
+	RequiredAcks string        `yaml:"required_acks"` // 确认级别：none、one 或 all（默认）
+	BatchSize    int           `yaml:"batch_size"`    // 生产者批量大小，默认 1
+	BatchTimeout time.Duration `yaml:"batch_timeout"` // 批量未满时的最长等待时间，0 表示使用 kafka-go 的默认值
+	MaxAttempts  int           `yaml:"max_attempts"`  // 发送失败时的最大尝试次数，0 表示使用 kafka-go 的默认值
+	Timeout      time.Duration `yaml:"timeout"`       // 连接、发送和读取偏移量的超时，默认 DefaultKafkaTimeout
+	Consume      bool          `yaml:"consume"`       // 是否消费本次运行的消息并测量端到端延迟
+	DrainTimeout time.Duration `yaml:"drain_timeout"` // 发送结束后等待消费的最长时间，默认 DefaultKafkaDrainTimeout
+}
+
+// KafkaRunStats 一次 Run 的消息计数
+type KafkaRunStats struct {
+	Produced int   // 发送成功的消息数
+	Failed   int   // 发送失败的消息数
+	Consumed int   // 消费到的本次运行消息数（未开启消费时为 0）
+	Lag      int64 // 结束时已发送但尚未消费的消息数（未开启消费时为 0）
+	MaxLag   int64 // 运行过程中的最大滞后
+}
+
+// KafkaTask Kafka 生产/消费压测任务，并发安全
+type KafkaTask struct {
+	Collector *result.Collector // 结果收集器，为 nil 时不记录结果
+	Writer    *kafka.Writer     // 所有生产者共用的 Writer
+
+	cfg    KafkaConfig
+	dialer *kafka.Dialer
+}
+
+// NewKafkaTask 校验配置并创建 Kafka 压测任务，此时不连接 Broker
+func NewKafkaTask(cfg KafkaConfig) (*KafkaTask, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka brokers are required")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka topic is required")
+	}
+	if cfg.Rate < 0 {
+		return nil, fmt.Errorf("kafka rate must not be negative")
+	}
+	acks := kafka.RequireAll
+	switch strings.ToLower(cfg.RequiredAcks) {
+	case "", "all":
+	case "one":
+		acks = kafka.RequireOne
+	case "none":
+		acks = kafka.RequireNone
+	default:
+		return nil, fmt.Errorf("unsupported required acks %q, expected none, one or all", cfg.RequiredAcks)
+	}
+	if cfg.Producers <= 0 {
+		cfg.Producers = 1
+	}
+	if cfg.Messages <= 0 {
+		cfg.Messages = 1
+	}
+	if cfg.MessageSize <= 0 {
+		cfg.MessageSize = DefaultKafkaMessageSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultKafkaTimeout
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = DefaultKafkaDrainTimeout
+	}
+
+	return &KafkaTask{
+		cfg:    cfg,
+		dialer: &kafka.Dialer{Timeout: cfg.Timeout},
+		Writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			RequiredAcks: acks,
+			BatchSize:    cfg.BatchSize,
+			BatchTimeout: cfg.BatchTimeout,
+			MaxAttempts:  cfg.MaxAttempts,
+			WriteTimeout: cfg.Timeout,
+			ReadTimeout:  cfg.Timeout,
+		},
+	}, nil
+}
+
+// Run 开启消费时先定位各分区的起始偏移量并启动消费者，然后所有生产者并发发送消息，最后等待消费追上。
+// 定位分区失败时直接返回错误；发送失败记为失败结果，合并后返回
+func (k *KafkaTask) Run(ctx context.Context) (KafkaRunStats, error) {
+	var stats KafkaRunStats
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return stats, fmt.Errorf("failed to generate run id: %v", err)
+	}
+	runID := []byte(hex.EncodeToString(id))
+
+	var produced, consumed, maxLag atomic.Int64
+	updateLag := func() {
+		lag := produced.Load() - consumed.Load()
+		for {
+			current := maxLag.Load()
+			if lag <= current || maxLag.CompareAndSwap(current, lag) {
+				return
+			}
+		}
+	}
+
+	consumeCtx, stopConsumers := context.WithCancel(context.Background())
+	defer stopConsumers()
+	var consumers sync.WaitGroup
+	if k.cfg.Consume {
+		readers, err := k.openReaders(ctx)
+		if err != nil {
+			return stats, err
+		}
+		for partition, reader := range readers {
+			consumers.Add(1)
+			go func(partition int, reader *kafka.Reader) {
+				defer consumers.Done()
+				defer reader.Close()
+				k.consume(consumeCtx, partition, reader, runID, func() {
+					consumed.Add(1)
+					updateLag()
+				})
+			}(partition, reader)
+		}
+	}
+
+	var failed atomic.Int64
+	errs := make([]error, k.cfg.Producers)
+	var wg sync.WaitGroup
+	for i := 0; i < k.cfg.Producers; i++ {
+		wg.Add(1)
+		go func(producer int) {
+			defer wg.Done()
+			ok, err := k.produceAll(ctx, producer, runID, func() {
+				produced.Add(1)
+				updateLag()
+			})
+			failed.Add(int64(k.cfg.Messages - ok))
+			errs[producer] = err
+		}(i)
+	}
+	wg.Wait()
+
+	if k.cfg.Consume {
+		deadline := time.After(k.cfg.DrainTimeout)
+		ticker := time.NewTicker(10 * time.Millisecond)
+	drain:
+		for consumed.Load() < produced.Load() {
+			select {
+			case <-ticker.C:
+			case <-deadline:
+				break drain
+			case <-ctx.Done():
+				break drain
+			}
+		}
+		ticker.Stop()
+		stopConsumers()
+		consumers.Wait()
+	}
+
+	stats.Produced = int(produced.Load())
+	stats.Failed = int(failed.Load())
+	if k.cfg.Consume {
+		stats.Consumed = int(consumed.Load())
+		stats.Lag = max(produced.Load()-consumed.Load(), 0)
+		stats.MaxLag = maxLag.Load()
+	}
+	return stats, errors.Join(errs...)
+}
+
+// openReaders 为主题的每个分区创建一个从当前最新偏移量开始读取的 Reader，键为分区号
+func (k *KafkaTask) openReaders(ctx context.Context) (map[int]*kafka.Reader, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.cfg.Timeout)
+	defer cancel()
+	var partitions []kafka.Partition
+	var err error
+	for _, broker := range k.cfg.Brokers {
+		if partitions, err = k.dialer.LookupPartitions(ctx, "tcp", broker, k.cfg.Topic); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up partitions of %s: %v", k.cfg.Topic, err)
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", k.cfg.Topic)
+	}
+
+	readers := make(map[int]*kafka.Reader, len(partitions))
+	for _, p := range partitions {
+		offset, err := k.lastOffset(ctx, p)
+		if err == nil {
+			reader := kafka.NewReader(kafka.ReaderConfig{
+				Brokers:   k.cfg.Brokers,
+				Topic:     k.cfg.Topic,
+				Partition: p.ID,
+				Dialer:    k.dialer,
+				MaxWait:   100 * time.Millisecond,
+			})
+			if err = reader.SetOffset(offset); err == nil {
+				readers[p.ID] = reader
+				continue
+			}
+			reader.Close()
+		}
+		for _, r := range readers {
+			r.Close()
+		}
+		return nil, fmt.Errorf("failed to open partition %d: %v", p.ID, err)
+	}
+	return readers, nil
+}
+
+// lastOffset 读取分区当前的最新偏移量
+func (k *KafkaTask) lastOffset(ctx context.Context, p kafka.Partition) (int64, error) {
+	conn, err := k.dialer.DialLeader(ctx, "tcp", fmt.Sprintf("%s:%d", p.Leader.Host, p.Leader.Port), p.Topic, p.ID)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return conn.ReadLastOffset()
+}
+
+// consume 读取一个分区，本次运行的消息记为投递样本，直到 ctx 取消
+func (k *KafkaTask) consume(ctx context.Context, partition int, reader *kafka.Reader, runID []byte, onMessage func()) {
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return
+		}
+		received := time.Now()
+		sent, ok := kafkaSentTime(msg, runID)
+		if !ok {
+			continue
+		}
+		k.record(result.ResultData{
+			ID:           k.cfg.Topic,
+			Method:       "CONSUME",
+			URL:          k.topicURL(),
+			DataType:     result.DeliveryDataType,
+			ThreadID:     partition,
+			StartTime:    sent,
+			EndTime:      received,
+			DataReceived: int64(len(msg.Value)),
+		}, nil)
+		onMessage()
+	}
+}
+
+// kafkaSentTime 返回本次运行消息的发送时间，其他消息返回 false
+func kafkaSentTime(msg kafka.Message, runID []byte) (time.Time, bool) {
+	var matched bool
+	var sent time.Time
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case kafkaRunHeader:
+			matched = string(h.Value) == string(runID)
+		case kafkaSentHeader:
+			if len(h.Value) == 8 {
+				sent = time.Unix(0, int64(binary.BigEndian.Uint64(h.Value)))
+			}
+		}
+	}
+	return sent, matched && !sent.IsZero()
+}
+
+// produceAll 一个生产者按速率发送 Messages 条消息，返回发送成功的条数，遇到错误时停止
+func (k *KafkaTask) produceAll(ctx context.Context, producer int, runID []byte, onProduced func()) (int, error) {
+	var interval time.Duration
+	if k.cfg.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / k.cfg.Rate)
+	}
+	value := make([]byte, k.cfg.MessageSize)
+	next := time.Now()
+	ok := 0
+	for i := 0; i < k.cfg.Messages; i++ {
+		if interval > 0 {
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return ok, ctx.Err()
+				}
+			}
+			next = next.Add(interval)
+		}
+		start := time.Now()
+		sent := make([]byte, 8)
+		binary.BigEndian.PutUint64(sent, uint64(start.UnixNano()))
+		err := k.Writer.WriteMessages(ctx, kafka.Message{
+			Value: value,
+			Headers: []kafka.Header{
+				{Key: kafkaRunHeader, Value: runID},
+				{Key: kafkaSentHeader, Value: sent},
+			},
+		})
+		k.record(result.ResultData{
+			ID:        k.cfg.Topic,
+			Method:    "PRODUCE",
+			URL:       k.topicURL(),
+			ThreadID:  producer,
+			StartTime: start,
+			DataSent:  int64(len(value)),
+		}, err)
+		if err != nil {
+			return ok, fmt.Errorf("producer %d: %v", producer, err)
+		}
+		ok++
+		onProduced()
+	}
+	return ok, nil
+}
+
+// topicURL 返回结果中记录的地址，例如 kafka://broker:9092/orders
+func (k *KafkaTask) topicURL() string {
+	return fmt.Sprintf("kafka://%s/%s", k.cfg.Brokers[0], k.cfg.Topic)
+}
+
+// record 结束计时并把结果写入收集器，投递样本的结束时间在调用前已设置
+func (k *KafkaTask) record(data result.ResultData, err error) {
+	if data.EndTime.IsZero() {
+		data.EndTime = time.Now()
+	}
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		data.ErrorMessage = err.Error()
+	}
+	if k.Collector == nil {
+		return
+	}
+	if data.Type == result.Success {
+		k.Collector.SaveSuccessResult(data)
+	} else {
+		k.Collector.SaveFailureResult(data)
+	}
+}
+
+// Close 关闭 Writer
+func (k *KafkaTask) Close() error {
+	return k.Writer.Close()
+}
@@ -0,0 +1,176 @@
+// kerberos.go
+// Kerberos 认证任务模块
+// 本文件负责压测 Kerberos（SPNEGO/Negotiate）认证的 HTTP 服务：加载 krb5 配置，使用密码或 keytab 登录 KDC，
+// 为每个请求获取服务票据并设置 Authorization: Negotiate 请求头，结果和各阶段耗时写入收集器。
+//
+// 技术实现细节：
+// 1. krb5 配置可以直接写在场景配置中，也可以指定 krb5.conf 文件；密码与 keytab 二选一。
+// 2. 登录（获取 TGT）在第一次请求时进行，并发请求只登录一次；登录失败不缓存，下一次请求重新尝试。
+// 3. gokrb5 客户端会缓存服务票据，只有第一次访问某个 SPN 时才请求 KDC，获取票据的耗时计入该请求的响应时间。
+// 4. SPN 为空时由 gokrb5 根据请求主机推导（HTTP/<主机>，会解析 CNAME）。
+// 5. 目前只支持 Kerberos，NTLM 需要额外的依赖，暂不支持。
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/jcmturner/gokrb5.v7/client"
+	"gopkg.in/jcmturner/gokrb5.v7/config"
+	"gopkg.in/jcmturner/gokrb5.v7/keytab"
+	"gopkg.in/jcmturner/gokrb5.v7/spnego"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// KerberosConfig Kerberos 认证配置
+type KerberosConfig struct {
+	Krb5Conf     string `yaml:"krb5_conf"`      // krb5.conf 内容，与 Krb5ConfPath 二选一
+	Krb5ConfPath string `yaml:"krb5_conf_path"` // krb5.conf 文件路径
+	Username     string `yaml:"username"`       // 用户名（不含域）
+	Realm        string `yaml:"realm"`          // 域，例如 EXAMPLE.COM
+	Password     string `yaml:"password"`       // 密码，与 KeytabPath 二选一
+	KeytabPath   string `yaml:"keytab_path"`    // keytab 文件路径
+	SPN          string `yaml:"spn"`            // 服务主体名称，例如 HTTP/www.example.com，为空时根据请求主机推导
+}
+
+// KerberosHTTPTask 使用 Kerberos 认证发送 HTTP 请求，并发安全，应在任务之间共用
+type KerberosHTTPTask struct {
+	Client    *http.Client      // 发送请求的 HTTP 客户端，为 nil 时使用 DefaultHTTPClient
+	Collector *result.Collector // 结果收集器，为 nil 时 Execute 不记录结果
+
+	spn string
+	krb *client.Client
+
+	mu       sync.Mutex
+	loggedIn bool
+}
+
+// NewKerberosHTTPTask 校验配置并创建 Kerberos 认证任务，此时不连接 KDC
+func NewKerberosHTTPTask(cfg KerberosConfig) (*KerberosHTTPTask, error) {
+	if cfg.Username == "" || cfg.Realm == "" {
+		return nil, fmt.Errorf("kerberos username and realm are required")
+	}
+	if (cfg.Password == "") == (cfg.KeytabPath == "") {
+		return nil, fmt.Errorf("exactly one of kerberos password and keytab is required")
+	}
+
+	var krbConf *config.Config
+	var err error
+	switch {
+	case cfg.Krb5Conf != "" && cfg.Krb5ConfPath != "":
+		return nil, fmt.Errorf("only one of krb5 config and krb5 config path can be set")
+	case cfg.Krb5Conf != "":
+		krbConf, err = config.NewConfigFromString(cfg.Krb5Conf)
+	case cfg.Krb5ConfPath != "":
+		krbConf, err = config.Load(cfg.Krb5ConfPath)
+	default:
+		return nil, fmt.Errorf("krb5 config is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load krb5 config: %v", err)
+	}
+
+	task := &KerberosHTTPTask{spn: cfg.SPN}
+	if cfg.KeytabPath != "" {
+		kt, err := keytab.Load(cfg.KeytabPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keytab: %v", err)
+		}
+		task.krb = client.NewClientWithKeytab(cfg.Username, cfg.Realm, kt, krbConf)
+	} else {
+		task.krb = client.NewClientWithPassword(cfg.Username, cfg.Realm, cfg.Password, krbConf)
+	}
+	return task, nil
+}
+
+// Login 登录 KDC 获取 TGT，已登录时直接返回
+func (k *KerberosHTTPTask) Login() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.loggedIn {
+		return nil
+	}
+	if err := k.krb.Login(); err != nil {
+		return fmt.Errorf("kerberos login failed: %v", err)
+	}
+	k.loggedIn = true
+	return nil
+}
+
+// Authorize 为请求设置 Negotiate 认证请求头，必要时先登录并获取服务票据
+func (k *KerberosHTTPTask) Authorize(req *http.Request) error {
+	if err := k.Login(); err != nil {
+		return err
+	}
+	if err := spnego.SetSPNEGOHeader(k.krb, req, k.spn); err != nil {
+		return fmt.Errorf("failed to set spnego header: %v", err)
+	}
+	return nil
+}
+
+// Do 认证并发送请求，返回响应和各阶段耗时
+func (k *KerberosHTTPTask) Do(req *http.Request) (*http.Response, PhaseTimings, error) {
+	if err := k.Authorize(req); err != nil {
+		return nil, PhaseTimings{}, err
+	}
+	client := k.Client
+	if client == nil {
+		client = DefaultHTTPClient()
+	}
+	return DoTimed(client, req)
+}
+
+// Execute 认证并发送请求，读取响应体后将结果（含各阶段耗时）写入收集器。
+// 认证失败、请求出错或状态码不是 2xx/3xx 时记为失败，并返回错误，状态码错误时带上响应体片段
+func (k *KerberosHTTPTask) Execute(req *http.Request, threadID int) (result.ResultData, error) {
+	data := result.ResultData{
+		ID:        req.URL.String(),
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		ThreadID:  threadID,
+		StartTime: time.Now(),
+		DataSent:  req.ContentLength,
+	}
+
+	resp, phases, err := k.Do(req)
+	if err == nil {
+		data.StatusCode = resp.StatusCode
+		var body []byte
+		body, data.DataReceived, err = ReadBody(resp)
+		if err != nil {
+			err = fmt.Errorf("failed to read response: %v", err)
+		} else if resp.StatusCode >= 400 {
+			err = fmt.Errorf("request failed with status %d", resp.StatusCode)
+			data.ResponseBody = string(body)
+		}
+	}
+	data.EndTime = time.Now()
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.ApplyPhases(phases)
+
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		data.ErrorMessage = err.Error()
+	}
+	if k.Collector != nil {
+		if data.Type == result.Success {
+			k.Collector.SaveSuccessResult(data)
+		} else {
+			k.Collector.SaveFailureResult(data)
+		}
+	}
+	return data, err
+}
+
+// Close 清除 Kerberos 会话和缓存的票据
+func (k *KerberosHTTPTask) Close() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.krb.Destroy()
+	k.loggedIn = false
+}
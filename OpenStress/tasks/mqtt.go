@@ -0,0 +1,722 @@
+// mqtt.go
+// MQTT 压测任务模块
+// 本文件负责 MQTT Broker 的发布/订阅压测：订阅者先连接并订阅主题，发布者按速率发布消息，
+// 订阅者收到消息后计算端到端投递延迟，发布结果和投递延迟都写入收集器，报告中展示投递延迟趋势。
+//
+// 技术实现细节：
+// 1. 内置一个精简的 MQTT 3.1.1 客户端（CONNECT、PUBLISH、SUBSCRIBE、PING、DISCONNECT），支持 QoS 0/1/2，
+//    支持 tcp:// 和 tls:// 地址；读循环在独立协程中分发确认报文和收到的消息。
+// 2. 消息载荷前 16 字节为本次运行的随机标识和发布时间（UnixNano，大端序），订阅者只统计本次运行发布的消息，
+//    其余字节按 PayloadSize 填充。
+// 3. 发布结果的响应时间为发送到收到确认（QoS 1 为 PUBACK，QoS 2 为 PUBCOMP）的耗时，QoS 0 只包含写入耗时；
+//    发布者连接的耗时记在其第一条发布结果上。
+// 4. 发布结束后最多等待 DrainTimeout 让消息投递完，未收到的消息计为丢失。
+
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// MQTT 任务默认值
+const (
+	DefaultMQTTKeepAlive    = 30 * time.Second
+	DefaultMQTTTimeout      = 5 * time.Second
+	DefaultMQTTDrainTimeout = 2 * time.Second
+	mqttHeaderSize          = 16 // 载荷头：运行标识 8 字节 + 发布时间 8 字节
+)
+
+// MQTT 报文类型
+const (
+	mqttConnect      = 1
+	mqttConnack      = 2
+	mqttPublish      = 3
+	mqttPuback       = 4
+	mqttPubrec       = 5
+	mqttPubrel       = 6
+	mqttPubcomp      = 7
+	mqttSubscribe    = 8
+	mqttSuback       = 9
+	mqttPingreq      = 12
+	mqttPingresp     = 13
+	mqttDisconnect   = 14
+	mqttMaxRemaining = 268435455
+)
+
+// MQTTConfig MQTT 发布/订阅压测配置
+type MQTTConfig struct {
+	Broker             string        `yaml:"broker"`               // Broker 地址，例如 tcp://10.0.0.1:1883 或 tls://broker:8883
+	ClientIDPrefix     string        `yaml:"client_id_prefix"`     // 客户端 ID 前缀，默认 openstress
+	Username           string        `yaml:"username"`             // 用户名
+	Password           string        `yaml:"password"`             // 密码
+	Topic              string        `yaml:"topic"`                // 发布和订阅的主题
+	QoS                byte          `yaml:"qos"`                  // 服务质量等级：0、1 或 2
+	Retain             bool          `yaml:"retain"`               // 是否发布保留消息
+	Publishers         int           `yaml:"publishers"`           // 发布者连接数，默认 1
+	Subscribers        int           `yaml:"subscribers"`          // 订阅者连接数，0 表示不测量投递延迟
+	Messages           int           `yaml:"messages"`             // 每个发布者发布的消息数，默认 1
+	Rate               float64       `yaml:"rate"`                 // 每个发布者每秒发布的消息数，0 表示不限速
+	PayloadSize        int           `yaml:"payload_size"`         // 载荷字节数，最小 16
+	KeepAlive          time.Duration `yaml:"keep_alive"`           // 心跳间隔，默认 DefaultMQTTKeepAlive
+	Timeout            time.Duration `yaml:"timeout"`              // 连接、订阅和发布确认的超时，默认 DefaultMQTTTimeout
+	DrainTimeout       time.Duration `yaml:"drain_timeout"`        // 发布结束后等待投递的最长时间，默认 DefaultMQTTDrainTimeout
+	PersistentSession  bool          `yaml:"persistent_session"`   // 使用持久会话（Clean Session = 0）
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"` // tls:// 时跳过证书校验
+}
+
+// MQTTRunStats 一次 Run 的消息计数
+type MQTTRunStats struct {
+	Published int // 发布成功的消息数
+	Failed    int // 发布失败的消息数
+	Expected  int // 订阅者应收到的消息数（发布成功数 × 订阅者数）
+	Delivered int // 订阅者实际收到的消息数
+}
+
+// Lost 返回未投递的消息数
+func (s MQTTRunStats) Lost() int {
+	return max(s.Expected-s.Delivered, 0)
+}
+
+// MQTTTask MQTT 发布/订阅压测任务，并发安全
+type MQTTTask struct {
+	Collector *result.Collector // 结果收集器，为 nil 时不记录结果
+
+	cfg     MQTTConfig
+	network string // tcp 或 tls
+	address string
+	seq     atomic.Int64 // 客户端 ID 序号
+}
+
+// NewMQTTTask 校验配置并创建 MQTT 压测任务，此时不连接 Broker
+func NewMQTTTask(cfg MQTTConfig) (*MQTTTask, error) {
+	task := &MQTTTask{network: "tcp", address: cfg.Broker}
+	if scheme, address, ok := strings.Cut(cfg.Broker, "://"); ok {
+		switch scheme {
+		case "tcp", "mqtt":
+		case "tls", "ssl", "mqtts":
+			task.network = "tls"
+		default:
+			return nil, fmt.Errorf("unsupported mqtt broker scheme %q", scheme)
+		}
+		task.address = address
+	}
+	if task.address == "" {
+		return nil, fmt.Errorf("mqtt broker is required")
+	}
+	if cfg.Topic == "" || strings.ContainsAny(cfg.Topic, "+#") {
+		return nil, fmt.Errorf("mqtt topic is required and must not contain wildcards")
+	}
+	if cfg.QoS > 2 {
+		return nil, fmt.Errorf("invalid mqtt qos %d", cfg.QoS)
+	}
+	if cfg.Subscribers < 0 || cfg.Rate < 0 {
+		return nil, fmt.Errorf("mqtt subscribers and rate must not be negative")
+	}
+	if cfg.ClientIDPrefix == "" {
+		cfg.ClientIDPrefix = "openstress"
+	}
+	if cfg.Publishers <= 0 {
+		cfg.Publishers = 1
+	}
+	if cfg.Messages <= 0 {
+		cfg.Messages = 1
+	}
+	cfg.PayloadSize = max(cfg.PayloadSize, mqttHeaderSize)
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = DefaultMQTTKeepAlive
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultMQTTTimeout
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = DefaultMQTTDrainTimeout
+	}
+	task.cfg = cfg
+	return task, nil
+}
+
+// Run 连接订阅者并订阅主题，然后所有发布者并发发布消息，最后等待消息投递完成。
+// 订阅者连接或订阅失败时直接返回错误；发布失败记为失败结果，合并后返回
+func (m *MQTTTask) Run(ctx context.Context) (MQTTRunStats, error) {
+	var stats MQTTRunStats
+	runID := make([]byte, 8)
+	if _, err := rand.Read(runID); err != nil {
+		return stats, fmt.Errorf("failed to generate run id: %v", err)
+	}
+
+	var delivered atomic.Int64
+	allDelivered := make(chan struct{})
+	var expected atomic.Int64
+	expected.Store(-1) // 发布结束前不会判定投递完成
+	var closeOnce sync.Once
+	onMessage := func(subscriber int) func(topic string, payload []byte) {
+		return func(topic string, payload []byte) {
+			received := time.Now()
+			if len(payload) < mqttHeaderSize || !bytes.Equal(payload[:8], runID) {
+				return
+			}
+			sent := time.Unix(0, int64(binary.BigEndian.Uint64(payload[8:16])))
+			m.record(result.ResultData{
+				ID:           m.cfg.Topic,
+				Method:       "DELIVER",
+				URL:          m.topicURL(),
+				DataType:     result.DeliveryDataType,
+				ThreadID:     subscriber,
+				StartTime:    sent,
+				EndTime:      received,
+				DataReceived: int64(len(payload)),
+			}, nil)
+			if n := delivered.Add(1); n == expected.Load() {
+				closeOnce.Do(func() { close(allDelivered) })
+			}
+		}
+	}
+
+	subscribers := make([]*mqttConn, 0, m.cfg.Subscribers)
+	defer func() {
+		for _, c := range subscribers {
+			c.close()
+		}
+	}()
+	for i := 0; i < m.cfg.Subscribers; i++ {
+		conn, err := m.dial(ctx, onMessage(i))
+		if err != nil {
+			return stats, fmt.Errorf("subscriber %d: %v", i, err)
+		}
+		subscribers = append(subscribers, conn)
+		if err := conn.subscribe(ctx, m.cfg.Topic, m.cfg.QoS); err != nil {
+			return stats, fmt.Errorf("subscriber %d: %v", i, err)
+		}
+	}
+
+	var published, failed atomic.Int64
+	errs := make([]error, m.cfg.Publishers)
+	var wg sync.WaitGroup
+	for i := 0; i < m.cfg.Publishers; i++ {
+		wg.Add(1)
+		go func(publisher int) {
+			defer wg.Done()
+			ok, err := m.publishAll(ctx, publisher, runID)
+			published.Add(int64(ok))
+			failed.Add(int64(m.cfg.Messages - ok))
+			errs[publisher] = err
+		}(i)
+	}
+	wg.Wait()
+
+	stats.Published = int(published.Load())
+	stats.Failed = int(failed.Load())
+	stats.Expected = stats.Published * len(subscribers)
+	expected.Store(int64(stats.Expected))
+	if delivered.Load() >= int64(stats.Expected) {
+		closeOnce.Do(func() { close(allDelivered) })
+	}
+	select {
+	case <-allDelivered:
+	case <-time.After(m.cfg.DrainTimeout):
+	case <-ctx.Done():
+	}
+	stats.Delivered = int(delivered.Load())
+	return stats, errors.Join(errs...)
+}
+
+// publishAll 一个发布者连接并按速率发布 Messages 条消息，返回发布成功的条数
+func (m *MQTTTask) publishAll(ctx context.Context, publisher int, runID []byte) (int, error) {
+	start := time.Now()
+	conn, err := m.dial(ctx, nil)
+	connect := time.Since(start)
+	data := m.publishResult(publisher, start, connect)
+	if err != nil {
+		err = fmt.Errorf("publisher %d: %v", publisher, err)
+		m.record(data, err)
+		return 0, err
+	}
+	defer conn.close()
+
+	var interval time.Duration
+	if m.cfg.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / m.cfg.Rate)
+	}
+	payload := make([]byte, m.cfg.PayloadSize)
+	copy(payload, runID)
+	next := time.Now()
+	ok := 0
+	for i := 0; i < m.cfg.Messages; i++ {
+		if interval > 0 {
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return ok, ctx.Err()
+				}
+			}
+			next = next.Add(interval)
+		}
+		if i > 0 {
+			// 连接耗时只计入第一条发布结果
+			data = m.publishResult(publisher, time.Now(), 0)
+		}
+		binary.BigEndian.PutUint64(payload[8:16], uint64(time.Now().UnixNano()))
+		err := conn.publish(ctx, m.cfg.Topic, payload, m.cfg.QoS, m.cfg.Retain)
+		data.DataSent = int64(len(payload))
+		m.record(data, err)
+		if err != nil {
+			return ok, fmt.Errorf("publisher %d: %v", publisher, err)
+		}
+		ok++
+	}
+	return ok, nil
+}
+
+// publishResult 创建一条发布结果
+func (m *MQTTTask) publishResult(publisher int, start time.Time, connect time.Duration) result.ResultData {
+	return result.ResultData{
+		ID:         m.cfg.Topic,
+		Method:     "PUBLISH",
+		URL:        m.topicURL(),
+		ThreadID:   publisher,
+		StartTime:  start,
+		Connect:    connect.Milliseconds(),
+		TCPConnect: connect,
+	}
+}
+
+// topicURL 返回结果中记录的地址，例如 tcp://broker:1883/sensors/temp
+func (m *MQTTTask) topicURL() string {
+	return fmt.Sprintf("%s://%s/%s", m.network, m.address, m.cfg.Topic)
+}
+
+// record 结束计时并把结果写入收集器，投递样本的结束时间在调用前已设置
+func (m *MQTTTask) record(data result.ResultData, err error) {
+	if data.EndTime.IsZero() {
+		data.EndTime = time.Now()
+	}
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		data.ErrorMessage = err.Error()
+	}
+	if m.Collector == nil {
+		return
+	}
+	if data.Type == result.Success {
+		m.Collector.SaveSuccessResult(data)
+	} else {
+		m.Collector.SaveFailureResult(data)
+	}
+}
+
+// dial 连接 Broker 并完成 CONNECT 握手
+func (m *MQTTTask) dial(ctx context.Context, onMessage func(topic string, payload []byte)) (*mqttConn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if m.network == "tls" {
+		dialer := &tls.Dialer{Config: &tls.Config{InsecureSkipVerify: m.cfg.InsecureSkipVerify}}
+		conn, err = dialer.DialContext(dialCtx, "tcp", m.address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(dialCtx, "tcp", m.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker: %v", err)
+	}
+
+	c := &mqttConn{
+		conn:      conn,
+		reader:    bufio.NewReader(conn),
+		timeout:   m.cfg.Timeout,
+		pending:   make(map[uint16]chan error),
+		inbound:   make(map[uint16]bool),
+		onMessage: onMessage,
+		done:      make(chan struct{}),
+	}
+	clientID := fmt.Sprintf("%s-%d-%d", m.cfg.ClientIDPrefix, time.Now().UnixNano()%1e6, m.seq.Add(1))
+	if err := c.connect(clientID, m.cfg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	go c.keepAlive(m.cfg.KeepAlive)
+	return c, nil
+}
+
+// mqttConn 一个 MQTT 连接
+type mqttConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint16
+	pending map[uint16]chan error // 等待确认的报文标识
+	inbound map[uint16]bool       // 已收到、尚未完成 QoS 2 流程的报文标识
+	err     error                 // 连接断开的原因
+
+	onMessage func(topic string, payload []byte)
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// connect 发送 CONNECT 并等待 CONNACK
+func (c *mqttConn) connect(clientID string, cfg MQTTConfig) error {
+	var body bytes.Buffer
+	writeMQTTString(&body, "MQTT")
+	body.WriteByte(4) // 协议级别 3.1.1
+	var flags byte
+	if !cfg.PersistentSession {
+		flags |= 0x02
+	}
+	if cfg.Username != "" {
+		flags |= 0x80
+	}
+	if cfg.Password != "" {
+		flags |= 0x40
+	}
+	body.WriteByte(flags)
+	binary.Write(&body, binary.BigEndian, uint16(cfg.KeepAlive/time.Second))
+	writeMQTTString(&body, clientID)
+	if cfg.Username != "" {
+		writeMQTTString(&body, cfg.Username)
+	}
+	if cfg.Password != "" {
+		writeMQTTString(&body, cfg.Password)
+	}
+	if err := c.writePacket(mqttConnect<<4, body.Bytes()); err != nil {
+		return fmt.Errorf("failed to send connect: %v", err)
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+	header, payload, err := readMQTTPacket(c.reader)
+	if err != nil {
+		return fmt.Errorf("failed to read connack: %v", err)
+	}
+	if header>>4 != mqttConnack || len(payload) != 2 {
+		return fmt.Errorf("unexpected packet type %d while waiting for connack", header>>4)
+	}
+	if code := payload[1]; code != 0 {
+		return fmt.Errorf("mqtt broker refused the connection: %s", connackReason(code))
+	}
+	return nil
+}
+
+// connackReason 返回 CONNACK 返回码的含义
+func connackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	default:
+		return fmt.Sprintf("return code %d", code)
+	}
+}
+
+// subscribe 订阅主题并等待 SUBACK
+func (c *mqttConn) subscribe(ctx context.Context, topic string, qos byte) error {
+	id, ack, err := c.track()
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	binary.Write(&body, binary.BigEndian, id)
+	writeMQTTString(&body, topic)
+	body.WriteByte(qos)
+	if err := c.writePacket(mqttSubscribe<<4|0x02, body.Bytes()); err != nil {
+		c.untrack(id)
+		return fmt.Errorf("failed to subscribe: %v", err)
+	}
+	if err := c.wait(ctx, id, ack); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %v", topic, err)
+	}
+	return nil
+}
+
+// publish 发布消息，QoS 大于 0 时等待确认
+func (c *mqttConn) publish(ctx context.Context, topic string, payload []byte, qos byte, retain bool) error {
+	header := byte(mqttPublish<<4) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	var body bytes.Buffer
+	writeMQTTString(&body, topic)
+	if qos == 0 {
+		body.Write(payload)
+		return c.writePacket(header, body.Bytes())
+	}
+
+	id, ack, err := c.track()
+	if err != nil {
+		return err
+	}
+	binary.Write(&body, binary.BigEndian, id)
+	body.Write(payload)
+	if err := c.writePacket(header, body.Bytes()); err != nil {
+		c.untrack(id)
+		return fmt.Errorf("failed to publish: %v", err)
+	}
+	return c.wait(ctx, id, ack)
+}
+
+// track 分配报文标识并登记等待确认
+func (c *mqttConn) track() (uint16, chan error, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, nil, c.err
+	}
+	for {
+		c.nextID++
+		if c.nextID == 0 {
+			continue
+		}
+		if _, busy := c.pending[c.nextID]; !busy {
+			break
+		}
+	}
+	ack := make(chan error, 1)
+	c.pending[c.nextID] = ack
+	return c.nextID, ack, nil
+}
+
+// untrack 取消等待确认
+func (c *mqttConn) untrack(id uint16) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// resolve 收到确认，通知等待方
+func (c *mqttConn) resolve(id uint16, err error) {
+	c.mu.Lock()
+	ack, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		ack <- err
+	}
+}
+
+// wait 等待确认，超时、context 取消或连接断开时返回错误
+func (c *mqttConn) wait(ctx context.Context, id uint16, ack chan error) error {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-ack:
+		return err
+	case <-timer.C:
+		c.untrack(id)
+		return fmt.Errorf("timed out waiting for acknowledgement of packet %d", id)
+	case <-ctx.Done():
+		c.untrack(id)
+		return ctx.Err()
+	case <-c.done:
+		return c.closeErr()
+	}
+}
+
+// readLoop 读取并分发 Broker 发来的报文，连接断开时结束
+func (c *mqttConn) readLoop() {
+	for {
+		header, body, err := readMQTTPacket(c.reader)
+		if err != nil {
+			c.shutdown(fmt.Errorf("mqtt connection lost: %v", err))
+			return
+		}
+		switch header >> 4 {
+		case mqttPublish:
+			c.handlePublish(header, body)
+		case mqttPuback, mqttPubcomp:
+			if len(body) >= 2 {
+				c.resolve(binary.BigEndian.Uint16(body), nil)
+			}
+		case mqttPubrec:
+			// QoS 2 发布：收到 PUBREC 后发送 PUBREL，继续等待 PUBCOMP
+			if len(body) >= 2 {
+				c.writePacket(mqttPubrel<<4|0x02, body[:2])
+			}
+		case mqttPubrel:
+			// QoS 2 接收：收到 PUBREL 后完成流程
+			if len(body) >= 2 {
+				c.mu.Lock()
+				delete(c.inbound, binary.BigEndian.Uint16(body))
+				c.mu.Unlock()
+				c.writePacket(mqttPubcomp<<4, body[:2])
+			}
+		case mqttSuback:
+			if len(body) >= 3 {
+				var err error
+				if body[2] == 0x80 {
+					err = fmt.Errorf("subscription rejected by the broker")
+				}
+				c.resolve(binary.BigEndian.Uint16(body), err)
+			}
+		case mqttPingresp:
+		}
+	}
+}
+
+// handlePublish 处理收到的消息并按 QoS 回复确认
+func (c *mqttConn) handlePublish(header byte, body []byte) {
+	qos := (header >> 1) & 0x03
+	if len(body) < 2 {
+		return
+	}
+	topicLen := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+topicLen {
+		return
+	}
+	topic := string(body[2 : 2+topicLen])
+	rest := body[2+topicLen:]
+	var id uint16
+	if qos > 0 {
+		if len(rest) < 2 {
+			return
+		}
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+
+	deliver := true
+	switch qos {
+	case 1:
+		c.writePacket(mqttPuback<<4, body[2+topicLen:4+topicLen])
+	case 2:
+		// 重发的 QoS 2 消息只投递一次
+		c.mu.Lock()
+		deliver = !c.inbound[id]
+		c.inbound[id] = true
+		c.mu.Unlock()
+		c.writePacket(mqttPubrec<<4, body[2+topicLen:4+topicLen])
+	}
+	if deliver && c.onMessage != nil {
+		c.onMessage(topic, rest)
+	}
+}
+
+// keepAlive 按心跳间隔发送 PINGREQ
+func (c *mqttConn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval * 3 / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.writePacket(mqttPingreq<<4, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// writePacket 写入一个报文
+func (c *mqttConn) writePacket(header byte, body []byte) error {
+	if len(body) > mqttMaxRemaining {
+		return fmt.Errorf("mqtt packet too large: %d bytes", len(body))
+	}
+	packet := make([]byte, 0, len(body)+5)
+	packet = append(packet, header)
+	for n := len(body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// close 发送 DISCONNECT 并关闭连接
+func (c *mqttConn) close() {
+	c.writePacket(mqttDisconnect<<4, nil)
+	c.shutdown(fmt.Errorf("mqtt connection closed"))
+}
+
+// shutdown 关闭连接，记录原因并唤醒所有等待方
+func (c *mqttConn) shutdown(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+// closeErr 返回连接断开的原因
+func (c *mqttConn) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// readMQTTPacket 读取一个报文，返回固定头的第一个字节和剩余部分
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, fmt.Errorf("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// writeMQTTString 写入带 2 字节长度前缀的字符串
+func writeMQTTString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
@@ -0,0 +1,595 @@
+// openapi.go
+// OpenAPI 导入模块
+// 本文件负责根据 OpenAPI 3.x 或 Swagger 2.0 文档生成 HTTP 测试计划的骨架（见 plan.go）：每个接口是计划中的一个请求，
+// 计划使用 weighted 模式，权重默认为 1，由用户按真实流量比例调整后即可覆盖整个 API。
+//
+// 技术实现细节：
+// 1. 文档可以是 JSON 或 YAML；接口按路径和方法排序，请求名称为 operationId，没有时为 "方法 路径"，可以按标签过滤。
+// 2. 服务地址写入变量 base_url（文档中的第一个服务地址，可以被选项覆盖），路径参数写入同名变量，
+//    变量值为参数的示例值，切换环境或改用 CSV 数据文件时只需修改变量。
+// 3. 必填的查询参数和请求头参数使用示例值；请求体优先使用文档中的示例，没有时按 schema 生成
+//    （解析 $ref、allOf/oneOf/anyOf，跳过只读字段，字符串按 format 生成），支持 JSON 和表单请求体。
+// 4. 接口的认证要求转换为请求头：Bearer/OAuth2 使用变量 token，Basic 使用变量 basic_auth，API Key 使用变量 api_key，
+//    变量值为占位符，需要用户填写；不支持的参数和请求体类型给出提示。
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultOpenAPIBaseURL 文档中没有可用的服务地址时使用的地址
+const DefaultOpenAPIBaseURL = "http://localhost:8080"
+
+// openAPIPlaceholder 需要用户填写的变量的占位值
+const openAPIPlaceholder = "changeme"
+
+// openAPIMethods 导入的请求方法及其顺序
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIMaxDepth 按 schema 生成示例时的最大嵌套深度
+const openAPIMaxDepth = 8
+
+// OpenAPIOptions OpenAPI 导入选项
+type OpenAPIOptions struct {
+	Name    string   // 测试计划名称，为空时使用文档标题
+	BaseURL string   // 服务地址，为空时使用文档中的第一个服务地址
+	Tags    []string // 只导入带有这些标签的接口，为空时导入全部接口
+}
+
+// openAPIImporter 一次导入的状态
+type openAPIImporter struct {
+	doc      map[string]interface{}
+	swagger2 bool
+	plan     *TestPlan
+	warnings []string
+}
+
+// ImportOpenAPIFile 读取 OpenAPI 文档并生成测试计划，同时返回生成时跳过的内容
+func ImportOpenAPIFile(path string, opts OpenAPIOptions) (*TestPlan, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read OpenAPI document: %v", err)
+	}
+	plan, warnings, err := ImportOpenAPI(data, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if plan.Name == "openapi" && opts.Name == "" {
+		plan.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	plan.Source = filepath.Base(path)
+	return plan, warnings, nil
+}
+
+// ImportOpenAPI 根据 OpenAPI 文档（JSON 或 YAML）生成测试计划，没有可导入的接口时返回错误
+func ImportOpenAPI(data []byte, opts OpenAPIOptions) (*TestPlan, []string, error) {
+	var raw interface{}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse OpenAPI document: %v", err)
+		}
+	} else if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse OpenAPI document: %v", err)
+	}
+	doc := oaMap(oaNormalize(raw))
+	im := &openAPIImporter{doc: doc}
+	switch {
+	case strings.HasPrefix(oaString(doc["openapi"]), "3."):
+	case oaString(doc["swagger"]) == "2.0":
+		im.swagger2 = true
+	default:
+		return nil, nil, fmt.Errorf("not an OpenAPI 3.x or Swagger 2.0 document")
+	}
+
+	im.plan = &TestPlan{Name: opts.Name, Mode: PlanWeighted, Variables: map[string]string{"base_url": im.baseURL(opts.BaseURL)}}
+	if im.plan.Name == "" {
+		im.plan.Name = oaString(oaMap(doc["info"])["title"])
+	}
+	if im.plan.Name == "" {
+		im.plan.Name = "openapi"
+	}
+
+	paths := oaMap(doc["paths"])
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, path := range names {
+		item := im.resolve(oaMap(paths[path]))
+		for _, method := range openAPIMethods {
+			op := oaMap(item[method])
+			if op == nil || !oaTagged(op, opts.Tags) {
+				continue
+			}
+			im.plan.Requests = append(im.plan.Requests, im.operation(path, method, item, op))
+		}
+	}
+	if len(im.plan.Requests) == 0 {
+		return nil, im.warnings, fmt.Errorf("no operations to import from OpenAPI document")
+	}
+	return im.plan, im.warnings, nil
+}
+
+// warn 记录生成时跳过的内容
+func (im *openAPIImporter) warn(format string, args ...interface{}) {
+	im.warnings = append(im.warnings, fmt.Sprintf(format, args...))
+}
+
+// baseURL 返回服务地址，服务地址中的变量使用默认值
+func (im *openAPIImporter) baseURL(override string) string {
+	if override != "" {
+		return strings.TrimSuffix(override, "/")
+	}
+	var base string
+	if im.swagger2 {
+		host := oaString(im.doc["host"])
+		if host == "" {
+			im.warn("document has no host, using %s", DefaultOpenAPIBaseURL)
+			return DefaultOpenAPIBaseURL + strings.TrimSuffix(oaString(im.doc["basePath"]), "/")
+		}
+		scheme := "http"
+		if schemes := oaSlice(im.doc["schemes"]); len(schemes) > 0 {
+			scheme = oaString(schemes[0])
+		}
+		base = scheme + "://" + host + oaString(im.doc["basePath"])
+	} else {
+		servers := oaSlice(im.doc["servers"])
+		if len(servers) == 0 {
+			im.warn("document has no servers, using %s", DefaultOpenAPIBaseURL)
+			return DefaultOpenAPIBaseURL
+		}
+		server := oaMap(servers[0])
+		base = oaString(server["url"])
+		for name, v := range oaMap(server["variables"]) {
+			base = strings.ReplaceAll(base, "{"+name+"}", oaString(oaMap(v)["default"]))
+		}
+		if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+			im.warn("server url %q is relative, using %s as the host", base, DefaultOpenAPIBaseURL)
+			base = DefaultOpenAPIBaseURL + "/" + strings.TrimPrefix(base, "/")
+		}
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// operation 将一个接口转换为计划中的请求
+func (im *openAPIImporter) operation(path, method string, item, op map[string]interface{}) PlanRequest {
+	title := strings.ToUpper(method) + " " + path
+	request := PlanRequest{Name: oaString(op["operationId"]), Method: strings.ToUpper(method), Weight: 1}
+	if request.Name == "" {
+		request.Name = title
+	}
+	headers := make(map[string]string)
+	var query []string
+	var form url.Values
+
+	// 接口级参数覆盖路径级的同名参数
+	params := make(map[string]map[string]interface{})
+	var order []string
+	for _, list := range [][]interface{}{oaSlice(item["parameters"]), oaSlice(op["parameters"])} {
+		for _, p := range list {
+			param := im.resolve(oaMap(p))
+			key := oaString(param["in"]) + ":" + oaString(param["name"])
+			if _, ok := params[key]; !ok {
+				order = append(order, key)
+			}
+			params[key] = param
+		}
+	}
+	for _, key := range order {
+		param := params[key]
+		name, in := oaString(param["name"]), oaString(param["in"])
+		switch in {
+		case "path":
+			if !variableRef.MatchString("${" + name + "}") {
+				path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(oaScalar(im.paramExample(param))))
+				continue
+			}
+			path = strings.ReplaceAll(path, "{"+name+"}", "${"+name+"}")
+			if _, ok := im.plan.Variables[name]; !ok {
+				im.plan.Variables[name] = oaScalar(im.paramExample(param))
+			}
+		case "query":
+			if param["required"] == true {
+				query = append(query, url.QueryEscape(name)+"="+url.QueryEscape(oaScalar(im.paramExample(param))))
+			}
+		case "header":
+			if param["required"] == true {
+				headers[name] = oaScalar(im.paramExample(param))
+			}
+		case "body":
+			request.Body = im.body(title, "application/json", im.example(oaMap(param["schema"]), 0, nil))
+			headers["Content-Type"] = "application/json"
+		case "formData":
+			if oaString(param["type"]) == "file" {
+				im.warn("%s: file parameter %q is not supported", title, name)
+				continue
+			}
+			if form == nil {
+				form = url.Values{}
+			}
+			form.Set(name, oaScalar(im.paramExample(param)))
+		default:
+			im.warn("%s: %s parameter %q is not supported", title, in, name)
+		}
+	}
+	if form != nil {
+		request.Body = form.Encode()
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+	if body := im.resolve(oaMap(op["requestBody"])); body != nil {
+		im.requestBody(title, body, &request, headers)
+	}
+	for name, value := range im.security(op, &query) {
+		headers[name] = value
+	}
+
+	request.URL = "${base_url}" + path
+	if len(query) > 0 {
+		request.URL += "?" + strings.Join(query, "&")
+	}
+	request.URL = escapeTemplate(request.URL)
+	request.Body = escapeTemplate(request.Body)
+	for name, value := range headers {
+		headers[name] = escapeTemplate(value)
+	}
+	if len(headers) > 0 {
+		request.Headers = headers
+	}
+	return request
+}
+
+// requestBody 按 OpenAPI 3 的 requestBody 生成请求体，优先 JSON，其次表单
+func (im *openAPIImporter) requestBody(title string, body map[string]interface{}, request *PlanRequest, headers map[string]string) {
+	content := oaMap(body["content"])
+	types := make([]string, 0, len(content))
+	for contentType := range content {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	choose := ""
+	for _, contentType := range types {
+		if contentType == "application/json" || strings.HasSuffix(contentType, "+json") {
+			choose = contentType
+			break
+		}
+		if contentType == "application/x-www-form-urlencoded" && choose == "" {
+			choose = contentType
+		}
+	}
+	if choose == "" {
+		if len(types) > 0 {
+			im.warn("%s: request body types %s are not supported", title, strings.Join(types, ", "))
+		}
+		return
+	}
+
+	media := oaMap(content[choose])
+	value, ok := media["example"]
+	if !ok {
+		examples := oaMap(media["examples"])
+		keys := make([]string, 0, len(examples))
+		for k := range examples {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			value, ok = im.resolve(oaMap(examples[keys[0]]))["value"]
+		}
+	}
+	if !ok {
+		value = im.example(oaMap(media["schema"]), 0, nil)
+	}
+	request.Body = im.body(title, choose, value)
+	headers["Content-Type"] = choose
+}
+
+// body 按内容类型编码示例值
+func (im *openAPIImporter) body(title, contentType string, value interface{}) string {
+	if contentType == "application/x-www-form-urlencoded" {
+		form := url.Values{}
+		for k, v := range oaMap(value) {
+			form.Set(k, oaScalar(v))
+		}
+		return form.Encode()
+	}
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		im.warn("%s: failed to encode example body: %v", title, err)
+		return ""
+	}
+	return string(data)
+}
+
+// security 将接口的认证要求转换为请求头，API Key 位于查询参数时追加到 query
+func (im *openAPIImporter) security(op map[string]interface{}, query *[]string) map[string]string {
+	requirements, ok := op["security"]
+	if !ok {
+		requirements = im.doc["security"]
+	}
+	list := oaSlice(requirements)
+	if len(list) == 0 {
+		return nil
+	}
+	schemes := oaMap(oaMap(im.doc["components"])["securitySchemes"])
+	if im.swagger2 {
+		schemes = oaMap(im.doc["securityDefinitions"])
+	}
+
+	headers := make(map[string]string)
+	names := make([]string, 0)
+	for name := range oaMap(list[0]) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := im.resolve(oaMap(schemes[name]))
+		kind := oaString(scheme["type"])
+		switch {
+		case kind == "http" && strings.EqualFold(oaString(scheme["scheme"]), "bearer"), kind == "oauth2", kind == "openIdConnect":
+			headers["Authorization"] = "Bearer ${token}"
+			im.placeholder("token")
+		case kind == "basic", kind == "http" && strings.EqualFold(oaString(scheme["scheme"]), "basic"):
+			headers["Authorization"] = "Basic ${basic_auth}"
+			im.placeholder("basic_auth")
+		case kind == "apiKey" && oaString(scheme["in"]) == "header":
+			headers[oaString(scheme["name"])] = "${api_key}"
+			im.placeholder("api_key")
+		case kind == "apiKey" && oaString(scheme["in"]) == "query":
+			*query = append(*query, url.QueryEscape(oaString(scheme["name"]))+"=${api_key}")
+			im.placeholder("api_key")
+		default:
+			im.warn("security scheme %q is not supported", name)
+		}
+	}
+	return headers
+}
+
+// placeholder 添加需要用户填写的变量
+func (im *openAPIImporter) placeholder(name string) {
+	if _, ok := im.plan.Variables[name]; !ok {
+		im.plan.Variables[name] = openAPIPlaceholder
+		im.warn("set variable %q before running the plan", name)
+	}
+}
+
+// paramExample 返回参数的示例值，Swagger 2.0 的参数本身就是 schema
+func (im *openAPIImporter) paramExample(param map[string]interface{}) interface{} {
+	if v, ok := param["example"]; ok {
+		return v
+	}
+	examples := oaMap(param["examples"])
+	keys := make([]string, 0, len(examples))
+	for k := range examples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		if v, ok := im.resolve(oaMap(examples[keys[0]]))["value"]; ok {
+			return v
+		}
+	}
+	if schema := oaMap(param["schema"]); schema != nil {
+		return im.example(schema, 0, nil)
+	}
+	return im.example(param, 0, nil)
+}
+
+// example 按 schema 生成示例值，seen 记录正在展开的引用，避免循环引用
+func (im *openAPIImporter) example(schema map[string]interface{}, depth int, seen map[string]bool) interface{} {
+	if schema == nil || depth > openAPIMaxDepth {
+		return nil
+	}
+	if ref := oaString(schema["$ref"]); ref != "" {
+		if seen[ref] {
+			return nil
+		}
+		next := map[string]bool{ref: true}
+		for k := range seen {
+			next[k] = true
+		}
+		return im.example(im.resolve(schema), depth+1, next)
+	}
+	if v, ok := schema["example"]; ok {
+		return v
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if enum := oaSlice(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if all := oaSlice(schema["allOf"]); len(all) > 0 {
+		merged := make(map[string]interface{})
+		for _, s := range all {
+			for k, v := range oaMap(im.example(oaMap(s), depth+1, seen)) {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := oaSlice(schema[key]); len(options) > 0 {
+			return im.example(oaMap(options[0]), depth+1, seen)
+		}
+	}
+
+	kind := oaString(schema["type"])
+	for _, t := range oaSlice(schema["type"]) {
+		// OpenAPI 3.1 的 type 可以是数组，例如 [string, "null"]
+		if kind = oaString(t); kind != "null" {
+			break
+		}
+	}
+	if kind == "" && schema["properties"] != nil {
+		kind = "object"
+	}
+	switch kind {
+	case "object":
+		obj := make(map[string]interface{})
+		for name, prop := range oaMap(schema["properties"]) {
+			p := oaMap(prop)
+			if p["readOnly"] == true {
+				continue
+			}
+			if v := im.example(p, depth+1, seen); v != nil {
+				obj[name] = v
+			}
+		}
+		return obj
+	case "array":
+		if item := im.example(oaMap(schema["items"]), depth+1, seen); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case "integer":
+		if v, ok := schema["minimum"]; ok {
+			return v
+		}
+		return 1
+	case "number":
+		if v, ok := schema["minimum"]; ok {
+			return v
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		return oaStringExample(oaString(schema["format"]))
+	}
+	return nil
+}
+
+// oaStringExample 按 format 生成字符串示例
+func oaStringExample(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	case "password":
+		return "password"
+	}
+	return "string"
+}
+
+// resolve 解析文档内的 $ref（例如 #/components/schemas/Pet），不是引用时原样返回
+func (im *openAPIImporter) resolve(node map[string]interface{}) map[string]interface{} {
+	for i := 0; i < openAPIMaxDepth; i++ {
+		ref := oaString(node["$ref"])
+		if ref == "" {
+			return node
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			im.warn("external reference %q is not supported", ref)
+			return nil
+		}
+		var target interface{} = im.doc
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			target = oaMap(target)[part]
+		}
+		if target == nil {
+			im.warn("reference %q not found", ref)
+			return nil
+		}
+		node = oaMap(target)
+	}
+	return node
+}
+
+// oaTagged 判断接口是否带有任一指定标签，tags 为空时导入全部接口
+func oaTagged(op map[string]interface{}, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, t := range oaSlice(op["tags"]) {
+		for _, tag := range tags {
+			if strings.EqualFold(oaString(t), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// oaNormalize 将 YAML 解析得到的 map[interface{}]interface{} 转换为 map[string]interface{}
+func oaNormalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = oaNormalize(val)
+		}
+		return m
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = oaNormalize(val)
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = oaNormalize(t[i])
+		}
+		return t
+	}
+	return v
+}
+
+// oaMap 将值转换为对象，不是对象时返回 nil
+func oaMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// oaSlice 将值转换为数组，不是数组时返回 nil
+func oaSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// oaString 将值转换为字符串，不是字符串时返回空字符串
+func oaString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// oaScalar 将示例值转换为参数值，对象和数组编码为 JSON
+func oaScalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,394 @@
+// plan.go
+// HTTP 测试计划模块
+// 本文件负责声明式的 HTTP 测试计划：计划是一组按顺序发送的请求（方法、URL、请求头、请求体和发送前的思考时间），
+// 保存为 YAML 文件，可以由 HAR 文件（见 har.go）、JMeter 脚本（见 jmx.go）或 OpenAPI 文档（见 openapi.go）导入后手工调整，
+// 再由 PlanTask 回放。
+//
+// 技术实现细节：
+// 1. 请求的 URL、请求头和请求体按请求模板解析（见 template.go），可以把录制的固定值改为场景变量或随机数据。
+// 2. PlanTask 在创建时解析全部模板并读取 CSV 数据文件，之后只读，所有 VU 共用；每次 Run 按顺序执行一遍计划，思考时间可以被 ctx 取消。
+// 3. 每个请求的结果写入收集器，名称默认为 "方法 路径"；状态码大于等于 400 或请求出错记为失败，失败后不再执行后续请求。
+// 4. 计划变量作为未定义变量的初始值；每次 Run 从每个数据文件读取下一行写入变量（所有 VU 共用读取位置），
+//    读完后从头开始，设置 stop_at_eof 时返回 ErrPlanDataExhausted。数据文件的相对路径相对于计划文件所在目录。
+// 5. weighted 模式下每次 Run 按权重随机选择一个请求执行（与 RedisTask 的命令组合相同），适合覆盖一组相互独立的接口；
+//    选择使用由全局随机种子派生的随机数生成器，可以复现。
+
+package tasks
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/potatoImp/OpenStress/random"
+	"github.com/potatoImp/OpenStress/result"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 测试计划的执行模式
+const (
+	PlanSequence = "sequence" // 按顺序执行全部请求（默认）
+	PlanWeighted = "weighted" // 按权重随机选择一个请求执行
+)
+
+// ErrPlanDataExhausted 设置了 stop_at_eof 的数据文件已经读完
+var ErrPlanDataExhausted = errors.New("test plan data exhausted")
+
+// PlanRequest 测试计划中的一个请求
+type PlanRequest struct {
+	Name      string            `yaml:"name,omitempty"`       // 报告中的名称，为空时使用 "方法 路径"
+	Method    string            `yaml:"method"`               // 请求方法，为空时为 GET
+	URL       string            `yaml:"url"`                  // 请求 URL（模板）
+	Headers   map[string]string `yaml:"headers,omitempty"`    // 请求头（值为模板）
+	Body      string            `yaml:"body,omitempty"`       // 请求体（模板）
+	ThinkTime time.Duration     `yaml:"think_time,omitempty"` // 发送本请求之前的等待时间
+	Weight    int               `yaml:"weight,omitempty"`     // weighted 模式下的权重，默认 1
+}
+
+// PlanDataSet 测试计划的 CSV 数据文件，每次执行计划读取一行，按列写入变量
+type PlanDataSet struct {
+	File            string   `yaml:"file"`                        // 文件路径，相对路径相对于计划文件所在目录
+	Variables       []string `yaml:"variables,omitempty"`         // 各列对应的变量名，为空时使用文件的第一行
+	Delimiter       string   `yaml:"delimiter,omitempty"`         // 分隔符，为空时为逗号，\t 表示制表符
+	IgnoreFirstLine bool     `yaml:"ignore_first_line,omitempty"` // 是否跳过第一行（指定了 Variables 时的表头）
+	StopAtEOF       bool     `yaml:"stop_at_eof,omitempty"`       // 读完后停止而不是从头开始
+}
+
+// PlanLoad 测试计划建议的负载，例如由 JMeter 线程组导入
+type PlanLoad struct {
+	VUs             int           `yaml:"vus"`                         // 虚拟用户数
+	RampUp          time.Duration `yaml:"ramp_up,omitempty"`           // 全部虚拟用户启动完成的时间
+	IterationsPerVU int           `yaml:"iterations_per_vu,omitempty"` // 每个虚拟用户执行计划的次数，0 表示不限制
+	Duration        time.Duration `yaml:"duration,omitempty"`          // 运行时长，0 表示只按次数停止
+}
+
+// TestPlan HTTP 测试计划
+type TestPlan struct {
+	Name      string            `yaml:"name"`                // 计划名称
+	Source    string            `yaml:"source,omitempty"`    // 计划来源，例如导入的 HAR 文件
+	Mode      string            `yaml:"mode,omitempty"`      // 执行模式：sequence（默认）或 weighted
+	Load      *PlanLoad         `yaml:"load,omitempty"`      // 建议的负载
+	Variables map[string]string `yaml:"variables,omitempty"` // 变量初始值，请求中以 ${name} 引用
+	Data      []PlanDataSet     `yaml:"data,omitempty"`      // CSV 数据文件
+	Requests  []PlanRequest     `yaml:"requests"`            // 按顺序发送的请求
+	Proxy     string            `yaml:"proxy,omitempty"`     // 本计划的请求使用的代理地址，覆盖任务 HTTP 客户端的代理配置（见 TransportConfig.Proxy）
+
+	dir string // 计划文件所在目录，用于解析数据文件的相对路径
+}
+
+// LoadTestPlan 从 YAML 文件加载测试计划
+func LoadTestPlan(path string) (*TestPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test plan: %v", err)
+	}
+	var plan TestPlan
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse test plan: %v", err)
+	}
+	plan.dir = filepath.Dir(path)
+	return &plan, nil
+}
+
+// Save 将测试计划保存为 YAML 文件
+func (p *TestPlan) Save(path string) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode test plan: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write test plan: %v", err)
+	}
+	return nil
+}
+
+// planStep 解析后的一个请求
+type planStep struct {
+	name      string
+	template  *RequestTemplate
+	thinkTime time.Duration
+	weight    int
+}
+
+// PlanTask 回放测试计划的任务，并发安全，所有 VU 共用
+type PlanTask struct {
+	Collector *result.Collector // 结果收集器，为 nil 时不记录结果
+	Client    *http.Client      // 发送请求的客户端，为 nil 时使用 DefaultHTTPClient
+
+	steps       []planStep
+	variables   map[string]string
+	data        []*planData
+	weighted    bool
+	totalWeight int
+
+	rngMu sync.Mutex
+	rng   *rand.Rand // weighted 模式下选择请求，由全局随机种子派生
+}
+
+// planData 读入内存的数据文件，所有 VU 共用读取位置
+type planData struct {
+	names     []string
+	rows      [][]string
+	stopAtEOF bool
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewPlanTask 校验测试计划并解析请求模板
+func NewPlanTask(plan *TestPlan) (*PlanTask, error) {
+	if len(plan.Requests) == 0 {
+		return nil, fmt.Errorf("test plan has no requests")
+	}
+	switch plan.Mode {
+	case "", PlanSequence, PlanWeighted:
+	default:
+		return nil, fmt.Errorf("unsupported test plan mode %q", plan.Mode)
+	}
+	steps := make([]planStep, 0, len(plan.Requests))
+	for i, r := range plan.Requests {
+		if r.URL == "" {
+			return nil, fmt.Errorf("request %d has no url", i)
+		}
+		if r.ThinkTime < 0 {
+			return nil, fmt.Errorf("request %d has a negative think time", i)
+		}
+		if r.Weight < 0 {
+			return nil, fmt.Errorf("request %d has a negative weight", i)
+		}
+		weight := r.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		method := strings.ToUpper(r.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+		tmpl, err := NewRequestTemplate(method, r.URL, r.Body, r.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %v", i, err)
+		}
+		name := r.Name
+		if name == "" {
+			name = method + " " + planPath(r.URL)
+		}
+		steps = append(steps, planStep{name: name, template: tmpl, thinkTime: r.ThinkTime, weight: weight})
+	}
+	task := &PlanTask{steps: steps, variables: plan.Variables}
+	if plan.Mode == PlanWeighted {
+		task.weighted = true
+		for _, step := range steps {
+			task.totalWeight += step.weight
+		}
+		task.rng = random.New("plan-" + plan.Name)
+	}
+	for i, ds := range plan.Data {
+		data, err := loadPlanData(ds, plan.dir)
+		if err != nil {
+			return nil, fmt.Errorf("data set %d: %v", i, err)
+		}
+		task.data = append(task.data, data)
+	}
+	return task, nil
+}
+
+// loadPlanData 读取 CSV 数据文件
+func loadPlanData(ds PlanDataSet, dir string) (*planData, error) {
+	path := ds.File
+	if path == "" {
+		return nil, fmt.Errorf("no file")
+	}
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data file: %v", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	switch ds.Delimiter {
+	case "", ",":
+	case "\\t", "\t":
+		reader.Comma = '\t'
+	default:
+		delimiter := []rune(ds.Delimiter)
+		if len(delimiter) != 1 {
+			return nil, fmt.Errorf("delimiter must be a single character: %q", ds.Delimiter)
+		}
+		reader.Comma = delimiter[0]
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %v", err)
+	}
+
+	data := &planData{names: ds.Variables, stopAtEOF: ds.StopAtEOF}
+	if len(data.names) == 0 || ds.IgnoreFirstLine {
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("data file %s is empty", ds.File)
+		}
+		if len(data.names) == 0 {
+			data.names = rows[0]
+		}
+		rows = rows[1:]
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("data file %s has no rows", ds.File)
+	}
+	data.rows = rows
+	return data, nil
+}
+
+// row 返回下一行数据，读完后从头开始或返回 ErrPlanDataExhausted
+func (d *planData) row() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.next == len(d.rows) {
+		if d.stopAtEOF {
+			return nil, ErrPlanDataExhausted
+		}
+		d.next = 0
+	}
+	row := d.rows[d.next]
+	d.next++
+	return row, nil
+}
+
+// planPath 返回 URL 的路径部分，用作默认的请求名称
+func planPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return rawURL
+	}
+	return u.Path
+}
+
+// Run 按顺序执行一遍测试计划（weighted 模式下按权重执行一个请求），遇到失败的请求时停止；
+// vars 和 faker 可以为 nil（见 RequestTemplate.NewRequest）
+// 计划变量和本次读取的数据行写入 vars，同一个 vars 多次 Run 时保留上一次提取的变量
+// ctx 中没有 VU 编号时以 threadID 作为 VU 编号（见 WithVU），用于按 VU 选择源地址
+func (t *PlanTask) Run(ctx context.Context, vars *Variables, faker *Faker, threadID int) error {
+	if _, ok := VUFrom(ctx); !ok {
+		ctx = WithVU(ctx, threadID)
+	}
+	if vars == nil {
+		vars = NewVariables(nil)
+	}
+	for name, value := range t.variables {
+		if _, ok := vars.Get(name); !ok {
+			vars.Set(name, value)
+		}
+	}
+	for _, data := range t.data {
+		row, err := data.row()
+		if err != nil {
+			return err
+		}
+		for i, name := range data.names {
+			if i < len(row) {
+				vars.Set(name, row[i])
+			}
+		}
+	}
+
+	steps := t.steps
+	if t.weighted {
+		steps = []planStep{t.pick()}
+	}
+	for _, step := range steps {
+		if step.thinkTime > 0 {
+			timer := time.NewTimer(step.thinkTime)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if _, err := t.execute(ctx, step, vars, faker, threadID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pick 按权重选择一个请求
+func (t *PlanTask) pick() planStep {
+	t.rngMu.Lock()
+	n := t.rng.Intn(t.totalWeight)
+	t.rngMu.Unlock()
+	for _, step := range t.steps {
+		if n < step.weight {
+			return step
+		}
+		n -= step.weight
+	}
+	return t.steps[len(t.steps)-1]
+}
+
+// execute 发送一个请求并把结果写入收集器
+func (t *PlanTask) execute(ctx context.Context, step planStep, vars *Variables, faker *Faker, threadID int) (result.ResultData, error) {
+	data := result.ResultData{ID: step.name, Method: step.template.Method, ThreadID: threadID}
+	req, err := step.template.NewRequest(ctx, vars, faker)
+	data.StartTime = time.Now()
+	if err == nil {
+		data.URL = req.URL.String()
+		if req.ContentLength > 0 {
+			data.DataSent = req.ContentLength
+		}
+		var resp *http.Response
+		var timings PhaseTimings
+		resp, timings, err = DoTimed(t.Client, req)
+		data.ApplyPhases(timings)
+		if err == nil {
+			var body []byte
+			body, data.DataReceived, err = ReadBody(resp)
+			data.StatusCode = resp.StatusCode
+			data.ResponseMsg = resp.Status
+			// 配置了收集器时按其成功判定策略判定，否则 4xx 和 5xx 为失败
+			if err == nil && t.Collector != nil {
+				err = t.Collector.EvaluateSuccess(data)
+			} else if err == nil && resp.StatusCode >= 400 {
+				err = fmt.Errorf("request failed with status %d", resp.StatusCode)
+			}
+			if err != nil {
+				data.ResponseBody = string(body)
+			}
+		}
+	}
+	data.EndTime = time.Now()
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		err = fmt.Errorf("%s: %v", step.name, err)
+		data.ErrorMessage = err.Error()
+	}
+	if t.Collector != nil {
+		if data.Type == result.Success {
+			t.Collector.SaveSuccessResult(data)
+		} else {
+			t.Collector.SaveFailureResult(data)
+		}
+	}
+	return data, err
+}
+
+// escapeTemplate 转义导入内容中的模板起始符，回放时按原文发送
+func escapeTemplate(s string) string {
+	return strings.ReplaceAll(s, "{{", `{{"{{"}}`)
+}
@@ -0,0 +1,372 @@
+// redis.go
+// Redis 压测任务模块
+// 本文件负责 Redis 基准测试：按权重混合 GET/SET/INCR/PIPELINE 命令，在配置的键空间内选择键，
+// 按配置的大小生成值，并按命令类型记录延迟和错误。
+//
+// 技术实现细节：
+// 1. 基于 go.mod 中已有的 go-redis 客户端，一个 RedisTask 持有一个 *redis.Client，所有任务共用连接池。
+// 2. 每次 Run 按权重随机选择一条命令执行；结果的 Method（JTL 的 label 列）为命令名，
+//    报告和外部工具可以按命令类型区分，Stats 返回本任务按命令类型汇总的次数、错误和延迟。
+// 3. 键由 KeyPattern 中的 {n} 替换为键编号生成，编号在 [0, KeySpace) 内随机或顺序选择。
+//    值为预先生成的随机字母，长度在 [ValueSize, ValueSizeMax] 内，压测过程中不再分配。
+//    随机数由全局随机种子派生（random.New），设置相同的种子时命令组合、键和值可以复现。
+// 4. GET 未命中（redis.Nil）不算错误，ResponseMsg 记为 miss；PIPELINE 一次发送 PipelineSize 条
+//    PipelineCommand 命令，记为一条结果，其中任意命令失败即为失败。
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/potatoImp/OpenStress/random"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// Redis 命令
+const (
+	RedisGet      = "GET"
+	RedisSet      = "SET"
+	RedisIncr     = "INCR"
+	RedisPipeline = "PIPELINE"
+)
+
+// 键选择方式
+const (
+	KeyRandom     = "random"     // 在键空间内均匀随机
+	KeySequential = "sequential" // 按编号顺序循环
+)
+
+// Redis 任务默认值
+const (
+	DefaultRedisKeyPattern   = "openstress:{n}"
+	DefaultRedisKeySpace     = 1000
+	DefaultRedisValueSize    = 64
+	DefaultRedisPipelineSize = 10
+)
+
+// RedisCommand 命令组合中的一条命令
+type RedisCommand struct {
+	Command         string `yaml:"command"`          // GET、SET、INCR 或 PIPELINE
+	Weight          int    `yaml:"weight"`           // 权重，默认 1
+	PipelineSize    int    `yaml:"pipeline_size"`    // PIPELINE 一次发送的命令数，默认 DefaultRedisPipelineSize
+	PipelineCommand string `yaml:"pipeline_command"` // PIPELINE 中的命令：GET、SET（默认）或 INCR
+}
+
+// RedisConfig Redis 压测配置
+type RedisConfig struct {
+	Addr         string         `yaml:"addr"`     // 地址，例如 127.0.0.1:6379
+	Username     string         `yaml:"username"` // ACL 用户名，可选
+	Password     string         `yaml:"password"`
+	DB           int            `yaml:"db"`
+	PoolSize     int            `yaml:"pool_size"`      // 连接池大小，0 表示使用 go-redis 的默认值
+	DialTimeout  time.Duration  `yaml:"dial_timeout"`   // 0 表示使用 go-redis 的默认值
+	ReadTimeout  time.Duration  `yaml:"read_timeout"`   // 0 表示使用 go-redis 的默认值
+	WriteTimeout time.Duration  `yaml:"write_timeout"`  // 0 表示使用 go-redis 的默认值
+	Commands     []RedisCommand `yaml:"commands"`       // 命令组合，为空时只执行 GET
+	KeyPattern   string         `yaml:"key_pattern"`    // 键模式，{n} 替换为键编号，默认 DefaultRedisKeyPattern
+	KeySpace     int            `yaml:"key_space"`      // 键的数量，默认 DefaultRedisKeySpace
+	KeySelection string         `yaml:"key_selection"`  // 键选择方式：random（默认）或 sequential
+	ValueSize    int            `yaml:"value_size"`     // SET 的值大小（字节），默认 DefaultRedisValueSize
+	ValueSizeMax int            `yaml:"value_size_max"` // 大于 ValueSize 时值大小在两者之间随机
+	TTL          time.Duration  `yaml:"ttl"`            // SET 的过期时间，0 表示不过期
+}
+
+// RedisCommandStats 一种命令的汇总
+type RedisCommandStats struct {
+	Count        int
+	Errors       int
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// AvgLatency 平均延迟
+func (s RedisCommandStats) AvgLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+// RedisTask Redis 压测任务，并发安全，应在任务之间共用以共享连接池
+type RedisTask struct {
+	Collector *result.Collector // 结果收集器，为 nil 时不记录结果
+	Client    *redis.Client     // 共享的客户端
+
+	cfg         RedisConfig
+	totalWeight int
+	values      []byte // 预先生成的值，长度为 ValueSizeMax
+	nextKey     atomic.Int64
+
+	rngMu sync.Mutex
+	rng   *rand.Rand // 由全局随机种子派生，命令选择、随机键和值长度可复现
+
+	mu    sync.Mutex
+	stats map[string]RedisCommandStats
+}
+
+// NewRedisTask 校验配置并创建 Redis 压测任务，不会立即建立连接
+func NewRedisTask(cfg RedisConfig) (*RedisTask, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("redis addr is required")
+	}
+	if len(cfg.Commands) == 0 {
+		cfg.Commands = []RedisCommand{{Command: RedisGet}}
+	}
+	task := &RedisTask{stats: make(map[string]RedisCommandStats)}
+	for i := range cfg.Commands {
+		c := &cfg.Commands[i]
+		c.Command = strings.ToUpper(c.Command)
+		if !validRedisCommand(c.Command) {
+			return nil, fmt.Errorf("unsupported redis command %q", c.Command)
+		}
+		if c.Weight < 0 {
+			return nil, fmt.Errorf("redis command %s has a negative weight", c.Command)
+		}
+		if c.Weight == 0 {
+			c.Weight = 1
+		}
+		if c.Command == RedisPipeline {
+			c.PipelineCommand = strings.ToUpper(c.PipelineCommand)
+			if c.PipelineCommand == "" {
+				c.PipelineCommand = RedisSet
+			}
+			if c.PipelineCommand == RedisPipeline || !validRedisCommand(c.PipelineCommand) {
+				return nil, fmt.Errorf("unsupported pipeline command %q", c.PipelineCommand)
+			}
+			if c.PipelineSize <= 0 {
+				c.PipelineSize = DefaultRedisPipelineSize
+			}
+		}
+		task.totalWeight += c.Weight
+	}
+	if cfg.KeyPattern == "" {
+		cfg.KeyPattern = DefaultRedisKeyPattern
+	}
+	if !strings.Contains(cfg.KeyPattern, "{n}") {
+		return nil, fmt.Errorf("key pattern %q must contain {n}", cfg.KeyPattern)
+	}
+	if cfg.KeySpace <= 0 {
+		cfg.KeySpace = DefaultRedisKeySpace
+	}
+	switch cfg.KeySelection {
+	case "":
+		cfg.KeySelection = KeyRandom
+	case KeyRandom, KeySequential:
+	default:
+		return nil, fmt.Errorf("unsupported key selection %q", cfg.KeySelection)
+	}
+	if cfg.ValueSize <= 0 {
+		cfg.ValueSize = DefaultRedisValueSize
+	}
+	if cfg.ValueSizeMax < cfg.ValueSize {
+		cfg.ValueSizeMax = cfg.ValueSize
+	}
+
+	task.cfg = cfg
+	task.rng = random.New("redis")
+	task.values = make([]byte, cfg.ValueSizeMax)
+	for i := range task.values {
+		task.values[i] = byte('a' + task.rng.Intn(26))
+	}
+	task.Client = redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	})
+	return task, nil
+}
+
+// validRedisCommand 判断是否为支持的命令
+func validRedisCommand(command string) bool {
+	switch command {
+	case RedisGet, RedisSet, RedisIncr, RedisPipeline:
+		return true
+	}
+	return false
+}
+
+// Run 按权重选择一条命令执行并记录结果
+func (r *RedisTask) Run(ctx context.Context, threadID int) error {
+	pick := r.intn(r.totalWeight)
+	for _, c := range r.cfg.Commands {
+		if pick < c.Weight {
+			_, err := r.Execute(ctx, c, threadID)
+			return err
+		}
+		pick -= c.Weight
+	}
+	return nil
+}
+
+// Execute 执行一条命令并把结果写入收集器
+func (r *RedisTask) Execute(ctx context.Context, c RedisCommand, threadID int) (result.ResultData, error) {
+	data := result.ResultData{
+		Method:    c.Command,
+		URL:       "redis://" + r.cfg.Addr,
+		ThreadID:  threadID,
+		StartTime: time.Now(),
+	}
+	var err error
+	if c.Command == RedisPipeline {
+		data.ID = fmt.Sprintf("%s %s x%d", RedisPipeline, c.PipelineCommand, c.PipelineSize)
+		err = r.pipeline(ctx, c, &data)
+	} else {
+		key := r.key()
+		data.ID = c.Command + " " + key
+		err = r.command(ctx, c.Command, key, &data)
+	}
+	data.EndTime = time.Now()
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		err = fmt.Errorf("%s: %v", data.ID, err)
+		data.ErrorMessage = err.Error()
+	}
+	r.recordStats(data)
+	if r.Collector != nil {
+		if data.Type == result.Success {
+			r.Collector.SaveSuccessResult(data)
+		} else {
+			r.Collector.SaveFailureResult(data)
+		}
+	}
+	return data, err
+}
+
+// command 执行单条命令
+func (r *RedisTask) command(ctx context.Context, command, key string, data *result.ResultData) error {
+	switch command {
+	case RedisGet:
+		data.DataSent = int64(len(key))
+		value, err := r.Client.Get(ctx, key).Result()
+		if err == redis.Nil {
+			data.ResponseMsg = "miss"
+			return nil
+		}
+		data.DataReceived = int64(len(value))
+		return err
+	case RedisSet:
+		value := r.value()
+		data.DataSent = int64(len(key) + len(value))
+		return r.Client.Set(ctx, key, value, r.cfg.TTL).Err()
+	default:
+		data.DataSent = int64(len(key))
+		value, err := r.Client.Incr(ctx, key).Result()
+		data.ResponseMsg = strconv.FormatInt(value, 10)
+		return err
+	}
+}
+
+// pipeline 在一个管道中发送 PipelineSize 条命令
+func (r *RedisTask) pipeline(ctx context.Context, c RedisCommand, data *result.ResultData) error {
+	pipe := r.Client.Pipeline()
+	for i := 0; i < c.PipelineSize; i++ {
+		key := r.key()
+		data.DataSent += int64(len(key))
+		switch c.PipelineCommand {
+		case RedisGet:
+			pipe.Get(ctx, key)
+		case RedisSet:
+			value := r.value()
+			data.DataSent += int64(len(value))
+			pipe.Set(ctx, key, value, r.cfg.TTL)
+		default:
+			pipe.Incr(ctx, key)
+		}
+	}
+	cmds, err := pipe.Exec(ctx)
+	var errs []error
+	var misses int
+	for _, cmd := range cmds {
+		if cmd.Err() == redis.Nil {
+			misses++
+			continue
+		}
+		if cmd.Err() != nil {
+			errs = append(errs, cmd.Err())
+			continue
+		}
+		if get, ok := cmd.(*redis.StringCmd); ok {
+			data.DataReceived += int64(len(get.Val()))
+		}
+	}
+	if misses > 0 {
+		data.ResponseMsg = fmt.Sprintf("%d misses", misses)
+	}
+	if len(errs) == 0 && err != nil && err != redis.Nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// key 按键选择方式生成下一个键
+func (r *RedisTask) key() string {
+	var n int64
+	if r.cfg.KeySelection == KeySequential {
+		n = (r.nextKey.Add(1) - 1) % int64(r.cfg.KeySpace)
+	} else {
+		n = int64(r.intn(r.cfg.KeySpace))
+	}
+	return strings.ReplaceAll(r.cfg.KeyPattern, "{n}", strconv.FormatInt(n, 10))
+}
+
+// value 返回一个随机长度的值
+func (r *RedisTask) value() string {
+	size := r.cfg.ValueSize
+	if r.cfg.ValueSizeMax > size {
+		size += r.intn(r.cfg.ValueSizeMax - size + 1)
+	}
+	return string(r.values[:size])
+}
+
+// intn 返回 [0, n) 内的随机数
+func (r *RedisTask) intn(n int) int {
+	r.rngMu.Lock()
+	defer r.rngMu.Unlock()
+	return r.rng.Intn(n)
+}
+
+// recordStats 按命令类型汇总
+func (r *RedisTask) recordStats(data result.ResultData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats[data.Method]
+	s.Count++
+	if data.Type != result.Success {
+		s.Errors++
+	}
+	s.TotalLatency += data.ResponseTime
+	s.MaxLatency = max(s.MaxLatency, data.ResponseTime)
+	r.stats[data.Method] = s
+}
+
+// Stats 返回按命令类型汇总的次数、错误和延迟
+func (r *RedisTask) Stats() map[string]RedisCommandStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]RedisCommandStats, len(r.stats))
+	for command, s := range r.stats {
+		stats[command] = s
+	}
+	return stats
+}
+
+// Close 关闭客户端
+func (r *RedisTask) Close() error {
+	return r.Client.Close()
+}
@@ -0,0 +1,449 @@
+// signer.go
+// 请求签名模块
+// 本文件负责为压测请求生成签名，支持 AWS SigV4 与通用 HMAC 头签名方案。
+//
+// 技术实现细节：
+// 1. RequestSigner 接口统一签名行为，每个场景可配置独立的签名器。
+// 2. 凭证通过 SecretStore 获取（环境变量或 YAML 密钥文件），避免硬编码在场景中。
+// 3. SigningTransport 包装 http.RoundTripper，对每个请求在发送前自动签名。
+
+package tasks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RequestSigner 请求签名接口
+type RequestSigner interface {
+	// Sign 对请求进行签名，body 为请求体内容（可能为空）
+	Sign(req *http.Request, body []byte) error
+}
+
+// SecretStore 密钥存储接口
+type SecretStore interface {
+	GetSecret(name string) (string, error)
+}
+
+// EnvSecretStore 从环境变量读取密钥
+type EnvSecretStore struct {
+	Prefix string // 环境变量前缀，例如 "OPENSTRESS_SECRET_"
+}
+
+// GetSecret 获取密钥
+func (s *EnvSecretStore) GetSecret(name string) (string, error) {
+	key := s.Prefix + name
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in environment (%s)", name, key)
+	}
+	return value, nil
+}
+
+// FileSecretStore 从 YAML 文件读取密钥（name: value 形式）
+type FileSecretStore struct {
+	secrets map[string]string
+}
+
+// NewFileSecretStore 加载 YAML 密钥文件
+func NewFileSecretStore(path string) (*FileSecretStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %v", err)
+	}
+
+	secrets := make(map[string]string)
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file: %v", err)
+	}
+	return &FileSecretStore{secrets: secrets}, nil
+}
+
+// GetSecret 获取密钥
+func (s *FileSecretStore) GetSecret(name string) (string, error) {
+	value, ok := s.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in secrets file", name)
+	}
+	return value, nil
+}
+
+// SignerConfig 场景签名配置
+type SignerConfig struct {
+	Type          string   `yaml:"type"`           // 签名类型：aws-sigv4 / hmac
+	Region        string   `yaml:"region"`         // AWS 区域
+	Service       string   `yaml:"service"`        // AWS 服务名
+	AccessKeyRef  string   `yaml:"access_key_ref"` // 访问密钥ID在 SecretStore 中的名称
+	SecretKeyRef  string   `yaml:"secret_key_ref"` // 访问密钥在 SecretStore 中的名称
+	TokenRef      string   `yaml:"token_ref"`      // 会话令牌在 SecretStore 中的名称（可选）
+	Algorithm     string   `yaml:"algorithm"`      // HMAC 算法：sha1 / sha256 / sha512
+	HeaderName    string   `yaml:"header_name"`    // HMAC 签名写入的请求头
+	Scheme        string   `yaml:"scheme"`         // HMAC 签名头前缀
+	SignedHeaders []string `yaml:"signed_headers"` // 参与 HMAC 签名的请求头
+}
+
+// NewSignerFromConfig 根据场景配置和密钥存储创建签名器
+func NewSignerFromConfig(cfg SignerConfig, store SecretStore) (RequestSigner, error) {
+	if store == nil {
+		return nil, fmt.Errorf("secret store is required")
+	}
+
+	keyID, err := store.GetSecret(cfg.AccessKeyRef)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := store.GetSecret(cfg.SecretKeyRef)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case "aws-sigv4":
+		signer := &AWSSigV4Signer{
+			Region:          cfg.Region,
+			Service:         cfg.Service,
+			AccessKeyID:     keyID,
+			SecretAccessKey: secret,
+		}
+		if cfg.TokenRef != "" {
+			if signer.SessionToken, err = store.GetSecret(cfg.TokenRef); err != nil {
+				return nil, err
+			}
+		}
+		return signer, nil
+	case "hmac":
+		hashFn, err := hmacHashFunc(cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		return &HMACSigner{
+			KeyID:         keyID,
+			Secret:        []byte(secret),
+			Hash:          hashFn,
+			HeaderName:    cfg.HeaderName,
+			Scheme:        cfg.Scheme,
+			SignedHeaders: cfg.SignedHeaders,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signer type: %s", cfg.Type)
+	}
+}
+
+// hmacHashFunc 根据算法名返回哈希函数
+func hmacHashFunc(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported hmac algorithm: %s", algorithm)
+	}
+}
+
+// AWSSigV4Signer AWS Signature Version 4 签名器
+type AWSSigV4Signer struct {
+	Region          string
+	Service         string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Now             func() time.Time // 用于测试时固定时间，默认 time.Now
+}
+
+// Sign 按 SigV4 规范对请求签名
+func (s *AWSSigV4Signer) Sign(req *http.Request, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	dateStamp := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Service == "s3" {
+		// S3 要求携带负载哈希，其他服务不需要
+		req.Header.Set("X-Amz-Content-Sha256", hashHex(body))
+	}
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	canonicalRequest, signedHeaders := s.canonicalRequest(req, body)
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, s.Region, s.Service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSum(sha256.New, []byte("AWS4"+s.SecretAccessKey), []byte(dateStamp))
+	signingKey = hmacSum(sha256.New, signingKey, []byte(s.Region))
+	signingKey = hmacSum(sha256.New, signingKey, []byte(s.Service))
+	signingKey = hmacSum(sha256.New, signingKey, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSum(sha256.New, signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// CanonicalRequest 返回请求的 SigV4 规范请求，服务端返回 SignatureDoesNotMatch 时可用于比对
+func (s *AWSSigV4Signer) CanonicalRequest(req *http.Request, body []byte) string {
+	canonicalRequest, _ := s.canonicalRequest(req, body)
+	return canonicalRequest
+}
+
+// canonicalRequest 构造规范请求，返回规范请求和参与签名的请求头列表
+func (s *AWSSigV4Signer) canonicalRequest(req *http.Request, body []byte) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	// 构造规范请求头，host 必须参与签名
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "authorization" || lower == "user-agent" {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[lower] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	return strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL, s.Service != "s3"),
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n"), signedHeaders
+}
+
+// canonicalURI 返回 SigV4 规范路径：每个路径段按 RFC 3986 编码。
+// 除 S3 外的服务需要先规范化路径（去掉 . 和 .. 段、合并重复的 /），并且每个路径段编码两次
+func canonicalURI(u *url.URL, normalize bool) string {
+	p := u.Path
+	if p == "" {
+		return "/"
+	}
+	if normalize {
+		trailing := strings.HasSuffix(p, "/")
+		p = path.Clean("/" + p)
+		if trailing && p != "/" {
+			p += "/"
+		}
+	}
+
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segment = awsEscape(segment)
+		if normalize {
+			segment = awsEscape(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery 返回 SigV4 规范查询字符串（按键排序并编码）
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape 按 RFC 3986 编码（空格编码为 %20）
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// HMACSigner 通用 HMAC 请求头签名器
+//
+// 签名字符串格式：
+//
+//	METHOD\nPATH?QUERY\nTIMESTAMP\nheader1:value1\n...\nHEX(SHA256(body))
+//
+// 签名结果以 `<Scheme> keyId="...",headers="...",signature="..."` 形式写入 HeaderName 指定的请求头。
+type HMACSigner struct {
+	KeyID           string
+	Secret          []byte
+	Hash            func() hash.Hash // 默认 sha256
+	HeaderName      string           // 默认 Authorization
+	Scheme          string           // 默认 HMAC-SHA256
+	TimestampHeader string           // 默认 X-Timestamp
+	SignedHeaders   []string         // 参与签名的请求头
+	Now             func() time.Time
+}
+
+// Sign 计算 HMAC 签名并写入请求头
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	if len(s.Secret) == 0 {
+		return fmt.Errorf("hmac secret is empty")
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := fmt.Sprintf("%d", now().Unix())
+	req.Header.Set(s.timestampHeader(), timestamp)
+
+	req.Header.Set(s.headerName(), s.authorization(req, body, timestamp))
+	return nil
+}
+
+// Verify 校验请求上的 HMAC 签名，用于在模拟服务端验证签名或排查签名问题
+func (s *HMACSigner) Verify(req *http.Request, body []byte) error {
+	if len(s.Secret) == 0 {
+		return fmt.Errorf("hmac secret is empty")
+	}
+	timestamp := req.Header.Get(s.timestampHeader())
+	if timestamp == "" {
+		return fmt.Errorf("missing %s header", s.timestampHeader())
+	}
+	got := req.Header.Get(s.headerName())
+	want := s.authorization(req, body, timestamp)
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		return fmt.Errorf("hmac signature mismatch")
+	}
+	return nil
+}
+
+// authorization 计算签名并返回签名请求头的值
+func (s *HMACSigner) authorization(req *http.Request, body []byte, timestamp string) string {
+	hashFn := s.Hash
+	if hashFn == nil {
+		hashFn = sha256.New
+	}
+	scheme := s.Scheme
+	if scheme == "" {
+		scheme = "HMAC-SHA256"
+	}
+
+	lines := []string{req.Method, req.URL.RequestURI(), timestamp}
+	for _, name := range s.SignedHeaders {
+		lines = append(lines, strings.ToLower(name)+":"+req.Header.Get(name))
+	}
+	lines = append(lines, hashHex(body))
+
+	signature := base64.StdEncoding.EncodeToString(hmacSum(hashFn, s.Secret, []byte(strings.Join(lines, "\n"))))
+	return fmt.Sprintf(`%s keyId="%s",headers="%s",signature="%s"`,
+		scheme, s.KeyID, strings.ToLower(strings.Join(s.SignedHeaders, " ")), signature)
+}
+
+// headerName 返回签名写入的请求头，默认 Authorization
+func (s *HMACSigner) headerName() string {
+	if s.HeaderName == "" {
+		return "Authorization"
+	}
+	return s.HeaderName
+}
+
+// timestampHeader 返回时间戳请求头，默认 X-Timestamp
+func (s *HMACSigner) timestampHeader() string {
+	if s.TimestampHeader == "" {
+		return "X-Timestamp"
+	}
+	return s.TimestampHeader
+}
+
+// SigningTransport 在发送请求前自动签名的 RoundTripper
+type SigningTransport struct {
+	Base   http.RoundTripper
+	Signer RequestSigner
+}
+
+// NewSigningTransport 创建签名 RoundTripper，base 为空时使用 http.DefaultTransport
+func NewSigningTransport(base http.RoundTripper, signer RequestSigner) *SigningTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &SigningTransport{Base: base, Signer: signer}
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip 不应修改原始请求，克隆后再签名
+	signed := req.Clone(req.Context())
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body for signing: %v", err)
+		}
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.ContentLength = int64(len(body))
+	}
+
+	if err := t.Signer.Sign(signed, body); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %v", err)
+	}
+	return t.Base.RoundTrip(signed)
+}
+
+// hashHex 计算 SHA256 并返回十六进制字符串
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSum 计算 HMAC
+func hmacSum(hashFn func() hash.Hash, key, data []byte) []byte {
+	mac := hmac.New(hashFn, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,275 @@
+// socket.go
+// TCP/UDP 压测任务模块
+// 本文件负责非 HTTP 服务的压测：建立 N 个 TCP 连接（或 UDP 套接字），发送配置的报文，
+// 可选地等待匹配的响应，并把连接耗时、收发字节数和错误写入收集器。
+//
+// 技术实现细节：
+// 1. 报文支持三种格式：text 原样发送、hex 十六进制（可包含空白）、template 使用 text/template 按连接和序号生成。
+// 2. 配置了响应匹配（正则表达式）时持续读取直到匹配、超时或超过 MaxResponseBytes；UDP 每次只读取一个数据报。
+//    没有配置响应匹配时只发送，不读取响应。Go 的正则按 UTF-8 匹配，二进制协议可开启 ResponseMatchHex，
+//    将响应编码为小写十六进制文本后再匹配。
+// 3. 每次发送记为一条结果，连接耗时只记在连接的第一条结果上（与 HTTP 复用连接时一致）；
+//    建立连接失败也记为一条失败结果。
+// 4. context 取消时关闭连接，正在进行的读写立即返回。
+
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// 报文格式
+const (
+	PayloadText     = "text"     // 原样发送
+	PayloadHex      = "hex"      // 十六进制，可包含空白，例如 "0a 0b 0c"
+	PayloadTemplate = "template" // text/template 模板，数据为 PayloadData
+)
+
+// 套接字任务默认值
+const (
+	DefaultSocketTimeout       = 5 * time.Second
+	DefaultMaxResponseBytes    = 64 << 10
+	defaultSocketReadChunkSize = 4 << 10
+)
+
+// SocketConfig TCP/UDP 压测配置
+type SocketConfig struct {
+	Network          string        `yaml:"network"`            // tcp 或 udp
+	Address          string        `yaml:"address"`            // 目标地址，例如 10.0.0.1:9000
+	Connections      int           `yaml:"connections"`        // 每次 Run 建立的连接数，默认 1
+	Messages         int           `yaml:"messages"`           // 每个连接发送的报文数，默认 1
+	Payload          string        `yaml:"payload"`            // 报文内容
+	PayloadFormat    string        `yaml:"payload_format"`     // 报文格式：text（默认）、hex 或 template
+	ResponseMatch    string        `yaml:"response_match"`     // 响应匹配的正则表达式，为空时不等待响应
+	ResponseMatchHex bool          `yaml:"response_match_hex"` // 将响应编码为小写十六进制文本后再匹配，适用于二进制协议
+	MaxResponseBytes int           `yaml:"max_response_bytes"` // 等待响应时最多读取的字节数，默认 DefaultMaxResponseBytes
+	ConnectTimeout   time.Duration `yaml:"connect_timeout"`    // 连接超时，默认 DefaultSocketTimeout
+	ReadTimeout      time.Duration `yaml:"read_timeout"`       // 等待响应的超时，默认 DefaultSocketTimeout
+	WriteTimeout     time.Duration `yaml:"write_timeout"`      // 发送超时，默认 DefaultSocketTimeout
+}
+
+// PayloadData 报文模板的数据
+type PayloadData struct {
+	ThreadID int       // Run 传入的线程编号
+	Conn     int       // 连接序号，从 0 开始
+	Seq      int       // 连接内的报文序号，从 0 开始
+	Time     time.Time // 生成报文的时间
+}
+
+// SocketTask TCP/UDP 压测任务，并发安全，应在任务之间共用
+type SocketTask struct {
+	Collector *result.Collector // 结果收集器，为 nil 时不记录结果
+
+	cfg      SocketConfig
+	payload  []byte
+	template *template.Template
+	match    *regexp.Regexp
+}
+
+// NewSocketTask 校验配置并创建 TCP/UDP 压测任务
+func NewSocketTask(cfg SocketConfig) (*SocketTask, error) {
+	cfg.Network = strings.ToLower(cfg.Network)
+	if cfg.Network != "tcp" && cfg.Network != "udp" {
+		return nil, fmt.Errorf("unsupported socket network %q, expected tcp or udp", cfg.Network)
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("socket address is required")
+	}
+	if cfg.Connections <= 0 {
+		cfg.Connections = 1
+	}
+	if cfg.Messages <= 0 {
+		cfg.Messages = 1
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = DefaultSocketTimeout
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = DefaultSocketTimeout
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultSocketTimeout
+	}
+
+	task := &SocketTask{cfg: cfg}
+	switch strings.ToLower(cfg.PayloadFormat) {
+	case "", PayloadText:
+		task.payload = []byte(cfg.Payload)
+	case PayloadHex:
+		payload, err := hex.DecodeString(strings.Join(strings.Fields(cfg.Payload), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex payload: %v", err)
+		}
+		task.payload = payload
+	case PayloadTemplate:
+		tmpl, err := template.New("payload").Parse(cfg.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload template: %v", err)
+		}
+		task.template = tmpl
+	default:
+		return nil, fmt.Errorf("unsupported payload format %q", cfg.PayloadFormat)
+	}
+	if cfg.ResponseMatch != "" {
+		match, err := regexp.Compile(cfg.ResponseMatch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response match: %v", err)
+		}
+		task.match = match
+	}
+	return task, nil
+}
+
+// Run 建立 Connections 个连接，每个连接发送 Messages 个报文，返回所有失败合并后的错误
+func (s *SocketTask) Run(ctx context.Context, threadID int) error {
+	errs := make([]error, s.cfg.Connections)
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Connections; i++ {
+		wg.Add(1)
+		go func(conn int) {
+			defer wg.Done()
+			errs[conn] = s.runConn(ctx, threadID, conn)
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runConn 建立一个连接并依次发送报文，遇到错误时停止
+func (s *SocketTask) runConn(ctx context.Context, threadID, connIndex int) error {
+	start := time.Now()
+	dialer := net.Dialer{Timeout: s.cfg.ConnectTimeout}
+	conn, err := dialer.DialContext(ctx, s.cfg.Network, s.cfg.Address)
+	connect := time.Since(start)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to %s: %v", s.cfg.Address, err)
+		s.record(s.newResult(threadID, start, connect), err)
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for seq := 0; seq < s.cfg.Messages; seq++ {
+		if seq > 0 {
+			// 连接耗时只计入第一条结果
+			start, connect = time.Now(), 0
+		}
+		data := s.newResult(threadID, start, connect)
+		payload, err := s.render(PayloadData{ThreadID: threadID, Conn: connIndex, Seq: seq, Time: time.Now()})
+		if err == nil {
+			data.DataSent, data.DataReceived, err = s.exchange(conn, payload)
+		}
+		if err != nil && ctx.Err() != nil {
+			err = fmt.Errorf("%v: %v", ctx.Err(), err)
+		}
+		s.record(data, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render 生成报文
+func (s *SocketTask) render(data PayloadData) ([]byte, error) {
+	if s.template == nil {
+		return s.payload, nil
+	}
+	var buf bytes.Buffer
+	if err := s.template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render payload: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exchange 发送报文，配置了响应匹配时读取响应直到匹配
+func (s *SocketTask) exchange(conn net.Conn, payload []byte) (int64, int64, error) {
+	conn.SetWriteDeadline(time.Now().Add(s.cfg.WriteTimeout))
+	sent, err := conn.Write(payload)
+	if err != nil {
+		return int64(sent), 0, fmt.Errorf("failed to send payload: %v", err)
+	}
+	if s.match == nil {
+		return int64(sent), 0, nil
+	}
+
+	conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
+	var response []byte
+	chunk := make([]byte, defaultSocketReadChunkSize)
+	if s.cfg.Network == "udp" {
+		// UDP 一次读取一个完整的数据报
+		chunk = make([]byte, s.cfg.MaxResponseBytes)
+	}
+	for {
+		n, err := conn.Read(chunk)
+		response = append(response, chunk[:n]...)
+		if s.matches(response) {
+			return int64(sent), int64(len(response)), nil
+		}
+		if err != nil {
+			return int64(sent), int64(len(response)), fmt.Errorf("response did not match %q: %v", s.cfg.ResponseMatch, err)
+		}
+		if s.cfg.Network == "udp" || len(response) >= s.cfg.MaxResponseBytes {
+			return int64(sent), int64(len(response)), fmt.Errorf("response did not match %q", s.cfg.ResponseMatch)
+		}
+	}
+}
+
+// matches 判断响应是否匹配
+func (s *SocketTask) matches(response []byte) bool {
+	if s.cfg.ResponseMatchHex {
+		return s.match.MatchString(hex.EncodeToString(response))
+	}
+	return s.match.Match(response)
+}
+
+// newResult 创建一条结果，connect 为本条结果包含的连接耗时
+func (s *SocketTask) newResult(threadID int, start time.Time, connect time.Duration) result.ResultData {
+	data := result.ResultData{
+		ID:        s.cfg.Network + "://" + s.cfg.Address,
+		Method:    strings.ToUpper(s.cfg.Network),
+		URL:       s.cfg.Network + "://" + s.cfg.Address,
+		ThreadID:  threadID,
+		StartTime: start,
+		Connect:   connect.Milliseconds(),
+	}
+	if s.cfg.Network == "tcp" {
+		data.TCPConnect = connect
+	}
+	return data
+}
+
+// record 结束计时并把结果写入收集器
+func (s *SocketTask) record(data result.ResultData, err error) {
+	data.EndTime = time.Now()
+	data.ResponseTime = data.EndTime.Sub(data.StartTime)
+	data.Type = result.Success
+	if err != nil {
+		data.Type = result.FailureType(err)
+		data.ErrorMessage = err.Error()
+	}
+	if s.Collector == nil {
+		return
+	}
+	if data.Type == result.Success {
+		s.Collector.SaveSuccessResult(data)
+	} else {
+		s.Collector.SaveFailureResult(data)
+	}
+}
@@ -0,0 +1,70 @@
+// sourceaddr.go
+// 源地址绑定模块
+// 本文件负责把发出的连接绑定到一组本地源 IP，使目标负载均衡器看到分散的客户端地址，
+// 避免所有流量来自同一个 IP 而被按源地址哈希到同一个后端或触发单 IP 限流。
+//
+// 技术实现细节：
+// 1. 源 IP 必须是本机网卡上已配置的地址，建立连接时通过 net.Dialer.LocalAddr 绑定，不会伪造数据包的源地址。
+// 2. 请求的 context 中带有 VU 编号（WithVU，VU 模式下由协程池和 PlanTask 设置）时，第 n 个 VU 固定使用
+//    第 n % len(SourceIPs) 个源 IP；没有 VU 编号时按连接轮询。
+// 3. 源地址在建立连接时选择，keep-alive 连接在 VU 之间复用时沿用建立时的源地址，各源 IP 上的连接数仍然均匀；
+//    需要每个 VU 严格使用自己的源地址时可以同时设置 disable_keep_alives。
+// 4. 源 IP 与目标地址族不同（例如 IPv4 源地址连接 IPv6 目标）时，建立连接会失败。
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+)
+
+// vuContextKey VU 编号在 context.Context 中的键
+type vuContextKey struct{}
+
+// WithVU 把虚拟用户编号放入 context.Context，绑定多个源 IP 时按 VU 选择源地址
+func WithVU(ctx context.Context, vu int) context.Context {
+	return context.WithValue(ctx, vuContextKey{}, vu)
+}
+
+// VUFrom 从 context.Context 中取出虚拟用户编号
+func VUFrom(ctx context.Context) (int, bool) {
+	vu, ok := ctx.Value(vuContextKey{}).(int)
+	return vu, ok
+}
+
+// parseSourceIPs 校验并解析源 IP 列表
+func parseSourceIPs(addrs []string) ([]net.IP, error) {
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source ip %q", addr)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// sourceDialer 从一组源 IP 中为每个连接选择本地地址
+type sourceDialer struct {
+	dialer net.Dialer
+	ips    []net.IP
+	next   atomic.Uint64 // 没有 VU 编号时轮询的下一个源 IP
+}
+
+// pick 选择连接使用的源 IP
+func (d *sourceDialer) pick(ctx context.Context) net.IP {
+	if vu, ok := VUFrom(ctx); ok && vu >= 0 {
+		return d.ips[vu%len(d.ips)]
+	}
+	return d.ips[(d.next.Add(1)-1)%uint64(len(d.ips))]
+}
+
+// DialContext 绑定源地址后建立连接
+func (d *sourceDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := d.dialer
+	dialer.LocalAddr = &net.TCPAddr{IP: d.pick(ctx)}
+	return dialer.DialContext(ctx, network, addr)
+}
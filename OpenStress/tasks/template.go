@@ -0,0 +1,249 @@
+// template.go
+// 请求模板模块
+// 本文件负责按模板在每次迭代时生成请求的 URL、请求体和请求头，模板中可以使用场景变量和随机数据函数（faker），
+// 不再需要预先生成大量 CSV 数据文件。
+//
+// 技术实现细节：
+// 1. 模板为 Go text/template 语法，创建时解析一次，每次迭代渲染一次；模板数据为当前的场景变量，
+//    例如 {{.orderId}}，引用未定义的变量时返回错误。渲染后的文本再按 Variables.Expand 展开 ${name}。
+// 2. 随机数据函数由 Faker 提供：uuid、firstName、lastName、name、email、phone、now、unix、unixMilli、
+//    randInt、randFloat、randString、randHex、randChoice、randBool。now 返回 time.Time，
+//    可以直接写 {{now.Format "2006-01-02"}}。
+// 3. Faker 使用调用方传入的随机数生成器，VU 模式下通常传入 VUContext.Rand，相同随机种子下生成的数据可以复现；
+//    Faker 不是并发安全的，每个 VU 使用各自的 Faker。
+// 4. 模板解析后只读，同一个 RequestTemplate 可以被所有 VU 共用；渲染时克隆模板并绑定当前 VU 的 Faker。
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/potatoImp/OpenStress/random"
+)
+
+// 生成姓名和邮箱使用的词表
+var (
+	fakerFirstNames = []string{"James", "Mary", "John", "Linda", "Robert", "Emma", "Michael", "Olivia", "David", "Sophia", "Wei", "Fang", "Lei", "Na", "Yang", "Jing"}
+	fakerLastNames  = []string{"Smith", "Johnson", "Brown", "Garcia", "Miller", "Davis", "Wilson", "Taylor", "Wang", "Li", "Zhang", "Liu", "Chen", "Zhao", "Huang", "Zhou"}
+	fakerDomains    = []string{"example.com", "example.org", "example.net", "test.local"}
+)
+
+const (
+	fakerAlphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	fakerHex          = "0123456789abcdef"
+)
+
+// Faker 随机数据生成器，不是并发安全的
+type Faker struct {
+	rng *rand.Rand
+}
+
+// NewFaker 使用 rng 创建随机数据生成器，rng 为 nil 时由全局随机种子派生
+func NewFaker(rng *rand.Rand) *Faker {
+	if rng == nil {
+		rng = random.New("faker")
+	}
+	return &Faker{rng: rng}
+}
+
+// Funcs 返回模板中可用的随机数据函数
+func (f *Faker) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"uuid":       f.UUID,
+		"firstName":  f.FirstName,
+		"lastName":   f.LastName,
+		"name":       f.Name,
+		"email":      f.Email,
+		"phone":      f.Phone,
+		"now":        time.Now,
+		"unix":       func() int64 { return time.Now().Unix() },
+		"unixMilli":  func() int64 { return time.Now().UnixMilli() },
+		"randInt":    f.Int,
+		"randFloat":  f.Float,
+		"randString": f.String,
+		"randHex":    f.Hex,
+		"randChoice": f.Choice,
+		"randBool":   f.Bool,
+	}
+}
+
+// UUID 生成随机的 UUID（版本 4）
+func (f *Faker) UUID() string {
+	var b [16]byte
+	f.rng.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// FirstName 随机名
+func (f *Faker) FirstName() string {
+	return fakerFirstNames[f.rng.Intn(len(fakerFirstNames))]
+}
+
+// LastName 随机姓
+func (f *Faker) LastName() string {
+	return fakerLastNames[f.rng.Intn(len(fakerLastNames))]
+}
+
+// Name 随机姓名
+func (f *Faker) Name() string {
+	return f.FirstName() + " " + f.LastName()
+}
+
+// Email 随机邮箱地址
+func (f *Faker) Email() string {
+	return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(f.FirstName()), strings.ToLower(f.LastName()),
+		f.rng.Intn(1000), fakerDomains[f.rng.Intn(len(fakerDomains))])
+}
+
+// Phone 随机 11 位手机号
+func (f *Faker) Phone() string {
+	return fmt.Sprintf("1%d%09d", 3+f.rng.Intn(7), f.rng.Intn(1000000000))
+}
+
+// Int 返回 [min, max] 内的随机整数
+func (f *Faker) Int(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + f.rng.Intn(max-min+1)
+}
+
+// Float 返回 [min, max) 内的随机浮点数
+func (f *Faker) Float(min, max float64) float64 {
+	return min + f.rng.Float64()*(max-min)
+}
+
+// String 返回长度为 n 的随机字母数字字符串
+func (f *Faker) String(n int) string {
+	return f.pick(fakerAlphanumeric, n)
+}
+
+// Hex 返回长度为 n 的随机十六进制字符串
+func (f *Faker) Hex(n int) string {
+	return f.pick(fakerHex, n)
+}
+
+// Choice 从给定的值中随机选择一个
+func (f *Faker) Choice(values ...string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[f.rng.Intn(len(values))]
+}
+
+// Bool 随机布尔值
+func (f *Faker) Bool() bool {
+	return f.rng.Intn(2) == 1
+}
+
+// pick 从 chars 中随机取 n 个字符
+func (f *Faker) pick(chars string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[f.rng.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// RequestTemplate 请求模板，URL、请求体和请求头的值均为模板
+type RequestTemplate struct {
+	Method string
+	url    *template.Template
+	body   *template.Template
+	header map[string]*template.Template
+}
+
+// NewRequestTemplate 解析请求模板，模板语法错误时返回错误
+func NewRequestTemplate(method, rawURL, body string, header map[string]string) (*RequestTemplate, error) {
+	rt := &RequestTemplate{Method: method, header: make(map[string]*template.Template, len(header))}
+	var err error
+	if rt.url, err = parsePayloadTemplate("url", rawURL); err != nil {
+		return nil, err
+	}
+	if rt.body, err = parsePayloadTemplate("body", body); err != nil {
+		return nil, err
+	}
+	for name, value := range header {
+		if rt.header[name], err = parsePayloadTemplate("header "+name, value); err != nil {
+			return nil, err
+		}
+	}
+	return rt, nil
+}
+
+// parsePayloadTemplate 解析模板，引用未定义的变量时执行报错
+func parsePayloadTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs((&Faker{}).Funcs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	return tmpl, nil
+}
+
+// NewRequest 渲染模板并展开变量后创建请求，每次迭代调用一次
+// vars 可以为 nil；faker 为 nil 时使用由全局随机种子派生的生成器
+func (rt *RequestTemplate) NewRequest(ctx context.Context, vars *Variables, faker *Faker) (*http.Request, error) {
+	if vars == nil {
+		vars = NewVariables(nil)
+	}
+	if faker == nil {
+		faker = NewFaker(nil)
+	}
+	data := vars.snapshot()
+	funcs := faker.Funcs()
+
+	rawURL, err := renderPayloadTemplate(rt.url, data, funcs)
+	if err != nil {
+		return nil, err
+	}
+	body, err := renderPayloadTemplate(rt.body, data, funcs)
+	if err != nil {
+		return nil, err
+	}
+	header := make(map[string]string, len(rt.header))
+	for name, tmpl := range rt.header {
+		if header[name], err = renderPayloadTemplate(tmpl, data, funcs); err != nil {
+			return nil, err
+		}
+	}
+	return vars.NewRequest(ctx, rt.Method, rawURL, body, header)
+}
+
+// RenderTemplate 使用场景变量和 faker 渲染一段模板文本，适合在非 HTTP 任务（消息体、SQL 参数等）中使用
+func RenderTemplate(text string, vars *Variables, faker *Faker) (string, error) {
+	tmpl, err := parsePayloadTemplate("payload", text)
+	if err != nil {
+		return "", err
+	}
+	if vars == nil {
+		vars = NewVariables(nil)
+	}
+	if faker == nil {
+		faker = NewFaker(nil)
+	}
+	return renderPayloadTemplate(tmpl, vars.snapshot(), faker.Funcs())
+}
+
+// renderPayloadTemplate 克隆模板并绑定当前的 faker 后渲染
+func renderPayloadTemplate(tmpl *template.Template, data map[string]string, funcs template.FuncMap) (string, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := clone.Funcs(funcs).Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %v", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
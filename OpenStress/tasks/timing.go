@@ -0,0 +1,142 @@
+// timing.go
+// HTTP 请求阶段计时模块
+// 本文件负责使用 httptrace 采集 HTTP 请求各阶段的耗时：DNS 解析、TCP 连接、TLS 握手和首字节时间（TTFB），
+// 结果通过 result.ResultData.ApplyPhases 写入 JTL 的 Latency/Connect 列和报告的阶段耗时图。
+//
+// 技术实现细节：
+// 1. 计时挂在请求的 context 上，与 tracing.Transport 等已有的 ClientTrace 组合使用，互不覆盖。
+// 2. 首字节时间从调用 DoTimed 开始计算，包含建立连接的时间（与 JMeter 的 Latency 口径一致）。
+// 3. 复用连接时没有 DNS、连接和握手回调，对应耗时为 0。
+// 4. 回调可能在传输层的其他协程中执行，各时间点使用互斥锁保护。
+// 5. 同时记录 TLS 版本和加密套件：优先取响应的 TLS 连接状态（复用连接时也有），请求失败时取握手回调中的状态。
+
+package tasks
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// PhaseTimings 一次 HTTP 请求各阶段的耗时
+type PhaseTimings struct {
+	DNSLookup    time.Duration // DNS 解析
+	TCPConnect   time.Duration // TCP 连接
+	TLSHandshake time.Duration // TLS 握手
+	TTFB         time.Duration // 首字节时间，包含建立连接
+	ConnReused   bool          // 是否复用了已有连接
+	TLSVersion   string        // TLS 版本，例如 TLS 1.3，非 HTTPS 请求为空
+	TLSCipher    string        // TLS 加密套件名称，非 HTTPS 请求为空
+}
+
+// ResultPhases 返回各阶段耗时，实现 result.PhaseTimer
+func (p PhaseTimings) ResultPhases() (dnsLookup, tcpConnect, tlsHandshake, ttfb time.Duration) {
+	return p.DNSLookup, p.TCPConnect, p.TLSHandshake, p.TTFB
+}
+
+// ResultTLS 返回 TLS 版本和加密套件，实现 result.TLSInfo
+func (p PhaseTimings) ResultTLS() (version, cipher string) {
+	return p.TLSVersion, p.TLSCipher
+}
+
+// phaseRecorder 记录请求各阶段的时间点
+type phaseRecorder struct {
+	mu                       sync.Mutex
+	start                    time.Time
+	dnsStart, dnsDone        time.Time
+	connectStart, connectEnd time.Time
+	tlsStart, tlsDone        time.Time
+	firstByte                time.Time
+	reused                   bool
+	tlsState                 *tls.ConnectionState // 握手完成时的连接状态
+}
+
+// clientTrace 返回记录各阶段时间点的 httptrace.ClientTrace
+func (p *phaseRecorder) clientTrace() *httptrace.ClientTrace {
+	mark := func(field *time.Time) {
+		p.mu.Lock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+		p.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.mu.Lock()
+			p.reused = info.Reused
+			p.mu.Unlock()
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&p.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&p.dnsDone) },
+		ConnectStart:         func(string, string) { mark(&p.connectStart) },
+		ConnectDone:          func(string, string, error) { mark(&p.connectEnd) },
+		TLSHandshakeStart:    func() { mark(&p.tlsStart) },
+		TLSHandshakeDone:     p.tlsHandshakeDone,
+		GotFirstResponseByte: func() { mark(&p.firstByte) },
+	}
+}
+
+// tlsHandshakeDone 记录握手完成时间和连接状态
+func (p *phaseRecorder) tlsHandshakeDone(state tls.ConnectionState, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tlsDone.IsZero() {
+		p.tlsDone = time.Now()
+	}
+	if err == nil {
+		p.tlsState = &state
+	}
+}
+
+// timings 计算各阶段耗时，缺少起止时间点的阶段为 0
+func (p *phaseRecorder) timings() PhaseTimings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	phase := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	timings := PhaseTimings{
+		DNSLookup:    phase(p.dnsStart, p.dnsDone),
+		TCPConnect:   phase(p.connectStart, p.connectEnd),
+		TLSHandshake: phase(p.tlsStart, p.tlsDone),
+		TTFB:         phase(p.start, p.firstByte),
+		ConnReused:   p.reused,
+	}
+	timings.setTLS(p.tlsState)
+	return timings
+}
+
+// setTLS 记录 TLS 版本和加密套件，state 为 nil 时不修改
+func (p *PhaseTimings) setTLS(state *tls.ConnectionState) {
+	if state == nil {
+		return
+	}
+	p.TLSVersion = tls.VersionName(state.Version)
+	p.TLSCipher = tls.CipherSuiteName(state.CipherSuite)
+}
+
+// DoTimed 使用 client 发送请求并采集各阶段耗时，client 为 nil 时使用 DefaultHTTPClient。
+// 请求出错时返回已经采集到的阶段耗时
+func DoTimed(client *http.Client, req *http.Request) (*http.Response, PhaseTimings, error) {
+	if client == nil {
+		client = DefaultHTTPClient()
+	}
+	recorder := &phaseRecorder{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), recorder.clientTrace()))
+	resp, err := client.Do(req)
+	timings := recorder.timings()
+	if resp != nil {
+		timings.setTLS(resp.TLS)
+	}
+	return resp, timings, err
+}
+
+// Do 使用任务的 HTTP 客户端发送请求并采集各阶段耗时
+func (t *Task) Do(req *http.Request) (*http.Response, PhaseTimings, error) {
+	return DoTimed(t.HTTPClient(), req)
+}
@@ -0,0 +1,52 @@
+// tlsclient.go
+// TLS 客户端证书模块
+// 本文件负责双向 TLS（mTLS）的客户端证书：场景配置一个证书时所有请求使用同一个证书，
+// 配置多个证书时按 VU 选择，模拟多个持有不同证书的客户端。
+//
+// 技术实现细节：
+// 1. 证书和私钥在创建传输层时读取并校验，文件缺失或不匹配时 NewTransport 返回错误，不会等到压测时才失败。
+// 2. 通过 tls.Config.GetClientCertificate 在握手时选择证书：握手的 context 中带有 VU 编号（WithVU）时，
+//    第 n 个 VU 使用第 n % len(ClientCerts) 个证书，没有 VU 编号时按连接轮询。
+// 3. 与源地址绑定相同，证书在建立连接时选择，keep-alive 连接在 VU 之间复用时沿用握手时的证书；
+//    需要每个 VU 严格使用自己的证书时可以同时设置 disable_keep_alives。
+
+package tasks
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+)
+
+// ClientCertConfig 一个客户端证书（PEM 格式的证书链和私钥文件）
+type ClientCertConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// loadClientCertificates 读取客户端证书
+func loadClientCertificates(configs []ClientCertConfig) ([]tls.Certificate, error) {
+	certs := make([]tls.Certificate, 0, len(configs))
+	for _, cfg := range configs {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s: %v", cfg.CertFile, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// clientCertSelector 握手时按 VU 或轮询选择客户端证书
+type clientCertSelector struct {
+	certs []tls.Certificate
+	next  atomic.Uint64 // 没有 VU 编号时轮询的下一个证书
+}
+
+// GetClientCertificate 实现 tls.Config.GetClientCertificate
+func (s *clientCertSelector) GetClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if vu, ok := VUFrom(info.Context()); ok && vu >= 0 {
+		return &s.certs[vu%len(s.certs)], nil
+	}
+	return &s.certs[(s.next.Add(1)-1)%uint64(len(s.certs))], nil
+}
@@ -0,0 +1,201 @@
+// transport.go
+// HTTP 传输层模块
+// 本文件负责创建压测请求共用的 http.Transport：连接池大小、keep-alive、HTTP/2 开关、
+// TLS 证书校验与自定义 CA、代理（HTTP/HTTPS/SOCKS5，支持认证）都可以通过配置调整。
+//
+// 技术实现细节：
+// 1. 每个任务新建客户端会为每个请求建立新连接，高并发下耗尽本地临时端口，并把建连耗时计入响应时间；
+//    任务共用同一个 Transport，连接在请求之间复用。
+// 2. http.DefaultTransport 每个主机只保留 2 个空闲连接，高并发时大部分连接用完即关闭，
+//    因此默认的每主机空闲连接数提高到 DefaultMaxIdleConnsPerHost。
+// 3. 未配置任务 HTTP 客户端时，任务使用 DefaultHTTPClient 返回的共享客户端，而不是 http.DefaultClient。
+// 4. 配置了 Bandwidth 时新建的每个连接按上行、下行速率限速（见 bandwidth.go）。
+// 5. 配置了 SourceIPs 时连接绑定到其中一个本地源 IP，按 VU 或按连接轮询（见 sourceaddr.go）。
+// 6. 配置了 ClientCerts 时启用双向 TLS，握手时按 VU 或按连接轮询选择客户端证书（见 tlsclient.go）。
+// 7. 代理地址的协议决定代理类型（http、https、socks5、socks5h），认证信息可以写在地址中，也可以通过
+//    ProxyUsername/ProxyPassword 单独配置；两者都支持 ${NAME} 引用环境变量，避免把密码写入配置文件。
+//    测试计划可以单独指定代理（TestPlan.Proxy），例如模拟经由不同地区出口的客户端。
+
+package tasks
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// 传输层默认值
+const (
+	DefaultMaxIdleConns        = 1000             // 所有主机的空闲连接总数
+	DefaultMaxIdleConnsPerHost = 256              // 每个主机的空闲连接数
+	DefaultIdleConnTimeout     = 90 * time.Second // 空闲连接的保留时间
+	DefaultDialTimeout         = 30 * time.Second // 建立 TCP 连接的超时时间
+	DefaultKeepAlive           = 30 * time.Second // TCP keep-alive 探测间隔
+	DefaultTLSHandshakeTimeout = 10 * time.Second // TLS 握手超时时间
+)
+
+// ProxyDirect 代理配置为该值时不使用代理（包括环境变量中的代理）
+const ProxyDirect = "direct"
+
+// TransportConfig HTTP 传输层配置，零值字段使用默认值
+type TransportConfig struct {
+	MaxIdleConns        int           `yaml:"max_idle_conns"`          // 所有主机的空闲连接总数，默认 DefaultMaxIdleConns
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"` // 每个主机的空闲连接数，默认 DefaultMaxIdleConnsPerHost
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host"`      // 每个主机的最大连接数（含使用中的连接），0 表示不限制
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`       // 空闲连接的保留时间，默认 DefaultIdleConnTimeout
+	DialTimeout         time.Duration `yaml:"dial_timeout"`            // 建立 TCP 连接的超时时间，默认 DefaultDialTimeout
+	KeepAlive           time.Duration `yaml:"keep_alive"`              // TCP keep-alive 探测间隔，默认 DefaultKeepAlive，小于 0 表示关闭
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout"`   // TLS 握手超时时间，默认 DefaultTLSHandshakeTimeout
+	DisableKeepAlives   bool          `yaml:"disable_keep_alives"`     // 关闭 HTTP keep-alive，每个请求使用新连接（模拟短连接客户端）
+	DisableHTTP2        bool          `yaml:"disable_http2"`           // 关闭 HTTP/2，只使用 HTTP/1.1
+	InsecureSkipVerify  bool          `yaml:"insecure_skip_verify"`    // 跳过服务端证书校验（仅用于测试环境的自签名证书）
+	CAFiles             []string      `yaml:"ca_files"`                // 额外信任的 CA 证书文件（PEM），追加到系统证书池
+	Proxy               string        `yaml:"proxy"`                   // 代理地址，为空时使用环境变量（HTTP_PROXY 等），ProxyDirect 表示不使用代理
+	ProxyUsername       string        `yaml:"proxy_username"`          // 代理认证的用户名，覆盖代理地址中的认证信息
+	ProxyPassword       string        `yaml:"proxy_password"`          // 代理认证的密码，支持 ${NAME} 引用环境变量
+	SourceIPs           []string      `yaml:"source_ips"`              // 发出连接绑定的本地源 IP，多个时按 VU 轮流使用，为空时由系统选择
+
+	Bandwidth   BandwidthConfig    `yaml:"bandwidth"`    // 模拟的客户端带宽，为零值时不限速
+	ClientCerts []ClientCertConfig `yaml:"client_certs"` // 双向 TLS 的客户端证书，多个时按 VU 轮流使用
+}
+
+// NewTransport 按配置创建 HTTP 传输层
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = DefaultKeepAlive
+	}
+	if cfg.TLSHandshakeTimeout <= 0 {
+		cfg.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	if err := cfg.Bandwidth.Validate(); err != nil {
+		return nil, err
+	}
+
+	proxy, err := proxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if len(cfg.CAFiles) > 0 {
+		if tlsConfig.RootCAs, err = loadCertPool(cfg.CAFiles); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.ClientCerts) > 0 {
+		certs, err := loadClientCertificates(cfg.ClientCerts)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = (&clientCertSelector{certs: certs}).GetClientCertificate
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive}
+	dial := dialer.DialContext
+	if len(cfg.SourceIPs) > 0 {
+		ips, err := parseSourceIPs(cfg.SourceIPs)
+		if err != nil {
+			return nil, err
+		}
+		dial = (&sourceDialer{dialer: *dialer, ips: ips}).DialContext
+	}
+	if cfg.Bandwidth.Enabled() {
+		dial = throttleDialer(dial, cfg.Bandwidth)
+	}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		ExpectContinueTimeout: time.Second,
+		// 设置了自定义 TLSClientConfig 后需要显式开启 HTTP/2
+		ForceAttemptHTTP2: !cfg.DisableHTTP2,
+	}
+	if cfg.DisableHTTP2 {
+		// 非 nil 的空映射关闭 HTTP/2 协商
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport, nil
+}
+
+// proxyFunc 根据代理配置返回 http.Transport 的 Proxy 函数
+func proxyFunc(cfg TransportConfig) (func(*http.Request) (*url.URL, error), error) {
+	switch cfg.Proxy {
+	case "", ProxyDirect:
+		if cfg.ProxyUsername != "" {
+			return nil, fmt.Errorf("proxy credentials require a proxy url")
+		}
+		if cfg.Proxy == "" {
+			return http.ProxyFromEnvironment, nil
+		}
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(os.ExpandEnv(cfg.Proxy))
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q", cfg.Proxy)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (supported: http, https, socks5, socks5h)", proxyURL.Scheme)
+	}
+	if cfg.ProxyUsername != "" {
+		proxyURL.User = url.UserPassword(os.ExpandEnv(cfg.ProxyUsername), os.ExpandEnv(cfg.ProxyPassword))
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// loadCertPool 在系统证书池的基础上追加 CA 证书文件
+func loadCertPool(files []string) (*x509.CertPool, error) {
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		certPool = x509.NewCertPool()
+	}
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %v", err)
+		}
+		if !certPool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", file)
+		}
+	}
+	return certPool, nil
+}
+
+var (
+	defaultClientOnce sync.Once
+	defaultClient     *http.Client
+)
+
+// DefaultHTTPClient 返回任务共用的默认 HTTP 客户端，使用默认配置的传输层，不设置超时
+func DefaultHTTPClient() *http.Client {
+	defaultClientOnce.Do(func() {
+		// 默认配置不读取文件也不解析代理地址，不会出错
+		transport, _ := NewTransport(TransportConfig{})
+		defaultClient = &http.Client{Transport: transport}
+	})
+	return defaultClient
+}
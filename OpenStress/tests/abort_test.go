@@ -0,0 +1,114 @@
+// abort_test.go
+// 错误预算测试模块
+// 本文件负责测试错误预算：滑动窗口错误率或连续失败超出时提前中止 VU 并记录中止原因和时间，
+// 错误率在预算内时正常运行到结束，以及中止信息在 HTML 和 Markdown 报告中的展示。
+
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestAbortOnConsecutiveFailures(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	started := time.Now()
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: time.Minute, Abort: pool.AbortPolicy{ConsecutiveFailures: 5}}, func(vu *pool.VUContext) {
+		vu.Fail(fmt.Errorf("connection refused"))
+	})
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	run.Wait()
+
+	abort, ok := run.Abort()
+	if !ok || run.StopReason() != pool.StopConsecutiveFailures || abort.Reason != pool.StopConsecutiveFailures {
+		t.Fatalf("expected to abort on consecutive failures, got %s", run.StopReason())
+	}
+	if abort.Time.Before(started) || time.Since(abort.Time) > 10*time.Second {
+		t.Errorf("unexpected abort time %v", abort.Time)
+	}
+	if !strings.Contains(abort.Detail, "consecutive failed iterations") {
+		t.Errorf("unexpected abort detail %q", abort.Detail)
+	}
+	if run.Errors() > 10 {
+		t.Errorf("vus should stop soon after the budget is exceeded, got %d errors", run.Errors())
+	}
+}
+
+func TestAbortOnErrorRate(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	policy := pool.AbortPolicy{ErrorRate: 50, Window: 5 * time.Second, MinIterations: 10}
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: time.Minute, Abort: policy}, func(vu *pool.VUContext) {
+		// 四次中三次失败，不会连续失败但错误率为 75%
+		if vu.Iteration%4 != 0 {
+			vu.Fail(nil)
+		}
+		time.Sleep(time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	run.Wait()
+
+	abort, ok := run.Abort()
+	if !ok || abort.Reason != pool.StopErrorRate {
+		t.Fatalf("expected to abort on error rate, got %s", run.StopReason())
+	}
+	if !strings.Contains(abort.Detail, "exceeded 50.00%") {
+		t.Errorf("unexpected abort detail %q", abort.Detail)
+	}
+	if run.Iterations() < 10 {
+		t.Errorf("error rate should not be judged before %d iterations, got %d", policy.MinIterations, run.Iterations())
+	}
+}
+
+func TestErrorBudgetWithinLimits(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	policy := pool.AbortPolicy{ErrorRate: 50, MinIterations: 5, ConsecutiveFailures: 3}
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 1, Duration: 200 * time.Millisecond, Abort: policy}, func(vu *pool.VUContext) {
+		if vu.Iteration%5 == 0 {
+			vu.Fail(nil)
+		}
+		time.Sleep(2 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	run.Wait()
+	if _, ok := run.Abort(); ok || run.StopReason() != pool.StopDuration {
+		t.Errorf("a 20%% error rate should stay within the budget, got %s", run.StopReason())
+	}
+
+	for _, policy := range []pool.AbortPolicy{{ErrorRate: 120}, {ErrorRate: -1}, {ConsecutiveFailures: -1}, {ErrorRate: 10, Window: -time.Second}} {
+		if _, err := taskPool.StartVUs(pool.VUConfig{VUs: 1, Duration: time.Second, Abort: policy}, func(*pool.VUContext) {}); err == nil {
+			t.Errorf("expected error for %+v", policy)
+		}
+	}
+}
+
+func TestAbortShownInReports(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	collector.SetAbort(string(pool.StopErrorRate), "error rate 80.00% (40/50 iterations) over the last 10s exceeded 50.00%", at)
+	stats := lockTestStats(t, collector)
+
+	if stats["Aborted"] != true || stats["StopReason"] != "error_rate" || !stats["AbortTime"].(time.Time).Equal(at) {
+		t.Fatalf("unexpected abort stats: %v %v %v", stats["Aborted"], stats["StopReason"], stats["AbortTime"])
+	}
+	html := result.GenerateHTMLReport(stats)
+	for _, want := range []string{"测试已提前中止", "错误率超出错误预算", "2024-05-01 10:30:00", "over the last 10s exceeded 50.00%"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML report should contain %q", want)
+		}
+	}
+	md := result.GenerateMarkdownReport(stats)
+	if !strings.Contains(md, "> **⚠️ 测试已提前中止：错误率超出错误预算（2024-05-01 10:30:00）**") {
+		t.Errorf("markdown report should state the abort reason and time:\n%s", md)
+	}
+}
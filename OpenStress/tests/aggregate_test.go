@@ -0,0 +1,139 @@
+// aggregate_test.go
+// 结果聚合测试模块
+// 本文件负责测试分片并发聚合的统计结果与逐条遍历计算的结果一致，并提供大数据量统计的基准测试。
+// 基准测试默认使用 100 万条记录，可通过 OPENSTRESS_BENCH_RECORDS 环境变量调整（例如 10000000）。
+
+package tests
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// generateAggregateResults 生成 n 条分布在 span 秒内的随机结果，按开始时间大致有序
+func generateAggregateResults(n int, span int, seed int64) []result.ResultData {
+	rng := rand.New(rand.NewSource(seed))
+	start := time.Unix(1700000000, 0)
+	results := make([]result.ResultData, n)
+	for i := range results {
+		begin := start.Add(time.Duration(i) * time.Duration(span) * time.Second / time.Duration(n)).Add(time.Duration(rng.Intn(500)) * time.Millisecond)
+		rt := time.Duration(1+rng.Intn(2000)) * time.Millisecond
+		results[i] = result.ResultData{
+			ID:           "r",
+			Type:         result.Success,
+			ResponseTime: rt,
+			StartTime:    begin,
+			EndTime:      begin.Add(rt),
+			DataSent:     int64(rng.Intn(4096)),
+			DataReceived: int64(rng.Intn(65536)),
+		}
+		if rng.Intn(10) == 0 {
+			results[i].Type = result.Failure
+		}
+	}
+	return results
+}
+
+func TestShardedAggregationMatchesSequential(t *testing.T) {
+	// 保证按多个分片聚合
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	results := generateAggregateResults(120000, 300, 7)
+
+	stats, err := collector.CurrentStats(results)
+	if err != nil {
+		t.Fatalf("failed to compute stats: %v", err)
+	}
+
+	// 逐条遍历计算期望值
+	first := results[0].StartTime.Unix()
+	last := first
+	for _, r := range results {
+		if sec := r.StartTime.Unix(); sec > last {
+			last = sec
+		}
+	}
+	n := int(last-first) + 1
+	tps, success, failure := make([]int, n), make([]int, n), make([]int, n)
+	rtSum, sentSum, receivedSum := make([]int64, n), make([]int64, n), make([]int64, n)
+	var failures int
+	var maxRT time.Duration
+	for _, r := range results {
+		i := int(r.StartTime.Unix() - first)
+		tps[i]++
+		if r.Type == result.Success {
+			success[i]++
+		} else {
+			failure[i]++
+			failures++
+		}
+		rtSum[i] += int64(r.ResponseTime)
+		sentSum[i] += r.DataSent
+		receivedSum[i] += r.DataReceived
+		if r.ResponseTime > maxRT {
+			maxRT = r.ResponseTime
+		}
+	}
+	avgRT, avgSent, avgReceived := make([]int, n), make([]int, n), make([]int, n)
+	for i := range tps {
+		if tps[i] > 0 {
+			avgRT[i] = int(float64(rtSum[i]) / float64(tps[i]) / 1000 / 1000)
+			avgSent[i] = int(sentSum[i] / int64(tps[i]))
+			avgReceived[i] = int(receivedSum[i] / int64(tps[i]))
+		}
+	}
+
+	if stats["TotalRequests"] != len(results) || stats["FailureCount"] != failures || stats["MaxResponseTime"] != maxRT {
+		t.Errorf("unexpected totals: %v requests, %v failures, max %v", stats["TotalRequests"], stats["FailureCount"], stats["MaxResponseTime"])
+	}
+	if stats["AvgTpsStartTime"] != first || stats["AvgTpsEndTime"] != last {
+		t.Errorf("unexpected time range %v - %v", stats["AvgTpsStartTime"], stats["AvgTpsEndTime"])
+	}
+	for key, want := range map[string][]int{
+		"TPSValues":                tps,
+		"SuccessValues":            success,
+		"FailureValues":            failure,
+		"AvgResponseTimeValues":    avgRT,
+		"AvgSentTrafficValues":     avgSent,
+		"AvgReceivedTrafficValues": avgReceived,
+	} {
+		if got := stats[key].([]int); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s differs from the sequential computation", key)
+		}
+	}
+}
+
+// benchmarkRecords 基准测试的记录数
+func benchmarkRecords() int {
+	if n, err := strconv.Atoi(os.Getenv("OPENSTRESS_BENCH_RECORDS")); err == nil && n > 0 {
+		return n
+	}
+	return 1000000
+}
+
+func BenchmarkCalculateTPS(b *testing.B) {
+	collector, _ := newTestCollector(b, result.CollectorConfig{})
+	results := generateAggregateResults(benchmarkRecords(), 6*3600, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		collector.CalculateTPS(results)
+	}
+}
+
+func BenchmarkCurrentStats(b *testing.B) {
+	collector, _ := newTestCollector(b, result.CollectorConfig{})
+	results := generateAggregateResults(benchmarkRecords(), 6*3600, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := collector.CurrentStats(results); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
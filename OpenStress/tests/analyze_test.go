@@ -0,0 +1,102 @@
+// analyze_test.go
+// 流式统计测试模块
+// 本文件负责测试 AnalyzeFile 从 JTL 文件（包括压缩、轮转的分段）流式计算的统计结果
+// 与加载全部结果后计算的统计结果完全一致，以及没有可统计的结果和文件不存在时的错误。
+
+package tests
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// saveAnalyzeResults 保存覆盖各项统计的结果：失败、超时、断言、阶段耗时、重试与限流、事务和投递样本
+func saveAnalyzeResults(collector *result.Collector, n int) {
+	rng := rand.New(rand.NewSource(3))
+	start := time.Now().Add(-time.Duration(n) * 50 * time.Millisecond)
+	for i := 0; i < n; i++ {
+		begin := start.Add(time.Duration(i) * 50 * time.Millisecond)
+		rt := time.Duration(5+rng.Intn(300)) * time.Millisecond
+		data := result.ResultData{
+			ID: "r", Type: result.Success, Method: "GET", URL: "http://svc/orders", StatusCode: 200,
+			StartTime: begin, EndTime: begin.Add(rt), ResponseTime: rt,
+			DataSent: int64(rng.Intn(500)), DataReceived: int64(rng.Intn(5000)),
+			Assertions: map[string]bool{"has_items": rng.Intn(20) > 0},
+			TTFB:       rt / 2, DNSLookup: time.Millisecond, TCPConnect: 2 * time.Millisecond,
+		}
+		if i%7 == 0 {
+			data.RetryCount = 1
+			data.ThrottleWait = 3 * time.Millisecond
+		}
+		switch {
+		case i%11 == 0:
+			data.Type, data.StatusCode, data.ErrorMessage = result.Failure, 503, "service unavailable"
+		case i%13 == 0:
+			data.Type, data.StatusCode, data.ErrorMessage = result.Failure, 500, "internal error"
+		case i%17 == 0:
+			data.Type, data.StatusCode, data.ErrorMessage = result.Timeout, 0, "context deadline exceeded"
+		}
+		if i%25 == 0 {
+			data.Transaction = "checkout"
+		} else if i%30 == 0 {
+			data.DataType = result.DeliveryDataType
+		}
+		if data.Type != result.Success {
+			collector.SaveFailureResult(data)
+		} else {
+			collector.SaveSuccessResult(data)
+		}
+	}
+}
+
+func TestAnalyzeFileMatchesInMemoryStats(t *testing.T) {
+	for _, cfg := range []result.CollectorConfig{{}, {JTLCompress: true, JTLRotateSize: 2000}} {
+		collector, _ := newTestCollector(t, cfg)
+		saveAnalyzeResults(collector, 600)
+
+		results, err := collector.LoadResultsFromFile()
+		if err != nil {
+			t.Fatalf("failed to load results: %v", err)
+		}
+		want, err := collector.GeneratePerformanceStats(results)
+		if err != nil {
+			t.Fatalf("failed to generate stats: %v", err)
+		}
+		got, err := collector.AnalyzeFile("")
+		if err != nil {
+			t.Fatalf("failed to analyze file: %v", err)
+		}
+
+		if len(got) != len(want) {
+			t.Errorf("compress=%v: expected %d keys, got %d", cfg.JTLCompress, len(want), len(got))
+		}
+		for key, value := range want {
+			if !reflect.DeepEqual(got[key], value) {
+				t.Errorf("compress=%v: %s differs: streamed %v, in memory %v", cfg.JTLCompress, key, got[key], value)
+			}
+		}
+		if _, ok := got["TransactionStats"]; !ok {
+			t.Error("transaction samples should be summarized")
+		}
+	}
+}
+
+func TestAnalyzeFileErrors(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	if _, err := collector.AnalyzeFile(filepath.Join(t.TempDir(), "missing.jtl")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	// 只有预热阶段的结果
+	now := time.Now()
+	collector.SaveSuccessResult(result.ResultData{ID: "r", Type: result.Success, StartTime: now.Add(-time.Minute), EndTime: now.Add(-time.Minute)})
+	collector.SetMeasurementStart(now)
+	if _, err := collector.AnalyzeFile(""); err == nil || !strings.Contains(err.Error(), "no results to analyze") {
+		t.Errorf("expected an error when every result is excluded, got %v", err)
+	}
+}
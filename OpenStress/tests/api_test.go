@@ -7,7 +7,7 @@
 // - 测试任务状态查询功能
 // - 边界情况和异常情况的测试（待实现）
 // - 测试覆盖率统计（待实现）
-// 
+//
 // 技术实现细节：
 // 1. 使用 Go 的 testing 包编写测试用例。
 // 2. 模拟 HTTP 请求，测试任务提交的 API。
@@ -18,8 +18,417 @@
 
 package tests
 
-// API 测试
-// TODO: 编写 API 相关的测试用例
-// TODO: 使用 Go 的 testing 包编写测试函数
-// TODO: 使用 Go 的 net/http 包模拟 HTTP 请求
-// TODO: 使用 Go 的 encoding/json 包解析 JSON 数据
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/api"
+	"github.com/potatoImp/OpenStress/auth"
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// newTestAPIServer 创建带真实协程池与结果收集器的 API 服务
+func newTestAPIServer(t *testing.T) (*api.APIServer, *pool.Pool, *result.Collector) {
+	t.Helper()
+
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "api_test.log", "APITest")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+
+	taskPool := pool.NewPool(4)
+	if taskPool == nil {
+		t.Fatal("failed to create pool")
+	}
+	t.Cleanup(taskPool.Shutdown)
+
+	collector, err := result.NewCollector(result.CollectorConfig{
+		BatchSize:   10,
+		JTLFilePath: filepath.Join(t.TempDir(), "api_test.jtl"),
+		Logger:      logger,
+		TaskID:      "api_test",
+	})
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	t.Cleanup(func() { collector.Close() })
+
+	return api.NewAPIServer(taskPool, collector, config.NewConfig()), taskPool, collector
+}
+
+// doRequest 发送请求并将响应体解析到 out（out 为 nil 时不解析）
+func doRequest(t *testing.T, handler http.Handler, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, &buf))
+
+	if out != nil {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+// waitFor 轮询直到条件满足或超时
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met before timeout")
+}
+
+func TestAPISubmitTaskAndQueryStatus(t *testing.T) {
+	server, taskPool, _ := newTestAPIServer(t)
+	done := make(chan struct{})
+	taskPool.RegisterTask("echo", func(threadID int32) { close(done) })
+
+	var available []string
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/tasks/available", nil, &available); code != http.StatusOK {
+		t.Fatalf("available: got status %d", code)
+	}
+	if len(available) != 1 || available[0] != "echo" {
+		t.Fatalf("available: got %v, want [echo]", available)
+	}
+
+	var submitted map[string]string
+	code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit",
+		api.TaskRequest{TaskName: "echo", TaskID: "echo-1"}, &submitted)
+	if code != http.StatusAccepted || submitted["task_id"] != "echo-1" {
+		t.Fatalf("submit: got %d %v", code, submitted)
+	}
+
+	<-done
+	var info pool.TaskInfo
+	waitFor(t, func() bool {
+		doRequest(t, server.Handler(), http.MethodGet, "/api/tasks/status?task_id=echo-1", nil, &info)
+		return info.Status == pool.TaskCompleted.String()
+	})
+}
+
+func TestAPISubmitTaskErrors(t *testing.T) {
+	server, _, _ := newTestAPIServer(t)
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/tasks/submit", bytes.NewBufferString("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid payload: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit", api.TaskRequest{TaskName: "missing"}, nil); code != http.StatusNotFound {
+		t.Errorf("unknown task: got %d, want %d", code, http.StatusNotFound)
+	}
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/tasks/status?task_id=missing", nil, nil); code != http.StatusNotFound {
+		t.Errorf("unknown task status: got %d, want %d", code, http.StatusNotFound)
+	}
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/pool/stop", nil, nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("wrong method: got %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAPISubmitTaskIdempotency(t *testing.T) {
+	server, taskPool, _ := newTestAPIServer(t)
+	release := make(chan struct{})
+	var runs int32
+	taskPool.RegisterTask("slow", func(threadID int32) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+	})
+
+	var first map[string]string
+	code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit",
+		api.TaskRequest{TaskName: "slow", TaskID: "slow-1", IdempotencyKey: "retry-key"}, &first)
+	if code != http.StatusAccepted || first["task_id"] != "slow-1" {
+		t.Fatalf("first submit: got %d %v", code, first)
+	}
+
+	// 重试使用相同的幂等键（通过请求头传入），返回已有任务
+	var retry map[string]string
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/submit",
+		bytes.NewBufferString(`{"task_name":"slow","task_id":"slow-2"}`))
+	req.Header.Set(api.IdempotencyKeyHeader, "retry-key")
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, req)
+	if err := json.NewDecoder(rec.Body).Decode(&retry); err != nil {
+		t.Fatalf("failed to decode retry response: %v", err)
+	}
+	if rec.Code != http.StatusOK || retry["task_id"] != "slow-1" {
+		t.Fatalf("retry submit: got %d %v", rec.Code, retry)
+	}
+
+	// 相同任务ID在执行期间重复提交同样被去重
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit",
+		api.TaskRequest{TaskName: "slow", TaskID: "slow-1"}, nil); code != http.StatusOK {
+		t.Fatalf("duplicate task id: got %d, want %d", code, http.StatusOK)
+	}
+
+	close(release)
+	waitFor(t, func() bool {
+		info, err := taskPool.GetTaskStatus("slow-1")
+		return err == nil && info.Status == pool.TaskCompleted.String()
+	})
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("expected task to run once, ran %d times", n)
+	}
+
+	// 任务结束后同一个键可以再次提交
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit",
+		api.TaskRequest{TaskName: "slow", TaskID: "slow-3", IdempotencyKey: "retry-key"}, nil); code != http.StatusAccepted {
+		t.Fatalf("resubmit after completion: got %d, want %d", code, http.StatusAccepted)
+	}
+}
+
+func TestAPIGetRunningTasks(t *testing.T) {
+	server, taskPool, _ := newTestAPIServer(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	taskPool.RegisterTask("block", func(threadID int32) {
+		close(started)
+		<-release
+	})
+
+	doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit", api.TaskRequest{TaskName: "block", TaskID: "block-1"}, nil)
+	<-started
+
+	var running []pool.TaskInfo
+	doRequest(t, server.Handler(), http.MethodGet, "/api/tasks/running", nil, &running)
+	if len(running) != 1 || running[0].ID != "block-1" {
+		t.Fatalf("running: got %+v, want block-1", running)
+	}
+
+	close(release)
+	waitFor(t, func() bool {
+		doRequest(t, server.Handler(), http.MethodGet, "/api/tasks/running", nil, &running)
+		return len(running) == 0
+	})
+}
+
+func TestAPIPoolControl(t *testing.T) {
+	server, taskPool, _ := newTestAPIServer(t)
+	taskPool.RegisterTask("noop", func(threadID int32) {})
+
+	var stats struct {
+		Pool pool.PoolStats `json:"pool"`
+	}
+	doRequest(t, server.Handler(), http.MethodPost, "/api/pool/pause", nil, nil)
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	if !stats.Pool.Paused {
+		t.Error("pause: pool not reported as paused")
+	}
+
+	doRequest(t, server.Handler(), http.MethodPost, "/api/pool/resume", nil, nil)
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	if stats.Pool.Paused {
+		t.Error("resume: pool still reported as paused")
+	}
+
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/pool/max-concurrency", map[string]int{"max_concurrency": 8}, nil); code != http.StatusOK {
+		t.Errorf("max concurrency: got %d", code)
+	}
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/pool/max-concurrency", map[string]int{"max_concurrency": 0}, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid max concurrency: got %d, want %d", code, http.StatusBadRequest)
+	}
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	if stats.Pool.MaxWorkers != 8 {
+		t.Errorf("max workers: got %d, want 8", stats.Pool.MaxWorkers)
+	}
+
+	doRequest(t, server.Handler(), http.MethodPost, "/api/pool/rate-limit", map[string]interface{}{"rate_limit": 50}, nil)
+	doRequest(t, server.Handler(), http.MethodPost, "/api/pool/rate-limit", map[string]interface{}{"rate_limit": 5, "task_type": "noop"}, nil)
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	if stats.Pool.RateLimit.GlobalRate != 50 || stats.Pool.RateLimit.TaskRates["noop"] != 5 {
+		t.Errorf("rate limit: got %+v", stats.Pool.RateLimit)
+	}
+
+	doRequest(t, server.Handler(), http.MethodPost, "/api/pool/stop", nil, nil)
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	if !stats.Pool.Shutdown {
+		t.Error("stop: pool not reported as shut down")
+	}
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit", api.TaskRequest{TaskName: "noop"}, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("submit after stop: got %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestAPIStatsIncludesCollectorResults(t *testing.T) {
+	server, _, collector := newTestAPIServer(t)
+
+	var stats map[string]json.RawMessage
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	if _, ok := stats["results"]; ok {
+		t.Fatal("results should be omitted before any data is collected")
+	}
+
+	start := time.Now()
+	collector.SaveSuccessResult(result.ResultData{ID: "r1", Type: result.Success, StartTime: start, EndTime: start.Add(20 * time.Millisecond), StatusCode: 200})
+	collector.SaveFailureResult(result.ResultData{ID: "r2", Type: result.Failure, StartTime: start, EndTime: start.Add(40 * time.Millisecond), ResponseTime: 40 * time.Millisecond, StatusCode: 500})
+
+	doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats)
+	var results map[string]interface{}
+	if err := json.Unmarshal(stats["results"], &results); err != nil {
+		t.Fatalf("failed to decode results: %v", err)
+	}
+	if results["TotalRequests"] != float64(2) {
+		t.Errorf("TotalRequests: got %v, want 2", results["TotalRequests"])
+	}
+}
+
+func TestAPIServerListen(t *testing.T) {
+	if _, err := pool.InitializeLogger(t.TempDir()+"/", "api_test.log", "APITest"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(1)
+	defer taskPool.Shutdown()
+
+	disabled := api.NewAPIServer(taskPool, nil, &config.Config{EnableAPIServer: false, APIAddr: "127.0.0.1:0"})
+	if err := disabled.Start(); err != nil || disabled.Addr() != "" {
+		t.Fatalf("disabled server should not listen: addr=%q err=%v", disabled.Addr(), err)
+	}
+
+	server := api.NewAPIServer(taskPool, nil, &config.Config{EnableAPIServer: true, APIAddr: "127.0.0.1:0"})
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer server.Shutdown(context.Background())
+
+	resp, err := http.Get("http://" + server.Addr() + "/api/stats")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+// newTestAuthManager 使用临时认证配置创建本地模式的认证管理器
+func newTestAuthManager(t *testing.T) *auth.AuthManager {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	content := `users:
+  - username: admin
+    api_key: admin_key
+    permissions: [submit, manage, monitor]
+  - username: viewer
+    api_key: viewer_key
+    permissions: [monitor]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write auth config: %v", err)
+	}
+	authManager, err := auth.NewAuthManager(path, nil)
+	if err != nil {
+		t.Fatalf("failed to create auth manager: %v", err)
+	}
+	return authManager
+}
+
+func TestAPIAuthMiddleware(t *testing.T) {
+	server, taskPool, _ := newTestAPIServer(t)
+	taskPool.RegisterTask("noop", func(threadID int32) {})
+	server.SetAuthenticator(newTestAuthManager(t))
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		apiKey string
+		want   int
+	}{
+		{"missing key", http.MethodGet, "/api/stats", "", http.StatusUnauthorized},
+		{"invalid key", http.MethodGet, "/api/stats", "bogus", http.StatusUnauthorized},
+		{"monitor allowed", http.MethodGet, "/api/stats", "viewer_key", http.StatusOK},
+		{"submit forbidden", http.MethodPost, "/api/tasks/submit", "viewer_key", http.StatusForbidden},
+		{"manage forbidden", http.MethodPost, "/api/pool/pause", "viewer_key", http.StatusForbidden},
+		{"submit allowed", http.MethodPost, "/api/tasks/submit", "admin_key", http.StatusAccepted},
+		{"manage allowed", http.MethodPost, "/api/pool/resume", "admin_key", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(api.TaskRequest{TaskName: "noop"})
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewReader(body))
+			if tc.apiKey != "" {
+				req.Header.Set(api.APIKeyHeader, tc.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("got status %d, want %d (body: %s)", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+// fakeTaskPool 只实现 API 测试用到的协程池方法，其余方法由嵌入的接口提供（调用时 panic）
+type fakeTaskPool struct {
+	pool.TaskPool
+	submitted []string
+}
+
+func (p *fakeTaskPool) GetAvailableTasks() []string { return []string{"login"} }
+
+func (p *fakeTaskPool) SubmitByName(name string, priority int, taskID, idempotencyKey string, timeout time.Duration) (pool.TaskInfo, bool, error) {
+	p.submitted = append(p.submitted, taskID)
+	return pool.TaskInfo{ID: taskID, Status: "pending"}, false, nil
+}
+
+func (p *fakeTaskPool) Stats() pool.PoolStats { return pool.PoolStats{MaxWorkers: 7} }
+
+// fakeCollector 没有任何结果的结果收集器
+type fakeCollector struct {
+	result.ResultCollector
+}
+
+func (c *fakeCollector) Results() []result.ResultData { return nil }
+
+func TestAPIServerWithMockedPoolAndCollector(t *testing.T) {
+	taskPool := &fakeTaskPool{}
+	server := api.NewAPIServer(taskPool, &fakeCollector{}, config.NewConfig())
+
+	var submitted map[string]string
+	code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit", api.TaskRequest{TaskName: "login", TaskID: "login-1"}, &submitted)
+	if code != http.StatusAccepted || submitted["task_id"] != "login-1" || len(taskPool.submitted) != 1 {
+		t.Fatalf("submit: got %d %v (submitted %v)", code, submitted, taskPool.submitted)
+	}
+	if code := doRequest(t, server.Handler(), http.MethodPost, "/api/tasks/submit", api.TaskRequest{TaskName: "logout"}, nil); code != http.StatusNotFound {
+		t.Errorf("unknown task: got status %d", code)
+	}
+
+	var stats struct {
+		Pool    pool.PoolStats  `json:"pool"`
+		Results json.RawMessage `json:"results"`
+	}
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats); code != http.StatusOK || stats.Pool.MaxWorkers != 7 || stats.Results != nil {
+		t.Errorf("stats: got %d %+v", code, stats)
+	}
+
+	// 值为 nil 的 *result.Collector 与未配置收集器相同
+	var collector *result.Collector
+	server = api.NewAPIServer(taskPool, collector, config.NewConfig())
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/stats", nil, &stats); code != http.StatusOK {
+		t.Errorf("stats without collector: got status %d", code)
+	}
+}
@@ -0,0 +1,110 @@
+// arrival_test.go
+// 到达率执行测试模块
+// 本文件负责测试开放模型的到达率执行：配置校验、固定间隔和泊松到达的到达数、
+// 响应变慢时到达率保持不变（VU 不足时丢弃到达）以及计划到达时间。
+
+package tests
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+)
+
+func TestArrivalConfigValidation(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	base := pool.VUConfig{VUs: 2, Duration: 100 * time.Millisecond}
+	for name, cfg := range map[string]pool.ArrivalConfig{
+		"zero rate":         {VUConfig: base},
+		"negative rate":     {VUConfig: base, Rate: -1},
+		"distribution":      {VUConfig: base, Rate: 10, Distribution: "burst"},
+		"iterations per vu": {VUConfig: pool.VUConfig{VUs: 2, Duration: time.Second, IterationsPerVU: 3}, Rate: 10},
+		"no vus":            {VUConfig: pool.VUConfig{Duration: time.Second}, Rate: 10},
+	} {
+		if _, err := taskPool.StartArrivals(cfg, func(*pool.VUContext) {}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := taskPool.StartRegisteredArrivals("missing", pool.ArrivalConfig{VUConfig: base, Rate: 10}); err == nil {
+		t.Error("expected an error for an unregistered task")
+	}
+}
+
+func TestArrivalRateIsIndependentOfLatency(t *testing.T) {
+	taskPool := newTestPool(t, 8)
+
+	// 响应很快时到达数约等于 Rate * Duration
+	var fast atomic.Int64
+	run, err := taskPool.StartArrivals(pool.ArrivalConfig{VUConfig: pool.VUConfig{VUs: 4, Duration: time.Second}, Rate: 100}, func(*pool.VUContext) { fast.Add(1) })
+	if err != nil {
+		t.Fatalf("failed to start arrivals: %v", err)
+	}
+	run.Wait()
+	if n := fast.Load(); n < 85 || n > 115 {
+		t.Errorf("expected about 100 iterations at 100/s for 1s, got %d", n)
+	}
+	if run.Dropped() != 0 || run.StopReason() != pool.StopDuration {
+		t.Errorf("expected no dropped arrivals and a duration stop, got %d dropped and %s", run.Dropped(), run.StopReason())
+	}
+
+	// 每次迭代 100ms、2 个 VU 最多承载 20/s，50/s 的到达中多出的部分被丢弃而不是降低到达率
+	var slow atomic.Int64
+	run, err = taskPool.StartArrivals(pool.ArrivalConfig{VUConfig: pool.VUConfig{VUs: 2, Duration: time.Second}, Rate: 50}, func(*pool.VUContext) {
+		slow.Add(1)
+		time.Sleep(100 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to start arrivals: %v", err)
+	}
+	run.Wait()
+	offered := slow.Load() + run.Dropped()
+	if offered < 40 || offered > 60 {
+		t.Errorf("expected about 50 offered arrivals, got %d executed + %d dropped", slow.Load(), run.Dropped())
+	}
+	if n := slow.Load(); n > 25 || run.Dropped() == 0 {
+		t.Errorf("expected at most about 20 executed iterations and some dropped arrivals, got %d and %d", n, run.Dropped())
+	}
+}
+
+func TestPoissonArrivalsAndScheduledTime(t *testing.T) {
+	taskPool := newTestPool(t, 8)
+	var mu sync.Mutex
+	var lags []time.Duration
+	scheduled := make(map[time.Time]bool)
+	run, err := taskPool.StartArrivals(pool.ArrivalConfig{VUConfig: pool.VUConfig{VUs: 4, Duration: time.Second}, Rate: 200, Distribution: pool.ArrivalPoisson}, func(vu *pool.VUContext) {
+		mu.Lock()
+		defer mu.Unlock()
+		lags = append(lags, time.Since(vu.ScheduledAt))
+		scheduled[vu.ScheduledAt] = true
+	})
+	if err != nil {
+		t.Fatalf("failed to start arrivals: %v", err)
+	}
+	run.Wait()
+
+	if n := len(lags) + int(run.Dropped()); n < 150 || n > 250 {
+		t.Errorf("expected about 200 poisson arrivals, got %d", n)
+	}
+	if len(scheduled) != len(lags) {
+		t.Errorf("every arrival should have its own scheduled time, got %d distinct for %d iterations", len(scheduled), len(lags))
+	}
+	for _, lag := range lags {
+		if lag < 0 || lag > 100*time.Millisecond {
+			t.Errorf("iteration started %v after its scheduled time", lag)
+			break
+		}
+	}
+
+	// 达到总迭代次数时提前结束
+	run, err = taskPool.StartArrivals(pool.ArrivalConfig{VUConfig: pool.VUConfig{VUs: 2, Duration: time.Minute, Iterations: 10}, Rate: 100}, func(*pool.VUContext) {})
+	if err != nil {
+		t.Fatalf("failed to start arrivals: %v", err)
+	}
+	run.Wait()
+	if run.Iterations() != 10 || run.StopReason() != pool.StopIterations {
+		t.Errorf("expected 10 iterations and an iterations stop, got %d and %s", run.Iterations(), run.StopReason())
+	}
+}
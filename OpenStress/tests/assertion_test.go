@@ -0,0 +1,138 @@
+// assertion_test.go
+// 内容断言测试模块
+// 本文件负责测试 JSON 路径提取、非空断言、内容断言通过率阈值，以及断言结果在 JTL 文件中的往返读写。
+
+package tests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+const assertionTestBody = `{"data":{"items":[{"id":7,"tags":[]},{"id":8,"tags":["a"]}],"total":2,"name":"","extra":null,"meta":{}}}`
+
+func TestExtractJSONPath(t *testing.T) {
+	cases := []struct {
+		path string
+		want interface{}
+	}{
+		{"data.total", 2.0},
+		{"data.items.1.id", 8.0},
+		{"data.items.1.tags", []interface{}{"a"}},
+		{"data.name", ""},
+		{"data.extra", nil},
+	}
+	for _, tc := range cases {
+		got, err := result.ExtractJSONPath([]byte(assertionTestBody), tc.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %#v, want %#v", tc.path, got, tc.want)
+		}
+	}
+
+	if doc, err := result.ExtractJSONPath([]byte(assertionTestBody), ""); err != nil || doc == nil {
+		t.Errorf("empty path should return the whole document, got %v (%v)", doc, err)
+	}
+	for _, path := range []string{"data.missing", "data.items.2", "data.items.-1", "data.items.x", "data.total.value"} {
+		if _, err := result.ExtractJSONPath([]byte(assertionTestBody), path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+	if _, err := result.ExtractJSONPath([]byte("not json"), "data"); err == nil {
+		t.Error("expected error for invalid json")
+	}
+}
+
+func TestAssertJSONNonEmpty(t *testing.T) {
+	cases := map[string]bool{
+		"data.items":        true,
+		"data.items.1.tags": true,
+		"data.total":        true,
+		"data.items.0.tags": false,
+		"data.name":         false,
+		"data.extra":        false,
+		"data.meta":         false,
+		"data.missing":      false,
+	}
+	for path, want := range cases {
+		if got := result.AssertJSONNonEmpty([]byte(assertionTestBody), path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+	if result.AssertJSONNonEmpty([]byte("{"), "") {
+		t.Error("invalid json should never pass")
+	}
+}
+
+// assertionResult 带内容断言结果的请求
+func assertionResult(url string, assertions map[string]bool) result.ResultData {
+	start := time.Now()
+	return result.ResultData{
+		Type: result.Success, StartTime: start, EndTime: start.Add(time.Millisecond), ResponseTime: time.Millisecond,
+		StatusCode: 200, Method: "GET", URL: url, Assertions: assertions,
+	}
+}
+
+func TestContentRateThreshold(t *testing.T) {
+	var results []result.ResultData
+	for i := 0; i < 10; i++ {
+		// /search 上 results_non_empty 通过 8/10，/other 上始终失败
+		results = append(results, assertionResult("/search?q=1", map[string]bool{"results_non_empty": i < 8}))
+		results = append(results, assertionResult("/other", map[string]bool{"results_non_empty": false}))
+	}
+
+	cases := []struct {
+		threshold string
+		passed    bool
+		actual    float64
+	}{
+		{"content_rate{url=/search,assertion=results_non_empty} >= 80%", true, 80},
+		{"content_rate{url=/search,assertion=results_non_empty} >= 99%", false, 80},
+		{"content_rate{assertion=results_non_empty} >= 40%", true, 40},
+		{"content_rate{url=/missing,assertion=results_non_empty} >= 0%", false, 0},
+	}
+	for _, tc := range cases {
+		collector, _ := newTestCollector(t, result.CollectorConfig{Thresholds: []string{tc.threshold}})
+		stats, err := collector.GeneratePerformanceStats(results)
+		if err != nil {
+			t.Fatalf("failed to generate stats: %v", err)
+		}
+		thresholdResults, _ := collector.EvaluateThresholds(stats)
+		r := thresholdResults[0]
+		if r.Passed != tc.passed || r.Actual != tc.actual {
+			t.Errorf("%s: passed=%v actual=%v, want passed=%v actual=%v (%s)", tc.threshold, r.Passed, r.Actual, tc.passed, tc.actual, r.Message)
+		}
+	}
+}
+
+func TestAssertionsRoundTripThroughJTL(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "assertion_jtl"})
+	assertions := map[string]bool{
+		"results_non_empty": true,
+		"a;b":               false,
+		"key=value":         true,
+		"with,comma":        true,
+		"100%":              false,
+	}
+	collector.CollectResult(assertionResult("/search", assertions))
+	if err := collector.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(loaded))
+	}
+	if !reflect.DeepEqual(loaded[0].Assertions, assertions) {
+		t.Fatalf("assertions changed after jtl round trip:\ngot:  %v\nwant: %v", loaded[0].Assertions, assertions)
+	}
+}
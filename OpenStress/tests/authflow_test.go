@@ -0,0 +1,219 @@
+// authflow_test.go
+// 登录令牌测试模块
+// 本文件负责测试登录令牌的提取与注入、401 时自动重新登录、按有效期刷新，
+// 以及按虚拟用户登录和共用令牌两种会话模式。
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// authTestServer 模拟登录接口和需要令牌的业务接口
+type authTestServer struct {
+	*httptest.Server
+	logins    atomic.Int32
+	expiresIn int // 登录响应中的有效期（秒），0 表示不返回
+
+	mu    sync.Mutex
+	valid map[string]bool
+	users map[string]string // 令牌 -> 登录用户
+}
+
+func newAuthTestServer(t *testing.T, expiresIn int) *authTestServer {
+	t.Helper()
+	s := &authTestServer{expiresIn: expiresIn, valid: make(map[string]bool), users: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		token := fmt.Sprintf("token-%d", s.logins.Add(1))
+		s.mu.Lock()
+		s.valid[token] = true
+		s.users[token] = r.URL.Query().Get("user")
+		s.mu.Unlock()
+		if s.expiresIn > 0 {
+			fmt.Fprintf(w, `{"data":{"access_token":%q},"expires_in":%d}`, token, s.expiresIn)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"access_token":%q}}`, token)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		ok := s.valid[token]
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s:%s", token, body)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// revokeAll 让已签发的令牌全部失效
+func (s *authTestServer) revokeAll() {
+	s.mu.Lock()
+	s.valid = make(map[string]bool)
+	s.mu.Unlock()
+}
+
+// loginConfig 使用 JSON 路径提取令牌的登录配置
+func (s *authTestServer) loginConfig() tasks.LoginConfig {
+	return tasks.LoginConfig{
+		NewRequest: func(ctx context.Context, vuID int) (*http.Request, error) {
+			return http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/login?user=vu%d", s.URL, vuID), nil)
+		},
+		Extract: tasks.TokenExtractor{JSONPath: "$.data.access_token", ExpiresInPath: "$.expires_in"},
+	}
+}
+
+// callAPI 调用业务接口，返回状态码和响应体
+func callAPI(t *testing.T, client *http.Client, url, body string) (int, string) {
+	t.Helper()
+	resp, err := client.Post(url+"/api", "text/plain", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestAuthFlowInjectsTokenAndRefreshesOn401(t *testing.T) {
+	server := newAuthTestServer(t, 0)
+	flow, err := tasks.NewAuthFlow(server.loginConfig())
+	if err != nil {
+		t.Fatalf("failed to create auth flow: %v", err)
+	}
+	client := flow.Client(nil)
+
+	for i := 0; i < 3; i++ {
+		if status, body := callAPI(t, client, server.URL, "a"); status != http.StatusOK || body != "token-1:a" {
+			t.Fatalf("unexpected response: %d %q", status, body)
+		}
+	}
+	if flow.Logins() != 1 {
+		t.Errorf("expected a single login, got %d", flow.Logins())
+	}
+
+	// 令牌被服务端作废：重新登录后重试，请求体会被重放
+	server.revokeAll()
+	if status, body := callAPI(t, client, server.URL, "b"); status != http.StatusOK || body != "token-2:b" {
+		t.Fatalf("expected a retry with a new token, got %d %q", status, body)
+	}
+	if flow.Logins() != 2 {
+		t.Errorf("expected one login after the 401, got %d", flow.Logins())
+	}
+}
+
+func TestAuthFlowRefreshesBeforeExpiry(t *testing.T) {
+	server := newAuthTestServer(t, 1)
+	cfg := server.loginConfig()
+	cfg.RefreshBefore = 500 * time.Millisecond
+	flow, err := tasks.NewAuthFlow(cfg)
+	if err != nil {
+		t.Fatalf("failed to create auth flow: %v", err)
+	}
+
+	first, err := flow.Token(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get token: %v", err)
+	}
+	if again, _ := flow.Token(context.Background()); again != first {
+		t.Errorf("token should be reused before expiry, got %s then %s", first, again)
+	}
+	time.Sleep(600 * time.Millisecond)
+	if refreshed, _ := flow.Token(context.Background()); refreshed == first {
+		t.Error("token should be refreshed when it is about to expire")
+	}
+}
+
+func TestAuthSessionsPerVUAndShared(t *testing.T) {
+	server := newAuthTestServer(t, 0)
+
+	perVU, err := tasks.NewAuthSessions(server.loginConfig(), true)
+	if err != nil {
+		t.Fatalf("failed to create sessions: %v", err)
+	}
+	for vu := 0; vu < 3; vu++ {
+		token, err := perVU.ForVU(vu).Token(context.Background())
+		if err != nil {
+			t.Fatalf("vu %d failed to log in: %v", vu, err)
+		}
+		server.mu.Lock()
+		user := server.users[token]
+		server.mu.Unlock()
+		if user != fmt.Sprintf("vu%d", vu) {
+			t.Errorf("vu %d logged in as %q", vu, user)
+		}
+	}
+	if perVU.ForVU(1) != perVU.ForVU(1) {
+		t.Error("a vu should keep its session")
+	}
+
+	// 共用令牌：并发请求只登录一次
+	server.logins.Store(0)
+	shared, err := tasks.NewAuthSessions(server.loginConfig(), false)
+	if err != nil {
+		t.Fatalf("failed to create sessions: %v", err)
+	}
+	var wg sync.WaitGroup
+	for vu := 0; vu < 10; vu++ {
+		wg.Add(1)
+		go func(vu int) {
+			defer wg.Done()
+			if _, err := shared.ForVU(vu).Token(context.Background()); err != nil {
+				t.Errorf("vu %d failed to get token: %v", vu, err)
+			}
+		}(vu)
+	}
+	wg.Wait()
+	if got := server.logins.Load(); got != 1 {
+		t.Errorf("expected one shared login, got %d", got)
+	}
+}
+
+func TestAuthFlowRegexAndErrors(t *testing.T) {
+	server := newAuthTestServer(t, 0)
+	cfg := server.loginConfig()
+	cfg.Extract = tasks.TokenExtractor{Regex: `"access_token":"([^"]+)"`}
+	flow, err := tasks.NewAuthFlow(cfg)
+	if err != nil {
+		t.Fatalf("failed to create auth flow: %v", err)
+	}
+	if token, err := flow.Token(context.Background()); err != nil || token != "token-1" {
+		t.Errorf("regex extraction failed: %q %v", token, err)
+	}
+
+	cfg.Extract = tasks.TokenExtractor{JSONPath: "$.data.missing"}
+	flow, _ = tasks.NewAuthFlow(cfg)
+	if _, err := flow.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected an extraction error naming the path, got %v", err)
+	}
+
+	for name, extract := range map[string]tasks.TokenExtractor{
+		"none":          {},
+		"both":          {JSONPath: "token", Regex: "(.*)"},
+		"no group":      {Regex: "token-[0-9]+"},
+		"invalid regex": {Regex: "("},
+	} {
+		cfg.Extract = extract
+		if _, err := tasks.NewAuthFlow(cfg); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// autoscale_test.go
+// 自动扩缩容测试模块
+// 本文件负责测试扩缩容决策：队列深度与 CPU 使用率的各种组合、连续采样防抖、上下限约束和冷却时间。
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+)
+
+// autoScaleTestConfig 测试用的扩缩容配置：worker 数在 [2, 8]，每次调整 2 个，条件需连续满足 2 次
+var autoScaleTestConfig = pool.AutoScaleConfig{
+	MinWorkers:         2,
+	MaxWorkers:         8,
+	ScaleUpQueueDepth:  10,
+	ScaleDownIdleRatio: 0.5,
+	MaxCPUUsage:        80,
+	Step:               2,
+	StableIntervals:    2,
+}
+
+func TestAutoScalerDecide(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []pool.ScaleSample
+		want    []int // 每次采样后的目标 worker 数
+	}{
+		{
+			name:    "queue backlog scales up after stable intervals",
+			samples: []pool.ScaleSample{{QueueDepth: 20, Running: 4, Workers: 4}, {QueueDepth: 20, Running: 4, Workers: 4}},
+			want:    []int{4, 6},
+		},
+		{
+			name:    "queue depth at threshold does not scale up",
+			samples: []pool.ScaleSample{{QueueDepth: 10, Running: 4, Workers: 4}, {QueueDepth: 10, Running: 4, Workers: 4}},
+			want:    []int{4, 4},
+		},
+		{
+			name:    "scale up is clamped to max workers",
+			samples: []pool.ScaleSample{{QueueDepth: 50, Running: 7, Workers: 7}, {QueueDepth: 50, Running: 7, Workers: 7}},
+			want:    []int{7, 8},
+		},
+		{
+			name:    "no scale up at max workers",
+			samples: []pool.ScaleSample{{QueueDepth: 50, Running: 8, Workers: 8}, {QueueDepth: 50, Running: 8, Workers: 8}},
+			want:    []int{8, 8},
+		},
+		{
+			name:    "hot cpu blocks scale up and scales down",
+			samples: []pool.ScaleSample{{QueueDepth: 20, Running: 6, Workers: 6, CPUUsage: 90}, {QueueDepth: 20, Running: 6, Workers: 6, CPUUsage: 90}},
+			want:    []int{6, 4},
+		},
+		{
+			name:    "cpu below limit still scales up",
+			samples: []pool.ScaleSample{{QueueDepth: 20, Running: 4, Workers: 4, CPUUsage: 79}, {QueueDepth: 20, Running: 4, Workers: 4, CPUUsage: 79}},
+			want:    []int{4, 6},
+		},
+		{
+			name:    "empty queue and idle workers scale down",
+			samples: []pool.ScaleSample{{QueueDepth: 0, Running: 1, Workers: 6}, {QueueDepth: 0, Running: 1, Workers: 6}},
+			want:    []int{6, 4},
+		},
+		{
+			name:    "queued tasks prevent scale down",
+			samples: []pool.ScaleSample{{QueueDepth: 3, Running: 0, Workers: 6}, {QueueDepth: 3, Running: 0, Workers: 6}},
+			want:    []int{6, 6},
+		},
+		{
+			name:    "busy workers prevent scale down",
+			samples: []pool.ScaleSample{{QueueDepth: 0, Running: 4, Workers: 6}, {QueueDepth: 0, Running: 4, Workers: 6}},
+			want:    []int{6, 6},
+		},
+		{
+			name:    "scale down is clamped to min workers",
+			samples: []pool.ScaleSample{{QueueDepth: 0, Running: 0, Workers: 3}, {QueueDepth: 0, Running: 0, Workers: 3}},
+			want:    []int{3, 2},
+		},
+		{
+			name:    "no scale down at min workers even with hot cpu",
+			samples: []pool.ScaleSample{{QueueDepth: 0, Running: 2, Workers: 2, CPUUsage: 95}, {QueueDepth: 0, Running: 2, Workers: 2, CPUUsage: 95}},
+			want:    []int{2, 2},
+		},
+		{
+			name: "alternating conditions reset the streak",
+			samples: []pool.ScaleSample{
+				{QueueDepth: 20, Running: 4, Workers: 4},
+				{QueueDepth: 0, Running: 0, Workers: 4},
+				{QueueDepth: 20, Running: 4, Workers: 4},
+				{QueueDepth: 5, Running: 4, Workers: 4},
+				{QueueDepth: 20, Running: 4, Workers: 4},
+			},
+			want: []int{4, 4, 4, 4, 4},
+		},
+	}
+
+	taskPool := newTestPool(t, 4)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaler, err := taskPool.NewAutoScaler(autoScaleTestConfig, nil)
+			if err != nil {
+				t.Fatalf("failed to create auto scaler: %v", err)
+			}
+			now := time.Now()
+			for i, sample := range tc.samples {
+				now = now.Add(time.Second)
+				if got := scaler.Decide(sample, now); got != tc.want[i] {
+					t.Errorf("sample %d %+v: got %d workers, want %d", i, sample, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAutoScalerCooldown(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	cfg := autoScaleTestConfig
+	cfg.StableIntervals = 1
+	cfg.Cooldown = 5 * time.Second
+	scaler, err := taskPool.NewAutoScaler(cfg, nil)
+	if err != nil {
+		t.Fatalf("failed to create auto scaler: %v", err)
+	}
+
+	backlog := pool.ScaleSample{QueueDepth: 20, Running: 4, Workers: 4}
+	now := time.Now()
+	if got := scaler.Decide(backlog, now); got != 6 {
+		t.Fatalf("first decision: got %d, want 6", got)
+	}
+	backlog.Workers, backlog.Running = 6, 6
+	if got := scaler.Decide(backlog, now.Add(2*time.Second)); got != 6 {
+		t.Errorf("decision within cooldown: got %d, want 6", got)
+	}
+	if got := scaler.Decide(backlog, now.Add(6*time.Second)); got != 8 {
+		t.Errorf("decision after cooldown: got %d, want 8", got)
+	}
+}
+
+func TestAutoScalerRejectsInvalidRange(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	for _, cfg := range []pool.AutoScaleConfig{
+		{MinWorkers: 0, MaxWorkers: 4},
+		{MinWorkers: 4, MaxWorkers: 2},
+	} {
+		if _, err := taskPool.NewAutoScaler(cfg, nil); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
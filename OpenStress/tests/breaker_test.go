@@ -0,0 +1,155 @@
+// breaker_test.go
+// 接口熔断测试模块
+// 本文件负责测试 HTTP 任务层按接口的熔断器：连续失败后断开并快速失败、不影响其他接口，
+// 半开探测成功后闭合、失败后重新断开，以及状态变化被收集器记录并在报告中生成熔断状态图。
+
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// newFlakyServer 创建测试服务：/dead 在 healthy 为 false 时返回 503，其他路径返回 200；hits 统计 /dead 收到的请求数
+func newFlakyServer(t *testing.T, healthy *atomic.Bool, hits *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" {
+			hits.Add(1)
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// breakerGet 发送 GET 请求，返回状态码（请求失败时为 0）和错误
+func breakerGet(client *http.Client, url string) (int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var hits atomic.Int32
+	server := newFlakyServer(t, &healthy, &hits)
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+
+	client, err := tasks.NewHTTPClient(tasks.HTTPClientConfig{Breaker: &tasks.BreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      100 * time.Millisecond,
+		OnStateChange:    collector.RecordBreakerTransition,
+	}})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	breaker := client.Transport.(*tasks.CircuitBreakerTransport)
+	dead := server.URL + "/dead"
+
+	for i := 0; i < 3; i++ {
+		if status, err := breakerGet(client, dead+"?i=1"); err != nil || status != http.StatusServiceUnavailable {
+			t.Fatalf("request %d: expected 503, got %d %v", i, status, err)
+		}
+	}
+	if state := breaker.State(dead); state != result.BreakerOpen {
+		t.Fatalf("breaker should open after 3 failures, got %s", state)
+	}
+	// 断开期间直接失败，不发送到下游；其他接口不受影响
+	if _, err := breakerGet(client, dead); !errors.Is(err, tasks.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("open breaker should not send requests, server got %d", hits.Load())
+	}
+	if status, err := breakerGet(client, server.URL+"/ok"); err != nil || status != http.StatusOK {
+		t.Errorf("other endpoints should not be affected: %d %v", status, err)
+	}
+
+	// 半开探测失败后重新断开
+	time.Sleep(120 * time.Millisecond)
+	if status, _ := breakerGet(client, dead); status != http.StatusServiceUnavailable || breaker.State(dead) != result.BreakerOpen {
+		t.Errorf("a failed probe should reopen the breaker, got %d %s", status, breaker.State(dead))
+	}
+
+	// 下游恢复后探测成功并闭合
+	healthy.Store(true)
+	time.Sleep(120 * time.Millisecond)
+	if status, err := breakerGet(client, dead); err != nil || status != http.StatusOK {
+		t.Fatalf("probe should be sent after the open timeout: %d %v", status, err)
+	}
+	if state := breaker.State(dead); state != result.BreakerClosed {
+		t.Errorf("breaker should close after a successful probe, got %s", state)
+	}
+
+	transitions := collector.BreakerTransitions()
+	var path []string
+	for _, tr := range transitions {
+		if tr.Endpoint != dead {
+			t.Errorf("unexpected endpoint %s", tr.Endpoint)
+		}
+		path = append(path, string(tr.To))
+	}
+	if got := strings.Join(path, ","); got != "open,half_open,open,half_open,closed" {
+		t.Errorf("unexpected transitions %s", got)
+	}
+
+	now := time.Now()
+	stats, err := collector.GeneratePerformanceStats([]result.ResultData{{ID: "r", Type: result.Success, StartTime: now.Add(-time.Second), EndTime: now}})
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	html := result.GenerateSelfContainedHTMLReport(stats, "breaker")
+	if !strings.Contains(html, "接口熔断状态") || !strings.Contains(html, "半开") {
+		t.Error("report should include the circuit breaker chart")
+	}
+	if n := strings.Count(html, "echarts.init("); n != 4 {
+		t.Errorf("expected 4 inline charts including the breaker chart, got %d", n)
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimitsProbes(t *testing.T) {
+	release := make(chan struct{})
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) > 1 {
+			<-release // 探测请求阻塞，直到测试放行
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	breaker := tasks.NewCircuitBreakerTransport(http.DefaultTransport, tasks.BreakerConfig{FailureThreshold: 1, OpenTimeout: 50 * time.Millisecond})
+	client := &http.Client{Transport: breaker}
+	if status, _ := breakerGet(client, server.URL); status != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", status)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// 第一个探测请求阻塞在服务端，其他请求在半开状态下直接失败
+	go breakerGet(client, server.URL)
+	waitFor(t, func() bool { return hits.Load() == 2 })
+	if _, err := breakerGet(client, server.URL); !errors.Is(err, tasks.ErrCircuitOpen) {
+		t.Errorf("only one probe should be allowed while half-open, got %v", err)
+	}
+	endpoint, _ := url.Parse(server.URL)
+	if state := breaker.State(tasks.BreakerEndpoint(endpoint)); state != result.BreakerHalfOpen {
+		t.Errorf("expected half-open, got %s", state)
+	}
+}
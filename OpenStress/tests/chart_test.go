@@ -0,0 +1,175 @@
+// chart_test.go
+// 静态图表图片测试模块
+// 本文件负责测试 PNG/SVG 静态图表的生成：只有一个点的序列、数值全部相同的序列，以及某张图失败时其余图照常生成；
+// 以及趋势图的按桶聚合、短时间测试和空序列的处理；吞吐量-响应时间关系图的每秒采样和展示条件。
+
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestGenerateChartImagesRendersChartsIndependently(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Unix()
+	stats := map[string]interface{}{
+		// 运行不足两秒：每个序列只有一个点
+		"AvgTpsStartTime": start,
+		"TPSValues":       []int{42},
+		"SuccessValues":   []int{40},
+		"FailureValues":   []int{2},
+		// 没有响应时间数据，该图无法生成
+		"AvgResponseStartTime": start,
+		// 所有点数值相同
+		"AvgTrafficStartTime":      start,
+		"AvgSentTrafficValues":     []int{0, 0, 0},
+		"AvgReceivedTrafficValues": []int{0, 0, 0},
+	}
+
+	paths, err := result.GenerateChartImages(stats, dir)
+	if err == nil || !strings.Contains(err.Error(), "response_time_chart") {
+		t.Fatalf("expected an error for the response time chart, got %v", err)
+	}
+	if strings.Contains(err.Error(), "tps_chart") || strings.Contains(err.Error(), "flow_trend_chart") {
+		t.Fatalf("charts with data should render: %v", err)
+	}
+
+	want := []string{"tps_chart.png", "tps_chart.svg", "flow_trend_chart.png", "flow_trend_chart.svg"}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %d images, got %v", len(want), paths)
+	}
+	for _, name := range want {
+		info, statErr := os.Stat(filepath.Join(dir, name))
+		if statErr != nil || info.Size() == 0 {
+			t.Errorf("%s was not rendered: %v", name, statErr)
+		}
+	}
+	for _, name := range []string{"response_time_chart.png", "response_time_chart.svg"} {
+		if _, statErr := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(statErr) {
+			t.Errorf("%s should not be left behind after a failed render", name)
+		}
+	}
+}
+
+func TestTrendChartKeepsSpikesInLongRuns(t *testing.T) {
+	dir := t.TempDir()
+	// 6 小时每秒一个点，其中只有 1 秒出现毛刺
+	values := make([]int, 6*3600)
+	for i := range values {
+		values[i] = 100
+	}
+	values[12345] = 999
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local).Unix()
+
+	path, err := result.GenerateTpsChartAsync(values, values, make([]int, len(values)), start, start+int64(len(values)-1), dir)
+	if err != nil {
+		t.Fatalf("failed to generate chart: %v", err)
+	}
+	page, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read chart: %v", err)
+	}
+	html := string(page)
+	if !strings.Contains(html, `"value":999`) {
+		t.Error("the spike should survive downsampling")
+	}
+	if n := strings.Count(html, `"value":`); n != 3*result.DefaultChartBuckets {
+		t.Errorf("expected %d points, got %d", 3*result.DefaultChartBuckets, n)
+	}
+	if !strings.Contains(html, "每点为 180 秒内的最大值") || !strings.Contains(html, `"08:00:00"`) || !strings.Contains(html, `"13:57:00"`) {
+		t.Error("chart should label buckets by their start time and note the bucket width")
+	}
+
+	// 短时间测试每秒一个点，不注明桶宽
+	path, err = result.GenerateTpsChartAsync([]int{1, 5, 2}, []int{1, 5, 2}, []int{0, 0, 0}, start, start+2, dir)
+	if err != nil {
+		t.Fatalf("failed to generate chart: %v", err)
+	}
+	page, _ = os.ReadFile(path)
+	if strings.Count(string(page), `"value":`) != 9 || strings.Contains(string(page), "每点为") {
+		t.Error("short runs should plot every second")
+	}
+}
+
+func TestChartAggregationConfig(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{ChartBuckets: 10, ChartAggregation: "P95"})
+	now := time.Now()
+	collector.SaveSuccessResult(result.ResultData{ID: "r", Type: result.Success, StartTime: now, EndTime: now.Add(time.Millisecond), ResponseTime: time.Millisecond})
+	results, _ := collector.LoadResultsFromFile()
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if stats["ChartBuckets"] != 10 || stats["ChartAggregation"] != result.ChartAggregateP95 {
+		t.Errorf("unexpected chart options: %v, %v", stats["ChartBuckets"], stats["ChartAggregation"])
+	}
+
+	if _, err := result.NewCollector(result.CollectorConfig{ChartAggregation: "median"}); err == nil || !strings.Contains(err.Error(), "unsupported chart aggregation") {
+		t.Errorf("expected an unsupported aggregation error, got %v", err)
+	}
+}
+
+func TestTrendChartShortRunsAndEmptySeries(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Unix()
+	// 运行不足 1 秒，每个序列只有一个点
+	path, err := result.GenerateResponseTimeChartAsync([]int{12}, []int{12}, []int{0}, start, start, dir)
+	if err != nil {
+		t.Fatalf("a one-second run should still produce a chart: %v", err)
+	}
+	page, _ := os.ReadFile(path)
+	if strings.Count(string(page), `"value":`) != 3 {
+		t.Error("expected one point per series")
+	}
+
+	_, err = result.GenerateTpsChartAsync([]int{1}, nil, []int{0}, start, start, dir)
+	var chartErr *result.ChartError
+	if !errors.As(err, &chartErr) || chartErr.Chart != "tps_chart" || chartErr.Series != "SuccessValues" || !errors.Is(err, result.ErrEmptySeries) {
+		t.Errorf("expected a structured empty series error, got %v", err)
+	}
+}
+
+func TestLatencyThroughputChart(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	start := time.Unix(time.Now().Unix(), 0)
+	// 阶梯加压：第 n 秒发出 n*5 个请求，负载越高响应时间越长
+	var results []result.ResultData
+	for sec := 1; sec <= 4; sec++ {
+		for i := 0; i < sec*5; i++ {
+			r := result.ResultData{Type: result.Success, StatusCode: 200, ResponseTime: time.Duration(sec*10+i) * time.Millisecond}
+			r.StartTime = start.Add(time.Duration(sec)*time.Second + time.Duration(i)*time.Millisecond)
+			r.EndTime = r.StartTime.Add(r.ResponseTime)
+			results = append(results, r)
+		}
+	}
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+
+	samples, _ := stats["ThroughputSamples"].([]result.ThroughputSample)
+	if len(samples) != 4 {
+		t.Fatalf("expected one sample per second, got %+v", samples)
+	}
+	last := samples[3]
+	if last.TPS != 20 || last.AvgResponseTime != 49500*time.Microsecond || last.P95ResponseTime < 55*time.Millisecond || last.P95ResponseTime > 59*time.Millisecond {
+		t.Errorf("unexpected sample for the busiest second: %+v", last)
+	}
+	content := result.GenerateSelfContainedHTMLReport(stats, "knee")
+	if !strings.Contains(content, "吞吐量-响应时间关系") {
+		t.Error("the latency vs throughput chart should be embedded when the load varies")
+	}
+
+	// 负载基本不变时图中只有一两个点，不展示该图
+	content = result.GenerateSelfContainedHTMLReport(lockTestStats(t, collector), "flat")
+	if strings.Contains(content, "吞吐量-响应时间关系") {
+		t.Error("the latency vs throughput chart should be omitted for a flat load")
+	}
+}
@@ -0,0 +1,181 @@
+// checkpoint_test.go
+// 检查点与断点续跑测试模块
+// 本文件负责测试任务池进度记录、检查点的保存与读取、定期保存，
+// 以及收集器从中断的运行继续写入同一个 JTL 文件。
+
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/checkpoint"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// saveCheckpointResult 保存一条成功或失败的结果
+func saveCheckpointResult(collector *result.Collector, success bool) {
+	now := time.Now()
+	data := result.ResultData{ID: "req", Type: result.Success, StartTime: now, EndTime: now.Add(10 * time.Millisecond), StatusCode: 200, Method: "GET", URL: "http://example.com"}
+	if !success {
+		data.Type = result.Failure
+		data.StatusCode = 500
+		data.ErrorMessage = "server error"
+		collector.SaveFailureResult(data)
+		return
+	}
+	collector.SaveSuccessResult(data)
+}
+
+func TestPoolProgressSkipsFinishedTasks(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	progress := pool.NewProgress([]string{"done-1"})
+	taskPool.SetProgress(progress)
+
+	if err := taskPool.Submit(func(int32) {}, 0, "done-1", 0); !errors.Is(err, pool.ErrTaskFinished) {
+		t.Fatalf("expected ErrTaskFinished for a task finished in a previous run, got %v", err)
+	}
+	if err := taskPool.Submit(func(int32) {}, 0, "new-1", 0); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	if err := taskPool.Submit(func(int32) { panic("boom") }, 0, "new-2", 0); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	// 执行失败（panic）的任务同样记录为已结束
+	waitFor(t, func() bool { return progress.Len() == 3 })
+	if got := progress.TaskIDs(); !reflect.DeepEqual(got, []string{"done-1", "new-1", "new-2"}) {
+		t.Errorf("unexpected finished tasks: %v", got)
+	}
+	if err := taskPool.Submit(func(int32) {}, 0, "new-1", 0); !errors.Is(err, pool.ErrTaskFinished) {
+		t.Errorf("expected ErrTaskFinished for a finished task, got %v", err)
+	}
+
+	// 关闭进度记录后任务ID可以再次提交
+	taskPool.SetProgress(nil)
+	if err := taskPool.Submit(func(int32) {}, 0, "new-1", 0); err != nil {
+		t.Errorf("task should be accepted without progress tracking: %v", err)
+	}
+}
+
+func TestCheckpointSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.checkpoint.json")
+
+	if _, err := checkpoint.Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not-exist error for a missing checkpoint, got %v", err)
+	}
+
+	want := &checkpoint.Checkpoint{
+		TaskID:        "resumable",
+		JTLFilePath:   filepath.Join(dir, "run.jtl"),
+		SavedAt:       time.Now().Truncate(time.Second),
+		Counters:      result.CollectorCounters{Written: 3, Success: 2, Failure: 1},
+		FinishedTasks: []string{"a", "b"},
+	}
+	if err := checkpoint.Save(path, want); err != nil {
+		t.Fatalf("failed to save checkpoint: %v", err)
+	}
+	got, err := checkpoint.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+	if got.TaskID != want.TaskID || got.JTLFilePath != want.JTLFilePath || !got.SavedAt.Equal(want.SavedAt) ||
+		got.Counters != want.Counters || !reflect.DeepEqual(got.FinishedTasks, want.FinishedTasks) {
+		t.Errorf("checkpoint changed after a round trip: got %+v, want %+v", got, want)
+	}
+
+	// 临时文件在重命名后不应残留
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the checkpoint file, got %d entries", len(entries))
+	}
+
+	if err := checkpoint.Remove(path); err != nil {
+		t.Fatalf("failed to remove checkpoint: %v", err)
+	}
+	if err := checkpoint.Remove(path); err != nil {
+		t.Errorf("removing a missing checkpoint should not fail: %v", err)
+	}
+}
+
+func TestCollectorResumesJTL(t *testing.T) {
+	dir := t.TempDir()
+	first, _ := newTestCollector(t, result.CollectorConfig{JTLFilePath: filepath.Join(dir, "run.jtl"), TaskID: "resumable"})
+	saveCheckpointResult(first, true)
+	saveCheckpointResult(first, true)
+	saveCheckpointResult(first, false)
+	if got := first.Counters(); got != (result.CollectorCounters{Written: 3, Success: 2, Failure: 1}) {
+		t.Fatalf("unexpected counters: %+v", got)
+	}
+	jtlPath := first.JTLFilePath()
+	first.Close()
+
+	// 模拟崩溃时写了一半的最后一行
+	file, err := os.OpenFile(jtlPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open JTL file: %v", err)
+	}
+	file.WriteString("1700000000000,12,GET,20")
+	file.Close()
+
+	resumed, _ := newTestCollector(t, result.CollectorConfig{JTLFilePath: filepath.Join(dir, "run.jtl"), TaskID: "resumable", ResumeJTLFilePath: jtlPath})
+	defer resumed.Close()
+	if resumed.JTLFilePath() != jtlPath {
+		t.Fatalf("resumed collector writes to %s, want %s", resumed.JTLFilePath(), jtlPath)
+	}
+	if got := resumed.Counters(); got != (result.CollectorCounters{Written: 3, Success: 2, Failure: 1}) {
+		t.Errorf("counters should continue from the existing JTL records: %+v", got)
+	}
+	saveCheckpointResult(resumed, false)
+	if got := resumed.Counters(); got != (result.CollectorCounters{Written: 4, Success: 2, Failure: 2}) {
+		t.Errorf("unexpected counters after resuming: %+v", got)
+	}
+
+	results, err := resumed.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load merged results: %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("expected 4 merged results, got %d", len(results))
+	}
+}
+
+func TestCheckpointWriterSavesProgress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.checkpoint.json")
+	taskPool := newTestPool(t, 2)
+	collector, _ := newTestCollector(t, result.CollectorConfig{JTLFilePath: filepath.Join(dir, "run.jtl"), TaskID: "resumable"})
+	defer collector.Close()
+
+	writer := checkpoint.Start(path, "resumable", 20*time.Millisecond, collector, taskPool, pool.NewProgress([]string{"task-0"}))
+	for _, id := range []string{"task-0", "task-1", "task-2"} {
+		taskPool.Submit(func(int32) { saveCheckpointResult(collector, true) }, 0, id, 0)
+	}
+
+	// 定期保存的检查点包含已结束的任务
+	waitFor(t, func() bool {
+		cp, err := checkpoint.Load(path)
+		return err == nil && len(cp.FinishedTasks) == 3
+	})
+	if err := writer.Stop(); err != nil {
+		t.Fatalf("failed to save the final checkpoint: %v", err)
+	}
+	cp, err := checkpoint.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+	if cp.TaskID != "resumable" || cp.JTLFilePath != collector.JTLFilePath() {
+		t.Errorf("unexpected checkpoint: %+v", cp)
+	}
+	if cp.Counters.Written != 2 || cp.Pool.Completed != 2 {
+		t.Errorf("expected 2 results from 2 completed tasks, got counters %+v and pool %+v", cp.Counters, cp.Pool)
+	}
+	if !reflect.DeepEqual(cp.FinishedTasks, []string{"task-0", "task-1", "task-2"}) {
+		t.Errorf("unexpected finished tasks: %v", cp.FinishedTasks)
+	}
+}
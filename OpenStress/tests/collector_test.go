@@ -0,0 +1,250 @@
+// collector_test.go
+// 结果收集器测试模块
+// 本文件负责测试结果收集器的关闭流程：重复关闭、并发关闭、关闭时写出未处理的结果以及关闭后提交结果；
+// 异步保存结果（Flush 等待已提交的结果写入，关闭后保存返回 ErrCollectorClosed）；
+// 以及并发保存结果时自动生成的样本ID唯一，并随 JTL 文件保存和加载；按标签划分的子收集器自动填写标签和请求信息。
+// 各测试共用的结果收集器由本文件的 newTestCollector 创建。
+
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// newTestCollector 创建用于测试的结果收集器，JTL 文件和报告写入临时目录，测试结束时自动关闭。
+// cfg 中未设置的日志、JTL 路径、任务ID和报告目录使用测试默认值，返回收集器和报告目录
+func newTestCollector(t testing.TB, cfg result.CollectorConfig) (*result.Collector, string) {
+	t.Helper()
+	if cfg.Logger == nil {
+		logger, err := pool.InitializeLogger(t.TempDir()+"/", "collector_test.log", "CollectorTest")
+		if err != nil {
+			t.Fatalf("failed to initialize logger: %v", err)
+		}
+		cfg.Logger = logger
+	}
+	if cfg.JTLFilePath == "" {
+		cfg.JTLFilePath = filepath.Join(t.TempDir(), "collector.jtl")
+	}
+	if cfg.TaskID == "" {
+		cfg.TaskID = "test"
+	}
+	if cfg.ReportDir == "" {
+		cfg.ReportDir = t.TempDir()
+	}
+	collector, err := result.NewCollector(cfg)
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	t.Cleanup(func() { collector.Close() })
+	return collector, cfg.ReportDir
+}
+
+func TestCollectorCloseFlushesPendingResults(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "close_flush"})
+
+	start := time.Now()
+	for i := 0; i < 200; i++ {
+		collector.CollectResult(result.ResultData{
+			Type: result.Success, StartTime: start, EndTime: start.Add(time.Millisecond),
+			StatusCode: 200, URL: "/", Method: "GET",
+		})
+	}
+	if err := collector.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+
+	if n := len(collector.Results()); n != 200 {
+		t.Fatalf("expected 200 results flushed on close, got %d", n)
+	}
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if len(loaded) != 200 {
+		t.Fatalf("expected 200 results in JTL, got %d", len(loaded))
+	}
+
+	// 关闭后提交的结果被丢弃，不会 panic
+	collector.CollectResult(result.ResultData{Type: result.Success, StartTime: start, EndTime: start})
+	if n := len(collector.Results()); n != 200 {
+		t.Fatalf("result collected after close: got %d results", n)
+	}
+}
+
+func TestCollectorConcurrentClose(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "close_concurrent"})
+
+	stop := make(chan struct{})
+	var writers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				now := time.Now()
+				collector.CollectResult(result.ResultData{Type: result.Failure, StartTime: now, EndTime: now, StatusCode: 500})
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	var closers sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		closers.Add(1)
+		go func(i int) {
+			defer closers.Done()
+			if i%2 == 0 {
+				errs <- collector.Close()
+			} else {
+				errs <- collector.CloseCollector()
+			}
+		}(i)
+	}
+	closers.Wait()
+	close(stop)
+	writers.Wait()
+
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("close returned error: %v", err)
+		}
+	}
+	if err := collector.Close(); err != nil {
+		t.Fatalf("repeated close returned error: %v", err)
+	}
+}
+
+func TestCollectorGeneratesUniqueSampleIDs(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "sample_ids"})
+
+	start := time.Now()
+	var writers sync.WaitGroup
+	for vu := 1; vu <= 4; vu++ {
+		writers.Add(1)
+		go func(vu int) {
+			defer writers.Done()
+			for i := 0; i < 50; i++ {
+				data := result.ResultData{Type: result.Success, StartTime: start, EndTime: start.Add(time.Millisecond), StatusCode: 200}
+				if vu%2 == 0 {
+					data.TraceID, data.VUID, data.Iteration = "trace", vu, i
+				}
+				collector.CollectResult(data)
+			}
+		}(vu)
+	}
+	writers.Wait()
+	collector.SaveSuccessResult(result.ResultData{ID: "checkout", Type: result.Success, StartTime: start, EndTime: start})
+	if err := collector.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, r := range collector.Results() {
+		if ids[r.ID] {
+			t.Fatalf("duplicate sample id %q", r.ID)
+		}
+		ids[r.ID] = true
+		if r.TraceID != "" && !strings.HasPrefix(r.ID, fmt.Sprintf("sample_ids-vu%d-it%d-", r.VUID, r.Iteration)) {
+			t.Errorf("sample id %q should carry the VU and iteration", r.ID)
+		}
+	}
+	if len(ids) != 201 || !ids["checkout"] {
+		t.Fatalf("expected 201 distinct ids including the caller supplied one, got %d", len(ids))
+	}
+
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	for _, r := range loaded {
+		if !ids[r.ID] {
+			t.Fatalf("sample id %q not preserved in the JTL file", r.ID)
+		}
+	}
+}
+
+func TestLabeledCollector(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "labels"})
+	login := collector.WithLabel("login").WithRequest("POST", "http://example.com/login")
+	search := login.WithLabel("search")
+
+	start := time.Now()
+	var rc result.ResultCollector = login
+	rc.SaveSuccessResult(result.ResultData{Type: result.Success, StartTime: start, EndTime: start, StatusCode: 200})
+	login.CollectResult(result.ResultData{Type: result.Failure, StartTime: start, EndTime: start, StatusCode: 500})
+	search.SaveSuccessResult(result.ResultData{Type: result.Success, StartTime: start, EndTime: start, StatusCode: 200, URL: "http://example.com/search?q=go"})
+	if err := collector.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	labels := make(map[string]int)
+	for _, r := range loaded {
+		labels[r.Label]++
+		if r.Label == "login" && (r.URL != "http://example.com/login" || r.Method != "POST") {
+			t.Errorf("login results should carry the default request: %+v", r)
+		}
+		if r.Label == "search" && r.URL != "http://example.com/search?q=go" {
+			t.Errorf("an explicit URL should not be overwritten: %+v", r)
+		}
+	}
+	if len(loaded) != 3 || labels["login"] != 2 || labels["search"] != 1 || login.Label() != "login" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+}
+
+func TestCollectorSavesAsynchronously(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "async_save"})
+
+	start := time.Now()
+	var writers sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for i := 0; i < 250; i++ {
+				// 超过数据通道容量时等待处理协程而不是丢弃结果
+				if err := collector.SaveFailureResult(result.ResultData{Type: result.Failure, StartTime: start, EndTime: start, StatusCode: 503}); err != nil {
+					t.Errorf("save failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	writers.Wait()
+	collector.Flush()
+	results := collector.Results()
+	if len(results) != 2000 || results[0].Type != result.Failure {
+		t.Fatalf("expected 2000 failures after flush, got %d", len(results))
+	}
+	if counters := collector.Counters(); counters.Written != 2000 || counters.Failure != 2000 {
+		t.Errorf("unexpected counters: %+v", counters)
+	}
+
+	if err := collector.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+	if err := collector.SaveSuccessResult(result.ResultData{StartTime: start, EndTime: start}); !errors.Is(err, result.ErrCollectorClosed) {
+		t.Errorf("expected ErrCollectorClosed after close, got %v", err)
+	}
+	collector.Flush() // 关闭后不阻塞
+}
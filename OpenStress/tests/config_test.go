@@ -0,0 +1,235 @@
+// config_test.go
+// 配置热加载测试模块
+// 本文件负责测试统一配置文件与环境变量覆盖、配置来源记录与生效配置输出、运行时配置的校验和差异比较，以及配置文件变化后热加载到协程池（并发数、限流和日志级别）。
+
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"go.uber.org/zap"
+)
+
+// writeRuntimeConfig 写入运行时配置文件：先写临时文件再重命名，与编辑器的保存方式一致
+func writeRuntimeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write runtime config: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to replace runtime config: %v", err)
+	}
+}
+
+// nextConfigEvent 等待下一个配置变化通知
+func nextConfigEvent(t *testing.T, events <-chan config.ConfigEvent) config.ConfigEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(3 * time.Second):
+		t.Fatal("no config event before timeout")
+		return config.ConfigEvent{}
+	}
+}
+
+func TestRuntimeConfigValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runtime.yaml")
+	for content, want := range map[string]string{
+		"log_level: LOUD\n":         "invalid log level",
+		"workers: -1\n":             "workers must not be negative",
+		"rate_limit:\n  rate: -5\n": "rate_limit",
+		"task_rate_limits:\n  Task_HTTP:\n    burst: -1\n": "task_rate_limits.Task_HTTP",
+		"worker: 4\n": "failed to parse runtime config",
+	} {
+		writeRuntimeConfig(t, path, content)
+		if _, err := config.LoadRuntimeConfig(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", content, want, err)
+		}
+	}
+
+	cfg, err := config.LoadRuntimeConfig("../config/runtime.example.yaml")
+	if err != nil || cfg.Workers != 20 || cfg.TaskRateLimits["Task_HTTP"].Rate != 200 {
+		t.Errorf("example runtime config should load, got %+v (%v)", cfg, err)
+	}
+}
+
+func TestDiffRuntimeConfig(t *testing.T) {
+	old := config.RuntimeConfig{LogLevel: "info", Workers: 4, TaskRateLimits: map[string]config.RateLimitConfig{"a": {Rate: 1}, "b": {Rate: 2}}}
+	updated := config.RuntimeConfig{LogLevel: "INFO", Workers: 8, RateLimit: config.RateLimitConfig{Rate: 100, Burst: 10}, TaskRateLimits: map[string]config.RateLimitConfig{"b": {Rate: 3}, "c": {Rate: 4}}}
+
+	var got []string
+	for _, change := range config.DiffRuntimeConfig(old, updated) {
+		got = append(got, change.String())
+	}
+	want := []string{"workers: 4 -> 8", "rate_limit: {0 0} -> {100 10}", "task_rate_limits.a: {1 0} -> {0 0}", "task_rate_limits.b: {2 0} -> {3 0}", "task_rate_limits.c: {0 0} -> {4 0}"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestConfigWatcherHotReload(t *testing.T) {
+	if _, err := pool.InitializeLogger(t.TempDir()+"/", "config_test.log", "ConfigTest"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer pool.SetLogLevel("INFO")
+	taskPool := pool.NewPool(2)
+	if taskPool == nil {
+		t.Fatal("failed to create pool")
+	}
+	defer taskPool.Shutdown()
+
+	path := filepath.Join(t.TempDir(), "runtime.yaml")
+	writeRuntimeConfig(t, path, "workers: 3\ntask_rate_limits:\n  Task_HTTP:\n    rate: 50\n    burst: 5\n")
+	watcher, err := config.NewConfigWatcher(path)
+	if err != nil {
+		t.Fatalf("failed to create config watcher: %v", err)
+	}
+	defer watcher.Close()
+	watcher.Debounce = 20 * time.Millisecond
+	current, version := watcher.Current()
+	if version != 1 || current.Workers != 3 {
+		t.Fatalf("unexpected initial config: %+v (version %d)", current, version)
+	}
+	if err := taskPool.ApplyConfigChanges(config.DiffRuntimeConfig(config.RuntimeConfig{}, current)); err != nil {
+		t.Fatalf("failed to apply initial config: %v", err)
+	}
+
+	events, unsubscribe := watcher.Subscribe(4)
+	defer unsubscribe()
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start config watcher: %v", err)
+	}
+
+	// 修改后的配置生效，事件中只包含变化的配置项
+	writeRuntimeConfig(t, path, "log_level: debug\nworkers: 6\nrate_limit:\n  rate: 100\n  burst: 10\n")
+	event := nextConfigEvent(t, events)
+	if event.Err != nil || event.Version != 2 || len(event.Changes) != 4 {
+		t.Fatalf("unexpected config event: %+v", event)
+	}
+	if err := taskPool.ApplyConfigChanges(event.Changes); err != nil {
+		t.Fatalf("failed to apply config changes: %v", err)
+	}
+	stats := taskPool.Stats()
+	if stats.MaxWorkers != 6 || stats.RateLimit.GlobalRate != 100 || len(stats.RateLimit.TaskRates) != 0 {
+		t.Errorf("config changes not applied to the pool: %+v", stats)
+	}
+	if pool.DefaultLogLevel != zap.DebugLevel {
+		t.Errorf("log level should be DEBUG, got %v", pool.DefaultLogLevel)
+	}
+
+	// 无效配置不生效，订阅者收到错误
+	writeRuntimeConfig(t, path, "workers: -2\n")
+	event = nextConfigEvent(t, events)
+	if event.Err == nil || event.Version != 2 || event.Config.Workers != 6 {
+		t.Fatalf("invalid config should be rejected and keep version 2: %+v", event)
+	}
+	if current, version := watcher.Current(); version != 2 || current.Workers != 6 {
+		t.Errorf("current config should be unchanged, got %+v (version %d)", current, version)
+	}
+
+	// 关闭后通道被关闭
+	watcher.Close()
+	for range events {
+	}
+}
+
+func TestLoadUnifiedConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openstress.yaml")
+	if _, err := config.LoadConfig("../config/openstress.example.yaml"); err != nil {
+		t.Errorf("example config should load: %v", err)
+	}
+	for content, want := range map[string]string{
+		"pool:\n  worker: 4\n":                  "failed to parse config file",
+		"pool:\n  workers: 0\n":                 "pool.workers must be positive",
+		"tracing:\n  sample_ratio: 2\n":         "tracing.sample_ratio",
+		"api:\n  enabled: true\n  addr: \"\"\n": "api.addr is required",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if _, err := config.LoadConfig(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", content, want, err)
+		}
+	}
+	content := "pool:\n  workers: 32\n  rate_limit: 200\ncollector:\n  task_id: nightly\nreport:\n  language: en-US\n  thresholds: [\"p95 < 800ms\"]\napi:\n  enabled: true\nauth:\n  redis:\n    addr: redis:6379\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("OPENSTRESS_POOL_WORKERS", "64")
+	t.Setenv("OPENSTRESS_AUTH_REDIS_PASSWORD", "secret")
+	t.Setenv("OPENSTRESS_REPORT_THRESHOLDS", "p99 < 1s, error_rate < 1%")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	// 环境变量优先于配置文件，未出现的配置项保持默认值
+	if cfg.Pool.Workers != 64 || cfg.Pool.RateLimit != 200 || cfg.Collector.TaskID != "nightly" || cfg.Collector.JTLFilePath != "./results/api.jtl" {
+		t.Errorf("unexpected pool/collector config: %+v %+v", cfg.Pool, cfg.Collector)
+	}
+	if cfg.Report.Language != "en-US" || strings.Join(cfg.Thresholds, "|") != "p99 < 1s|error_rate < 1%" {
+		t.Errorf("unexpected report config: %+v %v", cfg.Report, cfg.Thresholds)
+	}
+	if !cfg.EnableAPIServer || cfg.APIAddr != ":8080" || cfg.AuthConfigPath != "config/auth.yaml" || cfg.AuthRedis.Addr != "redis:6379" || cfg.AuthRedis.Password != "secret" {
+		t.Errorf("unexpected api/auth config: %+v", cfg)
+	}
+
+	t.Setenv("OPENSTRESS_POOL_WORKERS", "many")
+	if _, err := config.LoadConfig(""); err == nil || !strings.Contains(err.Error(), "OPENSTRESS_POOL_WORKERS") {
+		t.Errorf("expected an invalid environment variable error, got %v", err)
+	}
+}
+
+func TestDumpEffectiveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openstress.yaml")
+	if err := os.WriteFile(path, []byte("pool:\n  workers: 10\n  rate_limit: 50\napi:\n  addr: :9090\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("OPENSTRESS_POOL_RATE_LIMIT", "80")
+	t.Setenv("OPENSTRESS_AUTH_REDIS_PASSWORD", "hunter2")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	// 命令行参数由 main 记录来源；与默认值相同但在文件中写出的配置项来源为 file
+	cfg.APIAddr = ":7070"
+	cfg.MarkSource("api.addr", config.SourceFlag)
+	for key, want := range map[string]config.ConfigSource{
+		"pool.workers":        config.SourceFile,
+		"pool.rate_limit":     config.SourceEnv,
+		"api.addr":            config.SourceFlag,
+		"collector.task_id":   config.SourceDefault,
+		"auth.redis.password": config.SourceEnv,
+	} {
+		if got := cfg.Source(key); got != want {
+			t.Errorf("%s: expected source %s, got %s", key, want, got)
+		}
+	}
+
+	dump := config.DumpEffectiveConfig(cfg)
+	for _, want := range []string{
+		"# effective config (precedence: default < file < env < flag)\n# config file: " + path + "\n",
+		"pool.workers: 10  # file\n",
+		"pool.rate_limit: 80  # env OPENSTRESS_POOL_RATE_LIMIT\n",
+		"api.addr: \":7070\"  # flag\n",
+		"collector.task_id: \"api\"  # default\n",
+		"auth.redis.password: \"***\"  # env OPENSTRESS_AUTH_REDIS_PASSWORD\n",
+		"seed: 0  # default\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump should contain %q:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "hunter2") {
+		t.Errorf("dump should not contain the redis password:\n%s", dump)
+	}
+}
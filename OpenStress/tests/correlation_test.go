@@ -0,0 +1,134 @@
+// correlation_test.go
+// 请求关联测试模块
+// 本文件负责测试从响应中提取变量（正则、JSON 路径、响应头）、在后续请求中引用变量，
+// 以及提取失败和引用未定义变量时的错误信息。
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+func TestCorrelationMultiStepTransaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orders":
+			w.Header().Set("X-Request-Id", "req-7")
+			fmt.Fprint(w, `{"data":{"order":{"id":12345678,"no":"A-1"},"items":[{"sku":"s1"}]},"csrf":"<input name=\"csrf\" value=\"tok-9\">"}`)
+		case "/pay/12345678":
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprintf(w, "%s|%s|%s", r.URL.Query().Get("no"), r.Header.Get("X-Request-Id"), body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vars := tasks.NewVariables(map[string]string{"base": server.URL})
+	req, err := vars.NewRequest(context.Background(), http.MethodPost, "${base}/orders", "", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	err = vars.Extract(resp, body,
+		tasks.Extractor{Variable: "orderId", Type: tasks.ExtractJSONPath, Expression: "$.data.order.id"},
+		tasks.Extractor{Variable: "orderNo", Type: tasks.ExtractJSONPath, Expression: "data.order.no"},
+		tasks.Extractor{Variable: "items", Type: tasks.ExtractJSONPath, Expression: "$.data.items"},
+		tasks.Extractor{Variable: "csrf", Type: tasks.ExtractRegex, Expression: `value=\\"([^\\]+)\\"`},
+		tasks.Extractor{Variable: "requestId", Type: tasks.ExtractHeader, Expression: "x-request-id"},
+	)
+	if err != nil {
+		t.Fatalf("extraction failed: %v", err)
+	}
+	for name, want := range map[string]string{
+		"orderId":   "12345678",
+		"orderNo":   "A-1",
+		"items":     `[{"sku":"s1"}]`,
+		"csrf":      "tok-9",
+		"requestId": "req-7",
+	} {
+		if got, _ := vars.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	req, err = vars.NewRequest(context.Background(), http.MethodPost, "${base}/pay/${orderId}?no=${orderNo}",
+		`{"csrf":"${csrf}"}`, map[string]string{"X-Request-Id": "${requestId}"})
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if want := `A-1|req-7|{"csrf":"tok-9"}`; string(body) != want {
+		t.Errorf("variables not applied to the next request: got %q, want %q", body, want)
+	}
+}
+
+func TestCorrelationErrors(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}}
+	body := []byte(`{"error":"out of stock"}`)
+	vars := tasks.NewVariables(nil)
+
+	err := vars.Extract(resp, body,
+		tasks.Extractor{Variable: "orderId", Type: tasks.ExtractJSONPath, Expression: "$.data.id"},
+		tasks.Extractor{Variable: "token", Type: tasks.ExtractRegex, Expression: `token=(\w+)`},
+		tasks.Extractor{Variable: "location", Type: tasks.ExtractHeader, Expression: "Location"},
+		tasks.Extractor{Variable: "coupon", Type: tasks.ExtractRegex, Expression: `coupon=(\w+)`, Optional: true, Default: "none"},
+	)
+	if err == nil {
+		t.Fatal("expected extraction errors")
+	}
+	msg := err.Error()
+	for _, want := range []string{"orderId", "$.data.id", "token", "no match", "location", "header not found", "status 500", "out of stock"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error should mention %q: %s", want, msg)
+		}
+	}
+	var extractErr *tasks.ExtractionError
+	if !errors.As(err, &extractErr) || extractErr.Variable != "orderId" {
+		t.Errorf("expected an ExtractionError for orderId, got %v", err)
+	}
+	if strings.Contains(msg, "coupon") {
+		t.Errorf("optional extractor should not fail: %s", msg)
+	}
+	if got, _ := vars.Get("coupon"); got != "none" {
+		t.Errorf("optional extractor should use the default, got %q", got)
+	}
+	if _, ok := vars.Get("orderId"); ok {
+		t.Error("failed extraction should not set the variable")
+	}
+
+	for name, ex := range map[string]tasks.Extractor{
+		"invalid regex": {Variable: "x", Type: tasks.ExtractRegex, Expression: "("},
+		"missing group": {Variable: "x", Type: tasks.ExtractRegex, Expression: `(out)`, Group: 2},
+		"unknown type":  {Variable: "x", Type: "xpath", Expression: "//id"},
+	} {
+		if err := vars.Extract(resp, body, ex); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	_, err = vars.NewRequest(context.Background(), http.MethodGet, "http://example.com/orders/${orderId}?u=${user}", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "orderId, user") {
+		t.Errorf("expected an error naming the undefined variables, got %v", err)
+	}
+}
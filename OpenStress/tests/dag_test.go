@@ -0,0 +1,273 @@
+// dag_test.go
+// 任务依赖调度测试模块
+// 本文件负责测试协程池按依赖关系（DAG）调度 TaskDetail。
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+)
+
+// newDAGTask 创建记录执行顺序的任务
+func newDAGTask(t *testing.T, id string, record func(string), err error) *pool.TaskDetail {
+	t.Helper()
+	task, createErr := pool.NewTaskDetail(id, func() error {
+		record(id)
+		return err
+	})
+	if createErr != nil {
+		t.Fatalf("failed to create task %s: %v", id, createErr)
+	}
+	task.MaxRetries = 0
+	return task
+}
+
+func TestSubmitDAGFanOutFanIn(t *testing.T) {
+	if err := pool.InitLogger(t.TempDir()+"/", "dag_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(4)
+	t.Cleanup(taskPool.Shutdown)
+
+	var mu sync.Mutex
+	finished := map[string]int{}
+	seq := 0
+	record := func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		seq++
+		finished[id] = seq
+	}
+
+	// prepare -> (login, browse) -> checkout
+	prepare := newDAGTask(t, "prepare", record, nil)
+	login := newDAGTask(t, "login", record, nil)
+	browse := newDAGTask(t, "browse", record, nil)
+	checkout := newDAGTask(t, "checkout", record, nil)
+	login.AddDependency(prepare)
+	browse.AddDependency(prepare)
+	checkout.AddDependency(login)
+	checkout.AddDependency(browse)
+
+	run, err := taskPool.SubmitDAG("flow", []*pool.TaskDetail{checkout})
+	if err != nil {
+		t.Fatalf("failed to submit DAG: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := run.Wait(ctx); err != nil {
+		t.Fatalf("DAG failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if finished["prepare"] > finished["login"] || finished["prepare"] > finished["browse"] {
+		t.Errorf("prepare must run before login and browse: %v", finished)
+	}
+	if finished["checkout"] < finished["login"] || finished["checkout"] < finished["browse"] {
+		t.Errorf("checkout must run after login and browse: %v", finished)
+	}
+	for _, status := range run.Status() {
+		if status.Status != pool.TaskCompleted.String() {
+			t.Errorf("node %s: got %s", status.ID, status.Status)
+		}
+	}
+}
+
+func TestSubmitDAGFailureCancelsDependents(t *testing.T) {
+	if err := pool.InitLogger(t.TempDir()+"/", "dag_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(2)
+	t.Cleanup(taskPool.Shutdown)
+
+	var mu sync.Mutex
+	var ran []string
+	record := func(id string) {
+		mu.Lock()
+		ran = append(ran, id)
+		mu.Unlock()
+	}
+
+	setup := newDAGTask(t, "setup", record, fmt.Errorf("boom"))
+	other := newDAGTask(t, "other", record, nil)
+	step := newDAGTask(t, "step", record, nil)
+	final := newDAGTask(t, "final", record, nil)
+	step.AddDependency(setup)
+	final.AddDependency(step)
+	final.AddDependency(other)
+
+	run, err := taskPool.SubmitDAG("broken", []*pool.TaskDetail{final})
+	if err != nil {
+		t.Fatalf("failed to submit DAG: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := run.Wait(ctx); err == nil {
+		t.Fatal("expected DAG to report failure")
+	}
+
+	want := map[string]string{
+		"setup": pool.TaskFailed.String(),
+		"other": pool.TaskCompleted.String(),
+		"step":  pool.TaskCancelled.String(),
+		"final": pool.TaskCancelled.String(),
+	}
+	for _, status := range run.Status() {
+		if status.Status != want[status.ID] {
+			t.Errorf("node %s: got %s, want %s", status.ID, status.Status, want[status.ID])
+		}
+	}
+	// 失败的节点在协程池统计中同样记为失败
+	waitFor(t, func() bool {
+		stats := taskPool.Stats()
+		return stats.Failed == 1 && stats.Completed == 1
+	})
+	if info, err := taskPool.GetTaskStatus("broken/setup"); err != nil || info.Status != pool.TaskFailed.String() {
+		t.Errorf("expected the pool to record broken/setup as failed, got %+v (%v)", info, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range ran {
+		if id == "step" || id == "final" {
+			t.Errorf("cancelled task %s was executed", id)
+		}
+	}
+}
+
+func TestSubmitDAGRejectsCycles(t *testing.T) {
+	if err := pool.InitLogger(t.TempDir()+"/", "dag_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(1)
+	t.Cleanup(taskPool.Shutdown)
+
+	noop := func(string) {}
+	a := newDAGTask(t, "a", noop, nil)
+	b := newDAGTask(t, "b", noop, nil)
+	c := newDAGTask(t, "c", noop, nil)
+	a.AddDependency(c)
+	b.AddDependency(a)
+	c.AddDependency(b)
+
+	_, err := taskPool.SubmitDAG("loop", []*pool.TaskDetail{a})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if stats := taskPool.Stats(); stats.Submitted != 0 {
+		t.Fatalf("expected no task to be submitted, got %d", stats.Submitted)
+	}
+}
+
+func TestSubmitDAGPanicFailsNode(t *testing.T) {
+	if err := pool.InitLogger(t.TempDir()+"/", "dag_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(2)
+	t.Cleanup(taskPool.Shutdown)
+
+	explode, err := pool.NewTaskDetail("explode", func() error { panic("boom") })
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	explode.MaxRetries = 0
+	var ran []string
+	after := newDAGTask(t, "after", func(id string) { ran = append(ran, id) }, nil)
+	after.AddDependency(explode)
+
+	run, err := taskPool.SubmitDAG("panicky", []*pool.TaskDetail{after})
+	if err != nil {
+		t.Fatalf("failed to submit DAG: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := run.Wait(ctx); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the DAG to finish with a failure, got %v", err)
+	}
+
+	for _, status := range run.Status() {
+		switch status.ID {
+		case "explode":
+			if status.Status != pool.TaskFailed.String() || !strings.Contains(status.Error, "panicked") {
+				t.Errorf("explode: got %s (%s), want a failed panic", status.Status, status.Error)
+			}
+		case "after":
+			if status.Status != pool.TaskCancelled.String() {
+				t.Errorf("after: got %s, want cancelled", status.Status)
+			}
+		}
+	}
+	if len(ran) != 0 {
+		t.Errorf("dependent of a panicked task was executed: %v", ran)
+	}
+	// 协程池同样把 panic 的任务记为失败
+	waitFor(t, func() bool { return taskPool.Stats().Failed == 1 })
+}
+
+func TestSubmitDAGShutdownCancelsPendingNodes(t *testing.T) {
+	if err := pool.InitLogger(t.TempDir()+"/", "dag_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(1)
+	t.Cleanup(taskPool.Shutdown)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow, err := pool.NewTaskDetail("a-slow", func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	var mu sync.Mutex
+	var ran []string
+	record := func(id string) {
+		mu.Lock()
+		ran = append(ran, id)
+		mu.Unlock()
+	}
+	// b-queued 排在唯一的 worker 之后，c-next 依赖仍在执行的 a-slow
+	queued := newDAGTask(t, "b-queued", record, nil)
+	next := newDAGTask(t, "c-next", record, nil)
+	next.AddDependency(slow)
+
+	run, err := taskPool.SubmitDAG("stopping", []*pool.TaskDetail{queued, next})
+	if err != nil {
+		t.Fatalf("failed to submit DAG: %v", err)
+	}
+	<-started
+	taskPool.Shutdown()
+	close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := run.Wait(ctx); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the DAG to finish with cancelled nodes, got %v", err)
+	}
+
+	want := map[string]string{
+		"a-slow":   pool.TaskCompleted.String(),
+		"b-queued": pool.TaskCancelled.String(),
+		"c-next":   pool.TaskCancelled.String(),
+	}
+	for _, status := range run.Status() {
+		if status.Status != want[status.ID] {
+			t.Errorf("node %s: got %s, want %s", status.ID, status.Status, want[status.ID])
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 0 {
+		t.Errorf("tasks ran after shutdown: %v", ran)
+	}
+}
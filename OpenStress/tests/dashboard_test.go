@@ -0,0 +1,171 @@
+// dashboard_test.go
+// 内置看板测试模块
+// 本文件负责测试 API 服务内置的 Web 看板：看板页面不需要认证、历史运行列出报告和合并后的 JTL 分段、
+// 报告和结果文件的访问（包括 Cookie 认证、不提供锁文件和目录列表）。
+
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/api"
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// newDashboardTestServer 创建使用临时报告目录和结果目录的 API 服务，返回服务、报告根目录和结果目录
+func newDashboardTestServer(t *testing.T) (*api.APIServer, string, string) {
+	t.Helper()
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	taskPool := pool.NewPool(2)
+	if taskPool == nil {
+		t.Fatal("failed to create pool")
+	}
+	t.Cleanup(taskPool.Shutdown)
+	return api.NewAPIServer(taskPool, collector, config.NewConfig()), reportDir, filepath.Dir(collector.JTLFilePath())
+}
+
+// writeDashboardFile 写入测试文件
+func writeDashboardFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestDashboardPageAndRedirect(t *testing.T) {
+	server, _, _ := newDashboardTestServer(t)
+	server.SetAuthenticator(newTestAuthManager(t))
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/api/live") {
+		t.Errorf("dashboard page should be served without authentication, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/dashboard/" {
+		t.Errorf("root should redirect to the dashboard, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestDashboardListsRuns(t *testing.T) {
+	server, reportDir, resultDir := newDashboardTestServer(t)
+
+	writeDashboardFile(t, filepath.Join(reportDir, "old_2024-01-01_10-00-00", "old_2024-01-01_10-00-00.html"), "<html>old</html>")
+	writeDashboardFile(t, filepath.Join(reportDir, "nightly_2024-03-05_08-30-00", "summary.md"), "# summary")
+	writeDashboardFile(t, filepath.Join(reportDir, "nightly_2024-03-05_08-30-00", "nightly_2024-03-05_08-30-00.html"), "<html>nightly</html>")
+	writeDashboardFile(t, filepath.Join(reportDir, "nightly_2024-03-05_08-30-00", "static", "tps_chart.html"), "chart")
+	writeDashboardFile(t, filepath.Join(reportDir, "not-a-report", "notes.txt"), "x")
+	writeDashboardFile(t, filepath.Join(reportDir, ".nightly.lock"), "{}")
+
+	// 轮转后的分段合并为一次运行，压缩和未压缩的分段都列出
+	writeDashboardFile(t, filepath.Join(resultDir, "test_result_a.jtl.gz"), "aaaa")
+	writeDashboardFile(t, filepath.Join(resultDir, "test_result_a.1.jtl.gz"), "bb")
+	writeDashboardFile(t, filepath.Join(resultDir, "test_result_b.jtl"), "c")
+	writeDashboardFile(t, filepath.Join(resultDir, "notes.txt"), "ignored")
+
+	var runs api.RunsResponse
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/runs", nil, &runs); code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if len(runs.Reports) != 2 {
+		t.Fatalf("expected 2 reports, got %+v", runs.Reports)
+	}
+	nightly := runs.Reports[0]
+	if nightly.Name != "nightly" || nightly.Time.Format("2006-01-02 15:04:05") != "2024-03-05 08:30:00" {
+		t.Errorf("reports should be sorted newest first with parsed names, got %+v", nightly)
+	}
+	if strings.Join(nightly.Files, ",") != "nightly_2024-03-05_08-30-00.html,summary.md" {
+		t.Errorf("unexpected report files %v", nightly.Files)
+	}
+	if nightly.URL != "/reports/nightly_2024-03-05_08-30-00/nightly_2024-03-05_08-30-00.html" {
+		t.Errorf("unexpected report url %s", nightly.URL)
+	}
+
+	byName := make(map[string]api.RunResult)
+	for _, r := range runs.Results {
+		byName[r.Name] = r
+	}
+	if len(byName) != 2 {
+		t.Fatalf("expected 2 result files, got %+v", runs.Results)
+	}
+	rotated := byName["test_result_a.jtl.gz"]
+	if strings.Join(rotated.Segments, ",") != "test_result_a.jtl.gz,test_result_a.1.jtl.gz" || rotated.Size != 6 {
+		t.Errorf("segments should be merged into one run, got %+v", rotated)
+	}
+	if len(rotated.URLs) != 2 || rotated.URLs[1] != "/results/test_result_a.1.jtl.gz" {
+		t.Errorf("unexpected segment urls %v", rotated.URLs)
+	}
+}
+
+func TestDashboardServesReportFiles(t *testing.T) {
+	server, reportDir, resultDir := newDashboardTestServer(t)
+	server.SetAuthenticator(newTestAuthManager(t))
+	writeDashboardFile(t, filepath.Join(reportDir, "run_2024-01-01_10-00-00", "run_2024-01-01_10-00-00.html"), "<html>run</html>")
+	writeDashboardFile(t, filepath.Join(reportDir, ".run.lock"), "{}")
+	writeDashboardFile(t, filepath.Join(resultDir, "test_result_x.jtl"), "timeStamp,elapsed\n")
+
+	get := func(path, apiKey string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			req.Header.Set(api.APIKeyHeader, apiKey)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	report := "/reports/run_2024-01-01_10-00-00/run_2024-01-01_10-00-00.html"
+
+	if rec := get(report, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("report files require authentication, got %d", rec.Code)
+	}
+	// 报告中的图表页面无法携带请求头，使用看板写入的 Cookie 认证
+	if rec := get(report, "", &http.Cookie{Name: api.APIKeyCookie, Value: "viewer_key"}); rec.Code != http.StatusOK || rec.Body.String() != "<html>run</html>" {
+		t.Errorf("report should be served with the API key cookie, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := get("/api/stats", "", &http.Cookie{Name: api.APIKeyCookie, Value: "viewer_key"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("the API key cookie should only be accepted for report and result files, got %d", rec.Code)
+	}
+
+	if rec := get("/results/test_result_x.jtl", "viewer_key", nil); rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "timeStamp") {
+		t.Errorf("result file should be served, got %d", rec.Code)
+	}
+	for _, path := range []string{"/reports/.run.lock", "/reports/run_2024-01-01_10-00-00/", "/reports/", "/results/missing.jtl"} {
+		if rec := get(path, "viewer_key", nil); rec.Code != http.StatusNotFound {
+			t.Errorf("%s should not be served, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestDashboardListsSavedReport(t *testing.T) {
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	path, err := collector.SaveReportToFile(lockTestStats(t, collector), "saved")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	if collector.ReportDir() != reportDir {
+		t.Errorf("unexpected report directory %s", collector.ReportDir())
+	}
+
+	reports, err := result.ListReports(reportDir)
+	if err != nil {
+		t.Fatalf("failed to list reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Name != "saved" || reports[0].Report() != filepath.Base(path) {
+		t.Errorf("saved report should be listed, got %+v", reports)
+	}
+}
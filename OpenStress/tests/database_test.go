@@ -0,0 +1,198 @@
+// database_test.go
+// 数据库压测任务测试模块
+// 本文件使用一个内存 SQL 驱动测试数据库任务：参数和场景变量的传递、返回与影响行数的记录、
+// 错误记录，以及连接池上限在任务之间共享。
+
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// fakeSQL 内存 SQL 驱动："SELECT n" 返回 n 行，"UPDATE" 影响的行数等于参数个数，"FAIL" 返回错误
+type fakeSQL struct {
+	opened atomic.Int32
+	mu     sync.Mutex
+	args   [][]driver.Value
+}
+
+var fakeDriver = &fakeSQL{}
+
+func init() {
+	sql.Register("fakesql", fakeDriver)
+}
+
+func (d *fakeSQL) Open(string) (driver.Conn, error) {
+	d.opened.Add(1)
+	return fakeConn{d}, nil
+}
+
+type fakeConn struct{ d *fakeSQL }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, fmt.Errorf("not supported") }
+
+type fakeStmt struct {
+	d     *fakeSQL
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.record(args)
+	if strings.HasPrefix(s.query, "FAIL") {
+		return nil, fmt.Errorf("deadlock detected")
+	}
+	return driver.RowsAffected(len(args)), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.record(args)
+	if strings.HasPrefix(s.query, "FAIL") {
+		return nil, fmt.Errorf("relation does not exist")
+	}
+	// 模拟查询耗时，使并发查询同时占用连接
+	time.Sleep(5 * time.Millisecond)
+	n, _ := strconv.Atoi(strings.TrimPrefix(s.query, "SELECT "))
+	return &fakeRows{n: n}, nil
+}
+
+func (s fakeStmt) record(args []driver.Value) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.args = append(s.d.args, args)
+}
+
+type fakeRows struct{ n, i int }
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= r.n {
+		return io.EOF
+	}
+	r.i++
+	dest[0] = int64(r.i)
+	return nil
+}
+
+func TestDBTaskQueriesAndRows(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	task, err := tasks.NewDBTask(tasks.DBConfig{
+		Driver: "fakesql",
+		DSN:    "user:secret@tcp(db:3306)/shop",
+		Queries: []tasks.DBQuery{
+			{Name: "list_orders", SQL: "SELECT 3", Args: []interface{}{"${user_id}", 10}},
+			{Name: "touch_order", SQL: "UPDATE orders SET seen = 1 WHERE id = ? AND owner = ?", Args: []interface{}{7, "${user_id}"}, Exec: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create db task: %v", err)
+	}
+	defer task.Close()
+	task.Collector = collector
+	if err := task.Ping(context.Background()); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+
+	fakeDriver.mu.Lock()
+	fakeDriver.args = nil
+	fakeDriver.mu.Unlock()
+	if err := task.Run(context.Background(), tasks.NewVariables(map[string]string{"user_id": "42"}), 5); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	fakeDriver.mu.Lock()
+	args := fmt.Sprint(fakeDriver.args)
+	fakeDriver.mu.Unlock()
+	if args != "[[42 10] [7 42]]" {
+		t.Errorf("unexpected query args: %s", args)
+	}
+
+	results := collector.Results()
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if r := results[0]; r.Method != "QUERY" || r.URL != "fakesql://list_orders" || r.Rows != 3 || r.ThreadID != 5 || r.Type != result.Success {
+		t.Errorf("unexpected query result: %+v", r)
+	}
+	if r := results[1]; r.Method != "EXEC" || r.Rows != 2 || r.Type != result.Success {
+		t.Errorf("unexpected exec result: %+v", r)
+	}
+	for _, r := range results {
+		if strings.Contains(r.URL, "secret") {
+			t.Errorf("result should not contain the dsn: %s", r.URL)
+		}
+	}
+
+	// 行数写入 JTL 并能读回
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Rows+loaded[1].Rows != 5 {
+		t.Errorf("rows should survive the jtl round trip: %+v", loaded)
+	}
+}
+
+func TestDBTaskErrorsAndSharedPool(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	task, err := tasks.NewDBTask(tasks.DBConfig{Driver: "fakesql", DSN: "pool", MaxOpenConns: 2})
+	if err != nil {
+		t.Fatalf("failed to create db task: %v", err)
+	}
+	defer task.Close()
+	task.Collector = collector
+
+	if _, err := task.Execute(context.Background(), tasks.DBQuery{SQL: "FAIL  SELECT *\n FROM missing"}, nil, 1); err == nil || !strings.Contains(err.Error(), "FAIL SELECT * FROM missing: relation does not exist") {
+		t.Errorf("expected a query error, got %v", err)
+	}
+	if _, err := task.Execute(context.Background(), tasks.DBQuery{SQL: "SELECT 1", Args: []interface{}{"${missing}"}}, nil, 1); err == nil || !strings.Contains(err.Error(), "undefined variables: missing") {
+		t.Errorf("expected an undefined variable error, got %v", err)
+	}
+	results := collector.Results()
+	if len(results) != 2 || results[0].Type != result.Failure || results[1].Type != result.Failure {
+		t.Errorf("errors should be recorded as failures: %+v", results)
+	}
+
+	// 并发查询共享连接池，连接数不超过 MaxOpenConns
+	before := fakeDriver.opened.Load()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(thread int) {
+			defer wg.Done()
+			task.Execute(context.Background(), tasks.DBQuery{SQL: "SELECT 1"}, nil, thread)
+		}(i)
+	}
+	wg.Wait()
+	if opened := fakeDriver.opened.Load() - before; opened > 2 {
+		t.Errorf("expected at most 2 connections, opened %d", opened)
+	}
+
+	for name, cfg := range map[string]tasks.DBConfig{
+		"no driver":   {DSN: "x"},
+		"unknown":     {Driver: "oracle", DSN: "x"},
+		"no dsn":      {Driver: "fakesql"},
+		"empty query": {Driver: "fakesql", DSN: "x", Queries: []tasks.DBQuery{{Name: "blank"}}},
+	} {
+		if _, err := tasks.NewDBTask(cfg); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// elasticsearch_test.go
+// Elasticsearch 结果索引测试模块
+// 本文件负责测试结果文档的批量写入（NDJSON 格式、文档ID、运行ID和标签）、认证请求头、
+// 被限流文档的重试，以及标签通过环境变量配置。
+
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestElasticsearchIndexesResults(t *testing.T) {
+	var mu sync.Mutex
+	docs := make(map[string]map[string]interface{}) // 文档ID -> 文档
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" || r.Header.Get("Authorization") != "ApiKey test-key" {
+			t.Errorf("unexpected bulk request: %s %v", r.URL.Path, r.Header)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		var items []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action map[string]map[string]string
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+				t.Errorf("malformed bulk body: %v", err)
+				return
+			}
+			var doc map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+				t.Errorf("malformed document: %v", err)
+				return
+			}
+			id := action["index"]["_id"]
+			if action["index"]["_index"] != "loadtest" {
+				t.Errorf("unexpected index: %v", action)
+			}
+			switch {
+			case doc["request_id"] == "throttled" && requests == 1:
+				items = append(items, `{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}`)
+			case doc["request_id"] == "bad":
+				items = append(items, `{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}`)
+			default:
+				docs[id] = doc
+				items = append(items, `{"index":{"status":201}}`)
+			}
+		}
+		w.Write([]byte(`{"errors":true,"items":[` + strings.Join(items, ",") + `]}`))
+	}))
+	defer server.Close()
+
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "report", Elasticsearch: config.ElasticsearchConfig{
+		URL: server.URL, Index: "loadtest", APIKey: "test-key", Labels: map[string]string{"env": "ci"},
+		FlushInterval: time.Hour, RetryBackoff: time.Millisecond,
+	}})
+	now := time.Now()
+	collector.SaveSuccessResult(result.ResultData{ID: "ok", Type: result.Success, URL: "http://svc/orders?token=abc", Method: "GET",
+		StatusCode: 200, StartTime: now, EndTime: now.Add(12 * time.Millisecond)})
+	collector.SaveFailureResult(result.ResultData{ID: "throttled", Type: result.Timeout, StatusCode: 0, ErrorMessage: "timeout",
+		StartTime: now, EndTime: now.Add(time.Second), ResponseTime: time.Second})
+	collector.SaveFailureResult(result.ResultData{ID: "bad", Type: result.Failure, StatusCode: 500, StartTime: now, EndTime: now})
+	collector.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	// 关闭时写入剩余文档，被限流的文档重试后写入，被拒绝的文档不重试
+	if len(docs) != 2 || requests != 2 {
+		t.Fatalf("expected 2 documents in 2 requests, got %d in %d: %v", len(docs), requests, docs)
+	}
+	ok, throttled := docs["report-1"], docs["report-2"]
+	if ok == nil || throttled == nil {
+		t.Fatalf("documents should be keyed by run ID and sequence: %v", docs)
+	}
+	labels, _ := ok["labels"].(map[string]interface{})
+	if ok["run_id"] != "report" || ok["result"] != "success" || ok["response_time_ms"] != 12.0 || labels["env"] != "ci" {
+		t.Errorf("unexpected success document: %v", ok)
+	}
+	if ok["url"] != "http://svc/orders?token=***" {
+		t.Errorf("url should be redacted before indexing: %v", ok["url"])
+	}
+	if throttled["result"] != "timeout" || throttled["success"] != false || throttled["error_message"] != "timeout" {
+		t.Errorf("unexpected timeout document: %v", throttled)
+	}
+}
+
+func TestElasticsearchConfigFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openstress.yaml")
+	if err := os.WriteFile(path, []byte("collector:\n  elasticsearch:\n    url: http://es:9200\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("OPENSTRESS_COLLECTOR_ELASTICSEARCH_LABELS", "env=staging, build=42")
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	es := cfg.Collector.Elasticsearch
+	if es.URL != "http://es:9200" || len(es.Labels) != 2 || es.Labels["env"] != "staging" || es.Labels["build"] != "42" {
+		t.Errorf("unexpected elasticsearch config: %+v", es)
+	}
+	if cfg.Source("collector.elasticsearch.labels") != config.SourceEnv {
+		t.Errorf("labels should come from the environment")
+	}
+
+	t.Setenv("OPENSTRESS_COLLECTOR_ELASTICSEARCH_LABELS", "env")
+	if _, err := config.LoadConfig(path); err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Errorf("malformed labels should be rejected, got %v", err)
+	}
+}
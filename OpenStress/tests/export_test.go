@@ -0,0 +1,155 @@
+// export_test.go
+// 统计结果导出测试模块
+// 本文件负责测试统计结果的 JSON / CSV / OpenMetrics 导出格式，以及导出快照不被实时查询覆盖。
+
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// exportTestStats 导出测试使用的统计结果
+func exportTestStats() map[string]interface{} {
+	return map[string]interface{}{
+		"TotalRequests":   int64(120),
+		"SuccessRate":     99.5,
+		"P95ResponseTime": 250 * time.Millisecond,
+		"LatencyView":     "全部样本",
+		"TPSValues":       []int{10, 20, 30},
+		"LatencyPercentilesAll": map[string]time.Duration{
+			"P50ResponseTime": 100 * time.Millisecond,
+			"a\"b\\c\nd":      time.Second,
+		},
+	}
+}
+
+func TestExportStatsMapJSON(t *testing.T) {
+	data, err := result.ExportStatsMap(exportTestStats(), "JSON")
+	if err != nil {
+		t.Fatalf("failed to export json: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if decoded["P95ResponseTime"] != 250.0 {
+		t.Errorf("durations should be exported in milliseconds, got %v", decoded["P95ResponseTime"])
+	}
+	if series, ok := decoded["TPSValues"].([]interface{}); !ok || len(series) != 3 {
+		t.Errorf("per-second series should be kept in json, got %v", decoded["TPSValues"])
+	}
+	percentiles := decoded["LatencyPercentilesAll"].(map[string]interface{})
+	if percentiles["P50ResponseTime"] != 100.0 {
+		t.Errorf("nested durations should be exported in milliseconds, got %v", percentiles["P50ResponseTime"])
+	}
+}
+
+func TestExportStatsMapCSV(t *testing.T) {
+	data, err := result.ExportStatsMap(exportTestStats(), result.ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("failed to export csv: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if records[0][0] != "metric" || records[0][1] != "value" {
+		t.Fatalf("unexpected header: %v", records[0])
+	}
+	rows := map[string]string{}
+	for i, record := range records[1:] {
+		if i > 0 && records[i][0] > record[0] {
+			t.Errorf("rows are not sorted: %s after %s", record[0], records[i][0])
+		}
+		rows[record[0]] = record[1]
+	}
+	want := map[string]string{
+		"TotalRequests":                         "120",
+		"SuccessRate":                           "99.5",
+		"P95ResponseTime":                       "250",
+		"LatencyView":                           "全部样本",
+		"LatencyPercentilesAll.P50ResponseTime": "100",
+	}
+	for key, value := range want {
+		if rows[key] != value {
+			t.Errorf("%s: got %q, want %q", key, rows[key], value)
+		}
+	}
+	if _, ok := rows["TPSValues"]; ok {
+		t.Error("per-second series should not be exported to csv")
+	}
+}
+
+func TestExportStatsMapOpenMetrics(t *testing.T) {
+	data, err := result.ExportStatsMap(exportTestStats(), result.ExportFormatOpenMetrics)
+	if err != nil {
+		t.Fatalf("failed to export openmetrics: %v", err)
+	}
+	text := string(data)
+	for _, line := range []string{
+		"# TYPE openstress_total_requests gauge\nopenstress_total_requests 120\n",
+		"openstress_success_rate 99.5\n",
+		"# TYPE openstress_p95_response_time_seconds gauge\nopenstress_p95_response_time_seconds 0.25\n",
+		`openstress_latency_percentiles_all_seconds{key="P50ResponseTime"} 0.1` + "\n",
+		// 标签值中的双引号、反斜杠和换行需要转义
+		`openstress_latency_percentiles_all_seconds{key="a\"b\\c\nd"} 1` + "\n",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("missing %q in:\n%s", line, text)
+		}
+	}
+	if !strings.HasSuffix(text, "# EOF\n") {
+		t.Error("openmetrics output must end with # EOF")
+	}
+	if strings.Contains(text, "latency_view") || strings.Contains(text, "tps_values") {
+		t.Error("non-numeric metrics should not be exported to openmetrics")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if !strings.HasPrefix(line, "# ") && !strings.HasPrefix(line, "openstress_") {
+			t.Errorf("malformed line %q, a label value may contain a raw newline", line)
+		}
+	}
+
+	if _, err := result.ExportStatsMap(exportTestStats(), "xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestExportStatsKeepsSnapshotAcrossLiveQueries(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "export_snapshot"})
+	if _, err := collector.ExportStats(result.ExportFormatJSON); err == nil {
+		t.Fatal("expected error before any stats are generated")
+	}
+
+	start := time.Now()
+	final := []result.ResultData{
+		{Type: result.Success, StartTime: start, EndTime: start.Add(10 * time.Millisecond), ResponseTime: 10 * time.Millisecond, StatusCode: 200},
+		{Type: result.Success, StartTime: start, EndTime: start.Add(10 * time.Millisecond), ResponseTime: 10 * time.Millisecond, StatusCode: 200},
+	}
+	if _, err := collector.GeneratePerformanceStats(final); err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	before, err := collector.ExportStats(result.ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("failed to export stats: %v", err)
+	}
+
+	// 实时查询（如 /api/stats 轮询）使用不同的结果计算统计，不应覆盖导出快照
+	live := append(final, result.ResultData{Type: result.Failure, StartTime: start, EndTime: start.Add(time.Second), ResponseTime: time.Second, StatusCode: 500})
+	if _, err := collector.CurrentStats(live); err != nil {
+		t.Fatalf("failed to compute live stats: %v", err)
+	}
+	after, err := collector.ExportStats(result.ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("failed to export stats: %v", err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("export snapshot changed after a live query:\nbefore:\n%s\nafter:\n%s", before, after)
+	}
+}
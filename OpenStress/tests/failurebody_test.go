@@ -0,0 +1,103 @@
+// failurebody_test.go
+// 失败响应体采样测试模块
+// 本文件负责测试失败响应体的截断、按错误种类限量保存、写入 JTL 后重新加载，
+// 以及报告错误分析中展示的代表性失败响应。
+
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+func TestFailureBodyCapture(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{FailureBodyBytes: 16, FailureBodiesPerError: 2})
+	now := time.Now()
+	save := func(code int, message, body string, success bool) {
+		data := result.ResultData{ID: "r", URL: "http://svc/orders", StatusCode: code, ErrorMessage: message, ResponseBody: body,
+			StartTime: now, EndTime: now.Add(time.Millisecond), ResponseTime: time.Millisecond}
+		if success {
+			data.Type = result.Success
+			collector.SaveSuccessResult(data)
+			return
+		}
+		data.Type = result.Failure
+		collector.SaveFailureResult(data)
+	}
+	save(200, "", `{"ok":true}`, true)
+	for i := 0; i < 4; i++ {
+		save(500, "request failed with status 500", `{"error":"db timeout","retry":false}`, false)
+	}
+	save(404, "request failed with status 404", "not found, 找不到", false)
+	save(502, "connection reset", "", false)
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	var stored []string
+	for _, r := range results {
+		if r.Type == result.Success && r.ResponseBody != "" {
+			t.Errorf("success bodies should not be stored: %q", r.ResponseBody)
+		}
+		if r.ResponseBody != "" {
+			stored = append(stored, r.ResponseBody)
+		}
+	}
+	// 每种错误最多 2 条，截断到 16 字节，逗号原样保留，多字节字符不被截断
+	want := []string{`{"error":"db tim`, `{"error":"db tim`, "not found, 找"}
+	if strings.Join(stored, "|") != strings.Join(want, "|") {
+		t.Errorf("expected stored bodies %q, got %q", want, stored)
+	}
+
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	topErrors := stats["TopErrors"].([]result.ErrorSummary)
+	if topErrors[0].StatusCode != 500 || topErrors[0].Count != 4 || topErrors[0].Sample != `{"error":"db tim` {
+		t.Errorf("unexpected top error: %+v", topErrors[0])
+	}
+	html := result.GenerateHTMLReport(stats)
+	if !strings.Contains(html, "失败响应示例") || !strings.Contains(html, "<pre>{&#34;error&#34;:&#34;db tim</pre>") {
+		t.Error("html report should show representative failure bodies")
+	}
+	md := result.GenerateMarkdownReport(stats)
+	if !strings.Contains(md, "**响应体: 500 request failed with status 500**\n\n```\n{\"error\":\"db tim\n```") {
+		t.Errorf("markdown report should show representative failure bodies:\n%s", md)
+	}
+
+	if _, err := result.NewCollector(result.CollectorConfig{FailureBodySampleRate: 1.5}); err == nil {
+		t.Error("expected an error for an invalid sample rate")
+	}
+}
+
+func TestFailureBodyNotStoredByDefault(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	collector.SaveFailureResult(result.ResultData{ID: "r", Type: result.Failure, ResponseBody: "boom", StartTime: time.Now(), EndTime: time.Now()})
+	if body := collector.Results()[0].ResponseBody; body != "" {
+		t.Errorf("bodies should only be stored when FailureBodyBytes is set, got %q", body)
+	}
+}
+
+func TestReadBodyKeepsHead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", tasks.MaxCapturedBody+100)))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	head, n, err := tasks.ReadBody(resp)
+	if err != nil || len(head) != tasks.MaxCapturedBody || n != int64(tasks.MaxCapturedBody+100) {
+		t.Errorf("expected %d captured of %d bytes, got %d of %d, %v", tasks.MaxCapturedBody, tasks.MaxCapturedBody+100, len(head), n, err)
+	}
+}
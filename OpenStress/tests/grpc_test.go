@@ -0,0 +1,149 @@
+// grpc_test.go
+// gRPC 压测任务测试模块
+// 本文件使用注册了健康检查和反射服务的本地 gRPC 服务，测试通过反射解析方法、JSON 请求体与元数据中的变量、
+// 状态码的记录、健康检查，以及服务名、方法名和请求体错误时的提示。
+
+package tests
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+)
+
+// startGRPCServer 启动带健康检查和反射服务的 gRPC 服务，返回地址、健康状态和收到的 x-user 元数据
+func startGRPCServer(t *testing.T) (string, *health.Server, func() []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	var mu sync.Mutex
+	var users []string
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		mu.Lock()
+		users = append(users, md.Get("x-user")...)
+		mu.Unlock()
+		return handler(ctx, req)
+	}))
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String(), healthServer, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), users...)
+	}
+}
+
+func TestGRPCTaskReflectionInvoke(t *testing.T) {
+	addr, healthServer, users := startGRPCServer(t)
+	healthServer.SetServingStatus("orders", grpc_health_v1.HealthCheckResponse_SERVING)
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+
+	task, err := tasks.NewGRPCTask(context.Background(), tasks.GRPCConfig{
+		Target:   addr,
+		Service:  "grpc.health.v1.Health",
+		Method:   "Check",
+		Payload:  `{"service": "${svc}"}`,
+		Metadata: map[string]string{"x-user": "${user}"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create grpc task: %v", err)
+	}
+	defer task.Close()
+	task.Collector = collector
+
+	vars := tasks.NewVariables(map[string]string{"svc": "orders", "user": "alice"})
+	data, body, err := task.Execute(context.Background(), vars, 3)
+	if err != nil {
+		t.Fatalf("invoke failed: %v", err)
+	}
+	if data.Type != result.Success || data.StatusCode != 0 || data.ResponseMsg != "OK" || data.DataSent == 0 || data.DataReceived == 0 ||
+		data.URL != "grpc://"+addr+"/grpc.health.v1.Health/Check" || data.Method != "GRPC" || data.ThreadID != 3 {
+		t.Errorf("unexpected result: %+v", data)
+	}
+	// JSON 响应可直接用于变量提取
+	if err := vars.Extract(nil, body, tasks.Extractor{Variable: "status", Type: tasks.ExtractJSONPath, Expression: "$.status"}); err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+	if status, _ := vars.Get("status"); status != "SERVING" {
+		t.Errorf("expected SERVING, got %q from %s", status, body)
+	}
+	if got := users(); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("metadata should be expanded and sent, got %v", got)
+	}
+
+	// 服务端返回错误状态：记录状态码并记为失败
+	vars.Set("svc", "missing")
+	data, _, err = task.Execute(context.Background(), vars, 3)
+	if err == nil || data.Type != result.Failure || data.StatusCode != 5 || data.ResponseMsg != "NotFound" {
+		t.Errorf("expected a NotFound failure, got %+v, %v", data, err)
+	}
+	if _, _, err := task.Execute(context.Background(), nil, 3); err == nil || !strings.Contains(err.Error(), "undefined variables") {
+		t.Errorf("expected an undefined variable error, got %v", err)
+	}
+
+	// 健康检查
+	if _, err := task.HealthCheck(context.Background(), "orders", 1); err != nil {
+		t.Errorf("health check failed: %v", err)
+	}
+	healthServer.SetServingStatus("orders", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	if data, err := task.HealthCheck(context.Background(), "orders", 1); err == nil || data.ResponseMsg != "NOT_SERVING" || data.Type != result.Failure {
+		t.Errorf("expected a NOT_SERVING failure, got %+v, %v", data, err)
+	}
+	if len(collector.Results()) != 5 {
+		t.Errorf("expected 5 results, got %d", len(collector.Results()))
+	}
+}
+
+func TestGRPCTaskResolveErrors(t *testing.T) {
+	addr, _, _ := startGRPCServer(t)
+	for name, tc := range map[string]struct {
+		cfg  tasks.GRPCConfig
+		want string
+	}{
+		"service":   {tasks.GRPCConfig{Target: addr, Service: "shop.Orders", Method: "Get"}, "grpc service shop.Orders not found (available: "},
+		"method":    {tasks.GRPCConfig{Target: addr, Service: "grpc.health.v1.Health", Method: "Ping"}, "available: Check"},
+		"streaming": {tasks.GRPCConfig{Target: addr, Service: "grpc.health.v1.Health", Method: "Watch"}, "only unary methods"},
+		"payload":   {tasks.GRPCConfig{Target: addr, Service: "grpc.health.v1.Health", Method: "Check", Payload: `{"unknown": 1}`}, "invalid payload for grpc.health.v1.HealthCheckRequest"},
+		"target":    {tasks.GRPCConfig{Service: "a.B", Method: "C"}, "target is required"},
+		"pair":      {tasks.GRPCConfig{Target: addr, Service: "a.B"}, "must be set together"},
+	} {
+		task, err := tasks.NewGRPCTask(context.Background(), tc.cfg)
+		if err == nil {
+			task.Close()
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected %q in %v", name, tc.want, err)
+		}
+	}
+
+	// 只做健康检查时不需要反射
+	task, err := tasks.NewGRPCTask(context.Background(), tasks.GRPCConfig{Target: addr})
+	if err != nil {
+		t.Fatalf("failed to create grpc task: %v", err)
+	}
+	defer task.Close()
+	if _, err := task.HealthCheck(context.Background(), "", 1); err != nil {
+		t.Errorf("health check failed: %v", err)
+	}
+	if _, _, err := task.Execute(context.Background(), nil, 1); err == nil {
+		t.Error("execute without a method should fail")
+	}
+}
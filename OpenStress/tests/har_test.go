@@ -0,0 +1,165 @@
+// har_test.go
+// HAR 导入测试模块
+// 本文件负责测试 HAR 文件到 HTTP 测试计划的转换（排序、过滤静态资源和其他主机、请求头清理、请求体、思考时间），
+// 测试计划的保存和加载，以及按计划回放请求并写入结果。
+
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// testHAR 录制的请求：登录、图片、第三方统计、下单（并行的两个请求之一）、表单提交，条目顺序与时间顺序不一致
+const testHAR = `{"log": {"version": "1.2", "entries": [
+  {"startedDateTime": "2024-05-01T10:00:03.000Z", "time": 50, "_resourceType": "xhr",
+   "request": {"method": "post", "url": "https://shop.example.com/api/orders",
+     "headers": [{"name": ":authority", "value": "shop.example.com"}, {"name": "Content-Type", "value": "application/json"},
+                 {"name": "Cookie", "value": "session=abc"}, {"name": "Content-Length", "value": "42"}],
+     "postData": {"mimeType": "application/json", "text": "{\"note\":\"{{not a template}}\",\"qty\":2}"}}},
+  {"startedDateTime": "2024-05-01T10:00:00.000Z", "time": 200, "_resourceType": "document",
+   "request": {"method": "GET", "url": "https://shop.example.com/login?next=%2Fcart",
+     "headers": [{"name": "Host", "value": "shop.example.com"}, {"name": "Accept", "value": "text/html"}, {"name": "Accept-Encoding", "value": "gzip"}]}},
+  {"startedDateTime": "2024-05-01T10:00:00.300Z", "time": 20, "_resourceType": "image",
+   "request": {"method": "GET", "url": "https://shop.example.com/logo.png", "headers": []}},
+  {"startedDateTime": "2024-05-01T10:00:00.400Z", "time": 20,
+   "request": {"method": "GET", "url": "https://analytics.example.net/collect", "headers": []}},
+  {"startedDateTime": "2024-05-01T10:00:03.010Z", "time": 30,
+   "request": {"method": "GET", "url": "https://shop.example.com/api/cart", "headers": []}},
+  {"startedDateTime": "2024-05-01T10:01:00.000Z", "time": 10,
+   "request": {"method": "POST", "url": "https://shop.example.com/api/address", "headers": [],
+     "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "city", "value": "Shang Hai"}, {"name": "zip", "value": "200000"}]}}}
+]}}`
+
+func TestImportHAR(t *testing.T) {
+	plan, err := tasks.ImportHAR(strings.NewReader(testHAR), tasks.HAROptions{Name: "checkout", Hosts: []string{"shop.example.com"}})
+	if err != nil {
+		t.Fatalf("failed to import HAR: %v", err)
+	}
+	if plan.Name != "checkout" || len(plan.Requests) != 4 {
+		t.Fatalf("expected 4 requests after filtering, got %+v", plan)
+	}
+	login, order, cart, address := plan.Requests[0], plan.Requests[1], plan.Requests[2], plan.Requests[3]
+	if login.Method != "GET" || login.URL != "https://shop.example.com/login?next=%2Fcart" || login.ThinkTime != 0 {
+		t.Errorf("unexpected first request: %+v", login)
+	}
+	if len(login.Headers) != 1 || login.Headers["Accept"] != "text/html" {
+		t.Errorf("generated headers should be dropped: %v", login.Headers)
+	}
+
+	// 思考时间为上一个请求结束到本请求开始的间隔，并行请求为 0，超过上限时截断
+	if order.Method != "POST" || order.ThinkTime != 2800*time.Millisecond {
+		t.Errorf("unexpected order request: %+v", order)
+	}
+	if _, ok := order.Headers["Cookie"]; ok || len(order.Headers) != 1 {
+		t.Errorf("cookies and pseudo headers should be dropped: %v", order.Headers)
+	}
+	if cart.ThinkTime != 0 || address.ThinkTime != tasks.DefaultHARMaxThinkTime {
+		t.Errorf("unexpected think times: %v, %v", cart.ThinkTime, address.ThinkTime)
+	}
+	if address.Body != "city=Shang+Hai&zip=200000" {
+		t.Errorf("form params should be encoded: %q", address.Body)
+	}
+
+	// 保留静态资源和 Cookie，不导入思考时间
+	plan, err = tasks.ImportHAR(strings.NewReader(testHAR), tasks.HAROptions{IncludeStatic: true, KeepCookies: true, MaxThinkTime: -1})
+	if err != nil || len(plan.Requests) != 6 || plan.Name != "har" {
+		t.Fatalf("unexpected plan with static requests: %+v (%v)", plan, err)
+	}
+	for _, r := range plan.Requests {
+		if r.ThinkTime != 0 {
+			t.Errorf("think times should be dropped: %+v", r)
+		}
+	}
+	if plan.Requests[3].Headers["Cookie"] != "session=abc" {
+		t.Errorf("cookie should be kept: %v", plan.Requests[3].Headers)
+	}
+
+	if _, err := tasks.ImportHAR(strings.NewReader(testHAR), tasks.HAROptions{Hosts: []string{"other.example.com"}}); err == nil {
+		t.Error("importing without any matching request should fail")
+	}
+	if _, err := tasks.ImportHAR(strings.NewReader("not json"), tasks.HAROptions{}); err == nil {
+		t.Error("invalid HAR should be rejected")
+	}
+}
+
+func TestReplayImportedHAR(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		mu.Unlock()
+		if r.URL.Path == "/api/address" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	// 把录制的地址替换为测试服务，保存后重新加载
+	harPath := filepath.Join(t.TempDir(), "checkout.har")
+	if err := os.WriteFile(harPath, []byte(strings.ReplaceAll(testHAR, "https://shop.example.com", server.URL)), 0644); err != nil {
+		t.Fatalf("failed to write HAR: %v", err)
+	}
+	plan, err := tasks.ImportHARFile(harPath, tasks.HAROptions{Hosts: []string{strings.TrimPrefix(server.URL, "http://")}, MaxThinkTime: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to import HAR: %v", err)
+	}
+	if plan.Name != "checkout" || plan.Source != "checkout.har" {
+		t.Errorf("unexpected plan name or source: %q %q", plan.Name, plan.Source)
+	}
+	planPath := filepath.Join(t.TempDir(), "checkout.plan.yaml")
+	if err := plan.Save(planPath); err != nil {
+		t.Fatalf("failed to save plan: %v", err)
+	}
+	content, _ := os.ReadFile(planPath)
+	if !strings.Contains(string(content), "think_time: 20ms") {
+		t.Errorf("think times should be saved as durations:\n%s", content)
+	}
+	loaded, err := tasks.LoadTestPlan(planPath)
+	if err != nil || len(loaded.Requests) != len(plan.Requests) || loaded.Requests[1].ThinkTime != 20*time.Millisecond {
+		t.Fatalf("failed to load saved plan: %+v (%v)", loaded, err)
+	}
+
+	planTask, err := tasks.NewPlanTask(loaded)
+	if err != nil {
+		t.Fatalf("failed to create plan task: %v", err)
+	}
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	planTask.Collector = collector
+	if err := planTask.Run(context.Background(), nil, nil, 3); err == nil || !strings.Contains(err.Error(), "POST /api/address") {
+		t.Errorf("expected the failing request to stop the run, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"GET /login?next=%2Fcart ",
+		`POST /api/orders {"note":"{{not a template}}","qty":2}`,
+		"GET /api/cart ",
+		"POST /api/address city=Shang+Hai&zip=200000",
+	}
+	if strings.Join(received, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected replayed requests:\n%s", strings.Join(received, "\n"))
+	}
+	results := collector.Results()
+	if len(results) != 4 || results[0].ID != "GET /login" || results[0].ThreadID != 3 || results[3].Type != result.Failure || results[3].StatusCode != 400 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	if _, err := tasks.NewPlanTask(&tasks.TestPlan{Requests: []tasks.PlanRequest{{URL: "http://x/{{.missing"}}}); err == nil {
+		t.Error("invalid request template should be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+// intermediate_test.go
+// 阶段报告测试模块
+// 本文件负责测试阶段报告只包含指定时间窗口内的结果、报告序号递增，以及生成报告期间结果收集不受影响。
+
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// collectWindow 在 start 之后每 100ms 提交一个结果
+func collectWindow(collector *result.Collector, start time.Time, n int, url string) {
+	for i := 0; i < n; i++ {
+		begin := start.Add(time.Duration(i) * 100 * time.Millisecond)
+		collector.CollectResult(result.ResultData{
+			Type: result.Success, StartTime: begin, EndTime: begin.Add(5 * time.Millisecond),
+			ResponseTime: 5 * time.Millisecond, StatusCode: 200, Method: "GET", URL: url,
+		})
+	}
+}
+
+func TestGenerateIntermediateReportUsesWindow(t *testing.T) {
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{TaskID: "intermediate_window"})
+
+	first := time.Now().Add(-time.Hour)
+	second := first.Add(10 * time.Minute)
+	collectWindow(collector, first, 10, "/first-window")
+	collectWindow(collector, second, 10, "/second-window")
+	waitFor(t, func() bool { return len(collector.Results()) == 20 })
+
+	path, err := collector.GenerateIntermediateReport(first, first.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to generate intermediate report: %v", err)
+	}
+	if !strings.HasPrefix(path, reportDir) {
+		t.Errorf("report %s should be written under %s", path, reportDir)
+	}
+	if !strings.Contains(filepath.Base(path), "intermediate_report_intermediate_window_001") {
+		t.Errorf("unexpected report name %s", filepath.Base(path))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	// 报告的起止时间应当只覆盖第一个窗口的结果
+	wantStart := "<th>开始时间</th><td>" + first.Format("2006-01-02 15:04:05")
+	secondStart := second.Format("2006-01-02 15:04:05")
+	if !strings.Contains(string(content), wantStart) || strings.Contains(string(content), secondStart) {
+		t.Error("intermediate report should only contain results from its window")
+	}
+
+	path, err = collector.GenerateIntermediateReport(second, second.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to generate second intermediate report: %v", err)
+	}
+	if !strings.Contains(filepath.Base(path), "intermediate_report_intermediate_window_002") {
+		t.Errorf("report sequence should increase, got %s", filepath.Base(path))
+	}
+
+	if _, err := collector.GenerateIntermediateReport(time.Now(), time.Now().Add(time.Minute)); err == nil {
+		t.Error("expected error for a window without results")
+	}
+}
+
+func TestGenerateIntermediateReportConcurrentWithCollection(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "intermediate_concurrent"})
+
+	start := time.Now().Add(-time.Minute)
+	collectWindow(collector, start, 50, "/warmup")
+	waitFor(t, func() bool { return len(collector.Results()) == 50 })
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			if _, err := collector.GenerateIntermediateReport(start, start.Add(time.Minute)); err != nil {
+				t.Errorf("failed to generate intermediate report: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			collectWindow(collector, time.Now(), 50, "/live")
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	wg.Wait()
+
+	waitFor(t, func() bool { return len(collector.Results()) == 550 })
+}
@@ -0,0 +1,288 @@
+// jmx_test.go
+// JMeter 脚本导入测试模块
+// 本文件负责测试 JMeter .jmx 脚本到 HTTP 测试计划的转换（线程组负载、请求默认值、信息头和定时器的作用域、
+// 请求参数、CSV 数据文件、跳过的元件），以及按导入的计划读取数据文件回放请求。
+
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// testJMX 两个线程组：下单（默认值、信息头、CSV、定时器、事务控制器、提取器）和禁用的线程组
+const testJMX = `<?xml version="1.0" encoding="UTF-8"?>
+<jmeterTestPlan version="1.2" properties="5.0" jmeter="5.6.3">
+  <hashTree>
+    <TestPlan guiclass="TestPlanGui" testclass="TestPlan" testname="Shop" enabled="true">
+      <elementProp name="TestPlan.user_defined_variables" elementType="Arguments">
+        <collectionProp name="Arguments.arguments">
+          <elementProp name="host" elementType="Argument">
+            <stringProp name="Argument.name">host</stringProp>
+            <stringProp name="Argument.value">shop.example.com</stringProp>
+          </elementProp>
+        </collectionProp>
+      </elementProp>
+    </TestPlan>
+    <hashTree>
+      <ConfigTestElement guiclass="HttpDefaultsGui" testclass="ConfigTestElement" testname="HTTP Request Defaults" enabled="true">
+        <stringProp name="HTTPSampler.domain">${host}</stringProp>
+        <stringProp name="HTTPSampler.protocol">https</stringProp>
+        <stringProp name="HTTPSampler.port">443</stringProp>
+      </ConfigTestElement>
+      <hashTree/>
+      <HeaderManager guiclass="HeaderPanel" testclass="HeaderManager" testname="Headers" enabled="true">
+        <collectionProp name="HeaderManager.headers">
+          <elementProp name="" elementType="Header">
+            <stringProp name="Header.name">Accept</stringProp>
+            <stringProp name="Header.value">application/json</stringProp>
+          </elementProp>
+        </collectionProp>
+      </HeaderManager>
+      <hashTree/>
+      <ThreadGroup guiclass="ThreadGroupGui" testclass="ThreadGroup" testname="Checkout" enabled="true">
+        <stringProp name="ThreadGroup.num_threads">${__P(threads,20)}</stringProp>
+        <stringProp name="ThreadGroup.ramp_time">10</stringProp>
+        <elementProp name="ThreadGroup.main_controller" elementType="LoopController">
+          <boolProp name="LoopController.continue_forever">false</boolProp>
+          <stringProp name="LoopController.loops">5</stringProp>
+        </elementProp>
+        <boolProp name="ThreadGroup.scheduler">true</boolProp>
+        <stringProp name="ThreadGroup.duration">300</stringProp>
+      </ThreadGroup>
+      <hashTree>
+        <CSVDataSet guiclass="TestBeanGUI" testclass="CSVDataSet" testname="Users" enabled="true">
+          <stringProp name="filename">users.csv</stringProp>
+          <stringProp name="variableNames">user,sku</stringProp>
+          <boolProp name="ignoreFirstLine">true</boolProp>
+          <stringProp name="delimiter">;</stringProp>
+          <boolProp name="recycle">false</boolProp>
+          <stringProp name="shareMode">shareMode.all</stringProp>
+        </CSVDataSet>
+        <hashTree/>
+        <ConstantTimer guiclass="ConstantTimerGui" testclass="ConstantTimer" testname="Think" enabled="true">
+          <stringProp name="ConstantTimer.delay">1000</stringProp>
+        </ConstantTimer>
+        <hashTree/>
+        <HTTPSamplerProxy guiclass="HttpTestSampleGui" testclass="HTTPSamplerProxy" testname="Search" enabled="true">
+          <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+            <collectionProp name="Arguments.arguments">
+              <elementProp name="q" elementType="HTTPArgument">
+                <boolProp name="HTTPArgument.always_encode">true</boolProp>
+                <stringProp name="Argument.name">q</stringProp>
+                <stringProp name="Argument.value">red shoes ${sku}</stringProp>
+              </elementProp>
+            </collectionProp>
+          </elementProp>
+          <stringProp name="HTTPSampler.path">/api/search</stringProp>
+          <stringProp name="HTTPSampler.method">GET</stringProp>
+        </HTTPSamplerProxy>
+        <hashTree>
+          <RegexExtractor guiclass="RegexExtractorGui" testclass="RegexExtractor" testname="Extract id" enabled="true"/>
+          <hashTree/>
+        </hashTree>
+        <TransactionController guiclass="TransactionControllerGui" testclass="TransactionController" testname="Order" enabled="true"/>
+        <hashTree>
+          <HTTPSamplerProxy guiclass="HttpTestSampleGui" testclass="HTTPSamplerProxy" testname="Create order" enabled="true">
+            <boolProp name="HTTPSampler.postBodyRaw">true</boolProp>
+            <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+              <collectionProp name="Arguments.arguments">
+                <elementProp name="" elementType="HTTPArgument">
+                  <stringProp name="Argument.value">{"user":"${user}","sku":"${sku}","tag":"{{x}}"}</stringProp>
+                </elementProp>
+              </collectionProp>
+            </elementProp>
+            <stringProp name="HTTPSampler.path">api/orders</stringProp>
+            <stringProp name="HTTPSampler.method">POST</stringProp>
+          </HTTPSamplerProxy>
+          <hashTree>
+            <HeaderManager guiclass="HeaderPanel" testclass="HeaderManager" testname="JSON" enabled="true">
+              <collectionProp name="HeaderManager.headers">
+                <elementProp name="" elementType="Header">
+                  <stringProp name="Header.name">Content-Type</stringProp>
+                  <stringProp name="Header.value">application/json</stringProp>
+                </elementProp>
+              </collectionProp>
+            </HeaderManager>
+            <hashTree/>
+            <ConstantTimer guiclass="ConstantTimerGui" testclass="ConstantTimer" testname="Pay" enabled="true">
+              <stringProp name="ConstantTimer.delay">500</stringProp>
+            </ConstantTimer>
+            <hashTree/>
+          </hashTree>
+          <HTTPSamplerProxy guiclass="HttpTestSampleGui" testclass="HTTPSamplerProxy" testname="Address" enabled="true">
+            <elementProp name="HTTPsampler.Arguments" elementType="Arguments">
+              <collectionProp name="Arguments.arguments">
+                <elementProp name="city" elementType="HTTPArgument">
+                  <boolProp name="HTTPArgument.always_encode">true</boolProp>
+                  <stringProp name="Argument.name">city</stringProp>
+                  <stringProp name="Argument.value">Shang Hai</stringProp>
+                </elementProp>
+              </collectionProp>
+            </elementProp>
+            <stringProp name="HTTPSampler.domain">api.example.com</stringProp>
+            <stringProp name="HTTPSampler.port">8080</stringProp>
+            <stringProp name="HTTPSampler.protocol">http</stringProp>
+            <stringProp name="HTTPSampler.path">/address</stringProp>
+            <stringProp name="HTTPSampler.method">PUT</stringProp>
+          </HTTPSamplerProxy>
+          <hashTree/>
+        </hashTree>
+        <HTTPSamplerProxy guiclass="HttpTestSampleGui" testclass="HTTPSamplerProxy" testname="Disabled" enabled="false">
+          <stringProp name="HTTPSampler.path">/disabled</stringProp>
+        </HTTPSamplerProxy>
+        <hashTree/>
+      </hashTree>
+      <ThreadGroup guiclass="ThreadGroupGui" testclass="ThreadGroup" testname="Admin" enabled="false">
+        <stringProp name="ThreadGroup.num_threads">1</stringProp>
+      </ThreadGroup>
+      <hashTree/>
+      <ResultCollector guiclass="ViewResultsFullVisualizer" testclass="ResultCollector" testname="View Results Tree" enabled="true"/>
+      <hashTree/>
+    </hashTree>
+  </hashTree>
+</jmeterTestPlan>
+`
+
+func TestImportJMX(t *testing.T) {
+	plans, warnings, err := tasks.ImportJMX(strings.NewReader(testJMX))
+	if err != nil {
+		t.Fatalf("failed to import JMX: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Name != "Checkout" || len(plans[0].Requests) != 3 {
+		t.Fatalf("expected one plan with 3 requests, got %+v", plans)
+	}
+	plan := plans[0]
+	if *plan.Load != (tasks.PlanLoad{VUs: 20, RampUp: 10 * time.Second, IterationsPerVU: 5, Duration: 300 * time.Second}) {
+		t.Errorf("unexpected load: %+v", *plan.Load)
+	}
+	if plan.Variables["host"] != "shop.example.com" {
+		t.Errorf("user defined variables should be imported: %v", plan.Variables)
+	}
+	if len(plan.Data) != 1 || plan.Data[0].File != "users.csv" || strings.Join(plan.Data[0].Variables, ",") != "user,sku" ||
+		plan.Data[0].Delimiter != ";" || !plan.Data[0].IgnoreFirstLine || !plan.Data[0].StopAtEOF {
+		t.Errorf("unexpected data sets: %+v", plan.Data)
+	}
+
+	// 默认值和信息头作用于所有请求，定时器在作用域内累加，参数编码时保留变量引用
+	search, order, address := plan.Requests[0], plan.Requests[1], plan.Requests[2]
+	if search.Name != "Search" || search.Method != "GET" || search.URL != "https://${host}/api/search?q=red+shoes+${sku}" ||
+		search.ThinkTime != time.Second || search.Headers["Accept"] != "application/json" || search.Body != "" {
+		t.Errorf("unexpected search request: %+v", search)
+	}
+	if order.Method != "POST" || order.URL != "https://${host}/api/orders" || order.ThinkTime != 1500*time.Millisecond ||
+		order.Headers["Content-Type"] != "application/json" || order.Body != `{"user":"${user}","sku":"${sku}","tag":"{{"{{"}}x}}"}` {
+		t.Errorf("unexpected order request: %+v", order)
+	}
+	if address.Method != "PUT" || address.URL != "http://api.example.com:8080/address" || address.Body != "city=Shang+Hai" ||
+		address.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected address request: %+v", address)
+	}
+
+	// 提取器不支持，给出提示；禁用的元件和监听器静默跳过
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "RegexExtractor") {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	cfg := pool.TestPlanVUConfig(plan)
+	if cfg.VUs != 20 || cfg.IterationsPerVU != 5 || cfg.Duration != 300*time.Second {
+		t.Errorf("unexpected VU config: %+v", cfg)
+	}
+
+	if _, _, err := tasks.ImportJMX(strings.NewReader(`<jmeterTestPlan><hashTree/></jmeterTestPlan>`)); err == nil {
+		t.Error("importing without thread groups should fail")
+	}
+	if _, _, err := tasks.ImportJMX(strings.NewReader(`{"log": {}}`)); err == nil {
+		t.Error("invalid JMX should be rejected")
+	}
+}
+
+func TestReplayImportedJMX(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	// 导入的计划与数据文件保存在同一目录，数据文件按计划文件所在目录查找
+	dir := t.TempDir()
+	jmx := strings.NewReplacer(
+		"shop.example.com", strings.TrimPrefix(server.URL, "http://"),
+		`<stringProp name="HTTPSampler.protocol">https</stringProp>`, `<stringProp name="HTTPSampler.protocol">http</stringProp>`,
+		`<stringProp name="HTTPSampler.port">443</stringProp>`, "",
+		"1000", "0", "500", "0",
+	).Replace(testJMX)
+	jmxPath := filepath.Join(dir, "shop.jmx")
+	if err := os.WriteFile(jmxPath, []byte(jmx), 0644); err != nil {
+		t.Fatalf("failed to write JMX: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "users.csv"), []byte("user;sku\nalice;A1\nbob;B2\n"), 0644); err != nil {
+		t.Fatalf("failed to write data file: %v", err)
+	}
+	plans, _, err := tasks.ImportJMXFile(jmxPath)
+	if err != nil {
+		t.Fatalf("failed to import JMX: %v", err)
+	}
+	if plans[0].Source != "shop.jmx" {
+		t.Errorf("unexpected plan source: %q", plans[0].Source)
+	}
+	// 只回放前两个请求，第三个请求的主机不存在
+	plans[0].Requests = plans[0].Requests[:2]
+	planPath := filepath.Join(dir, "shop.plan.yaml")
+	if err := plans[0].Save(planPath); err != nil {
+		t.Fatalf("failed to save plan: %v", err)
+	}
+	loaded, err := tasks.LoadTestPlan(planPath)
+	if err != nil {
+		t.Fatalf("failed to load plan: %v", err)
+	}
+	planTask, err := tasks.NewPlanTask(loaded)
+	if err != nil {
+		t.Fatalf("failed to create plan task: %v", err)
+	}
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	planTask.Collector = collector
+
+	// 每次执行读取一行数据，读完后停止
+	for i := 0; i < 2; i++ {
+		if err := planTask.Run(context.Background(), nil, nil, 1); err != nil {
+			t.Fatalf("run %d failed: %v", i, err)
+		}
+	}
+	if err := planTask.Run(context.Background(), nil, nil, 1); err != tasks.ErrPlanDataExhausted {
+		t.Errorf("expected the data set to be exhausted, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"GET /api/search?q=red+shoes+A1 ",
+		`POST /api/orders {"user":"alice","sku":"A1","tag":"{{x}}"}`,
+		"GET /api/search?q=red+shoes+B2 ",
+		`POST /api/orders {"user":"bob","sku":"B2","tag":"{{x}}"}`,
+	}
+	if strings.Join(received, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected replayed requests:\n%s", strings.Join(received, "\n"))
+	}
+
+	loaded.Data[0].File = "missing.csv"
+	if _, err := tasks.NewPlanTask(loaded); err == nil {
+		t.Error("a missing data file should be rejected")
+	}
+}
@@ -0,0 +1,114 @@
+// kafka_test.go
+// Kafka 压测任务测试模块
+// 本文件负责测试 Kafka 任务的配置校验、Broker 不可用时的失败记录，以及消费样本汇总出的投递吞吐量。
+// 沙箱中没有 Kafka Broker，生产和消费的完整流程需要在接入真实集群时验证。
+
+package tests
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// closedAddr 返回一个没有服务监听的本地地址
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestKafkaTaskUnavailableBroker(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	cfg := tasks.KafkaConfig{
+		Brokers:     []string{closedAddr(t)},
+		Topic:       "orders",
+		Producers:   2,
+		Messages:    3,
+		MaxAttempts: 1,
+		Timeout:     time.Second,
+	}
+	task, err := tasks.NewKafkaTask(cfg)
+	if err != nil {
+		t.Fatalf("failed to create kafka task: %v", err)
+	}
+	defer task.Close()
+	task.Collector = collector
+
+	stats, err := task.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "producer 0") || !strings.Contains(err.Error(), "producer 1") {
+		t.Errorf("expected errors from both producers, got %v", err)
+	}
+	if stats.Produced != 0 || stats.Failed != 6 || stats.Consumed != 0 {
+		t.Errorf("unexpected run stats: %+v", stats)
+	}
+	// 生产者遇到错误后停止，每个生产者记一条失败结果
+	results := collector.Results()
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Type != result.Failure || r.Method != "PRODUCE" || !strings.HasSuffix(r.URL, "/orders") {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	}
+
+	// 开启消费时先定位分区，失败直接返回
+	cfg.Consume = true
+	consumer, _ := tasks.NewKafkaTask(cfg)
+	defer consumer.Close()
+	if _, err := consumer.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to look up partitions of orders") {
+		t.Errorf("expected a partition lookup error, got %v", err)
+	}
+
+	for name, bad := range map[string]tasks.KafkaConfig{
+		"brokers": {Topic: "t"},
+		"topic":   {Brokers: []string{"localhost:9092"}},
+		"rate":    {Brokers: []string{"localhost:9092"}, Topic: "t", Rate: -1},
+		"acks":    {Brokers: []string{"localhost:9092"}, Topic: "t", RequiredAcks: "leader"},
+	} {
+		if _, err := tasks.NewKafkaTask(bad); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
+
+func TestDeliveryThroughput(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	start := time.Now().Add(-time.Minute)
+	// 4 秒内收到 20 条消息
+	for i := 0; i < 20; i++ {
+		sent := start.Add(time.Duration(i) * 200 * time.Millisecond)
+		collector.SaveSuccessResult(result.ResultData{
+			ID: "orders", Method: "CONSUME", DataType: result.DeliveryDataType,
+			StartTime: sent, EndTime: sent.Add(10 * time.Millisecond), ResponseTime: 10 * time.Millisecond,
+		})
+	}
+	collector.SaveSuccessResult(result.ResultData{ID: "orders", Method: "PRODUCE", StartTime: start, EndTime: start.Add(time.Millisecond), ResponseTime: time.Millisecond})
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	delivery := stats["DeliveryStats"].(result.DeliveryStats)
+	if delivery.Messages != 20 || delivery.Throughput != 5.26 {
+		t.Errorf("expected 20 messages at 5.26 msg/s, got %+v", delivery)
+	}
+	if md := result.GenerateMarkdownReport(stats); !strings.Contains(md, "| 投递吞吐量 (msg/s) | 5.26 |") {
+		t.Errorf("markdown report should include the delivery throughput:\n%s", md)
+	}
+}
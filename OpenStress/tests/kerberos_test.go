@@ -0,0 +1,107 @@
+// kerberos_test.go
+// Kerberos 认证任务测试模块
+// 本文件负责测试 Kerberos 认证配置的校验，以及 KDC 不可用时请求被记为失败并写入收集器。
+
+package tests
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// testKrb5Conf 返回 KDC 指向 kdc 地址的 krb5 配置
+func testKrb5Conf(kdc string) string {
+	return `
+[libdefaults]
+    default_realm = TEST.LOCAL
+    udp_preference_limit = 1
+[realms]
+    TEST.LOCAL = {
+        kdc = ` + kdc + `
+    }
+`
+}
+
+func TestKerberosConfigValidation(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "krb5.conf")
+	if err := os.WriteFile(confPath, []byte(testKrb5Conf("127.0.0.1:88")), 0644); err != nil {
+		t.Fatalf("failed to write krb5 config: %v", err)
+	}
+
+	valid := tasks.KerberosConfig{Krb5ConfPath: confPath, Username: "user", Realm: "TEST.LOCAL", Password: "secret"}
+	if _, err := tasks.NewKerberosHTTPTask(valid); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*tasks.KerberosConfig){
+		"no username":       func(c *tasks.KerberosConfig) { c.Username = "" },
+		"no credentials":    func(c *tasks.KerberosConfig) { c.Password = "" },
+		"both credentials":  func(c *tasks.KerberosConfig) { c.KeytabPath = confPath },
+		"no krb5 config":    func(c *tasks.KerberosConfig) { c.Krb5ConfPath = "" },
+		"both krb5 configs": func(c *tasks.KerberosConfig) { c.Krb5Conf = testKrb5Conf("127.0.0.1:88") },
+		"missing krb5 file": func(c *tasks.KerberosConfig) { c.Krb5ConfPath = confPath + ".missing" },
+		"missing keytab":    func(c *tasks.KerberosConfig) { c.Password, c.KeytabPath = "", confPath+".keytab" },
+		"invalid krb5 conf": func(c *tasks.KerberosConfig) {
+			c.Krb5ConfPath, c.Krb5Conf = "", "[libdefaults]\n  ticket_lifetime = forever"
+		},
+	} {
+		cfg := valid
+		mutate(&cfg)
+		if _, err := tasks.NewKerberosHTTPTask(cfg); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
+
+func TestKerberosLoginFailureIsRecorded(t *testing.T) {
+	// 拿一个空闲端口作为不可用的 KDC
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	kdc := listener.Addr().String()
+	listener.Close()
+
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	task, err := tasks.NewKerberosHTTPTask(tasks.KerberosConfig{
+		Krb5Conf: testKrb5Conf(kdc),
+		Username: "user",
+		Realm:    "TEST.LOCAL",
+		Password: "secret",
+		SPN:      "HTTP/localhost",
+	})
+	if err != nil {
+		t.Fatalf("failed to create kerberos task: %v", err)
+	}
+	defer task.Close()
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	task.Collector = collector
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/auth", nil)
+	data, err := task.Execute(req, 3)
+	if err == nil || !strings.Contains(err.Error(), "kerberos login failed") {
+		t.Fatalf("expected a login error, got %v", err)
+	}
+	if called {
+		t.Error("request should not be sent without a service ticket")
+	}
+	if data.Type != result.Failure || data.ThreadID != 3 || data.URL != server.URL+"/auth" || data.ErrorMessage != err.Error() {
+		t.Errorf("unexpected result: %+v", data)
+	}
+	if results := collector.Results(); len(results) != 1 || results[0].Type != result.Failure {
+		t.Errorf("expected the failure to be recorded, got %+v", results)
+	}
+}
@@ -0,0 +1,94 @@
+// leak_test.go
+// 压测机内存泄漏检测测试模块
+// 本文件负责测试 Monitor 的存活堆趋势检测：持续上涨时判定为疑似泄漏并通知订阅者，
+// 以及疑似泄漏区间在报告资源使用图上的标注。
+
+package tests
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// leakedMemory 测试中故意保留的内存，模拟压测机的内存泄漏
+var leakedMemory [][]byte
+
+func TestMonitorDetectsHeapGrowth(t *testing.T) {
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "leak_test.log", "LeakTest")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	thresholds := pool.ResourceThresholds{MaxCPUUsage: 100, MaxMemoryUsage: 1 << 40, MaxGoroutines: 1 << 20}
+	thresholds.Leak = pool.LeakDetection{Window: 10, MinGrowthPerMinute: 1 << 20, MinR2: 0.8}
+	monitor := pool.NewMonitor(logger, 10*time.Millisecond, thresholds)
+	var mu sync.Mutex
+	var notified []pool.LeakSuspicion
+	monitor.AddLeakSink(func(leak pool.LeakSuspicion) {
+		mu.Lock()
+		notified = append(notified, leak)
+		mu.Unlock()
+	})
+	monitor.Start()
+	defer func() { leakedMemory = nil }()
+
+	// 每一步保留 1MB 并触发 GC，存活堆稳定上涨
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && len(monitor.LeakSuspicions()) == 0 {
+		leakedMemory = append(leakedMemory, make([]byte, 1<<20))
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	monitor.Stop()
+
+	suspicions := monitor.LeakSuspicions()
+	if len(suspicions) == 0 {
+		t.Fatal("expected a probable memory leak to be detected")
+	}
+	if leak := suspicions[0]; leak.GrowthPerMinute < 1<<20 || leak.R2 < 0.8 || !leak.Start.Before(leak.End) || leak.HeapLive == 0 {
+		t.Errorf("unexpected leak suspicion: %+v", leak)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) == 0 || !notified[0].Start.Equal(suspicions[0].Start) {
+		t.Errorf("leak sink was not notified of the suspicion: %+v", notified)
+	}
+
+	// 关闭检测时不跟踪趋势
+	thresholds.Leak = pool.LeakDetection{Disabled: true}
+	if got := pool.NewMonitor(logger, time.Second, thresholds).LeakSuspicions(); got != nil {
+		t.Errorf("expected no leak tracking when disabled, got %v", got)
+	}
+}
+
+func TestMemoryLeakAnnotatedInResourceChart(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	start := time.Now().Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		collector.RecordResourceSample(result.ResourceSample{Timestamp: start.Add(time.Duration(i) * time.Second), MemoryUsage: uint64(i+1) << 20, Goroutines: 10})
+	}
+	// 持续泄漏期间同一区间的更新替换上一条记录
+	collector.RecordMemoryLeak(result.MemoryLeak{Start: start.Add(2 * time.Second), End: start.Add(5 * time.Second), GrowthPerMinute: 1 << 20})
+	collector.RecordMemoryLeak(result.MemoryLeak{Start: start.Add(2 * time.Second), End: start.Add(8 * time.Second), GrowthPerMinute: 2 << 20})
+	if leaks := collector.MemoryLeaks(); len(leaks) != 1 || !leaks[0].End.Equal(start.Add(8*time.Second)) {
+		t.Fatalf("expected one updated leak interval, got %+v", leaks)
+	}
+
+	collector.SaveSuccessResult(result.ResultData{Type: result.Success, StatusCode: 200, StartTime: start, EndTime: start.Add(5 * time.Millisecond), ResponseTime: 5 * time.Millisecond})
+	stats, err := collector.AnalyzeFile("")
+	if err != nil {
+		t.Fatalf("failed to analyze results: %v", err)
+	}
+	if leaks, ok := stats["MemoryLeaks"].([]result.MemoryLeak); !ok || len(leaks) != 1 {
+		t.Fatalf("expected the leak interval in the stats, got %v", stats["MemoryLeaks"])
+	}
+	html := result.GenerateSelfContainedHTMLReport(stats, "leak")
+	if !strings.Contains(html, "疑似内存泄漏 (+2.00 MB/min)") || !strings.Contains(html, "markArea") {
+		t.Error("resource chart should annotate the probable memory leak")
+	}
+}
@@ -0,0 +1,204 @@
+// live_test.go
+// 实时进度推送测试模块
+// 本文件负责测试 GET /api/live：按间隔推送协程池和结果的实时统计、推送达到订阅级别的日志、
+// 事件流请求通过查询参数认证，以及 API 服务关闭时结束推送；同时测试收集器按秒汇总的实时统计。
+
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/api"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// sseEvent 一条 Server-Sent Events 事件
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readSSE 在后台读取事件流，响应结束时关闭返回的通道
+func readSSE(resp *http.Response) <-chan sseEvent {
+	events := make(chan sseEvent, 64)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			case line == "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextSSE 等待下一条满足条件的事件
+func nextSSE(t *testing.T, events <-chan sseEvent, match func(sseEvent) bool) sseEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("event stream closed")
+			}
+			if match(event) {
+				return event
+			}
+		case <-timeout:
+			t.Fatal("expected event not received before timeout")
+		}
+	}
+}
+
+// openLiveStream 建立事件流连接
+func openLiveStream(t *testing.T, url string, header http.Header) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open live stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAPILiveStream(t *testing.T) {
+	server, taskPool, collector := newTestAPIServer(t)
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+
+	resp := openLiveStream(t, httpServer.URL+"/api/live?interval_ms=100", nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	events := readSSE(resp)
+
+	var first api.LiveStats
+	if err := json.Unmarshal([]byte(nextSSE(t, events, func(e sseEvent) bool { return e.name == "stats" }).data), &first); err != nil {
+		t.Fatalf("failed to decode stats event: %v", err)
+	}
+	if first.Pool.MaxWorkers != 4 || first.Results == nil {
+		t.Errorf("unexpected first stats event %+v", first)
+	}
+
+	// 完成的请求在所在的秒结束后推送
+	if err := taskPool.Submit(func(int32) {}, 1, "live-task", 0); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	now := time.Now()
+	collector.SaveSuccessResult(result.ResultData{ID: "ok", Type: result.Success, StartTime: now.Add(-20 * time.Millisecond), EndTime: now})
+	collector.SaveFailureResult(result.ResultData{ID: "slow", Type: result.Timeout, StartTime: now.Add(-time.Second), EndTime: now, ResponseTime: time.Second})
+	var second result.LiveSecond
+	nextSSE(t, events, func(e sseEvent) bool {
+		var stats api.LiveStats
+		json.Unmarshal([]byte(e.data), &stats)
+		for _, s := range stats.Seconds {
+			if s.Requests > 0 {
+				second = s
+				return true
+			}
+		}
+		return false
+	})
+	if second.Requests != 2 || second.Failures != 1 || second.Timeouts != 1 || second.MaxResponseMs != 1000 || !second.Time.Equal(now.Truncate(time.Second)) {
+		t.Errorf("unexpected live second %+v", second)
+	}
+
+	// WARN 及以上的日志实时推送，INFO 不推送
+	logger, _ := pool.GetModuleLogger("live_test")
+	logger.Log("INFO", "routine message")
+	logger.Log("ERROR", "downstream unavailable")
+	logEvent := nextSSE(t, events, func(e sseEvent) bool { return e.name == "log" })
+	var entry pool.LogEvent
+	if err := json.Unmarshal([]byte(logEvent.data), &entry); err != nil {
+		t.Fatalf("failed to decode log event: %v", err)
+	}
+	if entry.Level != "ERROR" || entry.Module != "live_test" || entry.Message != "downstream unavailable" {
+		t.Errorf("unexpected log event %+v", entry)
+	}
+
+	// 关闭 API 服务时结束推送
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("live stream should end when the server shuts down")
+		}
+	}
+}
+
+func TestAPILiveStreamAuthAndValidation(t *testing.T) {
+	server, _, _ := newTestAPIServer(t)
+	server.SetAuthenticator(newTestAuthManager(t))
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+
+	streamHeader := http.Header{"Accept": {"text/event-stream"}}
+	if resp := openLiveStream(t, httpServer.URL+"/api/live?api_key=viewer_key", streamHeader); resp.StatusCode != http.StatusOK {
+		t.Errorf("event stream should accept the api_key query parameter, got %d", resp.StatusCode)
+	}
+	if resp := openLiveStream(t, httpServer.URL+"/api/stats?api_key=viewer_key", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("other requests should not accept the api_key query parameter, got %d", resp.StatusCode)
+	}
+	for _, query := range []string{"interval_ms=10", "interval_ms=abc", "log_level=LOUD"} {
+		if code := doRequest(t, server.Handler(), http.MethodGet, "/api/live?api_key=viewer_key&"+query, nil, nil); code != http.StatusUnauthorized {
+			t.Errorf("%s without the event stream header: got %d", query, code)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/live?"+query, nil)
+		req.Header.Set(api.APIKeyHeader, "viewer_key")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestCollectorLiveSeconds(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	base := time.Now().Truncate(time.Second).Add(-5 * time.Second)
+	for i, rt := range []time.Duration{10, 30, 50} {
+		end := base.Add(time.Duration(i/2)*2*time.Second + 100*time.Millisecond)
+		collector.SaveSuccessResult(result.ResultData{ID: "r", Type: result.Success, StartTime: end.Add(-rt * time.Millisecond), EndTime: end})
+	}
+
+	seconds := collector.LiveSeconds(base, base.Add(3*time.Second))
+	if len(seconds) != 3 {
+		t.Fatalf("expected 3 seconds including empty ones, got %d", len(seconds))
+	}
+	if seconds[0].Requests != 2 || seconds[0].AvgResponseMs != 20 || seconds[0].MaxResponseMs != 30 {
+		t.Errorf("unexpected first second %+v", seconds[0])
+	}
+	if seconds[1].Requests != 0 || seconds[2].Requests != 1 {
+		t.Errorf("unexpected seconds %+v", seconds)
+	}
+	if old := collector.LiveSeconds(base.Add(-time.Hour), base); len(old) != 120 {
+		t.Errorf("live seconds should be limited to the last 120 seconds, got %d", len(old))
+	}
+}
@@ -0,0 +1,107 @@
+// lock_test.go
+// 结果目录锁测试模块
+// 本文件负责测试同一任务的并发运行互斥、共享报告根目录中的同名报告互斥，以及残留锁的自动清理。
+
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// writeLockFile 写入一个锁文件，pid 为持有者进程号
+func writeLockFile(t *testing.T, path string, pid int) {
+	t.Helper()
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(map[string]interface{}{
+		"pid": pid, "host": host, "task_id": "other", "started_at": time.Now(), "target": path,
+	})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+}
+
+// lockTestStats 生成报告所需的统计结果
+func lockTestStats(t *testing.T, collector *result.Collector) map[string]interface{} {
+	t.Helper()
+	stats, err := collector.GeneratePerformanceStats(thresholdTestResults())
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	return stats
+}
+
+func TestCollectorRunLockRejectsConcurrentRun(t *testing.T) {
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "lock_test.log", "LockTest")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	cfg := result.CollectorConfig{JTLFilePath: filepath.Join(t.TempDir(), "run.jtl"), Logger: logger, TaskID: "locked_run"}
+
+	first, err := result.NewCollector(cfg)
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	if _, err := result.NewCollector(cfg); !errors.Is(err, result.ErrLocked) {
+		t.Fatalf("expected ErrLocked for a concurrent run of the same task, got %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+	second, err := result.NewCollector(cfg)
+	if err != nil {
+		t.Fatalf("run lock should be released on close: %v", err)
+	}
+	second.Close()
+}
+
+func TestReportLockIsInSharedReportRoot(t *testing.T) {
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{TaskID: "report_lock"})
+	stats := lockTestStats(t, collector)
+
+	// 另一个运行正在写入同名报告：锁文件位于共享的报告根目录
+	lockPath := filepath.Join(reportDir, ".nightly.lock")
+	writeLockFile(t, lockPath, os.Getpid())
+	if _, err := collector.SaveReportToFile(stats, "nightly"); !errors.Is(err, result.ErrLocked) {
+		t.Fatalf("expected ErrLocked while another run writes the same report, got %v", err)
+	}
+	entries, _ := os.ReadDir(reportDir)
+	if len(entries) != 1 {
+		t.Errorf("no report directory should be created while locked, got %d entries", len(entries))
+	}
+
+	// 其他报告名不受影响
+	if _, err := collector.SaveReportToFile(stats, "other"); err != nil {
+		t.Fatalf("failed to save a differently named report: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(reportDir, ".other.lock")); !os.IsNotExist(err) {
+		t.Errorf("report lock should be removed after saving, stat err: %v", err)
+	}
+
+	os.Remove(lockPath)
+	path, err := collector.SaveReportToFile(stats, "nightly")
+	if err != nil {
+		t.Fatalf("failed to save report after the lock was released: %v", err)
+	}
+	if filepath.Dir(filepath.Dir(path)) != reportDir {
+		t.Errorf("report %s should be written under %s", path, reportDir)
+	}
+}
+
+func TestReportLockCleansStaleLock(t *testing.T) {
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{TaskID: "stale_lock"})
+	stats := lockTestStats(t, collector)
+
+	// 持有者进程已退出的锁视为残留锁
+	writeLockFile(t, filepath.Join(reportDir, ".nightly.lock"), 1<<22+12345)
+	if _, err := collector.SaveReportToFile(stats, "nightly"); err != nil {
+		t.Fatalf("stale lock should be cleaned up automatically: %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+// log_test.go
+// 日志测试模块
+// 本文件负责测试按模块划分的日志记录器、日志级别配置、调用位置记录和远程日志输出。
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+)
+
+func TestModuleLoggersShareOutput(t *testing.T) {
+	first, err := pool.InitializeLogger(t.TempDir()+"/", "log_test.log", "LogTestA")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	second, err := pool.InitializeLogger(t.TempDir()+"/", "log_test.log", "LogTestB")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	if first.Module() != "LogTestA" || second.Module() != "LogTestB" {
+		t.Fatalf("module names not kept: got %q and %q", first.Module(), second.Module())
+	}
+
+	child, err := pool.GetModuleLogger("LogTestA")
+	if err != nil {
+		t.Fatalf("failed to get module logger: %v", err)
+	}
+	if child != first || first.Named("LogTestB") != second {
+		t.Fatal("expected one logger instance per module")
+	}
+
+	// GetLogger 保持原有行为，返回第一次初始化时的日志记录器
+	root, err := pool.GetLogger()
+	if err != nil || root == nil {
+		t.Fatalf("GetLogger failed: %v", err)
+	}
+	if root.Named(root.Module()) != root {
+		t.Fatal("Named with the same module should return the logger itself")
+	}
+	first.Log("INFO", "module logger test")
+	second.Log("INFO", "module logger test")
+}
+
+func TestSetLogLevelAppliesToAllOutputs(t *testing.T) {
+	if _, err := pool.InitializeLoggerWithConfig(t.TempDir()+"/", "log_test.log", "LogTestA", config.DefaultLogConfig()); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer pool.SetLogLevel("INFO")
+
+	if err := pool.SetLogLevel("VERBOSE"); err == nil {
+		t.Fatal("expected invalid level to be rejected")
+	}
+	for _, level := range []string{"DEBUG", "WARNING", "ERROR", "info"} {
+		if err := pool.SetLogLevel(level); err != nil {
+			t.Fatalf("SetLogLevel(%s): %v", level, err)
+		}
+	}
+
+	defaults := config.DefaultLogConfig()
+	if !defaults.FileEnabled || defaults.FileEncoder != "json" || defaults.ConsoleEnabled {
+		t.Fatalf("unexpected default log config: %+v", defaults)
+	}
+}
+
+func TestLogRecordsCallerOfLog(t *testing.T) {
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "log_test.log", "LogTestCaller")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	if logger.FilePath() == "" {
+		t.Skip("file output disabled")
+	}
+
+	// 批量写入：累计 10 条后后台协程才会写文件
+	marker := fmt.Sprintf("caller-test-%d", time.Now().UnixNano())
+	for i := 0; i < 10; i++ {
+		logger.Log("ERROR", marker)
+	}
+
+	var line string
+	deadline := time.Now().Add(2 * time.Second)
+	for line == "" && time.Now().Before(deadline) {
+		content, _ := os.ReadFile(logger.FilePath())
+		for _, l := range strings.Split(string(content), "\n") {
+			if strings.Contains(l, marker) {
+				line = l
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if line == "" {
+		t.Fatal("log entry not written")
+	}
+
+	var entry struct {
+		Caller  string `json:"caller"`
+		Details struct {
+			Module string `json:"module"`
+			File   string `json:"file"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("failed to parse log entry %q: %v", line, err)
+	}
+	if !strings.Contains(entry.Caller, "log_test.go") || !strings.HasSuffix(entry.Details.File, "log_test.go") {
+		t.Fatalf("caller should point to log_test.go, got caller=%q file=%q", entry.Caller, entry.Details.File)
+	}
+	if entry.Details.Module != "LogTestCaller" {
+		t.Fatalf("expected module LogTestCaller, got %q", entry.Details.Module)
+	}
+}
+
+func TestLoadRemoteLogConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remote_log.yaml")
+	content := `
+loki:
+  url: http://loki:3100
+  tenant_id: team-a
+  labels:
+    env: staging
+kafka:
+  brokers: ["kafka-1:9092", "kafka-2:9092"]
+  topic: openstress-logs
+level: WARN
+batch_size: 50
+flush_interval: 500ms
+max_retries: 5
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadRemoteLogConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.Loki == nil || cfg.Loki.URL != "http://loki:3100" || cfg.Loki.Labels["env"] != "staging" {
+		t.Fatalf("unexpected loki config: %+v", cfg.Loki)
+	}
+	if cfg.Kafka == nil || len(cfg.Kafka.Brokers) != 2 || cfg.Kafka.Topic != "openstress-logs" {
+		t.Fatalf("unexpected kafka config: %+v", cfg.Kafka)
+	}
+	if cfg.Level != "WARN" || cfg.BatchSize != 50 || cfg.FlushInterval != 500*time.Millisecond || cfg.MaxRetries != 5 {
+		t.Fatalf("unexpected remote log config: %+v", cfg)
+	}
+
+	if err := os.WriteFile(path, []byte("kafka:\n  topic: logs\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := config.LoadRemoteLogConfig(path); err == nil {
+		t.Fatal("expected kafka config without brokers to be rejected")
+	}
+}
+
+func TestLokiSinkRetriesAndDelivers(t *testing.T) {
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "log_test.log", "LogTestLoki")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+
+	var mu sync.Mutex
+	var requests int
+	var lines []string
+	var tenant string
+	var labels map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		// 第一次推送失败，验证重试
+		if requests == 1 {
+			http.Error(w, "ingester unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var push struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][2]string       `json:"values"`
+			} `json:"streams"`
+		}
+		if r.URL.Path != "/loki/api/v1/push" || json.NewDecoder(r.Body).Decode(&push) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		tenant = r.Header.Get("X-Scope-OrgID")
+		for _, stream := range push.Streams {
+			labels = stream.Stream
+			for _, value := range stream.Values {
+				lines = append(lines, value[1])
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	closeRemote, err := logger.AddRemoteSinks(config.RemoteLogConfig{
+		Loki:          &config.LokiConfig{URL: server.URL, TenantID: "team-a", Labels: map[string]string{"env": "test"}},
+		FlushInterval: 20 * time.Millisecond,
+		RetryBackoff:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to add remote sinks: %v", err)
+	}
+
+	marker := fmt.Sprintf("loki-test-%d", time.Now().UnixNano())
+	for i := 0; i < 10; i++ {
+		logger.Log("INFO", marker)
+	}
+	// 本地按 10 条一批写出，补足一批确保上面的日志都已交给远程输出
+	for i := 0; i < 10; i++ {
+		logger.Log("INFO", "loki-test-padding")
+	}
+
+	delivered := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, line := range lines {
+			if strings.Contains(line, marker) {
+				n++
+			}
+		}
+		return n
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for delivered() < 10 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := closeRemote(); err != nil {
+		t.Fatalf("failed to close remote sinks: %v", err)
+	}
+
+	if n := delivered(); n != 10 {
+		t.Fatalf("expected 10 entries delivered to loki, got %d", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests < 2 || tenant != "team-a" || labels["env"] != "test" || labels["job"] != "openstress" {
+		t.Fatalf("unexpected push: requests=%d tenant=%q labels=%v", requests, tenant, labels)
+	}
+}
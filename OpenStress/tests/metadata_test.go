@@ -0,0 +1,89 @@
+// metadata_test.go
+// 运行元数据测试模块
+// 本文件负责测试结果收集器记录的运行元数据：测试计划名称（默认使用任务ID）、主机和运行时信息、
+// 隐藏敏感的命令行参数、模拟的客户端带宽，以及元数据写入统计结果、导出的 JSON 和 HTML 报告概览。
+
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestRunMetadataInStatsAndReports(t *testing.T) {
+	args := os.Args
+	os.Args = []string{"openstress", "-api", "-addr", ":9000", "-webhook-token=abc123", "-api-key", "s3cr3t"}
+	t.Cleanup(func() { os.Args = args })
+
+	collector, _ := newTestCollector(t, result.CollectorConfig{TestPlan: "checkout <flow>"})
+	stats := lockTestStats(t, collector)
+
+	metadata, ok := stats["RunMetadata"].(result.RunMetadata)
+	if !ok {
+		t.Fatalf("stats should include run metadata, got %T", stats["RunMetadata"])
+	}
+	if metadata.TestPlan != "checkout <flow>" || metadata.GOMAXPROCS != runtime.GOMAXPROCS(0) || metadata.OS != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+	if metadata.Version == "" || metadata.StartTime.IsZero() {
+		t.Errorf("version and start time should be recorded, got %+v", metadata)
+	}
+	hostname, _ := os.Hostname()
+	if metadata.Hostname != hostname {
+		t.Errorf("expected hostname %s, got %s", hostname, metadata.Hostname)
+	}
+	if got := strings.Join(metadata.Args, " "); got != "-api -addr :9000 -webhook-token=*** -api-key ***" {
+		t.Errorf("sensitive arguments should be redacted, got %s", got)
+	}
+
+	data, err := collector.ExportStats(result.ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("failed to export stats: %v", err)
+	}
+	var exported struct {
+		RunMetadata map[string]interface{} `json:"RunMetadata"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("failed to decode exported stats: %v", err)
+	}
+	if exported.RunMetadata["test_plan"] != "checkout <flow>" || exported.RunMetadata["hostname"] != hostname {
+		t.Errorf("exported JSON should include run metadata, got %v", exported.RunMetadata)
+	}
+
+	html := result.GenerateHTMLReport(stats, "metadata")
+	if !strings.Contains(html, "<th>测试计划</th><td>checkout &lt;flow&gt;</td>") || !strings.Contains(html, "<th>命令行参数</th>") {
+		t.Error("report overview should include escaped run metadata")
+	}
+	if strings.Contains(html, "s3cr3t") || strings.Contains(html, "abc123") {
+		t.Error("report should not contain redacted arguments")
+	}
+}
+
+func TestRunMetadataDefaultsToTaskID(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "report"})
+	if plan := collector.RunMetadata().TestPlan; plan != "report" {
+		t.Errorf("test plan should default to the task ID, got %s", plan)
+	}
+}
+
+func TestRunMetadataRecordsBandwidth(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{Bandwidth: result.SimulatedBandwidth{DownloadBytesPerSecond: 200 * 1024}})
+	bandwidth := collector.RunMetadata().Bandwidth
+	if bandwidth == nil || bandwidth.DownloadBytesPerSecond != 200*1024 || bandwidth.UploadBytesPerSecond != 0 {
+		t.Fatalf("unexpected bandwidth metadata %+v", bandwidth)
+	}
+	html := result.GenerateHTMLReport(lockTestStats(t, collector), "bandwidth")
+	if !strings.Contains(html, "<th>模拟带宽</th><td>上行 不限速，下行 200.00 KB/s</td>") {
+		t.Error("report overview should include the simulated bandwidth")
+	}
+
+	plain, _ := newTestCollector(t, result.CollectorConfig{})
+	if plain.RunMetadata().Bandwidth != nil {
+		t.Error("bandwidth should not be recorded when it is not simulated")
+	}
+}
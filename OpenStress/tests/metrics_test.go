@@ -0,0 +1,128 @@
+// metrics_test.go
+// 自定义指标测试模块
+// 本文件负责测试任务代码上报的计数器、仪表和趋势指标的汇总结果，
+// 同名指标类型冲突时的处理，以及自定义指标在 HTML、Markdown 报告和趋势图中的展示。
+
+package tests
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestCustomMetricsAggregation(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+
+	// 多个任务并发上报
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processed := collector.Counter("items_processed")
+			latency := collector.Trend("db_query")
+			for i := 1; i <= 25; i++ {
+				processed.Add(2)
+				latency.Add(float64(i))
+			}
+		}()
+	}
+	wg.Wait()
+	collector.Gauge("queue_depth").Set(7)
+	collector.Gauge("queue_depth").Set(3)
+	collector.Counter("cache_hit").Inc()
+
+	now := time.Now()
+	results := []result.ResultData{{ID: "r", Type: result.Success, StartTime: now.Add(-2 * time.Second), EndTime: now, ResponseTime: 10 * time.Millisecond}}
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	metrics, ok := stats["CustomMetrics"].([]result.CustomMetricStat)
+	if !ok || len(metrics) != 4 {
+		t.Fatalf("expected 4 custom metrics, got %v", stats["CustomMetrics"])
+	}
+	byName := make(map[string]result.CustomMetricStat)
+	for _, m := range metrics {
+		byName[m.Name] = m
+	}
+	if names := []string{metrics[0].Name, metrics[1].Name, metrics[2].Name, metrics[3].Name}; strings.Join(names, ",") != "cache_hit,db_query,items_processed,queue_depth" {
+		t.Errorf("metrics should be sorted by name, got %v", names)
+	}
+
+	counter := byName["items_processed"]
+	if counter.Type != result.MetricCounter || counter.Count != 100 || counter.Value != 200 {
+		t.Errorf("unexpected counter: %+v", counter)
+	}
+	if counter.Rate < 99 || counter.Rate > 101 {
+		t.Errorf("counter rate should be about 100/s over the 2s run, got %v", counter.Rate)
+	}
+	gauge := byName["queue_depth"]
+	if gauge.Type != result.MetricGauge || gauge.Value != 3 || gauge.Min != 3 || gauge.Max != 7 {
+		t.Errorf("unexpected gauge: %+v", gauge)
+	}
+	trend := byName["db_query"]
+	if trend.Type != result.MetricTrend || trend.Count != 100 || trend.Min != 1 || trend.Max != 25 || trend.Avg != 13 || trend.P90 != 23 || trend.P95 != 24 {
+		t.Errorf("unexpected trend: %+v", trend)
+	}
+
+	series, ok := stats["CustomMetricSeries"].([]result.CustomMetricSample)
+	if !ok || len(series) == 0 {
+		t.Fatal("custom metric series should be recorded")
+	}
+	var total float64
+	for _, s := range series {
+		total += s.Values["items_processed"]
+	}
+	if total != 200 {
+		t.Errorf("per-second counter values should add up to 200, got %v", total)
+	}
+}
+
+func TestCustomMetricTypeConflict(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	collector.Counter("orders").Add(5)
+	collector.Gauge("orders").Set(100)
+
+	now := time.Now()
+	stats, err := collector.GeneratePerformanceStats([]result.ResultData{{ID: "r", Type: result.Success, StartTime: now, EndTime: now}})
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	metrics := stats["CustomMetrics"].([]result.CustomMetricStat)
+	if len(metrics) != 1 || metrics[0].Type != result.MetricCounter || metrics[0].Value != 5 {
+		t.Errorf("the conflicting gauge should be dropped, got %+v", metrics)
+	}
+}
+
+func TestCustomMetricsInReports(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	collector.Counter("items_processed").Add(42)
+	collector.Trend("payload_kb").Add(3.5)
+
+	now := time.Now()
+	stats, err := collector.GeneratePerformanceStats([]result.ResultData{{ID: "r", Type: result.Success, StartTime: now.Add(-time.Second), EndTime: now}})
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+
+	html := result.GenerateSelfContainedHTMLReport(stats, "metrics")
+	for _, want := range []string{"自定义指标", "items_processed", "payload_kb", "计数器", "趋势"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML report should contain %q", want)
+		}
+	}
+	if n := strings.Count(html, "echarts.init("); n != 4 {
+		t.Errorf("expected 4 inline charts including the custom metrics chart, got %d", n)
+	}
+
+	stats["Language"] = result.LanguageEnUS
+	markdown := result.GenerateMarkdownReport(stats)
+	if !strings.Contains(markdown, "### Custom Metrics") || !strings.Contains(markdown, "| items_processed | Counter | 1 | 42.00 |") {
+		t.Errorf("markdown report should list custom metrics:\n%s", markdown)
+	}
+}
@@ -0,0 +1,111 @@
+// mix_test.go
+// 流量配比测试模块
+// 本文件负责测试按权重混合多个任务：配置校验、每次迭代按权重选择任务、实际占比统计以及报告中的流量配比表。
+
+package tests
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestNewTrafficMixValidation(t *testing.T) {
+	for name, entries := range map[string][]pool.MixEntry{
+		"empty":     nil,
+		"no name":   {{Weight: 1}},
+		"zero":      {{Task: "browse", Weight: 0}},
+		"negative":  {{Task: "browse", Weight: -1}},
+		"duplicate": {{Task: "browse", Weight: 1}, {Task: "browse", Weight: 2}},
+	} {
+		if _, err := pool.NewTrafficMix(entries); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	mix, err := pool.NewTrafficMix([]pool.MixEntry{{Task: "browse", Weight: 1}, {Task: "search", Weight: 3}})
+	if err != nil {
+		t.Fatalf("failed to create mix: %v", err)
+	}
+	if shares := mix.Shares(); shares[0].Target != 25 || shares[1].Target != 75 || shares[0].Actual != 0 {
+		t.Errorf("unexpected shares before any iteration: %+v", shares)
+	}
+
+	// 相同种子下选择的任务序列相同
+	other, _ := pool.NewTrafficMix([]pool.MixEntry{{Task: "browse", Weight: 1}, {Task: "search", Weight: 3}})
+	r1, r2 := rand.New(rand.NewSource(7)), rand.New(rand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		if a, b := mix.Pick(r1), other.Pick(r2); a != b {
+			t.Fatalf("pick %d differs with the same seed: %s vs %s", i, a, b)
+		}
+	}
+}
+
+func TestStartMixVUs(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	var browse, search, checkout atomic.Int64
+	taskPool.RegisterTask("browse", func(int32) { browse.Add(1) })
+	taskPool.RegisterTask("search", func(int32) { search.Add(1) })
+	taskPool.RegisterTask("checkout", func(int32) { checkout.Add(1) })
+
+	missing, _ := pool.NewTrafficMix([]pool.MixEntry{{Task: "browse", Weight: 1}, {Task: "missing", Weight: 1}})
+	if _, err := taskPool.StartMixVUs(missing, pool.VUConfig{VUs: 1, Iterations: 1}); err == nil {
+		t.Error("expected an error for an unregistered task")
+	}
+
+	mix, err := pool.NewTrafficMix([]pool.MixEntry{{Task: "browse", Weight: 70}, {Task: "search", Weight: 20}, {Task: "checkout", Weight: 10}})
+	if err != nil {
+		t.Fatalf("failed to create mix: %v", err)
+	}
+	run, err := taskPool.StartMixVUs(mix, pool.VUConfig{VUs: 4, Iterations: 5000})
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	run.Wait()
+
+	shares := mix.Shares()
+	executed := []int64{browse.Load(), search.Load(), checkout.Load()}
+	var total int64
+	for i, share := range shares {
+		if share.Iterations != executed[i] {
+			t.Errorf("%s: counted %d iterations but executed %d", share.Task, share.Iterations, executed[i])
+		}
+		if math.Abs(share.Actual-share.Target) > 3 {
+			t.Errorf("%s: actual share %.2f%% too far from target %.2f%%", share.Task, share.Actual, share.Target)
+		}
+		total += share.Iterations
+	}
+	if total != 5000 {
+		t.Errorf("expected 5000 iterations in total, got %d", total)
+	}
+}
+
+func TestTrafficMixInReport(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	start := time.Now()
+	collector.SaveSuccessResult(result.ResultData{Type: result.Success, StatusCode: 200, StartTime: start, EndTime: start.Add(10 * time.Millisecond), ResponseTime: 10 * time.Millisecond})
+	collector.SetTrafficMix([]result.TrafficShare{
+		{Task: "browse", Weight: 70, Target: 70, Iterations: 712, Actual: 71.2},
+		{Task: "checkout", Weight: 30, Target: 30, Iterations: 288, Actual: 28.8},
+	})
+
+	stats, err := collector.AnalyzeFile("")
+	if err != nil {
+		t.Fatalf("failed to analyze results: %v", err)
+	}
+	if shares, ok := stats["TrafficMix"].([]result.TrafficShare); !ok || len(shares) != 2 {
+		t.Fatalf("expected the traffic mix in the stats, got %v", stats["TrafficMix"])
+	}
+	if !strings.Contains(result.GenerateMarkdownReport(stats), "| checkout | 30 | 30.00% | 288 | 28.80% |") {
+		t.Error("markdown report should include the traffic mix")
+	}
+	if !strings.Contains(result.GenerateHTMLReport(stats), "<h2>流量配比</h2>") {
+		t.Error("html report should include the traffic mix")
+	}
+}
@@ -0,0 +1,284 @@
+// mqtt_test.go
+// MQTT 压测任务测试模块
+// 本文件使用一个精简的内存 MQTT Broker 测试发布/订阅压测：QoS 1/2 的确认流程、投递延迟样本的记录、
+// 投递延迟与请求统计分开汇总并出现在报告中，以及认证失败和配置校验。
+
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// testBroker 精简的 MQTT Broker：只支持单层精确主题匹配，按消息的 QoS 转发给订阅者
+type testBroker struct {
+	listener net.Listener
+	password string // 非空时校验 CONNECT 中的密码
+
+	mu     sync.Mutex
+	subs   map[string][]*brokerConn
+	nextID uint16
+}
+
+// brokerConn Broker 侧的一个客户端连接
+type brokerConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+}
+
+func (c *brokerConn) write(header byte, body []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	packet := []byte{header}
+	for n := len(body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	c.conn.Write(append(packet, body...))
+}
+
+func newTestBroker(t *testing.T, password string) *testBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	b := &testBroker{listener: listener, password: password, subs: make(map[string][]*brokerConn)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(&brokerConn{conn: conn})
+		}
+	}()
+	return b
+}
+
+func (b *testBroker) addr() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// readPacket 读取一个 MQTT 报文
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(c&0x7f) * multiplier
+		multiplier *= 128
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+// mqttString 读取带长度前缀的字符串，返回字符串和剩余部分
+func mqttString(body []byte) (string, []byte) {
+	n := int(binary.BigEndian.Uint16(body))
+	return string(body[2 : 2+n]), body[2+n:]
+}
+
+func (b *testBroker) serve(c *brokerConn) {
+	defer c.conn.Close()
+	r := bufio.NewReader(c.conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case 1: // CONNECT
+			_, rest := mqttString(body) // 协议名
+			flags := rest[1]
+			_, rest = mqttString(rest[4:]) // 客户端 ID
+			var password string
+			if flags&0x80 != 0 {
+				_, rest = mqttString(rest)
+			}
+			if flags&0x40 != 0 {
+				password, _ = mqttString(rest)
+			}
+			if b.password != "" && password != b.password {
+				c.write(2<<4, []byte{0, 4})
+				return
+			}
+			c.write(2<<4, []byte{0, 0})
+		case 8: // SUBSCRIBE
+			topic, rest := mqttString(body[2:])
+			b.mu.Lock()
+			b.subs[topic] = append(b.subs[topic], c)
+			b.mu.Unlock()
+			c.write(9<<4, []byte{body[0], body[1], rest[0]})
+		case 3: // PUBLISH
+			qos := (header >> 1) & 0x03
+			topic, rest := mqttString(body)
+			if qos > 0 {
+				id := rest[:2]
+				rest = rest[2:]
+				if qos == 1 {
+					c.write(4<<4, id)
+				} else {
+					c.write(5<<4, id)
+				}
+			}
+			b.forward(topic, qos, rest)
+		case 5: // PUBREC（订阅者收到 QoS 2 消息）
+			c.write(6<<4|0x02, body[:2])
+		case 6: // PUBREL（发布者的 QoS 2 消息）
+			c.write(7<<4, body[:2])
+		case 12: // PINGREQ
+			c.write(13<<4, nil)
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+// forward 将消息转发给主题的所有订阅者
+func (b *testBroker) forward(topic string, qos byte, payload []byte) {
+	b.mu.Lock()
+	subs := append([]*brokerConn(nil), b.subs[topic]...)
+	b.mu.Unlock()
+	for _, sub := range subs {
+		body := append([]byte{byte(len(topic) >> 8), byte(len(topic))}, topic...)
+		if qos > 0 {
+			b.mu.Lock()
+			b.nextID++
+			id := b.nextID
+			b.mu.Unlock()
+			body = binary.BigEndian.AppendUint16(body, id)
+		}
+		sub.write(3<<4|qos<<1, append(body, payload...))
+	}
+}
+
+func TestMQTTPublishSubscribeDeliveryLatency(t *testing.T) {
+	for _, qos := range []byte{0, 1, 2} {
+		broker := newTestBroker(t, "")
+		collector, _ := newTestCollector(t, result.CollectorConfig{})
+		task, err := tasks.NewMQTTTask(tasks.MQTTConfig{
+			Broker:      broker.addr(),
+			Topic:       "sensors/temp",
+			QoS:         qos,
+			Publishers:  2,
+			Subscribers: 2,
+			Messages:    5,
+			Rate:        200,
+			PayloadSize: 64,
+		})
+		if err != nil {
+			t.Fatalf("qos %d: failed to create mqtt task: %v", qos, err)
+		}
+		task.Collector = collector
+
+		stats, err := task.Run(context.Background())
+		if err != nil {
+			t.Fatalf("qos %d: run failed: %v", qos, err)
+		}
+		if stats.Published != 10 || stats.Failed != 0 || stats.Expected != 20 || stats.Delivered != 20 || stats.Lost() != 0 {
+			t.Errorf("qos %d: unexpected run stats: %+v", qos, stats)
+		}
+
+		var publishes, deliveries int
+		for _, r := range collector.Results() {
+			switch {
+			case r.DataType == result.DeliveryDataType:
+				deliveries++
+				if r.DataReceived != 64 || r.ResponseTime <= 0 || r.ResponseTime > time.Second {
+					t.Errorf("qos %d: unexpected delivery sample: %+v", qos, r)
+				}
+			case r.Method == "PUBLISH" && r.Type == result.Success:
+				publishes++
+				if r.DataSent != 64 || !strings.HasSuffix(r.URL, "/sensors/temp") {
+					t.Errorf("qos %d: unexpected publish result: %+v", qos, r)
+				}
+			}
+		}
+		if publishes != 10 || deliveries != 20 {
+			t.Errorf("qos %d: expected 10 publishes and 20 deliveries, got %d and %d", qos, publishes, deliveries)
+		}
+
+		if qos != 2 {
+			continue
+		}
+		results, err := collector.LoadResultsFromFile()
+		if err != nil {
+			t.Fatalf("failed to load results: %v", err)
+		}
+		report, err := collector.GeneratePerformanceStats(results)
+		if err != nil {
+			t.Fatalf("failed to generate stats: %v", err)
+		}
+		if total := report["TotalRequests"].(int); total != 10 {
+			t.Errorf("deliveries should not count as requests, got %d requests", total)
+		}
+		delivery, ok := report["DeliveryStats"].(result.DeliveryStats)
+		if !ok || delivery.Messages != 20 || delivery.P99 < delivery.P50 {
+			t.Errorf("unexpected delivery stats: %+v", report["DeliveryStats"])
+		}
+		html := result.GenerateHTMLReport(report)
+		if !strings.Contains(html, "static/delivery_chart.html") || !strings.Contains(html, "消息投递延迟") {
+			t.Error("report should include the delivery latency section and chart")
+		}
+	}
+}
+
+func TestMQTTAuthAndConfig(t *testing.T) {
+	broker := newTestBroker(t, "secret")
+	cfg := tasks.MQTTConfig{Broker: broker.addr(), Topic: "t", Username: "user", Password: "wrong", Subscribers: 1}
+	task, err := tasks.NewMQTTTask(cfg)
+	if err != nil {
+		t.Fatalf("failed to create mqtt task: %v", err)
+	}
+	if _, err := task.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+
+	cfg.Password = "secret"
+	task, _ = tasks.NewMQTTTask(cfg)
+	if stats, err := task.Run(context.Background()); err != nil || stats.Delivered != 1 {
+		t.Errorf("expected one delivered message, got %+v, %v", stats, err)
+	}
+
+	for name, bad := range map[string]tasks.MQTTConfig{
+		"no broker": {Topic: "t"},
+		"scheme":    {Broker: "ws://localhost:80", Topic: "t"},
+		"no topic":  {Broker: "localhost:1883"},
+		"wildcard":  {Broker: "localhost:1883", Topic: "t/#"},
+		"qos":       {Broker: "localhost:1883", Topic: "t", QoS: 3},
+		"rate":      {Broker: "localhost:1883", Topic: "t", Rate: -1},
+	} {
+		if _, err := tasks.NewMQTTTask(bad); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
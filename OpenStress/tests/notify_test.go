@@ -0,0 +1,223 @@
+// notify_test.go
+// 压测完成通知测试模块
+// 本文件负责测试通知的消息格式（JSON、Slack、钉钉加签）、按事件过滤、自定义模板、失败重试，以及示例配置的加载。
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/notify"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// notifyRecorder 记录收到的通知请求
+type notifyRecorder struct {
+	mu       sync.Mutex
+	bodies   []string
+	queries  []string
+	failures atomic.Int32 // 前若干次请求返回的失败次数
+	status   int          // 失败时返回的状态码
+}
+
+func (r *notifyRecorder) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(body))
+		r.queries = append(r.queries, req.URL.RawQuery)
+		r.mu.Unlock()
+		if r.failures.Add(-1) >= 0 {
+			w.WriteHeader(r.status)
+			return
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (r *notifyRecorder) requests() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+// notifyTestSummary 一次阈值未通过的压测摘要
+func notifyTestSummary(t *testing.T) notify.Summary {
+	t.Helper()
+	collector, _ := newTestCollector(t, result.CollectorConfig{Thresholds: []string{"max <= 100ms", "error_rate < 5%"}})
+	stats, err := collector.GeneratePerformanceStats(thresholdTestResults())
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	collector.EvaluateThresholds(stats)
+	return notify.SummaryFromStats(stats, "nightly", "https://reports.example.com/nightly/index.html")
+}
+
+func TestSummaryFromStats(t *testing.T) {
+	summary := notifyTestSummary(t)
+	if summary.Event != notify.EventThresholdFailed || summary.ThresholdsPassed {
+		t.Errorf("expected a threshold failure event, got %+v", summary)
+	}
+	if summary.TotalRequests != 10 || summary.ErrorRate != 10 || summary.P95 != 100*time.Millisecond {
+		t.Errorf("unexpected metrics: %+v", summary)
+	}
+	if len(summary.FailedThresholds) != 1 || summary.FailedThresholds[0] != "error_rate < 5%" {
+		t.Errorf("unexpected failed thresholds: %v", summary.FailedThresholds)
+	}
+
+	passed := notify.SummaryFromStats(map[string]interface{}{"TotalRequests": 1}, "ok", "")
+	if passed.Event != notify.EventCompleted || !passed.ThresholdsPassed {
+		t.Errorf("a run without thresholds should pass, got %+v", passed)
+	}
+}
+
+func TestNotifyFormats(t *testing.T) {
+	var generic, slack, dingtalk notifyRecorder
+	notifier, err := notify.NewNotifier(config.NotificationConfig{Webhooks: []config.WebhookConfig{
+		{Name: "generic", URL: generic.server(t).URL},
+		{Name: "slack", Format: notify.FormatSlack, URL: slack.server(t).URL},
+		{Name: "dingtalk", Format: notify.FormatDingTalk, URL: dingtalk.server(t).URL + "/robot/send?access_token=abc", Secret: "SECabc"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	if err := notifier.Notify(context.Background(), notifyTestSummary(t)); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+
+	var payload struct {
+		Event     string  `json:"event"`
+		P95Ms     float64 `json:"p95_ms"`
+		ErrorRate float64 `json:"error_rate"`
+		ReportURL string  `json:"report_url"`
+		Text      string  `json:"text"`
+	}
+	if bodies := generic.requests(); len(bodies) != 1 || json.Unmarshal([]byte(bodies[0]), &payload) != nil {
+		t.Fatalf("unexpected generic requests: %v", bodies)
+	}
+	if payload.Event != notify.EventThresholdFailed || payload.P95Ms != 100 || payload.ErrorRate != 10 || !strings.Contains(payload.Text, "failed: error_rate < 5%") {
+		t.Errorf("unexpected generic payload: %+v", payload)
+	}
+
+	var slackPayload struct {
+		Text string `json:"text"`
+	}
+	if bodies := slack.requests(); len(bodies) != 1 || json.Unmarshal([]byte(bodies[0]), &slackPayload) != nil {
+		t.Fatalf("unexpected slack requests: %v", bodies)
+	}
+	if !strings.Contains(slackPayload.Text, "*nightly* thresholds failed") || !strings.Contains(slackPayload.Text, "<https://reports.example.com/nightly/index.html|View report>") {
+		t.Errorf("unexpected slack text:\n%s", slackPayload.Text)
+	}
+
+	var dingPayload struct {
+		MsgType  string `json:"msgtype"`
+		Markdown struct {
+			Title string `json:"title"`
+			Text  string `json:"text"`
+		} `json:"markdown"`
+	}
+	if bodies := dingtalk.requests(); len(bodies) != 1 || json.Unmarshal([]byte(bodies[0]), &dingPayload) != nil {
+		t.Fatalf("unexpected dingtalk requests: %v", bodies)
+	}
+	if dingPayload.MsgType != "markdown" || dingPayload.Markdown.Title != "nightly" || !strings.Contains(dingPayload.Markdown.Text, "未通过：error_rate < 5%") {
+		t.Errorf("unexpected dingtalk payload: %+v", dingPayload)
+	}
+	if query := dingtalk.queries[0]; !strings.HasPrefix(query, "access_token=abc&timestamp=") || !strings.Contains(query, "&sign=") {
+		t.Errorf("dingtalk request should be signed, got query %q", query)
+	}
+}
+
+func TestNotifyFiltersEventsAndRendersTemplate(t *testing.T) {
+	var onFailure, always notifyRecorder
+	notifier, err := notify.NewNotifier(config.NotificationConfig{Webhooks: []config.WebhookConfig{
+		{Name: "on-failure", URL: onFailure.server(t).URL, Events: []string{notify.EventThresholdFailed}},
+		{Name: "always", Format: notify.FormatSlack, URL: always.server(t).URL, Template: "{{.Title}}: {{.TotalRequests}} requests"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	passed := notify.Summary{Event: notify.EventCompleted, Title: "smoke", TotalRequests: 42, ThresholdsPassed: true}
+	if err := notifier.Notify(context.Background(), passed); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	if got := onFailure.requests(); len(got) != 0 {
+		t.Errorf("threshold_failed webhook should not be called for a passing run, got %v", got)
+	}
+	if got := always.requests(); len(got) != 1 || got[0] != `{"text":"smoke: 42 requests"}` {
+		t.Errorf("unexpected templated request: %v", got)
+	}
+
+	if err := notifier.Notify(context.Background(), notifyTestSummary(t)); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	if got := onFailure.requests(); len(got) != 1 {
+		t.Errorf("threshold_failed webhook should be called once for a failing run, got %d", len(got))
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	flaky := notifyRecorder{status: http.StatusServiceUnavailable}
+	flaky.failures.Store(2)
+	rejected := notifyRecorder{status: http.StatusBadRequest}
+	rejected.failures.Store(10)
+	notifier, err := notify.NewNotifier(config.NotificationConfig{
+		Webhooks: []config.WebhookConfig{
+			{Name: "flaky", URL: flaky.server(t).URL},
+			{Name: "rejected", URL: rejected.server(t).URL},
+		},
+		MaxRetries:   3,
+		RetryBackoff: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+
+	err = notifier.Notify(context.Background(), notify.Summary{Title: "retry", ThresholdsPassed: true})
+	if err == nil || !strings.Contains(err.Error(), "webhook rejected") || strings.Contains(err.Error(), "webhook flaky") {
+		t.Fatalf("expected only the rejected webhook to fail, got %v", err)
+	}
+	if got := len(flaky.requests()); got != 3 {
+		t.Errorf("flaky webhook should succeed on the third attempt, got %d attempts", got)
+	}
+	if got := len(rejected.requests()); got != 1 {
+		t.Errorf("4xx responses should not be retried, got %d attempts", got)
+	}
+}
+
+func TestNotifyConfig(t *testing.T) {
+	cfg, err := config.LoadNotificationConfig("../config/notification.example.yaml")
+	if err != nil {
+		t.Fatalf("failed to load example config: %v", err)
+	}
+	notifier, err := notify.NewNotifier(cfg)
+	if err != nil {
+		t.Fatalf("example config should be valid: %v", err)
+	}
+	if link := notifier.ReportLink("reports/nightly_2024-01-02_03-04-05/nightly_2024-01-02_03-04-05.html"); link != "https://reports.example.com/openstress/nightly_2024-01-02_03-04-05/nightly_2024-01-02_03-04-05.html" {
+		t.Errorf("unexpected report link %q", link)
+	}
+
+	for _, webhook := range []config.WebhookConfig{
+		{URL: "http://localhost", Format: "teams"},
+		{URL: "http://localhost", Events: []string{"started"}},
+		{URL: "http://localhost", Template: "{{.Missing"},
+		{Format: notify.FormatSlack},
+	} {
+		if _, err := notify.NewNotifier(config.NotificationConfig{Webhooks: []config.WebhookConfig{webhook}}); err == nil {
+			t.Errorf("expected error for %+v", webhook)
+		}
+	}
+}
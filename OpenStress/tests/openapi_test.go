@@ -0,0 +1,222 @@
+// openapi_test.go
+// OpenAPI 导入测试模块
+// 本文件负责测试根据 OpenAPI 3 和 Swagger 2.0 文档生成测试计划（服务地址和路径参数变量、示例请求体、
+// 必填参数、认证请求头、标签过滤），以及 weighted 模式按权重回放请求。
+
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// testOpenAPI 宠物商店：带引用、只读字段、循环引用和多种参数的 OpenAPI 3 文档
+const testOpenAPI = `openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: api
+security:
+  - bearer: []
+paths:
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: integer, example: 42}
+    get:
+      operationId: getPet
+      tags: [pets]
+      parameters:
+        - name: X-Request-Id
+          in: header
+          required: true
+          schema: {type: string, format: uuid}
+    delete:
+      tags: [admin]
+      security: []
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema: {type: integer, minimum: 10}
+        - name: cursor
+          in: query
+          schema: {type: string}
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+  schemas:
+    NewPet:
+      allOf:
+        - $ref: '#/components/schemas/Pet'
+        - type: object
+          properties:
+            note: {type: string, default: "{{hello}}"}
+    Pet:
+      type: object
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string, example: Rex}
+        status: {type: string, enum: [available, sold]}
+        born: {type: string, format: date}
+        tags:
+          type: array
+          items: {type: string}
+        parent:
+          $ref: '#/components/schemas/Pet'
+`
+
+// testSwagger Swagger 2.0 文档，API Key 认证、请求体参数和表单参数
+const testSwagger = `{
+	"swagger": "2.0",
+	"info": {"title": "Orders"},
+	"host": "orders.example.com:8443",
+	"schemes": ["https"],
+	"basePath": "/api",
+	"securityDefinitions": {"key": {"type": "apiKey", "in": "header", "name": "X-API-Key"}},
+	"security": [{"key": []}],
+	"paths": {
+		"/orders": {"post": {"parameters": [{"in": "body", "name": "order", "schema": {"type": "object", "properties": {"qty": {"type": "integer"}, "price": {"type": "number"}}}}]}},
+		"/login": {"post": {"security": [], "parameters": [{"in": "formData", "name": "user", "type": "string", "default": "bob"}, {"in": "formData", "name": "remember", "type": "boolean"}]}}
+	}
+}`
+
+func TestImportOpenAPI(t *testing.T) {
+	plan, warnings, err := tasks.ImportOpenAPI([]byte(testOpenAPI), tasks.OpenAPIOptions{})
+	if err != nil {
+		t.Fatalf("failed to import OpenAPI: %v", err)
+	}
+	if plan.Name != "Petstore" || plan.Mode != tasks.PlanWeighted || len(plan.Requests) != 4 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if plan.Variables["base_url"] != "https://api.example.com/v1" || plan.Variables["petId"] != "42" || plan.Variables["token"] != "changeme" {
+		t.Errorf("unexpected variables: %v", plan.Variables)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"token"`) {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	// 路径按字母排序，同一路径下按 get、put、post、delete 排序
+	list, create, get, remove := plan.Requests[0], plan.Requests[1], plan.Requests[2], plan.Requests[3]
+	if list.Name != "listPets" || list.URL != "${base_url}/pets?limit=10" || list.Weight != 1 || list.Headers["Authorization"] != "Bearer ${token}" {
+		t.Errorf("unexpected list request: %+v", list)
+	}
+	wantBody := `{"born":"2024-01-01","name":"Rex","note":"{{"{{"}}hello}}","status":"available","tags":["string"]}`
+	if create.Method != "POST" || create.Body != wantBody || create.Headers["Content-Type"] != "application/json" {
+		t.Errorf("unexpected create request: %+v\n%s", create, create.Body)
+	}
+	if get.Name != "getPet" || get.URL != "${base_url}/pets/${petId}" || get.Headers["X-Request-Id"] != "3fa85f64-5717-4562-b3fc-2c963f66afa6" {
+		t.Errorf("unexpected get request: %+v", get)
+	}
+	if remove.Name != "DELETE /pets/{petId}" || remove.Headers != nil {
+		t.Errorf("operations without security should not send credentials: %+v", remove)
+	}
+
+	plan, _, err = tasks.ImportOpenAPI([]byte(testOpenAPI), tasks.OpenAPIOptions{Name: "admin", BaseURL: "http://localhost:9000/", Tags: []string{"admin"}})
+	if err != nil || plan.Name != "admin" || len(plan.Requests) != 1 || plan.Variables["base_url"] != "http://localhost:9000" {
+		t.Errorf("unexpected filtered plan: %+v (%v)", plan, err)
+	}
+
+	plan, _, err = tasks.ImportOpenAPI([]byte(testSwagger), tasks.OpenAPIOptions{})
+	if err != nil || len(plan.Requests) != 2 {
+		t.Fatalf("failed to import Swagger 2.0: %+v (%v)", plan, err)
+	}
+	login, order := plan.Requests[0], plan.Requests[1]
+	if plan.Variables["base_url"] != "https://orders.example.com:8443/api" {
+		t.Errorf("unexpected base url: %v", plan.Variables)
+	}
+	if login.Body != "remember=true&user=bob" || login.Headers["Content-Type"] != "application/x-www-form-urlencoded" || login.Headers["X-API-Key"] != "" {
+		t.Errorf("unexpected login request: %+v", login)
+	}
+	if order.Body != `{"price":1.5,"qty":1}` || order.Headers["X-API-Key"] != "${api_key}" {
+		t.Errorf("unexpected order request: %+v", order)
+	}
+
+	if _, _, err := tasks.ImportOpenAPI([]byte("openapi: 3.0.0\npaths: {}\n"), tasks.OpenAPIOptions{}); err == nil {
+		t.Error("importing without operations should fail")
+	}
+	if _, _, err := tasks.ImportOpenAPI([]byte(`{"name": "not a spec"}`), tasks.OpenAPIOptions{}); err == nil {
+		t.Error("documents that are not OpenAPI should be rejected")
+	}
+}
+
+func TestWeightedPlanReplay(t *testing.T) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer secret" && r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	plan, _, err := tasks.ImportOpenAPI([]byte(testOpenAPI), tasks.OpenAPIOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to import OpenAPI: %v", err)
+	}
+	plan.Variables["token"] = "secret"
+	plan.Requests[0].Weight = 6 // listPets
+	plan.Requests[3].Weight = 0 // 默认权重 1
+	planTask, err := tasks.NewPlanTask(plan)
+	if err != nil {
+		t.Fatalf("failed to create plan task: %v", err)
+	}
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	planTask.Collector = collector
+
+	const runs = 900
+	for i := 0; i < runs; i++ {
+		if err := planTask.Run(context.Background(), nil, nil, 1); err != nil {
+			t.Fatalf("run %d failed: %v", i, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// 权重 6:1:1:1，每次只执行一个请求
+	total := counts["GET /pets"] + counts["POST /pets"] + counts["GET /pets/42"] + counts["DELETE /pets/42"]
+	if total != runs || counts["GET /pets"] < 500 || counts["GET /pets"] > 700 || counts["DELETE /pets/42"] == 0 {
+		t.Errorf("unexpected request mix: %v", counts)
+	}
+	if len(collector.Results()) != runs {
+		t.Errorf("expected %d results, got %d", runs, len(collector.Results()))
+	}
+
+	plan.Mode = "random"
+	if _, err := tasks.NewPlanTask(plan); err == nil {
+		t.Error("unknown plan modes should be rejected")
+	}
+	plan.Mode = tasks.PlanWeighted
+	plan.Requests[0].Weight = -1
+	if _, err := tasks.NewPlanTask(plan); err == nil {
+		t.Error("negative weights should be rejected")
+	}
+}
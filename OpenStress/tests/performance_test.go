@@ -17,5 +17,669 @@
 
 package tests
 
-// 性能测试
-// TODO: 编写性能测试用例
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// newTestPool 创建用于性能测试的协程池
+func newTestPool(tb testing.TB, workers int) *pool.Pool {
+	tb.Helper()
+
+	if _, err := pool.InitializeLogger(tb.TempDir()+"/", "performance_test.log", "PerformanceTest"); err != nil {
+		tb.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := pool.NewPool(workers)
+	if taskPool == nil {
+		tb.Fatal("failed to create pool")
+	}
+	tb.Cleanup(taskPool.Shutdown)
+	return taskPool
+}
+
+// blockPool 提交一个占满唯一 worker 的任务，返回用于放行的函数
+func blockPool(tb testing.TB, taskPool *pool.Pool) func() {
+	tb.Helper()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	err := taskPool.Submit(func(int32) {
+		close(started)
+		<-release
+	}, 0, "blocker", 0)
+	if err != nil {
+		tb.Fatalf("failed to submit blocker: %v", err)
+	}
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		tb.Fatal("blocker did not start")
+	}
+	return func() { close(release) }
+}
+
+// TestPriorityScheduling 验证高优先级任务先执行，同一优先级内按提交顺序执行
+func TestPriorityScheduling(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	release := blockPool(t, taskPool)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	submissions := []struct {
+		id       string
+		priority int
+	}{
+		{"low-1", 1}, {"high-1", 10}, {"mid-1", 5}, {"low-2", 1}, {"high-2", 10}, {"mid-2", 5},
+	}
+	for _, s := range submissions {
+		id := s.id
+		wg.Add(1)
+		err := taskPool.Submit(func(int32) {
+			defer wg.Done()
+			mu.Lock()
+			order = append(order, id)
+			mu.Unlock()
+		}, s.priority, id, 0)
+		if err != nil {
+			t.Fatalf("failed to submit %s: %v", id, err)
+		}
+	}
+	if depth := taskPool.QueueDepth(); depth != len(submissions) {
+		t.Fatalf("expected queue depth %d, got %d", len(submissions), depth)
+	}
+
+	release()
+	wg.Wait()
+
+	expected := []string{"high-1", "high-2", "mid-1", "mid-2", "low-1", "low-2"}
+	if fmt.Sprint(order) != fmt.Sprint(expected) {
+		t.Fatalf("expected execution order %v, got %v", expected, order)
+	}
+}
+
+// TestPriorityAgingPreventsStarvation 验证持续提交高优先级任务时，低优先级任务因老化仍能得到执行
+func TestPriorityAgingPreventsStarvation(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	taskPool.SetPriorityAging(20 * time.Millisecond)
+	release := blockPool(t, taskPool)
+
+	lowRan := make(chan time.Time, 1)
+	submitted := time.Now()
+	if err := taskPool.Submit(func(int32) { lowRan <- time.Now() }, 0, "low", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+
+	// 始终保持队列中有高优先级任务，严格优先级下低优先级任务永远不会执行
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if taskPool.QueueDepth() < 3 {
+				taskPool.Submit(func(int32) { time.Sleep(2 * time.Millisecond) }, 10, fmt.Sprintf("high-%d", i), 0)
+			} else {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	waitFor(t, func() bool { return taskPool.QueueDepth() >= 3 })
+	release()
+
+	select {
+	case ran := <-lowRan:
+		// 优先级相差 10，每 20ms 提高 1 级，约 200ms 后超过新提交的高优先级任务
+		if waited := ran.Sub(submitted); waited < 150*time.Millisecond {
+			t.Errorf("low-priority task ran after %v, before aging could lift it", waited)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("low-priority task starved by a steady stream of high-priority tasks")
+	}
+}
+
+// TestSubmitRejectsDuplicateTaskID 验证重复提交仍在排队或执行的任务ID时 Submit 返回 ErrDuplicateTask，
+// 而 SubmitWithKey 返回已有任务
+func TestSubmitRejectsDuplicateTaskID(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	release := blockPool(t, taskPool)
+
+	var runs int32
+	count := func(int32) { atomic.AddInt32(&runs, 1) }
+	if err := taskPool.Submit(count, 0, "dup", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+	if err := taskPool.Submit(count, 0, "dup", 0); !errors.Is(err, pool.ErrDuplicateTask) {
+		t.Errorf("Submit: expected ErrDuplicateTask, got %v", err)
+	}
+	if err := taskPool.SubmitWithContext(func(*pool.TaskContext) {}, 0, "dup", 0); !errors.Is(err, pool.ErrDuplicateTask) {
+		t.Errorf("SubmitWithContext: expected ErrDuplicateTask, got %v", err)
+	}
+	info, deduplicated, err := taskPool.SubmitWithKey(count, 0, "dup", "", 0)
+	if err != nil || !deduplicated || info.ID != "dup" {
+		t.Errorf("SubmitWithKey: expected the existing task, got %+v, %v, %v", info, deduplicated, err)
+	}
+
+	release()
+	waitFor(t, func() bool {
+		info, err := taskPool.GetTaskStatus("dup")
+		return err == nil && info.Status == pool.TaskCompleted.String()
+	})
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("expected the task to run once, ran %d times", n)
+	}
+	// 任务结束后同一个任务ID可以再次提交
+	if err := taskPool.Submit(count, 0, "dup", 0); err != nil {
+		t.Errorf("resubmit after completion: %v", err)
+	}
+}
+
+// TestShutdownCancelsQueuedTasks 验证关闭协程池时排队中的任务被取消
+func TestShutdownCancelsQueuedTasks(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	release := blockPool(t, taskPool)
+	defer release()
+
+	if err := taskPool.Submit(func(int32) {}, 0, "queued", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+	taskPool.Shutdown()
+
+	info, err := taskPool.GetTaskStatus("queued")
+	if err != nil {
+		t.Fatalf("failed to get task status: %v", err)
+	}
+	if info.Status != pool.TaskCancelled.String() {
+		t.Fatalf("expected queued task to be cancelled, got %s", info.Status)
+	}
+	if err := taskPool.Submit(func(int32) {}, 0, "late", 0); err == nil {
+		t.Fatal("expected submit after shutdown to fail")
+	}
+}
+
+// TestPauseHoldsQueuedTasksUntilResume 验证暂停期间提交的任务在恢复之前不会执行
+func TestPauseHoldsQueuedTasksUntilResume(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	taskPool.Pause()
+
+	ran := make(chan struct{})
+	if err := taskPool.Submit(func(int32) { close(ran) }, 0, "paused", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+	select {
+	case <-ran:
+		t.Fatal("task ran while the pool was paused")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if info, err := taskPool.GetTaskStatus("paused"); err != nil || info.Status != pool.TaskPending.String() {
+		t.Fatalf("expected paused task to stay pending, got %+v (%v)", info, err)
+	}
+
+	taskPool.Start()
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task did not run after the pool was resumed")
+	}
+}
+
+// TestShutdownIsIdempotent 验证重复和并发关闭协程池是安全的
+func TestShutdownIsIdempotent(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			taskPool.Shutdown()
+		}()
+	}
+	wg.Wait()
+	taskPool.Shutdown()
+
+	if !taskPool.Stats().Shutdown {
+		t.Fatal("expected pool to report shutdown")
+	}
+}
+
+// TestWaitBlocksUntilSubmittedTasksFinish 验证 Wait 等待排队中和执行中的任务全部结束
+func TestWaitBlocksUntilSubmittedTasksFinish(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+
+	var done atomic.Int32
+	for i := 0; i < 5; i++ {
+		err := taskPool.Submit(func(int32) {
+			time.Sleep(30 * time.Millisecond)
+			done.Add(1)
+		}, 0, fmt.Sprintf("wait-%d", i), 0)
+		if err != nil {
+			t.Fatalf("failed to submit: %v", err)
+		}
+	}
+	taskPool.Wait()
+	if got := done.Load(); got != 5 {
+		t.Fatalf("Wait returned before all tasks finished: %d of 5 done", got)
+	}
+	if stats := taskPool.Stats(); stats.Completed != 5 {
+		t.Errorf("expected 5 completed tasks, got %+v", stats)
+	}
+}
+
+// TestWaitAfterShutdownWaitsForRunningTasks 验证关闭后 Wait 只等待正在执行的任务，排队中的任务已被取消
+func TestWaitAfterShutdownWaitsForRunningTasks(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	if err := taskPool.Submit(func(int32) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(true)
+	}, 0, "running", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+	if err := taskPool.Submit(func(int32) {}, 0, "queued", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+	<-started
+	taskPool.Shutdown()
+
+	waited := make(chan struct{})
+	go func() {
+		taskPool.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait did not return after the running task finished")
+	}
+	if !finished.Load() {
+		t.Fatal("Wait returned while a task was still running")
+	}
+	if info, err := taskPool.GetTaskStatus("queued"); err != nil || info.Status != pool.TaskCancelled.String() {
+		t.Errorf("expected queued task to be cancelled, got %+v (%v)", info, err)
+	}
+}
+
+// TestFinishedTasksAreEvicted 验证只保留最近完成的任务，等待和执行中的任务不会被清理
+func TestFinishedTasksAreEvicted(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	taskPool.SetFinishedTaskRetention(2)
+
+	for i := 0; i < 5; i++ {
+		if err := taskPool.Submit(func(int32) {}, 0, fmt.Sprintf("done-%d", i), 0); err != nil {
+			t.Fatalf("failed to submit: %v", err)
+		}
+		id := fmt.Sprintf("done-%d", i)
+		waitFor(t, func() bool {
+			info, err := taskPool.GetTaskStatus(id)
+			return err != nil || info.Status == pool.TaskCompleted.String()
+		})
+	}
+	release := blockPool(t, taskPool)
+	defer release()
+	if err := taskPool.Submit(func(int32) {}, 0, "waiting", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := taskPool.GetTaskStatus(fmt.Sprintf("done-%d", i)); err == nil {
+			t.Errorf("done-%d should have been evicted", i)
+		}
+	}
+	for _, id := range []string{"done-3", "done-4", "blocker", "waiting"} {
+		if _, err := taskPool.GetTaskStatus(id); err != nil {
+			t.Errorf("%s should still be queryable: %v", id, err)
+		}
+	}
+}
+
+// TestMonitorSamplesInResourceChart 验证 Monitor 的采样写入结果收集器，并在报告中生成资源使用图
+func TestMonitorSamplesInResourceChart(t *testing.T) {
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "performance_test.log", "PerformanceTest")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	collector, err := result.NewCollector(result.CollectorConfig{
+		BatchSize:   10,
+		JTLFilePath: filepath.Join(t.TempDir(), "resource.jtl"),
+		Logger:      logger,
+		TaskID:      "resource_test",
+	})
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	defer collector.Close()
+
+	monitor := pool.NewMonitor(logger, 10*time.Millisecond, pool.ResourceThresholds{MaxCPUUsage: 100, MaxMemoryUsage: 1 << 40, MaxGoroutines: 1 << 20})
+	monitor.AddMetricsSink(func(metrics pool.SystemMetrics) {
+		collector.RecordResourceSample(result.ResourceSample{
+			Timestamp:   metrics.Timestamp,
+			CPUUsage:    metrics.CPUUsage,
+			MemoryUsage: metrics.MemoryUsage,
+			Goroutines:  metrics.Goroutines,
+		})
+	})
+	monitor.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(collector.ResourceSamples()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	monitor.Stop()
+
+	samples := collector.ResourceSamples()
+	if len(samples) < 3 {
+		t.Fatalf("expected at least 3 resource samples, got %d", len(samples))
+	}
+	if samples[0].Goroutines == 0 || samples[0].MemoryUsage == 0 {
+		t.Fatalf("unexpected resource sample: %+v", samples[0])
+	}
+
+	start := time.Now()
+	if err := collector.SaveSuccessResult(result.ResultData{
+		Type: result.Success, StartTime: start, EndTime: start.Add(5 * time.Millisecond),
+		ResponseTime: 5 * time.Millisecond, StatusCode: 200, URL: "/", Method: "GET",
+	}); err != nil {
+		t.Fatalf("failed to save result: %v", err)
+	}
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	stats, err := collector.GeneratePerformanceStats(loaded)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if got, _ := stats["ResourceSamples"].([]result.ResourceSample); len(got) != len(samples) {
+		t.Fatalf("stats contain %d resource samples, want %d", len(got), len(samples))
+	}
+	if report := result.GenerateHTMLReport(stats); !strings.Contains(report, "static/resource_chart.html") {
+		t.Fatal("report does not embed the resource chart")
+	}
+
+	chartPath, err := result.GenerateResourceChartAsync(samples, t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to generate resource chart: %v", err)
+	}
+	if info, err := os.Stat(chartPath); err != nil || info.Size() == 0 {
+		t.Fatalf("resource chart not written: %v", err)
+	}
+}
+
+// TestMonitorTracksPoolLifecycle 验证协程池把任务提交、执行、完成、失败和重试上报给 Monitor
+func TestMonitorTracksPoolLifecycle(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	if err := pool.InitLogger(t.TempDir()+"/", "performance_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	logger, _ := pool.GetLogger()
+	monitor := pool.NewMonitor(logger, time.Hour, pool.ResourceThresholds{MaxCPUUsage: 100, MaxMemoryUsage: 1 << 40, MaxGoroutines: 1 << 20})
+	monitor.Start()
+	defer monitor.Stop()
+	taskPool.SetMonitor(monitor)
+
+	for i := 0; i < 3; i++ {
+		if err := taskPool.Submit(func(int32) { time.Sleep(5 * time.Millisecond) }, 0, fmt.Sprintf("ok-%d", i), 0); err != nil {
+			t.Fatalf("failed to submit: %v", err)
+		}
+	}
+	if err := taskPool.Submit(func(int32) { panic("boom") }, 0, "panics", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+
+	// 第一次执行失败，重试一次后成功
+	attempts := 0
+	flaky, err := pool.NewTaskDetail("flaky", func() error {
+		attempts++
+		if attempts == 1 {
+			return fmt.Errorf("transient error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	flaky.RetryDelay = time.Millisecond
+	run, err := taskPool.SubmitDAG("retry", []*pool.TaskDetail{flaky})
+	if err != nil {
+		t.Fatalf("failed to submit DAG: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := run.Wait(ctx); err != nil {
+		t.Fatalf("DAG did not complete: %v", err)
+	}
+
+	var stats pool.TaskStats
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		stats = monitor.GetTaskStats()
+		if stats.CompletedTasks+stats.FailedTasks == 5 && stats.Retries == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats.TotalTasks != 5 || stats.CompletedTasks != 4 || stats.FailedTasks != 1 || stats.Retries != 1 || stats.RunningTasks != 0 {
+		t.Fatalf("unexpected task stats: %+v", stats)
+	}
+	if stats.AverageTime <= 0 {
+		t.Fatalf("expected a positive average execution time, got %v", stats.AverageTime)
+	}
+}
+
+// benchmarkQueuedTasks 在 worker 被占满时提交 n 个随机优先级的任务，再放行并等待全部执行完毕
+func benchmarkQueuedTasks(b *testing.B, n int) {
+	taskPool := newTestPool(b, 1)
+	rng := rand.New(rand.NewSource(1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		release := blockPool(b, taskPool)
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for j := 0; j < n; j++ {
+			err := taskPool.Submit(func(int32) { wg.Done() }, rng.Intn(10), fmt.Sprintf("bench-%d-%d", i, j), 0)
+			if err != nil {
+				b.Fatalf("failed to submit: %v", err)
+			}
+		}
+		release()
+		wg.Wait()
+	}
+	b.ReportMetric(float64(n), "tasks/op")
+}
+
+// BenchmarkPriorityQueue100k 10 万个排队任务的调度性能
+func BenchmarkPriorityQueue100k(b *testing.B) {
+	benchmarkQueuedTasks(b, 100000)
+}
+
+// TestMonitorCountsSubmissionAsItsOwnEvent 验证提交作为独立事件计入总任务数，取消排队任务不会重复计数
+func TestMonitorCountsSubmissionAsItsOwnEvent(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	logger, _ := pool.GetLogger()
+	monitor := pool.NewMonitor(logger, time.Hour, pool.ResourceThresholds{MaxCPUUsage: 100, MaxMemoryUsage: 1 << 40, MaxGoroutines: 1 << 20})
+	monitor.Start()
+	defer monitor.Stop()
+	taskPool.SetMonitor(monitor)
+
+	taskPool.Pause()
+	for i := 0; i < 2; i++ {
+		if err := taskPool.Submit(func(int32) {}, 0, fmt.Sprintf("queued-%d", i), 0); err != nil {
+			t.Fatalf("failed to submit: %v", err)
+		}
+	}
+	taskPool.Shutdown()
+
+	waitFor(t, func() bool { return monitor.GetTaskStats().CancelledTasks == 2 })
+	if stats := monitor.GetTaskStats(); stats.TotalTasks != 2 || stats.RunningTasks != 0 || stats.CompletedTasks != 0 {
+		t.Fatalf("unexpected task stats: %+v", stats)
+	}
+}
+
+// BenchmarkPriorityQueue200k 20 万个排队任务的调度性能
+func BenchmarkPriorityQueue200k(b *testing.B) {
+	benchmarkQueuedTasks(b, 200000)
+}
+
+// TestLatencyViewsExcludeRetriesAndThrottleWait 验证协程池记录的限流等待和重试次数写入结果后，
+// 全量口径与"排除重试/限流等待"口径的百分位不同
+func TestLatencyViewsExcludeRetriesAndThrottleWait(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	taskPool.SetRateLimit(20, 1)
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "latency_views"})
+
+	var mu sync.Mutex
+	var results []result.ResultData
+	record := func(tc *pool.TaskContext, responseTime time.Duration) {
+		start := time.Now()
+		r := result.ResultData{
+			Type: result.Success, StartTime: start, EndTime: start.Add(responseTime), ResponseTime: responseTime,
+			StatusCode: 200, URL: "/orders", Method: "GET",
+		}
+		r.ApplyExecution(tc)
+		mu.Lock()
+		results = append(results, r)
+		mu.Unlock()
+	}
+
+	// 限流：20/s、容量 1，除第一个任务外都需要等待令牌
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		err := taskPool.SubmitWithContext(func(tc *pool.TaskContext) {
+			defer wg.Done()
+			record(tc, 5*time.Millisecond)
+		}, 0, fmt.Sprintf("throttled-%d", i), 0)
+		if err != nil {
+			t.Fatalf("failed to submit: %v", err)
+		}
+	}
+	wg.Wait()
+
+	// 重试：第一次执行超时，第二次成功并记录一个很慢的样本
+	tm, err := pool.NewTimeoutManager(30*time.Millisecond, 3, 0)
+	if err != nil {
+		t.Fatalf("failed to create timeout manager: %v", err)
+	}
+	tm.RetryWithContext(&pool.TaskContext{TraceID: pool.NewTraceID(), VUID: -1}, func(tc *pool.TaskContext) {
+		if tc.RetryCount == 0 {
+			time.Sleep(60 * time.Millisecond)
+			return
+		}
+		record(tc, 500*time.Millisecond)
+	})
+
+	var throttled, retried int
+	for _, r := range results {
+		if r.ThrottleWait > 0 {
+			throttled++
+			if r.ResponseTime < r.ThrottleWait {
+				t.Errorf("response time %v does not include throttle wait %v", r.ResponseTime, r.ThrottleWait)
+			}
+		}
+		if r.RetryCount > 0 {
+			retried++
+		}
+	}
+	if throttled == 0 || retried != 1 {
+		t.Fatalf("expected throttled and retried results, got %d throttled, %d retried", throttled, retried)
+	}
+
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	all := stats["LatencyPercentilesAll"].(map[string]time.Duration)
+	adjusted := stats["LatencyPercentilesAdjusted"].(map[string]time.Duration)
+	if all["P99ResponseTime"] != 500*time.Millisecond {
+		t.Errorf("all view P99: got %v, want the retried sample (500ms)", all["P99ResponseTime"])
+	}
+	if adjusted["P99ResponseTime"] >= 20*time.Millisecond {
+		t.Errorf("adjusted view P99 should exclude the retry and throttle wait, got %v", adjusted["P99ResponseTime"])
+	}
+	if all["P50ResponseTime"] <= adjusted["P50ResponseTime"] {
+		t.Errorf("all view P50 %v should include throttle wait and exceed adjusted P50 %v",
+			all["P50ResponseTime"], adjusted["P50ResponseTime"])
+	}
+	if stats["RetriedCount"] != 1 || stats["TotalThrottleWait"].(time.Duration) <= 0 {
+		t.Errorf("unexpected retry/throttle totals: %v, %v", stats["RetriedCount"], stats["TotalThrottleWait"])
+	}
+
+	// 重试次数和限流等待时间写入 JTL 后可以读回
+	for _, r := range results {
+		if err := collector.SaveSuccessResult(r); err != nil {
+			t.Fatalf("failed to save result: %v", err)
+		}
+	}
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	var loadedRetried, loadedThrottled int
+	for _, r := range loaded {
+		if r.RetryCount > 0 {
+			loadedRetried++
+		}
+		if r.ThrottleWait > 0 {
+			loadedThrottled++
+		}
+	}
+	if loadedRetried != 1 || loadedThrottled == 0 {
+		t.Fatalf("retry/throttle columns not round-tripped: %d retried, %d throttled", loadedRetried, loadedThrottled)
+	}
+}
+
+// TestThrottledTasksDoNotHoldWorkers 验证限流发生在占用 worker 之前：吞吐量受限于速率，
+// 等待令牌的任务不占用 worker，虚拟用户仍可预留全部 worker
+func TestThrottledTasksDoNotHoldWorkers(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	taskPool.SetRateLimit(20, 1)
+
+	const total = 10
+	var done int32
+	start := time.Now()
+	for i := 0; i < total; i++ {
+		err := taskPool.Submit(func(int32) { atomic.AddInt32(&done, 1) }, 0, fmt.Sprintf("rate-%d", i), 0)
+		if err != nil {
+			t.Fatalf("failed to submit: %v", err)
+		}
+	}
+
+	// 限流期间 worker 空闲，可以整体预留给虚拟用户
+	time.Sleep(50 * time.Millisecond)
+	if running := taskPool.Stats().RunningWorkers; running > 1 {
+		t.Errorf("throttled tasks hold %d workers", running)
+	}
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 3, Duration: 50 * time.Millisecond}, func(*pool.VUContext) {
+		time.Sleep(10 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("workers should be free while tasks wait for tokens: %v", err)
+	}
+	run.Wait()
+
+	waitFor(t, func() bool { return atomic.LoadInt32(&done) == total })
+	// 20/s、容量 1：10 个任务至少需要 9 个令牌间隔
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("%d tasks finished in %v, faster than the rate limit allows", total, elapsed)
+	}
+}
@@ -0,0 +1,204 @@
+// phases_test.go
+// 请求阶段耗时测试模块
+// 本文件负责测试 HTTP 请求各阶段耗时的采集、JTL 中 Latency/Connect 与阶段耗时列的读写，
+// 以及报告中的阶段耗时图和按 TLS 版本、加密套件汇总的 TLS 连接统计。
+
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// fixedPhases 固定的阶段耗时
+type fixedPhases struct{ dns, connect, tls, ttfb time.Duration }
+
+func (f fixedPhases) ResultPhases() (time.Duration, time.Duration, time.Duration, time.Duration) {
+	return f.dns, f.connect, f.tls, f.ttfb
+}
+
+// tlsPhases 固定的阶段耗时和 TLS 信息
+type tlsPhases struct {
+	fixedPhases
+	version, cipher string
+}
+
+func (p tlsPhases) ResultTLS() (string, string) {
+	return p.version, p.cipher
+}
+
+func TestDoTimedCapturesPhases(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport, err := tasks.NewTransport(tasks.TransportConfig{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	task := &tasks.Task{ID: "timed", Client: &http.Client{Transport: transport}}
+
+	get := func() tasks.PhaseTimings {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, phases, err := task.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return phases
+	}
+
+	// 新连接：有 TCP 连接和 TLS 握手，地址是 IP 没有 DNS 解析
+	first := get()
+	if first.ConnReused || first.TCPConnect <= 0 || first.TLSHandshake <= 0 || first.DNSLookup != 0 {
+		t.Errorf("unexpected phases for a new connection: %+v", first)
+	}
+	if first.TLSVersion == "" || first.TLSCipher == "" {
+		t.Errorf("tls version and cipher should be recorded: %+v", first)
+	}
+	if first.TTFB < 20*time.Millisecond || first.TTFB < first.TCPConnect+first.TLSHandshake {
+		t.Errorf("ttfb should include the server delay and connection setup: %+v", first)
+	}
+
+	// 复用连接：没有建连阶段
+	second := get()
+	if !second.ConnReused || second.TCPConnect != 0 || second.TLSHandshake != 0 || second.TLSVersion != first.TLSVersion {
+		t.Errorf("unexpected phases for a reused connection: %+v", second)
+	}
+	if second.TTFB < 20*time.Millisecond {
+		t.Errorf("ttfb should include the server delay: %+v", second)
+	}
+}
+
+func TestPhaseTimingsInJTLAndReport(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		r := result.ResultData{
+			Type:         result.Success,
+			StatusCode:   200,
+			Method:       "GET",
+			URL:          "https://example.com",
+			StartTime:    start.Add(time.Duration(i) * 100 * time.Millisecond),
+			ResponseTime: 100 * time.Millisecond,
+		}
+		r.EndTime = r.StartTime.Add(r.ResponseTime)
+		r.ApplyPhases(fixedPhases{dns: 5 * time.Millisecond, connect: 10 * time.Millisecond, tls: 15 * time.Millisecond, ttfb: 60 * time.Millisecond})
+		collector.SaveSuccessResult(r)
+	}
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	got := results[0]
+	if got.Connect != 30 || got.TTFB != 60*time.Millisecond || got.DNSLookup != 5*time.Millisecond ||
+		got.TCPConnect != 10*time.Millisecond || got.TLSHandshake != 15*time.Millisecond {
+		t.Errorf("phase timings not kept in the JTL file: %+v", got)
+	}
+
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	breakdown, ok := stats["PhaseBreakdown"].([]result.PhaseSample)
+	if !ok || len(breakdown) == 0 {
+		t.Fatalf("expected a phase breakdown, got %v", stats["PhaseBreakdown"])
+	}
+	var requests int
+	for _, sample := range breakdown {
+		requests += sample.Requests
+		if sample.ServerWait != 30*time.Millisecond || sample.Download != 40*time.Millisecond {
+			t.Errorf("unexpected phase averages: %+v", sample)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("expected 4 timed requests in the breakdown, got %d", requests)
+	}
+
+	path, err := collector.SaveReportToFile(stats, "phases")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "static/phase_chart.html") {
+		t.Error("report should include the phase chart")
+	}
+	waitFor(t, func() bool {
+		_, err := os.Stat(filepath.Join(filepath.Dir(path), "static", "phase_chart.html"))
+		return err == nil
+	})
+
+	// 没有采集阶段耗时的结果不生成阶段耗时图
+	stats, err = collector.GeneratePerformanceStats(thresholdTestResults())
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if _, ok := stats["PhaseBreakdown"]; ok {
+		t.Error("results without phase timings should not produce a phase breakdown")
+	}
+	if strings.Contains(result.GenerateHTMLReport(stats), "phase_chart") {
+		t.Error("report without phase timings should not include the phase chart")
+	}
+}
+
+func TestTLSStatsInJTLAndReport(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	start := time.Now()
+	for i, phases := range []result.PhaseTimer{
+		tlsPhases{fixedPhases{tls: 10 * time.Millisecond, ttfb: 30 * time.Millisecond}, "TLS 1.3", "TLS_AES_128_GCM_SHA256"},
+		tlsPhases{fixedPhases{tls: 30 * time.Millisecond, ttfb: 50 * time.Millisecond}, "TLS 1.3", "TLS_AES_128_GCM_SHA256"},
+		tlsPhases{fixedPhases{ttfb: 20 * time.Millisecond}, "TLS 1.3", "TLS_AES_128_GCM_SHA256"}, // 复用连接
+		tlsPhases{fixedPhases{tls: 40 * time.Millisecond, ttfb: 60 * time.Millisecond}, "TLS 1.2", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		fixedPhases{ttfb: 20 * time.Millisecond}, // 非 HTTPS 请求
+	} {
+		r := result.ResultData{Type: result.Success, StatusCode: 200, StartTime: start.Add(time.Duration(i) * time.Millisecond), ResponseTime: 80 * time.Millisecond}
+		r.EndTime = r.StartTime.Add(r.ResponseTime)
+		r.ApplyPhases(phases)
+		collector.SaveSuccessResult(r)
+	}
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if results[0].TLSVersion != "TLS 1.3" || results[0].TLSCipher != "TLS_AES_128_GCM_SHA256" || results[4].TLSVersion != "" {
+		t.Errorf("tls version and cipher not kept in the JTL file: %+v", results[0])
+	}
+
+	stats, err := collector.AnalyzeFile("")
+	if err != nil {
+		t.Fatalf("failed to analyze results: %v", err)
+	}
+	tlsStats, ok := stats["TLSStats"].([]result.TLSStat)
+	if !ok || len(tlsStats) != 2 {
+		t.Fatalf("expected stats for two tls versions, got %v", stats["TLSStats"])
+	}
+	if got := tlsStats[0]; got.Version != "TLS 1.3" || got.Requests != 3 || got.Handshakes != 2 || got.AvgHandshake != 20*time.Millisecond || got.MaxHandshake != 30*time.Millisecond {
+		t.Errorf("unexpected tls stats: %+v", got)
+	}
+	if !strings.Contains(result.GenerateMarkdownReport(stats), "| TLS 1.2 | TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 | 1 | 1 | 40.00 ms |") {
+		t.Error("markdown report should include the tls stats")
+	}
+	if !strings.Contains(result.GenerateHTMLReport(stats), "<h2>TLS 连接</h2>") {
+		t.Error("html report should include the tls stats")
+	}
+
+	if stats := lockTestStats(t, collector); stats["TLSStats"] != nil {
+		t.Error("results without tls information should not produce tls stats")
+	}
+}
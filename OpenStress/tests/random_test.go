@@ -0,0 +1,84 @@
+// random_test.go
+// 随机种子测试模块
+// 本文件负责测试全局随机种子：相同种子下派生的随机序列和 VU 的随机行为可以复现，以及种子写入报告。
+
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/random"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestRandomSeedIsReproducible(t *testing.T) {
+	defer random.SetSeed(0)
+
+	random.SetSeed(42)
+	if random.Seed() != 42 {
+		t.Fatalf("expected seed 42, got %d", random.Seed())
+	}
+	a, b := random.New("feeder"), random.New("feeder")
+	for i := 0; i < 10; i++ {
+		if x, y := a.Int63(), b.Int63(); x != y {
+			t.Fatalf("same name should give the same sequence, got %d and %d", x, y)
+		}
+	}
+	if random.New("feeder").Int63() == random.New("redis").Int63() {
+		t.Error("different names should give different sequences")
+	}
+	random.SetSeed(0)
+	if random.Seed() == 0 {
+		t.Error("seed 0 should be replaced by a generated seed")
+	}
+
+	// 每个 VU 的随机序列只取决于种子和 VU 编号
+	taskPool := newTestPool(t, 2)
+	draws := func() map[int][]int {
+		var mu sync.Mutex
+		got := map[int][]int{}
+		_, err := taskPool.RunVUs(pool.VUConfig{VUs: 2, IterationsPerVU: 5}, func(vu *pool.VUContext) {
+			n := vu.Rand.Intn(1000)
+			vu.ThinkTime(0, time.Millisecond)
+			mu.Lock()
+			got[vu.VUID] = append(got[vu.VUID], n)
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatalf("failed to run vus: %v", err)
+		}
+		return got
+	}
+	random.SetSeed(7)
+	first := draws()
+	random.SetSeed(7)
+	second := draws()
+	if len(first[0]) != 5 || !reflect.DeepEqual(first, second) {
+		t.Errorf("vu draws differ between runs: %v vs %v", first, second)
+	}
+	if reflect.DeepEqual(first[0], first[1]) {
+		t.Error("vus should have independent sequences")
+	}
+
+	// 种子写入报告
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	collector.SaveSuccessResult(result.ResultData{ID: "r", StartTime: time.Now(), EndTime: time.Now(), ResponseTime: time.Millisecond})
+	stats, err := collector.CurrentStats(collector.Results())
+	if err != nil {
+		t.Fatalf("failed to compute stats: %v", err)
+	}
+	if stats["Seed"] != int64(7) {
+		t.Errorf("expected seed 7 in stats, got %v", stats["Seed"])
+	}
+	if md := result.GenerateMarkdownReport(stats); !strings.Contains(md, "随机种子: `7`") {
+		t.Errorf("markdown report should include the seed:\n%s", md)
+	}
+	if html := result.GenerateHTMLReport(stats); !strings.Contains(html, "<th>随机种子</th><td>7</td>") {
+		t.Error("html report should include the seed")
+	}
+}
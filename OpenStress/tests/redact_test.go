@@ -0,0 +1,152 @@
+// redact_test.go
+// 敏感信息隐藏测试模块
+// 本文件负责测试自由文本中敏感字段值的隐藏、自定义敏感词，以及日志和结果文件、报告中不出现敏感信息；
+// 同时测试结果脱敏（去掉查询参数、替换用户标识、丢弃响应消息）。
+
+package tests
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/redact"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestRedactString(t *testing.T) {
+	for input, want := range map[string]string{
+		"Authorization: Bearer eyJhbGciOi.abc":           "Authorization: Bearer ***",
+		`{"user":"bob","password":"p@ss word"}`:          `{"user":"bob","password":"***"}`,
+		"GET /orders?api_key=123&page=2":                 "GET /orders?api_key=***&page=2",
+		"config: {Addr:redis:6379 Password:secret DB:0}": "config: {Addr:redis:6379 Password:*** DB:0}",
+		"X-Auth-Token: 'abc'":                            "X-Auth-Token: '***'",
+		"Cookie: session=abc; theme=dark\nnext line":     "Cookie: ***\nnext line",
+		"token expired, please login again":              "token expired, please login again",
+		"request failed with status 500":                 "request failed with status 500",
+	} {
+		if got := redact.String(input); got != want {
+			t.Errorf("%q: expected %q, got %q", input, want, got)
+		}
+	}
+	if !redact.IsSensitive("X-API-Key") || redact.IsSensitive("task_id") {
+		t.Error("unexpected sensitive field detection")
+	}
+}
+
+func TestRedactCustomPatterns(t *testing.T) {
+	defer redact.SetPatterns(redact.DefaultPatterns)
+	redact.SetPatterns(append(append([]string(nil), redact.DefaultPatterns...), " X-Session ", ""))
+
+	if got := redact.String("x-session-id=42 user=bob"); got != "x-session-id=*** user=bob" {
+		t.Errorf("custom pattern not applied: %q", got)
+	}
+	if got := redact.Patterns(); len(got) != len(redact.DefaultPatterns)+1 || got[len(got)-1] != "x-session" {
+		t.Errorf("unexpected patterns: %q", got)
+	}
+
+	redact.SetPatterns(nil)
+	if got := redact.String("password=secret"); got != "password=secret" {
+		t.Errorf("nothing should be redacted without patterns: %q", got)
+	}
+}
+
+func TestRedactLogsAndResults(t *testing.T) {
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{FailureBodyBytes: 24})
+	logger, err := pool.GetModuleLogger("RedactTest")
+	if err != nil {
+		t.Fatalf("failed to get logger: %v", err)
+	}
+
+	// 日志：订阅者和日志文件中都不包含令牌
+	events, unsubscribe, err := logger.Subscribe("INFO", 4)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer unsubscribe()
+	logger.Log("INFO", "calling upstream with Authorization: Bearer log-secret-token")
+	select {
+	case event := <-events:
+		if strings.Contains(event.Message, "log-secret-token") || !strings.Contains(event.Message, "Bearer ***") {
+			t.Errorf("log message not redacted: %q", event.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no log event before timeout")
+	}
+
+	// 结果：JTL 文件和报告中都不包含 URL 中的 API Key 和响应体中的密码
+	now := time.Now()
+	collector.SaveFailureResult(result.ResultData{ID: "r", Type: result.Failure, URL: "http://svc/login?api_key=url-secret", StatusCode: 401,
+		ErrorMessage: "request failed with status 401", ResponseBody: `{"password":"body-secret-value"}`,
+		StartTime: now, EndTime: now.Add(time.Millisecond), ResponseTime: time.Millisecond})
+	results, err := collector.LoadResultsFromFile()
+	if err != nil || len(results) != 1 {
+		t.Fatalf("failed to load results: %v (%d results)", err, len(results))
+	}
+	if results[0].URL != "http://svc/login?api_key=***" || results[0].ResponseBody != `{"password":"***"}` {
+		t.Errorf("stored result not redacted: %+v", results[0])
+	}
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	path, err := collector.SaveReportToFile(stats, "redact")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, secret := range []string{"url-secret", "body-secret-value"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("report in %s should not contain %q", reportDir, secret)
+		}
+	}
+}
+
+func TestScrubResults(t *testing.T) {
+	if _, err := result.NewCollector(result.CollectorConfig{JTLFilePath: t.TempDir() + "/scrub.jtl", Scrub: config.ScrubConfig{MaskIdentifiers: true, Identifiers: []string{"("}}}); err == nil {
+		t.Error("expected an error for an invalid identifier pattern")
+	}
+
+	scrub := config.ScrubConfig{StripQuery: true, MaskIdentifiers: true, DropMessages: true, Identifiers: []string{`acct-[0-9]+`}}
+	collector, _ := newTestCollector(t, result.CollectorConfig{FailureBodyBytes: 64, Scrub: scrub})
+	now := time.Now()
+	// 结果在写入 JTL 文件和保存到内存之前脱敏，这里检查内存中的结果（JTL 不保存响应消息）
+	collector.SaveSuccessResult(result.ResultData{ID: "ok", Type: result.Success, StatusCode: 200, ResponseMsg: "welcome alice@example.com",
+		URL: "http://svc:8080/users/12345/orders/678?email=alice@example.com#top", StartTime: now, EndTime: now.Add(time.Millisecond)})
+	collector.SaveFailureResult(result.ResultData{ID: "fail", Type: result.Failure, StatusCode: 404, ResponseBody: `{"user":"bob@example.com"}`,
+		URL:          "http://svc/sessions/0b6c7a4e-1d2f-4a3b-9c8d-7e6f5a4b3c2d/v2",
+		ErrorMessage: "status 404: no order for bob@example.com in acct-991",
+		StartTime:    now, EndTime: now.Add(time.Millisecond), ResponseTime: time.Millisecond})
+
+	results := collector.Results()
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if got := results[0]; got.URL != "http://svc:8080/users/{id}/orders/{id}" || got.ResponseMsg != "" {
+		t.Errorf("success result not scrubbed: %+v", got)
+	}
+	if got := results[1]; got.URL != "http://svc/sessions/{uuid}/v2" || got.ResponseBody != "" || got.ErrorMessage != "status 404: no order for {email} in {id}" {
+		t.Errorf("failure result not scrubbed: %+v", got)
+	}
+	if stored, err := collector.LoadResultsFromFile(); err != nil || len(stored) != 2 || stored[1].URL != "http://svc/sessions/{uuid}/v2" || stored[1].ResponseBody != "" {
+		t.Errorf("JTL file not scrubbed: %v %+v", err, stored)
+	}
+
+	// 只替换用户标识时保留查询参数和响应消息，查询参数中的邮箱同样被替换
+	collector, _ = newTestCollector(t, result.CollectorConfig{Scrub: config.ScrubConfig{MaskIdentifiers: true}})
+	collector.SaveSuccessResult(result.ResultData{ID: "q", Type: result.Success, StatusCode: 200, ResponseMsg: "OK",
+		URL: "/users/42?page=2&email=carol@example.com", StartTime: now, EndTime: now.Add(time.Millisecond)})
+	results = collector.Results()
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if got := results[0]; got.URL != "/users/{id}?page=2&email={email}" || got.ResponseMsg != "OK" {
+		t.Errorf("unexpected scrubbed result: %+v", got)
+	}
+}
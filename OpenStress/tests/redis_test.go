@@ -0,0 +1,232 @@
+// redis_test.go
+// Redis 压测任务测试模块
+// 本文件使用一个精简的内存 RESP 服务测试 Redis 任务：命令组合、键空间与值大小、
+// GET 未命中、管道，以及按命令类型记录的延迟和错误。
+
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// startRedisServer 启动支持 PING/SELECT/GET/SET/INCR 的内存 RESP 服务，返回地址和数据
+func startRedisServer(t *testing.T) (string, func() map[string]string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+	handle := func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			return "+PONG\r\n"
+		case "SELECT":
+			return "+OK\r\n"
+		case "GET":
+			value, ok := data[args[1]]
+			if !ok {
+				return "$-1\r\n"
+			}
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+		case "SET":
+			data[args[1]] = args[2]
+			return "+OK\r\n"
+		case "INCR":
+			n, err := strconv.ParseInt(data[args[1]], 10, 64)
+			if err != nil && data[args[1]] != "" {
+				return "-ERR value is not an integer or out of range\r\n"
+			}
+			data[args[1]] = strconv.FormatInt(n+1, 10)
+			return fmt.Sprintf(":%d\r\n", n+1)
+		}
+		return "-ERR unknown command\r\n"
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readRESPCommand(r)
+					if err != nil {
+						return
+					}
+					conn.Write([]byte(handle(args)))
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		snapshot := make(map[string]string, len(data))
+		for k, v := range data {
+			snapshot[k] = v
+		}
+		return snapshot
+	}
+}
+
+// readRESPCommand 读取一条 RESP 数组格式的命令
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisTaskCommandsAndKeySpace(t *testing.T) {
+	addr, snapshot := startRedisServer(t)
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	task, err := tasks.NewRedisTask(tasks.RedisConfig{
+		Addr:         addr,
+		KeyPattern:   "user:{n}:profile",
+		KeySpace:     4,
+		KeySelection: tasks.KeySequential,
+		ValueSize:    10,
+		ValueSizeMax: 20,
+		Commands: []tasks.RedisCommand{
+			{Command: "set", Weight: 3},
+			{Command: "get", Weight: 1},
+			{Command: "pipeline", PipelineCommand: "get", PipelineSize: 6},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create redis task: %v", err)
+	}
+	defer task.Close()
+	task.Collector = collector
+	ctx := context.Background()
+
+	// 空库 GET 未命中不算错误
+	miss, err := task.Execute(ctx, tasks.RedisCommand{Command: tasks.RedisGet}, 1)
+	if err != nil || miss.ResponseMsg != "miss" || miss.ID != "GET user:0:profile" {
+		t.Errorf("expected a miss, got %+v, %v", miss, err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := task.Execute(ctx, tasks.RedisCommand{Command: tasks.RedisSet}, 1); err != nil {
+			t.Fatalf("set failed: %v", err)
+		}
+	}
+	data := snapshot()
+	if len(data) != 4 {
+		t.Fatalf("expected 4 keys, got %v", data)
+	}
+	for key, value := range data {
+		if !strings.HasPrefix(key, "user:") || len(value) < 10 || len(value) > 20 {
+			t.Errorf("unexpected key or value size: %s=%q", key, value)
+		}
+	}
+
+	pipe, err := task.Execute(ctx, tasks.RedisCommand{Command: tasks.RedisPipeline, PipelineCommand: tasks.RedisGet, PipelineSize: 6}, 1)
+	if err != nil || pipe.Method != "PIPELINE" || pipe.DataReceived < 60 || pipe.ResponseMsg != "" {
+		t.Errorf("unexpected pipeline result: %+v, %v", pipe, err)
+	}
+
+	// 按权重混合执行
+	for i := 0; i < 50; i++ {
+		if err := task.Run(ctx, 2); err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+	}
+	stats := task.Stats()
+	if stats["SET"].Count < 4 || stats["GET"].Count < 1 || stats["PIPELINE"].Count < 1 {
+		t.Errorf("expected every command in the mix, got %+v", stats)
+	}
+	total := 0
+	for command, s := range stats {
+		total += s.Count
+		if s.Errors != 0 || s.AvgLatency() <= 0 || s.MaxLatency < s.AvgLatency() {
+			t.Errorf("unexpected %s stats: %+v", command, s)
+		}
+	}
+	if total != 56 || len(collector.Results()) != 56 {
+		t.Errorf("expected 56 commands, got %d stats and %d results", total, len(collector.Results()))
+	}
+}
+
+func TestRedisTaskErrors(t *testing.T) {
+	addr, _ := startRedisServer(t)
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	task, err := tasks.NewRedisTask(tasks.RedisConfig{Addr: addr, KeySpace: 1})
+	if err != nil {
+		t.Fatalf("failed to create redis task: %v", err)
+	}
+	defer task.Close()
+	task.Collector = collector
+	ctx := context.Background()
+
+	// 对字符串值执行 INCR 失败
+	task.Execute(ctx, tasks.RedisCommand{Command: tasks.RedisSet}, 1)
+	if _, err := task.Execute(ctx, tasks.RedisCommand{Command: tasks.RedisIncr}, 1); err == nil || !strings.Contains(err.Error(), "not an integer") {
+		t.Errorf("expected an incr error, got %v", err)
+	}
+	if _, err := task.Execute(ctx, tasks.RedisCommand{Command: tasks.RedisPipeline, PipelineCommand: tasks.RedisIncr, PipelineSize: 3}, 1); err == nil || !strings.Contains(err.Error(), "PIPELINE INCR x3") {
+		t.Errorf("expected a pipeline error, got %v", err)
+	}
+	if stats := task.Stats(); stats["INCR"].Errors != 1 || stats["PIPELINE"].Errors != 1 || stats["SET"].Errors != 0 {
+		t.Errorf("errors should be counted per command: %+v", stats)
+	}
+	var failures int
+	for _, r := range collector.Results() {
+		if r.Type == result.Failure {
+			failures++
+		}
+	}
+	if failures != 2 {
+		t.Errorf("expected 2 failed results, got %d", failures)
+	}
+
+	for name, cfg := range map[string]tasks.RedisConfig{
+		"addr":      {},
+		"command":   {Addr: addr, Commands: []tasks.RedisCommand{{Command: "DEL"}}},
+		"weight":    {Addr: addr, Commands: []tasks.RedisCommand{{Command: "GET", Weight: -1}}},
+		"pipeline":  {Addr: addr, Commands: []tasks.RedisCommand{{Command: "PIPELINE", PipelineCommand: "PIPELINE"}}},
+		"pattern":   {Addr: addr, KeyPattern: "static"},
+		"selection": {Addr: addr, KeySelection: "zipf"},
+	} {
+		if _, err := tasks.NewRedisTask(cfg); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
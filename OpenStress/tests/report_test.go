@@ -0,0 +1,301 @@
+// report_test.go
+// HTML 报告测试模块
+// 本文件负责测试 HTML 报告的输出形式：单文件自包含报告不引用任何外部文件，图表直接嵌入页面；
+// 默认报告随 static 目录写出 echarts 脚本，离线也能显示图表；报告文本按配置的语言（zh-CN/en-US）输出；
+// 报告目录和文件按命名规则生成，自定义 html/template 模板可以替换页面结构。
+
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestSelfContainedReportIsSingleFile(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	stats := lockTestStats(t, collector)
+
+	path, err := collector.SaveReportToFile(stats, "single")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("failed to read report directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("self-contained report should be a single file, got %d entries", len(entries))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	content := string(data)
+	for _, unwanted := range []string{"static/", "src='http", "src=\"http", "<iframe", "<link rel='stylesheet'"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("self-contained report should not reference external files, found %q", unwanted)
+		}
+	}
+	// 内联的 echarts 库、报告样式以及每张图表的初始化脚本
+	for _, want := range []string{"Licensed to the Apache Software Foundation", ".concept-card", "每秒事务数 (TPS)", "响应时间趋势 (ms)", "网络流量趋势 (byte)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("self-contained report is missing %q", want)
+		}
+	}
+	if n := strings.Count(content, "echarts.init("); n != 3 {
+		t.Errorf("expected 3 inline charts, got %d", n)
+	}
+}
+
+func TestSelfContainedReportIncludesResourceChart(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	stats := lockTestStats(t, collector)
+	start := time.Now()
+	stats["ResourceSamples"] = []result.ResourceSample{
+		{Timestamp: start, CPUUsage: 35, MemoryUsage: 64 << 20, Goroutines: 12},
+		{Timestamp: start.Add(time.Second), CPUUsage: 97, MemoryUsage: 70 << 20, Goroutines: 40},
+	}
+
+	content := result.GenerateSelfContainedHTMLReport(stats, "resources")
+	if !strings.Contains(content, "goroutine 数量") {
+		t.Error("resource chart should be embedded when samples exist")
+	}
+	if n := strings.Count(content, "echarts.init("); n != 4 {
+		t.Errorf("expected 4 inline charts, got %d", n)
+	}
+}
+
+func TestDefaultReportReferencesStaticDirectory(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	stats := lockTestStats(t, collector)
+
+	path, err := collector.SaveReportToFile(stats, "multi")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !strings.Contains(string(data), "static/tps_chart.html") {
+		t.Error("default report should embed chart pages from the static directory")
+	}
+	if strings.Contains(string(data), "src='http") {
+		t.Error("default report should not load anything from the network")
+	}
+	staticDir := filepath.Join(filepath.Dir(path), "static")
+	for _, name := range []string{"styles.css", "script.js", "tps_chart.html", "assets/echarts.min.js"} {
+		if _, err := os.Stat(filepath.Join(staticDir, name)); err != nil {
+			t.Errorf("static/%s was not written: %v", name, err)
+		}
+	}
+
+	// 图表页面从随报告写出的 assets 目录加载 echarts，离线也能显示
+	chartPage, err := os.ReadFile(filepath.Join(staticDir, "tps_chart.html"))
+	if err != nil {
+		t.Fatalf("failed to read chart page: %v", err)
+	}
+	if !strings.Contains(string(chartPage), `src="assets/echarts.min.js"`) || strings.Contains(string(chartPage), "https://") {
+		t.Errorf("chart page should load echarts from the local assets directory:\n%s", chartPage)
+	}
+}
+
+func TestReportLanguageEnglish(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{Language: "en-US"})
+	results := thresholdTestResults()
+	stats := lockTestStats(t, collector)
+
+	path, err := collector.SaveReportToFile(stats, "english")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"<html lang='en'>", "Test Statistics", "Response Time Percentiles", "TPS Trend", "Concepts", "The success rate reached", "Default view: All samples"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("english report is missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"测试统计数据", "参考概念", "本次测试", "全部样本"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("english report contains chinese text %q", unwanted)
+		}
+	}
+
+	chartPage, err := os.ReadFile(filepath.Join(filepath.Dir(path), "static", "tps_chart.html"))
+	if err != nil {
+		t.Fatalf("failed to read chart page: %v", err)
+	}
+	if !strings.Contains(string(chartPage), "Transactions Per Second") {
+		t.Error("chart titles should follow the report language")
+	}
+
+	summary := collector.GenerateSummaryReport(results)
+	if !strings.HasPrefix(summary, "Test Report:") || !strings.Contains(summary, "Total Requests: 10") {
+		t.Errorf("unexpected english summary:\n%s", summary)
+	}
+}
+
+func TestReportLanguageDefaultsToChinese(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	stats := lockTestStats(t, collector)
+
+	content := result.GenerateHTMLReport(stats)
+	for _, want := range []string{"<html lang='zh'>", "测试统计数据", "参考概念", "本次测试的请求成功率"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("default report is missing %q", want)
+		}
+	}
+	if summary := collector.GenerateSummaryReport(thresholdTestResults()); !strings.Contains(summary, "总请求数: 10") {
+		t.Errorf("unexpected default summary:\n%s", summary)
+	}
+
+	// 直接调用 GenerateHTMLReport 时可以通过 stats["Language"] 切换语言
+	stats["Language"] = result.LanguageEnUS
+	if content := result.GenerateHTMLReport(stats); !strings.Contains(content, "Test Statistics") {
+		t.Error("stats[\"Language\"] should switch the report language")
+	}
+}
+
+func TestReportNamePattern(t *testing.T) {
+	collector, reportDir := newTestCollector(t, result.CollectorConfig{TaskID: "report", ReportNamePattern: "{task}-{name}-{time}", SelfContainedReport: true})
+	path, err := collector.SaveReportToFile(lockTestStats(t, collector), "nightly")
+	if err != nil {
+		t.Fatalf("failed to save report: %v", err)
+	}
+	dir := filepath.Base(filepath.Dir(path))
+	if !strings.HasPrefix(dir, "report-nightly-") || filepath.Base(path) != dir+".html" || filepath.Dir(filepath.Dir(path)) != reportDir {
+		t.Errorf("report should be named by the pattern, got %s", path)
+	}
+
+	_, err = result.NewCollector(result.CollectorConfig{JTLFilePath: filepath.Join(t.TempDir(), "report.jtl"), TaskID: "report", ReportNamePattern: "{name}/{time}"})
+	if err == nil || !strings.Contains(err.Error(), "path separators") {
+		t.Errorf("expected a report name pattern error, got %v", err)
+	}
+}
+
+func TestCustomReportTemplate(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "report.tmpl")
+	custom := `<!DOCTYPE html><html><head>{{template "head" .}}</head><body><h1>ACME {{.Metadata.TestPlan}}</h1>` +
+		`<p id='total'>{{index .Stats "TotalRequests"}}</p><p id='avg'>{{ms (index .Stats "AvgResponseTime")}}</p>{{template "body" .}}</body></html>`
+	if err := os.WriteFile(templatePath, []byte(custom), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	for _, selfContained := range []bool{false, true} {
+		collector, _ := newTestCollector(t, result.CollectorConfig{ReportTemplate: templatePath, TestPlan: "<checkout>", SelfContainedReport: selfContained})
+		stats := lockTestStats(t, collector)
+		path, err := collector.SaveReportToFile(stats, "custom")
+		if err != nil {
+			t.Fatalf("failed to save report: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read report: %v", err)
+		}
+		page := string(data)
+		if !strings.Contains(page, "<h1>ACME &lt;checkout&gt;</h1>") || !strings.Contains(page, "<p id='total'>"+strconv.Itoa(stats["TotalRequests"].(int))+"</p>") {
+			t.Errorf("custom template should be used with escaped values (self-contained %v)", selfContained)
+		}
+		if !strings.Contains(page, "<p id='avg'>") || !strings.Contains(page, " ms</p>") || !strings.Contains(page, "<h2>测试概览</h2>") {
+			t.Errorf("custom template should render helpers and the built-in report body (self-contained %v)", selfContained)
+		}
+	}
+
+	// 默认模板保持原有的页面结构
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	page := result.GenerateHTMLReport(lockTestStats(t, collector), "default")
+	if !strings.HasPrefix(page, "<!DOCTYPE html>\n<html lang='zh'>\n<head>\n<meta charset='UTF-8'>") || !strings.Contains(page, "<script src='static/script.js'></script>\n</body>\n</html>") {
+		t.Errorf("unexpected default report structure: %.120s", page)
+	}
+
+	if err := os.WriteFile(templatePath, []byte("{{template \"body\" ."), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	_, err := result.NewCollector(result.CollectorConfig{JTLFilePath: filepath.Join(t.TempDir(), "report.jtl"), TaskID: "report", ReportTemplate: templatePath})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse report template") {
+		t.Errorf("expected a template parse error, got %v", err)
+	}
+}
+
+func TestHTMLReportWithMissingStats(t *testing.T) {
+	// 手工构造的统计结果缺少大部分指标时报告仍能生成，缺少的可选部分不展示
+	for _, stats := range []map[string]interface{}{
+		{},
+		{"TotalRequests": 3, "SuccessRate": 50.0, "TopErrors": []result.ErrorSummary{{Count: 1, Error: "<boom>"}}},
+	} {
+		page := result.GenerateHTMLReport(stats, "partial <run>")
+		if !strings.Contains(page, "<h1>partial &lt;run&gt;</h1>") || !strings.Contains(page, "<th>AvgResponseTime</th><td>-</td>") {
+			t.Errorf("report should render with placeholders for missing stats: %v", stats)
+		}
+		for _, section := range []string{"测试已提前中止", "响应时间百分位", "失败响应示例", "<th>StopReason</th>", "<th>随机种子</th>"} {
+			if strings.Contains(page, section) {
+				t.Errorf("report should omit %q when its stats are missing", section)
+			}
+		}
+	}
+	page := result.GenerateHTMLReport(map[string]interface{}{"SuccessRate": 50.0})
+	if !strings.Contains(page, "<th>SuccessRate</th><td class='error'>50.000%</td>") {
+		t.Errorf("success rate below the standard should be highlighted")
+	}
+}
+
+func TestReportLanguageRejectsUnknown(t *testing.T) {
+	if lang, err := result.ParseLanguage("EN_us"); err != nil || lang != result.LanguageEnUS {
+		t.Errorf("expected en-US, got %q (%v)", lang, err)
+	}
+	_, err := result.NewCollector(result.CollectorConfig{JTLFilePath: filepath.Join(t.TempDir(), "report.jtl"), TaskID: "report", Language: "fr-FR"})
+	if err == nil || !strings.Contains(err.Error(), "fr-FR") {
+		t.Errorf("expected an unsupported language error, got %v", err)
+	}
+}
+
+func TestGenerateMarkdownReport(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{Thresholds: []string{"max <= 100ms", "error_rate < 5%"}})
+	results := thresholdTestResults()
+	start := results[len(results)-1].EndTime
+	for i, message := range []string{"timeout", "timeout", "bad | gateway\nupstream"} {
+		begin := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		results = append(results, result.ResultData{
+			Type: result.Failure, StatusCode: 502, ErrorMessage: message,
+			StartTime: begin, EndTime: begin.Add(time.Millisecond), ResponseTime: time.Millisecond,
+		})
+	}
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	collector.EvaluateThresholds(stats)
+	stats["Language"] = result.LanguageEnUS
+
+	report := result.GenerateMarkdownReport(stats, "PR #42")
+	for _, want := range []string{
+		"## PR #42",
+		"| Total Requests | 13 |",
+		"| P95 |",
+		"**1 of 2 thresholds failed**",
+		"| ✅ | max <= 100ms |",
+		"| ❌ | error_rate < 5% |",
+		"| 2 | 502 | timeout |",
+		"| 1 | 502 | bad \\| gateway upstream |",
+		"| 1 | 500 | - |",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("markdown report is missing %q:\n%s", want, report)
+		}
+	}
+	// 主要错误按次数从多到少排列
+	if strings.Index(report, "| 2 | 502 | timeout |") > strings.Index(report, "| 1 | 500 | - |") {
+		t.Errorf("top errors should be sorted by count:\n%s", report)
+	}
+}
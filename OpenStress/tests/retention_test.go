@@ -0,0 +1,63 @@
+// retention_test.go
+// 结果保留策略测试模块
+// 本文件负责测试内存中结果的保留策略：last 只保留最近的结果，aggregates 只保留汇总数据，
+// disk 从 JTL 文件读取结果，以及无效策略的校验。
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// saveRetentionResults 依次保存 n 条结果，第 i 条的响应时间为 i+1 毫秒
+func saveRetentionResults(collector *result.Collector, n int) time.Time {
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		elapsed := time.Duration(i+1) * time.Millisecond
+		collector.SaveSuccessResult(result.ResultData{Type: result.Success, StatusCode: 200, StartTime: start, EndTime: start.Add(elapsed)})
+	}
+	return start
+}
+
+func TestResultRetention(t *testing.T) {
+	last, _ := newTestCollector(t, result.CollectorConfig{ResultRetention: "LAST", ResultRetentionLimit: 5})
+	start := saveRetentionResults(last, 12)
+	results := last.Results()
+	if len(results) != 5 || results[0].ResponseTime != 8*time.Millisecond || results[4].ResponseTime != 12*time.Millisecond {
+		t.Fatalf("expected the 5 most recent results in order, got %+v", results)
+	}
+	if counters := last.Counters(); counters.Written != 12 {
+		t.Errorf("counters should include every result: %+v", counters)
+	}
+	if _, err := last.GenerateIntermediateReport(start, start.Add(time.Second)); err != nil {
+		t.Errorf("intermediate reports should use the retained results: %v", err)
+	}
+
+	aggregates, _ := newTestCollector(t, result.CollectorConfig{ResultRetention: result.RetainAggregates})
+	saveRetentionResults(aggregates, 12)
+	if results := aggregates.Results(); len(results) != 0 {
+		t.Errorf("no results should be kept in memory, got %d", len(results))
+	}
+	stats, err := aggregates.AnalyzeFile("")
+	if err != nil || stats["TotalRequests"] != 12 {
+		t.Errorf("full stats should still be available from the JTL file: %v (%v)", stats["TotalRequests"], err)
+	}
+
+	disk, _ := newTestCollector(t, result.CollectorConfig{ResultRetention: result.RetainDisk})
+	saveRetentionResults(disk, 12)
+	if results := disk.Results(); len(results) != 12 || results[11].ResponseTime != 12*time.Millisecond {
+		t.Errorf("results should be read back from the JTL file, got %d", len(results))
+	}
+
+	for _, cfg := range []result.CollectorConfig{
+		{ResultRetention: "ring"},
+		{ResultRetention: result.RetainLast},
+	} {
+		if _, err := result.NewCollector(cfg); err == nil {
+			t.Errorf("invalid retention %+v should be rejected", cfg)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// retry_test.go
+// 重试策略测试模块
+// 本文件负责测试任务的重试策略：失败后按最大执行次数和退避等待重试、按状态码和错误类型决定是否重试，
+// 重试次数写入 TaskContext 和结果，超时后仍在后台运行的执行不受后续重试影响（需要 -race 运行），
+// 以及 TaskDetail 使用重试策略和非法策略的校验。
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestSubmitWithRetryBacksOff(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+
+	var attempts atomic.Int32
+	var elapsed atomic.Int64
+	start := time.Now()
+	policy := pool.RetryPolicy{MaxAttempts: 4, Backoff: pool.BackoffExponential, Delay: 10 * time.Millisecond, Jitter: 0.2}
+	err := taskPool.SubmitWithRetry(func(tc *pool.TaskContext) error {
+		if attempts.Add(1) < 4 {
+			return fmt.Errorf("transient error")
+		}
+		elapsed.Store(int64(time.Since(start)))
+		data := result.ResultData{ID: "flaky", Type: result.Success, StartTime: time.Now(), EndTime: time.Now()}
+		data.ApplyExecution(tc)
+		return collector.SaveSuccessResult(data)
+	}, policy, 1, "flaky", 0)
+	if err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	waitFor(t, func() bool { return taskPool.Stats().Completed == 1 })
+
+	// 指数退避：10ms、20ms、40ms，抖动 ±20%
+	if waited := time.Duration(elapsed.Load()); waited < 56*time.Millisecond {
+		t.Errorf("expected exponential backoff of at least 56ms, got %v", waited)
+	}
+	if stats := taskPool.Stats(); stats.Retries != 3 || stats.Failed != 0 {
+		t.Errorf("expected 3 retries and no failure, got %d and %d", stats.Retries, stats.Failed)
+	}
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil || len(results) != 1 {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if results[0].RetryCount != 3 {
+		t.Errorf("result should record 3 retries, got %d", results[0].RetryCount)
+	}
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if stats["TotalRetries"] != 3 || stats["RetriedCount"] != 1 {
+		t.Errorf("unexpected retry totals: %v, %v", stats["TotalRetries"], stats["RetriedCount"])
+	}
+	if md := result.GenerateMarkdownReport(stats); !strings.Contains(md, "| 重试次数（重试样本数） | 3 (1) |") {
+		t.Errorf("markdown report should show the retry overhead:\n%s", md)
+	}
+}
+
+func TestRetryOnPredicate(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	retryable := pool.RetryPolicy{MaxAttempts: 3, Backoff: pool.BackoffFixed, Delay: time.Millisecond, RetryOn: pool.RetryOnStatus(http.StatusServiceUnavailable)}
+
+	// 503 重试到最大执行次数后记为失败
+	var unavailable atomic.Int32
+	if err := taskPool.SubmitWithRetry(func(*pool.TaskContext) error {
+		unavailable.Add(1)
+		return &pool.StatusError{Code: http.StatusServiceUnavailable}
+	}, retryable, 1, "unavailable", 0); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	// 400 不重试
+	var badRequest atomic.Int32
+	if err := taskPool.SubmitWithRetry(func(*pool.TaskContext) error {
+		badRequest.Add(1)
+		return fmt.Errorf("create order: %w", &pool.StatusError{Code: http.StatusBadRequest})
+	}, retryable, 1, "bad-request", 0); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	waitFor(t, func() bool { return taskPool.Stats().Failed == 2 })
+	if unavailable.Load() != 3 || badRequest.Load() != 1 {
+		t.Errorf("expected 3 and 1 attempts, got %d and %d", unavailable.Load(), badRequest.Load())
+	}
+	status, err := taskPool.GetTaskStatus("unavailable")
+	if err != nil || status.Status != pool.TaskFailed.String() {
+		t.Errorf("task should fail after its last attempt, got %+v %v", status, err)
+	}
+
+	// 每次执行单独计算超时，超时也可以按错误类型重试
+	var slow atomic.Int32
+	policy := pool.RetryPolicy{MaxAttempts: 2, Delay: time.Millisecond, RetryOn: pool.RetryOnErrors(pool.ErrTaskTimeout)}
+	if err := taskPool.SubmitWithRetry(func(tc *pool.TaskContext) error {
+		if slow.Add(1) == 1 {
+			<-tc.Context().Done()
+		}
+		return nil
+	}, policy, 1, "slow-once", 30*time.Millisecond); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	waitFor(t, func() bool { return taskPool.Stats().Completed == 1 })
+	if slow.Load() != 2 || taskPool.Stats().TimedOut != 0 {
+		t.Errorf("a timed out attempt should be retried, got %d attempts and %d timeouts", slow.Load(), taskPool.Stats().TimedOut)
+	}
+}
+
+func TestTimedOutAttemptKeepsOwnContext(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	policy := pool.RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond, RetryOn: pool.RetryOnErrors(pool.ErrTaskTimeout)}
+
+	// 前两次执行忽略截止时间继续运行，期间不断读取自己的上下文；后续重试不能修改这些上下文
+	var attempts atomic.Int32
+	var mismatches atomic.Int32
+	release := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(2)
+	err := taskPool.SubmitWithRetry(func(tc *pool.TaskContext) error {
+		attempt := attempts.Add(1)
+		if attempt == 3 {
+			return nil
+		}
+		defer background.Done()
+		for {
+			select {
+			case <-release:
+				return nil
+			default:
+			}
+			own, ok := pool.TaskContextFrom(tc.Context())
+			if tc.RetryCount != int(attempt-1) || !ok || own != tc {
+				mismatches.Add(1)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}, policy, 1, "slow-twice", 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	waitFor(t, func() bool { return taskPool.Stats().Completed == 1 })
+	close(release)
+	background.Wait()
+
+	if attempts.Load() != 3 || mismatches.Load() != 0 {
+		t.Errorf("expected 3 attempts each seeing its own context, got %d attempts and %d mismatches", attempts.Load(), mismatches.Load())
+	}
+}
+
+func TestTaskDetailRetryPolicy(t *testing.T) {
+	if err := pool.InitLogger(t.TempDir()+"/", "retry_test.log"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	taskPool := newTestPool(t, 2)
+
+	errFatal := errors.New("invalid credentials")
+	attempts := 0
+	login, err := pool.NewTaskDetail("login", func() error {
+		attempts++
+		return errFatal
+	})
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	login.Retry = &pool.RetryPolicy{MaxAttempts: 5, Delay: time.Millisecond, RetryOn: func(err error) bool { return !errors.Is(err, errFatal) }}
+	run, err := taskPool.SubmitDAG("retry", []*pool.TaskDetail{login})
+	if err != nil {
+		t.Fatalf("failed to submit DAG: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := run.Wait(ctx); err == nil {
+		t.Fatal("expected the DAG to fail")
+	}
+	if attempts != 1 {
+		t.Errorf("a non-retryable error should not be retried, got %d attempts", attempts)
+	}
+
+	invalid := []pool.RetryPolicy{{MaxAttempts: -1}, {Jitter: 1.5}, {Backoff: "linear"}, {Delay: -time.Second}}
+	for _, policy := range invalid {
+		if err := taskPool.SubmitWithRetry(func(*pool.TaskContext) error { return nil }, policy, 1, "invalid", 0); err == nil {
+			t.Errorf("expected error for %+v", policy)
+		}
+	}
+	broken, _ := pool.NewTaskDetail("broken", func() error { return nil })
+	broken.Retry = &pool.RetryPolicy{Jitter: -1}
+	if _, err := taskPool.SubmitDAG("invalid", []*pool.TaskDetail{broken}); err == nil {
+		t.Error("expected error for an invalid TaskDetail retry policy")
+	}
+}
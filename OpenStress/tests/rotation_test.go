@@ -0,0 +1,109 @@
+// rotation_test.go
+// JTL 压缩与轮转测试模块
+// 本文件负责测试 JTL 文件的 gzip 压缩写入、按大小和时间轮转，以及运行中和关闭后跨分段读取结果。
+
+package tests
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// saveRotationResults 保存 n 条结果，每 5 条中有 1 条失败，返回时结果已写入 JTL 文件
+func saveRotationResults(collector *result.Collector, n int) {
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		data := result.ResultData{ID: "r", Method: "GET", URL: "http://svc/orders", StartTime: now, EndTime: now.Add(time.Millisecond), ResponseTime: time.Millisecond}
+		if i%5 == 0 {
+			data.Type = result.Failure
+			data.StatusCode = 500
+			data.ErrorMessage = "boom"
+			collector.SaveFailureResult(data)
+		} else {
+			data.Type = result.Success
+			data.StatusCode = 200
+			collector.SaveSuccessResult(data)
+		}
+	}
+	collector.Flush()
+}
+
+func TestJTLCompressionAndSizeRotation(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{JTLCompress: true, JTLRotateSize: 300})
+	saveRotationResults(collector, 60)
+
+	base := collector.JTLFilePath()
+	segments, _ := filepath.Glob(strings.TrimSuffix(base, ".jtl") + "*.jtl.gz")
+	if len(segments) < 2 {
+		t.Fatalf("expected several compressed segments, got %v", segments)
+	}
+	if _, err := os.Stat(base); !os.IsNotExist(err) {
+		t.Errorf("uncompressed file should not be written: %v", err)
+	}
+
+	// 运行中读取：当前分段尚未关闭
+	results, err := collector.LoadResultsFromFile()
+	if err != nil || len(results) != 60 {
+		t.Fatalf("expected 60 results across segments while running, got %d, %v", len(results), err)
+	}
+
+	collector.Close()
+	results, err = collector.LoadResultsFromFile()
+	if err != nil || len(results) != 60 {
+		t.Fatalf("expected 60 results across segments after close, got %d, %v", len(results), err)
+	}
+	failures := 0
+	for _, r := range results {
+		if r.Type == result.Failure {
+			failures++
+		}
+	}
+	if failures != 12 {
+		t.Errorf("expected 12 failures, got %d", failures)
+	}
+
+	// 关闭后每个分段都是完整的 gzip 文件，带表头
+	for _, path := range segments {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", path, err)
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("%s is not a gzip file: %v", path, err)
+		}
+		buf := make([]byte, 9)
+		if _, err := io.ReadFull(gz, buf); err != nil || string(buf) != "timeStamp" {
+			t.Errorf("%s should start with the header, got %q, %v", path, buf, err)
+		}
+		file.Close()
+	}
+}
+
+func TestJTLTimeRotation(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{JTLRotateInterval: 50 * time.Millisecond})
+	saveRotationResults(collector, 5)
+	time.Sleep(60 * time.Millisecond)
+	saveRotationResults(collector, 5)
+
+	base := collector.JTLFilePath()
+	second := strings.TrimSuffix(base, ".jtl") + ".1.jtl"
+	if _, err := os.Stat(second); err != nil {
+		t.Fatalf("expected a second segment %s: %v", second, err)
+	}
+	results, err := collector.LoadResultsFromFile()
+	if err != nil || len(results) != 10 {
+		t.Errorf("expected 10 results across segments, got %d, %v", len(results), err)
+	}
+
+	if _, err := result.NewCollector(result.CollectorConfig{JTLCompress: true, ResumeJTLFilePath: base}); err == nil {
+		t.Error("resuming a compressed JTL file should be rejected")
+	}
+}
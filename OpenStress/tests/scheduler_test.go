@@ -0,0 +1,113 @@
+// scheduler_test.go
+// 定时调度测试模块
+// 本文件负责对 cron 表达式解析和定时调度器进行单元测试。
+
+package tests
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/scheduler"
+)
+
+func TestParseCronNext(t *testing.T) {
+	base := time.Date(2024, 3, 15, 10, 7, 30, 0, time.UTC) // 周五
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2024, 3, 15, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, 3, 18, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2024, 3, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+	for _, c := range cases {
+		schedule, err := scheduler.ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.expr, err)
+			continue
+		}
+		if got := schedule.Next(base); !got.Equal(c.want) {
+			t.Errorf("%s: next run %v, want %v", c.expr, got, c.want)
+		}
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "@every 10ms"} {
+		if _, err := scheduler.ParseCron(expr); err == nil {
+			t.Errorf("%s: expected parse error", expr)
+		}
+	}
+}
+
+func TestSchedulerRunsEachRunWithOwnCollector(t *testing.T) {
+	if _, err := pool.InitializeLogger(t.TempDir()+"/", "scheduler_test.log", "SchedulerTest"); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	logger, _ := pool.GetLogger()
+	taskPool := pool.NewPool(2)
+	t.Cleanup(taskPool.Shutdown)
+
+	s := scheduler.NewScheduler(taskPool, &result.CollectorConfig{
+		BatchSize:   10,
+		JTLFilePath: filepath.Join(t.TempDir(), "scheduled.jtl"),
+		Logger:      logger,
+	})
+	s.Start()
+	defer s.Stop()
+
+	var mu sync.Mutex
+	var runIDs []string
+	done := make(chan struct{}, 2)
+	record := func(runID string, collector *result.Collector) {
+		if collector == nil {
+			t.Errorf("run %s got no collector", runID)
+		}
+		mu.Lock()
+		runIDs = append(runIDs, runID)
+		mu.Unlock()
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	}
+
+	if err := s.ScheduleAt("probe", time.Now().Add(50*time.Millisecond), 1, record); err != nil {
+		t.Fatalf("failed to schedule: %v", err)
+	}
+	if err := s.ScheduleAt("probe", time.Now().Add(time.Second), 1, record); err == nil {
+		t.Fatal("expected duplicate job name to be rejected")
+	}
+	if err := s.ScheduleCron("smoke", "@every 1s", 1, record); err != nil {
+		t.Fatalf("failed to schedule cron job: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("scheduled runs did not complete, got %v", runIDs)
+		}
+	}
+
+	s.Stop()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runIDs) < 2 || runIDs[0] == runIDs[1] {
+		t.Fatalf("expected two distinct run IDs, got %v", runIDs)
+	}
+	for _, job := range s.Jobs() {
+		if job.Name == "probe" && (job.Runs != 1 || !job.NextRun.IsZero()) {
+			t.Errorf("one-shot job: got %+v", job)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// selftest_test.go
+// 压测机自测测试模块
+// 本文件负责测试自测的容量估计、结果保存与读取、请求失败时的退避，以及设置限流时的容量提示。
+
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/selftest"
+)
+
+func TestSelfTestEstimatesCapacity(t *testing.T) {
+	result, err := selftest.Run(selftest.Options{Duration: 200 * time.Millisecond, Concurrency: 4})
+	if err != nil {
+		t.Fatalf("selftest failed: %v", err)
+	}
+	if result.Requests == 0 || result.RPS <= 0 {
+		t.Fatalf("selftest completed no requests: %+v", result)
+	}
+	if result.Capacity <= 0 || result.Capacity >= result.RPS {
+		t.Errorf("capacity estimate %.0f should be below the measured rate %.0f", result.Capacity, result.RPS)
+	}
+	if _, ok := result.CheckPlan(result.Capacity / 2); !ok {
+		t.Error("a plan below capacity should pass")
+	}
+	if warning, ok := result.CheckPlan(result.Capacity * 2); ok || warning == "" {
+		t.Error("a plan above capacity should return a warning")
+	}
+
+	path := filepath.Join(t.TempDir(), "selftest.json")
+	if err := result.Save(path); err != nil {
+		t.Fatalf("failed to save result: %v", err)
+	}
+	loaded, err := selftest.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load result: %v", err)
+	}
+	if loaded.Capacity != result.Capacity || loaded.Requests != result.Requests {
+		t.Errorf("loaded result %+v differs from saved %+v", loaded, result)
+	}
+}
+
+// TestSelfTestBacksOffOnErrors 验证请求失败时客户端会退避，而不是空转重试
+func TestSelfTestBacksOffOnErrors(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			// 直接断开连接，客户端得到传输层错误
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	time.AfterFunc(300*time.Millisecond, func() { failing.Store(false) })
+
+	result, err := selftest.Run(selftest.Options{Duration: 800 * time.Millisecond, Concurrency: 4, Target: server.URL})
+	if err != nil {
+		t.Fatalf("selftest failed: %v", err)
+	}
+	// 4 个客户端从 10ms 开始指数退避，300ms 内每个客户端只会失败几次
+	if result.Errors == 0 || result.Errors > 40 {
+		t.Errorf("expected a few backed-off errors, got %d", result.Errors)
+	}
+	if result.Requests == 0 {
+		t.Error("clients should recover once the target succeeds again")
+	}
+}
+
+func TestSetRateLimitWarnsAboveGeneratorCapacity(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+
+	if warning := taskPool.SetRateLimit(1000, 1); warning != "" {
+		t.Errorf("no warning expected without a capacity estimate, got %q", warning)
+	}
+	taskPool.SetGeneratorCapacity(500)
+	if warning := taskPool.SetRateLimit(400, 1); warning != "" {
+		t.Errorf("no warning expected within capacity, got %q", warning)
+	}
+	if warning := taskPool.SetRateLimit(1000, 1); !strings.Contains(warning, "capacity of 500") {
+		t.Errorf("expected a capacity warning, got %q", warning)
+	}
+	taskPool.SetGeneratorCapacity(0)
+	if warning := taskPool.SetRateLimit(1000, 1); warning != "" {
+		t.Errorf("capacity 0 should disable the warning, got %q", warning)
+	}
+}
@@ -0,0 +1,104 @@
+// shutdown_test.go
+// 优雅停止测试模块
+// 本文件负责测试收到中断信号时的停止流程：停止步骤按注册顺序只执行一次、超时的步骤不阻塞后续步骤、
+// 中断后注册的步骤立即执行，以及中断原因在报告中的展示。
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestShutdownManagerRunsStepsInOrder(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	shutdown := pool.NewShutdownManager()
+	shutdown.StepTimeout = 50 * time.Millisecond
+
+	var mu sync.Mutex
+	var steps []string
+	record := func(name string) {
+		mu.Lock()
+		steps = append(steps, name)
+		mu.Unlock()
+	}
+	shutdown.Register("pool", func(ctx context.Context) error {
+		taskPool.Shutdown()
+		record("pool")
+		return nil
+	})
+	shutdown.Register("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		record("stuck")
+		return nil
+	})
+	shutdown.Register("vus", func(ctx context.Context) error {
+		record("vus")
+		return nil
+	})
+
+	if _, _, ok := shutdown.Interruption(); ok {
+		t.Fatal("should not be interrupted before a signal")
+	}
+	if !shutdown.Trigger(syscall.SIGTERM) {
+		t.Fatal("first trigger should start the shutdown")
+	}
+	if shutdown.Trigger(syscall.SIGINT) {
+		t.Error("second trigger should be ignored")
+	}
+	select {
+	case <-shutdown.Interrupted():
+	default:
+		t.Fatal("Interrupted should be closed after a signal")
+	}
+	select {
+	case <-shutdown.Stopped():
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown steps did not complete")
+	}
+
+	mu.Lock()
+	if got := strings.Join(steps, ","); got != "pool,vus" {
+		t.Errorf("expected steps to run once in order without waiting for the stuck step, got %s", got)
+	}
+	mu.Unlock()
+	if err := taskPool.Submit(func(int32) {}, 1, "after-shutdown", time.Second); err == nil {
+		t.Error("pool should reject tasks after the shutdown step")
+	}
+	if sig, at, ok := shutdown.Interruption(); !ok || sig != syscall.SIGTERM || at.IsZero() {
+		t.Errorf("unexpected interruption: %v %v %v", sig, at, ok)
+	}
+
+	late := make(chan struct{})
+	shutdown.Register("late", func(ctx context.Context) error {
+		close(late)
+		return nil
+	})
+	select {
+	case <-late:
+	default:
+		t.Error("a step registered after the interruption should run immediately")
+	}
+}
+
+func TestInterruptedShownInReports(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	collector.SetAbort(string(pool.StopInterrupted), "received "+syscall.SIGINT.String(), at)
+	stats := lockTestStats(t, collector)
+
+	if stats["StopReason"] != "interrupted" {
+		t.Fatalf("unexpected stop reason: %v", stats["StopReason"])
+	}
+	md := result.GenerateMarkdownReport(stats)
+	if !strings.Contains(md, "测试已提前中止：收到中断信号（2024-05-01 10:30:00）") {
+		t.Errorf("markdown report should state the interruption:\n%s", md)
+	}
+}
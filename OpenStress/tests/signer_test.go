@@ -0,0 +1,186 @@
+// signer_test.go
+// 请求签名测试模块
+// 本文件负责测试 AWS SigV4 签名（使用 AWS 公布的 SigV4 测试套件向量）、HMAC 签名的签名与校验，
+// 以及按配置创建的签名 HTTP 客户端。
+
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// sigV4TestTime AWS SigV4 测试套件使用的签名时间
+var sigV4TestTime = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+func TestAWSSigV4KnownAnswerVectors(t *testing.T) {
+	cases := []struct {
+		name    string
+		method  string
+		url     string
+		service string
+		headers map[string]string
+		want    string
+	}{
+		{
+			// aws-sig-v4-test-suite/get-vanilla
+			name:    "get-vanilla",
+			method:  "GET",
+			url:     "https://example.amazonaws.com/",
+			service: "service",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			// SigV4 签名流程文档中的 IAM ListUsers 示例
+			name:    "iam-list-users",
+			method:  "GET",
+			url:     "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			service: "iam",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.url, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			signer := &tasks.AWSSigV4Signer{
+				Region:          "us-east-1",
+				Service:         tc.service,
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+				Now:             sigV4TestTime,
+			}
+			if err := signer.Sign(req, nil); err != nil {
+				t.Fatalf("failed to sign request: %v", err)
+			}
+			if got := req.Header.Get("Authorization"); got != tc.want {
+				t.Errorf("authorization mismatch\ngot:  %s\nwant: %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAWSSigV4CanonicalURIEncoding(t *testing.T) {
+	cases := []struct {
+		service string
+		url     string
+		want    string
+	}{
+		// 除 S3 外的服务：路径规范化，且每个路径段编码两次
+		{"service", "https://example.amazonaws.com/example space/", "/example%2520space/"},
+		{"service", "https://example.amazonaws.com/a/./b/../c//d", "/a/c/d"},
+		// S3：路径不规范化，只编码一次
+		{"s3", "https://bucket.s3.amazonaws.com/example space/a//b", "/example%20space/a//b"},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		signer := &tasks.AWSSigV4Signer{Region: "us-east-1", Service: tc.service, Now: sigV4TestTime}
+		canonical := signer.CanonicalRequest(req, nil)
+		if got := strings.Split(canonical, "\n")[1]; got != tc.want {
+			t.Errorf("%s %s: canonical URI %q, want %q", tc.service, tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestHMACSignVerifyRoundTrip(t *testing.T) {
+	signer := &tasks.HMACSigner{
+		KeyID:         "key-1",
+		Secret:        []byte("top-secret"),
+		SignedHeaders: []string{"Content-Type"},
+	}
+	body := []byte(`{"order":42}`)
+
+	req := httptest.NewRequest("POST", "http://api.example.com/orders?dry_run=1", nil)
+	req.Header.Set("Content-Type", "application/json")
+	if err := signer.Sign(req, body); err != nil {
+		t.Fatalf("failed to sign request: %v", err)
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), `HMAC-SHA256 keyId="key-1",headers="content-type",signature="`) {
+		t.Fatalf("unexpected authorization header: %s", req.Header.Get("Authorization"))
+	}
+	if err := signer.Verify(req, body); err != nil {
+		t.Fatalf("signature of unmodified request should verify: %v", err)
+	}
+
+	if err := signer.Verify(req, []byte(`{"order":43}`)); err == nil {
+		t.Error("expected verification to fail for a modified body")
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	if err := signer.Verify(req, body); err == nil {
+		t.Error("expected verification to fail for a modified signed header")
+	}
+	other := &tasks.HMACSigner{KeyID: "key-1", Secret: []byte("wrong"), SignedHeaders: []string{"Content-Type"}}
+	req.Header.Set("Content-Type", "application/json")
+	if err := other.Verify(req, body); err == nil {
+		t.Error("expected verification to fail with a different secret")
+	}
+}
+
+func TestNewHTTPClientSignsRequests(t *testing.T) {
+	verifier := &tasks.HMACSigner{KeyID: "scenario", Secret: []byte("s3cr3t")}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifier.Verify(r, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	secretsFile := filepath.Join(dir, "secrets.yaml")
+	if err := os.WriteFile(secretsFile, []byte("id: scenario\nsecret: s3cr3t\n"), 0600); err != nil {
+		t.Fatalf("failed to write secrets file: %v", err)
+	}
+	configFile := filepath.Join(dir, "http_client.yaml")
+	config := "timeout: 5s\nsecrets_file: " + secretsFile + "\nsigner:\n  type: hmac\n  access_key_ref: id\n  secret_key_ref: secret\n"
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write client config: %v", err)
+	}
+
+	cfg, err := tasks.LoadHTTPClientConfig(configFile)
+	if err != nil {
+		t.Fatalf("failed to load client config: %v", err)
+	}
+	client, err := tasks.NewHTTPClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	task := &tasks.Task{ID: "signed", Client: client}
+	resp, err := task.HTTPClient().Get(server.URL + "/ping")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected signed request to be accepted, got status %d", resp.StatusCode)
+	}
+
+	resp, err = (&tasks.Task{ID: "unsigned"}).HTTPClient().Get(server.URL + "/ping")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unsigned request to be rejected, got status %d", resp.StatusCode)
+	}
+}
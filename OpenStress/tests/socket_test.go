@@ -0,0 +1,188 @@
+// socket_test.go
+// TCP/UDP 压测任务测试模块
+// 本文件负责测试 TCP/UDP 任务的报文格式、响应匹配，以及连接耗时、收发字节数和错误的记录。
+
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// startTCPEcho 启动按行回显的 TCP 服务，记录收到的每一行
+func startTCPEcho(t *testing.T) (string, func() []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	var lines []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					mu.Lock()
+					lines = append(lines, scanner.Text())
+					mu.Unlock()
+					conn.Write([]byte("ECHO " + scanner.Text() + "\n"))
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), lines...)
+	}
+}
+
+func TestSocketTaskTCPTemplateAndMatch(t *testing.T) {
+	addr, received := startTCPEcho(t)
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	task, err := tasks.NewSocketTask(tasks.SocketConfig{
+		Network:       "tcp",
+		Address:       addr,
+		Connections:   3,
+		Messages:      2,
+		Payload:       "PING {{.ThreadID}}-{{.Conn}}-{{.Seq}}\n",
+		PayloadFormat: tasks.PayloadTemplate,
+		ResponseMatch: `ECHO PING \d+-\d+-\d+\n`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create socket task: %v", err)
+	}
+	task.Collector = collector
+
+	if err := task.Run(context.Background(), 7); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	lines := received()
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 messages, got %v", lines)
+	}
+	for _, want := range []string{"PING 7-0-0", "PING 7-2-1"} {
+		if !strings.Contains(strings.Join(lines, ","), want) {
+			t.Errorf("expected %q in %v", want, lines)
+		}
+	}
+
+	results := collector.Results()
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+	var withConnect int
+	for _, r := range results {
+		if r.Type != result.Success || r.Method != "TCP" || r.URL != "tcp://"+addr || r.ThreadID != 7 {
+			t.Errorf("unexpected result: %+v", r)
+		}
+		if r.DataSent != int64(len("PING 7-0-0\n")) || r.DataReceived != r.DataSent+int64(len("ECHO ")) {
+			t.Errorf("unexpected byte counts: sent %d, received %d", r.DataSent, r.DataReceived)
+		}
+		if r.TCPConnect > 0 {
+			withConnect++
+		}
+	}
+	if withConnect != 3 {
+		t.Errorf("connect time should be recorded once per connection, got %d", withConnect)
+	}
+}
+
+func TestSocketTaskUDPHexAndFailures(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			// 回复报文的第一个字节加一
+			reply := append([]byte{buf[0] + 1}, buf[1:n]...)
+			server.WriteTo(reply, addr)
+		}
+	}()
+
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	udp, err := tasks.NewSocketTask(tasks.SocketConfig{
+		Network:          "udp",
+		Address:          server.LocalAddr().String(),
+		Payload:          "01 ab cd",
+		PayloadFormat:    tasks.PayloadHex,
+		ResponseMatch:    "^02abcd$",
+		ResponseMatchHex: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create socket task: %v", err)
+	}
+	udp.Collector = collector
+	if err := udp.Run(context.Background(), 1); err != nil {
+		t.Fatalf("udp run failed: %v", err)
+	}
+	if results := collector.Results(); len(results) != 1 || results[0].DataSent != 3 || results[0].DataReceived != 3 {
+		t.Errorf("unexpected udp result: %+v", results)
+	}
+
+	// 响应不匹配：读取超时后记为失败
+	addr, _ := startTCPEcho(t)
+	mismatch, _ := tasks.NewSocketTask(tasks.SocketConfig{
+		Network: "tcp", Address: addr, Payload: "hello\n", ResponseMatch: "WORLD", ReadTimeout: 100 * time.Millisecond,
+	})
+	mismatch.Collector = collector
+	if err := mismatch.Run(context.Background(), 2); err == nil || !strings.Contains(err.Error(), `did not match "WORLD"`) {
+		t.Errorf("expected a match error, got %v", err)
+	}
+
+	// 连接失败：每个连接记一条失败结果
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := listener.Addr().String()
+	listener.Close()
+	refused, _ := tasks.NewSocketTask(tasks.SocketConfig{Network: "tcp", Address: closed, Connections: 2, Payload: "x"})
+	refused.Collector = collector
+	if err := refused.Run(context.Background(), 3); err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("expected a connect error, got %v", err)
+	}
+
+	var failures int
+	for _, r := range collector.Results() {
+		if r.Type == result.Failure {
+			failures++
+		}
+	}
+	if failures != 3 {
+		t.Errorf("expected 3 failed results, got %d", failures)
+	}
+
+	for name, cfg := range map[string]tasks.SocketConfig{
+		"network":  {Network: "sctp", Address: addr},
+		"address":  {Network: "tcp"},
+		"hex":      {Network: "tcp", Address: addr, Payload: "zz", PayloadFormat: tasks.PayloadHex},
+		"template": {Network: "tcp", Address: addr, Payload: "{{.Missing", PayloadFormat: tasks.PayloadTemplate},
+		"format":   {Network: "tcp", Address: addr, PayloadFormat: "base64"},
+		"match":    {Network: "tcp", Address: addr, ResponseMatch: "("},
+	} {
+		if _, err := tasks.NewSocketTask(cfg); err == nil {
+			t.Errorf("%s: expected a config error", name)
+		}
+	}
+}
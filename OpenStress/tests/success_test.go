@@ -0,0 +1,77 @@
+// success_test.go
+// 成功判定测试模块
+// 本文件负责测试按状态码类别、具体状态码、接口覆盖规则和内容断言判定请求是否成功，
+// 以及 CollectDataWithParams 按策略设置结果类型。
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestSuccessPolicy(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{SuccessPolicy: result.SuccessPolicy{
+		StatusCodes: []string{"2xx", "304"},
+		Endpoints: map[string][]string{
+			"/items":        {"2xx", "404"},
+			"/items/legacy": {"410"},
+		},
+		RequireAssertions: true,
+	}})
+	cases := []struct {
+		url        string
+		status     int
+		assertions map[string]bool
+		success    bool
+	}{
+		{"/users", 201, nil, true},
+		{"/users", 304, nil, true},
+		{"/users", 302, nil, false},
+		{"/users", 404, nil, false},
+		{"/items/1", 404, nil, true},
+		{"/items/legacy/1", 404, nil, false}, // 最长的子串优先
+		{"/items/legacy/1", 410, nil, true},
+		{"/users", 200, map[string]bool{"has_id": true, "non_empty": false}, false},
+		{"/users", 0, nil, false},
+	}
+	for _, c := range cases {
+		err := collector.EvaluateSuccess(result.ResultData{URL: c.url, StatusCode: c.status, Assertions: c.assertions})
+		if (err == nil) != c.success {
+			t.Errorf("%s %d %v: expected success=%v, got %v", c.url, c.status, c.assertions, c.success, err)
+		}
+	}
+
+	for _, policy := range []result.SuccessPolicy{
+		{StatusCodes: []string{"6xx"}},
+		{StatusCodes: []string{"ok"}},
+		{Endpoints: map[string][]string{"/items": nil}},
+	} {
+		if _, err := result.NewCollector(result.CollectorConfig{SuccessPolicy: policy}); err == nil {
+			t.Errorf("invalid policy %+v should be rejected", policy)
+		}
+	}
+}
+
+func TestCollectDataWithParamsAppliesSuccessPolicy(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	start := time.Now()
+	for _, status := range []int{200, 302, 404, 503} {
+		collector.CollectDataWithParams("probe", start, start.Add(time.Millisecond), status, "GET", "http://example.com/", 0, 0, 1, "text", "", 1, 1, 0)
+	}
+	if err := collector.Close(); err != nil {
+		t.Fatalf("failed to close collector: %v", err)
+	}
+
+	failures := make(map[int]string)
+	for _, r := range collector.Results() {
+		if r.Type != result.Success {
+			failures[r.StatusCode] = r.ErrorMessage
+		}
+	}
+	if len(failures) != 2 || failures[404] != "request failed with status 404" || failures[503] == "" {
+		t.Errorf("expected only 4xx and 5xx to fail by default, got %v", failures)
+	}
+}
@@ -0,0 +1,87 @@
+// template_test.go
+// 请求模板测试模块
+// 本文件负责测试请求模板：URL、请求体和请求头中的模板渲染、随机数据函数、与 ${name} 变量的配合，
+// 相同随机数生成器下生成数据的可复现性，以及模板语法错误和未定义变量的错误信息。
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+func TestRequestTemplateRendersPerIteration(t *testing.T) {
+	rt, err := tasks.NewRequestTemplate(http.MethodPost,
+		"${base}/users/{{randInt 1 9}}?ts={{unixMilli}}",
+		`{"id":"{{uuid}}","name":"{{name}}","email":"{{email}}","code":"{{randString 8}}","plan":"{{randChoice "free" "pro"}}","tenant":"{{.tenant}}","day":"{{now.Format "2006-01-02"}}"}`,
+		map[string]string{"X-Request-Id": "{{randHex 16}}", "X-Tenant": "${tenant}"})
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+
+	vars := tasks.NewVariables(map[string]string{"base": "http://localhost", "tenant": "acme"})
+	faker := tasks.NewFaker(rand.New(rand.NewSource(1)))
+	seen := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		req, err := rt.NewRequest(context.Background(), vars, faker)
+		if err != nil {
+			t.Fatalf("failed to render request: %v", err)
+		}
+		if !regexp.MustCompile(`^http://localhost/users/[1-9]\?ts=\d+$`).MatchString(req.URL.String()) {
+			t.Errorf("unexpected url %s", req.URL)
+		}
+		if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(req.Header.Get("X-Request-Id")) || req.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("unexpected headers %v", req.Header)
+		}
+		raw, _ := io.ReadAll(req.Body)
+		var body map[string]string
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("body is not valid json: %s", raw)
+		}
+		if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(body["id"]) ||
+			!strings.Contains(body["email"], "@") || len(body["code"]) != 8 || body["tenant"] != "acme" ||
+			(body["plan"] != "free" && body["plan"] != "pro") || len(body["day"]) != 10 {
+			t.Errorf("unexpected body %s", raw)
+		}
+		seen[body["id"]] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("each iteration should generate new data, got %d distinct ids", len(seen))
+	}
+
+	// 相同的随机数生成器生成相同的数据
+	a, _ := tasks.RenderTemplate("{{uuid}} {{name}} {{phone}} {{randFloat 0 1}} {{randBool}}", nil, tasks.NewFaker(rand.New(rand.NewSource(42))))
+	b, _ := tasks.RenderTemplate("{{uuid}} {{name}} {{phone}} {{randFloat 0 1}} {{randBool}}", nil, tasks.NewFaker(rand.New(rand.NewSource(42))))
+	if a == "" || a != b {
+		t.Errorf("same rng should render the same payload, got %q and %q", a, b)
+	}
+}
+
+func TestRequestTemplateErrors(t *testing.T) {
+	if _, err := tasks.NewRequestTemplate(http.MethodGet, "http://localhost/{{uuid", "", nil); err == nil || !strings.Contains(err.Error(), "invalid url template") {
+		t.Errorf("expected a url syntax error, got %v", err)
+	}
+	if _, err := tasks.NewRequestTemplate(http.MethodGet, "http://localhost", "{{unknownFunc}}", nil); err == nil || !strings.Contains(err.Error(), "invalid body template") {
+		t.Errorf("expected an unknown function error, got %v", err)
+	}
+
+	rt, err := tasks.NewRequestTemplate(http.MethodGet, "http://localhost/{{.missing}}", "", nil)
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	if _, err := rt.NewRequest(context.Background(), nil, nil); err == nil || !strings.Contains(err.Error(), "failed to render url template") {
+		t.Errorf("expected a missing variable error, got %v", err)
+	}
+	rt, _ = tasks.NewRequestTemplate(http.MethodGet, "http://localhost/${missing}", "", nil)
+	if _, err := rt.NewRequest(context.Background(), nil, nil); err == nil || !strings.Contains(err.Error(), "undefined variables: missing") {
+		t.Errorf("expected an undefined variable error, got %v", err)
+	}
+}
@@ -1,16 +1,13 @@
 package tests
 
 import (
-	"OpenStress/pool"
 	"fmt"
-
-	// "net/http"
+	"net/http"
 	"time"
 
-	"OpenStress/result"
-
-	"github.com/jcmturner/gokrb5/client"
-	"gopkg.in/jcmturner/gokrb5.v7/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
 )
 
 // TestTaskPool 测试任务池的功能
@@ -49,75 +46,30 @@ func TestTask_AD() {
     .example.com = WTEST.COM
     example.com = WTEST.COM
 `
-	var conf, _ = config.NewConfigFromString(krb5conf)
+	kerberosTask, err := tasks.NewKerberosHTTPTask(tasks.KerberosConfig{
+		Krb5Conf: krb5conf,
+		Username: "Administrator",
+		Realm:    "WTEST.COM",
+		Password: "Emm@2022",
+		SPN:      "HTTP/www.example.com",
+	})
+	if err != nil {
+		fmt.Println("Error loading krb5 configuration: ", err)
+		return
+	}
+	defer kerberosTask.Close()
+	kerberosTask.Collector = collector
 	fmt.Println("krb5配置信息初始化完成：", krb5conf)
-	// 定义高优先级任务
+	// 定义高优先级任务：登录、获取服务票据并发送带 Negotiate 认证头的请求，结果由 kerberosTask 写入收集器
 	highPriorityTask := func(threadID int32) {
-
-		// 		krb5conf := `
-		// [libdefaults]
-		//     default_realm = TEST.COM
-		//     udp_preference_limit = 1
-		// [realms]
-		//     TEST.COM = {
-		//         kdc = 10.10.27.145
-		//         admin_server = 10.10.27.145
-		//     }
-		// [domain_realm]
-		//     .example.com = EXAMPLE.COM
-		//     example.com = EXAMPLE.COM
-		//     `
-		// conf, err := config.NewConfigFromString(krb5conf)
-		// if err != nil {
-		// 	fmt.Println("Error loading krb5 configuration: ", err)
-
-		// }
-		startTime := time.Now()
-		// 创建 Kerberos 客户端
-		Kerberos_client := client.NewClientWithPassword("Administrator", "WTEST.COM", "Emm@2022", conf)
-
-		// 登录
-		err = Kerberos_client.Login()
+		req, err := http.NewRequest(http.MethodGet, "http://10.10.27.145:8089/auth", nil)
 		if err != nil {
-			fmt.Printf("Error logging in with user: %v", err)
-		} else {
-			//succ_cnt++
+			fmt.Printf("创建请求失败: %v\n", err)
+			return
 		}
-
-		spn := "HTTP/www.example.com"
-		Kerberos_client.GetServiceTicket(spn)
-		if err != nil {
-			collector.SaveFailureResult(result.ResultData{
-				ID:           "test1",
-				Type:         result.Failure,
-				ResponseTime: 1 * time.Millisecond,
-				StartTime:    startTime,
-				EndTime:      time.Now(),
-				StatusCode:   404,
-				Method:       "GET",
-				URL:          "Kerberos://10.10.27.145:8089/auth",
-				DataSent:     1024,
-				DataReceived: 2048,
-				ThreadID:     int(threadID),
-			})
+		if _, err := kerberosTask.Execute(req, int(threadID)); err != nil {
 			fmt.Printf("请求失败: %v\n", err)
-			return
 		}
-		// defer resp.Body.Close()
-		// fmt.Printf("请求成功，状态码: %d\n", resp.StatusCode)
-		collector.SaveSuccessResult(result.ResultData{
-			ID:           "test1",
-			Type:         result.Success,
-			ResponseTime: 2 * time.Millisecond,
-			StartTime:    startTime,
-			EndTime:      time.Now(),
-			StatusCode:   200,
-			Method:       "GET",
-			URL:          "Kerberos://10.10.27.145:8089/auth",
-			DataSent:     1024,
-			DataReceived: 2048,
-			ThreadID:     int(threadID),
-		})
 	}
 
 	// 提交高优先级任务
@@ -132,7 +84,8 @@ func TestTask_AD() {
 	// 关闭任务池
 	taskPool.Shutdown()
 
-	// 加载结果数据
+	// 等待结果写入后加载结果数据
+	collector.Flush()
 	results, err := collector.LoadResultsFromFile()
 	if err != nil {
 		fmt.Printf("Error loading results: %v\n", err)
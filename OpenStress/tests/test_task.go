@@ -1,21 +1,60 @@
 package tests
 
 import (
-	"OpenStress/pool"
+	"context"
 	"fmt"
-
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
-	"OpenStress/result"
+	"github.com/potatoImp/OpenStress/checkpoint"
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/notify"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/publish"
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tasks"
 )
 
+// TaskPoolOptions TestTaskPool1 的运行选项，零值表示不启用对应功能
+type TaskPoolOptions struct {
+	Thresholds     []string                   // 压测结束后判定的阈值规则，未通过时返回 result.ExitCodeThresholdsFailed
+	Notifier       *notify.Notifier           // 不为 nil 时在压测结束后发送测试摘要通知
+	Publisher      publish.ReportPublisher    // 不为 nil 时把报告上传到外部存储，通知中使用上传后的链接
+	Elasticsearch  config.ElasticsearchConfig // URL 不为空时每条结果同时写入 Elasticsearch
+	History        config.HistoryConfig       // 目录不为空时记录本次运行的关键指标，并与最近几次运行的基线比较，回归写入报告
+	JUnitPath      string                     // 不为空时把阈值判定结果写入该 JUnit XML 文件，供 CI 展示
+	CheckpointPath string                     // 不为空时定期保存检查点
+	Resume         bool                       // 为 true 时从检查点继续中断的压测，跳过已结束的任务并追加写入同一个 JTL 文件
+	Shutdown       *pool.ShutdownManager      // 不为 nil 时收到 SIGINT/SIGTERM 会关闭任务池，已收集的结果仍生成标记为已中断的报告并保留检查点
+}
+
 // TestTaskPool 测试任务池的功能
-func TestTaskPool1() {
+// 返回值为进程退出码：阈值未通过时为 result.ExitCodeThresholdsFailed，被中断时为 pool.ExitCodeInterrupted
+func TestTaskPool1(opts TaskPoolOptions) int {
 	maxWorkers := 100
 	taskPool := pool.NewPool(maxWorkers)
 
 	stressLogger, _ := pool.GetLogger()
+
+	// 断点续跑：读取上次的检查点
+	var resumed *checkpoint.Checkpoint
+	if opts.Resume {
+		if opts.CheckpointPath == "" {
+			stressLogger.Log("ERROR", "Cannot resume: no checkpoint file configured")
+			return 1
+		}
+		cp, err := checkpoint.Load(opts.CheckpointPath)
+		if err != nil {
+			stressLogger.Log("ERROR", "Failed to load checkpoint: "+err.Error())
+			return 1
+		}
+		resumed = cp
+		fmt.Printf("从检查点继续：已结束 %d 个任务，已写入 %d 条结果（%s）\n",
+			len(cp.FinishedTasks), cp.Counters.Written, cp.SavedAt.Format(time.RFC3339))
+	}
+
 	// result 模块测试方法
 	collectorConfig := result.CollectorConfig{
 		BatchSize:       10,
@@ -25,27 +64,56 @@ func TestTaskPool1() {
 		NumGoroutines:   2,
 		CollectInterval: 5,
 		TaskID:          "testTask",
+		Thresholds:      opts.Thresholds,
+		Elasticsearch:   opts.Elasticsearch,
+		History:         opts.History,
+	}
+	if resumed != nil {
+		collectorConfig.ResumeJTLFilePath = resumed.JTLFilePath
 	}
 	collector, err := result.NewCollector(collectorConfig)
 	if err != nil {
 		stressLogger.Log("ERROR", "Failed to create collector: "+err.Error())
+		return 1
 	}
 	collector.InitializeCollector()
 
+	// 收到中断信号时关闭任务池，取消尚未执行的任务
+	if opts.Shutdown != nil {
+		opts.Shutdown.Register("pool", func(ctx context.Context) error {
+			taskPool.Shutdown()
+			return nil
+		})
+	}
+
+	// 定期保存检查点，续跑时已结束的任务会被任务池跳过
+	var checkpointWriter *checkpoint.Writer
+	if opts.CheckpointPath != "" {
+		var progress *pool.Progress
+		if resumed != nil {
+			progress = pool.NewProgress(resumed.FinishedTasks)
+		}
+		checkpointWriter = checkpoint.Start(opts.CheckpointPath, collectorConfig.TaskID, checkpoint.DefaultInterval, collector, taskPool, progress)
+	}
+
 	// 定义高优先级任务
 	highPriorityTask := func(threadID int32) {
 		time.Sleep(1 * time.Second) // 模拟任务执行时间
 
-		resp, err := http.Get("http://10.10.27.111:8089/index.html")
+		// 使用共享客户端，连接在任务之间复用；同时采集 DNS、连接、TLS 和首字节耗时
+		req, err := http.NewRequest(http.MethodGet, "http://10.10.27.111:8089/index.html", nil)
+		if err != nil {
+			fmt.Printf("创建请求失败: %v\n", err)
+			return
+		}
+		resp, phases, err := tasks.DoTimed(nil, req)
 		// if err != nil {
 		// 	// 连接失败时处理错误
 		// 	fmt.Println("Request failed:", err)
 		// 	return // 可以提前返回，避免执行到 defer 语句
 		// }
-		defer resp.Body.Close()
 		if err != nil {
 			collector.SaveFailureResult(result.ResultData{
-				ID:           "test1",
 				Type:         result.Failure,
 				ResponseTime: 0,
 				StartTime:    time.Now(),
@@ -60,10 +128,9 @@ func TestTaskPool1() {
 			fmt.Printf("请求失败: %v\n", err)
 			return
 		}
-		// defer resp.Body.Close()
+		defer resp.Body.Close()
 		// fmt.Printf("请求成功，状态码: %d\n", resp.StatusCode)
-		collector.SaveSuccessResult(result.ResultData{
-			ID:           "test1",
+		successResult := result.ResultData{
 			Type:         result.Success,
 			ResponseTime: 0,
 			StartTime:    time.Now(),
@@ -74,10 +141,11 @@ func TestTaskPool1() {
 			DataSent:     1024,
 			DataReceived: 2048,
 			ThreadID:     int(threadID),
-		})
+		}
+		successResult.ApplyPhases(phases)
+		collector.SaveSuccessResult(successResult)
 
 		collector.SaveFailureResult(result.ResultData{
-			ID:           "test1",
 			Type:         result.Failure,
 			ResponseTime: 2 * time.Millisecond,
 			StartTime:    time.Now(),
@@ -126,14 +194,32 @@ func TestTaskPool1() {
 	// 启动任务池
 	taskPool.Start()
 
-	// 关闭任务池
+	// 等待已提交的任务全部结束后再关闭任务池，被中断时只等待正在执行的任务
+	taskPool.Wait()
 	taskPool.Shutdown()
 
-	// 加载结果数据
+	// 被中断时在报告中标记，已收集的结果照常生成报告
+	interrupted := false
+	if opts.Shutdown != nil {
+		if sig, at, ok := opts.Shutdown.Interruption(); ok {
+			interrupted = true
+			collector.SetAbort(string(pool.StopInterrupted), "received "+sig.String(), at)
+		}
+	}
+
+	// 保存最后一次检查点，报告生成失败时仍可续跑
+	if checkpointWriter != nil {
+		if err := checkpointWriter.Stop(); err != nil {
+			fmt.Println("Error saving checkpoint:", err)
+		}
+	}
+
+	// 等待结果写入后加载结果数据
+	collector.Flush()
 	results, err := collector.LoadResultsFromFile()
 	if err != nil {
 		fmt.Printf("Error loading results: %v\n", err)
-		return
+		return 1
 	}
 	// 生成并打印测试报告
 	report := collector.GenerateSummaryReport(results)
@@ -143,20 +229,81 @@ func TestTaskPool1() {
 	stats, err := collector.GeneratePerformanceStats(results)
 	if err != nil {
 		fmt.Println("Error generating stats:", err)
-		return
+		return 1
 	}
 	fmt.Println("Performance Stats:")
 	fmt.Println(stats)
 
+	// 阈值判定，结果同时写入报告
+	thresholdResults, passed := collector.EvaluateThresholds(stats)
+	if !passed {
+		fmt.Println("Thresholds failed")
+	}
+	if opts.JUnitPath != "" {
+		if err := saveJUnitReport(collector, opts.JUnitPath); err != nil {
+			fmt.Println("Error saving JUnit report:", err)
+			return 1
+		}
+	}
+
+	// 与历史运行的基线比较，回归只记录警告并写入报告，不影响退出码
+	if _, err := collector.RecordTrend(stats, "01X批次OpenStress产品基准测试报告"); err != nil {
+		fmt.Println("Error recording trend:", err)
+	}
+
 	// 保存HTML报告到文件
 	reportPath, err := collector.SaveReportToFile(stats, "01X批次OpenStress产品基准测试报告")
 	if err != nil {
 		fmt.Println("Error saving report:", err)
-		return
+		return 1
 	}
 
 	// 输出生成的报告路径
 	fmt.Printf("测试报告已生成：%s\n", reportPath)
 
+	// 上传报告，上传失败时通知中使用本地报告链接
+	var reportURL string
+	if opts.Publisher != nil {
+		if reportURL, err = opts.Publisher.Publish(context.Background(), reportPath); err != nil {
+			fmt.Println("Error publishing report:", err)
+		} else {
+			fmt.Printf("测试报告已上传：%s\n", reportURL)
+		}
+	}
+
+	// 场景已完成，删除检查点；被中断时保留检查点以便续跑
+	if checkpointWriter != nil && !interrupted {
+		if err := checkpoint.Remove(opts.CheckpointPath); err != nil {
+			fmt.Println("Error removing checkpoint:", err)
+		}
+	}
+
+	// 发送压测完成通知，通知失败不影响退出码
+	if opts.Notifier != nil {
+		if reportURL == "" {
+			reportURL = opts.Notifier.ReportLink(reportPath)
+		}
+		summary := notify.SummaryFromStats(stats, "01X批次OpenStress产品基准测试报告", reportURL)
+		if err := opts.Notifier.Notify(context.Background(), summary); err != nil {
+			fmt.Println("Error sending notification:", err)
+		}
+	}
+
 	collector.CloseCollector()
+	if interrupted {
+		return pool.ExitCodeInterrupted
+	}
+	return result.ThresholdExitCode(thresholdResults)
+}
+
+// saveJUnitReport 将阈值判定结果写入 JUnit XML 文件
+func saveJUnitReport(collector *result.Collector, path string) error {
+	data, err := collector.ExportStats(result.ExportFormatJUnit)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
 }
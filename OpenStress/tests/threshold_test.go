@@ -0,0 +1,205 @@
+// threshold_test.go
+// 阈值判定测试模块
+// 本文件负责测试阈值表达式的解析（合法与非法表达式）、对统计结果的判定、退出码、报告中的转义以及 JUnit XML 导出。
+
+package tests
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestParseThresholdValid(t *testing.T) {
+	cases := []struct {
+		expr string
+		want result.Threshold
+	}{
+		{"p95 < 800ms", result.Threshold{Metric: "p95", Operator: "<", Value: 800}},
+		{"P99 <= 1.5s", result.Threshold{Metric: "p99", Operator: "<=", Value: 1500}},
+		{"avg < 250", result.Threshold{Metric: "avg", Operator: "<", Value: 250}},
+		{"error_rate < 1%", result.Threshold{Metric: "error_rate", Operator: "<", Value: 1}},
+		{"tps > 300", result.Threshold{Metric: "tps", Operator: ">", Value: 300}},
+		{"total_requests == 10", result.Threshold{Metric: "total_requests", Operator: "==", Value: 10}},
+		{
+			"content_rate{url=/search,assertion=results_non_empty} >= 99%",
+			result.Threshold{Metric: "content_rate", Operator: ">=", Value: 99, URLPattern: "/search", Assertion: "results_non_empty"},
+		},
+	}
+	for _, tc := range cases {
+		got, err := result.ParseThreshold(tc.expr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.expr, err)
+			continue
+		}
+		tc.want.Expression = tc.expr
+		if got != tc.want {
+			t.Errorf("%q: got %+v, want %+v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseThresholdMalformed(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"p95<800ms",
+		"p95 < 800 ms",
+		"p95 != 800ms",
+		"p42 < 800ms",
+		"p95{url=/a} < 800ms",
+		"p95 < fast",
+		"p95 < 800xs",
+		"error_rate < %",
+		"content_rate >= 99%",
+		"content_rate{url=/search} >= 99%",
+		"content_rate{assertion=ok,method=GET} >= 99%",
+	} {
+		if _, err := result.ParseThreshold(expr); err == nil {
+			t.Errorf("%q: expected parse error", expr)
+		}
+	}
+
+	if _, err := result.ParseThresholds([]string{"p95 < 800ms", "tps >> 1"}); err == nil {
+		t.Error("expected ParseThresholds to fail when any expression is malformed")
+	}
+}
+
+// thresholdTestResults 9 个成功请求（10ms）与 1 个失败请求（100ms）
+func thresholdTestResults() []result.ResultData {
+	start := time.Now()
+	results := make([]result.ResultData, 0, 10)
+	for i := 0; i < 10; i++ {
+		r := result.ResultData{Type: result.Success, StatusCode: 200, ResponseTime: 10 * time.Millisecond}
+		if i == 9 {
+			r = result.ResultData{Type: result.Failure, StatusCode: 500, ResponseTime: 100 * time.Millisecond}
+		}
+		r.StartTime = start.Add(time.Duration(i) * 100 * time.Millisecond)
+		r.EndTime = r.StartTime.Add(r.ResponseTime)
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestEvaluateThresholds(t *testing.T) {
+	cases := []struct {
+		name       string
+		thresholds []string
+		passed     []bool
+	}{
+		{"all pass", []string{"max <= 100ms", "error_rate <= 10%", "total_requests == 10"}, []bool{true, true, true}},
+		{"error rate fails", []string{"max <= 100ms", "error_rate < 5%"}, []bool{true, false}},
+		{"latency fails", []string{"min > 50ms"}, []bool{false}},
+		{"missing metric fails", []string{"content_rate{assertion=missing} >= 99%"}, []bool{false}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			collector, _ := newTestCollector(t, result.CollectorConfig{Thresholds: tc.thresholds})
+			stats, err := collector.GeneratePerformanceStats(thresholdTestResults())
+			if err != nil {
+				t.Fatalf("failed to generate stats: %v", err)
+			}
+
+			results, passed := collector.EvaluateThresholds(stats)
+			if len(results) != len(tc.passed) {
+				t.Fatalf("expected %d results, got %d", len(tc.passed), len(results))
+			}
+			allPassed := true
+			for i, r := range results {
+				if r.Passed != tc.passed[i] {
+					t.Errorf("%s: passed=%v (%s), want %v", r.Expression, r.Passed, r.Message, tc.passed[i])
+				}
+				allPassed = allPassed && tc.passed[i]
+			}
+			if passed != allPassed {
+				t.Errorf("overall passed=%v, want %v", passed, allPassed)
+			}
+			if stats["ThresholdsPassed"] != allPassed {
+				t.Errorf("stats[ThresholdsPassed]=%v, want %v", stats["ThresholdsPassed"], allPassed)
+			}
+			if _, ok := stats["ThresholdResults"].([]result.ThresholdResult); !ok {
+				t.Error("threshold results should be stored in stats for the report")
+			}
+
+			wantCode := 0
+			if !allPassed {
+				wantCode = result.ExitCodeThresholdsFailed
+			}
+			if code := result.ThresholdExitCode(results); code != wantCode {
+				t.Errorf("exit code %d, want %d", code, wantCode)
+			}
+		})
+	}
+}
+
+func TestThresholdReportEscapesExpressions(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	stats, err := collector.GeneratePerformanceStats(thresholdTestResults())
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	stats["ThresholdResults"] = []result.ThresholdResult{{
+		Threshold: result.Threshold{Expression: "p95 < <script>alert(1)</script>"},
+		Message:   "<b>message</b>",
+	}}
+	stats["ContentAssertionStats"] = []result.ContentAssertionStat{{
+		URL:       "/search?q=<img src=x onerror=alert(1)>",
+		Assertion: "<i>non_empty</i>",
+		Total:     1,
+	}}
+	report := result.GenerateHTMLReport(stats, "threshold")
+	for _, raw := range []string{"<script>alert(1)", "<img src=x", "<b>message", "<i>non_empty"} {
+		if strings.Contains(report, raw) {
+			t.Errorf("report contains unescaped %q", raw)
+		}
+	}
+	if !strings.Contains(report, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Error("expected the threshold expression to be html-escaped")
+	}
+}
+
+func TestExportThresholdsAsJUnit(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "threshold", Thresholds: []string{"max <= 100ms", "error_rate < 5%"}})
+	stats, err := collector.GeneratePerformanceStats(thresholdTestResults())
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if _, err := collector.ExportStats(result.ExportFormatJUnit); err == nil {
+		t.Error("exporting JUnit XML before evaluating thresholds should fail")
+	}
+	collector.EvaluateThresholds(stats)
+
+	data, err := collector.ExportStats(result.ExportFormatJUnit)
+	if err != nil {
+		t.Fatalf("failed to export JUnit XML: %v", err)
+	}
+	var suites struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name      string `xml:"name,attr"`
+				ClassName string `xml:"classname,attr"`
+				Failure   *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, data)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 1 || suites.Suites[0].Name != "threshold" {
+		t.Fatalf("unexpected JUnit summary: %s", data)
+	}
+	cases := suites.Suites[0].Cases
+	if len(cases) != 2 || cases[0].Name != "max <= 100ms" || cases[0].Failure != nil || cases[0].ClassName != "openstress.thresholds" {
+		t.Errorf("unexpected passing test case: %+v", cases)
+	}
+	if cases[1].Name != "error_rate < 5%" || cases[1].Failure == nil || cases[1].Failure.Message != "error_rate = 10.000" {
+		t.Errorf("unexpected failing test case: %s", data)
+	}
+}
@@ -0,0 +1,137 @@
+// timeout_test.go
+// 任务超时测试模块
+// 本文件负责测试协程池按任务超时时间中止等待：超时任务记为 Timeout 状态并单独计数，
+// 以及超时结果在 JTL 文件中的读写、统计中的超时请求数和报告中的展示。
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestPoolEnforcesTaskTimeout(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	cancelled := make(chan error, 1)
+	err := taskPool.SubmitWithContext(func(tc *pool.TaskContext) {
+		<-tc.Context().Done()
+		cancelled <- tc.Context().Err()
+	}, 1, "slow", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	if err := taskPool.Submit(func(int32) {}, 1, "fast", time.Second); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("task context should carry the deadline, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task context was not cancelled after the timeout")
+	}
+	waitFor(t, func() bool {
+		status, err := taskPool.GetTaskStatus("slow")
+		return err == nil && status.Status == pool.TaskTimeout.String()
+	})
+	waitFor(t, func() bool { return taskPool.Stats().Completed == 1 })
+
+	stats := taskPool.Stats()
+	if stats.TimedOut != 1 || stats.Failed != 0 {
+		t.Errorf("expected 1 timed out and 0 failed tasks, got %d and %d", stats.TimedOut, stats.Failed)
+	}
+}
+
+func TestPoolTimeoutReleasesWorker(t *testing.T) {
+	taskPool := newTestPool(t, 1)
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	// 任务不响应 context，超时后 worker 仍然释放，后续任务可以执行
+	if err := taskPool.Submit(func(int32) { <-block }, 1, "stuck", 30*time.Millisecond); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	done := make(chan struct{})
+	if err := taskPool.Submit(func(int32) { close(done) }, 1, "next", 0); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a timed out task should release its worker")
+	}
+	if stats := taskPool.Stats(); stats.TimedOut != 1 {
+		t.Errorf("expected 1 timed out task, got %d", stats.TimedOut)
+	}
+
+	// panic 仍然记为失败
+	if err := taskPool.Submit(func(int32) { panic("boom") }, 1, "panics", time.Second); err != nil {
+		t.Fatalf("failed to submit task: %v", err)
+	}
+	waitFor(t, func() bool { return taskPool.Stats().Failed == 1 })
+}
+
+func TestFailureType(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if got := result.FailureType(fmt.Errorf("query: %w", ctx.Err())); got != result.Timeout {
+		t.Errorf("deadline exceeded should be a timeout, got %v", got)
+	}
+	if got := result.FailureType(errors.New("connection refused")); got != result.Failure {
+		t.Errorf("other errors should be failures, got %v", got)
+	}
+}
+
+func TestTimeoutResultsCountedSeparately(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	now := time.Now()
+	for i, typ := range []result.ResultType{result.Success, result.Success, result.Failure, result.Timeout, result.Timeout} {
+		begin := now.Add(time.Duration(i-5) * time.Second)
+		data := result.ResultData{ID: "r", Type: typ, Method: "GET", URL: "http://svc/slow", StartTime: begin, EndTime: begin.Add(10 * time.Millisecond)}
+		if typ == result.Timeout {
+			data.ErrorMessage = "context deadline exceeded"
+		}
+		if typ == result.Success {
+			collector.SaveSuccessResult(data)
+		} else {
+			collector.SaveFailureResult(data)
+		}
+	}
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	var timeouts int
+	for _, r := range results {
+		if r.Type == result.Timeout {
+			timeouts++
+		}
+	}
+	if timeouts != 2 {
+		t.Fatalf("timeouts should round-trip through the JTL file, got %d", timeouts)
+	}
+
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if stats["TimeoutCount"] != 2 || stats["FailureCount"] != 3 {
+		t.Errorf("expected 2 timeouts among 3 failures, got %v and %v", stats["TimeoutCount"], stats["FailureCount"])
+	}
+	if html := result.GenerateHTMLReport(stats); !strings.Contains(html, "<th>TimeoutCount</th><td>2</td>") {
+		t.Error("HTML report should show the timeout count")
+	}
+	if md := result.GenerateMarkdownReport(stats); !strings.Contains(md, "| 超时请求数 | 2 |") {
+		t.Errorf("markdown report should show the timeout count:\n%s", md)
+	}
+}
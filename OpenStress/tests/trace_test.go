@@ -0,0 +1,192 @@
+// trace_test.go
+// 任务上下文测试模块
+// 本文件负责测试 TaskContext 的传递、追踪ID写入 JTL 和报告明细，以及任务与 HTTP 请求的链路追踪。
+
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+	"github.com/potatoImp/OpenStress/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSubmitWithContextPropagatesTraceID(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+
+	got := make(chan *pool.TaskContext, 1)
+	if err := taskPool.SubmitWithContext(func(tc *pool.TaskContext) { got <- tc }, 0, "traced", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+
+	var tc *pool.TaskContext
+	select {
+	case tc = <-got:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task did not run")
+	}
+	if tc.TraceID == "" || tc.TaskID != "traced" || tc.VUID != -1 {
+		t.Fatalf("unexpected task context: %+v", tc)
+	}
+	if !strings.Contains(tc.LogPrefix(), "trace="+tc.TraceID) {
+		t.Fatalf("log prefix %q does not contain trace ID", tc.LogPrefix())
+	}
+	info, err := taskPool.GetTaskStatus("traced")
+	if err != nil || info.TraceID != tc.TraceID {
+		t.Fatalf("task status trace ID: got %+v (%v), want %s", info, err, tc.TraceID)
+	}
+}
+
+func TestVUIterationsGetDistinctTraceIDs(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	_, err := taskPool.RunVUs(pool.VUConfig{VUs: 2, Duration: 200 * time.Millisecond}, func(vu *pool.VUContext) {
+		mu.Lock()
+		if seen[vu.TraceID] {
+			t.Errorf("vu %d iteration %d reused trace ID %s", vu.VUID, vu.Iteration, vu.TraceID)
+		}
+		seen[vu.TraceID] = true
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to run vus: %v", err)
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected several iterations, got %d", len(seen))
+	}
+}
+
+func TestTraceIDStampedIntoJTLAndReport(t *testing.T) {
+	logger, err := pool.InitializeLogger(t.TempDir()+"/", "trace_test.log", "TraceTest")
+	if err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	collector, err := result.NewCollector(result.CollectorConfig{
+		BatchSize:   10,
+		JTLFilePath: filepath.Join(t.TempDir(), "trace.jtl"),
+		Logger:      logger,
+		TaskID:      "trace_test",
+	})
+	if err != nil {
+		t.Fatalf("failed to create collector: %v", err)
+	}
+	defer collector.Close()
+
+	start := time.Now()
+	failed := result.ResultData{
+		Type: result.Failure, StartTime: start, EndTime: start.Add(10 * time.Millisecond),
+		StatusCode: 500, URL: "/checkout", Method: "POST", ErrorMessage: "internal error",
+		TraceID: "abc123", VUID: 3, Iteration: 7,
+	}
+	if err := collector.SaveFailureResult(failed); err != nil {
+		t.Fatalf("failed to save result: %v", err)
+	}
+
+	loaded, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].TraceID != "abc123" || loaded[0].VUID != 3 || loaded[0].Iteration != 7 {
+		t.Fatalf("trace fields not round-tripped: %+v", loaded)
+	}
+
+	stats, err := collector.GeneratePerformanceStats(loaded)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	report := result.GenerateHTMLReport(stats)
+	if !strings.Contains(report, "失败请求明细") || !strings.Contains(report, "abc123") {
+		t.Fatal("report does not contain failed sample drill-down with trace ID")
+	}
+}
+
+// keepSpansExporter 关闭时保留已导出的 span（InMemoryExporter 关闭时会清空）
+type keepSpansExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+// Shutdown 不清空已导出的 span
+func (keepSpansExporter) Shutdown(context.Context) error { return nil }
+
+func TestTaskAndHTTPSpansShareTraceID(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.InitWithExporter(keepSpansExporter{exporter}, tracing.Config{ServiceName: "trace_test"})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	traceparent := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	taskPool := newTestPool(t, 1)
+	client := tracing.NewClient(nil)
+	done := make(chan *pool.TaskContext, 1)
+	err := taskPool.SubmitWithContext(func(tc *pool.TaskContext) {
+		req, _ := http.NewRequestWithContext(tc.Context(), http.MethodGet, server.URL, nil)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+		done <- tc
+	}, 0, "traced-http", 0)
+	if err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+
+	var tc *pool.TaskContext
+	select {
+	case tc = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task did not run")
+	}
+	if header := <-traceparent; !strings.Contains(header, tc.TraceID) {
+		t.Fatalf("traceparent %q does not carry trace ID %s", header, tc.TraceID)
+	}
+
+	// 任务结束后刷新导出器
+	waitFor(t, func() bool {
+		info, err := taskPool.GetTaskStatus("traced-http")
+		return err == nil && info.Status == pool.TaskCompleted.String()
+	})
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shutdown tracing: %v", err)
+	}
+
+	var taskSpan, httpSpan bool
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID().String() != tc.TraceID {
+			t.Errorf("span %s has trace ID %s, want %s", span.Name, span.SpanContext.TraceID(), tc.TraceID)
+		}
+		switch span.Name {
+		case "task traced-http":
+			taskSpan = true
+		case "HTTP GET":
+			httpSpan = true
+			var ttfb bool
+			for _, attr := range span.Attributes {
+				ttfb = ttfb || attr.Key == "http.ttfb_ms"
+			}
+			if !ttfb {
+				t.Error("HTTP span has no TTFB attribute")
+			}
+		}
+	}
+	if !taskSpan || !httpSpan {
+		t.Fatalf("expected task and HTTP spans, got %v", exporter.GetSpans().Snapshots())
+	}
+}
@@ -0,0 +1,106 @@
+// transaction_test.go
+// 事务统计测试模块
+// 本文件负责测试事务的计时与成败判定、事务样本在 JTL 中的读写，
+// 以及事务统计与总体请求统计分开汇总并出现在报告中。
+
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// transactionRequest 构造事务中的一个请求样本
+func transactionRequest(url string, ok bool, elapsed time.Duration) result.ResultData {
+	r := result.ResultData{Type: result.Success, StatusCode: 200, Method: "POST", URL: url, StartTime: time.Now(), DataSent: 10, DataReceived: 100}
+	if !ok {
+		r.Type, r.StatusCode, r.ErrorMessage = result.Failure, 500, "payment declined"
+	}
+	r.EndTime = r.StartTime.Add(elapsed)
+	r.ResponseTime = elapsed
+	return r
+}
+
+func TestTransactionGroupsRequests(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+
+	for i := 0; i < 3; i++ {
+		err := collector.RunTransaction("checkout", func(tx *result.Transaction) error {
+			tx.Add(transactionRequest("https://example.com/orders", true, 10*time.Millisecond))
+			time.Sleep(30 * time.Millisecond) // 思考时间计入事务耗时
+			return tx.Add(transactionRequest("https://example.com/pay", i != 2, 10*time.Millisecond))
+		})
+		if err != nil {
+			t.Fatalf("unexpected transaction error: %v", err)
+		}
+	}
+	extractErr := errors.New("extract orderId failed")
+	if err := collector.RunTransaction("browse", func(tx *result.Transaction) error {
+		tx.Add(transactionRequest("https://example.com/items", true, 5*time.Millisecond))
+		return extractErr
+	}); err != extractErr {
+		t.Errorf("expected the function error to be returned, got %v", err)
+	}
+
+	tx := collector.BeginTransaction("browse")
+	tx.Add(transactionRequest("https://example.com/items", true, 5*time.Millisecond))
+	first := tx.End()
+	if again := tx.End(); again.EndTime != first.EndTime {
+		t.Error("ending a transaction twice should not record it again")
+	}
+	if first.Type != result.Success || first.Transaction != "browse" || first.DataReceived != 100 {
+		t.Errorf("unexpected transaction sample: %+v", first)
+	}
+
+	results, err := collector.LoadResultsFromFile()
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	var loaded []result.ResultData
+	for _, r := range results {
+		if r.Transaction != "" {
+			loaded = append(loaded, r)
+		}
+	}
+	if len(results) != 13 || len(loaded) != 5 {
+		t.Fatalf("expected 8 requests and 5 transactions in the JTL file, got %d results and %d transactions", len(results), len(loaded))
+	}
+	if loaded[0].Transaction != "checkout" || loaded[0].Method != "" || loaded[0].ResponseTime < 30*time.Millisecond {
+		t.Errorf("transaction sample not kept in the JTL file: %+v", loaded[0])
+	}
+
+	stats, err := collector.GeneratePerformanceStats(results)
+	if err != nil {
+		t.Fatalf("failed to generate stats: %v", err)
+	}
+	if total := stats["TotalRequests"].(int); total != 8 {
+		t.Errorf("transactions should not count as requests, got %d requests", total)
+	}
+	transactionStats, ok := stats["TransactionStats"].([]result.TransactionStat)
+	if !ok || len(transactionStats) != 2 {
+		t.Fatalf("expected stats for 2 transactions, got %v", stats["TransactionStats"])
+	}
+	browse, checkout := transactionStats[0], transactionStats[1]
+	if checkout.Name != "checkout" || checkout.Count != 3 || checkout.FailureCount != 1 || checkout.SuccessRate != 66.667 {
+		t.Errorf("unexpected checkout stats: %+v", checkout)
+	}
+	if checkout.MinResponseTime < 30*time.Millisecond || checkout.P95ResponseTime < checkout.AvgResponseTime {
+		t.Errorf("checkout should be timed end to end: %+v", checkout)
+	}
+	if browse.Name != "browse" || browse.Count != 2 || browse.FailureCount != 1 {
+		t.Errorf("unexpected browse stats: %+v", browse)
+	}
+
+	for name, report := range map[string]string{
+		"html":     result.GenerateHTMLReport(stats),
+		"markdown": result.GenerateMarkdownReport(stats),
+	} {
+		if !strings.Contains(report, "事务统计") || !strings.Contains(report, "checkout") {
+			t.Errorf("%s report should include the transaction stats", name)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+// transport_test.go
+// HTTP 传输层测试模块
+// 本文件负责测试共享客户端的连接复用、HTTP/2 开关、TLS 证书校验与自定义 CA、代理配置（HTTP、SOCKS5 与代理认证），
+// 以及带宽模拟、绑定多个源 IP 和双向 TLS 的客户端证书。
+
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/tasks"
+)
+
+// getStatus 发送 GET 请求并读完响应体，使连接可以复用
+func getStatus(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+func TestDefaultHTTPClientReusesConnections(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := (&tasks.Task{ID: "reuse"}).HTTPClient()
+	if client != tasks.DefaultHTTPClient() {
+		t.Fatal("tasks without a client should share the default client")
+	}
+
+	// 顺序请求只使用一个连接
+	for i := 0; i < 20; i++ {
+		getStatus(t, client, server.URL)
+	}
+	if got := connections.Load(); got != 1 {
+		t.Errorf("expected sequential requests to reuse 1 connection, got %d", got)
+	}
+
+	// 并发请求的连接数不超过并发数（http.DefaultTransport 每个主机只保留 2 个空闲连接，会不断新建连接）
+	const workers = 10
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					t.Errorf("request failed: %v", err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := connections.Load(); got > workers+1 {
+		t.Errorf("expected at most %d connections for %d concurrent workers, got %d", workers+1, workers, got)
+	}
+}
+
+func TestTransportTLSAndHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	// 默认校验证书，自签名证书被拒绝
+	transport, err := tasks.NewTransport(tasks.TransportConfig{})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err == nil {
+		t.Fatal("expected a certificate error for a self-signed server")
+	}
+
+	// 信任自定义 CA 后默认协商 HTTP/2
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("failed to write ca file: %v", err)
+	}
+	transport, err = tasks.NewTransport(tasks.TransportConfig{CAFiles: []string{caFile}})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if resp := getStatus(t, &http.Client{Transport: transport}, server.URL); resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", resp.Proto)
+	}
+
+	// 跳过证书校验并关闭 HTTP/2
+	transport, err = tasks.NewTransport(tasks.TransportConfig{InsecureSkipVerify: true, DisableHTTP2: true})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if resp := getStatus(t, &http.Client{Transport: transport}, server.URL); resp.ProtoMajor != 1 {
+		t.Errorf("expected HTTP/1.1 with HTTP/2 disabled, got %s", resp.Proto)
+	}
+
+	if _, err := tasks.NewTransport(tasks.TransportConfig{CAFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}}); err == nil {
+		t.Error("expected an error for a missing ca file")
+	}
+	emptyCA := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(emptyCA, []byte("not a certificate"), 0644)
+	if _, err := tasks.NewTransport(tasks.TransportConfig{CAFiles: []string{emptyCA}}); err == nil {
+		t.Error("expected an error for a ca file without certificates")
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var proxied, authorization atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 经过代理的请求行是完整的 URL
+		proxied.Store(r.URL.String())
+		authorization.Store(r.Header.Get("Proxy-Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer proxy.Close()
+
+	transport, err := tasks.NewTransport(tasks.TransportConfig{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	resp := getStatus(t, &http.Client{Transport: transport}, "http://target.invalid/path")
+	if resp.StatusCode != http.StatusAccepted || proxied.Load() != "http://target.invalid/path" {
+		t.Errorf("request did not go through the proxy: status %d, proxied %v", resp.StatusCode, proxied.Load())
+	}
+
+	if transport, err := tasks.NewTransport(tasks.TransportConfig{Proxy: tasks.ProxyDirect}); err != nil || transport.Proxy != nil {
+		t.Errorf("direct proxy should disable proxying: %v", err)
+	}
+	if _, err := tasks.NewTransport(tasks.TransportConfig{Proxy: "not a url"}); err == nil {
+		t.Error("expected an error for an invalid proxy url")
+	}
+	if _, err := tasks.NewTransport(tasks.TransportConfig{Proxy: "ftp://proxy.internal:21"}); err == nil {
+		t.Error("expected an error for an unsupported proxy scheme")
+	}
+	if _, err := tasks.NewTransport(tasks.TransportConfig{ProxyUsername: "user"}); err == nil {
+		t.Error("proxy credentials without a proxy url should be rejected")
+	}
+
+	// 代理认证，密码从环境变量读取
+	t.Setenv("OPENSTRESS_TEST_PROXY_PASSWORD", "s3cr3t")
+	transport, err = tasks.NewTransport(tasks.TransportConfig{Proxy: proxy.URL, ProxyUsername: "loadtest", ProxyPassword: "${OPENSTRESS_TEST_PROXY_PASSWORD}"})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	getStatus(t, &http.Client{Transport: transport}, "http://target.invalid/auth")
+	if got := authorization.Load(); got != "Basic "+base64.StdEncoding.EncodeToString([]byte("loadtest:s3cr3t")) {
+		t.Errorf("unexpected proxy authorization %v", got)
+	}
+}
+
+// serveSOCKS5 运行只支持用户名密码认证和 CONNECT 命令的 SOCKS5 代理，把认证的用户名写入 users
+func serveSOCKS5(t *testing.T, users chan<- string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 262)
+				// 协商认证方法：只接受用户名密码认证（0x02）
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{5, 2})
+				io.ReadFull(conn, buf[:2])
+				user := make([]byte, buf[1])
+				io.ReadFull(conn, user)
+				io.ReadFull(conn, buf[:1])
+				io.ReadFull(conn, buf[:buf[0]])
+				users <- string(user)
+				conn.Write([]byte{1, 0})
+				// CONNECT 请求，目标地址为 IPv4 或域名
+				io.ReadFull(conn, buf[:4])
+				var host string
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					name := make([]byte, buf[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				io.ReadFull(conn, buf[:2])
+				target, err := net.Dial("tcp", net.JoinHostPort(host, fmt.Sprint(int(buf[0])<<8|int(buf[1]))))
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestTransportSOCKS5Proxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	users := make(chan string, 1)
+	proxyAddr := serveSOCKS5(t, users)
+	transport, err := tasks.NewTransport(tasks.TransportConfig{Proxy: "socks5://" + proxyAddr, ProxyUsername: "vu-eu", ProxyPassword: "pass"})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if resp := getStatus(t, &http.Client{Transport: transport}, server.URL); resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status %d through the socks5 proxy", resp.StatusCode)
+	}
+	select {
+	case user := <-users:
+		if user != "vu-eu" {
+			t.Errorf("unexpected socks5 user %q", user)
+		}
+	default:
+		t.Error("request did not go through the socks5 proxy")
+	}
+}
+
+func TestTransportBandwidthThrottling(t *testing.T) {
+	payload := strings.Repeat("x", 20000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	transport, err := tasks.NewTransport(tasks.TransportConfig{Bandwidth: tasks.BandwidthConfig{DownloadBytesPerSecond: 100000}})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	defer transport.CloseIdleConnections()
+	start := time.Now()
+	getStatus(t, &http.Client{Transport: transport}, server.URL)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("20000 bytes at 100000 B/s should take about 200ms, took %v", elapsed)
+	}
+
+	transport, err = tasks.NewTransport(tasks.TransportConfig{Bandwidth: tasks.BandwidthConfig{UploadBytesPerSecond: 50000}})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	defer transport.CloseIdleConnections()
+	start = time.Now()
+	resp, err := (&http.Client{Transport: transport}).Post(server.URL, "text/plain", strings.NewReader(payload[:10000]))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("10000 bytes at 50000 B/s should take about 200ms, took %v", elapsed)
+	}
+
+	if _, err := tasks.NewTransport(tasks.TransportConfig{Bandwidth: tasks.BandwidthConfig{UploadBytesPerSecond: -1}}); err == nil {
+		t.Error("negative bandwidth should be rejected")
+	}
+}
+
+func TestTransportSourceIPs(t *testing.T) {
+	var mu sync.Mutex
+	var sources []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		mu.Lock()
+		sources = append(sources, host)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	transport, err := tasks.NewTransport(tasks.TransportConfig{SourceIPs: []string{"127.0.0.1", "127.0.0.2"}, DisableKeepAlives: true})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	client := &http.Client{Transport: transport}
+	for _, vu := range []int{0, 1, 2, 3} {
+		req, _ := http.NewRequestWithContext(tasks.WithVU(context.Background(), vu), http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	for i := 0; i < 2; i++ {
+		getStatus(t, client, server.URL)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(sources, ","); got != "127.0.0.1,127.0.0.2,127.0.0.1,127.0.0.2,127.0.0.1,127.0.0.2" {
+		t.Errorf("connections should be bound to the source IPs per VU, then round-robin, got %s", got)
+	}
+
+	if _, err := tasks.NewTransport(tasks.TransportConfig{SourceIPs: []string{"10.0.0"}}); err == nil {
+		t.Error("invalid source ip should be rejected")
+	}
+}
+
+// writeClientCert 生成通用名为 name 的自签名客户端证书，返回证书和私钥文件
+func writeClientCert(t *testing.T, name string) tasks.ClientCertConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	dir := t.TempDir()
+	cfg := tasks.ClientCertConfig{CertFile: filepath.Join(dir, name+".pem"), KeyFile: filepath.Join(dir, name+"-key.pem")}
+	os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return cfg
+}
+
+func TestTransportClientCertificates(t *testing.T) {
+	var mu sync.Mutex
+	var clients []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		clients = append(clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+		mu.Unlock()
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// 服务端要求客户端证书，没有配置时握手失败
+	transport, err := tasks.NewTransport(tasks.TransportConfig{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err == nil {
+		t.Fatal("expected a handshake error without a client certificate")
+	}
+
+	// 多个证书按 VU 选择
+	transport, err = tasks.NewTransport(tasks.TransportConfig{
+		InsecureSkipVerify: true,
+		DisableKeepAlives:  true,
+		ClientCerts:        []tasks.ClientCertConfig{writeClientCert(t, "vu-a"), writeClientCert(t, "vu-b")},
+	})
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	client := &http.Client{Transport: transport}
+	for _, vu := range []int{0, 1, 2} {
+		req, _ := http.NewRequestWithContext(tasks.WithVU(context.Background(), vu), http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	mu.Lock()
+	if got := strings.Join(clients, ","); got != "vu-a,vu-b,vu-a" {
+		t.Errorf("client certificates should be selected per VU, got %s", got)
+	}
+	mu.Unlock()
+
+	missing := tasks.ClientCertConfig{CertFile: filepath.Join(t.TempDir(), "missing.pem"), KeyFile: filepath.Join(t.TempDir(), "missing-key.pem")}
+	if _, err := tasks.NewTransport(tasks.TransportConfig{ClientCerts: []tasks.ClientCertConfig{missing}}); err == nil {
+		t.Error("expected an error for a missing client certificate")
+	}
+}
@@ -0,0 +1,267 @@
+// trend_test.go
+// 历史运行趋势测试模块
+// 本文件负责测试历史运行趋势：按场景记录和读取运行指标、与滚动基线比较判定回归、
+// 运行结束时记录趋势并写入报告，以及通过 API 查询场景列表和单个场景的趋势。
+
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/api"
+	"github.com/potatoImp/OpenStress/config"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+// trendTestRun 创建一次测试用的运行记录
+func trendTestRun(scenario string, at time.Time, p95, tps, errorRate float64) result.TrendRun {
+	return result.TrendRun{
+		Scenario: scenario,
+		Time:     at,
+		Metrics:  map[string]float64{"avg": p95 / 2, "p95": p95, "tps": tps, "error_rate": errorRate, "total_requests": 1000},
+	}
+}
+
+// trendTestStats 创建包含趋势指标的统计结果
+func trendTestStats(p95 time.Duration, tps float64, failures int) map[string]interface{} {
+	return map[string]interface{}{
+		"AvgResponseTime": p95 / 2,
+		"P95ResponseTime": p95,
+		"TPS":             tps,
+		"TotalRequests":   1000,
+		"FailureCount":    failures,
+	}
+}
+
+// findTrendDelta 返回指定指标的对比
+func findTrendDelta(t *testing.T, deltas []result.TrendDelta, metric string) result.TrendDelta {
+	t.Helper()
+	for _, d := range deltas {
+		if d.Metric == metric {
+			return d
+		}
+	}
+	t.Fatalf("no comparison for metric %s in %+v", metric, deltas)
+	return result.TrendDelta{}
+}
+
+func TestTrendStoreRecordsRuns(t *testing.T) {
+	if result.NewTrendStore(config.HistoryConfig{}) != nil {
+		t.Error("trend store should be disabled without a directory")
+	}
+	dir := t.TempDir()
+	store := result.NewTrendStore(config.HistoryConfig{Dir: dir})
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 4; i++ {
+		if err := store.Record(trendTestRun("checkout", start.Add(time.Duration(i)*time.Minute), 100, 50, 0)); err != nil {
+			t.Fatalf("failed to record run: %v", err)
+		}
+	}
+	// 场景名中的路径分隔符不会写到目录之外；替换后文件名相同的场景互不影响
+	for _, scenario := range []string{"nightly/search", "nightly_search"} {
+		if err := store.Record(trendTestRun(scenario, start.Add(10*time.Minute), 80, 60, 0)); err != nil {
+			t.Fatalf("failed to record run: %v", err)
+		}
+	}
+	if err := store.Record(result.TrendRun{}); err == nil {
+		t.Error("a run without a scenario name should be rejected")
+	}
+
+	// 写了一半的行被跳过
+	file, err := os.OpenFile(filepath.Join(dir, "checkout.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open trend file: %v", err)
+	}
+	file.WriteString(`{"scenario":"checkout","metr`)
+	file.Close()
+
+	runs, err := store.Runs("checkout", 0)
+	if err != nil || len(runs) != 4 {
+		t.Fatalf("expected 4 runs, got %d (%v)", len(runs), err)
+	}
+	latest, err := store.Runs("checkout", 2)
+	if err != nil || len(latest) != 2 || !latest[1].Time.Equal(runs[3].Time) {
+		t.Errorf("limit should return the most recent runs in order, got %+v", latest)
+	}
+	if runs, _ := store.Runs("nightly/search", 0); len(runs) != 1 || runs[0].Scenario != "nightly/search" {
+		t.Errorf("scenarios sharing a file name should be kept apart, got %+v", runs)
+	}
+	if runs, err := store.Runs("unknown", 0); err != nil || len(runs) != 0 {
+		t.Errorf("unknown scenario should have no runs, got %d (%v)", len(runs), err)
+	}
+
+	scenarios, err := store.Scenarios()
+	if err != nil {
+		t.Fatalf("failed to list scenarios: %v", err)
+	}
+	if len(scenarios) != 2 || scenarios[1].Scenario != "checkout" || scenarios[1].Runs != 4 {
+		t.Errorf("unexpected scenarios %+v", scenarios)
+	}
+}
+
+func TestTrendStoreComparesWithRollingBaseline(t *testing.T) {
+	store := result.NewTrendStore(config.HistoryConfig{Dir: t.TempDir(), Baseline: 3})
+	start := time.Now()
+	history := []result.TrendRun{
+		trendTestRun("s", start, 1000, 10, 5), // 超出基线窗口，不参与比较
+		trendTestRun("s", start, 90, 50, 0.2),
+		trendTestRun("s", start, 100, 50, 0.4),
+		trendTestRun("s", start, 110, 50, 0.6),
+	}
+
+	if deltas := store.Compare(nil, history[1]); deltas != nil {
+		t.Errorf("first run should not be compared, got %+v", deltas)
+	}
+
+	// p95 上升 20% 超过默认的 10%，TPS 下降 5% 未超过，错误率上升 2 个百分点超过默认的 1 个百分点
+	deltas := store.Compare(history, trendTestRun("s", start, 120, 47.5, 2.4))
+	p95 := findTrendDelta(t, deltas, "p95")
+	if p95.Baseline != 100 || p95.Change != 20 || !p95.Regressed {
+		t.Errorf("p95 should regress against the rolling baseline, got %+v", p95)
+	}
+	if tps := findTrendDelta(t, deltas, "tps"); tps.Regressed || tps.Change != -5 {
+		t.Errorf("a 5%% tps drop should be tolerated, got %+v", tps)
+	}
+	errorRate := findTrendDelta(t, deltas, "error_rate")
+	if !errorRate.Regressed || errorRate.ChangeText() != "+2.00pp" {
+		t.Errorf("error rate should regress by percentage points, got %+v (%s)", errorRate, errorRate.ChangeText())
+	}
+	for _, d := range deltas {
+		if d.Metric == "total_requests" {
+			t.Error("total requests should not be compared")
+		}
+	}
+
+	// TPS 下降超过容忍度时判定为回归，响应时间下降不是回归
+	deltas = store.Compare(history, trendTestRun("s", start, 50, 40, 0.4))
+	if tps := findTrendDelta(t, deltas, "tps"); !tps.Regressed {
+		t.Errorf("a 20%% tps drop should regress, got %+v", tps)
+	}
+	if p95 := findTrendDelta(t, deltas, "p95"); p95.Regressed {
+		t.Errorf("faster responses should not regress, got %+v", p95)
+	}
+}
+
+func TestRecordTrendReportsRegression(t *testing.T) {
+	historyDir := t.TempDir()
+	collector, _ := newTestCollector(t, result.CollectorConfig{TaskID: "report", History: config.HistoryConfig{Dir: historyDir, Baseline: 3, Tolerance: 15}})
+
+	for i := 0; i < 3; i++ {
+		stats := trendTestStats(100*time.Millisecond, 200, 0)
+		if _, err := collector.RecordTrend(stats, "checkout"); err != nil {
+			t.Fatalf("failed to record trend: %v", err)
+		}
+		if i == 0 && len(stats["TrendComparison"].([]result.TrendDelta)) != 0 {
+			t.Error("first run should have no comparison")
+		}
+	}
+
+	stats := trendTestStats(130*time.Millisecond, 195, 0)
+	deltas, err := collector.RecordTrend(stats, "checkout")
+	if err != nil {
+		t.Fatalf("failed to record trend: %v", err)
+	}
+	if p95 := findTrendDelta(t, deltas, "p95"); !p95.Regressed || p95.Limit != 15 {
+		t.Errorf("p95 30%% above the baseline should regress, got %+v", p95)
+	}
+	if runs, ok := stats["TrendBaselineRuns"].(int); !ok || runs != 3 {
+		t.Errorf("expected a baseline of 3 runs, got %v", stats["TrendBaselineRuns"])
+	}
+
+	runs, err := collector.TrendStore().Runs("checkout", 0)
+	if err != nil || len(runs) != 4 {
+		t.Fatalf("expected 4 recorded runs, got %d (%v)", len(runs), err)
+	}
+	if got := strings.Join(runs[3].Regressed, ","); got != "avg,p95" {
+		t.Errorf("latest run should record the regressed metrics, got %q", got)
+	}
+	if runs[3].TaskID != "report" || runs[3].Metrics["p95"] != 130 {
+		t.Errorf("unexpected recorded run %+v", runs[3])
+	}
+
+	markdown := result.GenerateMarkdownReport(stats)
+	if !strings.Contains(markdown, "**2 项指标相对基线回归** (与最近 3 次运行的平均值比较)") || !strings.Contains(markdown, "| ❌ | p95 | 100.00 | 130.00 | +30.00% |") {
+		t.Errorf("markdown report should include the trend comparison:\n%s", markdown)
+	}
+	if html := result.GenerateHTMLReport(stats); !strings.Contains(html, "<h2>历史趋势对比</h2>") || !strings.Contains(html, "<td class='error'>回归</td>") {
+		t.Error("html report should include the trend comparison")
+	}
+}
+
+func TestRecordTrendDisabled(t *testing.T) {
+	collector, _ := newTestCollector(t, result.CollectorConfig{})
+	stats := trendTestStats(100*time.Millisecond, 200, 0)
+	if deltas, err := collector.RecordTrend(stats, "checkout"); deltas != nil || err != nil {
+		t.Errorf("recording should be skipped without a history directory, got %v, %v", deltas, err)
+	}
+	if _, ok := stats["TrendComparison"]; ok {
+		t.Error("stats should not contain a trend comparison")
+	}
+}
+
+func TestTrendAPI(t *testing.T) {
+	taskPool := pool.NewPool(2)
+	if taskPool == nil {
+		t.Fatal("failed to create pool")
+	}
+	t.Cleanup(taskPool.Shutdown)
+
+	// 未配置趋势库
+	server := api.NewAPIServer(taskPool, nil, config.NewConfig())
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/trends", nil, nil); code != http.StatusNotFound {
+		t.Errorf("expected 404 without trend history, got %d", code)
+	}
+
+	// 收集器未配置时使用配置文件中的趋势目录
+	cfg := config.NewConfig()
+	cfg.Report.History = config.HistoryConfig{Dir: t.TempDir(), Baseline: 2}
+	store := result.NewTrendStore(cfg.Report.History)
+	start := time.Now().Add(-time.Hour)
+	for i, p95 := range []float64{100, 100, 100, 150} {
+		if err := store.Record(trendTestRun("checkout", start.Add(time.Duration(i)*time.Minute), p95, 50, 0)); err != nil {
+			t.Fatalf("failed to record run: %v", err)
+		}
+	}
+	if err := store.Record(trendTestRun("search", start, 80, 60, 0)); err != nil {
+		t.Fatalf("failed to record run: %v", err)
+	}
+	server = api.NewAPIServer(taskPool, nil, cfg)
+
+	var scenarios api.TrendScenariosResponse
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/trends", nil, &scenarios); code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if len(scenarios.Scenarios) != 2 || scenarios.Scenarios[0].Scenario != "checkout" || scenarios.Scenarios[0].Runs != 4 {
+		t.Errorf("unexpected scenarios %+v", scenarios.Scenarios)
+	}
+
+	// limit 只限制返回的运行数，回归对比仍基于全部记录
+	var trend api.TrendResponse
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/trends/checkout?limit=1", nil, &trend); code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if len(trend.Runs) != 1 || trend.Runs[0].Metrics["p95"] != 150 || trend.BaselineRuns != 2 {
+		t.Errorf("unexpected trend %+v", trend)
+	}
+	if p95 := findTrendDelta(t, trend.Comparison, "p95"); !p95.Regressed || p95.Baseline != 100 {
+		t.Errorf("latest run should regress against the baseline, got %+v", p95)
+	}
+
+	var single api.TrendResponse
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/trends/search", nil, &single); code != http.StatusOK || len(single.Comparison) != 0 {
+		t.Errorf("a single run should have no comparison, got %d %+v", code, single.Comparison)
+	}
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/trends/unknown", nil, nil); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown scenario, got %d", code)
+	}
+	if code := doRequest(t, server.Handler(), http.MethodGet, "/api/trends/checkout?limit=0", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid limit, got %d", code)
+	}
+}
@@ -0,0 +1,208 @@
+// users_test.go
+// 用户与权限管理测试模块
+// 本文件负责测试运行时创建用户、更新权限、轮换 API 密钥、删除用户、版本冲突，
+// 以及写回 YAML 配置文件时保留注释、密码字段和文件权限。
+
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/potatoImp/OpenStress/auth"
+)
+
+// usersTestConfig 带注释和密码字段的认证配置
+const usersTestConfig = `# 认证配置文件
+users:
+  - username: admin
+    password: s3cret # 管理员密码
+    api_key: admin_key
+    permissions: [submit, manage, monitor]
+  - username: viewer
+    api_key: viewer_key
+    permissions: [monitor]
+`
+
+// newUsersTestManager 使用临时配置文件创建认证管理器，返回配置文件路径
+func newUsersTestManager(t *testing.T, mode os.FileMode) (*auth.AuthManager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	if err := os.WriteFile(path, []byte(usersTestConfig), mode); err != nil {
+		t.Fatalf("failed to write auth config: %v", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatalf("failed to chmod auth config: %v", err)
+	}
+	authManager, err := auth.NewAuthManager(path, nil)
+	if err != nil {
+		t.Fatalf("failed to create auth manager: %v", err)
+	}
+	t.Cleanup(func() { authManager.Close() })
+	return authManager, path
+}
+
+// assertPermission 检查 API 密钥是否有效并拥有指定权限
+func assertPermission(t *testing.T, am *auth.AuthManager, apiKey string, perm auth.Permission, want bool) {
+	t.Helper()
+	user, err := am.ValidateAPIKey(apiKey)
+	got := err == nil && user != nil && am.HasPermission(user, perm)
+	if got != want {
+		t.Errorf("key %s permission %s: got %v, want %v (err: %v)", apiKey, perm, got, want, err)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	am, _ := newUsersTestManager(t, 0644)
+
+	created, err := am.CreateUser("admin", auth.UserAuth{Username: "ci", Permissions: []auth.Permission{auth.PermissionSubmit}})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if !strings.HasPrefix(created.APIKey, "osk_") || created.Version != 1 {
+		t.Fatalf("unexpected created user: %+v", created)
+	}
+	assertPermission(t, am, created.APIKey, auth.PermissionSubmit, true)
+	assertPermission(t, am, created.APIKey, auth.PermissionManage, false)
+
+	if _, err := am.CreateUser("admin", auth.UserAuth{Username: "ci"}); !errors.Is(err, auth.ErrUserExists) {
+		t.Errorf("expected ErrUserExists, got %v", err)
+	}
+	if _, err := am.CreateUser("admin", auth.UserAuth{Username: "dup", APIKey: "viewer_key"}); err == nil {
+		t.Error("expected error for an api key already in use")
+	}
+	if _, err := am.CreateUser("admin", auth.UserAuth{Username: "bad", Permissions: []auth.Permission{"root"}}); err == nil {
+		t.Error("expected error for an unknown permission")
+	}
+	if _, err := am.CreateUser("admin", auth.UserAuth{}); err == nil {
+		t.Error("expected error for an empty username")
+	}
+}
+
+func TestUpdateUserPermissions(t *testing.T) {
+	am, _ := newUsersTestManager(t, 0644)
+	assertPermission(t, am, "viewer_key", auth.PermissionSubmit, false)
+
+	updated, err := am.UpdateUserPermissions("admin", "viewer", []auth.Permission{auth.PermissionMonitor, auth.PermissionSubmit}, 1)
+	if err != nil {
+		t.Fatalf("failed to update permissions: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("expected version 2, got %d", updated.Version)
+	}
+	// 权限变更立即生效，不受本地缓存影响
+	assertPermission(t, am, "viewer_key", auth.PermissionSubmit, true)
+
+	if _, err := am.UpdateUserPermissions("admin", "viewer", []auth.Permission{"root"}, 2); err == nil {
+		t.Error("expected error for an unknown permission")
+	}
+	if _, err := am.UpdateUserPermissions("admin", "nobody", nil, 1); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUserVersionConflict(t *testing.T) {
+	am, _ := newUsersTestManager(t, 0644)
+
+	if _, err := am.UpdateUserPermissions("admin", "viewer", []auth.Permission{auth.PermissionSubmit}, 1); err != nil {
+		t.Fatalf("failed to update permissions: %v", err)
+	}
+	// 基于旧版本的修改都会被拒绝，且不会改变用户
+	if _, err := am.UpdateUserPermissions("admin", "viewer", []auth.Permission{auth.PermissionManage}, 1); !errors.Is(err, auth.ErrVersionConflict) {
+		t.Errorf("update: expected ErrVersionConflict, got %v", err)
+	}
+	if _, err := am.RotateAPIKey("admin", "viewer", 1); !errors.Is(err, auth.ErrVersionConflict) {
+		t.Errorf("rotate: expected ErrVersionConflict, got %v", err)
+	}
+	if err := am.DeleteUser("admin", "viewer", 1); !errors.Is(err, auth.ErrVersionConflict) {
+		t.Errorf("delete: expected ErrVersionConflict, got %v", err)
+	}
+	user, err := am.GetUser("viewer")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if user.Version != 2 || user.APIKey != "viewer_key" || len(user.Permissions) != 1 || user.Permissions[0] != auth.PermissionSubmit {
+		t.Errorf("user changed by a conflicting update: %+v", user)
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	am, _ := newUsersTestManager(t, 0644)
+	assertPermission(t, am, "viewer_key", auth.PermissionMonitor, true)
+
+	rotated, err := am.RotateAPIKey("admin", "viewer", 1)
+	if err != nil {
+		t.Fatalf("failed to rotate api key: %v", err)
+	}
+	if rotated.APIKey == "viewer_key" || rotated.Version != 2 {
+		t.Fatalf("unexpected rotated user: %+v", rotated)
+	}
+	// 旧密钥立即失效，新密钥沿用原有权限
+	assertPermission(t, am, "viewer_key", auth.PermissionMonitor, false)
+	assertPermission(t, am, rotated.APIKey, auth.PermissionMonitor, true)
+}
+
+func TestDeleteUser(t *testing.T) {
+	am, _ := newUsersTestManager(t, 0644)
+	assertPermission(t, am, "viewer_key", auth.PermissionMonitor, true)
+
+	if err := am.DeleteUser("admin", "viewer", 1); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if _, err := am.GetUser("viewer"); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	assertPermission(t, am, "viewer_key", auth.PermissionMonitor, false)
+	if err := am.DeleteUser("admin", "viewer", 1); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound for a second delete, got %v", err)
+	}
+}
+
+func TestUserChangesPersistToYAML(t *testing.T) {
+	am, path := newUsersTestManager(t, 0640)
+
+	created, err := am.CreateUser("admin", auth.UserAuth{Username: "ci", Permissions: []auth.Permission{auth.PermissionSubmit}})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if _, err := am.UpdateUserPermissions("admin", "viewer", []auth.Permission{auth.PermissionMonitor, auth.PermissionSubmit}, 1); err != nil {
+		t.Fatalf("failed to update permissions: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat config: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("config file mode changed to %v", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"# 认证配置文件", "password: s3cret # 管理员密码", "permissions: [submit, manage, monitor]"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("config lost %q:\n%s", want, content)
+		}
+	}
+
+	// 重新加载后用户与权限保持一致
+	reloaded, err := auth.NewAuthManager(path, nil)
+	if err != nil {
+		t.Fatalf("failed to reload auth manager: %v", err)
+	}
+	defer reloaded.Close()
+	admin, err := reloaded.GetUser("admin")
+	if err != nil || admin.Password != "s3cret" {
+		t.Errorf("admin password not kept after reload: %+v (%v)", admin, err)
+	}
+	assertPermission(t, reloaded, "viewer_key", auth.PermissionSubmit, true)
+	assertPermission(t, reloaded, created.APIKey, auth.PermissionSubmit, true)
+	if viewer, err := reloaded.GetUser("viewer"); err != nil || viewer.Version != 2 {
+		t.Errorf("viewer version not persisted: %+v (%v)", viewer, err)
+	}
+}
@@ -0,0 +1,496 @@
+// vu_test.go
+// 虚拟用户测试模块
+// 本文件负责测试启动屏障、虚拟用户执行（worker 预留、测量开始时间与停止条件、限流等待可被停止和关闭中断、
+// 活跃 VU 数与负载采样），
+// 以及分布式模式下多个 worker 的同步启动和停止。
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/potatoImp/OpenStress/api"
+	"github.com/potatoImp/OpenStress/pool"
+	"github.com/potatoImp/OpenStress/result"
+)
+
+func TestStartBarrierReleasesWhenAllArrive(t *testing.T) {
+	barrier := pool.NewStartBarrier(3, 0, time.Time{})
+
+	released := make(chan time.Time, 3)
+	for i := 0; i < 2; i++ {
+		go func() {
+			barrier.Arrive()
+			start, _ := barrier.Wait(context.Background())
+			released <- start
+		}()
+	}
+	select {
+	case <-released:
+		t.Fatal("barrier released before all parties arrived")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if !barrier.MeasureStart().IsZero() {
+		t.Fatal("measure start should be zero before the barrier is released")
+	}
+
+	barrier.Arrive()
+	measureStart, err := barrier.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if !measureStart.Equal(measureStart.Truncate(time.Second)) {
+		t.Errorf("measure start %v is not aligned to a whole second", measureStart)
+	}
+	if time.Now().Before(measureStart) {
+		t.Errorf("wait returned before the measure start %v", measureStart)
+	}
+	for i := 0; i < 2; i++ {
+		if start := <-released; !start.Equal(measureStart) {
+			t.Errorf("parties got different measure starts: %v vs %v", start, measureStart)
+		}
+	}
+}
+
+func TestStartBarrierUsesStartAtAndWarmUp(t *testing.T) {
+	warmUp := 200 * time.Millisecond
+	startAt := time.Now().Add(500 * time.Millisecond)
+	barrier := pool.NewStartBarrier(1, warmUp, startAt)
+	barrier.Arrive()
+
+	measureStart, err := barrier.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if !measureStart.Equal(startAt) {
+		t.Errorf("measure start %v, want %v", measureStart, startAt)
+	}
+	// 放行时刻为 StartAt - WarmUp，保证各 worker 的预热时长一致
+	if now := time.Now(); now.Before(startAt.Add(-warmUp)) || !now.Before(startAt) {
+		t.Errorf("released at %v, want within [%v, %v)", now, startAt.Add(-warmUp), startAt)
+	}
+}
+
+func TestStartBarrierWaitHonoursContext(t *testing.T) {
+	barrier := pool.NewStartBarrier(2, 0, time.Time{})
+	barrier.Arrive()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := barrier.Wait(ctx); err == nil {
+		t.Fatal("expected wait to fail when the context is cancelled")
+	}
+
+	delayed := pool.NewStartBarrier(1, 0, time.Now().Add(time.Hour))
+	delayed.Arrive()
+	if _, err := delayed.Wait(ctx); err == nil {
+		t.Fatal("expected wait for a future start to fail when the context is cancelled")
+	}
+}
+
+func TestSyncStartTimeAlignsToSecond(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 300*int(time.Millisecond), time.UTC)
+	got := pool.SyncStartTime(now, time.Second, 2*time.Second)
+	if want := time.Date(2024, 1, 1, 10, 0, 4, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	aligned := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	if got := pool.SyncStartTime(aligned, time.Second, 0); !got.Equal(aligned.Add(time.Second)) {
+		t.Errorf("already aligned time should not be rounded up, got %v", got)
+	}
+}
+
+func TestRunVUsRunsEveryVUAfterMeasureStart(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+
+	var mu sync.Mutex
+	iterations := map[int]int{}
+	var warmUpIterations int32
+	measureStart, err := taskPool.RunVUs(pool.VUConfig{VUs: 3, Duration: 200 * time.Millisecond, WarmUp: 100 * time.Millisecond}, func(vu *pool.VUContext) {
+		if !vu.Measuring() {
+			atomic.AddInt32(&warmUpIterations, 1)
+		}
+		mu.Lock()
+		iterations[vu.VUID]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to run vus: %v", err)
+	}
+	if time.Now().Before(measureStart.Add(200 * time.Millisecond)) {
+		t.Error("RunVUs returned before the measurement phase ended")
+	}
+	for id := 0; id < 3; id++ {
+		if iterations[id] == 0 {
+			t.Errorf("vu %d did not run", id)
+		}
+	}
+	if atomic.LoadInt32(&warmUpIterations) == 0 {
+		t.Error("expected some iterations during warm-up")
+	}
+}
+
+func TestRunVUsValidation(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+	noop := func(*pool.VUContext) {}
+
+	for _, cfg := range []pool.VUConfig{
+		{VUs: 0, Duration: time.Second},
+		{VUs: 1, Duration: 0},
+		{VUs: 1, Duration: time.Second, MaxErrors: -1},
+		{VUs: 3, Duration: time.Second},
+		{VUs: 1, Duration: time.Second, StartAt: time.Now().Add(-time.Second)},
+	} {
+		if _, err := taskPool.RunVUs(cfg, noop); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestVUHooksLifecycle(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+	iterations := map[int]int{}
+	hooks := pool.VUHooks{
+		OnTestStart: func() (interface{}, error) {
+			record("test start")
+			return "shared-token", nil
+		},
+		OnVUStart: func(vu *pool.VUContext) error {
+			if vu.VUID == 2 {
+				return fmt.Errorf("login failed")
+			}
+			vu.State = fmt.Sprintf("client-%d", vu.VUID)
+			return nil
+		},
+		OnVUStop: func(vu *pool.VUContext) {
+			record(fmt.Sprintf("stop %v", vu.State))
+			if vu.VUID == 1 {
+				panic("close failed")
+			}
+		},
+		OnTestEnd: func(data interface{}) {
+			record(fmt.Sprintf("test end %v", data))
+		},
+	}
+	_, err := taskPool.RunVUs(pool.VUConfig{VUs: 3, Duration: 100 * time.Millisecond, Hooks: hooks}, func(vu *pool.VUContext) {
+		if vu.SetupData != "shared-token" || vu.State != fmt.Sprintf("client-%d", vu.VUID) {
+			t.Errorf("vu %d has unexpected data: %v, %v", vu.VUID, vu.SetupData, vu.State)
+		}
+		mu.Lock()
+		iterations[vu.VUID]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to run vus: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if iterations[0] == 0 || iterations[1] == 0 || iterations[2] != 0 {
+		t.Errorf("only vus whose OnVUStart succeeded should iterate, got %v", iterations)
+	}
+	// OnTestStart 最先执行，OnTestEnd 在所有 OnVUStop（包括 panic 的）之后执行，失败的 VU 不执行 OnVUStop
+	if len(events) != 4 || events[0] != "test start" || events[3] != "test end shared-token" {
+		t.Fatalf("unexpected hook order: %v", events)
+	}
+	if stops := events[1] + "," + events[2]; stops != "stop client-0,stop client-1" && stops != "stop client-1,stop client-0" {
+		t.Errorf("unexpected OnVUStop calls: %v", events)
+	}
+}
+
+func TestVUHooksSetupFailureAndRegisteredHooks(t *testing.T) {
+	taskPool := newTestPool(t, 2)
+
+	var ran int32
+	_, err := taskPool.RunVUs(pool.VUConfig{VUs: 1, Duration: time.Second, Hooks: pool.VUHooks{
+		OnTestStart: func() (interface{}, error) { return nil, fmt.Errorf("no test data") },
+	}}, func(*pool.VUContext) { atomic.AddInt32(&ran, 1) })
+	if err == nil || !strings.Contains(err.Error(), "OnTestStart failed: no test data") {
+		t.Errorf("expected the setup error, got %v", err)
+	}
+	if atomic.LoadInt32(&ran) != 0 {
+		t.Error("no vu should run when OnTestStart fails")
+	}
+
+	// 已注册任务通过名称启动时使用 RegisterVUHooks 设置的钩子
+	var started, ended int32
+	taskPool.RegisterTask("browse", func(int32) { time.Sleep(10 * time.Millisecond) })
+	taskPool.RegisterVUHooks("browse", pool.VUHooks{
+		OnVUStart: func(*pool.VUContext) error { atomic.AddInt32(&started, 1); return nil },
+		OnTestEnd: func(interface{}) { atomic.AddInt32(&ended, 1) },
+	})
+	run, err := taskPool.StartRegisteredVUs("browse", pool.VUConfig{VUs: 2, Duration: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	run.Wait()
+	if atomic.LoadInt32(&started) != 2 || atomic.LoadInt32(&ended) != 1 {
+		t.Errorf("expected 2 OnVUStart calls and 1 OnTestEnd call, got %d and %d", started, ended)
+	}
+}
+
+// TestRunVUsReservesWorkers 验证 VU 预留 worker：并发启动时总 VU 数不会超过 worker 数，排队任务也不会占用 VU 的 worker
+func TestRunVUsReservesWorkers(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+
+	var started, failed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run, err := taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: 300 * time.Millisecond}, func(*pool.VUContext) {
+				time.Sleep(10 * time.Millisecond)
+			})
+			if err != nil {
+				atomic.AddInt32(&failed, 1)
+				return
+			}
+			atomic.AddInt32(&started, 1)
+			run.Wait()
+		}()
+	}
+	wg.Wait()
+	if started != 2 || failed != 2 {
+		t.Fatalf("expected 2 runs to start and 2 to be rejected, got %d started and %d rejected", started, failed)
+	}
+
+	// VU 运行期间 worker 全部被预留，提交的任务要等 VU 结束后才执行
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 4, Duration: 200 * time.Millisecond}, func(*pool.VUContext) {
+		time.Sleep(10 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	var taskRan int32
+	if err := taskPool.Submit(func(int32) { atomic.StoreInt32(&taskRan, 1) }, 0, "after-vus", 0); err != nil {
+		t.Fatalf("failed to submit: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&taskRan) == 1 {
+		t.Fatal("queued task ran on a worker reserved for vus")
+	}
+	run.Wait()
+	waitFor(t, func() bool { return atomic.LoadInt32(&taskRan) == 1 })
+}
+
+func TestStartCoordinatorSynchronizesWorkers(t *testing.T) {
+	type worker struct {
+		url   string
+		first atomic.Int64 // 第一次迭代的时间（UnixNano）
+	}
+	workers := make([]*worker, 2)
+	for i := range workers {
+		w := &worker{}
+		server, taskPool, _ := newTestAPIServer(t)
+		taskPool.RegisterTask("probe", func(int32) {
+			w.first.CompareAndSwap(0, time.Now().UnixNano())
+			time.Sleep(10 * time.Millisecond)
+		})
+		httpServer := httptest.NewServer(server.Handler())
+		t.Cleanup(httpServer.Close)
+		w.url = httpServer.URL
+		workers[i] = w
+	}
+
+	coordinator := &api.StartCoordinator{Workers: []string{workers[0].url, workers[1].url}, Lead: 300 * time.Millisecond}
+	warmUp := 100 * time.Millisecond
+	startAt, err := coordinator.Start(context.Background(), api.VUStartRequest{TaskName: "probe", VUs: 2, DurationMs: 200, WarmUpMs: warmUp.Milliseconds()})
+	if err != nil {
+		t.Fatalf("failed to start workers: %v", err)
+	}
+	if !startAt.Equal(startAt.Truncate(time.Second)) {
+		t.Errorf("start time %v is not aligned to a whole second", startAt)
+	}
+
+	for i, w := range workers {
+		waitFor(t, func() bool { return w.first.Load() != 0 })
+		first := time.Unix(0, w.first.Load())
+		// 每个 worker 都在 StartAt - WarmUp 之后才开始执行
+		if first.Before(startAt.Add(-warmUp)) {
+			t.Errorf("worker %d started at %v, before the synchronized warm-up start %v", i, first, startAt.Add(-warmUp))
+		}
+	}
+
+	// 未注册的任务和无法连接的 worker 都会汇总为错误
+	if _, err := coordinator.Start(context.Background(), api.VUStartRequest{TaskName: "missing", VUs: 1, DurationMs: 100}); err == nil {
+		t.Error("expected error for an unknown task")
+	}
+	unreachable := &api.StartCoordinator{Workers: []string{"http://127.0.0.1:1"}, Lead: 100 * time.Millisecond}
+	if _, err := unreachable.Start(context.Background(), api.VUStartRequest{TaskName: "probe", VUs: 1, DurationMs: 100}); err == nil {
+		t.Error("expected error for an unreachable worker")
+	}
+}
+
+func TestVUStopConditions(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+
+	// 只按总迭代次数停止
+	var count atomic.Int32
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 3, Iterations: 10}, func(*pool.VUContext) { count.Add(1) })
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	run.Wait()
+	if count.Load() != 10 || run.Iterations() != 10 || run.StopReason() != pool.StopIterations {
+		t.Errorf("expected 10 iterations stopped by iterations, got %d (%s)", count.Load(), run.StopReason())
+	}
+
+	// 每个 VU 完成各自的迭代次数
+	var mu sync.Mutex
+	perVU := map[int]int{}
+	run, _ = taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: time.Minute, IterationsPerVU: 4}, func(vu *pool.VUContext) {
+		mu.Lock()
+		perVU[vu.VUID]++
+		mu.Unlock()
+	})
+	run.Wait()
+	if perVU[0] != 4 || perVU[1] != 4 || run.StopReason() != pool.StopIterationsPerVU {
+		t.Errorf("expected 4 iterations per vu, got %v (%s)", perVU, run.StopReason())
+	}
+
+	// 出错的迭代（Fail 或 panic）达到最大错误数时先于时长停止
+	run, _ = taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: time.Minute, MaxErrors: 5}, func(vu *pool.VUContext) {
+		if vu.Iteration%2 == 0 {
+			panic("boom")
+		}
+		vu.Fail(fmt.Errorf("unexpected status"))
+	})
+	run.Wait()
+	if run.Errors() < 5 || run.StopReason() != pool.StopMaxErrors {
+		t.Errorf("expected to stop on max errors, got %d errors (%s)", run.Errors(), run.StopReason())
+	}
+
+	// 时长先到
+	run, _ = taskPool.StartVUs(pool.VUConfig{VUs: 1, Duration: 100 * time.Millisecond, Iterations: 1000000}, func(*pool.VUContext) { time.Sleep(5 * time.Millisecond) })
+	run.Wait()
+	if run.StopReason() != pool.StopDuration {
+		t.Errorf("expected to stop on duration, got %s", run.StopReason())
+	}
+
+	// 外部停止
+	run, _ = taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: time.Minute}, func(*pool.VUContext) { time.Sleep(5 * time.Millisecond) })
+	time.Sleep(50 * time.Millisecond)
+	run.Stop()
+	run.Wait()
+	if run.StopReason() != pool.StopExternal {
+		t.Errorf("expected an external stop, got %s", run.StopReason())
+	}
+}
+
+func TestRateLimitedVUsStopPromptly(t *testing.T) {
+	// 每 10 秒一个令牌：第一次迭代后 VU 都在等待令牌，停止和关闭必须中断等待
+	for _, stop := range []struct {
+		name   string
+		reason pool.StopReason
+		stop   func(taskPool *pool.Pool, run *pool.VURun)
+	}{
+		{"stop", pool.StopExternal, func(_ *pool.Pool, run *pool.VURun) { run.Stop() }},
+		{"shutdown", pool.StopShutdown, func(taskPool *pool.Pool, _ *pool.VURun) { taskPool.Shutdown() }},
+	} {
+		taskPool := newTestPool(t, 4)
+		taskPool.SetRateLimit(0.1, 1)
+		var count atomic.Int32
+		run, err := taskPool.StartVUs(pool.VUConfig{VUs: 2, Duration: time.Minute}, func(*pool.VUContext) { count.Add(1) })
+		if err != nil {
+			t.Fatalf("%s: failed to start vus: %v", stop.name, err)
+		}
+		waitFor(t, func() bool { return count.Load() == 1 })
+		time.Sleep(20 * time.Millisecond)
+
+		start := time.Now()
+		stop.stop(taskPool, run)
+		run.Wait()
+		if waited := time.Since(start); waited > time.Second {
+			t.Errorf("%s: rate limited vus took %v to stop", stop.name, waited)
+		}
+		if count.Load() != 1 || run.Iterations() != 1 || run.StopReason() != stop.reason {
+			t.Errorf("%s: expected 1 iteration stopped by %s, got %d executed, %d counted (%s)", stop.name, stop.reason, count.Load(), run.Iterations(), run.StopReason())
+		}
+	}
+}
+
+func TestActiveVUsAndLoadSamples(t *testing.T) {
+	taskPool := newTestPool(t, 4)
+	release := make(chan struct{})
+	run, err := taskPool.StartVUs(pool.VUConfig{VUs: 3, Duration: time.Minute}, func(*pool.VUContext) { <-release })
+	if err != nil {
+		t.Fatalf("failed to start vus: %v", err)
+	}
+	waitFor(t, func() bool { return taskPool.ActiveVUs() == 3 })
+
+	// 与 main.go 相同，每次采样同时读取活跃 VU 数和队列深度
+	collector, _ := newTestCollector(t, result.CollectorConfig{SelfContainedReport: true})
+	start := time.Now()
+	collector.RecordLoadSample(result.LoadSample{Timestamp: start, ActiveVUs: taskPool.ActiveVUs(), QueueDepth: taskPool.QueueDepth()})
+	run.Stop()
+	close(release)
+	run.Wait()
+	if taskPool.ActiveVUs() != 0 {
+		t.Errorf("expected no active vus after the run, got %d", taskPool.ActiveVUs())
+	}
+	collector.RecordLoadSample(result.LoadSample{Timestamp: start.Add(time.Second), ActiveVUs: taskPool.ActiveVUs(), QueueDepth: 7})
+
+	stats := lockTestStats(t, collector)
+	samples, _ := stats["LoadSamples"].([]result.LoadSample)
+	if len(samples) != 2 || samples[0].ActiveVUs != 3 || samples[1].ActiveVUs != 0 || samples[1].QueueDepth != 7 {
+		t.Fatalf("unexpected load samples: %+v", samples)
+	}
+	content := result.GenerateSelfContainedHTMLReport(stats, "load")
+	if !strings.Contains(content, "活跃虚拟用户") || !strings.Contains(content, "队列深度") {
+		t.Error("the VUs + queue chart should be embedded when load samples exist")
+	}
+}
+
+func TestStopVUsViaAPIRecordsReason(t *testing.T) {
+	server, taskPool, collector := newTestAPIServer(t)
+	taskPool.RegisterTask("probe", func(int32) {
+		start := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		collector.SaveSuccessResult(result.ResultData{ID: "probe", StartTime: start, EndTime: time.Now(), ResponseTime: 5 * time.Millisecond})
+	})
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+
+	coordinator := &api.StartCoordinator{Workers: []string{httpServer.URL}, Lead: 100 * time.Millisecond}
+	if err := coordinator.Stop(context.Background(), ""); err != nil {
+		t.Errorf("stopping without running vus should not fail: %v", err)
+	}
+	startAt, err := coordinator.Start(context.Background(), api.VUStartRequest{TaskName: "probe", VUs: 2, DurationMs: 60000})
+	if err != nil {
+		t.Fatalf("failed to start workers: %v", err)
+	}
+	time.Sleep(time.Until(startAt) + 50*time.Millisecond)
+	if err := coordinator.Stop(context.Background(), "probe"); err != nil {
+		t.Fatalf("failed to stop workers: %v", err)
+	}
+
+	var stats map[string]interface{}
+	waitFor(t, func() bool {
+		stats, _ = collector.CurrentStats(collector.Results())
+		return stats["StopReason"] == "external"
+	})
+	if md := result.GenerateMarkdownReport(stats); !strings.Contains(md, "| 结束原因 | 外部停止 |") {
+		t.Errorf("markdown report should include the stop reason:\n%s", md)
+	}
+	if html := result.GenerateHTMLReport(stats); !strings.Contains(html, "<th>StopReason</th><td>外部停止</td>") {
+		t.Error("html report should include the stop reason")
+	}
+}
@@ -0,0 +1,126 @@
+// http.go
+// HTTP 链路追踪模块
+// 本文件负责为压测 HTTP 请求创建 span，并记录 DNS 解析、建立连接、TLS 握手和首字节时间（TTFB）。
+//
+// 技术实现细节：
+// 1. Transport 包装 http.RoundTripper，每个请求一个 span，响应状态码 >= 500 或请求出错时标记为错误。
+// 2. 使用 net/http/httptrace 记录各阶段耗时，写入 span 属性（毫秒）并记录为 span 事件。
+// 3. 通过全局传播器注入 traceparent 请求头。
+
+package tracing
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport 带链路追踪的 http.RoundTripper
+type Transport struct {
+	Base http.RoundTripper // 实际发送请求的 RoundTripper，为 nil 时使用 http.DefaultTransport
+}
+
+// NewClient 返回使用 Transport 的 HTTP 客户端，base 为 nil 时使用 http.DefaultClient 的配置
+func NewClient(base *http.Client) *http.Client {
+	client := &http.Client{}
+	if base != nil {
+		*client = *base
+	}
+	client.Transport = &Transport{Base: client.Transport}
+	return client
+}
+
+// RoundTrip 发送请求并记录 span
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := Tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+			attribute.String("server.address", req.URL.Hostname()),
+		))
+	defer span.End()
+
+	timing := &phaseTiming{span: span}
+	ctx = httptrace.WithClientTrace(ctx, timing.clientTrace())
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := base.RoundTrip(req)
+	timing.record()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// phaseTiming 记录请求各阶段的时间点
+type phaseTiming struct {
+	span trace.Span
+
+	mu                     sync.Mutex
+	start                  time.Time
+	dnsStart, dnsDone      time.Time
+	connectStart, connDone time.Time
+	tlsStart, tlsDone      time.Time
+	firstByte              time.Time
+}
+
+// clientTrace 返回记录各阶段时间点的 httptrace.ClientTrace
+func (p *phaseTiming) clientTrace() *httptrace.ClientTrace {
+	p.start = time.Now()
+	mark := func(field *time.Time, event string) {
+		p.mu.Lock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+		p.mu.Unlock()
+		p.span.AddEvent(event)
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&p.dnsStart, "dns.start") },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&p.dnsDone, "dns.done") },
+		ConnectStart:         func(string, string) { mark(&p.connectStart, "connect.start") },
+		ConnectDone:          func(string, string, error) { mark(&p.connDone, "connect.done") },
+		TLSHandshakeStart:    func() { mark(&p.tlsStart, "tls.start") },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&p.tlsDone, "tls.done") },
+		GotFirstResponseByte: func() { mark(&p.firstByte, "first_byte") },
+	}
+}
+
+// record 将各阶段耗时写入 span 属性（毫秒），复用连接时没有 DNS 和连接阶段
+func (p *phaseTiming) record() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	phase := func(key string, from, to time.Time) {
+		if !from.IsZero() && !to.IsZero() {
+			p.span.SetAttributes(attribute.Float64(key, float64(to.Sub(from).Microseconds())/1000))
+		}
+	}
+	phase("http.dns_ms", p.dnsStart, p.dnsDone)
+	phase("http.connect_ms", p.connectStart, p.connDone)
+	phase("http.tls_ms", p.tlsStart, p.tlsDone)
+	phase("http.ttfb_ms", p.start, p.firstByte)
+}
@@ -0,0 +1,153 @@
+// tracing.go
+// 链路追踪模块
+// 本文件负责 OpenTelemetry 的初始化与 span 创建，压测产生的链路可以通过 OTLP 导出到 Jaeger/Tempo，
+// 与被测服务端的链路放在一起查看。
+//
+// 技术实现细节：
+// 1. 使用 OTLP/HTTP 导出器和批量处理器，未启用时使用 OpenTelemetry 的空实现，不产生开销。
+// 2. 自定义 ID 生成器：context 中带有预设追踪ID（pool.TaskContext.TraceID）时以它作为 span 的追踪ID，
+//    因此日志、JTL 结果和 Jaeger 中看到的是同一个追踪ID。
+// 3. 使用 W3C Trace Context 传播器，HTTP 请求会携带 traceparent 请求头，服务端链路可以接到压测端 span 之下。
+
+package tracing
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 压测端 span 的 instrumentation 名称
+const instrumentationName = "OpenStress"
+
+// Config 链路追踪配置
+type Config struct {
+	Endpoint    string  // OTLP/HTTP 接收地址，例如 "localhost:4318"，带 http:// 前缀时视为 Insecure
+	Insecure    bool    // 使用 HTTP 而不是 HTTPS
+	ServiceName string  // 服务名，默认 "openstress"
+	SampleRatio float64 // 采样比例（0~1），小于等于 0 时全部采样
+}
+
+// Init 初始化全局 TracerProvider，返回用于刷新并关闭导出器的函数
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("tracing endpoint is required")
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "openstress"
+	}
+	if strings.HasPrefix(cfg.Endpoint, "http://") {
+		cfg.Insecure = true
+	}
+	cfg.Endpoint = strings.TrimPrefix(strings.TrimPrefix(cfg.Endpoint, "http://"), "https://")
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+	return InitWithExporter(exporter, cfg), nil
+}
+
+// InitWithExporter 使用指定的导出器初始化全局 TracerProvider（例如测试中使用内存导出器），
+// 返回用于刷新并关闭导出器的函数
+func InitWithExporter(exporter sdktrace.SpanExporter, cfg Config) func(context.Context) error {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "openstress"
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithIDGenerator(newIDGenerator()),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown
+}
+
+// Tracer 返回压测端使用的 Tracer，未初始化时为空实现
+func Tracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(instrumentationName)
+}
+
+// StartSpan 创建 span，ctx 中带有预设追踪ID时使用该追踪ID
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// traceIDKey context 中预设追踪ID的键
+type traceIDKey struct{}
+
+// WithTraceID 在 context 中预设根 span 的追踪ID（32 位十六进制），格式无效时忽略
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	id, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// idGenerator 优先使用 context 中预设追踪ID的 ID 生成器
+type idGenerator struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newIDGenerator 创建 ID 生成器
+func newIDGenerator() *idGenerator {
+	var seed int64
+	_ = binary.Read(crand.Reader, binary.LittleEndian, &seed)
+	return &idGenerator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// NewIDs 生成根 span 的追踪ID和 span ID
+func (g *idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	traceID, ok := ctx.Value(traceIDKey{}).(trace.TraceID)
+	if !ok {
+		for !traceID.IsValid() {
+			_, _ = g.rng.Read(traceID[:])
+		}
+	}
+	return traceID, g.newSpanID()
+}
+
+// NewSpanID 生成子 span 的 span ID
+func (g *idGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.newSpanID()
+}
+
+// newSpanID 生成非零的 span ID，调用方需持有锁
+func (g *idGenerator) newSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	for !spanID.IsValid() {
+		_, _ = g.rng.Read(spanID[:])
+	}
+	return spanID
+}